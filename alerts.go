@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/output"
+)
+
+// alerts highlight chat and voice lines that mention one of the alert
+// keywords, such as the player's name or a callout
+type alerts struct {
+	patterns []*regexp.Regexp
+	sound    *output.Sound // nil when silent
+}
+
+// startAlerts puts the keyword alerts in front of every sink and returns a
+// function that removes them again
+func startAlerts(cfg config.AlertConfig) (stop func()) {
+	a := alerts{patterns: alertPatterns(cfg.Keywords)}
+	if len(a.patterns) == 0 {
+		return func() {}
+	}
+	if cfg.Sound != "" {
+		sound, err := output.NewSound(cfg.Sound)
+		if err != nil {
+			slog.Warn("Alerts play no sound", "err", err)
+		} else {
+			a.sound = sound
+		}
+	}
+	remove := bus.AddFilter(a.filter)
+	return func() {
+		remove()
+		if a.sound != nil {
+			a.sound.Close()
+		}
+	}
+}
+
+// alertPatterns compiles the keywords: words and phrases match as whole
+// words in any case, and /.../ is a regular expression
+func alertPatterns(keywords []string) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, k := range keywords {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		expr := `(?i)(?:^|[^\pL\pN_])` + regexp.QuoteMeta(k) + `(?:$|[^\pL\pN_])`
+		if len(k) > 2 && strings.HasPrefix(k, "/") && strings.HasSuffix(k, "/") {
+			expr = `(?i)` + k[1:len(k)-1]
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			slog.Warn("Alert keyword skipped", "keyword", k, "err", err)
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns
+}
+
+// filter highlights a chat or voice translation whose text or original
+// matches a keyword and plays the sound. Repeats of spam are left alone so
+// they cannot keep it ringing.
+func (a alerts) filter(e events.Event) (events.Event, bool) {
+	t, ok := e.(events.TranslationDone)
+	if !ok || t.Err != nil || t.Superseded || t.Via == viaRepeated || (t.Source != "chat" && t.Source != "voice") {
+		return e, true
+	}
+	if !a.match(t.Translated) && !a.match(t.Original) {
+		return e, true
+	}
+	t.Highlight = true
+	if a.sound != nil {
+		a.sound.Play()
+	}
+	return t, true
+}
+
+func (a alerts) match(text string) bool {
+	for _, re := range a.patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/server"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+const (
+	// defaultAPIAddr is used when neither -addr nor http_addr is set
+	defaultAPIAddr = "localhost:8787"
+	// apiTimeout bounds one translation or transcription request
+	apiTimeout = 2 * time.Minute
+	// maxAPIAudio bounds an uploaded recording
+	maxAPIAudio = 50 << 20
+)
+
+// runAPICommand serves translation and transcription to other programs on
+// the embedded web server, without watching CS2. Events, status, pause and
+// the target language work as in the other modes.
+func runAPICommand(args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Print(i18n.T("Warning: %v (using defaults)\n", err))
+	}
+	cfg.ApplyEnv()
+
+	fs := flag.NewFlagSet("api", flag.ExitOnError)
+	addr := fs.String("addr", cmp.Or(cfg.HTTPAddr, defaultAPIAddr), "Address to serve the API on")
+	fs.StringVar(&cfg.Lang, "lang", cfg.Lang, "Target language for translation")
+	fs.StringVar(&cfg.Model, "model", cfg.Model, "Ollama model to use for translation")
+	fs.BoolVar(&cfg.Voice, "voice", cfg.Voice, "Start the transcriber so audio can be submitted")
+	fs.Parse(args)
+	if err := cfg.NormalizeLanguages(); err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	translator.Configure(cfg.HTTPSettings())
+	translator.ConfigureContext(cfg.ContextSettings())
+
+	if err := ensureEnvironment(bufio.NewScanner(os.Stdin), cfg.Model, cfg.Voice); err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	chain, err := translator.NewChain(ctx, cfg.BackendSettings(), cfg.Lang)
+	if err != nil {
+		fmt.Print(i18n.T("Error creating translator: %v\n", err))
+		os.Exit(1)
+	}
+	fmt.Print(i18n.T("Using %s for translation to %s\n", chain, translator.LanguageName(cfg.Lang)))
+	tr := withLatencyBudget(ctx, chain, cfg)
+	defer tr.Close()
+	targetLang = cfg.Lang
+
+	listener := initAudioListener(cfg.Voice, cfg.WhisperSettings(true))
+	if listener != nil {
+		defer listener.Stop()
+	}
+	disp := pipeline.NewDispatcher(ctx, apiTranslator(tr), pipeline.Options{
+		Workers:   cfg.Workers,
+		ChunkSize: cfg.ChunkChars,
+		MaxSize:   cfg.MaxMessageChars,
+	})
+	defer disp.Close()
+
+	cfg.HTTPAddr = pickHTTPAddr(*addr)
+	srv := newWebServer(cfg, "api")
+	api := &apiServer{disp: disp, listener: listener, waiting: map[string]chan audio.Transcription{}}
+	api.register(srv)
+	if err := srv.Start(); err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	defer srv.Shutdown()
+	defer deck.close()
+	fmt.Print(i18n.T("API listening on http://%s (reference at /docs). Press Ctrl+C to exit.\n", srv.Addr()))
+
+	api.run(ctx, tr)
+}
+
+// apiServer hands API requests to the dispatcher and transcriber, whose
+// results the run loop passes back to the waiting handlers
+type apiServer struct {
+	disp     *pipeline.Dispatcher
+	listener *audio.Listener // nil without -voice
+
+	mu      sync.Mutex
+	next    int
+	waiting map[string]chan audio.Transcription // by probe tag
+}
+
+type apiTranslateRequest struct {
+	Text       string `json:"text"`
+	Kind       string `json:"kind,omitempty"`        // "chat" (default) or "voice"
+	SourceLang string `json:"source_lang,omitempty"` // ISO 639-1 code, detected when empty
+	Context    string `json:"context,omitempty"`     // recent speech, for voice
+	Private    bool   `json:"private,omitempty"`     // only use local backends
+}
+
+type apiTranslation struct {
+	Translated string `json:"translated"`
+	Language   string `json:"language"`
+	Truncated  int    `json:"truncated,omitempty"`
+	ElapsedMS  int64  `json:"elapsed_ms"`
+}
+
+type apiTranscript struct {
+	Text       string          `json:"text"`
+	Language   string          `json:"language"`
+	Confidence float64         `json:"confidence"`
+	DurationMS int64           `json:"duration_ms"`
+	ElapsedMS  int64           `json:"elapsed_ms"`
+	Result     *apiTranslation `json:"translation,omitempty"`
+}
+
+// apiCall is the payload of an API job
+type apiCall struct {
+	req  apiTranslateRequest
+	done chan pipeline.Result
+}
+
+func (a *apiServer) register(srv *server.Server) {
+	srv.Handle("POST", "/api/translate", "Translate {\"text\", \"kind\", \"source_lang\", \"context\", \"private\"} into the target language", a.handleTranslate)
+	srv.HandleUpload("/api/transcribe", "Transcribe the uploaded recording (WAV or anything FFmpeg reads); ?translate=1 also translates it", a.handleTranscribe)
+}
+
+func (a *apiServer) handleTranslate(w http.ResponseWriter, r *http.Request) {
+	var req apiTranslateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Text == "" {
+		apiError(w, http.StatusBadRequest, "expected {\"text\": \"...\"}")
+		return
+	}
+	if req.Kind != "" && req.Kind != string(translator.KindChat) && req.Kind != string(translator.KindVoice) {
+		apiError(w, http.StatusBadRequest, "kind must be \"chat\" or \"voice\"")
+		return
+	}
+	if deck.currentState().Paused {
+		apiError(w, http.StatusServiceUnavailable, "paused")
+		return
+	}
+	res, status := a.translate(r.Context(), req)
+	if status != http.StatusOK {
+		apiError(w, status, res.Err.Error())
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, translationResponse(res))
+}
+
+// translate runs req through the dispatcher and waits for it
+func (a *apiServer) translate(ctx context.Context, req apiTranslateRequest) (pipeline.Result, int) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+	call := &apiCall{req: req, done: make(chan pipeline.Result, 1)}
+	a.disp.Submit(pipeline.Job{Text: req.Text, Payload: call})
+	select {
+	case res := <-call.done:
+		if res.Err != nil {
+			return res, http.StatusBadGateway
+		}
+		return res, http.StatusOK
+	case <-ctx.Done():
+		return pipeline.Result{Err: fmt.Errorf("no translation within %s", apiTimeout)}, http.StatusGatewayTimeout
+	}
+}
+
+func translationResponse(res pipeline.Result) *apiTranslation {
+	return &apiTranslation{
+		Translated: res.Text,
+		Language:   targetLang,
+		Truncated:  res.Truncated,
+		ElapsedMS:  res.Latency.Milliseconds(),
+	}
+}
+
+func (a *apiServer) handleTranscribe(w http.ResponseWriter, r *http.Request) {
+	if a.listener == nil {
+		apiError(w, http.StatusServiceUnavailable, "transcription is off; start with -voice")
+		return
+	}
+	if deck.currentState().Paused {
+		apiError(w, http.StatusServiceUnavailable, "paused")
+		return
+	}
+
+	// The transcriber reads from its output directory, which Docker mounts
+	f, err := os.CreateTemp(a.listener.OutputDir(), "api_*.wav")
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	_, err = io.Copy(f, http.MaxBytesReader(w, r.Body, maxAPIAudio))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		apiError(w, http.StatusBadRequest, "failed to read the recording: "+err.Error())
+		return
+	}
+
+	a.mu.Lock()
+	a.next++
+	tag := fmt.Sprintf("api-%d", a.next)
+	result := make(chan audio.Transcription, 1)
+	a.waiting[tag] = result
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.waiting, tag)
+		a.mu.Unlock()
+	}()
+	a.listener.SubmitProbeAs(f.Name(), tag)
+
+	var t audio.Transcription
+	select {
+	case t = <-result:
+	case <-time.After(apiTimeout):
+		apiError(w, http.StatusGatewayTimeout, fmt.Sprintf("no transcription within %s", apiTimeout))
+		return
+	case <-r.Context().Done():
+		return
+	}
+	resp := apiTranscript{
+		Text:       t.Text,
+		Language:   t.Language,
+		Confidence: t.Confidence,
+		DurationMS: t.Duration.Milliseconds(),
+		ElapsedMS:  t.Elapsed.Milliseconds(),
+	}
+	if r.URL.Query().Get("translate") != "" && t.Text != "" {
+		res, status := a.translate(r.Context(), apiTranslateRequest{
+			Text: t.Text, Kind: string(translator.KindVoice), SourceLang: t.Language,
+		})
+		if status != http.StatusOK {
+			apiError(w, status, res.Err.Error())
+			return
+		}
+		resp.Result = translationResponse(res)
+	}
+	server.WriteJSON(w, http.StatusOK, resp)
+}
+
+// run passes results to the waiting handlers and handles control requests
+// until ctx ends
+func (a *apiServer) run(ctx context.Context, tr translator.Translator) {
+	var transcriptions <-chan audio.Transcription
+	var transcriberStatus <-chan audio.Status
+	if a.listener != nil {
+		transcriptions = a.listener.Transcriptions()
+		transcriberStatus = a.listener.Status()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println(i18n.T("\nStopping..."))
+			return
+
+		case res := <-a.disp.Results():
+			call, ok := res.Job.Payload.(*apiCall)
+			if !ok {
+				continue
+			}
+			bus.Publish(events.TranslationDone{
+				Source: "api", Original: res.Job.Text, Translated: res.Text,
+				Language: targetLang, Elapsed: res.Latency, Truncated: res.Truncated, Err: res.Err,
+			})
+			observeLatency(res.Latency)
+			call.done <- res
+
+		case t, ok := <-transcriptions:
+			if !ok {
+				slog.Error("Transcriber stopped; audio can no longer be submitted")
+				transcriptions = nil
+				continue
+			}
+			a.mu.Lock()
+			result, ok := a.waiting[t.Speaker]
+			a.mu.Unlock()
+			t.Speaker = ""
+			if t.Text != "" {
+				bus.Publish(transcriptEvent(t))
+			}
+			if ok {
+				result <- t
+			}
+
+		case status := <-transcriberStatus:
+			bus.Publish(transcriberEvent(status))
+
+		case act := <-deck.Actions():
+			switch act.Action {
+			case deckSetLang:
+				switchLang(tr, act.Lang)
+			case deckPause, deckResume, deckTogglePause:
+				publishPause(pauseRequested(act.Action, deck.currentState().Paused))
+			default:
+				deck.fail(act.Action + " is not available in api mode")
+			}
+		}
+	}
+}
+
+// apiTranslator translates API jobs as their request asks
+func apiTranslator(tr translator.Translator) pipeline.TranslateFunc {
+	return func(ctx context.Context, job pipeline.Job, text string) (string, error) {
+		call := job.Payload.(*apiCall)
+		req := translator.Request{
+			Text:       text,
+			Kind:       translator.Kind(cmp.Or(call.req.Kind, string(translator.KindChat))),
+			SourceLang: call.req.SourceLang,
+			Context:    translator.VoiceContext{ContextText: call.req.Context},
+			Private:    call.req.Private,
+		}
+		if req.SourceLang == "" {
+			req.SourceLang = translator.DetectLanguage(text)
+		}
+		return tr.TranslateWithContext(ctx, req)
+	}
+}
+
+func apiError(w http.ResponseWriter, status int, msg string) {
+	server.WriteJSON(w, status, map[string]string{"error": msg})
+}
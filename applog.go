@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/logging"
+)
+
+// setupLogging starts the logger cfg describes: the console at its level and
+// cs-translate.log in the settings directory. The returned function closes
+// the log file.
+func setupLogging(cfg config.LoggingConfig) func() {
+	level, levelErr := logging.ParseLevel(cfg.Level)
+	opts := logging.Options{Level: level, Format: cfg.Format}
+	if cfg.File {
+		if dir, err := config.Dir(); err == nil {
+			opts.File = filepath.Join(dir, logging.FileName)
+		}
+	}
+	closeLog, err := logging.Setup(opts)
+	if levelErr != nil {
+		slog.Warn("Using log level warn", "err", levelErr)
+	}
+	if err != nil {
+		slog.Warn("Logging set up partly", "err", err)
+	}
+	if opts.File != "" {
+		slog.Info("Logging to file", "path", opts.File, "level", level.String(), "format", cfg.Format)
+	}
+	return func() { closeLog() }
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// viaAsIs marks chat shown as it was written because it is only emoji,
+// emoticons or ASCII art
+const viaAsIs = "as is"
+
+// passArt reports whether c has nothing to translate and shows it as it is,
+// without an LLM round-trip
+func passArt(c events.ChatReceived) bool {
+	if !translator.IsEmojiOrArt(c.Text) {
+		return false
+	}
+	bus.Publish(events.TranslationDone{
+		MessageID: c.ID, Source: "chat", Player: c.Player, Team: c.Team, Dead: c.Dead, Line: c.Line,
+		Log: c.Log, Original: c.Text, Translated: c.Text, Language: chatLang(c), Via: viaAsIs,
+	})
+	return true
+}
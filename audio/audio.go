@@ -2,7 +2,29 @@
 // using FFmpeg for all platforms.
 package audio
 
+import (
+	"fmt"
+	"strconv"
+)
+
 // GetAvailableDevices returns a list of available audio devices
 func GetAvailableDevices() ([]string, error) {
 	return getPlatformDevices()
 }
+
+// ResolveDevice turns the number of a device, as -list-audio-devices shows
+// it from 1, into its name; other values are names already
+func ResolveDevice(device string) (string, error) {
+	n, err := strconv.Atoi(device)
+	if err != nil {
+		return device, nil
+	}
+	devices, err := GetAvailableDevices()
+	if err != nil {
+		return "", fmt.Errorf("could not list audio devices for device %d: %w", n, err)
+	}
+	if n < 1 || n > len(devices) {
+		return "", fmt.Errorf("%w: there is no device %d; -list-audio-devices shows 1 to %d", ErrNoMonitorSource, n, len(devices))
+	}
+	return devices[n-1], nil
+}
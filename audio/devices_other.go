@@ -4,14 +4,16 @@
 package audio
 
 import (
-	"os/exec"
+	"fmt"
 	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
 )
 
 // getPlatformDevices returns available audio devices on Linux/macOS
 func getPlatformDevices() ([]string, error) {
 	// Try PulseAudio first
-	out, err := exec.Command("pactl", "list", "sources", "short").Output()
+	out, err := execwrap.Command("pactl", "list", "sources", "short").Output()
 	if err == nil {
 		var devices []string
 		lines := strings.Split(string(out), "\n")
@@ -45,11 +47,13 @@ func GetDeviceHelpText() string {
 - On Linux: Uses PulseAudio/PipeWire monitor sources
 - Common format: <sink_name>.monitor (e.g., "alsa_output.pci-0000_00_1b.0.analog-stereo.monitor")
 - Run 'pactl list sources short' to see available sources
+- app:<program> (e.g. app:cs2) records only that program's audio
+- With PipeWire: pw:<node name> records any node
 `
 }
 
 func listFFmpegDevices() ([]string, error) {
-	cmd := exec.Command("ffmpeg", "-list_devices", "true", "-f", "pulse", "-i", "dummy")
+	cmd := execwrap.Command("ffmpeg", "-list_devices", "true", "-f", "pulse", "-i", "dummy")
 	out, _ := cmd.CombinedOutput()
 
 	var devices []string
@@ -66,3 +70,26 @@ func listFFmpegDevices() ([]string, error) {
 
 	return devices, nil
 }
+
+// CheckSource verifies that a PulseAudio/PipeWire source exists. If the
+// sources cannot be listed the check is skipped and ffmpeg reports instead.
+func CheckSource(source string) error {
+	if IsPipeWireDevice(source) {
+		_, err := FindPipeWireNode(source)
+		return err
+	}
+	if IsAppDevice(source) {
+		return checkApp(source)
+	}
+	out, err := execwrap.Command("pactl", "list", "sources", "short").Output()
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) >= 2 && parts[1] == source {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNoMonitorSource, source)
+}
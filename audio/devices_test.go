@@ -0,0 +1,64 @@
+package audio_test
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// Devices are listed from pactl and picked by number or by name
+func TestDevices(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("devices come from pactl on Linux and macOS only")
+	}
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"pactl list sources short": {Stdout: "0\talsa_input.usb-headset\tPipeWire\ts16le 2ch 48000Hz\tRUNNING\n" +
+			"1\talsa_output.pci.analog-stereo.monitor\tPipeWire\ts16le 2ch 48000Hz\tIDLE\n"},
+	}}
+	defer execwrap.Use(fake)()
+
+	devices, err := audio.GetAvailableDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 || devices[0] != "alsa_input.usb-headset" {
+		t.Fatalf("devices from pactl are %q", devices)
+	}
+	if d, err := audio.ResolveDevice("2"); err != nil || d != devices[1] {
+		t.Fatalf("-audiodevice 2 is %q (%v), want %s", d, err, devices[1])
+	}
+	if d, err := audio.ResolveDevice("3"); !errors.Is(err, audio.ErrNoMonitorSource) {
+		t.Fatalf("-audiodevice 3 of 2 devices is %q (%v)", d, err)
+	}
+	if d, _ := audio.ResolveDevice(devices[1]); d != devices[1] {
+		t.Fatalf("the name %s became %q", devices[1], d)
+	}
+}
+
+// The level meter reads the samples ffmpeg streams: here five tenths of a
+// second at 0x2020, -12 dBFS, and a partial one that is not metered
+func TestRecord(t *testing.T) {
+	clip := filepath.Join(t.TempDir(), "test.wav")
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"ffmpeg -f pulse -i headset -t 0.5 -c:a pcm_s16le -ar 16000 -ac 1 -y " + clip +
+			" -c:a pcm_s16le -ar 16000 -ac 1 -f s16le pipe:1": {Stdout: strings.Repeat(" ", 5*3200+100)},
+	}}
+	defer execwrap.Use(fake)()
+
+	in := audio.Input{Args: []string{"-f", "pulse", "-i", "headset"}}
+	var readings []float64
+	err := audio.Record(t.Context(), in, 500*time.Millisecond, clip, func(dB float64) { readings = append(readings, dB) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 5 || math.Round(readings[0]) != -12 {
+		t.Fatalf("the meter read %v, want five times -12 dB", readings)
+	}
+}
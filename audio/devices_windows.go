@@ -3,16 +3,25 @@
 
 package audio
 
-// getPlatformDevices returns the virtual-audio-capturer device on Windows
+import (
+	"fmt"
+	"strings"
+)
+
+// getPlatformDevices returns the DirectShow audio devices, falling back to
+// virtual-audio-capturer if ffmpeg cannot list them
 func getPlatformDevices() ([]string, error) {
+	if devices, _ := DShowAudioDevices(); len(devices) > 0 {
+		return devices, nil
+	}
 	// virtual-audio-capturer from screen-capture-recorder
 	// https://github.com/rdp/screen-capture-recorder-to-video-windows-free
-	return []string{"virtual-audio-capturer"}, nil
+	return []string{ScreenCaptureRecorderDevice}, nil
 }
 
 // GetDefaultDeviceName returns the default audio device name for Windows
 func GetDefaultDeviceName() string {
-	return "virtual-audio-capturer"
+	return ScreenCaptureRecorderDevice
 }
 
 // GetDeviceHelpText returns platform-specific help for device selection
@@ -22,5 +31,33 @@ func GetDeviceHelpText() string {
 - Requires: https://github.com/rdp/screen-capture-recorder-to-video-windows-free
 - Install screen-capture-recorder and the virtual audio device will be available
 - No device selection needed - uses virtual-audio-capturer by default
+- Missing devices can be installed by cs-translate during setup
 `
 }
+
+// CheckSource verifies that a DirectShow device exists. If ffmpeg cannot
+// list devices the check is skipped and ffmpeg reports instead.
+func CheckSource(source string) error {
+	if IsAppDevice(source) {
+		return fmt.Errorf("%w: %s", ErrAppCaptureUnsupported, source)
+	}
+	devices, _ := DShowAudioDevices()
+	if len(devices) == 0 {
+		return nil
+	}
+	for _, d := range devices {
+		if strings.EqualFold(d, source) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNoMonitorSource, source)
+}
+
+// AppInput fails on Windows: ffmpeg has no per-process loopback. Routing the
+// program to VB-Audio Virtual Cable and recording "CABLE Output" does the same.
+func AppInput(device string) (Input, error) {
+	return Input{}, fmt.Errorf("%w: %s", ErrAppCaptureUnsupported, device)
+}
+
+// ReleaseAppCapture has nothing to undo on Windows
+func ReleaseAppCapture() {}
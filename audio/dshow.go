@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// Windows DirectShow devices used for capture
+const (
+	// ScreenCaptureRecorderDevice records the system output; it comes with
+	// screen-capture-recorder
+	ScreenCaptureRecorderDevice = "virtual-audio-capturer"
+	// VBCableDevice is the recording end of VB-Audio's virtual cable
+	VBCableDevice = "CABLE Output (VB-Audio Virtual Cable)"
+)
+
+// DShowAudioDevices lists DirectShow audio capture devices via ffmpeg. It
+// only returns devices on Windows.
+func DShowAudioDevices() ([]string, error) {
+	out, _ := execwrap.Command("ffmpeg", "-hide_banner", "-list_devices", "true", "-f", "dshow", "-i", "dummy").CombinedOutput()
+	return parseDShowDevices(string(out)), nil
+}
+
+// parseDShowDevices reads both the old sectioned listing and the newer one
+// that tags each device with "(audio)"
+func parseDShowDevices(out string) []string {
+	var devices []string
+	inAudio := false
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.Contains(line, "DirectShow audio devices"):
+			inAudio = true
+			continue
+		case strings.Contains(line, "DirectShow video devices"):
+			inAudio = false
+			continue
+		case strings.Contains(line, "Alternative name"):
+			continue
+		}
+		parts := strings.Split(line, "\"")
+		if len(parts) < 3 {
+			continue
+		}
+		tail := parts[len(parts)-1]
+		if strings.Contains(tail, "(audio)") || (inAudio && !strings.Contains(tail, "(video)")) {
+			devices = append(devices, parts[1])
+		}
+	}
+	return devices
+}
+
+// HasDShowDevice reports whether name is an installed audio capture device
+func HasDShowDevice(name string) bool {
+	devices, _ := DShowAudioDevices()
+	for _, d := range devices {
+		if strings.EqualFold(d, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package audio
+
+import "errors"
+
+var (
+	// ErrNoMonitorSource is returned when the capture source does not exist on this system
+	ErrNoMonitorSource = errors.New("audio monitor source not found")
+
+	// ErrAppCaptureUnsupported is returned for "app:" devices where one program cannot be recorded alone
+	ErrAppCaptureUnsupported = errors.New("recording a single application is not supported here")
+
+	// ErrWhisperOOM is returned when the transcriber ran out of (GPU) memory loading the model
+	ErrWhisperOOM = errors.New("whisper ran out of memory")
+
+	// ErrTranscriberNotReady is returned when the transcriber exits before signalling ready
+	ErrTranscriberNotReady = errors.New("transcriber exited before becoming ready")
+
+	// ErrTranscriberExited is reported when a running transcriber dies
+	ErrTranscriberExited = errors.New("transcriber exited")
+)
@@ -0,0 +1,90 @@
+package audio
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Whisper's own thresholds for deciding a segment holds no speech
+const (
+	DefaultMinAvgLogProb   = -1.0
+	DefaultMaxNoSpeechProb = 0.6
+)
+
+// DefaultHallucinations are phrases Whisper tends to produce from silence or
+// noise, learned from video subtitles
+var DefaultHallucinations = []string{
+	"thank you for watching",
+	"thanks for watching",
+	"thank you so much for watching",
+	"please subscribe",
+	"like and subscribe",
+	"subtitles by the amara.org community",
+	"amara.org",
+	"untertitel im auftrag des zdf",
+	"untertitelung des zdf",
+	"untertitel der amara.org-community",
+	"sous-titres réalisés par la communauté d'amara.org",
+	"продолжение следует",
+	"редактор субтитров",
+	"субтитры сделал",
+	"ご視聴ありがとうございました",
+	"thank you",
+	"you",
+}
+
+// Filter decides which transcriptions are kept
+type Filter struct {
+	MinAvgLogProb   float64  // drop below this average log probability
+	MaxNoSpeechProb float64  // drop above this no-speech probability
+	Blocklist       []string // phrases dropped when they make up the whole text
+}
+
+// DefaultFilter uses Whisper's thresholds and the built-in phrases
+func DefaultFilter() Filter {
+	return Filter{
+		MinAvgLogProb:   DefaultMinAvgLogProb,
+		MaxNoSpeechProb: DefaultMaxNoSpeechProb,
+		Blocklist:       DefaultHallucinations,
+	}
+}
+
+// Reject returns why t should be dropped, or "" to keep it
+func (f Filter) Reject(t Transcription) string {
+	if f.MaxNoSpeechProb > 0 && t.NoSpeechProb > f.MaxNoSpeechProb {
+		return "no speech"
+	}
+	if f.MinAvgLogProb < 0 && t.AvgLogProb < f.MinAvgLogProb {
+		return "low confidence"
+	}
+	text := normalizePhrase(t.Text)
+	for _, phrase := range f.Blocklist {
+		p := normalizePhrase(phrase)
+		if p == "" {
+			continue
+		}
+		// Whisper often repeats the phrase, e.g. "Thank you. Thank you."
+		if text == p || strings.Trim(strings.ReplaceAll(text, p, ""), " ") == "" {
+			return "known hallucination"
+		}
+	}
+	return ""
+}
+
+// normalizePhrase lowercases and drops punctuation so "Thanks for watching!"
+// matches "thanks for watching"
+func normalizePhrase(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case !space && b.Len() > 0:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+const (
+	// DefaultTempMax is the size audio files in the listener's folder may
+	// add up to before the oldest are deleted
+	DefaultTempMax = 256 << 20
+	// DefaultTempMaxAge is how old an audio file gets before it is deleted
+	// as left behind; far longer than any file waits for the transcriber
+	DefaultTempMaxAge = 10 * time.Minute
+	// DefaultJanitorInterval is how often the janitor looks
+	DefaultJanitorInterval = 30 * time.Second
+)
+
+func (o Options) tempMax() int64 {
+	if o.TempMax <= 0 {
+		return DefaultTempMax
+	}
+	return o.TempMax
+}
+
+func (o Options) tempMaxAge() time.Duration {
+	if o.TempMaxAge <= 0 {
+		return DefaultTempMaxAge
+	}
+	return o.TempMaxAge
+}
+
+func (o Options) janitorInterval() time.Duration {
+	if o.JanitorInterval <= 0 {
+		return DefaultJanitorInterval
+	}
+	return o.JanitorInterval
+}
+
+// janitor keeps the audio files of a long session from filling the disk:
+// segments, slices and submitted files a crash or a dropped request left
+// behind are deleted, here and in the container's /tmp, until the listener
+// stops
+func (l *Listener) janitor() {
+	defer close(l.janitorDone)
+	ticker := time.NewTicker(l.opts.janitorInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.sweep()
+		case <-l.ctx.Done():
+			return
+		}
+	}
+}
+
+// sweep deletes audio files older than Options.TempMaxAge, then the oldest
+// until those left fit in Options.TempMax
+func (l *Listener) sweep() {
+	entries, err := os.ReadDir(l.outputDir)
+	if err != nil {
+		return
+	}
+	type audioFile struct {
+		path string
+		size int64
+		mod  time.Time
+	}
+	var files []audioFile
+	var total int64
+	stale := 0
+	cutoff := time.Now().Add(-l.opts.tempMaxAge())
+	for _, e := range entries {
+		if e.IsDir() || !isAudioFile(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(l.outputDir, e.Name())
+		if info.ModTime().Before(cutoff) {
+			if os.Remove(path) == nil {
+				stale++
+			}
+			continue
+		}
+		files = append(files, audioFile{path, info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	if stale > 0 {
+		slog.Debug("Removed stale audio files", "dir", l.outputDir, "files", stale)
+	}
+
+	if limit := l.opts.tempMax(); total > limit {
+		slices.SortFunc(files, func(a, b audioFile) int { return a.mod.Compare(b.mod) })
+		removed := 0
+		for _, f := range files {
+			if total <= limit {
+				break
+			}
+			if os.Remove(f.path) == nil {
+				total -= f.size
+				removed++
+			}
+		}
+		slog.Warn("Audio temp folder is over its size cap; removed the oldest recordings", "dir", l.outputDir, "files", removed, "max_bytes", limit)
+	}
+
+	if l.useDocker {
+		l.sweepContainer()
+	}
+}
+
+// sweepContainer deletes the segments docker cp left in the container's
+// /tmp when their removal failed
+func (l *Listener) sweepContainer() {
+	minutes := int(math.Ceil(l.opts.tempMaxAge().Minutes()))
+	// docker cp creates root-owned files
+	cmd := execwrap.CommandContext(l.ctx, "docker", "exec", "-u", "root", "cs-translate",
+		"find", "/tmp", "-maxdepth", "1", "-type", "f",
+		"(", "-name", "*.wav", "-o", "-name", "*.flac", "-o", "-name", "*.opus", ")",
+		"-mmin", fmt.Sprintf("+%d", minutes), "-delete")
+	if out, err := cmd.CombinedOutput(); err != nil && l.ctx.Err() == nil {
+		slog.Debug("Cleaning the container's /tmp failed", "err", err, "output", strings.TrimSpace(string(out)))
+	}
+}
+
+// isAudioFile reports whether name is a segment, slice or submitted file;
+// the transcriber script and the owner file are kept
+func isAudioFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".wav", ".flac", ".opus":
+		return true
+	}
+	return false
+}
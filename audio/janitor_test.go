@@ -0,0 +1,76 @@
+package audio_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// Audio files left too long, and the oldest beyond the size cap, are deleted
+// from a Docker listener's folder, and the container's /tmp is cleaned of
+// stale segments
+func TestJanitor(t *testing.T) {
+	ready, err := json.Marshal(map[string]any{"type": "ready", "protocol": audio.ProtocolVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"docker ps --filter name=cs-translate --format {{.Names}}":                         {Stdout: "cs-translate\n"},
+		"docker exec -i -e WHISPER_MODEL=tiny cs-translate python3 -u /app/transcriber.py": {Stdout: string(ready) + "\n", Wait: time.Minute},
+	}}
+	defer execwrap.Use(fake)()
+	t.Setenv("USE_DOCKER_WHISPER", "1")
+
+	l, err := audio.NewListener("", audio.Options{
+		Model: "tiny", TempMax: 3000, TempMaxAge: time.Minute, JanitorInterval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+
+	now := time.Now()
+	files := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"slice_1.wav", 2 * time.Minute}, // left behind
+		{"api_a.wav", 3 * time.Second},   // the oldest of three over the cap
+		{"api_b.wav", 2 * time.Second},
+		{"api_c.wav", time.Second},
+	}
+	for _, f := range files {
+		path := filepath.Join(l.OutputDir(), f.name)
+		if err := os.WriteFile(path, make([]byte, 1500), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-f.age), now.Add(-f.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	left := func() []string {
+		var names []string
+		entries, _ := os.ReadDir(l.OutputDir())
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+	want := []string{".cs-translate-owner", "api_b.wav", "api_c.wav"}
+	for deadline := time.Now().Add(testTimeout); !slices.Equal(left(), want); {
+		if time.Now().After(deadline) {
+			t.Fatalf("folder holds %q, want %q", left(), want)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !fake.Ran("docker", "exec", "-u", "root", "cs-translate", "find", "/tmp", "-maxdepth", "1", "-type", "f",
+		"(", "-name", "*.wav", "-o", "-name", "*.flac", "-o", "-name", "*.opus", ")", "-mmin", "+1", "-delete") {
+		t.Fatalf("the container's /tmp was not cleaned: %v", fake.Calls())
+	}
+}
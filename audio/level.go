@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// SilentLevel is the mean loudness, in dBFS, below which a recording holds
+// nothing worth transcribing
+const SilentLevel = -50
+
+// Level is the loudness of a recording in dBFS, as ffmpeg's volumedetect
+// measures it
+type Level struct {
+	Mean float64
+	Max  float64
+}
+
+// Silent reports whether nothing audible was recorded
+func (l Level) Silent() bool {
+	return l.Mean < SilentLevel
+}
+
+// MeasureLevel records d of in and returns how loud it was
+func MeasureLevel(ctx context.Context, in Input, d time.Duration) (Level, error) {
+	ctx, cancel := context.WithTimeout(ctx, d+10*time.Second)
+	defer cancel()
+	args := append(append([]string{}, in.Args...),
+		"-t", strconv.FormatFloat(d.Seconds(), 'f', -1, 64), "-af", "volumedetect", "-f", "null", "-")
+	cmd := execwrap.CommandContext(ctx, "ffmpeg", args...)
+	var out bytes.Buffer
+	cmd.Stderr = &out
+	if err := in.Start(cmd); err != nil {
+		return Level{}, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return Level{}, fmt.Errorf("recording failed: %w: %s", err, lastLine(out.String()))
+	}
+	mean, ok := parseVolume(out.String(), "mean_volume:")
+	if !ok {
+		return Level{}, fmt.Errorf("ffmpeg measured no volume: %s", lastLine(out.String()))
+	}
+	max, _ := parseVolume(out.String(), "max_volume:")
+	return Level{Mean: mean, Max: max}, nil
+}
+
+// meterInterval is how much audio each Record level reading covers
+const meterInterval = 100 * time.Millisecond
+
+// Record records d of in into path as a 16 kHz mono WAV and calls level
+// with the loudness, in dBFS, of every meterInterval as it comes in
+func Record(ctx context.Context, in Input, d time.Duration, path string, level func(dB float64)) error {
+	ctx, cancel := context.WithTimeout(ctx, d+10*time.Second)
+	defer cancel()
+	pcm := []string{"-c:a", "pcm_s16le", "-ar", "16000", "-ac", "1"}
+	args := append(append([]string{}, in.Args...), "-t", strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+	args = append(append(args, pcm...), "-y", path)
+	args = append(append(args, pcm...), "-f", "s16le", "pipe:1")
+	cmd := execwrap.CommandContext(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := in.Start(cmd); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	r := bufio.NewReader(stdout)
+	chunk := make([]int16, int(16000*meterInterval.Seconds()))
+	for {
+		err := binary.Read(r, binary.LittleEndian, chunk)
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			break
+		}
+		if err != nil {
+			cmd.Process.Kill()
+			break
+		}
+		level(loudness(chunk))
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("recording failed: %w: %s", err, lastLine(stderr.String()))
+	}
+	return nil
+}
+
+// loudness is the RMS level of samples in dBFS; digital silence is -91 dB,
+// just below what 16 bits can hold
+func loudness(samples []int16) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	rms := math.Sqrt(sum/float64(len(samples))) / 32768
+	return max(20*math.Log10(rms), -91)
+}
+
+// pcmLoudness is the loudness of raw 16-bit little-endian samples
+func pcmLoudness(pcm []byte) float64 {
+	samples := make([]int16, len(pcm)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(pcm[2*i:]))
+	}
+	if len(samples) == 0 {
+		return -91
+	}
+	return loudness(samples)
+}
+
+// parseVolume reads the value after key, e.g. "mean_volume: -23.5 dB", from
+// volumedetect's output
+func parseVolume(out, key string) (float64, bool) {
+	idx := strings.Index(out, key)
+	if idx == -1 {
+		return 0, false
+	}
+	v := out[idx+len(key):]
+	end := strings.Index(v, " dB")
+	if end == -1 {
+		return 0, false
+	}
+	vol, err := strconv.ParseFloat(strings.TrimSpace(v[:end]), 64)
+	return vol, err == nil
+}
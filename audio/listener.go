@@ -1,11 +1,10 @@
 package audio
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,41 +15,93 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/tempdir"
 	"github.com/micha/cs-ingame-translate/translator"
 )
 
 type Listener struct {
 	outputDir      string
-	ffmpegCmd      *exec.Cmd
-	pythonCmd      *exec.Cmd
-	pythonStdin    io.WriteCloser
-	pythonStdout   *bufio.Scanner
-	stop           chan struct{}
-	transcriptions chan string
-	mu             sync.Mutex
-	fileQueue      chan string
+	transcriptions chan Transcription // closed by the worker, its only sender
+	mu             sync.Mutex         // guards proc
+	queue          *fileQueue
 	useDocker      bool
+	opts           Options
+	hostAudioDir   string // host side of the container audio mount, if present
+
+	// proc is the running transcriber. Only the worker replaces it, through
+	// spawn, when the process dies.
+	proc   *transcriberProc
+	spawn  func() (*transcriberProc, error)
+	status chan Status
+
+	// ctx is cancelled by Stop (or when the worker exits) and ends every
+	// goroutine owned by the listener
+	ctx         context.Context
+	cancel      context.CancelFunc
+	workerDone  chan struct{}
+	janitorDone chan struct{}
+	stopOnce    sync.Once
+
+	// captureCancel and captureDone belong to the running ffmpeg capture, so
+	// it can be stopped and restarted on another device
+	captureMu     sync.Mutex
+	captureCancel context.CancelFunc
+	captureDone   chan struct{}
 }
 
 func useDockerWhisper() bool {
 	return os.Getenv("USE_DOCKER_WHISPER") == "1"
 }
 
-func NewListener(scriptPath string) (*Listener, error) {
+func NewListener(scriptPath string, opts Options) (*Listener, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
 	if useDockerWhisper() {
-		return newDockerListener()
+		return newDockerListener(opts)
+	}
+	return newLocalListener(scriptPath, opts)
+}
+
+func newListener(outputDir string, proc *transcriberProc, spawn func() (*transcriberProc, error), useDocker bool, opts Options) *Listener {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Listener{
+		outputDir:      outputDir,
+		proc:           proc,
+		spawn:          spawn,
+		status:         make(chan Status, statusBuffer),
+		transcriptions: make(chan Transcription),
+		queue:          newFileQueue(opts.MaxBacklog),
+		useDocker:      useDocker,
+		opts:           opts,
+		ctx:            ctx,
+		cancel:         cancel,
+		workerDone:     make(chan struct{}),
+		janitorDone:    make(chan struct{}),
 	}
-	return newLocalListener(scriptPath)
+	go l.janitor()
+	return l
 }
 
-func newLocalListener(scriptPath string) (*Listener, error) {
-	tmpDir, err := os.MkdirTemp("", "cs-translate-audio")
+func newLocalListener(scriptPath string, opts Options) (*Listener, error) {
+	script, err := os.ReadFile(scriptPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("transcriber script not found at %s", scriptPath)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read transcriber script: %w", err)
+	}
+
+	tmpDir, err := tempdir.New("", "cs-translate-audio")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
 
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("transcriber script not found at %s", scriptPath)
+	// Keep our own copy so the transcriber can be restarted later
+	ownScript := filepath.Join(tmpDir, "transcriber.py")
+	if err := os.WriteFile(ownScript, script, 0644); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("failed to write transcriber script: %w", err)
 	}
 
 	cwd, _ := os.Getwd()
@@ -69,162 +120,158 @@ func newLocalListener(scriptPath string) (*Listener, error) {
 		}
 	}
 
-	cmd := exec.Command(pythonPath, "-u", scriptPath)
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get python stdin: %w", err)
+	spawn := func() (*transcriberProc, error) {
+		cmd := execwrap.Command(pythonPath, "-u", ownScript)
+		cmd.Env = append(cmd.Environ(), fmt.Sprintf("WHISPER_MODEL=%s", opts.whisperModel()))
+		return startTranscriber(cmd, "Transcriber init")
 	}
 
-	stdout, err := cmd.StdoutPipe()
+	proc, err := spawn()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get python stdout: %w", err)
+		os.RemoveAll(tmpDir)
+		return nil, err
 	}
 
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), fmt.Sprintf("WHISPER_MODEL=%s", getWhisperModel()))
+	l := newListener(tmpDir, proc, spawn, false, opts)
 
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start transcriber.py: %w", err)
-	}
+	go l.worker()
 
-	scanner := bufio.NewScanner(stdout)
+	return l, nil
+}
 
-	if scanner.Scan() {
-		text := scanner.Text()
-		if !strings.Contains(text, "READY") {
-			log.Printf("Transcriber initialization: %s", text)
-			for scanner.Scan() {
-				text = scanner.Text()
-				if strings.Contains(text, "READY") {
-					break
-				}
-				log.Printf("Transcriber init: %s", text)
-			}
-		}
+// NewCommandListener runs the transcriber newCmd starts instead of
+// transcriber.py, e.g. a stand-in speaking the same protocol. newCmd is
+// called again for every restart.
+func NewCommandListener(newCmd func() *exec.Cmd, opts Options) (*Listener, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	tmpDir, err := tempdir.New("", "cs-translate-audio")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
 
-	l := &Listener{
-		outputDir:      tmpDir,
-		pythonCmd:      cmd,
-		pythonStdin:    stdin,
-		pythonStdout:   scanner,
-		stop:           make(chan struct{}),
-		transcriptions: make(chan string),
-		fileQueue:      make(chan string, 100),
-		useDocker:      false,
+	spawn := func() (*transcriberProc, error) {
+		return startTranscriber(newCmd(), "Transcriber init")
+	}
+	proc, err := spawn()
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
 	}
 
+	l := newListener(tmpDir, proc, spawn, false, opts)
 	go l.worker()
-
 	return l, nil
 }
 
-func newDockerListener() (*Listener, error) {
-	log.Println("Using Docker-based Whisper transcription")
+func newDockerListener(opts Options) (*Listener, error) {
+	slog.Info("Using Docker-based Whisper transcription")
 
 	containerName := "cs-translate"
 
-	checkCmd := exec.Command("docker", "ps", "--filter", "name="+containerName, "--format", "{{.Names}}")
-	output, err := checkCmd.Output()
-	if err != nil || strings.TrimSpace(string(output)) != containerName {
+	if !containerRunning(containerName) {
 		return nil, fmt.Errorf("Docker container '%s' is not running. Please run cs-translate first to start the container", containerName)
 	}
 
-	tmpDir, err := os.MkdirTemp("", "cs-translate-audio")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
-	}
-
-	// Use persistent docker exec command
-	cmd := exec.Command("docker", "exec", "-i", "cs-translate", "python3", "-u", "/app/transcriber.py")
-	cmd.Env = append(os.Environ(), fmt.Sprintf("WHISPER_MODEL=%s", getWhisperModel()))
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get docker stdin: %w", err)
+	// Segments written below the mounted audio dir are visible in the
+	// container directly; older containers without the mount need docker cp
+	var hostAudioDir string
+	baseDir := ""
+	if containerHasAudioMount(containerName) {
+		var err error
+		hostAudioDir, err = translator.HostAudioDir()
+		if err != nil {
+			return nil, err
+		}
+		baseDir = hostAudioDir
+	} else {
+		slog.Warn("Container has no audio mount; copying segments with docker cp. Run 'cs-translate container update' to fix.")
 	}
 
-	stdout, err := cmd.StdoutPipe()
+	tmpDir, err := tempdir.New(baseDir, "cs-translate-audio")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get docker stdout: %w", err)
-	}
-
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start docker process: %w", err)
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
+	// The container user may have a different uid than ours
+	os.Chmod(tmpDir, 0755)
 
-	// Wait for READY signal from transcriber
-	scanner := bufio.NewScanner(stdout)
-	if scanner.Scan() {
-		text := scanner.Text()
-		if !strings.Contains(text, "READY") {
-			log.Printf("Docker Transcriber initialization: %s", text)
-			for scanner.Scan() {
-				text = scanner.Text()
-				if strings.Contains(text, "READY") {
-					break
-				}
-				log.Printf("Docker Transcriber init: %s", text)
+	// Use persistent docker exec command
+	spawn := func() (*transcriberProc, error) {
+		// A crashed transcriber can take the container down with it
+		if !containerRunning(containerName) {
+			if err := execwrap.Command("docker", "start", containerName).Run(); err != nil {
+				return nil, fmt.Errorf("failed to start container %s: %w", containerName, err)
 			}
 		}
+		// The model has to reach the process inside the container
+		cmd := execwrap.Command("docker", "exec", "-i", "-e", "WHISPER_MODEL="+opts.whisperModel(),
+			containerName, "python3", "-u", "/app/transcriber.py")
+		return startTranscriber(cmd, "Docker Transcriber init")
 	}
 
-	l := &Listener{
-		outputDir:      tmpDir,
-		pythonCmd:      cmd,
-		pythonStdin:    stdin,
-		pythonStdout:   scanner,
-		stop:           make(chan struct{}),
-		transcriptions: make(chan string),
-		fileQueue:      make(chan string, 100),
-		useDocker:      true,
+	proc, err := spawn()
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
 	}
 
+	l := newListener(tmpDir, proc, spawn, true, opts)
+	l.hostAudioDir = hostAudioDir
+
 	go l.dockerPersistentWorker()
 
 	return l, nil
 }
 
+func containerRunning(name string) bool {
+	output, err := execwrap.Command("docker", "ps", "--filter", "name="+name, "--format", "{{.Names}}").Output()
+	return err == nil && strings.TrimSpace(string(output)) == name
+}
+
 func (l *Listener) dockerPersistentWorker() {
-	for path := range l.fileQueue {
-		// Start timing for transcription
-		transcribeStart := time.Now()
+	defer l.workerExit()
 
-		// 1. Copy file to container
-		fileName := filepath.Base(path)
-		containerPath := "/tmp/" + fileName
-		// We use `docker cp` to copy the file into the container
-		cpCmd := exec.Command("docker", "cp", path, "cs-translate:"+containerPath)
-		if err := cpCmd.Run(); err != nil {
-			log.Printf("Failed to copy file to container: %v", err)
-			os.Remove(path)
-			continue
+	for {
+		f, ok := l.nextFile()
+		if !ok {
+			return
 		}
+		path := f.path
 
-		// 2. Send container path to python
-		l.mu.Lock()
-		_, err := fmt.Fprintln(l.pythonStdin, containerPath)
-		l.mu.Unlock()
+		// Start timing for transcription
+		transcribeStart := time.Now()
+		waited := transcribeStart.Sub(f.queued)
+
+		// 1. Make the file visible inside the container; audio kept in
+		// memory goes over stdin instead
+		containerPath, mounted := l.containerPathFor(path)
+		if f.pcm != nil {
+			containerPath, mounted = "", true
+		} else if !mounted {
+			containerPath = "/tmp/" + filepath.Base(path)
+			// We use `docker cp` to copy the file into the container
+			cpCmd := execwrap.Command("docker", "cp", path, "cs-translate:"+containerPath)
+			if err := cpCmd.Run(); err != nil {
+				slog.Error("Failed to copy audio to the container", "err", err)
+				os.Remove(path)
+				continue
+			}
+		}
 
+		// 2. Send container path to python and read the result
+		resp, err := l.transcribe(containerPath, f)
 		if err != nil {
-			log.Printf("Failed to send path to docker transcriber: %v", err)
+			os.Remove(path)
+			if !l.restartTranscriber(err) {
+				return
+			}
 			continue
 		}
-
-		// 3. Read result
-		if l.pythonStdout.Scan() {
-			text := strings.TrimSpace(l.pythonStdout.Text())
-			transcribeDuration := time.Since(transcribeStart)
-			if text != "" {
-				l.transcriptions <- fmt.Sprintf("%s|%.2f", text, transcribeDuration.Seconds())
-			}
-		} else {
-			if err := l.pythonStdout.Err(); err != nil {
-				log.Printf("Error reading from docker transcriber: %v", err)
-			}
+		if resp.Error != "" {
+			slog.Warn("Transcription failed", "file", filepath.Base(path), "err", resp.Error)
+		} else if resp.Text != "" && !l.deliver(resp.transcription(f, time.Since(transcribeStart), waited)) {
+			os.Remove(path)
 			return
 		}
 
@@ -232,75 +279,278 @@ func (l *Listener) dockerPersistentWorker() {
 		os.Remove(path)
 
 		// 5. Cleanup container file (async)
-		go exec.Command("docker", "exec", "cs-translate", "rm", containerPath).Run()
+		if !mounted {
+			// docker cp creates root-owned files, which the container user cannot remove from /tmp
+			go execwrap.Command("docker", "exec", "-u", "root", "cs-translate", "rm", containerPath).Run()
+		}
 	}
 }
 
+// containerPathFor maps a host file below the mounted audio dir to its path
+// inside the container
+func (l *Listener) containerPathFor(path string) (string, bool) {
+	if l.hostAudioDir == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(l.hostAudioDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return translator.ContainerAudioDir + "/" + filepath.ToSlash(rel), true
+}
+
+func containerHasAudioMount(name string) bool {
+	out, err := execwrap.Command("docker", "inspect", "-f", "{{range .Mounts}}{{.Destination}} {{end}}", name).Output()
+	if err != nil {
+		return false
+	}
+	for _, dest := range strings.Fields(string(out)) {
+		if dest == translator.ContainerAudioDir {
+			return true
+		}
+	}
+	return false
+}
+
+// Model returns the Whisper model the transcriber loaded
+func (l *Listener) Model() string {
+	return l.opts.whisperModel()
+}
+
+// OutputDir is where the listener keeps audio files. Files submitted from
+// here are shared with the Docker transcriber without copying.
+func (l *Listener) OutputDir() string {
+	return l.outputDir
+}
+
 func (l *Listener) dockerWorker() {
 	// Deprecated in favor of dockerPersistentWorker, keeping for reference if needed but not used
 }
 
+// Source is a device to capture. Label tags its transcriptions, e.g. "game"
+// or "discord", so speech from several devices captured at once can be told
+// apart.
+type Source struct {
+	Label  string
+	Device string
+}
+
+// Start begins capturing from device. A capture that is already running is
+// stopped first, so Start also switches devices.
 func (l *Listener) Start(ctx context.Context, device string) error {
-	var cmd *exec.Cmd
-	pattern := filepath.Join(l.outputDir, "audio_%03d.wav")
-	//segment_time
-	segmentTime := "2"
+	return l.StartSources(ctx, []Source{{Device: device}})
+}
 
-	if runtime.GOOS == "windows" {
-		// Windows: Use virtual-audio-capturer from screen-capture-recorder
-		// https://github.com/rdp/screen-capture-recorder-to-video-windows-free
-		inputDevice := device
-		if inputDevice == "" || inputDevice == "default" {
-			inputDevice = "virtual-audio-capturer"
+// StartSources captures every source at once, each with an ffmpeg of its
+// own whose segments are transcribed apart from the others'. A capture that
+// is already running is stopped first.
+func (l *Listener) StartSources(ctx context.Context, sources []Source) error {
+	if err := validateSources(sources); err != nil {
+		return err
+	}
+	l.captureMu.Lock()
+	defer l.captureMu.Unlock()
+
+	l.stopCaptureLocked()
+	if l.ctx.Err() != nil {
+		return fmt.Errorf("audio listener is stopped")
+	}
+
+	captureCtx, cancel := context.WithCancel(ctx)
+	// Capture never outlives the listener
+	stopWithListener := context.AfterFunc(l.ctx, cancel)
+	stop := func() {
+		stopWithListener()
+		cancel()
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range sources {
+		if err := l.startSource(captureCtx, s, &wg); err != nil {
+			stop()
+			wg.Wait()
+			return err
 		}
+	}
 
-		log.Printf("Starting audio listener on Windows device: %s", inputDevice)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wg.Wait()
+	}()
+	l.captureCancel = stop
+	l.captureDone = done
+	return nil
+}
 
-		cmd = exec.CommandContext(ctx, "ffmpeg",
-			"-f", "dshow", "-i", fmt.Sprintf("audio=%s", inputDevice),
-			"-f", "segment", "-segment_time", segmentTime,
-			"-c:a", "pcm_s16le", "-ar", "16000", "-ac", "1",
-			"-reset_timestamps", "1",
-			pattern,
-		)
-	} else {
-		// Linux / PulseAudio
-		source := device
-		if source == "" || source == "default" {
-			source = GetDefaultMonitorSource()
+// validateSources checks that several sources carry distinct labels, which
+// also name their segment files
+func validateSources(sources []Source) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("no audio source to capture")
+	}
+	seen := make(map[string]bool)
+	for _, s := range sources {
+		if s.Label == "" && len(sources) > 1 {
+			return fmt.Errorf("capturing %d audio sources at once needs a label for each", len(sources))
 		}
+		for _, r := range s.Label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Errorf("capture source label %q may only use lowercase letters, digits and -", s.Label)
+			}
+		}
+		if seen[s.Label] {
+			return fmt.Errorf("capture source label %q is used twice", s.Label)
+		}
+		seen[s.Label] = true
+	}
+	return nil
+}
 
-		log.Printf("Starting audio listener on source: %s", source)
+// startSource starts the ffmpeg and file watcher of s; wg is done once both
+// have ended
+func (l *Listener) startSource(ctx context.Context, s Source, wg *sync.WaitGroup) error {
+	in, err := sourceInput(s.Device)
+	if err != nil {
+		return err
+	}
 
-		cmd = exec.CommandContext(ctx, "ffmpeg",
-			"-f", "pulse", "-i", source,
-			"-f", "segment", "-segment_time", segmentTime,
-			"-c:a", "pcm_s16le", "-ar", "16000", "-ac", "1",
+	prefix := "audio_"
+	if s.Label != "" {
+		prefix += s.Label + "_"
+	}
+	codec, ext := l.opts.segmentFormat()
+	pattern := filepath.Join(l.outputDir, prefix+"%03d"+ext)
+	segmentTime := strconv.FormatFloat(l.opts.segment().Seconds(), 'f', -1, 64)
+
+	args := append(append([]string{}, in.Args...), l.opts.Preprocess.Args()...)
+	pipe := l.opts.Intermediate == IntermediatePipe
+	if pipe {
+		args = append(args, "-f", "s16le", "-ar", "16000", "-ac", "1", "pipe:1")
+	} else {
+		args = append(args, "-f", "segment", "-segment_time", segmentTime)
+		args = append(args, codec...)
+		args = append(args,
+			"-ar", "16000", "-ac", "1",
 			"-reset_timestamps", "1",
 			pattern,
 		)
 	}
+	sourceCtx, cancel := context.WithCancel(ctx)
+	cmd := execwrap.CommandContext(sourceCtx, "ffmpeg", args...)
+	var stdout io.Reader
+	if pipe {
+		var err error
+		if stdout, err = cmd.StdoutPipe(); err != nil {
+			cancel()
+			return fmt.Errorf("failed to start ffmpeg: %w", err)
+		}
+	}
 
-	if err := cmd.Start(); err != nil {
+	if err := in.Start(cmd); err != nil {
+		cancel()
 		return fmt.Errorf("failed to start ffmpeg: %w", err)
 	}
-	l.ffmpegCmd = cmd
-
-	go l.watchFiles(ctx)
 
+	// ffmpeg exiting on its own (e.g. device removed) also ends the watcher,
+	// but not the capture of other sources
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		if pipe {
+			// Wait closes the pipe, so it has to be read to the end first
+			l.readSegments(sourceCtx, stdout, prefix, s.Label)
+		}
+		if err := cmd.Wait(); err != nil && sourceCtx.Err() == nil {
+			slog.Warn("Audio capture exited", "source", s.Label, "err", err)
+		}
+		cancel()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if !pipe {
+			l.watchFiles(sourceCtx, prefix, ext, s.Label)
+		}
+		<-exited
+	}()
 	return nil
 }
 
-func (l *Listener) watchFiles(ctx context.Context) {
+// sourceInput is the ffmpeg input recording device
+func sourceInput(device string) (Input, error) {
+	if IsAppDevice(device) {
+		in, err := AppInput(device)
+		if err != nil {
+			return Input{}, err
+		}
+		slog.Info("Starting audio listener", "application", device)
+		return in, nil
+	}
+	if runtime.GOOS == "windows" {
+		// Windows: Use virtual-audio-capturer from screen-capture-recorder
+		// https://github.com/rdp/screen-capture-recorder-to-video-windows-free
+		inputDevice := device
+		if inputDevice == "" || inputDevice == "default" {
+			inputDevice = "virtual-audio-capturer"
+		}
+
+		slog.Info("Starting audio listener", "device", inputDevice)
+		return Input{Args: []string{"-f", "dshow", "-i", fmt.Sprintf("audio=%s", inputDevice)}}, nil
+	}
+	// Linux / PulseAudio, or a PipeWire node through pw-record
+	source := device
+	if source == "" || source == "default" {
+		source = GetDefaultMonitorSource()
+	}
+
+	if err := CheckSource(source); err != nil {
+		return Input{}, err
+	}
+
+	slog.Info("Starting audio listener", "input", source)
+	if IsPipeWireDevice(source) {
+		return PipeWireInput(source), nil
+	}
+	return Input{Args: []string{"-f", "pulse", "-i", source}}, nil
+}
+
+// Restart switches the running capture to another device
+func (l *Listener) Restart(ctx context.Context, device string) error {
+	return l.Start(ctx, device)
+}
+
+// StopCapture stops ffmpeg and its file watcher but keeps the transcriber
+// running, so Start can be called again later
+func (l *Listener) StopCapture() {
+	l.captureMu.Lock()
+	defer l.captureMu.Unlock()
+	l.stopCaptureLocked()
+}
+
+func (l *Listener) stopCaptureLocked() {
+	if l.captureCancel == nil {
+		return
+	}
+	l.captureCancel()
+	<-l.captureDone
+	l.captureCancel = nil
+	l.captureDone = nil
+}
+
+// watchFiles queues the segments named prefix...ext, tagged with capture, as
+// ffmpeg finishes them
+func (l *Listener) watchFiles(ctx context.Context, prefix, ext, capture string) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		log.Printf("Failed to create fsnotify watcher: %v", err)
+		slog.Error("Failed to watch captured audio", "err", err)
 		return
 	}
 	defer watcher.Close()
 
 	if err := watcher.Add(l.outputDir); err != nil {
-		log.Printf("Failed to watch tmp dir: %v", err)
+		slog.Error("Failed to watch captured audio", "dir", l.outputDir, "err", err)
 		return
 	}
 
@@ -309,8 +559,10 @@ func (l *Listener) watchFiles(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			return
-		case <-l.stop:
+			// The last segment is still being written by the capture that just ended
+			if lastFile != "" {
+				os.Remove(lastFile)
+			}
 			return
 		case event, ok := <-watcher.Events:
 			if !ok {
@@ -318,10 +570,11 @@ func (l *Listener) watchFiles(ctx context.Context) {
 			}
 
 			if event.Op&fsnotify.Create == fsnotify.Create {
-				if strings.HasSuffix(event.Name, ".wav") {
+				// Only capture segments; submitted files may live here too
+				if isSegment(filepath.Base(event.Name), prefix) && strings.HasSuffix(event.Name, ext) {
 					if lastFile != "" && lastFile != event.Name {
 						// Enqueue previous file
-						l.fileQueue <- lastFile
+						l.queue.push(queuedFile{path: lastFile, capture: capture, live: true})
 					}
 					lastFile = event.Name
 				}
@@ -330,20 +583,156 @@ func (l *Listener) watchFiles(ctx context.Context) {
 			if !ok {
 				return
 			}
-			log.Printf("Watcher error: %v", err)
+			slog.Warn("Watching captured audio", "err", err)
+		}
+	}
+}
+
+// readSegments cuts the raw PCM ffmpeg writes to r into segments and queues
+// them in memory, tagged with capture, until r ends
+func (l *Listener) readSegments(ctx context.Context, r io.Reader, prefix, capture string) {
+	size := int(l.opts.segment().Seconds()*16000) * 2 // 16-bit samples at 16 kHz
+	for n := 0; ctx.Err() == nil; n++ {
+		pcm := make([]byte, size)
+		if _, err := io.ReadFull(r, pcm); err != nil {
+			// A partial last segment is cut off mid-word anyway
+			return
+		}
+		l.queue.push(queuedFile{pcm: pcm, name: fmt.Sprintf("%s%03d", prefix, n), capture: capture, live: true})
+	}
+}
+
+// isSegment reports whether name is a segment named prefix then its number,
+// so that "audio_" does not also take the segments of "audio_game_"
+func isSegment(name, prefix string) bool {
+	rest, ok := strings.CutPrefix(name, prefix)
+	return ok && rest != "" && rest[0] >= '0' && rest[0] <= '9'
+}
+
+// queuedFile is an audio file waiting for the transcriber, with the speaker
+// if the source knows it
+type queuedFile struct {
+	path    string
+	speaker string
+	capture string    // label of the capture source of a live segment
+	pcm     []byte    // the audio of a segment kept in memory, instead of path
+	name    string    // what to call a segment kept in memory
+	live    bool      // a capture segment rather than a submitted file
+	probe   bool      // delivered even when silent, empty or filtered
+	queued  time.Time // when it was pushed
+}
+
+// nextFile blocks until a queued file is available or the listener stops.
+// Live segments that piled up are joined into one file. A transcriber that
+// dies while idle is restarted here.
+func (l *Listener) nextFile() (queuedFile, bool) {
+	for {
+		if n := l.queue.takeDrops(); n > 0 {
+			l.publish(Status{State: StatusBehind, Dropped: n})
+		}
+		if batch, ok := l.queue.pop(); ok {
+			return l.merge(batch), true
+		}
+		select {
+		case <-l.queue.ready:
+			continue
+		case _, ok := <-l.proc.responses:
+			if ok {
+				// Output nobody asked for
+				continue
+			}
+			if !l.restartTranscriber(l.proc.exitCause()) {
+				return queuedFile{}, false
+			}
+		case <-l.ctx.Done():
+			return queuedFile{}, false
+		}
+	}
+}
+
+// merge returns batch as one file. If the segments cannot be joined the
+// newest is kept and the rest are dropped as stale.
+func (l *Listener) merge(batch []queuedFile) queuedFile {
+	if len(batch) == 1 {
+		return batch[0]
+	}
+	last := batch[len(batch)-1]
+	if last.pcm != nil {
+		// Segments kept in memory are raw samples and simply join up
+		var pcm []byte
+		for _, f := range batch {
+			pcm = append(pcm, f.pcm...)
+		}
+		last.pcm = pcm
+		last.queued = batch[0].queued
+		return last
+	}
+	path, err := coalesce(l.outputDir, batch)
+	if err != nil {
+		slog.Warn("Transcribing only the newest segment", "err", err)
+		for _, f := range batch[:len(batch)-1] {
+			os.Remove(f.path)
 		}
+		return last
 	}
+	last.path = path
+	last.queued = batch[0].queued // the oldest segment waited longest
+	return last
+}
+
+// deliver emits t unless the filter rejects it. It returns false once the
+// listener stops.
+func (l *Listener) deliver(t Transcription) bool {
+	if reason := l.opts.Filter.Reject(t); reason != "" {
+		slog.Debug("Dropped transcription", "reason", reason, "text", t.Text)
+		return true
+	}
+	return l.emit(t)
+}
+
+// emit delivers a transcription unless the listener stops first
+func (l *Listener) emit(t Transcription) bool {
+	select {
+	case l.transcriptions <- t:
+		return true
+	case <-l.ctx.Done():
+		return false
+	}
+}
+
+// workerExit runs when a worker returns. Without a worker nothing can be
+// transcribed, so the whole listener shuts down and consumers see the
+// transcriptions channel close.
+func (l *Listener) workerExit() {
+	l.cancel()
+	close(l.transcriptions)
+	close(l.workerDone)
 }
 
 func (l *Listener) worker() {
-	for path := range l.fileQueue {
+	defer l.workerExit()
+
+	for {
+		f, ok := l.nextFile()
+		if !ok {
+			return
+		}
+		path := f.path
+
 		// Wait a bit ensuring file closed
-		time.Sleep(100 * time.Millisecond)
+		if f.pcm == nil {
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-l.ctx.Done():
+				os.Remove(path)
+				return
+			}
+		}
 
 		// Check if audio is silent before transcribing
-		if l.isSilent(path) {
+		if !f.probe && l.silent(f) {
 			if strings.Contains(path, "slice_") {
-				log.Printf("Audio file '%s' is silent, skipping transcription.", filepath.Base(path))
+				slog.Debug("Audio is silent, skipping transcription", "file", filepath.Base(path))
 			}
 			os.Remove(path)
 			continue
@@ -351,37 +740,35 @@ func (l *Listener) worker() {
 
 		// Start timing for transcription
 		transcribeStart := time.Now()
+		waited := transcribeStart.Sub(f.queued)
 
 		if strings.Contains(path, "slice_") {
-			log.Printf("Sending file '%s' to transcriber...", filepath.Base(path))
+			slog.Debug("Sending audio to the transcriber", "file", filepath.Base(path))
 		}
 
-		// Send to python
-		// We hold a lock just in case, though this is the only writer
-		l.mu.Lock()
-		_, err := fmt.Fprintln(l.pythonStdin, path)
-		l.mu.Unlock()
-
+		// Send to python and read the result
+		resp, err := l.transcribe(path, f)
 		if err != nil {
-			log.Printf("Failed to send path to transcriber: %v", err)
+			// The file is dropped; the segment it covered is lost
+			os.Remove(path)
+			if !l.restartTranscriber(err) {
+				return
+			}
 			continue
 		}
-
-		// Read result
-		// Assuming strict 1:1 request/response
-		if l.pythonStdout.Scan() {
-			text := strings.TrimSpace(l.pythonStdout.Text())
-			transcribeDuration := time.Since(transcribeStart)
-			if text != "" {
-				// Include timing with transcription
-				l.transcriptions <- fmt.Sprintf("%s|%.2f", text, transcribeDuration.Seconds())
+		if resp.Error != "" {
+			slog.Warn("Transcription failed", "file", filepath.Base(path), "err", resp.Error)
+		} else if f.probe {
+			if !l.emit(resp.transcription(f, time.Since(transcribeStart), waited)) {
+				os.Remove(path)
+				return
 			}
-		} else {
-			if err := l.pythonStdout.Err(); err != nil {
-				log.Printf("Error reading from transcriber: %v", err)
+		} else if resp.Text != "" {
+			// Include timing with transcription
+			if !l.deliver(resp.transcription(f, time.Since(transcribeStart), waited)) {
+				os.Remove(path)
+				return
 			}
-			// Scanner closed?
-			return
 		}
 
 		// Remove file
@@ -389,31 +776,66 @@ func (l *Listener) worker() {
 	}
 }
 
+// SubmitFile queues a file for transcription. Files submitted after Stop
+// are removed instead.
 func (l *Listener) SubmitFile(path string) {
-	l.fileQueue <- path
+	l.SubmitSpeech(path, "")
 }
 
-func (l *Listener) Transcriptions() <-chan string {
-	return l.transcriptions
+// SubmitSpeech queues a file spoken by a known speaker. The speaker is
+// carried through to the Transcription.
+func (l *Listener) SubmitSpeech(path, speaker string) {
+	if l.ctx.Err() != nil {
+		os.Remove(path)
+		return
+	}
+	l.queue.push(queuedFile{path: path, speaker: speaker})
 }
 
-func (l *Listener) Stop() {
-	close(l.stop)
-	close(l.fileQueue)
+// SubmitProbe queues a file whose transcription is always delivered, even
+// when it is silent, empty or filtered, so its Elapsed time can be measured
+func (l *Listener) SubmitProbe(path string) {
+	l.SubmitProbeAs(path, "")
+}
 
-	if l.ffmpegCmd != nil && l.ffmpegCmd.Process != nil {
-		l.ffmpegCmd.Process.Kill()
+// SubmitProbeAs queues a probe whose Transcription carries tag as its
+// Speaker, so a caller waiting for several can tell them apart
+func (l *Listener) SubmitProbeAs(path, tag string) {
+	if l.ctx.Err() != nil {
+		os.Remove(path)
+		return
 	}
+	l.queue.push(queuedFile{path: path, speaker: tag, probe: true})
+}
 
-	if l.pythonCmd != nil && l.pythonCmd.Process != nil {
-		l.pythonCmd.Process.Kill()
-	}
+// Transcriptions returns the channel of results. It is closed once the
+// listener stops or the transcriber cannot be restarted.
+func (l *Listener) Transcriptions() <-chan Transcription {
+	return l.transcriptions
+}
 
-	os.RemoveAll(l.outputDir)
+// Stop shuts down capture and the transcriber and waits for all listener
+// goroutines to exit. It is safe to call more than once.
+func (l *Listener) Stop() {
+	l.stopOnce.Do(func() {
+		l.cancel()
+		l.StopCapture()
+
+		l.mu.Lock()
+		l.proc.kill()
+		l.mu.Unlock()
+		<-l.workerDone
+		<-l.janitorDone
+		// The worker may have replaced proc before it saw ctx
+		l.proc.kill()
+		l.proc.wait()
+
+		os.RemoveAll(l.outputDir)
+	})
 }
 
 func GetDefaultMonitorSource() string {
-	out, err := exec.Command("pactl", "get-default-sink").Output()
+	out, err := execwrap.Command("pactl", "get-default-sink").Output()
 	if err == nil {
 		sink := strings.TrimSpace(string(out))
 		if sink != "" {
@@ -423,15 +845,26 @@ func GetDefaultMonitorSource() string {
 	return "default.monitor"
 }
 
-func getWhisperModel() string {
+func (o Options) whisperModel() string {
+	if o.Model != "" {
+		return o.Model
+	}
 	return translator.DefaultWhisperModel
 }
 
+// silent reports whether f is too quiet to hold speech
+func (l *Listener) silent(f queuedFile) bool {
+	if f.pcm != nil {
+		return pcmLoudness(f.pcm) < SilentLevel
+	}
+	return l.isSilent(f.path)
+}
+
 func (l *Listener) isSilent(path string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(l.ctx, 5*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffmpeg",
+	cmd := execwrap.CommandContext(ctx, "ffmpeg",
 		"-i", path,
 		"-af", "volumedetect",
 		"-f", "null", "-",
@@ -442,17 +875,6 @@ func (l *Listener) isSilent(path string) bool {
 		return false
 	}
 
-	output := string(out)
-
-	if idx := strings.Index(output, "mean_volume:"); idx != -1 {
-		volumeStr := output[idx+12:]
-		if end := strings.Index(volumeStr, " dB"); end != -1 {
-			volumeStr = volumeStr[:end]
-			if vol, err := strconv.ParseFloat(strings.TrimSpace(volumeStr), 64); err == nil {
-				return vol < -50
-			}
-		}
-	}
-
-	return false
+	mean, ok := parseVolume(string(out), "mean_volume:")
+	return ok && mean < SilentLevel
 }
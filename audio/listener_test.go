@@ -0,0 +1,190 @@
+package audio_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/fakegame"
+)
+
+// Live segments are written as FLAC files when asked, and with pipe none are
+// written: ffmpeg's raw output is cut into segments in memory and sent to
+// the transcriber in the request
+func TestIntermediate(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	answers := map[string]fakegame.Answer{
+		"audio_000": {Text: "rush b", Language: "en"},
+		"audio_001": {Text: "rush b", Language: "en"},
+	}
+	answersPath := filepath.Join(dir, "answers.json")
+	if err := fakegame.WriteAnswers(answersPath, answers); err != nil {
+		t.Fatal(err)
+	}
+	newListener := func(intermediate string) (*audio.Listener, error) {
+		return audio.NewCommandListener(func() *exec.Cmd {
+			return fakegame.TranscriberCommand(answersPath)
+		}, audio.Options{Segment: 500 * time.Millisecond, Intermediate: intermediate})
+	}
+	if _, err := newListener("mp3"); err == nil {
+		t.Fatalf("an unknown intermediate format was accepted")
+	}
+	// transcription waits for the first one from l
+	transcription := func(l *audio.Listener) (audio.Transcription, error) {
+		select {
+		case heard, ok := <-l.Transcriptions():
+			if !ok {
+				return heard, fmt.Errorf("listener stopped")
+			}
+			return heard, nil
+		case <-time.After(testTimeout):
+			return audio.Transcription{}, fmt.Errorf("no transcription within %s", testTimeout)
+		}
+	}
+	// Raw samples of 0x2020, -12 dB: half a second makes a segment
+	loud := strings.Repeat(" ", 2*16000+100)
+
+	flac, err := newListener(audio.IntermediateFLAC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer flac.Stop()
+	flacCapture := "ffmpeg -f pulse -i game.monitor -f segment -segment_time 0.5 -c:a flac -ar 16000 -ac 1 -reset_timestamps 1 " +
+		filepath.Join(flac.OutputDir(), "audio_%03d.flac")
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"pactl list sources short": {Stdout: "1\tgame.monitor\tmodule-null-sink.c\n"},
+		flacCapture:                {Wait: time.Minute},
+		"ffmpeg -f pulse -i game.monitor -f s16le -ar 16000 -ac 1 pipe:1": {Stdout: loud, Wait: time.Minute},
+	}}
+	defer execwrap.Use(fake)()
+
+	if err := flac.Start(ctx, "game.monitor"); err != nil {
+		t.Fatal(err)
+	}
+	if !fake.Ran(strings.Fields(flacCapture)...) {
+		t.Fatalf("ffmpeg does not write FLAC segments: %v", fake.Calls())
+	}
+	time.Sleep(200 * time.Millisecond) // the watcher is up
+	for _, name := range []string{"audio_000.flac", "audio_001.flac"} {
+		if err := os.WriteFile(filepath.Join(flac.OutputDir(), name), []byte("fLaC"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if heard, err := transcription(flac); err != nil {
+		t.Fatalf("flac: %v", err)
+	} else if heard.Text != "rush b" {
+		t.Fatalf("flac segment transcribed as %q", heard.Text)
+	}
+	flac.Stop()
+
+	pipe, err := newListener(audio.IntermediatePipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pipe.Stop()
+	if err := pipe.Start(ctx, "game.monitor"); err != nil {
+		t.Fatal(err)
+	}
+	heard, err := transcription(pipe)
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	if heard.Text != "rush b" {
+		t.Fatalf("piped segment transcribed as %q", heard.Text)
+	}
+	// The folder only holds the file naming its owner
+	if files, _ := os.ReadDir(pipe.OutputDir()); len(files) > 1 {
+		t.Fatalf("piped capture wrote %s", files[len(files)-1].Name())
+	}
+}
+
+// Capture started, restarted on another device and stopped again and
+// again while clips keep coming shuts down cleanly each time: Stop returns
+// and the transcriptions channel is closed. Run with -race.
+func TestStartRestartStop(t *testing.T) {
+	ctx := t.Context()
+	answersPath := filepath.Join(t.TempDir(), "answers.json")
+	if err := fakegame.WriteAnswers(answersPath, map[string]fakegame.Answer{}); err != nil {
+		t.Fatal(err)
+	}
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"pactl list sources short": {Stdout: "1\tgame.monitor\tmodule-null-sink.c\n2\tdiscord.monitor\tmodule-null-sink.c\n"},
+	}}
+	defer execwrap.Use(fake)()
+
+	for i := range 5 {
+		l, err := audio.NewCommandListener(func() *exec.Cmd {
+			return fakegame.TranscriberCommand(answersPath)
+		}, audio.Options{Segment: 2 * time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		capture := func(device string) string {
+			return "ffmpeg -f pulse -i " + device + " -f segment -segment_time 2 -c:a pcm_s16le -ar 16000 -ac 1 -reset_timestamps 1 " +
+				filepath.Join(l.OutputDir(), "audio_%03d.wav")
+		}
+		// No command of the last listener is running any more
+		fake.Responses[capture("game.monitor")] = execwrap.Response{Wait: time.Minute}
+		fake.Responses[capture("discord.monitor")] = execwrap.Response{Wait: time.Minute}
+
+		drained := make(chan struct{})
+		go func() {
+			defer close(drained)
+			for range l.Transcriptions() {
+			}
+		}()
+		// Clips keep coming from the capture and from outside it; writes
+		// fail once Stop has removed the folder
+		stop := make(chan struct{})
+		fed := make(chan struct{})
+		go func() {
+			defer close(fed)
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+				os.WriteFile(filepath.Join(l.OutputDir(), fmt.Sprintf("audio_%03d.wav", n)), []byte("RIFF"), 0o644)
+				clip := filepath.Join(l.OutputDir(), fmt.Sprintf("api_%d.wav", n))
+				os.WriteFile(clip, []byte("RIFF"), 0o644)
+				l.SubmitFile(clip)
+			}
+		}()
+
+		if err := l.Start(ctx, "game.monitor"); err != nil {
+			t.Fatalf("round %d: start: %v", i, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if err := l.Restart(ctx, "discord.monitor"); err != nil {
+			t.Fatalf("round %d: restart: %v", i, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if i%2 == 1 {
+			// Stopped while capturing or after its capture was stopped
+			l.StopCapture()
+		}
+		l.Stop()
+		close(stop)
+		<-fed
+
+		select {
+		case <-drained:
+		case <-time.After(testTimeout):
+			t.Fatalf("round %d: transcriptions still open after Stop", i)
+		}
+		for _, device := range []string{"game.monitor", "discord.monitor"} {
+			if !fake.Ran(strings.Fields(capture(device))...) {
+				t.Fatalf("round %d: %s was not captured: %v", i, device, fake.Calls())
+			}
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package audio_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/fakegame"
+)
+
+// testTimeout bounds the wait for a transcription, the listener to shut
+// down or the janitor to sweep
+const testTimeout = 10 * time.Second
+
+func TestMain(m *testing.M) {
+	// Commands faked by execwrap and the canned transcriber run the test
+	// binary again to answer
+	execwrap.ServeFake()
+	fakegame.ServeTranscriberCommand()
+	os.Exit(m.Run())
+}
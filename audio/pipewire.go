@@ -0,0 +1,228 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// Device prefixes beyond plain source names. "pw:" names a PipeWire node by
+// node.name or object.serial, "app:" the playback of one application, matched
+// by process binary or application name.
+const (
+	PipeWireNodePrefix = "pw:"
+	AppDevicePrefix    = "app:"
+)
+
+// Input is what ffmpeg records from. When Target is set, pw-record captures
+// that "pw:" or "app:" device and Args read its raw samples from file
+// descriptor 3.
+type Input struct {
+	Args   []string // ffmpeg input options
+	Target string   // "pw:" or "app:" device recorded by pw-record
+}
+
+// PipeWireInput records a "pw:" or "app:" device through pw-record
+func PipeWireInput(device string) Input {
+	return Input{
+		Args:   []string{"-f", "s16le", "-ar", "16000", "-ac", "1", "-i", "pipe:3"},
+		Target: device,
+	}
+}
+
+// IsPipeWireDevice reports whether device names a PipeWire node rather than
+// a PulseAudio source
+func IsPipeWireDevice(device string) bool {
+	return strings.HasPrefix(device, PipeWireNodePrefix)
+}
+
+// IsAppDevice reports whether device names an application, see AppInput
+func IsAppDevice(device string) bool {
+	return strings.HasPrefix(device, AppDevicePrefix)
+}
+
+// Start starts cmd, an ffmpeg command built from in.Args. For PipeWire
+// targets pw-record is started first and feeds ffmpeg through a pipe, so
+// either one exiting ends the other.
+func (in Input) Start(cmd *exec.Cmd) error {
+	if in.Target == "" {
+		return cmd.Start()
+	}
+	feedArgs, err := pipeWireFeed(in.Target)
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	feed := execwrap.Command("pw-record", feedArgs...)
+	feed.Stdout = w
+	if err := feed.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return fmt.Errorf("failed to start pw-record: %w", err)
+	}
+	w.Close()
+
+	// pipe:3 in Args; ExtraFiles start at descriptor 3
+	cmd.ExtraFiles = []*os.File{r}
+	err = cmd.Start()
+	r.Close()
+	if err != nil {
+		feed.Process.Kill()
+		feed.Wait()
+		return err
+	}
+	// pw-record gets SIGPIPE once ffmpeg is gone
+	go feed.Wait()
+	return nil
+}
+
+// PipeWireNode is an audio node from pw-dump
+type PipeWireNode struct {
+	ID          int
+	Serial      string
+	Name        string // node.name
+	Description string
+	Class       string // media.class, e.g. Audio/Sink or Stream/Output/Audio
+	AppName     string
+	AppBinary   string
+}
+
+// Device returns the -audiodevice value that captures the node
+func (n PipeWireNode) Device() string {
+	if n.IsAppStream() {
+		if n.AppBinary != "" {
+			return AppDevicePrefix + n.AppBinary
+		}
+		return AppDevicePrefix + n.AppName
+	}
+	if n.Name != "" {
+		return PipeWireNodePrefix + n.Name
+	}
+	return PipeWireNodePrefix + n.Serial
+}
+
+// IsAppStream reports whether the node is an application's playback
+func (n PipeWireNode) IsAppStream() bool {
+	return n.Class == "Stream/Output/Audio"
+}
+
+// PipeWireAvailable reports whether pw-dump and pw-record are installed
+func PipeWireAvailable() bool {
+	if _, err := execwrap.LookPath("pw-record"); err != nil {
+		return false
+	}
+	_, err := execwrap.LookPath("pw-dump")
+	return err == nil
+}
+
+// PipeWireNodes lists sinks, sources and application playback streams
+func PipeWireNodes() ([]PipeWireNode, error) {
+	out, err := execwrap.Command("pw-dump").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pw-dump failed: %w", err)
+	}
+	return parsePipeWireDump(out)
+}
+
+func parsePipeWireDump(data []byte) ([]PipeWireNode, error) {
+	var objects []struct {
+		ID   int    `json:"id"`
+		Type string `json:"type"`
+		Info struct {
+			Props map[string]any `json:"props"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("invalid pw-dump output: %w", err)
+	}
+
+	var nodes []PipeWireNode
+	for _, o := range objects {
+		if o.Type != "PipeWire:Interface:Node" {
+			continue
+		}
+		p := o.Info.Props
+		n := PipeWireNode{
+			ID:          o.ID,
+			Serial:      propString(p, "object.serial"),
+			Name:        propString(p, "node.name"),
+			Description: propString(p, "node.description"),
+			Class:       propString(p, "media.class"),
+			AppName:     propString(p, "application.name"),
+			AppBinary:   propString(p, "application.process.binary"),
+		}
+		switch n.Class {
+		case "Audio/Sink", "Audio/Source", "Audio/Source/Virtual", "Stream/Output/Audio":
+			nodes = append(nodes, n)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// propString reads a property that pw-dump may print as string or number
+func propString(props map[string]any, key string) string {
+	switch v := props[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return ""
+}
+
+// FindPipeWireNode resolves a "pw:" or "app:" device to its node
+func FindPipeWireNode(device string) (PipeWireNode, error) {
+	nodes, err := PipeWireNodes()
+	if err != nil {
+		return PipeWireNode{}, err
+	}
+	return matchPipeWireNode(nodes, device)
+}
+
+func matchPipeWireNode(nodes []PipeWireNode, device string) (PipeWireNode, error) {
+	if app, ok := strings.CutPrefix(device, AppDevicePrefix); ok {
+		for _, n := range nodes {
+			if n.IsAppStream() && (strings.EqualFold(n.AppBinary, app) || strings.EqualFold(n.AppName, app)) {
+				return n, nil
+			}
+		}
+		return PipeWireNode{}, fmt.Errorf("%w: no PipeWire playback stream of '%s' (is it running and playing sound?)", ErrNoMonitorSource, app)
+	}
+	name := strings.TrimPrefix(device, PipeWireNodePrefix)
+	for _, n := range nodes {
+		if n.Name == name || n.Serial == name {
+			return n, nil
+		}
+	}
+	return PipeWireNode{}, fmt.Errorf("%w: no PipeWire node '%s'", ErrNoMonitorSource, name)
+}
+
+// pipeWireFeed returns the pw-record arguments that write device as raw
+// 16 kHz mono samples to stdout
+func pipeWireFeed(device string) ([]string, error) {
+	n, err := FindPipeWireNode(device)
+	if err != nil {
+		return nil, err
+	}
+	target := n.Serial
+	if target == "" {
+		target = strconv.Itoa(n.ID)
+	}
+	args := []string{"--target", target, "--rate", "16000", "--channels", "1", "--format", "s16"}
+	if n.Class == "Audio/Sink" {
+		// Record what the sink plays rather than waiting for input
+		args = append(args, "-P", "{ stream.capture.sink = true }")
+	}
+	return append(args, "-"), nil
+}
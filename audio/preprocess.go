@@ -0,0 +1,38 @@
+package audio
+
+import "strings"
+
+// Preprocess is the ffmpeg filter chain applied to captured audio before it
+// is transcribed
+type Preprocess struct {
+	BandPass  bool   // keep the 300-3400 Hz voice band
+	Denoise   bool   // FFT denoiser against steady game and fan noise
+	Normalize bool   // single-pass loudness normalization
+	Custom    string // extra -af chain, applied last
+}
+
+// Chain returns the -af filter graph, or "" when nothing is enabled
+func (p Preprocess) Chain() string {
+	var filters []string
+	if p.BandPass {
+		filters = append(filters, "highpass=f=300", "lowpass=f=3400")
+	}
+	if p.Denoise {
+		filters = append(filters, "afftdn=nf=-25")
+	}
+	if p.Normalize {
+		filters = append(filters, "loudnorm=I=-16:TP=-1.5:LRA=11")
+	}
+	if c := strings.TrimSpace(p.Custom); c != "" {
+		filters = append(filters, c)
+	}
+	return strings.Join(filters, ",")
+}
+
+// Args returns the ffmpeg output options for the chain
+func (p Preprocess) Args() []string {
+	if chain := p.Chain(); chain != "" {
+		return []string{"-af", chain}
+	}
+	return nil
+}
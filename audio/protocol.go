@@ -0,0 +1,174 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ProtocolVersion is the JSON-lines protocol spoken with transcriber.py.
+// Version 2 added requests carrying their audio as pcm instead of a path.
+const ProtocolVersion = 2
+
+// Whisper tasks
+const (
+	TaskTranscribe = "transcribe"
+	TaskTranslate  = "translate" // Whisper translates to English itself
+)
+
+// Options configure the transcriber. Model is loaded at startup; the rest is
+// passed with every request.
+type Options struct {
+	Model string // Whisper model name (empty: translator.DefaultWhisperModel)
+
+	// Languages restricts detection to these Whisper codes; a single entry
+	// skips detection entirely
+	Languages []string
+	Task      string
+
+	// Filter drops transcriptions that are probably not speech
+	Filter Filter
+
+	// Preprocess filters captured audio before it is transcribed
+	Preprocess Preprocess
+
+	// MaxBacklog drops live segments that waited longer than this for the
+	// transcriber (0: DefaultMaxBacklog)
+	MaxBacklog time.Duration
+
+	// Segment is the length of live capture segments (0: DefaultSegment)
+	Segment time.Duration
+
+	// Intermediate is how live segments reach the transcriber (empty:
+	// IntermediateWAV)
+	Intermediate string
+
+	// TempMax caps the bytes of audio files in the listener's folder; the
+	// oldest go first (0: DefaultTempMax)
+	TempMax int64
+	// TempMaxAge deletes audio files left this long (0: DefaultTempMaxAge)
+	TempMaxAge time.Duration
+	// JanitorInterval is how often both are enforced (0:
+	// DefaultJanitorInterval)
+	JanitorInterval time.Duration
+}
+
+// Intermediate formats of live segments
+const (
+	IntermediateWAV  = "wav"  // 16-bit PCM files
+	IntermediateFLAC = "flac" // lossless files of about half the size
+	IntermediateOpus = "opus" // lossy files of about a twentieth
+	// IntermediatePipe keeps segments in memory: ffmpeg's output is read
+	// from a pipe and sent over the transcriber's stdin
+	IntermediatePipe = "pipe"
+)
+
+// segmentFormat is the ffmpeg codec and the file extension of segments
+// written as o.Intermediate
+func (o Options) segmentFormat() (codec []string, ext string) {
+	switch o.Intermediate {
+	case IntermediateFLAC:
+		return []string{"-c:a", "flac"}, ".flac"
+	case IntermediateOpus:
+		// Speech needs far less than Opus's default bitrate
+		return []string{"-c:a", "libopus", "-b:a", "24k"}, ".opus"
+	}
+	return []string{"-c:a", "pcm_s16le"}, ".wav"
+}
+
+// DefaultSegment is the length of live capture segments. Longer segments give
+// Whisper more context but add their length to the latency.
+const DefaultSegment = 2 * time.Second
+
+func (o Options) segment() time.Duration {
+	if o.Segment <= 0 {
+		return DefaultSegment
+	}
+	return o.Segment
+}
+
+func (o Options) validate() error {
+	switch o.Intermediate {
+	case "", IntermediateWAV, IntermediateFLAC, IntermediateOpus, IntermediatePipe:
+	default:
+		return fmt.Errorf("unknown intermediate format %q (use %s, %s, %s or %s)", o.Intermediate, IntermediateWAV, IntermediateFLAC, IntermediateOpus, IntermediatePipe)
+	}
+	switch o.Task {
+	case "", TaskTranscribe, TaskTranslate:
+		return nil
+	}
+	return fmt.Errorf("unknown whisper task %q (use %s or %s)", o.Task, TaskTranscribe, TaskTranslate)
+}
+
+// Transcription is one transcribed audio file
+type Transcription struct {
+	ID           uint64
+	Speaker      string // who spoke, when the audio source knows it
+	Capture      string // label of the capture source, e.g. "discord"; "" for one unlabelled device
+	Text         string
+	Language     string        // detected source language, e.g. "de"
+	Task         string        // TaskTranslate when Text is already English
+	Confidence   float64       // 0..1, from Whisper's average log probability
+	AvgLogProb   float64       // Whisper's average token log probability
+	NoSpeechProb float64       // Whisper's estimate that the audio holds no speech
+	Duration     time.Duration // length of the audio
+	Elapsed      time.Duration // time spent transcribing
+	Waited       time.Duration // time the audio waited for the transcriber
+}
+
+// request is one line written to the transcriber. Audio is either the file
+// at Path or PCM, 16-bit mono samples at 16 kHz, named Name in messages.
+type request struct {
+	ID        uint64   `json:"id"`
+	Path      string   `json:"path,omitempty"`
+	PCM       []byte   `json:"pcm,omitempty"` // base64 in the JSON line
+	Name      string   `json:"name,omitempty"`
+	Languages []string `json:"languages,omitempty"`
+	Task      string   `json:"task,omitempty"`
+}
+
+// response is one line read from the transcriber. Type is "ready" for the
+// handshake and "result" for answers to a request.
+type response struct {
+	Type         string  `json:"type"`
+	Protocol     int     `json:"protocol,omitempty"`
+	ID           uint64  `json:"id"`
+	Text         string  `json:"text"`
+	Language     string  `json:"language"`
+	Task         string  `json:"task"`
+	Confidence   float64 `json:"confidence"`
+	AvgLogProb   float64 `json:"avg_logprob"`
+	NoSpeechProb float64 `json:"no_speech_prob"`
+	Duration     float64 `json:"duration"`
+	Error        string  `json:"error"`
+}
+
+// parseResponse decodes a protocol line. Anything else the transcriber
+// prints (library noise, debug output) is reported as not ok.
+func parseResponse(line []byte) (response, bool) {
+	var resp response
+	if len(line) == 0 || line[0] != '{' {
+		return resp, false
+	}
+	if err := json.Unmarshal(line, &resp); err != nil || resp.Type == "" {
+		return resp, false
+	}
+	return resp, true
+}
+
+func (r response) transcription(f queuedFile, elapsed, waited time.Duration) Transcription {
+	return Transcription{
+		ID:           r.ID,
+		Speaker:      f.speaker,
+		Capture:      f.capture,
+		Text:         r.Text,
+		Language:     r.Language,
+		Task:         r.Task,
+		Confidence:   r.Confidence,
+		AvgLogProb:   r.AvgLogProb,
+		NoSpeechProb: r.NoSpeechProb,
+		Duration:     time.Duration(r.Duration * float64(time.Second)),
+		Elapsed:      elapsed,
+		Waited:       waited,
+	}
+}
@@ -0,0 +1,193 @@
+//go:build !windows
+// +build !windows
+
+package audio
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// pulseAppSink is the private sink an application is moved to so it can be
+// recorded alone. A loopback plays it on the original sink, so the user keeps
+// hearing it.
+const pulseAppSink = "cs_translate_app"
+
+var pulseApp struct {
+	mu      sync.Mutex
+	modules []string          // loaded module indexes, unloaded in reverse
+	moved   map[string]string // sink input index -> sink it came from
+}
+
+// pulseSinkInput is one playback stream from 'pactl list sink-inputs'
+type pulseSinkInput struct {
+	Index  string
+	Sink   string // sink index
+	Name   string // application.name
+	Binary string // application.process.binary
+}
+
+func pulseSinkInputs() ([]pulseSinkInput, error) {
+	out, err := execwrap.Command("pactl", "list", "sink-inputs").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pactl failed: %w", err)
+	}
+	return parsePulseSinkInputs(string(out)), nil
+}
+
+func parsePulseSinkInputs(out string) []pulseSinkInput {
+	var inputs []pulseSinkInput
+	var cur *pulseSinkInput
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if idx, ok := strings.CutPrefix(line, "Sink Input #"); ok {
+			inputs = append(inputs, pulseSinkInput{Index: idx})
+			cur = &inputs[len(inputs)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if sink, ok := strings.CutPrefix(line, "Sink: "); ok {
+			cur.Sink = sink
+		} else if v, ok := pulseProp(line, "application.name"); ok {
+			cur.Name = v
+		} else if v, ok := pulseProp(line, "application.process.binary"); ok {
+			cur.Binary = v
+		}
+	}
+	return inputs
+}
+
+// pulseProp reads a `key = "value"` property line
+func pulseProp(line, key string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, key+" = ")
+	if !ok {
+		return "", false
+	}
+	return strings.Trim(rest, `"`), true
+}
+
+// findPulseSinkInput returns the first playback stream of app, matched by
+// process binary or application name
+func findPulseSinkInput(app string) (pulseSinkInput, error) {
+	inputs, err := pulseSinkInputs()
+	if err != nil {
+		return pulseSinkInput{}, err
+	}
+	for _, in := range inputs {
+		if strings.EqualFold(in.Binary, app) || strings.EqualFold(in.Name, app) {
+			return in, nil
+		}
+	}
+	return pulseSinkInput{}, fmt.Errorf("%w: no PulseAudio playback stream of '%s' (is it running and playing sound?)", ErrNoMonitorSource, app)
+}
+
+// pulseSinkName maps a sink index to its name
+func pulseSinkName(index string) (string, bool) {
+	out, err := execwrap.Command("pactl", "list", "sinks", "short").Output()
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) >= 2 && (parts[0] == index || parts[1] == index) {
+			return parts[1], true
+		}
+	}
+	return "", false
+}
+
+func loadPulseModule(args ...string) error {
+	out, err := execwrap.Command("pactl", append([]string{"load-module"}, args...)...).Output()
+	if err != nil {
+		return fmt.Errorf("pactl load-module %s failed: %w", args[0], err)
+	}
+	pulseApp.modules = append(pulseApp.modules, strings.TrimSpace(string(out)))
+	return nil
+}
+
+// routePulseApp moves app to the private sink and returns the source that
+// records it
+func routePulseApp(app string) (string, error) {
+	in, err := findPulseSinkInput(app)
+	if err != nil {
+		return "", err
+	}
+
+	pulseApp.mu.Lock()
+	defer pulseApp.mu.Unlock()
+
+	if _, ok := pulseSinkName(pulseAppSink); !ok {
+		original, ok := pulseSinkName(in.Sink)
+		if !ok {
+			return "", fmt.Errorf("sink %s of '%s' not found", in.Sink, app)
+		}
+		if err := loadPulseModule("module-null-sink", "sink_name="+pulseAppSink, "sink_properties=device.description=cs-translate-"+app); err != nil {
+			return "", err
+		}
+		if err := loadPulseModule("module-loopback", "source="+pulseAppSink+".monitor", "sink="+original, "latency_msec=30"); err != nil {
+			releasePulseAppLocked()
+			return "", err
+		}
+	}
+	if _, ok := pulseApp.moved[in.Index]; !ok {
+		if err := execwrap.Command("pactl", "move-sink-input", in.Index, pulseAppSink).Run(); err != nil {
+			return "", fmt.Errorf("failed to move '%s' to %s: %w", app, pulseAppSink, err)
+		}
+		if pulseApp.moved == nil {
+			pulseApp.moved = map[string]string{}
+		}
+		pulseApp.moved[in.Index] = in.Sink
+	}
+	return pulseAppSink + ".monitor", nil
+}
+
+// ReleaseAppCapture moves applications back to their sinks and removes the
+// private sink
+func ReleaseAppCapture() {
+	pulseApp.mu.Lock()
+	defer pulseApp.mu.Unlock()
+	releasePulseAppLocked()
+}
+
+func releasePulseAppLocked() {
+	for index, sink := range pulseApp.moved {
+		execwrap.Command("pactl", "move-sink-input", index, sink).Run()
+	}
+	pulseApp.moved = nil
+	for i := len(pulseApp.modules) - 1; i >= 0; i-- {
+		execwrap.Command("pactl", "unload-module", pulseApp.modules[i]).Run()
+	}
+	pulseApp.modules = nil
+}
+
+// AppInput records only the program named by an "app:" device: straight
+// from its PipeWire stream when pw-record is available, otherwise through a
+// private PulseAudio sink
+func AppInput(device string) (Input, error) {
+	if PipeWireAvailable() {
+		if _, err := FindPipeWireNode(device); err != nil {
+			return Input{}, err
+		}
+		return PipeWireInput(device), nil
+	}
+	source, err := routePulseApp(strings.TrimPrefix(device, AppDevicePrefix))
+	if err != nil {
+		return Input{}, err
+	}
+	return Input{Args: []string{"-f", "pulse", "-i", source}}, nil
+}
+
+// checkApp reports whether an "app:" device can be recorded right now
+func checkApp(device string) error {
+	if PipeWireAvailable() {
+		_, err := FindPipeWireNode(device)
+		return err
+	}
+	_, err := findPulseSinkInput(strings.TrimPrefix(device, AppDevicePrefix))
+	return err
+}
@@ -0,0 +1,158 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+const (
+	// DefaultMaxBacklog is how long a live segment may wait for the
+	// transcriber before it is dropped as stale
+	DefaultMaxBacklog = 10 * time.Second
+
+	// maxLiveQueue bounds waiting live segments; the oldest goes first
+	maxLiveQueue = 16
+	// maxSubmittedQueue bounds waiting submitted files (F9 captures, talk,
+	// Discord speakers)
+	maxSubmittedQueue = 100
+	// coalesceMax live segments waiting together are transcribed as one file
+	coalesceMax = 3
+	// dropReportInterval limits how often drops are reported
+	dropReportInterval = 10 * time.Second
+)
+
+// fileQueue holds audio waiting for the transcriber. Submitted files are
+// served before live segments and are only dropped when their own bound is
+// reached. Live segments are dropped when they are older than the backlog
+// limit or when too many pile up, since a late live translation is worth
+// less than a current one.
+type fileQueue struct {
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	live      []queuedFile
+	submitted []queuedFile
+	dropped   int // since the last report
+	reported  time.Time
+
+	ready chan struct{} // signalled while the queue is not empty
+}
+
+func newFileQueue(maxAge time.Duration) *fileQueue {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxBacklog
+	}
+	return &fileQueue{maxAge: maxAge, ready: make(chan struct{}, 1)}
+}
+
+// push adds f, dropping the oldest file of its kind when the queue is full
+func (q *fileQueue) push(f queuedFile) {
+	f.queued = time.Now()
+	q.mu.Lock()
+	if f.live {
+		q.live = append(q.live, f)
+		if len(q.live) > maxLiveQueue {
+			q.drop(q.live[0])
+			q.live = q.live[1:]
+		}
+	} else {
+		q.submitted = append(q.submitted, f)
+		if len(q.submitted) > maxSubmittedQueue {
+			q.drop(q.submitted[0])
+			q.submitted = q.submitted[1:]
+		}
+	}
+	q.mu.Unlock()
+	q.signal()
+}
+
+// pop returns the next submitted file, or up to coalesceMax live segments
+// of one capture source that are not stale yet. ok is false when nothing is waiting.
+func (q *fileQueue) pop() (batch []queuedFile, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	defer func() {
+		if len(q.live)+len(q.submitted) > 0 {
+			q.signal()
+		}
+	}()
+
+	if len(q.submitted) > 0 {
+		f := q.submitted[0]
+		q.submitted = q.submitted[1:]
+		return []queuedFile{f}, true
+	}
+	now := time.Now()
+	for len(q.live) > 0 && now.Sub(q.live[0].queued) > q.maxAge {
+		q.drop(q.live[0])
+		q.live = q.live[1:]
+	}
+	if len(q.live) == 0 {
+		return nil, false
+	}
+	// Only segments of the same capture source are joined
+	capture := q.live[0].capture
+	var rest []queuedFile
+	for _, f := range q.live {
+		if len(batch) < coalesceMax && f.capture == capture {
+			batch = append(batch, f)
+		} else {
+			rest = append(rest, f)
+		}
+	}
+	q.live = rest
+	return batch, true
+}
+
+// drop removes a file that will not be transcribed; q.mu must be held
+func (q *fileQueue) drop(f queuedFile) {
+	os.Remove(f.path)
+	q.dropped++
+}
+
+// takeDrops returns how many files were dropped since the last call, at most
+// once per dropReportInterval
+func (q *fileQueue) takeDrops() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.dropped == 0 || time.Since(q.reported) < dropReportInterval {
+		return 0
+	}
+	n := q.dropped
+	q.dropped = 0
+	q.reported = time.Now()
+	return n
+}
+
+func (q *fileQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// coalesce joins live segments into one file in dir so the transcriber is
+// called once for the backlog. The segments are removed on success.
+func coalesce(dir string, batch []queuedFile) (string, error) {
+	out := filepath.Join(dir, fmt.Sprintf("merged_%d.wav", time.Now().UnixNano()))
+	var args []string
+	for _, f := range batch {
+		args = append(args, "-i", f.path)
+	}
+	args = append(args,
+		"-filter_complex", fmt.Sprintf("concat=n=%d:v=0:a=1", len(batch)),
+		"-c:a", "pcm_s16le", "-ar", "16000", "-ac", "1", "-y", out)
+	if err := execwrap.Command("ffmpeg", args...).Run(); err != nil {
+		os.Remove(out)
+		return "", fmt.Errorf("failed to join %d segments: %w", len(batch), err)
+	}
+	for _, f := range batch {
+		os.Remove(f.path)
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// StatusState is a stage in the transcriber lifecycle
+type StatusState string
+
+const (
+	StatusCrashed    StatusState = "crashed"
+	StatusRestarting StatusState = "restarting"
+	StatusReady      StatusState = "ready"
+	StatusFailed     StatusState = "failed"
+	StatusBehind     StatusState = "behind" // queued audio was dropped
+)
+
+// Status reports a change in transcriber health
+type Status struct {
+	State   StatusState
+	Attempt int   // restart attempt, starting at 1
+	Err     error // why the transcriber died or the restart failed
+	Dropped int   // audio files dropped since the last StatusBehind
+}
+
+func (s Status) String() string {
+	switch s.State {
+	case StatusRestarting:
+		return fmt.Sprintf("transcriber restarting (attempt %d/%d)", s.Attempt, maxRestartAttempts)
+	case StatusReady:
+		return "transcriber ready again"
+	case StatusBehind:
+		return fmt.Sprintf("transcriber falling behind: dropped %d stale audio segment(s)", s.Dropped)
+	default:
+		if s.Err != nil {
+			return fmt.Sprintf("transcriber %s: %v", s.State, s.Err)
+		}
+		return "transcriber " + string(s.State)
+	}
+}
+
+const (
+	statusBuffer       = 16
+	maxRestartAttempts = 5
+	restartBackoff     = time.Second
+	maxRestartBackoff  = 30 * time.Second
+)
+
+// Status returns transcriber health events. Events are dropped when nobody
+// keeps up, so the channel never stalls transcription.
+func (l *Listener) Status() <-chan Status {
+	return l.status
+}
+
+func (l *Listener) publish(s Status) {
+	select {
+	case l.status <- s:
+	default:
+	}
+}
+
+// transcribe sends one path, or the audio f keeps in memory, to the
+// transcriber and waits for the answer
+// with the same id. An error means the transcriber is gone and has to be
+// restarted; a failure for this file alone is reported in the response.
+func (l *Listener) transcribe(path string, f queuedFile) (response, error) {
+	l.proc.nextID++
+	id := l.proc.nextID
+	req := request{ID: id, Path: path, Languages: l.opts.Languages, Task: l.opts.Task}
+	if f.pcm != nil {
+		req.Path, req.PCM, req.Name = "", f.pcm, f.name
+	}
+	line, _ := json.Marshal(req)
+
+	l.mu.Lock()
+	_, err := fmt.Fprintf(l.proc.stdin, "%s\n", line)
+	l.mu.Unlock()
+	if err != nil {
+		return response{}, l.proc.exitCause()
+	}
+
+	for {
+		resp, ok := <-l.proc.responses
+		if !ok {
+			return response{}, l.proc.exitCause()
+		}
+		if resp.Type == "result" && resp.ID == id {
+			resp.Text = strings.TrimSpace(resp.Text)
+			return resp, nil
+		}
+		slog.Debug("Ignoring transcriber message", "type", resp.Type, "id", resp.ID)
+	}
+}
+
+// restartTranscriber replaces a dead transcriber, backing off between
+// attempts. It returns false once the listener stops or every attempt failed.
+func (l *Listener) restartTranscriber(cause error) bool {
+	if l.ctx.Err() != nil {
+		return false
+	}
+	slog.Warn("Transcriber died", "err", cause)
+	l.publish(Status{State: StatusCrashed, Err: cause})
+
+	backoff := restartBackoff
+	for attempt := 1; attempt <= maxRestartAttempts; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-l.ctx.Done():
+			return false
+		}
+
+		l.publish(Status{State: StatusRestarting, Attempt: attempt})
+		proc, err := l.spawn()
+		if err != nil {
+			slog.Warn("Transcriber restart failed", "attempt", attempt, "err", err)
+			cause = err
+			backoff = min(backoff*2, maxRestartBackoff)
+			continue
+		}
+
+		l.mu.Lock()
+		if l.ctx.Err() != nil {
+			l.mu.Unlock()
+			proc.kill()
+			proc.wait()
+			return false
+		}
+		l.proc = proc
+		l.mu.Unlock()
+
+		slog.Info("Transcriber restarted", "attempts", attempt)
+		l.publish(Status{State: StatusReady, Attempt: attempt})
+		return true
+	}
+
+	l.publish(Status{State: StatusFailed, Err: cause})
+	return false
+}
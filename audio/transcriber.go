@@ -0,0 +1,183 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const stderrTailSize = 4096
+
+// stderrTail forwards transcriber stderr to our own while keeping the most
+// recent output, so startup failures can be classified
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (s *stderrTail) Write(p []byte) (int, error) {
+	os.Stderr.Write(p)
+
+	s.mu.Lock()
+	s.buf = append(s.buf, p...)
+	if len(s.buf) > stderrTailSize {
+		s.buf = s.buf[len(s.buf)-stderrTailSize:]
+	}
+	s.mu.Unlock()
+	return len(p), nil
+}
+
+func (s *stderrTail) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.buf)
+}
+
+// waitReady consumes transcriber output until the ready message. If the
+// process exits first, the captured stderr is used to explain why.
+func waitReady(scanner *bufio.Scanner, cmd *exec.Cmd, stderr *stderrTail, logPrefix string) error {
+	for scanner.Scan() {
+		text := scanner.Text()
+		if resp, ok := parseResponse(scanner.Bytes()); ok && resp.Type == "ready" {
+			if resp.Protocol < ProtocolVersion {
+				return fmt.Errorf("%w: transcriber speaks protocol %d, expected %d; run 'cs-translate container update'", ErrTranscriberNotReady, resp.Protocol, ProtocolVersion)
+			}
+			if resp.Protocol != ProtocolVersion {
+				return fmt.Errorf("%w: transcriber speaks protocol %d, expected %d", ErrTranscriberNotReady, resp.Protocol, ProtocolVersion)
+			}
+			return nil
+		}
+		if strings.TrimSpace(text) == "READY" {
+			return fmt.Errorf("%w: transcriber is outdated; run 'cs-translate container update'", ErrTranscriberNotReady)
+		}
+		slog.Debug(logPrefix, "output", text)
+	}
+
+	// stdout closed without a ready message; wait so all stderr has been copied
+	cmd.Wait()
+
+	output := stderr.String()
+	if strings.Contains(strings.ToLower(output), "out of memory") {
+		return ErrWhisperOOM
+	}
+	if last := lastLine(output); last != "" {
+		return fmt.Errorf("%w: %s", ErrTranscriberNotReady, last)
+	}
+	return ErrTranscriberNotReady
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// transcriberProc is one running transcriber.py, local or inside the
+// container, that has completed the ready handshake
+type transcriberProc struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	stderr *stderrTail
+
+	// responses carries protocol messages after the handshake and is closed
+	// when the process exits, so a crash is noticed even while idle
+	responses chan response
+	nextID    uint64
+	quit      chan struct{}
+	quitOnce  sync.Once
+
+	waitOnce sync.Once
+	waitErr  error
+}
+
+// startTranscriber starts cmd and blocks until it reports ready
+func startTranscriber(cmd *exec.Cmd, logPrefix string) (*transcriberProc, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transcriber stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transcriber stdout: %w", err)
+	}
+
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start transcriber: %w", err)
+	}
+
+	p := &transcriberProc{
+		cmd:       cmd,
+		stdin:     stdin,
+		stdout:    bufio.NewScanner(stdout),
+		stderr:    stderr,
+		responses: make(chan response),
+		quit:      make(chan struct{}),
+	}
+	if err := waitReady(p.stdout, cmd, stderr, logPrefix); err != nil {
+		p.kill()
+		return nil, err
+	}
+	go p.readResponses()
+	return p, nil
+}
+
+func (p *transcriberProc) readResponses() {
+	defer close(p.responses)
+	for p.stdout.Scan() {
+		resp, ok := parseResponse(p.stdout.Bytes())
+		if !ok {
+			slog.Debug("Transcriber", "output", p.stdout.Text())
+			continue
+		}
+		select {
+		case p.responses <- resp:
+		case <-p.quit:
+			return
+		}
+	}
+	if err := p.stdout.Err(); err != nil {
+		slog.Error("Failed to read from transcriber", "err", err)
+	}
+}
+
+// kill stops the process without waiting for it
+func (p *transcriberProc) kill() {
+	p.quitOnce.Do(func() { close(p.quit) })
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+}
+
+// wait reaps the process once and returns why it exited
+func (p *transcriberProc) wait() error {
+	p.waitOnce.Do(func() {
+		p.waitErr = p.cmd.Wait()
+	})
+	return p.waitErr
+}
+
+// exitCause describes why a transcriber that was running has gone away
+func (p *transcriberProc) exitCause() error {
+	p.kill()
+	err := p.wait()
+	output := p.stderr.String()
+	if strings.Contains(strings.ToLower(output), "out of memory") {
+		return ErrWhisperOOM
+	}
+	if last := lastLine(output); last != "" {
+		return fmt.Errorf("%w: %s", ErrTranscriberExited, last)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTranscriberExited, err)
+	}
+	return ErrTranscriberExited
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/setup"
+)
+
+// pickSample is how long each candidate device is recorded for its level
+const pickSample = 2 * time.Second
+
+// audioPickStep lets the user choose the capture device by its measured
+// level, once; the choice is saved as audio_device and put in *device
+func audioPickStep(device *string) setup.Step {
+	return setup.Step{
+		Name:  "audiopick",
+		Title: i18n.T("Audio capture device chosen"),
+		Run: func(scanner *bufio.Scanner) error {
+			return pickAudioDevice(scanner, device)
+		},
+		Optional: true,
+	}
+}
+
+// pickAudioDevice records pickSample from every device at once, shows how
+// loud each was and asks which one to capture. Enter keeps auto-detection.
+func pickAudioDevice(scanner *bufio.Scanner, device *string) error {
+	devices, err := audio.GetAvailableDevices()
+	if err != nil {
+		return fmt.Errorf("could not list audio devices: %w", err)
+	}
+	if len(devices) < 2 {
+		if len(devices) == 1 {
+			fmt.Print(i18n.T("Only one audio device found: %s\n", devices[0]))
+		}
+		return nil
+	}
+	if setup.DryRun {
+		fmt.Print(i18n.T("[dry run] record %s from %d audio devices and ask which one to capture\n", pickSample, len(devices)))
+		return nil
+	}
+	fmt.Print(i18n.T("Play some game audio or music now; each audio device is recorded for %s...\n", pickSample))
+	levels := measureDevices(context.Background(), devices)
+	for i, d := range devices {
+		fmt.Printf("  %d. %-50s %s\n", i+1, d, levels[i])
+	}
+	for {
+		fmt.Print(i18n.T("Number of the device that plays the game [Enter: auto-detect]: "))
+		if !scanner.Scan() {
+			return nil
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > len(devices) {
+			fmt.Print(i18n.T("Enter a number from 1 to %d.\n", len(devices)))
+			continue
+		}
+		*device = devices[n-1]
+		return saveAudioDevice(*device)
+	}
+}
+
+// measureDevices records every device in parallel and describes its level
+func measureDevices(ctx context.Context, devices []string) []string {
+	levels := make([]string, len(devices))
+	var wg sync.WaitGroup
+	for i, d := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			levels[i] = describeLevel(measureDevice(ctx, d))
+		}()
+	}
+	wg.Wait()
+	return levels
+}
+
+// measureDevice records pickSample of device
+func measureDevice(ctx context.Context, device string) (audio.Level, error) {
+	in, err := captureInput(device)
+	if err != nil {
+		return audio.Level{}, err
+	}
+	return audio.MeasureLevel(ctx, in, pickSample)
+}
+
+// describeLevel draws a level as a meter, e.g. "[############--------] -24 dB"
+func describeLevel(l audio.Level, err error) string {
+	if err != nil {
+		return i18n.T("(not recordable: %v)", err)
+	}
+	meter := levelMeter(l.Mean)
+	if l.Silent() {
+		meter += " " + i18n.T("(silent)")
+	}
+	return meter
+}
+
+// levelMeter draws dB, from -60 dBFS up, as a bar of 20
+func levelMeter(dB float64) string {
+	const width, floor = 20, -60.0
+	filled := int((dB - floor) / -floor * width)
+	filled = max(0, min(width, filled))
+	return fmt.Sprintf("[%s%s] %.0f dB", strings.Repeat("#", filled), strings.Repeat("-", width-filled), dB)
+}
+
+// saveAudioDevice keeps device as audio_device in the settings file
+func saveAudioDevice(device string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("could not save the audio device: %w", err)
+	}
+	cfg.AudioDevice = device
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("could not save the audio device: %w", err)
+	}
+	fmt.Print(i18n.T("Capturing '%s'; saved as audio_device in the settings.\n", device))
+	return nil
+}
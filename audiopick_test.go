@@ -0,0 +1,30 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// The picker shows the monitor playing and the headset silent
+func TestMeasureDevices(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("devices are captured from PulseAudio on Linux and macOS only")
+	}
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"pactl list sources short": {Stdout: "0\talsa_input.usb-headset\tPipeWire\ts16le 2ch 48000Hz\tRUNNING\n" +
+			"1\talsa_output.pci.analog-stereo.monitor\tPipeWire\ts16le 2ch 48000Hz\tIDLE\n"},
+		"ffmpeg -f pulse -i alsa_output.pci.analog-stereo.monitor -t 2 -af volumedetect -f null -": {
+			Stderr: "[Parsed_volumedetect_0 @ 0x1] mean_volume: -23.5 dB\n[Parsed_volumedetect_0 @ 0x1] max_volume: -4.0 dB\n"},
+		"ffmpeg -f pulse -i alsa_input.usb-headset -t 2 -af volumedetect -f null -": {
+			Stderr: "[Parsed_volumedetect_0 @ 0x1] mean_volume: -91.0 dB\n[Parsed_volumedetect_0 @ 0x1] max_volume: -91.0 dB\n"},
+	}}
+	defer execwrap.Use(fake)()
+
+	levels := measureDevices(t.Context(), []string{"alsa_input.usb-headset", "alsa_output.pci.analog-stereo.monitor"})
+	if !strings.Contains(levels[0], "(silent)") || levels[1] != "[############--------] -24 dB" {
+		t.Fatalf("device levels are %q", levels)
+	}
+}
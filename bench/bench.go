@@ -0,0 +1,125 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// Variant is a named prompt template, see translator.DefaultPrompt
+type Variant struct {
+	Name   string `json:"name"`
+	Prompt string `json:"prompt"`
+}
+
+// BuiltinVariants are compared when no prompt file is given
+var BuiltinVariants = []Variant{
+	{Name: "default", Prompt: translator.DefaultPrompt},
+	{Name: "gaming", Prompt: "You translate Counter-Strike 2 in-game chat. Keep player names, callouts (A, B, mid, banana, long) and gaming terms (eco, drop, AWP, smoke, flash) as players would say them. Translate the following message to {lang}. Output ONLY the translation, nothing else:\n\n{text}"},
+	{Name: "terse", Prompt: "{lang} translation of this game chat message, no explanations:\n{text}"},
+}
+
+// LoadVariants reads a JSON array of variants
+func LoadVariants(path string) ([]Variant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var variants []Variant
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, v := range variants {
+		if v.Name == "" || !strings.Contains(v.Prompt, "{text}") {
+			return nil, fmt.Errorf("%s: variant %d needs a name and a prompt containing {text}", path, i+1)
+		}
+	}
+	return variants, nil
+}
+
+// Translator is what a benchmark drives; OllamaTranslator implements it
+type Translator interface {
+	Translate(ctx context.Context, text string) (string, error)
+	SetTargetLang(lang string)
+	SetPrompt(tmpl string)
+}
+
+// Output is one translation made during a run
+type Output struct {
+	Sample  Sample
+	Text    string
+	ChrF    float64
+	Latency time.Duration
+	Err     error
+}
+
+// Result summarizes one variant
+type Result struct {
+	Variant   Variant
+	ChrF      float64 // corpus chrF over successful translations
+	Latencies []time.Duration
+	Errors    int
+	Outputs   []Output
+}
+
+// Percentile returns the latency below which p (0-1) of translations finished
+func (r Result) Percentile(p float64) time.Duration {
+	return Percentile(r.Latencies, p)
+}
+
+// Percentile returns the duration below which p (0-1) of latencies fall
+func Percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(p * float64(len(sorted)-1))
+	return sorted[i]
+}
+
+// Run translates every sample with every variant, runs times each, and
+// scores the outputs. progress, if set, is called after each translation.
+func Run(ctx context.Context, tr Translator, variants []Variant, samples []Sample, runs int, progress func(Variant, Output)) []Result {
+	if runs < 1 {
+		runs = 1
+	}
+	var results []Result
+	for _, v := range variants {
+		tr.SetPrompt(v.Prompt)
+		res := Result{Variant: v}
+		var hyps, refs []string
+		for run := 0; run < runs; run++ {
+			for _, s := range samples {
+				if ctx.Err() != nil {
+					break
+				}
+				tr.SetTargetLang(s.Target)
+				start := time.Now()
+				text, err := tr.Translate(ctx, s.Text)
+				out := Output{Sample: s, Text: text, Latency: time.Since(start), Err: err}
+				if err != nil {
+					res.Errors++
+				} else {
+					out.ChrF = ChrF(text, s.Ref)
+					res.Latencies = append(res.Latencies, out.Latency)
+					hyps = append(hyps, text)
+					refs = append(refs, s.Ref)
+				}
+				res.Outputs = append(res.Outputs, out)
+				if progress != nil {
+					progress(v, out)
+				}
+			}
+		}
+		res.ChrF = CorpusChrF(hyps, refs)
+		results = append(results, res)
+	}
+	return results
+}
@@ -0,0 +1,105 @@
+package bench_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/bench"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// benchModelEnv names an Ollama model to score the prompt variants with.
+// Without it they run against the mock Ollama, which only measures the
+// overhead around the model.
+const benchModelEnv = "CS_TRANSLATE_BENCH_MODEL"
+
+// loadSamples reads the built-in labeled set
+func loadSamples(b *testing.B) []bench.Sample {
+	b.Helper()
+	samples, err := bench.LoadSamples("")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return samples
+}
+
+// Identical lines score 100, unrelated ones nearly 0, and a close
+// translation in between
+func TestChrF(t *testing.T) {
+	tests := []struct {
+		hyp, ref string
+		min, max float64
+	}{
+		{"gg wp everyone", "gg wp everyone", 100, 100},
+		{"GG WP  everyone", "gg wp everyone", 100, 100},
+		{"", "gg wp everyone", 0, 0},
+		{"xyz", "gg wp everyone", 0, 10},
+		{"rush B, don't stop", "rush B, do not stop", 50, 99},
+	}
+	for _, tt := range tests {
+		if got := bench.ChrF(tt.hyp, tt.ref); got < tt.min || got > tt.max {
+			t.Errorf("ChrF(%q, %q) = %.1f, want %.0f to %.0f", tt.hyp, tt.ref, got, tt.min, tt.max)
+		}
+	}
+	if got := bench.CorpusChrF([]string{"gg", "rush b"}, []string{"gg", "rush b"}); got != 100 {
+		t.Errorf("CorpusChrF of identical sets = %.1f, want 100", got)
+	}
+}
+
+func BenchmarkChrF(b *testing.B) {
+	samples := loadSamples(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, s := range samples {
+			bench.ChrF(s.Text, s.Ref)
+		}
+	}
+}
+
+func BenchmarkCorpusChrF(b *testing.B) {
+	samples := loadSamples(b)
+	var hyps, refs []string
+	for _, s := range samples {
+		hyps = append(hyps, s.Text)
+		refs = append(refs, s.Ref)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		bench.CorpusChrF(hyps, refs)
+	}
+}
+
+// BenchmarkPrompts translates the labeled set with each built-in prompt
+// variant and reports its chrF and p95 latency next to the time per set:
+//
+//	CS_TRANSLATE_BENCH_MODEL=gemma3:4b go test -run '^$' -bench Prompts ./bench
+func BenchmarkPrompts(b *testing.B) {
+	samples := loadSamples(b)
+	model := os.Getenv(benchModelEnv)
+	if model == "" {
+		o := fakegame.NewOllama()
+		b.Cleanup(o.Close)
+		translator.OllamaHost = o.URL
+		model = "fake"
+	}
+	tr, err := translator.NewOllamaTranslator(b.Context(), model, "English")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { tr.Close() })
+
+	for _, v := range bench.BuiltinVariants {
+		b.Run(v.Name, func(b *testing.B) {
+			var res bench.Result
+			for b.Loop() {
+				res = bench.Run(b.Context(), tr, []bench.Variant{v}, samples, 1, nil)[0]
+			}
+			if res.Errors > 0 {
+				b.Fatalf("%d of %d translations failed", res.Errors, len(samples))
+			}
+			b.ReportMetric(res.ChrF, "chrF")
+			b.ReportMetric(float64(res.Percentile(0.95).Microseconds())/1000, "p95-ms")
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Package bench scores translation models, prompt variants and voice
+// transcription against a labeled set of gaming chat, so changes can be
+// compared by numbers.
+package bench
+
+import (
+	"strings"
+	"unicode"
+)
+
+// chrF settings as in sacreBLEU: character n-grams up to 6, recall weighted
+// twice as much as precision
+const (
+	chrFOrder = 6
+	chrFBeta  = 2.0
+)
+
+// chrfStats holds matched, hypothesis and reference n-gram counts per order
+type chrfStats [chrFOrder][3]int
+
+// ChrF scores one hypothesis against its reference from 0 to 100. Whitespace
+// is ignored and case is folded, since chat is rarely capitalized.
+func ChrF(hyp, ref string) float64 {
+	return chrfStatsOf(hyp, ref).score()
+}
+
+// CorpusChrF scores a whole set by summing n-gram counts over all pairs
+func CorpusChrF(hyps, refs []string) float64 {
+	var total chrfStats
+	for i := range hyps {
+		if i >= len(refs) {
+			break
+		}
+		s := chrfStatsOf(hyps[i], refs[i])
+		for n := range total {
+			for k := range total[n] {
+				total[n][k] += s[n][k]
+			}
+		}
+	}
+	return total.score()
+}
+
+func chrfStatsOf(hyp, ref string) chrfStats {
+	h, r := chrfChars(hyp), chrfChars(ref)
+	var s chrfStats
+	for n := 1; n <= chrFOrder; n++ {
+		hc, rc := ngrams(h, n), ngrams(r, n)
+		matched, hypCount, refCount := 0, 0, 0
+		for g, c := range hc {
+			hypCount += c
+			matched += min(c, rc[g])
+		}
+		for _, c := range rc {
+			refCount += c
+		}
+		s[n-1] = [3]int{matched, hypCount, refCount}
+	}
+	return s
+}
+
+// score averages precision and recall over the orders that have n-grams on
+// both sides and combines them into the F-beta score
+func (s chrfStats) score() float64 {
+	var precision, recall float64
+	orders := 0
+	for _, o := range s {
+		if o[1] == 0 || o[2] == 0 {
+			continue
+		}
+		precision += float64(o[0]) / float64(o[1])
+		recall += float64(o[0]) / float64(o[2])
+		orders++
+	}
+	if orders == 0 {
+		return 0
+	}
+	precision /= float64(orders)
+	recall /= float64(orders)
+	if precision == 0 && recall == 0 {
+		return 0
+	}
+	b2 := chrFBeta * chrFBeta
+	return 100 * (1 + b2) * precision * recall / (b2*precision + recall)
+}
+
+func chrfChars(s string) []rune {
+	var out []rune
+	for _, r := range strings.ToLower(s) {
+		if !unicode.IsSpace(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func ngrams(chars []rune, n int) map[string]int {
+	grams := map[string]int{}
+	for i := 0; i+n <= len(chars); i++ {
+		grams[string(chars[i:i+n])]++
+	}
+	return grams
+}
@@ -0,0 +1,75 @@
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed chat.jsonl
+var builtinSamples []byte
+
+// Sample is one chat line with a reference translation
+type Sample struct {
+	Text   string `json:"text"`
+	Source string `json:"source"` // language of Text, also the text-to-speech voice
+	Target string `json:"target"` // language of Ref
+	Ref    string `json:"ref"`
+	// Audio is a recording of Text for the voice benchmark, relative to the
+	// set file; without it Text is spoken with text-to-speech
+	Audio string `json:"audio,omitempty"`
+}
+
+// LoadSamples reads a JSON Lines file of samples, or the built-in set when
+// path is empty
+func LoadSamples(path string) ([]Sample, error) {
+	if path == "" {
+		return parseSamples(bytes.NewReader(builtinSamples))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	samples, err := parseSamples(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, s := range samples {
+		if s.Audio != "" && !filepath.IsAbs(s.Audio) {
+			samples[i].Audio = filepath.Join(filepath.Dir(path), s.Audio)
+		}
+	}
+	return samples, nil
+}
+
+func parseSamples(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		var s Sample
+		if err := json.Unmarshal([]byte(text), &s); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if s.Text == "" || s.Ref == "" {
+			return nil, fmt.Errorf("line %d: text and ref are required", line)
+		}
+		if s.Target == "" {
+			s.Target = "English"
+		}
+		samples = append(samples, s)
+	}
+	return samples, scanner.Err()
+}
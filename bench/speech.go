@@ -0,0 +1,118 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/translator"
+	"github.com/micha/cs-ingame-translate/tts"
+)
+
+// Transcriber turns an audio file into text, as the Whisper listener does
+type Transcriber interface {
+	Transcribe(ctx context.Context, path string) (text string, elapsed time.Duration, err error)
+}
+
+// Clip is a sample as audio
+type Clip struct {
+	Sample Sample
+	Path   string
+}
+
+// RenderClips returns a clip for every sample: its recording, or Text
+// spoken in its source language with text-to-speech into dir. Samples no
+// voice can speak are left out and their count returned.
+func RenderClips(ctx context.Context, samples []Sample, dir string) ([]Clip, int, error) {
+	var clips []Clip
+	skipped := 0
+	var firstErr error
+	for i, s := range samples {
+		if s.Audio != "" {
+			clips = append(clips, Clip{Sample: s, Path: s.Audio})
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprintf("clip_%02d.wav", i))
+		if err := tts.Render(ctx, s.Text, translator.LanguageCode(s.Source), path); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			skipped++
+			continue
+		}
+		clips = append(clips, Clip{Sample: s, Path: path})
+	}
+	if len(clips) == 0 && firstErr != nil {
+		return nil, skipped, fmt.Errorf("no clip could be spoken: %w", firstErr)
+	}
+	return clips, skipped, nil
+}
+
+// SpeechOutput is one clip transcribed and translated
+type SpeechOutput struct {
+	Clip       Clip
+	Heard      string // the transcription
+	Text       string // its translation
+	HeardChrF  float64
+	ChrF       float64
+	Transcribe time.Duration
+	Latency    time.Duration // transcription and translation
+	Err        error
+}
+
+// SpeechResult summarizes a voice run
+type SpeechResult struct {
+	HeardChrF float64 // transcriptions against the spoken text
+	ChrF      float64 // their translations against the references
+	Latencies []time.Duration
+	Errors    int
+	Outputs   []SpeechOutput
+}
+
+// Percentile returns the latency below which p (0-1) of clips were done
+func (r SpeechResult) Percentile(p float64) time.Duration {
+	return Percentile(r.Latencies, p)
+}
+
+// RunSpeech transcribes every clip and translates the transcription, runs
+// times each, like a voice line in a match. progress, if set, is called
+// after each clip.
+func RunSpeech(ctx context.Context, stt Transcriber, tr Translator, clips []Clip, runs int, progress func(SpeechOutput)) SpeechResult {
+	if runs < 1 {
+		runs = 1
+	}
+	var res SpeechResult
+	var heard, spoken, hyps, refs []string
+	for run := 0; run < runs; run++ {
+		for _, c := range clips {
+			if ctx.Err() != nil {
+				break
+			}
+			out := SpeechOutput{Clip: c}
+			out.Heard, out.Transcribe, out.Err = stt.Transcribe(ctx, c.Path)
+			if out.Err == nil {
+				tr.SetTargetLang(c.Sample.Target)
+				start := time.Now()
+				out.Text, out.Err = tr.Translate(ctx, out.Heard)
+				out.Latency = out.Transcribe + time.Since(start)
+			}
+			if out.Err != nil {
+				res.Errors++
+			} else {
+				out.HeardChrF = ChrF(out.Heard, c.Sample.Text)
+				out.ChrF = ChrF(out.Text, c.Sample.Ref)
+				res.Latencies = append(res.Latencies, out.Latency)
+				heard, spoken = append(heard, out.Heard), append(spoken, c.Sample.Text)
+				hyps, refs = append(hyps, out.Text), append(refs, c.Sample.Ref)
+			}
+			res.Outputs = append(res.Outputs, out)
+			if progress != nil {
+				progress(out)
+			}
+		}
+	}
+	res.HeardChrF = CorpusChrF(heard, spoken)
+	res.ChrF = CorpusChrF(hyps, refs)
+	return res
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/bench"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/tempdir"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// runBenchCommand runs the labeled chat set, and the same lines spoken,
+// through the configured Whisper and translation models and reports p50/p95
+// latency and chrF for each combination. -prompts compares prompt variants
+// on the chat lines instead.
+func runBenchCommand(args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Print(i18n.T("Warning: %v (using defaults)\n", err))
+	}
+	cfg.ApplyEnv()
+	if cfg.CPU {
+		cfg.ApplyCPUProfile()
+	}
+	translator.Configure(cfg.HTTPSettings())
+
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	modelList := fs.String("model", cfg.Model, "Comma-separated Ollama models to benchmark")
+	whisperList := fs.String("whisper-model", cfg.Whisper.Model, "Comma-separated Whisper models for the spoken lines")
+	setPath := fs.String("set", "", "JSON Lines file of {text, source, target, ref, audio} samples (default: built-in set)")
+	promptsPath := fs.String("prompts", "", "JSON file of [{name, prompt}] variants to compare, or builtin (default: the configured prompt)")
+	voice := fs.Bool("voice", true, "Also speak the lines with text-to-speech and run them through Whisper")
+	runs := fs.Int("runs", 1, "Run every sample this many times")
+	verbose := fs.Bool("v", false, "Print every translation with its score")
+	fs.Parse(args)
+
+	samples, err := bench.LoadSamples(*setPath)
+	if err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	prompt := cfg.Prompt
+	if prompt == "" {
+		prompt = translator.DefaultPrompt
+	}
+	variants := []bench.Variant{{Name: "configured", Prompt: prompt}}
+	switch *promptsPath {
+	case "":
+	case "builtin":
+		variants = bench.BuiltinVariants
+	default:
+		if variants, err = bench.LoadVariants(*promptsPath); err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+	}
+	models := splitList(*modelList)
+	if len(models) == 0 {
+		models = []string{translator.DefaultOllamaModel}
+	}
+	// Prompt comparisons only look at text
+	withVoice := *voice && *promptsPath == ""
+
+	if withVoice {
+		if err := ensureEnvironment(bufio.NewScanner(os.Stdin), models[0], true); err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	chat := benchChat(ctx, models, variants, samples, *runs, *verbose)
+	if ctx.Err() == nil && withVoice {
+		whispers := splitList(*whisperList)
+		if len(whispers) == 0 {
+			whispers = []string{translator.DefaultLiveWhisperModel}
+		}
+		benchVoice(ctx, cfg, whispers, models, prompt, samples, *runs, *verbose)
+	}
+
+	// Translations failing is usually Ollama or the model missing
+	for _, r := range chat {
+		for _, out := range r.Outputs {
+			if out.Err != nil {
+				if !printHint(out.Err) {
+					fmt.Print(i18n.T("First error: %v\n", out.Err))
+				}
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+// benchChat translates the samples with every model and variant
+func benchChat(ctx context.Context, models []string, variants []bench.Variant, samples []bench.Sample, runs int, verbose bool) []bench.Result {
+	fmt.Print(i18n.T("Chat: %d lines, %d prompt variants, %d models\n", len(samples), len(variants), len(models)))
+	var all []bench.Result
+	var rows []string
+	for _, model := range models {
+		tr, err := translator.NewOllamaTranslator(ctx, model, "English")
+		if err != nil {
+			fmt.Print(i18n.T("  %s skipped: %v\n", model, err))
+			continue
+		}
+		results := bench.Run(ctx, tr, variants, samples, runs, func(v bench.Variant, out bench.Output) {
+			if !verbose {
+				return
+			}
+			if out.Err != nil {
+				fmt.Printf("  [%s/%s] %q: error: %v\n", model, v.Name, out.Sample.Text, out.Err)
+				return
+			}
+			fmt.Printf("  [%s/%s] %5.1f %q -> %q (ref %q)\n", model, v.Name, out.ChrF, out.Sample.Text, out.Text, out.Sample.Ref)
+		})
+		tr.Close()
+		for _, r := range results {
+			rows = append(rows, fmt.Sprintf("%-28s %-14s %6.1f %9s %9s %7d", model, r.Variant.Name, r.ChrF,
+				r.Percentile(0.5).Round(time.Millisecond), r.Percentile(0.95).Round(time.Millisecond), r.Errors))
+		}
+		all = append(all, results...)
+	}
+	fmt.Printf("\n%-28s %-14s %6s %9s %9s %7s\n", "model", "prompt", "chrF", "p50", "p95", "errors")
+	for _, row := range rows {
+		fmt.Println(row)
+	}
+	fmt.Println()
+	return all
+}
+
+// benchVoice speaks the samples and runs them through every Whisper model,
+// translating what was heard with every translation model
+func benchVoice(ctx context.Context, cfg config.Config, whispers, models []string, prompt string, samples []bench.Sample, runs int, verbose bool) {
+	dir, err := tempdir.New("", "cs-translate-bench")
+	if err != nil {
+		fmt.Print(i18n.T("Voice skipped: %v\n", err))
+		return
+	}
+	defer os.RemoveAll(dir)
+	clips, skipped, err := bench.RenderClips(ctx, samples, dir)
+	if err != nil {
+		fmt.Print(i18n.T("Voice skipped: %v\n", err))
+		return
+	}
+	fmt.Print(i18n.T("Voice: %d clips", len(clips)))
+	if skipped > 0 {
+		fmt.Print(" " + i18n.T("(%d lines had no text-to-speech voice)", skipped))
+	}
+	fmt.Println()
+
+	var rows []string
+	for _, w := range whispers {
+		opts := cfg.WhisperSettings(false)
+		opts.Model = w
+		listener := initAudioListener(true, opts)
+		if listener == nil {
+			fmt.Print(i18n.T("  Whisper %s skipped: transcriber did not start\n", w))
+			continue
+		}
+		stt := listenerTranscriber{listener}
+		// The first transcription loads the model and is not counted
+		if _, _, err := stt.Transcribe(ctx, clips[0].Path); err != nil {
+			fmt.Print(i18n.T("  Whisper %s skipped: %v\n", w, err))
+			listener.Stop()
+			continue
+		}
+		for _, model := range models {
+			tr, err := translator.NewOllamaTranslator(ctx, model, "English")
+			if err != nil {
+				fmt.Print(i18n.T("  %s skipped: %v\n", model, err))
+				continue
+			}
+			tr.SetPrompt(prompt)
+			res := bench.RunSpeech(ctx, stt, tr, clips, runs, func(out bench.SpeechOutput) {
+				if !verbose {
+					return
+				}
+				if out.Err != nil {
+					fmt.Printf("  [%s/%s] %q: error: %v\n", w, model, out.Clip.Sample.Text, out.Err)
+					return
+				}
+				fmt.Printf("  [%s/%s] %5.1f heard %q -> %q (ref %q)\n", w, model, out.ChrF, out.Heard, out.Text, out.Clip.Sample.Ref)
+			})
+			tr.Close()
+			rows = append(rows, fmt.Sprintf("%-10s %-28s %6.1f %6.1f %9s %9s %7d", w, model, res.HeardChrF, res.ChrF,
+				res.Percentile(0.5).Round(time.Millisecond), res.Percentile(0.95).Round(time.Millisecond), res.Errors))
+		}
+		listener.Stop()
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	fmt.Printf("\n%-10s %-28s %6s %6s %9s %9s %7s\n", "whisper", "model", "heard", "chrF", "p50", "p95", "errors")
+	for _, row := range rows {
+		fmt.Println(row)
+	}
+	fmt.Println(i18n.T("(heard: chrF of the transcription against the spoken line; latency: transcription plus translation)"))
+}
+
+// listenerTranscriber runs bench clips through the Whisper listener. It
+// deletes what it transcribed, so each clip is copied in first.
+type listenerTranscriber struct {
+	listener *audio.Listener
+}
+
+func (t listenerTranscriber) Transcribe(ctx context.Context, path string) (string, time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	dst := filepath.Join(t.listener.OutputDir(), fmt.Sprintf("bench_%d%s", time.Now().UnixNano(), filepath.Ext(path)))
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return "", 0, err
+	}
+	t.listener.SubmitProbe(dst)
+	select {
+	case tr, ok := <-t.listener.Transcriptions():
+		if !ok {
+			return "", 0, fmt.Errorf("transcriber stopped")
+		}
+		return tr.Text, tr.Elapsed, nil
+	case <-time.After(tuneProbeTimeout):
+		return "", 0, fmt.Errorf("no transcription within %s", tuneProbeTimeout)
+	case <-ctx.Done():
+		return "", 0, ctx.Err()
+	}
+}
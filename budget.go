@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// latencyBudget degrades voice translation while messages take longer than
+// -max-latency; nil when no budget is set
+var latencyBudget *pipeline.Budget
+
+// viaBudget marks voice the latency budget left untranslated
+const viaBudget = "latency budget"
+
+// budgetTranslator hands messages to a smaller model while the latency
+// budget asks for it
+type budgetTranslator struct {
+	translator.Translator
+	fast translator.Translator
+}
+
+// withLatencyBudget sets up latencyBudget from cfg and returns tr, wrapped so
+// it can switch to cfg.FastModel under load
+func withLatencyBudget(ctx context.Context, tr translator.Translator, cfg config.Config) translator.Translator {
+	if cfg.MaxLatency <= 0 {
+		return tr
+	}
+	var fast translator.Translator
+	if cfg.FastModel != "" && cfg.FastModel != cfg.Model {
+		var err error
+		if fast, err = translator.NewChain(ctx, []translator.BackendConfig{ollamaBackend(cfg, cfg.FastModel)}, cfg.Lang); err != nil {
+			slog.Warn("Fast model unavailable, the latency budget will not switch models", "model", cfg.FastModel, "err", err)
+			fast = nil
+		}
+	}
+	levels := []pipeline.Level{pipeline.LevelNoContext}
+	if fast != nil {
+		levels = append(levels, pipeline.LevelFastModel)
+	}
+	levels = append(levels, pipeline.LevelNoVoice)
+	latencyBudget = pipeline.NewBudget(time.Duration(cfg.MaxLatency), levels...)
+	if fast == nil {
+		return tr
+	}
+	return &budgetTranslator{Translator: tr, fast: fast}
+}
+
+func (b *budgetTranslator) current() translator.Translator {
+	if latencyBudget.Level() >= pipeline.LevelFastModel {
+		return b.fast
+	}
+	return b.Translator
+}
+
+func (b *budgetTranslator) Translate(ctx context.Context, text string) (string, error) {
+	return b.current().Translate(ctx, text)
+}
+
+func (b *budgetTranslator) TranslateWithContext(ctx context.Context, req translator.Request) (string, error) {
+	return b.current().TranslateWithContext(ctx, req)
+}
+
+func (b *budgetTranslator) SetTargetLang(lang string) {
+	b.Translator.SetTargetLang(lang)
+	b.fast.SetTargetLang(lang)
+}
+
+func (b *budgetTranslator) Unload() error {
+	translator.Unload(b.fast)
+	return translator.Unload(b.Translator)
+}
+
+func (b *budgetTranslator) Close() error {
+	b.fast.Close()
+	return b.Translator.Close()
+}
+
+// observeLatency records how long a message took from arrival to
+// translation and announces changes of the budget level
+func observeLatency(d time.Duration) {
+	if level, changed := latencyBudget.Observe(d); changed {
+		announceBudget(level)
+	}
+}
+
+// skipLatency records voice shown untranslated by the budget, which steps
+// quality back up after a while
+func skipLatency() {
+	if level, changed := latencyBudget.Skip(); changed {
+		announceBudget(level)
+	}
+}
+
+// announceBudget shows a change of the budget level
+func announceBudget(level pipeline.Level) {
+	state := "degraded"
+	if level == pipeline.LevelFull {
+		state = "ready"
+	}
+	bus.Publish(events.Status{
+		Source: "budget", State: state,
+		Message: fmt.Sprintf("%s (average latency %s, budget %s)", level,
+			latencyBudget.Average().Round(10*time.Millisecond), latencyBudget.Max()),
+	})
+	if level != pipeline.LevelFull {
+		explainLatency()
+	}
+}
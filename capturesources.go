@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+)
+
+// parseCaptureFlag reads -capture: label=device, e.g. discord=app:discord
+func parseCaptureFlag(v string) (config.CaptureSource, error) {
+	label, device, ok := strings.Cut(v, "=")
+	label = strings.ToLower(strings.TrimSpace(label))
+	if !ok || label == "" {
+		return config.CaptureSource{}, fmt.Errorf("want label=device, e.g. discord=app:discord")
+	}
+	return config.CaptureSource{Label: label, Device: strings.TrimSpace(device)}, nil
+}
+
+// captureSources is what voice mode records: every capture_sources entry,
+// its device resolved like -audiodevice, or else device alone
+func captureSources(cfgs []config.CaptureSource, device string) ([]audio.Source, error) {
+	if len(cfgs) == 0 {
+		return []audio.Source{{Device: device}}, nil
+	}
+	var sources []audio.Source
+	for _, c := range cfgs {
+		resolved, err := audio.ResolveDevice(c.Device)
+		if err != nil {
+			return nil, fmt.Errorf("capture source %s: %w", c.Label, err)
+		}
+		sources = append(sources, audio.Source{Label: c.Label, Device: resolved})
+	}
+	return sources, nil
+}
+
+// describeSources lists labelled sources, e.g. "game (auto-detect), discord
+// (app:discord)"
+func describeSources(sources []audio.Source) string {
+	var parts []string
+	for _, s := range sources {
+		device := s.Device
+		if device == "" {
+			device = "auto-detect"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", s.Label, device))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,606 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/monitor"
+	"github.com/micha/cs-ingame-translate/parser"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// testRig is the chat pipeline of CS2 mode wired to the fake game
+type testRig struct {
+	ollama  *fakegame.Ollama
+	console *fakegame.ConsoleLog
+	mon     *monitor.Monitor
+	tr      translator.Translator
+	disp    *pipeline.Dispatcher
+	done    chan struct{}
+}
+
+func newTestRig(t *testing.T, opts pipeline.Options) *testRig {
+	t.Helper()
+	r := &testRig{ollama: fakegame.NewOllama(), done: make(chan struct{})}
+	translator.OllamaHost = r.ollama.URL
+
+	console, err := fakegame.NewConsoleLog(filepath.Join(t.TempDir(), "console.log"))
+	if err != nil {
+		r.ollama.Close()
+		t.Fatal(err)
+	}
+	r.console = console
+	if r.mon, err = monitor.NewMonitor(console.Path()); err != nil {
+		r.ollama.Close()
+		console.Close()
+		t.Fatal(err)
+	}
+	if r.tr, err = translator.NewOllamaTranslator(t.Context(), "fake", "English"); err != nil {
+		r.close()
+		t.Fatal(err)
+	}
+	r.disp = pipeline.NewDispatcher(t.Context(), chatTranslator(keepEntities(r.tr)), opts)
+
+	// What runCS2Mode does with each line, minus the event bus
+	submit := submitChat(r.disp)
+	go func() {
+		defer close(r.done)
+		for line := range r.mon.Lines() {
+			if line.Err != nil {
+				continue
+			}
+			if msg := parser.ParseLine(line.Text); msg != nil {
+				submit(chatEvent(msg, line.Source))
+			}
+		}
+	}()
+	return r
+}
+
+func (r *testRig) close() {
+	r.mon.Stop()
+	if r.disp != nil {
+		r.disp.Close()
+		<-r.done
+	}
+	if r.tr != nil {
+		r.tr.Close()
+	}
+	r.ollama.Close()
+	r.console.Close()
+}
+
+// say writes each text as chat from its own player
+func (r *testRig) say(prefix string, texts ...string) error {
+	for i, text := range texts {
+		if err := r.console.Say("ALL", fmt.Sprintf("%s%d", prefix, i), text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect waits for n results
+func (r *testRig) collect(n int) ([]pipeline.Result, error) {
+	var results []pipeline.Result
+	timeout := time.After(testTimeout)
+	for len(results) < n {
+		select {
+		case res := <-r.disp.Results():
+			results = append(results, res)
+		case <-timeout:
+			return results, fmt.Errorf("got %d of %d results within %s", len(results), n, testTimeout)
+		}
+	}
+	return results, nil
+}
+
+// expectTranslations checks that results are the translations of texts, in
+// that order
+func expectTranslations(results []pipeline.Result, texts []string) error {
+	if len(results) != len(texts) {
+		return fmt.Errorf("got %d results, want %d", len(results), len(texts))
+	}
+	for i, res := range results {
+		if res.Err != nil {
+			return fmt.Errorf("result %d (%q): %w", i, res.Job.Text, res.Err)
+		}
+		if want := fakegame.Translation(texts[i]); res.Text != want {
+			return fmt.Errorf("result %d is %q, want %q", i, res.Text, want)
+		}
+	}
+	return nil
+}
+
+func numberedLines(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s message %d", prefix, i)
+	}
+	return lines
+}
+
+// Stopping shows the chat translations still running and takes no new ones
+func TestShutdown(t *testing.T) {
+	var mu sync.Mutex
+	var shown []string
+	defer bus.Subscribe(func(e events.Event) {
+		if td, ok := e.(events.TranslationDone); ok && td.Err == nil {
+			mu.Lock()
+			shown = append(shown, td.Translated)
+			mu.Unlock()
+		}
+	})()
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+	r.ollama.SetDelay(200 * time.Millisecond)
+
+	lines := numberedLines("leaving", 3)
+	if err := r.say("p", lines...); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(testTimeout); len(r.ollama.Texts()) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the first message never reached Ollama")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The rig hands the monitor's lines over on its own; wait until all are queued
+	time.Sleep(100 * time.Millisecond)
+	shutDown(r.disp, nil, nil)
+	r.disp.Submit(pipeline.Job{Text: "after stopping", Payload: &chatJob{}})
+	mu.Lock()
+	got := append([]string(nil), shown...)
+	mu.Unlock()
+	var want []string
+	for _, l := range lines {
+		want = append(want, fakegame.Translation(l))
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("shown while stopping: %q, want %q", got, want)
+	}
+	select {
+	case res := <-r.disp.Results():
+		t.Fatalf("a job submitted after stopping ran: %q", res.Job.Text)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// A player repeating one message gets it translated once and a flood of
+// different messages is cut off at the rate, while the rest is shown
+// untranslated
+func TestSpam(t *testing.T) {
+	chatLimiter = pipeline.NewLimiter(pipeline.LimitOptions{DedupeWindow: 5 * time.Second, Rate: 1, Burst: 2})
+	defer func() { chatLimiter = nil }()
+	var mu sync.Mutex
+	held := map[string]int{}
+	defer bus.Subscribe(func(e events.Event) {
+		if td, ok := e.(events.TranslationDone); ok {
+			mu.Lock()
+			held[td.Via]++
+			mu.Unlock()
+		}
+	})()
+
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+
+	for i := range 10 {
+		if err := r.console.Say("ALL", "griefer", "n"+strings.Repeat("o", 1+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.console.Say("ALL", "griefer", "stop"); err != nil {
+		t.Fatal(err)
+	}
+	flood := numberedLines("flood", 6)
+	for _, text := range flood {
+		if err := r.console.Say("ALL", "flooder", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"no", "stop", flood[0], flood[1]}
+	results, err := r.collect(len(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := expectTranslations(results, want); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case res := <-r.disp.Results():
+		t.Fatalf("unexpected extra result for %q", res.Job.Text)
+	case <-time.After(time.Second):
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if held[viaRepeated] != 1 || held[viaRateLimited] != len(flood)-2 {
+		t.Fatalf("shown untranslated: %d repeated (want 1), %d rate limited (want %d)",
+			held[viaRepeated], held[viaRateLimited], len(flood)-2)
+	}
+}
+
+// While the translator is busy, voice goes before queued chat and team chat
+// before all-chat that was written earlier
+func TestPriority(t *testing.T) {
+	ctx := t.Context()
+	gate := pipeline.NewGate(1)
+	r := newTestRig(t, pipeline.Options{Workers: 1, Gate: gate})
+	defer r.close()
+	r.ollama.SetDelay(800 * time.Millisecond)
+
+	if err := r.console.Say("ALL", "a", "all first"); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(testTimeout); len(r.ollama.Texts()) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the first message never reached Ollama")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := r.console.Say("ALL", "b", "all second"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.console.Say("CT", "c", "team first"); err != nil {
+		t.Fatal(err)
+	}
+	voice := make(chan error, 1)
+	go func() {
+		_, err := gatedTranslator{Translator: r.tr, gate: gate, prio: pipeline.PriorityVoice}.Translate(ctx, "voice first")
+		voice <- err
+	}()
+
+	if _, err := r.collect(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-voice; err != nil {
+		t.Fatalf("voice: %v", err)
+	}
+	want := []string{"all first", "voice first", "team first", "all second"}
+	if got := r.ollama.Texts(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("translated in the order %q, want %q", got, want)
+	}
+}
+
+// Player names seen in chat and kept terms reach the model as placeholders
+// and come back unchanged
+func TestEntities(t *testing.T) {
+	keptEntities, keepNames = translator.NewEntities("AWP"), true
+	defer func() { keptEntities, keepNames = nil, false }()
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+
+	lines := []struct{ player, text, sent, want string }{
+		{"Дима", "привет", "привет", fakegame.Translation("привет")},
+		{"vova", "Дима купи awp", "[[1]] купи [[2]]", fakegame.Translation("Дима купи awp")},
+		{"vova", "Дима!", "", "Дима!"}, // nothing left to translate
+	}
+	for _, l := range lines {
+		if err := r.console.Say("ALL", l.player, l.text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := r.collect(len(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent []string
+	for i, l := range lines {
+		if results[i].Err != nil || results[i].Text != l.want {
+			t.Fatalf("%q was translated as %q (%v), want %q", l.text, results[i].Text, results[i].Err, l.want)
+		}
+		if l.sent != "" {
+			sent = append(sent, l.sent)
+		}
+	}
+	if got := r.ollama.Texts(); strings.Join(got, "|") != strings.Join(sent, "|") {
+		t.Fatalf("Ollama was sent %q, want %q", got, sent)
+	}
+}
+
+// Emoji, emoticons and ASCII art are shown as they are without reaching the
+// model, and color codes are stripped before it sees a line
+func TestArt(t *testing.T) {
+	var mu sync.Mutex
+	var asIs []string
+	defer bus.Subscribe(func(e events.Event) {
+		if td, ok := e.(events.TranslationDone); ok && td.Via == viaAsIs {
+			mu.Lock()
+			asIs = append(asIs, td.Translated)
+			mu.Unlock()
+		}
+	})()
+
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+
+	art := []string{":D", "¯\\_(ツ)_/¯", "(╯°□°)╯︵ ┻━┻", "😂😂 xD"}
+	if err := r.say("art", art...); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.console.Say("ALL", "\x03vova\x01", "\x07привет\x01 :)"); err != nil {
+		t.Fatal(err)
+	}
+	results, err := r.collect(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := expectTranslations(results, []string{"привет :)"}); err != nil {
+		t.Fatal(err)
+	}
+	if p := results[0].Job.Key; p != "vova" {
+		t.Fatalf("player name is %q, want it without color codes", p)
+	}
+	if got := r.ollama.Texts(); len(got) != 1 {
+		t.Fatalf("Ollama was sent %q, want only the message with words", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(asIs, "|") != strings.Join(art, "|") {
+		t.Fatalf("shown as is: %q, want %q", asIs, art)
+	}
+}
+
+// With structured answers, chat the model finds in the target language is
+// shown as written, unsure translations are marked and only confident
+// detections are remembered as the player's language
+func TestStructured(t *testing.T) {
+	structured, minConfidence, targetLang = true, 0.5, "English"
+	defer func() { structured, minConfidence, targetLang = false, 0, "" }()
+	var mu sync.Mutex
+	var done []events.TranslationDone
+	defer bus.Subscribe(func(e events.Event) {
+		if td, ok := e.(events.TranslationDone); ok {
+			mu.Lock()
+			done = append(done, td)
+			mu.Unlock()
+		}
+	})()
+
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+	r.tr.(*translator.OllamaTranslator).SetStructured(true)
+
+	steps := []struct {
+		player, text, lang string
+		confidence         float64
+		answer             string // a model ignoring the format
+		want, via, likely  string
+	}{
+		{player: "ivan", text: "привет", lang: "ru", confidence: 0.9, want: fakegame.Translation("привет"), likely: "ru"},
+		{player: "bob", text: "gg wp", lang: "en", confidence: 0.95, want: "gg wp", via: "own language", likely: "en"},
+		{player: "hans", text: "hallo", lang: "de", confidence: 0.2, want: fakegame.Translation("hallo")},
+		{player: "free", text: "bonjour", answer: "Hello", want: "Hello"},
+	}
+	for i, st := range steps {
+		r.ollama.SetDetection(st.lang, st.confidence)
+		if st.answer != "" {
+			r.ollama.SetAnswer(func(string) string { return st.answer })
+		}
+		if err := r.console.Say("ALL", st.player, st.text); err != nil {
+			t.Fatal(err)
+		}
+		results, err := r.collect(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatResult(results[0])
+		mu.Lock()
+		td := done[len(done)-1]
+		mu.Unlock()
+		if len(done) != i+1 || td.Err != nil || td.Translated != st.want || td.Via != st.via {
+			t.Fatalf("%q was shown as %q via %q (%v), want %q via %q", st.text, td.Translated, td.Via, td.Err, st.want, st.via)
+		}
+		if td.Detected != st.lang || td.Confidence != st.confidence || unsure(td) != (st.confidence > 0 && st.confidence < 0.5) {
+			t.Fatalf("%q was reported in %q at %.2f, want %q at %.2f", st.text, td.Detected, td.Confidence, st.lang, st.confidence)
+		}
+		if got := chatLanguages.Likely(st.player); got != st.likely {
+			t.Fatalf("%s is remembered as writing %q, want %q", st.player, got, st.likely)
+		}
+	}
+}
+
+// Team chat and all-chat are translated into the languages lang_rules give
+// them, and reported with those languages
+func TestLangRules(t *testing.T) {
+	langRules, targetLang = []config.LangRule{{Source: "team", Lang: "de"}, {Source: "chat", Lang: "pt-BR"}}, "en"
+	defer func() { langRules, targetLang = nil, "" }()
+	var mu sync.Mutex
+	var done []events.TranslationDone
+	defer bus.Subscribe(func(e events.Event) {
+		if td, ok := e.(events.TranslationDone); ok {
+			mu.Lock()
+			done = append(done, td)
+			mu.Unlock()
+		}
+	})()
+
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+	// The fake translates the whole prompt, which is only the language
+	r.tr.(*translator.OllamaTranslator).SetPrompt("{lang}")
+
+	for _, st := range []struct{ team, want, lang string }{
+		{"CT", "German", "de"},
+		{"ALL", "Brazilian Portuguese", "pt-BR"},
+	} {
+		if err := r.console.Say(st.team, "ivan", "привет"); err != nil {
+			t.Fatal(err)
+		}
+		results, err := r.collect(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatResult(results[0])
+		mu.Lock()
+		td := done[len(done)-1]
+		mu.Unlock()
+		if td.Translated != fakegame.Translation(st.want) || td.Language != st.lang {
+			t.Fatalf("%s chat became %q in %q, want %s in %q", st.team, td.Translated, td.Language, st.want, st.lang)
+		}
+	}
+	if got := voiceLang(); got != "en" {
+		t.Fatalf("voice is translated into %q without a rule, want the -lang %q", got, "en")
+	}
+}
+
+// Chat mentioning an alert keyword, as a whole word or by regular
+// expression, is highlighted; other chat is not
+func TestAlerts(t *testing.T) {
+	defer startAlerts(config.AlertConfig{Keywords: []string{"rush", `/plant\s+a\b/`}})()
+	var mu sync.Mutex
+	var done []events.TranslationDone
+	defer bus.Subscribe(func(e events.Event) {
+		if td, ok := e.(events.TranslationDone); ok {
+			mu.Lock()
+			done = append(done, td)
+			mu.Unlock()
+		}
+	})()
+
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+
+	for _, st := range []struct {
+		text      string
+		highlight bool
+	}{
+		{"RUSH B now", true},
+		{"nice brush", false},
+		{"plant   A site", true},
+		{"planted", false},
+	} {
+		if err := r.console.Say("ALL", "ivan", st.text); err != nil {
+			t.Fatal(err)
+		}
+		results, err := r.collect(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatResult(results[0])
+		mu.Lock()
+		td := done[len(done)-1]
+		mu.Unlock()
+		if td.Highlight != st.highlight {
+			t.Fatalf("%q was highlighted: %v, want %v", st.text, td.Highlight, st.highlight)
+		}
+	}
+}
+
+// Translated chat is tagged by the classifier, toxic chat is hidden with
+// -hide-toxic, and the match summary counts the tones
+func TestTone(t *testing.T) {
+	var mu sync.Mutex
+	var done []events.TranslationDone
+	defer bus.Subscribe(func(e events.Event) {
+		if td, ok := e.(events.TranslationDone); ok {
+			mu.Lock()
+			done = append(done, td)
+			mu.Unlock()
+		}
+	})()
+	summary := &toneSummary{}
+	defer bus.Subscribe(summary.handle)()
+
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+	classifier = translator.NewClassifier(translator.BackendConfig{Type: translator.BackendOllama, Model: "fake"})
+	hideToxic = true
+	defer func() { classifier, hideToxic = nil, false }()
+	r.ollama.SetTone(func(text string) string {
+		switch {
+		case strings.Contains(text, "noob"):
+			return "toxic"
+		case strings.Contains(text, "gg"):
+			return "friendly"
+		}
+		return "neutral"
+	})
+
+	for _, st := range []struct{ player, text, tone string }{
+		{"ivan", "gg wp", "friendly"},
+		{"ivan", "you noob", "toxic"},
+		{"bob", "rotate b", "neutral"},
+	} {
+		if err := r.console.Say("ALL", st.player, st.text); err != nil {
+			t.Fatal(err)
+		}
+		results, err := r.collect(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handleChatResult(results[0])
+		mu.Lock()
+		td := done[len(done)-1]
+		mu.Unlock()
+		if td.Tone != st.tone || hidden(td) != (st.tone == "toxic") {
+			t.Fatalf("%q was tagged %q (hidden: %v), want %q", st.text, td.Tone, hidden(td), st.tone)
+		}
+	}
+	summary.mu.Lock()
+	got := summarizeTones(summary.tones, summary.toxic)
+	summary.mu.Unlock()
+	if want := "Chat this match: 1 friendly, 1 neutral, 1 toxic (ivan 1)"; got != want {
+		t.Fatalf("match summary %q, want %q", got, want)
+	}
+}
+
+// A match's chat is written to its transcript, and when the server announces
+// game over the model's summary is saved next to it
+func TestSummary(t *testing.T) {
+	dir := t.TempDir()
+	targetLang = "en"
+	defer func() { targetLang = "" }()
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+	transcripts := filepath.Join(dir, "transcripts")
+	stop := startTranscript(transcripts, translator.NewSummarizer(translator.BackendConfig{Type: translator.BackendOllama, Model: "fake"}))
+	defer stop()
+
+	bus.Publish(events.MatchStarted{Map: "de_dust2"})
+	if err := r.console.Say("ALL", "ivan", "привет"); err != nil {
+		t.Fatal(err)
+	}
+	results, err := r.collect(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handleChatResult(results[0])
+	mapName, score, ok := parser.ParseGameOver(`L 02/02/2026 - 01:20:44: Game Over: competitive mg_active de_dust2 score 13:7 after 41 min`)
+	if !ok {
+		t.Fatalf("game over line not recognized")
+	}
+	bus.Publish(events.MatchEnded{Map: mapName, Score: score})
+	stop()
+
+	paths, _ := filepath.Glob(filepath.Join(transcripts, "*_de_dust2.txt"))
+	if len(paths) != 1 {
+		t.Fatalf("found transcripts %v, want one for de_dust2", paths)
+	}
+	text, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[ALL] ivan: привет → " + fakegame.Translation("привет"), "Game over on de_dust2, 13:7"} {
+		if !strings.Contains(string(text), want) {
+			t.Fatalf("transcript %q lacks %q", text, want)
+		}
+	}
+	summary, err := os.ReadFile(strings.TrimSuffix(paths[0], ".txt") + ".summary.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fakegame.Translation(strings.TrimSpace(string(text))) + "\n"; string(summary) != want {
+		t.Fatalf("summary %q, want the model's answer %q", summary, want)
+	}
+}
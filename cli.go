@@ -3,18 +3,20 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/speakers"
 	"github.com/micha/cs-ingame-translate/translator"
 )
 
@@ -22,85 +24,140 @@ func listAudioDevices() {
 	fmt.Println(audio.GetDeviceHelpText())
 	devices, err := audio.GetAvailableDevices()
 	if err != nil {
-		fmt.Printf("Error listing devices: %v\n", err)
+		fmt.Print(i18n.T("Error listing devices: %v\n", err))
 	} else {
-		fmt.Println("Available audio devices:")
+		fmt.Println(i18n.T("Available audio devices:"))
 		for i, device := range devices {
 			fmt.Printf("  %d. %s\n", i+1, device)
 		}
+		fmt.Println(i18n.T("Pass the number or the name to -audiodevice, e.g. -audiodevice 2."))
+	}
+	if audio.PipeWireAvailable() {
+		listPipeWireNodes()
 	}
 	os.Exit(0)
 }
 
-func selectMode(scanner *bufio.Scanner) string {
-	fmt.Println("Select Mode:")
-	fmt.Println("1. CS2 In-Game Translate (Monitor Console Log)")
-	fmt.Println("2. Additionally listening to system output audio " +
-		"\nPress F9 to capture the last 15 seconds, transcribe, and translate.")
-	fmt.Print("Enter choice [1]: ")
+// listPipeWireNodes prints PipeWire devices and playing applications with the
+// -audiodevice value that records each
+func listPipeWireNodes() {
+	nodes, err := audio.PipeWireNodes()
+	if err != nil {
+		fmt.Print(i18n.T("Error listing PipeWire nodes: %v\n", err))
+		return
+	}
+	fmt.Println(i18n.T("\nPipeWire nodes:"))
+	for _, n := range nodes {
+		if !n.IsAppStream() {
+			fmt.Printf("  %-50s %s (%s)\n", n.Device(), n.Description, n.Class)
+		}
+	}
+	fmt.Println(i18n.T("\nApplications playing audio (records only that program):"))
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		if n.IsAppStream() && !seen[n.Device()] {
+			seen[n.Device()] = true
+			fmt.Printf("  %-50s %s\n", n.Device(), n.AppName)
+		}
+	}
+}
+
+func selectMode(scanner *bufio.Scanner, window time.Duration) string {
+	fmt.Println(i18n.T("Select Mode:"))
+	fmt.Println(i18n.T("1. CS2 In-Game Translate (Monitor Console Log)"))
+	fmt.Print(i18n.T("2. Additionally listening to system output audio "+
+		"\nPress F9 to capture the last %s, transcribe, and translate.\n", window))
+	fmt.Println(i18n.T("3. CS2 In-Game Translate + talk to your team " +
+		"\nPress F10, speak, and press F10 again to translate your microphone into the team's language."))
+	fmt.Print(i18n.T("Enter choice [%s]: ", "1"))
 
 	mode := "1"
 	if scanner.Scan() {
 		input := strings.TrimSpace(scanner.Text())
-		if input == "2" {
-			mode = "2"
+		if input == "2" || input == "3" {
+			mode = input
 		}
 	}
 	return mode
 }
 
 func promptVoiceEnable(scanner *bufio.Scanner) bool {
-	fmt.Print("Enable Voice Transcription (uses Docker by default)? [y/N]: ")
-	if scanner.Scan() {
-		input := strings.TrimSpace(scanner.Text())
-		if strings.ToLower(input) == "y" || strings.ToLower(input) == "yes" {
-			return true
-		}
-	}
-	return false
+	fmt.Print(i18n.T("Enable Voice Transcription (uses Docker by default)? [y/N]: "))
+	return scanner.Scan() && i18n.Yes(scanner.Text())
 }
 
-func initAudioListener(useVoice bool) *audio.Listener {
+func initAudioListener(useVoice bool, opts audio.Options) *audio.Listener {
 	if !useVoice {
 		return nil
 	}
 
 	tmpFile, err := os.CreateTemp("", "transcriber-*.py")
 	if err != nil {
-		log.Fatalf("Failed to create temp file for transcriber: %v", err)
+		fatalf("Failed to create temp file for transcriber: %v", err)
 	}
 	defer os.Remove(tmpFile.Name())
 
 	if _, err := tmpFile.Write(transcriberScript); err != nil {
-		log.Fatalf("Failed to write transcriber script: %v", err)
+		fatalf("Failed to write transcriber script: %v", err)
 	}
 	if err := tmpFile.Close(); err != nil {
-		log.Fatalf("Failed to close temp transcriber file: %v", err)
+		fatalf("Failed to close temp transcriber file: %v", err)
 	}
 
-	log.Println("Initializing Audio Transcription Engine...")
-	audioListener, err := audio.NewListener(tmpFile.Name())
+	slog.Info("Initializing audio transcription")
+	audioListener, err := audio.NewListener(tmpFile.Name(), opts)
 	if err != nil {
-		log.Printf("Warning: Failed to create audio listener: %v", err)
+		if !printHint(err) {
+			slog.Warn("Failed to create audio listener", "err", err)
+		}
 		return nil
 	}
 	return audioListener
 }
 
+// voiceContextWindow is how far back recent speech is used as context; 0
+// gives voice no context
+var voiceContextWindow = 10 * time.Second
+
 type voiceContextItem struct {
 	text      string
 	timestamp time.Time
 }
 
-func parseTranscription(text string) (string, float64) {
-	transcribeDuration := 0.0
-	transcribedText := text
-	if idx := strings.LastIndex(text, "|"); idx != -1 {
-		if n, err := fmt.Sscanf(text[idx+1:], "%f", &transcribeDuration); err == nil && n == 1 {
-			transcribedText = text[:idx]
-		}
+// voiceContext is recent speech given to the translator as context. When
+// the console log shows round boundaries it only covers the current round,
+// so unrelated rounds are not mixed.
+type voiceContext struct {
+	items []voiceContextItem
+	round int  // 0 when rounds are unknown
+	ended bool // the round is over, e.g. talk during the buy phase
+}
+
+// handle follows round and map changes from the bus
+func (c *voiceContext) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.MatchStarted:
+		*c = voiceContext{}
+	case events.RoundStarted:
+		*c = voiceContext{round: e.Round}
+	case events.RoundEnded:
+		c.ended = true
 	}
-	return transcribedText, transcribeDuration
+}
+
+// add records text and returns the context spoken before it
+func (c *voiceContext) add(text string, now time.Time) translator.VoiceContext {
+	c.items = pruneOldContext(c.items, now.Add(-voiceContextWindow))
+	vc := translator.VoiceContext{
+		ContextText: buildContextString(c.items),
+		Window:      voiceContextWindow,
+		Round:       c.round,
+		RoundOver:   c.ended,
+	}
+	if voiceContextWindow > 0 {
+		c.items = append(c.items, voiceContextItem{text: text, timestamp: now})
+	}
+	return vc
 }
 
 func pruneOldContext(context []voiceContextItem, cutoff time.Time) []voiceContextItem {
@@ -109,15 +166,12 @@ func pruneOldContext(context []voiceContextItem, cutoff time.Time) []voiceContex
 			return context[i:]
 		}
 	}
-	return context
+	return nil
 }
 
 func buildContextString(context []voiceContextItem) string {
-	if len(context) == 0 {
-		return ""
-	}
 	var sb strings.Builder
-	for i, v := range context[:len(context)-1] {
+	for i, v := range context {
 		if i > 0 {
 			sb.WriteString("\n")
 		}
@@ -126,103 +180,107 @@ func buildContextString(context []voiceContextItem) string {
 	return sb.String()
 }
 
-func handleVoiceTranscription(ctx context.Context, tr *translator.OllamaTranslator, text string, voiceContext []voiceContextItem) (string, string, float64) {
-	transcribedText, transcribeDuration := parseTranscription(text)
-
-	now := time.Now()
-	voiceContext = append(voiceContext, voiceContextItem{text: transcribedText, timestamp: now})
-
-	cutoff := now.Add(-10 * time.Second)
-	voiceContext = pruneOldContext(voiceContext, cutoff)
+func handleVoiceTranscription(ctx context.Context, tr translator.Translator, t audio.Transcription, voiceCtx *voiceContext) (translated, via string, took time.Duration) {
+	transcribedText := t.Text
+	if speaksTargetLang(t) {
+		return transcribedText, "own language", 0
+	}
+	if whisperTranslated(t) {
+		return transcribedText, "whisper", 0
+	}
+	if skipPrivate() {
+		return transcribedText, viaPrivate, 0
+	}
 
-	contextText := buildContextString(voiceContext)
+	vc := voiceCtx.add(transcribedText, time.Now())
+	switch level := latencyBudget.Level(); {
+	case level >= pipeline.LevelNoVoice:
+		return transcribedText, viaBudget, 0
+	case level >= pipeline.LevelNoContext:
+		vc.ContextText = ""
+	}
 
 	translateStart := time.Now()
-	var translated string
 	var err error
-	if len(contextText) > 0 {
-		translated, err = tr.TranslateWithContext(ctx, transcribedText, translator.VoiceContext{ContextText: contextText})
-	} else {
-		translated, err = tr.Translate(ctx, transcribedText)
-	}
-	translateDuration := time.Since(translateStart)
+	translated, err = tr.TranslateWithContext(ctx, translator.Request{
+		Text:       transcribedText,
+		Kind:       translator.KindVoice,
+		SourceLang: t.Language,
+		TargetLang: voiceLang(),
+		Context:    vc,
+		Private:    privateVoice(),
+	})
+	took = time.Since(translateStart)
 
+	if errors.Is(err, translator.ErrNoLocalBackend) {
+		return transcribedText, viaPrivate, took
+	}
 	if err != nil {
 		translated = transcribedText
 	}
 
-	return translated, fmt.Sprintf("voice %.2fs: ", translateDuration.Seconds()), transcribeDuration
+	return translated, "", took
 }
 
-func sliceAudioFile(inputPath, tmpDir string, listener *audio.Listener) {
-	go func() {
-		defer os.Remove(inputPath)
-
-		slicePath := filepath.Join(tmpDir, fmt.Sprintf("slice_%d.wav", time.Now().UnixNano()))
-
-		// log.Printf("Slicing audio: %s -> %s", inputPath, slicePath)
+// whisperTranslated reports whether Whisper already produced the target
+// language, so the LLM step can be skipped
+func whisperTranslated(t audio.Transcription) bool {
+	return t.Task == audio.TaskTranslate && translator.LanguageCode(voiceLang()) == "en"
+}
 
-		sliceCmd := exec.Command("ffmpeg", "-sseof", "-15", "-i", inputPath, "-c", "copy", "-y", slicePath)
-		if out, err := sliceCmd.CombinedOutput(); err != nil {
-			log.Printf("Quick slice failed, trying re-encode: %v", err)
-			sliceCmd = exec.Command("ffmpeg", "-sseof", "-15", "-i", inputPath, "-c:a", "pcm_s16le", "-y", slicePath)
-			if out2, err2 := sliceCmd.CombinedOutput(); err2 != nil {
-				log.Printf("Slice failed: %v\n%s\n%s", err2, string(out), string(out2))
-				return
-			}
-		}
+// speakerProfiles remembers the language of attributed speakers; nil when
+// profiles are disabled
+var speakerProfiles *speakers.Store
 
-		absPath, _ := filepath.Abs(slicePath)
-		// log.Printf("Submitting file: %s", absPath)
-		listener.SubmitFile(absPath)
-	}()
+// speaksTargetLang records the detected language and reports whether the
+// speaker is known to use the target language and this segment agrees, in
+// which case translating would only rewrite it
+func speaksTargetLang(t audio.Transcription) bool {
+	if speakerProfiles == nil || t.Speaker == "" {
+		return false
+	}
+	settled := speakerProfiles.Observe(t.Speaker, t.Language)
+	target := translator.LanguageCode(voiceLang())
+	return target != "" && settled == target && (t.Language == "" || t.Language == target)
 }
 
-func stopRecordingGracefully(cmd *exec.Cmd, stdin io.WriteCloser) {
-	if cmd == nil || cmd.Process == nil {
-		return
+// loadSpeakerProfiles opens the profile file in the config dir
+func loadSpeakerProfiles() *speakers.Store {
+	dir, err := config.Dir()
+	if err != nil {
+		slog.Warn("Speaker profiles disabled", "err", err)
+		return nil
 	}
-
-	if stdin != nil {
-		if _, err := stdin.Write([]byte("q")); err != nil {
-			// Write failed, process might be dead or stuck, try signal
-			if runtime.GOOS == "windows" {
-				cmd.Process.Kill()
-			} else {
-				cmd.Process.Signal(syscall.SIGTERM)
-			}
-		}
-		stdin.Close()
-	} else {
-		if runtime.GOOS == "windows" {
-			cmd.Process.Kill()
-		} else {
-			cmd.Process.Signal(syscall.SIGTERM)
-		}
+	store, err := speakers.Load(filepath.Join(dir, "speakers.json"))
+	if err != nil {
+		slog.Warn("Starting with empty speaker profiles", "err", err)
 	}
+	return store
+}
 
-	done := make(chan error, 1)
-	go func() { done <- cmd.Wait() }()
-	select {
-	case <-done:
-	case <-time.After(500 * time.Millisecond):
-		log.Println("Warning: ffmpeg process did not exit in time, killing...")
-		cmd.Process.Kill()
-		// Don't block forever if it's truly stuck, though this leaks a goroutine
-		select {
-		case <-done:
-		case <-time.After(1 * time.Second):
-			log.Println("Error: ffmpeg process stuck even after Kill")
+// splitList parses a comma-separated flag value
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
 }
 
-func renameWithRetry(from, to string) error {
-	for i := 0; i < 10; i++ {
-		if err := os.Rename(from, to); err == nil {
-			return nil
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	return os.Rename(from, to)
+// transcriberEvent converts a transcriber health change for the bus
+func transcriberEvent(s audio.Status) events.Status {
+	return events.Status{Source: "voice", State: string(s.State), Message: s.String(), Err: s.Err}
+}
+
+// spokenAt estimates when the speech of t began, from the length of its
+// audio and the time it waited and took to transcribe before now
+func spokenAt(t audio.Transcription, now time.Time) time.Time {
+	return now.Add(-(t.Duration + t.Waited + t.Elapsed))
+}
+
+// transcriptEvent converts a transcription for the bus
+func transcriptEvent(t audio.Transcription) events.TranscriptDone {
+	return events.TranscriptDone{Speaker: t.Speaker, Capture: t.Capture, Text: t.Text, Language: t.Language, Elapsed: t.Elapsed}
 }
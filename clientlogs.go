@@ -0,0 +1,109 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/monitor"
+	"github.com/micha/cs-ingame-translate/parser"
+)
+
+// clientLogs follows the chat logs of matchmaking clients; nil unless
+// client_logs or -client-log is set
+var clientLogs *monitor.Group
+
+// clientLogFormats is how the lines of each followed client log read
+var clientLogFormats []clientLog
+
+type clientLog struct {
+	path   string // file or glob pattern
+	format *parser.ClientFormat
+}
+
+// defaultClientLog is where a client keeps its log; "" when unknown
+func defaultClientLog(client string) string {
+	dir, err := os.UserConfigDir() // %AppData%
+	if err != nil || runtime.GOOS != "windows" {
+		return ""
+	}
+	switch client {
+	case "faceit":
+		return filepath.Join(dir, "FACEIT", "logs", "*.log")
+	case "esea":
+		return filepath.Join(dir, "ESEA", "logs", "*.log")
+	}
+	return ""
+}
+
+// parseClientLogFlag reads -client-log: a client, e.g. faceit, or
+// client=path
+func parseClientLogFlag(v string) config.ClientLogConfig {
+	client, path, _ := strings.Cut(v, "=")
+	return config.ClientLogConfig{Client: strings.ToLower(strings.TrimSpace(client)), Path: strings.TrimSpace(path)}
+}
+
+// followClientLogs starts following the client logs cfgs name
+func followClientLogs(cfgs []config.ClientLogConfig) error {
+	var formats []clientLog
+	var patterns []string
+	for _, c := range cfgs {
+		pattern, builtIn := parser.ClientFormats[c.Client]
+		if c.Pattern != "" {
+			pattern = parser.ClientPattern{Pattern: c.Pattern}
+		} else if !builtIn {
+			return fmt.Errorf("client log %q needs a pattern; built in are faceit, esea and steam", c.Client)
+		}
+		if c.Room != "" {
+			pattern.Room = c.Room
+		}
+		format, err := parser.NewClientFormat(c.Client, pattern)
+		if err != nil {
+			return err
+		}
+		path := cmp.Or(c.Path, defaultClientLog(c.Client))
+		if path == "" {
+			return fmt.Errorf("client log %q needs a path, e.g. -client-log %s=/path/to/chat.log", c.Client, c.Client)
+		}
+		formats = append(formats, clientLog{path: path, format: format})
+		patterns = append(patterns, path)
+	}
+	logs, err := monitor.NewGroup(patterns, func(path string) {
+		fmt.Print(i18n.T("Monitoring client log: %s\n", path))
+	})
+	if err != nil {
+		return err
+	}
+	if len(logs.Files()) == 0 {
+		fmt.Print(i18n.T("Waiting for client logs matching %s\n", strings.Join(patterns, ", ")))
+	}
+	clientLogs, clientLogFormats = logs, formats
+	return nil
+}
+
+// clientLogLines returns the lines of clientLogs, or nil when it is not set
+func clientLogLines() chan *monitor.Line {
+	if clientLogs == nil {
+		return nil
+	}
+	return clientLogs.Lines()
+}
+
+// clientFormat is the format of the client log at path; nil for the
+// console and server logs
+func clientFormat(path string) *parser.ClientFormat {
+	for _, c := range clientLogFormats {
+		if c.path == path {
+			return c.format
+		}
+		if ok, _ := filepath.Match(c.path, path); ok {
+			return c.format
+		}
+	}
+	return nil
+}
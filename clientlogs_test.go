@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+)
+
+// Chat appended to FACEIT's, ESEA's and Steam chat logs, and to one read
+// with a pattern of its own, comes out as chat of the right room, a room
+// set for a built-in format included; other lines and what was there
+// before are not
+func TestClientLogs(t *testing.T) {
+	dir := t.TempDir()
+	if err := followClientLogs([]config.ClientLogConfig{{Client: "matchmaker"}}); err == nil {
+		t.Fatal("a client without a built-in format was followed without a pattern")
+	}
+	logs := map[string]string{
+		"faceit":  filepath.Join(dir, "FACEIT", "main.log"),
+		"esea":    filepath.Join(dir, "ESEA", "client.log"),
+		"steam":   filepath.Join(dir, "steam", "chat.txt"),
+		"friends": filepath.Join(dir, "steam", "friends.txt"),
+		"mm":      filepath.Join(dir, "mm.log"),
+	}
+	for _, path := range logs {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("2026-10-14 21:00:00 CHAT [ALL] old: from before\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := followClientLogs([]config.ClientLogConfig{
+		{Client: "faceit", Path: logs["faceit"]},
+		{Client: "esea", Path: filepath.Join(dir, "ESEA", "*.log")},
+		{Client: "steam", Path: logs["steam"]},
+		{Client: "steam", Path: logs["friends"], Room: "team"},
+		{Client: "mm", Path: logs["mm"], Pattern: `^(?P<player>\w+) says: (?P<text>.+)$`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	defer func() {
+		clientLogs.Stop()
+		<-done
+		clientLogs, clientLogFormats = nil, nil
+	}()
+	lines := clientLogs.Lines()
+	stop := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case line := <-lines:
+				if line.Err == nil {
+					publishLogLine(line)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	defer close(stop)
+
+	got := make(chan events.ChatReceived, 10)
+	unsubscribe := bus.Subscribe(func(e events.Event) {
+		if c, ok := e.(events.ChatReceived); ok {
+			c.ID = 0 // compared with want, which has no IDs
+			got <- c
+		}
+	})
+	defer unsubscribe()
+	appendLine := func(path, line string) error {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(f, line)
+		return errors.Join(err, f.Close())
+	}
+	writes := [][2]string{
+		{logs["faceit"], "[2026-10-14 21:03:11.250] [info]  joined match room 1-abc"},
+		{logs["faceit"], "[2026-10-14 21:03:12.001] [info]  chat: [match] ivan: всем удачи"},
+		{logs["esea"], "2026-10-14 21:03:13 CHAT [TEAM] olga: играем от б"},
+		{logs["mm"], "bob says: hola a todos"},
+		{logs["steam"], "[2026-10-14 21:03:14] l1ght: invite me"},
+		{logs["friends"], "[2026-10-14 21:03:14] olga: смоки на мид"},
+		{logs["faceit"], "[2026-10-14 21:03:14.502] [info]  chat: [faction] ivan: раш б"},
+	}
+	for _, w := range writes {
+		if err := appendLine(w[0], w[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []events.ChatReceived{
+		{Player: "ivan", Team: "ALL", Text: "всем удачи", Line: "[FACEIT] [ALL] ivan: всем удачи", Log: logs["faceit"]},
+		{Player: "olga", Team: "TEAM", Text: "играем от б", Line: "[ESEA] [TEAM] olga: играем от б", Log: logs["esea"]},
+		{Player: "bob", Team: "ALL", Text: "hola a todos", Line: "[MM] [ALL] bob: hola a todos", Log: logs["mm"]},
+		{Player: "l1ght", Team: "PARTY", Text: "invite me", Line: "[STEAM] [PARTY] l1ght: invite me", Log: logs["steam"]},
+		{Player: "olga", Team: "TEAM", Text: "смоки на мид", Line: "[STEAM] [TEAM] olga: смоки на мид", Log: logs["friends"]},
+		{Player: "ivan", Team: "TEAM", Text: "раш б", Line: "[FACEIT] [TEAM] ivan: раш б", Log: logs["faceit"]},
+	}
+	// The logs are followed separately, so only the order within one holds
+	var chats []events.ChatReceived
+	timeout := time.After(testTimeout)
+	for len(chats) < len(want) {
+		select {
+		case c := <-got:
+			chats = append(chats, c)
+		case <-timeout:
+			t.Fatalf("got %d of %d chat lines: %+v", len(chats), len(want), chats)
+		}
+	}
+	for _, w := range want {
+		if !slices.Contains(chats, w) {
+			t.Fatalf("no chat %+v in %+v", w, chats)
+		}
+	}
+	if slices.Index(chats, want[0]) > slices.Index(chats, want[5]) {
+		t.Fatalf("FACEIT chat out of order: %+v", chats)
+	}
+	select {
+	case c := <-got:
+		t.Fatalf("unexpected chat %+v", c)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
 	"github.com/micha/cs-ingame-translate/setup"
 )
 
@@ -25,31 +28,54 @@ func checkCondebug(scanner *bufio.Scanner) error {
 	foundConfig, configured := findCondebugInConfigs(dataPaths)
 
 	if !foundConfig {
-		fmt.Println("Warning: Could not verify launch options.")
+		fmt.Println(i18n.T("Warning: Could not verify launch options."))
 		return nil
 	}
 
 	if !configured {
-		fmt.Println("CS2 launch option '-condebug' not detected.")
-		fmt.Printf("Do you want to open Steam properties for CS2 to set it? [Y/n]: ")
-		if scanner.Scan() {
-			text := strings.TrimSpace(scanner.Text())
-			if text == "" || strings.ToLower(text) == "y" || strings.ToLower(text) == "yes" {
-				return openSteamSettings()
+		fmt.Println(i18n.T("CS2 launch option '-condebug' not detected."))
+		if !steamRunning() {
+			fmt.Print(i18n.T("Add it to the launch options now (localconfig.vdf is backed up first)? [Y/n]: "))
+			if promptYes(scanner) {
+				if err := addCondebug(dataPaths); err != nil {
+					fmt.Print(i18n.T("Could not edit the launch options: %v\n", err))
+				} else {
+					return nil
+				}
 			}
+		} else {
+			fmt.Println(i18n.T("Steam is running, so it cannot be added automatically (close Steam to have cs-translate edit it)."))
 		}
+		fmt.Print(i18n.T("Do you want to open Steam properties for CS2 to set it? [Y/n]: "))
+		if promptYes(scanner) {
+			return openSteamSettings()
+		}
+		return errCondebugMissing
 	}
 	return nil
 }
 
+// promptYes reads an answer that defaults to yes
+func promptYes(scanner *bufio.Scanner) bool {
+	if !scanner.Scan() {
+		return false
+	}
+	text := strings.TrimSpace(scanner.Text())
+	return text == "" || i18n.Yes(text)
+}
+
 func findCondebugInConfigs(dataPaths []string) (bool, bool) {
 	configPaths := getConfigFilePaths(dataPaths)
 	for _, configPath := range configPaths {
-		contentBytes, err := os.ReadFile(configPath)
+		root, _, err := readLocalConfig(configPath)
 		if err != nil {
+			// Fall back to a plain search if the file does not parse
+			if contentBytes, err := os.ReadFile(configPath); err == nil && strings.Contains(string(contentBytes), "-condebug") {
+				return true, true
+			}
 			continue
 		}
-		if strings.Contains(string(contentBytes), "-condebug") {
+		if hasCondebug(root) {
 			return true, true
 		}
 	}
@@ -61,25 +87,62 @@ func openSteamSettings() error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "linux":
-		cmd = exec.Command("xdg-open", url)
+		cmd = execwrap.Command("xdg-open", url)
 	case "windows":
-		cmd = exec.Command("cmd", "/c", "start", url)
+		cmd = execwrap.Command("cmd", "/c", "start", url)
 	case "darwin":
-		cmd = exec.Command("open", url)
+		cmd = execwrap.Command("open", url)
 	default:
 		return fmt.Errorf("unsupported OS")
 	}
+	if setup.DryRun {
+		fmt.Print(i18n.T("[dry run] %s\n", strings.Join(cmd.Args, " ")))
+		return nil
+	}
 	return cmd.Start()
 }
 
+// keepContainer leaves the container running on exit even if we started it
+var keepContainer bool
+
 func stopDockerContainer() {
-	cmd := exec.Command("docker", "stop", "cs-translate")
+	if keepContainer || !setup.StartedContainer() {
+		return
+	}
+	fmt.Println(i18n.T("Stopping Docker container..."))
+	cmd := execwrap.Command("docker", "stop", setup.ContainerName)
 	cmd.Run()
 }
 
-func ensureEnvironment(scanner *bufio.Scanner, useVoice bool) error {
-	if err := setup.EnsureEnvironment(scanner, useVoice); err != nil {
+// ensureEnvironment runs the setup wizard; steps follow the built-in ones
+func ensureEnvironment(scanner *bufio.Scanner, model string, useVoice bool, steps ...setup.Step) error {
+	err := setup.RunWizard(scanner, setup.Options{
+		Model: model,
+		Voice: useVoice,
+		Steps: steps,
+		Warn: func(err error) {
+			if !printHint(err) {
+				slog.Warn("Setup", "err", err)
+			}
+		},
+	})
+	if err != nil {
 		return fmt.Errorf("setup failed: %v", err)
 	}
 	return nil
 }
+
+// condebugStep checks the -condebug launch option as the last setup step,
+// offering to add it
+func condebugStep() setup.Step {
+	return setup.Step{
+		Name:  "condebug",
+		Title: i18n.T("CS2 launch option -condebug is set"),
+		Check: func() error {
+			_, err := checkCondebugConfigured()
+			return err
+		},
+		Run:      checkCondebug,
+		Optional: true,
+	}
+}
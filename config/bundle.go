@@ -0,0 +1,201 @@
+package config
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+const (
+	// bundleConfig is the settings file inside a bundle
+	bundleConfig = "config.json"
+	// maxBundleFile bounds each file read from a bundle
+	maxBundleFile = 1 << 20
+)
+
+// BundleFiles are files from Dir shared in a bundle next to the settings,
+// when they exist: prompt variants for cs-translate bench
+var BundleFiles = []string{"prompts.json"}
+
+// Bundle is a shareable set of settings
+type Bundle struct {
+	Config Config
+	Files  map[string][]byte // BundleFiles found in the bundle, by name
+}
+
+// Change is one setting that an import would change
+type Change struct {
+	Key      string
+	Old, New string
+}
+
+// Shareable returns cfg with the machine-local settings reset to defaults
+func Shareable(cfg Config) Config {
+	return withLocal(cfg, Default())
+}
+
+// withLocal returns c with every share:"local" field taken from from
+func withLocal(c, from Config) Config {
+	copyLocal(reflect.ValueOf(&c).Elem(), reflect.ValueOf(from))
+	return c
+}
+
+func copyLocal(dst, src reflect.Value) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.Tag.Get("share") == "local":
+			dst.Field(i).Set(src.Field(i))
+		case field.Type.Kind() == reflect.Struct && field.Type != durationType:
+			copyLocal(dst.Field(i), src.Field(i))
+		}
+	}
+}
+
+// ExportBundle writes the shareable settings and BundleFiles to a zip file
+func ExportBundle(path string, cfg Config) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	data, err := json.MarshalIndent(Shareable(cfg), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, bundleConfig, append(data, '\n')); err != nil {
+		return err
+	}
+	for _, name := range BundleFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeZipFile(zw, name, data); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadBundle opens a bundle written by ExportBundle. Settings missing from
+// it get their defaults; unknown files are ignored.
+func ReadBundle(path string) (*Bundle, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	b := &Bundle{Config: Default(), Files: map[string][]byte{}}
+	found := false
+	for _, f := range zr.File {
+		if f.Name != bundleConfig && !isBundleFile(f.Name) {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s in %s: %w", f.Name, path, err)
+		}
+		if f.Name == bundleConfig {
+			if err := json.Unmarshal(data, &b.Config); err != nil {
+				return nil, fmt.Errorf("%s in %s: %w", f.Name, path, err)
+			}
+			found = true
+			continue
+		}
+		b.Files[f.Name] = data
+	}
+	if !found {
+		return nil, fmt.Errorf("%s holds no %s", path, bundleConfig)
+	}
+	return b, nil
+}
+
+func isBundleFile(name string) bool {
+	for _, n := range BundleFiles {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// Settings are small; refuse anything that is not
+	data, err := io.ReadAll(io.LimitReader(rc, maxBundleFile+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBundleFile {
+		return nil, fmt.Errorf("larger than %d bytes", maxBundleFile)
+	}
+	return data, nil
+}
+
+// Merge applies the bundle's shareable settings on top of current, keeping
+// the machine-local ones
+func (b *Bundle) Merge(current Config) Config {
+	return withLocal(b.Config, current)
+}
+
+// Diff lists the settings that differ between old and new
+func Diff(old, new Config) []Change {
+	var before, after []Option
+	collectOptions(reflect.ValueOf(old), "", &before)
+	collectOptions(reflect.ValueOf(new), "", &after)
+	var changes []Change
+	for i := range before {
+		if before[i].Default != after[i].Default {
+			changes = append(changes, Change{Key: before[i].Key, Old: before[i].Default, New: after[i].Default})
+		}
+	}
+	return changes
+}
+
+// ApplyBundle saves cfg as the settings file and writes the bundle's files
+// into Dir
+func ApplyBundle(b *Bundle, cfg Config) error {
+	if err := Save(cfg); err != nil {
+		return err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	for name, data := range b.Files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
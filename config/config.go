@@ -0,0 +1,652 @@
+// Package config holds the persistent settings file. Every option carries a
+// doc tag; the reference page and JSON schema are generated from them.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/hooks"
+	"github.com/micha/cs-ingame-translate/mqtt"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/plugins"
+	"github.com/micha/cs-ingame-translate/resources"
+	"github.com/micha/cs-ingame-translate/subtitle"
+	"github.com/micha/cs-ingame-translate/telegram"
+	"github.com/micha/cs-ingame-translate/translator"
+	"github.com/micha/cs-ingame-translate/twitch"
+)
+
+// FileName is the settings file inside Dir
+const FileName = "config.json"
+
+const (
+	// LogEnv overrides log_path and log_profiles
+	LogEnv = "CS_TRANSLATE_LOG"
+	// ProfileEnv selects the log_profiles entry; the host name is used
+	// when it is unset
+	ProfileEnv = "CS_TRANSLATE_PROFILE"
+	// DirEnv overrides Dir, e.g. for a service that runs as another user
+	DirEnv = "CS_TRANSLATE_CONFIG_DIR"
+)
+
+// Duration is a time.Duration that reads and writes as "3s" in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"3s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// HTTPConfig tunes the connection to Ollama
+type HTTPConfig struct {
+	RequestTimeout Duration `json:"request_timeout" flag:"request-timeout" doc:"Timeout for a single translation request"`
+	ClientTimeout  Duration `json:"client_timeout" flag:"http-timeout" doc:"Upper bound for any request to Ollama"`
+	Pool           int      `json:"pool" flag:"http-pool" doc:"Keep-alive connections kept open to Ollama"`
+	HTTP2          bool     `json:"http2" flag:"http2" doc:"Talk HTTP/2 to Ollama (h2c for http:// hosts; the server must support it)"`
+	Concurrency    int      `json:"concurrency" flag:"ollama-concurrency" doc:"Requests sent to Ollama at once, counting tone tags and summaries; the rest wait without using up request_timeout (0: no limit)"`
+	VoiceWeight    int      `json:"voice_weight" flag:"voice-weight" doc:"While voice and chat both wait for Ollama, voice gets this many of the freed places for every chat_weight chat gets"`
+	ChatWeight     int      `json:"chat_weight" flag:"chat-weight" doc:"See voice_weight"`
+}
+
+// WhisperConfig tunes voice transcription
+type WhisperConfig struct {
+	Model        string   `json:"model" flag:"whisper-model" doc:"Whisper model for live segments in CS2 mode (tiny, base, small)"`
+	CaptureModel string   `json:"capture_model" flag:"whisper-capture-model" doc:"Whisper model for 15s F9 captures in echo mode (medium, turbo)"`
+	Languages    []string `json:"languages" flag:"whisper-lang" doc:"Expected spoken languages as Whisper codes, e.g. [\"de\", \"ru\"] (empty: detect any)"`
+	Task         string   `json:"task" flag:"whisper-task" doc:"transcribe, or translate to have Whisper output English directly"`
+
+	MinAvgLogProb   float64  `json:"min_avg_logprob" flag:"whisper-min-logprob" doc:"Drop transcriptions whose average log probability is below this (0 disables)"`
+	MaxNoSpeechProb float64  `json:"max_no_speech_prob" flag:"whisper-max-no-speech" doc:"Drop transcriptions whose no-speech probability is above this (0 disables)"`
+	Blocklist       []string `json:"blocklist" doc:"Phrases Whisper hallucinates from noise; dropped when they are the whole transcription"`
+
+	BandPass    bool   `json:"band_pass" flag:"band-pass" doc:"Keep only the 300-3400 Hz voice band of captured audio"`
+	Denoise     bool   `json:"denoise" flag:"denoise" doc:"Remove steady background noise from captured audio (ffmpeg afftdn)"`
+	Normalize   bool   `json:"normalize" flag:"normalize" doc:"Even out the loudness of captured audio (ffmpeg loudnorm)"`
+	AudioFilter string `json:"audio_filter" flag:"audio-filter" doc:"Extra ffmpeg -af filter chain for captured audio, applied after the others"`
+
+	MaxBacklog Duration `json:"max_backlog" flag:"max-backlog" doc:"Drop live voice segments that waited longer than this for the transcriber"`
+	Segment    Duration `json:"segment" flag:"whisper-segment" doc:"Length of live voice segments; longer is more accurate but adds latency"`
+
+	TempMaxMB  int      `json:"temp_max_mb" flag:"temp-max-mb" doc:"Megabytes of audio files the temp folder may hold in a long session before the oldest are deleted"`
+	TempMaxAge Duration `json:"temp_max_age" flag:"temp-max-age" doc:"Delete audio files left in the temp folder, and in the container's /tmp, this long"`
+
+	Intermediate string `json:"intermediate" flag:"whisper-intermediate" doc:"How live voice segments reach Whisper: wav files, flac or opus files that write less to disk, or pipe to keep them in memory and send them over the transcriber's stdin (empty: wav)"`
+}
+
+// DiscordConfig joins a Discord voice channel as a bot. The token comes from
+// the CS_TRANSLATE_DISCORD_TOKEN environment variable.
+type DiscordConfig struct {
+	GuildID   string `json:"guild_id" doc:"Discord server (guild) ID"`
+	ChannelID string `json:"channel_id" flag:"discord-channel" doc:"Voice channel ID to transcribe (empty: Discord disabled)"`
+}
+
+// HotkeyConfig binds the hotkeys to other keys, mouse side buttons or
+// gamepad buttons
+type HotkeyConfig struct {
+	Pause   string `json:"pause" flag:"pause-key" doc:"Key or button that pauses instead of F8: f1 to f12, mouse4, mouse5, or pad-a, pad-b, pad-x, pad-y, pad-lb, pad-rb, pad-back, pad-start, pad-ls, pad-rs (empty: F8)"`
+	Capture string `json:"capture" flag:"capture-key" doc:"Key or button that captures voice instead of F9, named as for pause (empty: F9)"`
+	Talk    string `json:"talk" flag:"talk-key" doc:"Key or button that starts and stops talk mode instead of F10, named as for pause (empty: F10)"`
+}
+
+// GameChatConfig relays translations into CS2 chat through a cfg file that
+// a key bound in the game executes
+type GameChatConfig struct {
+	Mode     string `json:"mode" flag:"game-chat" doc:"Write the latest translation to cs_translate_chat.cfg, for a key bound in CS2 to post it to the chat: all, or talk for your own translated speech only (empty: off)"`
+	Command  string `json:"command" flag:"game-chat-command" doc:"say_team to post to your team, or say for everyone"`
+	Bind     string `json:"bind" flag:"game-chat-bind" doc:"CS2 key bound to post it, added to autoexec.cfg, e.g. kp_enter (empty: bind it yourself)" share:"local"`
+	MaxBytes int    `json:"max_bytes" doc:"Longer translations are cut to this many bytes with ..., as CS2 does not send long chat"`
+	CfgDir   string `json:"cfg_dir" doc:"CS2's game/csgo/cfg folder (empty: found next to the console log or in the Steam libraries)" share:"local"`
+}
+
+// ContextConfig is the recent speech given to voice translations as context
+type ContextConfig struct {
+	Window     Duration `json:"window" flag:"context-window" doc:"How far back recent speech is given to voice translation as context (0s: no context)"`
+	MaxEntries int      `json:"max_entries" flag:"context-entries" doc:"Most recent transcriptions kept as context (0: no limit)"`
+	MaxTokens  int      `json:"max_tokens" flag:"context-tokens" doc:"Estimated tokens the context may take in a prompt; the oldest lines are dropped first (0: no limit)"`
+	Prompt     string   `json:"prompt" doc:"Voice prompt with context; {when}, {context}, {lang} and {text} stand for its age, the recent speech, the target language and the message (empty: built-in)"`
+}
+
+// TalkConfig translates the user's own microphone for the team
+type TalkConfig struct {
+	Enabled   bool   `json:"enabled" flag:"talk" doc:"Press F10 to start and stop translating your microphone (CS2 mode)"`
+	Lang      string `json:"lang" flag:"talk-lang" doc:"Language your team speaks; your speech is translated into it"`
+	Mic       string `json:"mic" flag:"mic" doc:"Microphone to record (empty: system default on Linux)" share:"local"`
+	TTS       bool   `json:"tts" flag:"tts" doc:"Speak the translation with text-to-speech"`
+	TTSDevice string `json:"tts_device" flag:"tts-device" doc:"Output for text-to-speech, e.g. a virtual cable used as microphone (empty: default output)" share:"local"`
+}
+
+// HookConfig runs a command on a pipeline event
+type HookConfig struct {
+	Event   string   `json:"event" doc:"Event to run on: on_translation or on_match_start"`
+	Command []string `json:"command" doc:"Program and arguments; the event is passed as JSON on stdin" secret:"args"`
+	Timeout Duration `json:"timeout" doc:"Kill the command after this long (0s: 5s)"`
+}
+
+// PluginConfig runs a plugin program for the whole session
+type PluginConfig struct {
+	Name    string   `json:"name" doc:"Name shown in logs (empty: the program)"`
+	Command []string `json:"command" doc:"Program and arguments; it speaks JSON Lines on stdin and stdout" secret:"args"`
+	Events  []string `json:"events" doc:"Event kinds sent to the plugin, e.g. translation_done (empty: all)"`
+	Filter  bool     `json:"filter" doc:"Chat and voice translations wait for the plugin to keep, change, highlight or drop them"`
+	Timeout Duration `json:"timeout" doc:"Show a translation unchanged when the filter has not answered after this long (0s: 500ms)"`
+}
+
+// BackendConfig is one translation backend of the fallback chain
+type BackendConfig struct {
+	Type    string   `json:"type" doc:"ollama, libretranslate or passthrough (shows the original)"`
+	URL     string   `json:"url,omitempty" doc:"LibreTranslate server (empty: http://localhost:5000); its API key is read from CS_TRANSLATE_LIBRETRANSLATE_KEY"`
+	Model   string   `json:"model,omitempty" doc:"Ollama model (empty: model)"`
+	Timeout Duration `json:"timeout" doc:"Hand the message to the next backend after this long (0s: no limit)"`
+	Local   bool     `json:"local,omitempty" doc:"Trust this backend with private messages although it does not run on this machine, e.g. Ollama on your LAN"`
+}
+
+// LangRuleSources are the messages a LangRule can apply to: all chat,
+// all-chat, team chat and voice
+var LangRuleSources = []string{"chat", "all", "team", "voice"}
+
+// LangRule translates one kind of message into its own target language
+type LangRule struct {
+	Source string `json:"source" doc:"Messages the rule applies to: chat, all (all-chat), team (team chat) or voice"`
+	Lang   string `json:"lang" doc:"Target language for them, written like lang"`
+}
+
+// SpamConfig protects the translator from chat spam
+type SpamConfig struct {
+	DedupeWindow Duration `json:"dedupe_window" flag:"dedupe-window" doc:"Collapse a player's message that repeats their previous one within this window; it is shown once and not translated again (0s disables)"`
+	Rate         float64  `json:"rate" flag:"chat-rate" doc:"Chat translations per second one player gets on average; messages beyond it are shown untranslated (0: unlimited)"`
+	Burst        int      `json:"burst" flag:"chat-burst" doc:"Messages a player may send at once before chat_rate applies"`
+}
+
+// AlertConfig makes translations that mention something important stand out
+type AlertConfig struct {
+	Keywords []string `json:"keywords" flag:"alert" doc:"Words and phrases, e.g. your name, rush or plant A, that highlight a chat or voice line mentioning them in its translation or original; they match as whole words in any case, and /.../ is a regular expression"`
+	Sound    string   `json:"sound" flag:"alert-sound" doc:"Played when a line is highlighted: bell for the terminal bell, or the path of a WAV file (empty: silent)" share:"local"`
+}
+
+// ToneConfig tags chat as friendly, neutral or toxic
+type ToneConfig struct {
+	Model     string `json:"model" flag:"tone-model" doc:"Ollama model that tags translated chat as friendly, neutral or toxic in a second pass, e.g. gemma3:1b; a summary is printed after each match (empty: off)"`
+	HideToxic bool   `json:"hide_toxic" flag:"hide-toxic" doc:"Show chat tagged as toxic as hidden instead of printing it"`
+}
+
+// TranscriptConfig keeps a written record of each match
+type TranscriptConfig struct {
+	Save    bool `json:"save" flag:"transcript" doc:"Write the chat and voice of each match, with their translations, to a file in the transcripts folder of the settings directory"`
+	Summary bool `json:"summary" flag:"summary" doc:"When a match ends and on exit, have the model summarize team communication, key calls and notable moments in the target language, saved next to the transcript; implies save"`
+}
+
+// OBSConfig subtitles OBS recordings and shows translations in OBS. The
+// obs-websocket password comes from the CS_TRANSLATE_OBS_PASSWORD
+// environment variable.
+type OBSConfig struct {
+	Addr      string   `json:"addr" flag:"obs" doc:"obs-websocket address, e.g. localhost:4455 (empty: off)" share:"local"`
+	Subtitles bool     `json:"subtitles" flag:"obs-subtitles" doc:"While OBS records, time voice against the video and save a subtitle track next to it when the recording stops"`
+	Format    string   `json:"format" flag:"obs-format" doc:"Subtitle format of recordings: srt or vtt"`
+	Source    string   `json:"source" flag:"obs-source" doc:"Text source that shows the latest translations, for streams without a browser source (empty: none)"`
+	Scene     string   `json:"scene" flag:"obs-scene" doc:"Scene the text source is added to when OBS has no source of that name (empty: it must exist)"`
+	Lines     int      `json:"lines" flag:"obs-lines" doc:"Translations the text source shows at once, the newest last"`
+	Hold      Duration `json:"hold" flag:"obs-hold" doc:"Take a translation off the text source after this long (0s: keep it until newer ones push it out)"`
+	Captions  bool     `json:"captions" flag:"obs-captions" doc:"Send translations as closed captions of the stream while OBS streams"`
+	Chat      bool     `json:"chat" flag:"obs-chat" doc:"Also subtitle and show chat, not only voice"`
+}
+
+// TwitchConfig posts translations to a Twitch channel's chat. The OAuth
+// token comes from the CS_TRANSLATE_TWITCH_TOKEN environment variable.
+type TwitchConfig struct {
+	Channel string `json:"channel" flag:"twitch" doc:"Twitch channel whose chat gets the translations of all-chat, so viewers can follow foreign lobbies (empty: off)" share:"local"`
+	Nick    string `json:"nick" flag:"twitch-nick" doc:"Account that posts, e.g. a bot made a moderator (empty: the channel)" share:"local"`
+	Team    bool   `json:"team" flag:"twitch-team" doc:"Also post team chat and voice, which tells viewers your team's calls"`
+	Rate    int    `json:"rate" flag:"twitch-rate" doc:"Messages posted per 30 seconds at most; Twitch allows 20, or 100 for moderators and the broadcaster"`
+	Command string `json:"command" doc:"Chat command with which the broadcaster and moderators pause and resume the relay, e.g. !translate off (empty: none)"`
+}
+
+// TelegramConfig mirrors translations to a Telegram chat, where the bot
+// also takes commands. The bot token comes from the
+// CS_TRANSLATE_TELEGRAM_TOKEN environment variable.
+type TelegramConfig struct {
+	Chat string `json:"chat" flag:"telegram" doc:"Telegram chat ID or @channel that gets the translations and may send the bot commands, e.g. a coach following from a phone (empty: off)" share:"local"`
+}
+
+// MQTTConfig publishes events to an MQTT broker, e.g. for home automation.
+// The password comes from the CS_TRANSLATE_MQTT_PASSWORD environment
+// variable.
+type MQTTConfig struct {
+	Broker   string   `json:"broker" flag:"mqtt" doc:"MQTT broker that gets the events, e.g. localhost:1883 or mqtts://broker.example.com (empty: off)" share:"local"`
+	Username string   `json:"username" flag:"mqtt-user" doc:"Username on the broker (empty: none)" share:"local"`
+	Prefix   string   `json:"prefix" flag:"mqtt-prefix" doc:"Topic prefix; each event goes to <prefix>/<event kind>, e.g. cs-translate/translation_done"`
+	Events   []string `json:"events" doc:"Event kinds published, e.g. translation_done, or alert for highlighted translations (empty: all)"`
+}
+
+// ClientLogConfig follows the chat log of a matchmaking client such as
+// FACEIT's, whose match room chat never reaches console.log, or of Steam
+// chat
+type ClientLogConfig struct {
+	Client  string `json:"client" doc:"faceit, esea, steam, or a name of your own for another client read with pattern"`
+	Path    string `json:"path" doc:"Log file or glob pattern of the client (empty: where the FACEIT or ESEA client keeps it on Windows)"`
+	Pattern string `json:"pattern" doc:"Regular expression for its chat lines with the named groups player and text, and optionally team (empty: the built-in format of faceit, esea or steam)"`
+	Room    string `json:"room" doc:"Team of chat lines pattern finds no team in, e.g. PARTY to treat them as team chat (empty: ALL)"`
+}
+
+// CaptureSource is one of several audio devices captured at once,
+// e.g. the game's output and Discord's, whose speech is shown with its label
+type CaptureSource struct {
+	Label  string `json:"label" doc:"Tag of its transcriptions, e.g. game or discord: lowercase letters, digits and -"`
+	Device string `json:"device" doc:"Audio device, by name or by its number in -list-audio-devices, or app:<program> to record one program, e.g. app:discord (empty: auto-detect)"`
+}
+
+// LoggingConfig is cs-translate's own log, the one to attach to bug reports
+type LoggingConfig struct {
+	Level  string `json:"level" flag:"log-level" doc:"Messages shown in the console: warn, info or debug (-v is info, -debug is debug)"`
+	Format string `json:"format" flag:"log-format" doc:"text, or json for one JSON object per line in the console and the log file"`
+	File   bool   `json:"file" doc:"Also write cs-translate.log in the settings directory, with at least the info messages; it is rotated at 5 MB and three old files are kept"`
+}
+
+// Config is the full settings file. Command line flags override it. Fields
+// tagged share:"local" describe this machine and stay out of settings
+// bundles; those tagged secret are redacted in bug reports.
+type Config struct {
+	LogPath         string            `json:"log_path" flag:"log" doc:"Path or glob of the CS2 console log; several are separated like PATH entries and followed as one (empty: auto-detect)" share:"local"`
+	LogProfiles     map[string]string `json:"log_profiles" doc:"Console log path per profile, overriding log_path; the profile is CS_TRANSLATE_PROFILE or the host name" share:"local"`
+	LogWait         Duration          `json:"log_wait" flag:"log-wait" doc:"Stop waiting for an auto-detected console log after this long (0s: wait forever)"`
+	ClientLogs      []ClientLogConfig `json:"client_logs" doc:"Chat logs of matchmaking clients such as FACEIT and ESEA, followed next to the console log" share:"local"`
+	LogListen       string            `json:"log_listen" flag:"log-listen" doc:"Address to receive server logs on, sent with logaddress_add (UDP) or logaddress_add_http, e.g. :27500 (empty: off)" share:"local"`
+	LogSecret       string            `json:"log_secret" flag:"log-secret" doc:"Secret the server's logs must carry: sv_logsecret for UDP, the URL path for HTTP" share:"local" secret:"true"`
+	Model           string            `json:"model" flag:"model" doc:"Ollama model used for translation"`
+	CPU             bool              `json:"cpu" flag:"cpu" doc:"Use models and timings a laptop CPU keeps up with: gemma3:1b, the tiny and base Whisper models, longer voice segments and timeouts; expect a few seconds per translation"`
+	Lang            string            `json:"lang" flag:"lang" doc:"Target language for translation"`
+	LangRules       []LangRule        `json:"lang_rules" doc:"Target languages for some messages instead of lang, e.g. team chat into German and all-chat into English; the first rule for a message wins"`
+	UILang          string            `json:"ui_lang" flag:"ui-lang" doc:"Language of cs-translate's own messages: en, de or ru (empty: from LANG or the system)" share:"local"`
+	Prompt          string            `json:"prompt" doc:"Chat translation prompt; {lang} and {text} stand for the target language and the message, see cs-translate bench (empty: built-in)"`
+	Backends        []BackendConfig   `json:"backends" doc:"Translation backends tried in order until one answers, e.g. ollama, then libretranslate, then passthrough (empty: Ollama only)"`
+	Structured      bool              `json:"structured" flag:"structured" doc:"Ask Ollama for JSON answers that also give the language of the message and the model's confidence; chat already in the target language is then shown as written"`
+	MinConfidence   float64           `json:"min_confidence" flag:"min-confidence" doc:"With structured answers, mark chat translations the model is less sure of than this, from 0 to 1, and only trust its language detection above it (0: never mark)"`
+	AudioDevice     string            `json:"audio_device" flag:"audiodevice" doc:"Audio device to capture, by name or by its number in -list-audio-devices (empty: auto-detect)" share:"local"`
+	CaptureApp      string            `json:"capture_app" flag:"capture-app" doc:"Record only this program's audio, e.g. cs2, when audio_device is empty"`
+	CaptureSources  []CaptureSource   `json:"capture_sources" doc:"Audio devices captured at once instead of audio_device, each with a label, e.g. game and discord; each is transcribed on its own and its speech shown with the label" share:"local"`
+	FFmpeg          string            `json:"ffmpeg" flag:"ffmpeg" doc:"ffmpeg executable to record and convert audio with (empty: the one in PATH, else the one cs-translate setup ffmpeg downloaded)" share:"local"`
+	Voice           bool              `json:"voice" flag:"voice" doc:"Enable voice transcription without asking"`
+	VoiceMode       string            `json:"voice_mode" flag:"voice-mode" doc:"How CS2 mode transcribes voice: live, every segment as it is captured; key, only the last echo_window when F9 is pressed, sparing the GPU; or both (empty: live)"`
+	Workers         int               `json:"workers" flag:"workers" doc:"Concurrent translations; while all are busy, voice goes first, then team chat, then all-chat"`
+	SupersedeWindow Duration          `json:"supersede_window" flag:"supersede-window" doc:"Cancel a player's pending translation if they send another message within this window (0s disables)"`
+	ShutdownTimeout Duration          `json:"shutdown_timeout" flag:"shutdown-timeout" doc:"On Ctrl+C, wait this long for chat translations still running to be shown and forwarded (0s: quit at once)"`
+	ReorderWindow   Duration          `json:"reorder_window" flag:"reorder-window" doc:"Hold a chat translation that finished before an earlier message's up to this long, so chat is shown in the order it was sent (0s: show translations as they finish)"`
+	Clipboard       string            `json:"clipboard" flag:"clipboard" doc:"Copy the latest translation to the clipboard to paste it in game: all, or talk for your own translated speech only (empty: off)"`
+	Notify          string            `json:"notify" flag:"notify" doc:"Show chat and voice translations as desktop notifications: background while CS2 is not the focused window, e.g. alt-tabbed, or always for a windowed game (empty: off)"`
+	Privacy         string            `json:"privacy" flag:"privacy" doc:"Keep team chat, voice and your own messages private: local sends them only to backends on this machine, all-chat leaves them untranslated (empty: off)"`
+	PlayerName      string            `json:"player_name" flag:"name" doc:"Your in-game name, so privacy mode recognizes your own messages" share:"local"`
+	MaxLatency      Duration          `json:"max_latency" flag:"max-latency" doc:"Drop voice context, switch to fast_model and finally skip voice translation while translations take longer than this end to end (0s disables)"`
+	FastModel       string            `json:"fast_model" flag:"fast-model" doc:"Smaller Ollama model used while max_latency is exceeded (empty: keep the model)"`
+	ChunkChars      int               `json:"chunk_chars" flag:"chunk-chars" doc:"Split longer chat messages into translation requests of this many characters (0: never)"`
+	MaxMessageChars int               `json:"max_message_chars" flag:"max-message-chars" doc:"Translate at most this many characters of one message and mark the rest as truncated (0: unlimited)"`
+	GameWatch       bool              `json:"game_watch" flag:"game-watch" doc:"Pause capture and unload the translation model while CS2 is not running, resuming when it starts"`
+	ResourcePoll    Duration          `json:"resource_interval" flag:"resource-interval" doc:"Sample GPU load, VRAM (nvidia-smi) and the models Ollama holds this often, showing in the status line when models load, unload or spill onto the CPU or VRAM fills up (0s disables)"`
+	KeepContainer   bool              `json:"keep_container" flag:"keep-container" doc:"Leave the Docker container running on exit"`
+	EchoWindow      Duration          `json:"echo_window" flag:"echo-window" doc:"Audio captured by a single F9 press in echo mode, and in CS2 mode with voice_mode key"`
+	HoldToCapture   bool              `json:"hold_to_capture" flag:"hold-to-capture" doc:"F9 captures exactly while it is held, push-to-talk style, instead of the last echo_window"`
+	Hotkeys         HotkeyConfig      `json:"hotkeys" doc:"Other keys, mouse side buttons or gamepad buttons for F8, F9 and F10, for players with no free keys during a match" share:"local"`
+	HTTPAddr        string            `json:"http_addr" flag:"http-addr" doc:"Address of the embedded web server, e.g. localhost:8787 (empty: disabled)" share:"local"`
+	HTTP            HTTPConfig        `json:"http" doc:"Connection to Ollama"`
+	Whisper         WhisperConfig     `json:"whisper" doc:"Voice transcription"`
+	VoiceContext    ContextConfig     `json:"voice_context" doc:"Recent speech given to voice translation as context"`
+	Discord         DiscordConfig     `json:"discord" doc:"Discord voice channel transcription (CS2 mode)"`
+	Talk            TalkConfig        `json:"talk" doc:"Translate your own microphone for your team"`
+	GameChat        GameChatConfig    `json:"game_chat" doc:"Translations relayed into the game chat for teammates without cs-translate"`
+	SpeakerProfiles bool              `json:"speaker_profiles" flag:"speaker-profiles" doc:"Remember each voice speaker's language and skip translating speakers who already use the target language"`
+	KeepTerms       []string          `json:"keep_terms" doc:"Weapons, map callouts and other words kept untranslated, matched as whole words in any case"`
+	KeepNames       bool              `json:"keep_names" flag:"keep-names" doc:"Keep the names of players seen in chat untranslated when messages mention them"`
+	NameHints       []string          `json:"name_hints" doc:"Player names containing ':' that chat lines are split after; type /fix while running to add one"`
+	Hooks           []HookConfig      `json:"hooks" doc:"Commands run on pipeline events" share:"local"`
+	HookConcurrency int               `json:"hook_concurrency" doc:"Hook commands allowed to run at once; events beyond that are skipped"`
+	Spam            SpamConfig        `json:"spam" doc:"Chat spam protection"`
+	Alerts          AlertConfig       `json:"alerts" doc:"Highlight and sound for chat and voice mentioning a keyword"`
+	Tone            ToneConfig        `json:"tone" doc:"Friendly, neutral or toxic tags for chat"`
+	Transcript      TranscriptConfig  `json:"transcript" doc:"Match transcripts and summaries"`
+	OBS             OBSConfig         `json:"obs" doc:"Subtitles for OBS recordings and translations shown in OBS"`
+	Twitch          TwitchConfig      `json:"twitch" doc:"Translations posted to Twitch chat"`
+	Telegram        TelegramConfig    `json:"telegram" doc:"Translations mirrored to a Telegram chat, which can switch the language"`
+	MQTT            MQTTConfig        `json:"mqtt" doc:"Events published to an MQTT broker"`
+	Plugins         []PluginConfig    `json:"plugins" doc:"Programs that receive events and can filter translations" share:"local"`
+	Logging         LoggingConfig     `json:"logging" doc:"cs-translate's own log messages" share:"local"`
+}
+
+// Default returns the built-in settings
+func Default() Config {
+	httpDefaults := translator.DefaultHTTPConfig()
+	contextDefaults := translator.DefaultContextSettings()
+	return Config{
+		Model:           translator.DefaultOllamaModel,
+		Lang:            "English",
+		Workers:         2,
+		SupersedeWindow: Duration(3 * time.Second),
+		ReorderWindow:   Duration(time.Second),
+		ShutdownTimeout: Duration(5 * time.Second),
+		ResourcePoll:    Duration(resources.DefaultInterval),
+		ChunkChars:      400,
+		MaxMessageChars: 2000,
+		LogWait:         Duration(5 * time.Minute),
+		EchoWindow:      Duration(15 * time.Second),
+		HookConcurrency: 2,
+		SpeakerProfiles: true,
+		KeepTerms:       append([]string(nil), translator.DefaultKeepTerms...),
+		KeepNames:       true,
+		MinConfidence:   0.5,
+		Talk:            TalkConfig{Lang: "English"},
+		GameChat:        GameChatConfig{Command: "say_team", MaxBytes: 120},
+		VoiceContext: ContextConfig{
+			Window:     Duration(10 * time.Second),
+			MaxEntries: contextDefaults.MaxEntries,
+			MaxTokens:  contextDefaults.MaxTokens,
+		},
+		Whisper: WhisperConfig{
+			Model:        translator.DefaultLiveWhisperModel,
+			CaptureModel: translator.DefaultWhisperModel,
+			Task:         audio.TaskTranscribe,
+
+			MinAvgLogProb:   audio.DefaultMinAvgLogProb,
+			MaxNoSpeechProb: audio.DefaultMaxNoSpeechProb,
+			Blocklist:       append([]string(nil), audio.DefaultHallucinations...),
+			MaxBacklog:      Duration(audio.DefaultMaxBacklog),
+			Segment:         Duration(audio.DefaultSegment),
+			TempMaxMB:       audio.DefaultTempMax >> 20,
+			TempMaxAge:      Duration(audio.DefaultTempMaxAge),
+		},
+		Spam: SpamConfig{
+			DedupeWindow: Duration(30 * time.Second),
+			Rate:         0.5,
+			Burst:        4,
+		},
+		Alerts: AlertConfig{Sound: "bell"},
+		OBS: OBSConfig{
+			Subtitles: true,
+			Format:    subtitle.FormatSRT,
+			Lines:     3,
+			Hold:      Duration(10 * time.Second),
+		},
+		Twitch: TwitchConfig{Rate: twitch.DefaultRate, Command: "!translate"},
+		MQTT:   MQTTConfig{Prefix: "cs-translate"},
+		Logging: LoggingConfig{
+			Level:  "warn",
+			Format: "text",
+			File:   true,
+		},
+		HTTP: HTTPConfig{
+			RequestTimeout: Duration(httpDefaults.RequestTimeout),
+			ClientTimeout:  Duration(httpDefaults.ClientTimeout),
+			Pool:           httpDefaults.MaxIdleConnsPerHost,
+			Concurrency:    2,
+			VoiceWeight:    2,
+			ChatWeight:     1,
+		},
+	}
+}
+
+// Dir returns the cs-translate directory in the user's config dir, or
+// CS_TRANSLATE_CONFIG_DIR when it is set
+func Dir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user config directory: %v", err)
+	}
+	return filepath.Join(base, "cs-translate"), nil
+}
+
+// Path returns the location of the settings file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the settings file on top of the defaults. A missing file is
+// not an error.
+func Load() (Config, error) {
+	cfg := Default()
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Default(), fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes cfg to the settings file
+func Save(cfg Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Profile returns the name used to pick a log_profiles entry
+func Profile() string {
+	if p := os.Getenv(ProfileEnv); p != "" {
+		return p
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// ApplyEnv resolves the console log path from the active profile and
+// CS_TRANSLATE_LOG. Command line flags are applied afterwards and win.
+func (c *Config) ApplyEnv() {
+	if p := c.LogProfiles[Profile()]; p != "" {
+		c.LogPath = p
+	}
+	if p := os.Getenv(LogEnv); p != "" {
+		c.LogPath = p
+	}
+}
+
+// CaptureDevice is the device to record: audio_device, or capture_app as an
+// "app:" device when no device is set
+func (c Config) CaptureDevice() string {
+	if c.AudioDevice == "" && c.CaptureApp != "" {
+		return "app:" + c.CaptureApp
+	}
+	return c.AudioDevice
+}
+
+// HTTPSettings converts the file settings into the translator client config
+func (c Config) HTTPSettings() translator.HTTPConfig {
+	httpConfig := translator.DefaultHTTPConfig()
+	httpConfig.RequestTimeout = time.Duration(c.HTTP.RequestTimeout)
+	httpConfig.ClientTimeout = time.Duration(c.HTTP.ClientTimeout)
+	httpConfig.MaxIdleConnsPerHost = c.HTTP.Pool
+	httpConfig.HTTP2 = c.HTTP.HTTP2
+	httpConfig.MaxConcurrent = c.HTTP.Concurrency
+	httpConfig.VoiceWeight = c.HTTP.VoiceWeight
+	httpConfig.ChatWeight = c.HTTP.ChatWeight
+	return httpConfig
+}
+
+// ContextSettings converts the voice context limits for the translator
+func (c Config) ContextSettings() translator.ContextSettings {
+	return translator.ContextSettings{
+		MaxEntries: c.VoiceContext.MaxEntries,
+		MaxTokens:  c.VoiceContext.MaxTokens,
+		Prompt:     c.VoiceContext.Prompt,
+	}
+}
+
+// WhisperSettings converts the voice settings for the audio listener. Echo
+// mode transcribes F9 captures and uses the capture model.
+func (c Config) WhisperSettings(echoMode bool) audio.Options {
+	model := c.Whisper.Model
+	if echoMode {
+		model = c.Whisper.CaptureModel
+	}
+	return audio.Options{
+		Model:     model,
+		Languages: c.Whisper.Languages,
+		Task:      c.Whisper.Task,
+		Filter: audio.Filter{
+			MinAvgLogProb:   c.Whisper.MinAvgLogProb,
+			MaxNoSpeechProb: c.Whisper.MaxNoSpeechProb,
+			Blocklist:       c.Whisper.Blocklist,
+		},
+		Preprocess:   c.AudioPreprocess(),
+		MaxBacklog:   time.Duration(c.Whisper.MaxBacklog),
+		Segment:      time.Duration(c.Whisper.Segment),
+		Intermediate: c.Whisper.Intermediate,
+		TempMax:      int64(c.Whisper.TempMaxMB) << 20,
+		TempMaxAge:   time.Duration(c.Whisper.TempMaxAge),
+	}
+}
+
+// AudioPreprocess is the filter chain for captured audio
+func (c Config) AudioPreprocess() audio.Preprocess {
+	return audio.Preprocess{
+		BandPass:  c.Whisper.BandPass,
+		Denoise:   c.Whisper.Denoise,
+		Normalize: c.Whisper.Normalize,
+		Custom:    c.Whisper.AudioFilter,
+	}
+}
+
+// BackendSettings is the translation backend chain; Ollama with model when
+// none is configured
+func (c Config) BackendSettings() []translator.BackendConfig {
+	if len(c.Backends) == 0 {
+		return []translator.BackendConfig{{Type: translator.BackendOllama, Model: c.Model, Prompt: c.Prompt, Structured: c.Structured}}
+	}
+	var bs []translator.BackendConfig
+	for _, b := range c.Backends {
+		model := b.Model
+		if model == "" {
+			model = c.Model
+		}
+		bs = append(bs, translator.BackendConfig{
+			Type:       b.Type,
+			URL:        b.URL,
+			APIKey:     os.Getenv(translator.LibreTranslateKeyEnv),
+			Model:      model,
+			Prompt:     c.Prompt,
+			Structured: c.Structured,
+			Timeout:    time.Duration(b.Timeout),
+			Local:      b.Local,
+		})
+	}
+	return bs
+}
+
+// NormalizeLanguages rewrites lang, talk.lang and the languages of
+// lang_rules as their canonical codes, e.g. "de" for "German" or "de-DE",
+// and reports a language or rule it does not know
+func (c *Config) NormalizeLanguages() error {
+	lang, err := translator.NormalizeLanguage(c.Lang)
+	if err != nil {
+		return fmt.Errorf("lang: %w", err)
+	}
+	c.Lang = lang
+	if c.Talk.Lang != "" {
+		if c.Talk.Lang, err = translator.NormalizeLanguage(c.Talk.Lang); err != nil {
+			return fmt.Errorf("talk lang: %w", err)
+		}
+	}
+	rules := make([]LangRule, len(c.LangRules))
+	for i, r := range c.LangRules {
+		if !slices.Contains(LangRuleSources, r.Source) {
+			return fmt.Errorf("lang_rules[%d]: unknown source %q (use %s)", i, r.Source, strings.Join(LangRuleSources, ", "))
+		}
+		if rules[i].Lang, err = translator.NormalizeLanguage(r.Lang); err != nil {
+			return fmt.Errorf("lang_rules[%d]: %w", i, err)
+		}
+		rules[i].Source = r.Source
+	}
+	c.LangRules = rules
+	return nil
+}
+
+// SpamSettings returns the chat deduplication and rate limit
+func (c Config) SpamSettings() pipeline.LimitOptions {
+	return pipeline.LimitOptions{
+		DedupeWindow: time.Duration(c.Spam.DedupeWindow),
+		Rate:         c.Spam.Rate,
+		Burst:        c.Spam.Burst,
+	}
+}
+
+// PluginSettings converts the configured plugins
+func (c Config) PluginSettings() []plugins.Config {
+	var ps []plugins.Config
+	for _, p := range c.Plugins {
+		ps = append(ps, plugins.Config{Name: p.Name, Command: p.Command, Events: p.Events, Filter: p.Filter, Timeout: time.Duration(p.Timeout)})
+	}
+	return ps
+}
+
+// TwitchSettings converts the Twitch relay settings; the token is not part
+// of them
+func (c Config) TwitchSettings() twitch.Config {
+	return twitch.Config{
+		Channel: c.Twitch.Channel,
+		Nick:    c.Twitch.Nick,
+		Rate:    c.Twitch.Rate,
+		Window:  twitch.DefaultWindow,
+		Command: c.Twitch.Command,
+	}
+}
+
+// TelegramSettings converts the Telegram settings; the token is not part of
+// them
+func (c Config) TelegramSettings() telegram.Config {
+	return telegram.Config{Chat: c.Telegram.Chat}
+}
+
+// MQTTSettings converts the MQTT broker settings; the password is not part
+// of them
+func (c Config) MQTTSettings() mqtt.Config {
+	return mqtt.Config{Broker: c.MQTT.Broker, Username: c.MQTT.Username}
+}
+
+// HookSettings converts the configured hooks for the hooks runner
+func (c Config) HookSettings() []hooks.Hook {
+	var hs []hooks.Hook
+	for _, h := range c.Hooks {
+		hs = append(hs, hooks.Hook{Event: h.Event, Command: h.Command, Timeout: time.Duration(h.Timeout)})
+	}
+	return hs
+}
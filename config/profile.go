@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+// CPUModel is the translation model of the CPU profile: Gemma 3 with 1B
+// parameters, 4-bit quantized (~800 MB)
+const CPUModel = "gemma3:1b"
+
+// ApplyCPUProfile switches the settings still at their defaults to ones a
+// laptop CPU keeps up with: a small translation model, the tiny and base
+// Whisper models, longer voice segments, one worker and more patient
+// timeouts. Settings changed in the file or by flag are kept.
+func (c *Config) ApplyCPUProfile() {
+	d := Default()
+	english := len(c.Whisper.Languages) == 1 && c.Whisper.Languages[0] == "en"
+	liveModel, captureModel := "tiny", "base"
+	if english {
+		liveModel, captureModel = "tiny.en", "base.en"
+	}
+
+	keepOr(&c.Model, d.Model, CPUModel)
+	keepOr(&c.Workers, d.Workers, 1)
+	keepOr(&c.HTTP.Concurrency, d.HTTP.Concurrency, 1)
+	keepOr(&c.Whisper.Model, d.Whisper.Model, liveModel)
+	keepOr(&c.Whisper.CaptureModel, d.Whisper.CaptureModel, captureModel)
+	// Fewer, longer segments spend less time loading audio into the model
+	keepOr(&c.Whisper.Segment, d.Whisper.Segment, Duration(4*time.Second))
+	keepOr(&c.Whisper.MaxBacklog, d.Whisper.MaxBacklog, Duration(20*time.Second))
+	keepOr(&c.HTTP.RequestTimeout, d.HTTP.RequestTimeout, Duration(90*time.Second))
+	keepOr(&c.HTTP.ClientTimeout, d.HTTP.ClientTimeout, Duration(5*time.Minute))
+}
+
+// keepOr sets *v to profile unless it was changed from def
+func keepOr[T comparable](v *T, def, profile T) {
+	if *v == def {
+		*v = profile
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import "reflect"
+
+// RedactedValue replaces secrets in Redacted settings
+const RedactedValue = "<redacted>"
+
+// Redacted returns cfg with its secrets replaced by RedactedValue, for bug
+// reports. Fields tagged secret:"true" are replaced when set; of commands
+// tagged secret:"args" only the program is kept, since arguments often
+// carry webhook URLs or tokens.
+func Redacted(cfg Config) Config {
+	redact(reflect.ValueOf(&cfg).Elem())
+	return cfg
+}
+
+// Secrets lists the values of the set secret:"true" fields, to be removed
+// from text such as log files
+func Secrets(cfg Config) []string {
+	var secrets []string
+	collectSecrets(reflect.ValueOf(cfg), &secrets)
+	return secrets
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		switch {
+		case field.Tag.Get("secret") == "true" && fv.Kind() == reflect.String:
+			if fv.String() != "" {
+				fv.SetString(RedactedValue)
+			}
+		case field.Tag.Get("secret") == "args" && fv.Kind() == reflect.Slice:
+			if fv.Len() > 1 {
+				args := []string{fv.Index(0).String()}
+				for range fv.Len() - 1 {
+					args = append(args, RedactedValue)
+				}
+				fv.Set(reflect.ValueOf(args))
+			}
+		case field.Type.Kind() == reflect.Struct && field.Type != durationType:
+			redact(fv)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			// A copy, so cfg's caller keeps its own entries
+			items := reflect.MakeSlice(field.Type, fv.Len(), fv.Len())
+			reflect.Copy(items, fv)
+			for j := 0; j < items.Len(); j++ {
+				redact(items.Index(j))
+			}
+			fv.Set(items)
+		}
+	}
+}
+
+func collectSecrets(v reflect.Value, secrets *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		switch {
+		case field.Tag.Get("secret") == "true" && fv.Kind() == reflect.String:
+			if fv.String() != "" {
+				*secrets = append(*secrets, fv.String())
+			}
+		case field.Type.Kind() == reflect.Struct && field.Type != durationType:
+			collectSecrets(fv, secrets)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				collectSecrets(fv.Index(j), secrets)
+			}
+		}
+	}
+}
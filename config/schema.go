@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Option describes one setting for the generated reference
+type Option struct {
+	Key     string `json:"key"` // dotted JSON path, e.g. "http.pool"
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Flag    string `json:"flag,omitempty"`
+	Doc     string `json:"doc"`
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// Options lists every setting with its default, in declaration order
+func Options() []Option {
+	var opts []Option
+	collectOptions(reflect.ValueOf(Default()), "", &opts)
+	return opts
+}
+
+func collectOptions(v reflect.Value, prefix string, opts *[]Option) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := jsonName(field)
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		fv := v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			collectOptions(fv, key, opts)
+			continue
+		}
+		*opts = append(*opts, Option{
+			Key:     key,
+			Type:    typeName(field.Type),
+			Default: defaultString(fv),
+			Flag:    field.Tag.Get("flag"),
+			Doc:     field.Tag.Get("doc"),
+		})
+	}
+}
+
+// Schema returns a JSON Schema (draft 2020-12) for the settings file, for
+// editor autocompletion
+func Schema() map[string]any {
+	schema := structSchema(reflect.ValueOf(Default()))
+	// Lets editors pick the schema up from the file itself
+	schema["properties"].(map[string]any)["$schema"] = map[string]any{"type": "string"}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "cs-translate configuration"
+	return schema
+}
+
+func structSchema(v reflect.Value) map[string]any {
+	t := v.Type()
+	props := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := jsonName(field)
+		if key == "" {
+			continue
+		}
+		prop := valueSchema(field.Type, v.Field(i))
+		if doc := field.Tag.Get("doc"); doc != "" {
+			prop["description"] = doc
+		}
+		props[key] = prop
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+func valueSchema(t reflect.Type, v reflect.Value) map[string]any {
+	if t == durationType {
+		return map[string]any{
+			"type":    "string",
+			"pattern": `^(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+$|^0$`,
+			"default": time.Duration(v.Interface().(Duration)).String(),
+		}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(v)
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": valueSchema(t.Elem(), reflect.Zero(t.Elem()))}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": valueSchema(t.Elem(), reflect.Zero(t.Elem()))}
+	}
+	s := map[string]any{"type": typeName(t)}
+	if v.IsValid() && !v.IsZero() {
+		s["default"] = v.Interface()
+	}
+	return s
+}
+
+func jsonName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	tag := f.Tag.Get("json")
+	for i := 0; i < len(tag); i++ {
+		if tag[i] == ',' {
+			tag = tag[:i]
+			break
+		}
+	}
+	if tag == "-" {
+		return ""
+	}
+	if tag == "" {
+		return f.Name
+	}
+	return tag
+}
+
+func typeName(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "string"
+}
+
+func defaultString(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Interface().(Duration)).String()
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	b, _ := json.Marshal(v.Interface())
+	return string(b)
+}
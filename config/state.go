@@ -0,0 +1,54 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// StateFileName holds what cs-translate remembers between runs, inside Dir.
+// Unlike the settings file it is written by the program, never by the user.
+const StateFileName = "state.json"
+
+// State is remembered between runs
+type State struct {
+	LastLogPath string `json:"last_log_path,omitempty"`
+}
+
+func statePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, StateFileName), nil
+}
+
+// LoadState reads the remembered state; a missing or broken file yields the
+// zero State
+func LoadState() State {
+	var s State
+	path, err := statePath()
+	if err != nil {
+		return s
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &s)
+	}
+	return s
+}
+
+// SaveState writes s for the next run
+func SaveState(s State) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/i18n"
+)
+
+func runConfigCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println(i18n.T("Usage: cs-translate config <path|init|schema|export|import>"))
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "path":
+		path, err := config.Path()
+		if err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+		fmt.Println(path)
+
+	case "init":
+		path, err := config.Path()
+		if err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			fmt.Print(i18n.T("Config file already exists: %s\n", path))
+			os.Exit(1)
+		}
+		if err := config.Save(config.Default()); err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+		fmt.Print(i18n.T("Wrote default config to %s\n", path))
+
+	case "schema":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(config.Schema())
+
+	case "export":
+		if len(args) < 2 {
+			fmt.Println(i18n.T("Usage: cs-translate config export <bundle.zip>"))
+			os.Exit(2)
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+		if err := config.ExportBundle(args[1], cfg); err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+		fmt.Print(i18n.T("Wrote %s. Log paths, audio devices, hooks and the web address are left out.\n", args[1]))
+
+	case "import":
+		importBundle(args[1:])
+
+	default:
+		fmt.Println(i18n.T("Usage: cs-translate config <path|init|schema|export|import>"))
+		os.Exit(2)
+	}
+}
+
+// importBundle shows what a settings bundle would change and applies it
+// after confirmation
+func importBundle(args []string) {
+	fs := flag.NewFlagSet("config import", flag.ExitOnError)
+	yes := fs.Bool("y", false, "Apply without asking")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Println(i18n.T("Usage: cs-translate config import [-y] <bundle.zip>"))
+		os.Exit(2)
+	}
+
+	bundle, err := config.ReadBundle(fs.Arg(0))
+	if err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	current, err := config.Load()
+	if err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	merged := bundle.Merge(current)
+
+	changes := config.Diff(current, merged)
+	if len(changes) == 0 && len(bundle.Files) == 0 {
+		fmt.Println(i18n.T("The bundle matches your settings; nothing to do."))
+		return
+	}
+	for _, c := range changes {
+		fmt.Printf("  %s: %s -> %s\n", c.Key, c.Old, c.New)
+	}
+	for name := range bundle.Files {
+		fmt.Print(i18n.T("  %s: replaced from the bundle\n", name))
+	}
+
+	if !*yes {
+		fmt.Print(i18n.T("Apply these changes? [y/N]: "))
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() || !i18n.Yes(scanner.Text()) {
+			fmt.Println(i18n.T("Nothing changed."))
+			return
+		}
+	}
+	if err := config.ApplyBundle(bundle, merged); err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	fmt.Println(i18n.T("Settings imported."))
+}
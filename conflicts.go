@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/vdf"
+)
+
+// cs2Running reports whether the game is running
+func cs2Running() bool {
+	switch runtime.GOOS {
+	case "windows":
+		out, err := execwrap.Command("tasklist", "/FI", "IMAGENAME eq cs2.exe", "/NH").Output()
+		return err == nil && bytes.Contains(bytes.ToLower(out), []byte("cs2.exe"))
+	default:
+		return execwrap.Command("pgrep", "-x", "cs2").Run() == nil
+	}
+}
+
+// logReaders lists other programs that have path open, besides CS2 and
+// cs-translate. Only Linux and macOS can tell.
+func logReaders(path string) []string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	names := map[string]bool{}
+	switch runtime.GOOS {
+	case "linux":
+		procs, _ := filepath.Glob("/proc/[0-9]*")
+		self := strconv.Itoa(os.Getpid())
+		for _, proc := range procs {
+			if filepath.Base(proc) == self {
+				continue
+			}
+			fds, _ := filepath.Glob(filepath.Join(proc, "fd", "*"))
+			for _, fd := range fds {
+				if target, err := os.Readlink(fd); err == nil && target == abs {
+					comm, _ := os.ReadFile(filepath.Join(proc, "comm"))
+					names[strings.TrimSpace(string(comm))] = true
+					break
+				}
+			}
+		}
+	case "darwin":
+		out, _ := execwrap.Command("lsof", "-Fc", abs).Output()
+		for _, line := range strings.Split(string(out), "\n") {
+			if name, ok := strings.CutPrefix(line, "c"); ok {
+				names[name] = true
+			}
+		}
+		delete(names, filepath.Base(os.Args[0]))
+	}
+	delete(names, "cs2")
+	delete(names, "")
+
+	var list []string
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// warnLogConflicts points out other tools reading console.log
+func warnLogConflicts(path string) {
+	if readers := logReaders(path); len(readers) > 0 {
+		fmt.Println(display.Paint(display.Yellow, i18n.T("Note: other programs also have console.log open: %s. If one of them clears the log, chat lines can be missed.", strings.Join(readers, ", "))))
+	}
+}
+
+// watchLogTruncation warns when console.log shrinks while CS2 keeps running.
+// CS2 itself only truncates it on start, so this means another tool is
+// clearing it.
+func watchLogTruncation(ctx context.Context, path string) {
+	var lastSize int64 = -1
+	warned := false
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			lastSize = -1
+			continue
+		}
+		size := info.Size()
+		if lastSize >= 0 && size < lastSize && !warned && cs2Running() {
+			warned = true
+			fmt.Println("\n" + display.Paint(display.Yellow, i18n.T("Warning: console.log was truncated while CS2 is running. Another tool is probably clearing it; close it so chat lines are not lost.")))
+			if readers := logReaders(path); len(readers) > 0 {
+				fmt.Println(display.Paint(display.Yellow, i18n.T("Programs with the log open: %s", strings.Join(readers, ", "))))
+			}
+		}
+		lastSize = size
+	}
+}
+
+// gsiPorts returns the local ports claimed by CS2 game state integration
+// configs of other tools, mapped to the config file names
+func gsiPorts() map[int][]string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	ports := map[int][]string{}
+	for _, lib := range steamLibraries(home) {
+		cfgDir := filepath.Join(lib, filepath.Dir(cs2LogPath), "cfg")
+		files, _ := filepath.Glob(filepath.Join(cfgDir, "gamestate_integration_*.cfg"))
+		for _, file := range files {
+			f, err := os.Open(file)
+			if err != nil {
+				continue
+			}
+			doc, err := vdf.Parse(f)
+			f.Close()
+			if err != nil {
+				continue
+			}
+			for _, entry := range doc.Children {
+				if port := uriPort(entry.Child("uri")); port > 0 {
+					ports[port] = append(ports[port], filepath.Base(file))
+				}
+			}
+		}
+	}
+	return ports
+}
+
+func uriPort(n *vdf.Node) int {
+	if n == nil {
+		return 0
+	}
+	u, err := url.Parse(n.Value)
+	if err != nil {
+		return 0
+	}
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 && u.Scheme == "http" {
+		port = 80
+	}
+	return port
+}
+
+// pickHTTPAddr keeps addr unless its port is taken, by another program or by
+// a game state integration config, and then moves to the next free port
+func pickHTTPAddr(addr string) string {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port == 0 {
+		return addr
+	}
+	claimed := gsiPorts()
+	for p := port; p < port+20 && p <= 65535; p++ {
+		candidate := net.JoinHostPort(host, strconv.Itoa(p))
+		if users := claimed[p]; len(users) > 0 {
+			fmt.Print(i18n.T("Port %d is used by CS2 game state integration (%s).\n", p, strings.Join(users, ", ")))
+			continue
+		}
+		ln, err := net.Listen("tcp", candidate)
+		if err != nil {
+			fmt.Print(i18n.T("Port %d is already in use by another program.\n", p))
+			continue
+		}
+		ln.Close()
+		if p != port {
+			fmt.Print(i18n.T("Serving the web API on port %d instead.\n", p))
+		}
+		return candidate
+	}
+	return addr
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// consoleSink prints events to the terminal. Echo mode shows voice as
+// Original/Translated pairs; CS2 mode lines it up with chat.
+type consoleSink struct {
+	echo bool
+}
+
+func (c consoleSink) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.TranscriptDone:
+		if c.echo {
+			fmt.Print(i18n.T("\nOriginal: %s\n", e.Text))
+		} else {
+			fmt.Print(i18n.T("Voice %.2fs: %s \n", e.Elapsed.Seconds(), e.Text))
+		}
+	case events.TranslationDone:
+		c.translation(e)
+	case events.Error:
+		if !printHintOnce(e.Err) {
+			slog.Warn("Translation failed", "kind", e.Source, "err", e.Err)
+		}
+	case events.Status:
+		printStatus(e)
+	case events.SummaryDone:
+		fmt.Println(display.Paint(display.BoldCyan, i18n.T("Match summary:")+"\n"+e.Summary))
+		if e.Path != "" {
+			fmt.Println(display.Paint(display.Dim, i18n.T("Saved to %s", e.Path)))
+		}
+	case events.Recording:
+		if e.State == "started" {
+			fmt.Println(display.Paint(display.Dim, i18n.T("OBS is recording; its subtitles are saved when it stops")))
+		}
+	case events.SubtitlesSaved:
+		fmt.Println(display.Paint(display.Dim, i18n.T("%d subtitles for the recording saved to %s", e.Cues, e.Path)))
+	}
+}
+
+func (c consoleSink) translation(t events.TranslationDone) {
+	switch t.Source {
+	case "chat":
+		if tagLogs && t.Log != "" {
+			t.Line = display.Paint(display.Dim, "["+logLabel(t.Log)+"]") + " " + t.Line
+		}
+		if t.Superseded {
+			fmt.Println(t.Line)
+			fmt.Println(display.Paint(display.Dim, t.Player+" : "+i18n.T("(superseded by a newer message)")))
+			return
+		}
+		if t.Via == viaRepeated {
+			fmt.Println(t.Line)
+			fmt.Println(display.Paint(display.Dim, t.Player+" : "+i18n.T("(repeated; further repeats are hidden)")))
+			return
+		}
+		if hidden(t) {
+			fmt.Println(display.Paint(display.Dim, t.Player+" : "+i18n.T("(toxic, hidden)")))
+			return
+		}
+		translated := t.Translated
+		if t.Err != nil {
+			printHintOnce(t.Err)
+			translated = i18n.T("[Translation Pending/Error]")
+		} else if t.Via == viaPrivate {
+			translated += " " + display.Paint(display.Dim, i18n.T("(private, not translated)"))
+		} else if t.Via == viaRateLimited {
+			translated += " " + display.Paint(display.Dim, i18n.T("(rate limited, not translated)"))
+		} else if t.Via == "own language" {
+			translated += " " + display.Paint(display.Dim, i18n.T("(own language, not translated)"))
+		} else if unsure(t) {
+			translated += " " + display.Paint(display.Dim, i18n.T("(unsure, %.0f%%)", t.Confidence*100))
+		} else if t.Truncated > 0 {
+			translated += " " + i18n.T("[truncated: %d more characters not translated]", t.Truncated)
+		}
+		if t.Tone == string(translator.ToneToxic) {
+			translated += " " + display.Paint(display.Dim, i18n.T("(toxic)"))
+		}
+		outputChat(t.Player, translated, t.Dead, t.Line, t.Highlight)
+
+	case "voice":
+		if c.echo {
+			fmt.Println(display.Paint(display.BoldGreen, i18n.T("Translated: ")+t.Translated))
+			return
+		}
+		prefix := i18n.T("voice %.2fs: ", t.Elapsed.Seconds())
+		if t.Via != "" {
+			prefix = i18n.T("voice (%s): ", t.Via)
+		}
+		if t.Player != "" {
+			prefix = t.Player + " " + prefix
+		}
+		if t.Capture != "" {
+			prefix = "[" + t.Capture + "] " + prefix
+		}
+		outputChat(prefix, t.Translated, false, "", t.Highlight)
+
+	case "talk":
+		fmt.Println(display.Paint(display.BoldCyan, i18n.T("[talk] You: ")+t.Original))
+		fmt.Println(display.Paint(display.BoldCyan, i18n.T("[talk] Say (%s): %s", t.Language, t.Translated)))
+	}
+}
+
+// printStatus reports component health, e.g. transcriber crashes and restarts
+func printStatus(s events.Status) {
+	switch s.State {
+	case "ready":
+		fmt.Println(display.Paint(display.BoldGreen, fmt.Sprintf("[%s] %s", s.Source, s.Message)))
+	case "ok":
+		fmt.Println(display.Paint(display.Dim, fmt.Sprintf("[%s] %s", s.Source, s.Message)))
+	case "failed":
+		fmt.Println(display.Paint(display.BoldRed, fmt.Sprintf("[%s] %s; ", s.Source, s.Message)+i18n.T("voice translation is disabled")))
+		printHintOnce(s.Err)
+	default:
+		fmt.Println(display.Paint(display.Yellow, fmt.Sprintf("[%s] %s", s.Source, s.Message)))
+	}
+}
+
+// maxNameColumn caps the name column so one long name cannot push all
+// messages to the right
+const maxNameColumn = 20
+
+// nameColumn grows to the widest name seen so messages line up
+var nameColumn int
+
+// outputChat prints a translated line; highlighted ones, marked by an alert
+// or a plugin, stand out in yellow
+func outputChat(name, text string, isDead bool, originalLine string, highlight bool) {
+	if originalLine != "" {
+		fmt.Println(originalLine)
+	}
+	prefix := ""
+	if isDead {
+		prefix = "*DEAD* "
+	}
+	label := prefix + name
+	if w := display.StringWidth(label); w > nameColumn {
+		nameColumn = min(w, maxNameColumn)
+	}
+	style := display.BoldGreen
+	if highlight {
+		style = display.BoldYellow
+	}
+	fmt.Println(display.Paint(style, display.Fit(label, nameColumn)+" : "+text))
+}
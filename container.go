@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/setup"
+)
+
+func runContainerCommand(args []string) {
+	fs := flag.NewFlagSet("container", flag.ExitOnError)
+	follow := fs.Bool("f", false, "Follow log output (logs only)")
+	fs.BoolVar(&setup.DryRun, "dry-run", false, "Print the docker commands instead of running them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), i18n.T("Usage: cs-translate container <stop|rm|logs|update> [-f] [-dry-run]"))
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	action := args[0]
+	fs.Parse(args[1:])
+
+	var err error
+	switch action {
+	case "stop":
+		err = setup.StopContainer()
+	case "rm":
+		err = setup.RemoveContainer()
+	case "logs":
+		err = setup.ContainerLogs(*follow)
+	case "update":
+		err = setup.UpdateContainer()
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/tempdir"
+)
+
+func runDevicesCommand(args []string) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "test") {
+		fmt.Println(i18n.T("Usage: cs-translate devices <list|test> [-audiodevice name|number] [-duration 5s]"))
+		os.Exit(2)
+	}
+	if args[0] == "list" {
+		listAudioDevices()
+	}
+	cfg, _ := config.Load()
+	cfg.ApplyEnv()
+	fs := flag.NewFlagSet("devices test", flag.ExitOnError)
+	device := fs.String("audiodevice", cfg.CaptureDevice(), "Audio device to test, by name or number (default: the configured one, else auto-detect)")
+	duration := fs.Duration("duration", 5*time.Second, "How long to record")
+	fs.Parse(args[1:])
+	useFFmpeg(cfg.FFmpeg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := testDevice(ctx, cfg, *device, *duration); err != nil {
+		fmt.Printf("%s: %v\n", display.Paint(display.BoldRed, display.CrossMark+" devices test"), err)
+		if h, ok := lookupHint(err); ok {
+			for _, step := range h.steps {
+				fmt.Printf("    - %s\n", step)
+			}
+		}
+		os.Exit(1)
+	}
+}
+
+// testDevice records d from device with a live meter and has Whisper
+// transcribe it, the way voice mode will
+func testDevice(ctx context.Context, cfg config.Config, device string, d time.Duration) error {
+	source, err := audio.ResolveDevice(device)
+	if err != nil {
+		return err
+	}
+	in, err := captureInput(source)
+	if err != nil {
+		return err
+	}
+	if source == "" {
+		source = i18n.T("auto-detect")
+	}
+	dir, err := tempdir.New("", "cs-devices-test")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	clip := filepath.Join(dir, "test.wav")
+
+	fmt.Print(i18n.T("Recording %s from '%s'; say something as you would in a match...\n", d, source))
+	peak, err := recordWithMeter(ctx, in, d, clip)
+	if err != nil {
+		return err
+	}
+	if peak < audio.SilentLevel {
+		return fmt.Errorf("%w: nothing audible came from '%s' (peak %.0f dB); pick another device with cs-translate setup device", audio.ErrNoMonitorSource, source, peak)
+	}
+	fmt.Print(i18n.T("%s Audio captured (peak %.0f dB)\n", display.CheckMark, peak))
+
+	fmt.Println(i18n.T("Transcribing the recording..."))
+	listener := initAudioListener(true, cfg.WhisperSettings(false))
+	if listener == nil {
+		return errors.New("the transcriber did not start; run cs-translate doctor")
+	}
+	defer listener.Stop()
+	text, took, err := listenerTranscriber{listener}.Transcribe(ctx, clip)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return errors.New("Whisper heard no speech; speak closer to the microphone or raise the game's voice volume, then test again")
+	}
+	fmt.Print(i18n.T("%s Heard in %s: %q\n", display.CheckMark, took.Round(time.Millisecond), text))
+	fmt.Print(i18n.T("\nVoice mode will work with -audiodevice '%s'.\n", source))
+	return nil
+}
+
+// recordWithMeter records d of in into path, redrawing a level meter on one
+// line as it goes, and returns the loudest level
+func recordWithMeter(ctx context.Context, in audio.Input, d time.Duration, path string) (peak float64, err error) {
+	peak = -91
+	err = audio.Record(ctx, in, d, path, func(dB float64) {
+		peak = max(peak, dB)
+		fmt.Printf("\r  %s  peak %.0f dB ", levelMeter(dB), peak)
+	})
+	fmt.Println()
+	return peak, err
+}
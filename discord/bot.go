@@ -0,0 +1,229 @@
+// Package discord joins a Discord voice channel as a bot and hands every
+// speaker's audio to the transcriber, attributed by display name.
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TokenEnv holds the bot token. It is never read from the settings file, so
+// it cannot leak through /api/config.
+const TokenEnv = "CS_TRANSLATE_DISCORD_TOKEN"
+
+const (
+	// silenceGap ends a speaker's segment
+	silenceGap = 700 * time.Millisecond
+	// maxSegment cuts long monologues so translations keep flowing
+	maxSegment = 10 * time.Second
+	// minSegmentPackets drops clicks and coughs (20ms per packet)
+	minSegmentPackets = 15
+)
+
+// Config selects the voice channel to join
+type Config struct {
+	Token     string
+	GuildID   string
+	ChannelID string
+}
+
+// Sink receives a finished segment file and who spoke it
+type Sink func(path, speaker string)
+
+// Bot is a connected voice bot
+type Bot struct {
+	dir  string
+	sink Sink
+
+	session *discordgo.Session
+	vc      *discordgo.VoiceConnection
+	guildID string
+
+	mu    sync.Mutex
+	users map[uint32]string // SSRC to user ID
+	names map[string]string // user ID to display name
+
+	// streams is only touched by the receive goroutine
+	streams map[uint32]*segment
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// segment is one speaker's audio being written to disk
+type segment struct {
+	path    string
+	file    *os.File
+	ogg     *oggWriter
+	packets int
+	started time.Time
+	last    time.Time
+}
+
+// Start connects, joins the channel muted and begins writing segments to dir
+func Start(cfg Config, dir string, sink Sink) (*Bot, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("discord bot token missing; set %s", TokenEnv)
+	}
+	if cfg.GuildID == "" || cfg.ChannelID == "" {
+		return nil, errors.New("discord guild and channel IDs are required")
+	}
+
+	session, err := discordgo.New("Bot " + cfg.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discord session: %w", err)
+	}
+	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildVoiceStates
+	if err := session.Open(); err != nil {
+		return nil, fmt.Errorf("failed to connect to discord: %w", err)
+	}
+
+	// Muted: we only listen
+	vc, err := session.ChannelVoiceJoin(cfg.GuildID, cfg.ChannelID, true, false)
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to join voice channel: %w", err)
+	}
+	if vc.OpusRecv == nil {
+		vc.Disconnect()
+		session.Close()
+		return nil, errors.New("voice connection has no receive channel")
+	}
+
+	b := &Bot{
+		dir:     dir,
+		sink:    sink,
+		session: session,
+		vc:      vc,
+		guildID: cfg.GuildID,
+		users:   make(map[uint32]string),
+		names:   make(map[string]string),
+		streams: make(map[uint32]*segment),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	vc.AddHandler(b.onSpeaking)
+	go b.receive()
+	return b, nil
+}
+
+// onSpeaking learns which user sends on which SSRC
+func (b *Bot) onSpeaking(vc *discordgo.VoiceConnection, vs *discordgo.VoiceSpeakingUpdate) {
+	b.mu.Lock()
+	b.users[uint32(vs.SSRC)] = vs.UserID
+	_, known := b.names[vs.UserID]
+	b.mu.Unlock()
+	if !known {
+		go b.resolveName(vs.UserID)
+	}
+}
+
+func (b *Bot) resolveName(userID string) {
+	name := "Discord user"
+	if m, err := b.session.State.Member(b.guildID, userID); err == nil {
+		name = m.DisplayName()
+	} else if m, err := b.session.GuildMember(b.guildID, userID); err == nil {
+		name = m.DisplayName()
+	}
+	b.mu.Lock()
+	b.names[userID] = name
+	b.mu.Unlock()
+}
+
+func (b *Bot) speaker(ssrc uint32) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if name, ok := b.names[b.users[ssrc]]; ok {
+		return name
+	}
+	return "Discord user"
+}
+
+func (b *Bot) receive() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			for ssrc := range b.streams {
+				b.finish(ssrc, false)
+			}
+			return
+		case p, ok := <-b.vc.OpusRecv:
+			if !ok {
+				return
+			}
+			// Three-byte packets are Opus silence frames sent after speech
+			if len(p.Opus) <= 3 {
+				continue
+			}
+			b.write(p)
+		case now := <-ticker.C:
+			for ssrc, seg := range b.streams {
+				if now.Sub(seg.last) > silenceGap {
+					b.finish(ssrc, true)
+				}
+			}
+		}
+	}
+}
+
+func (b *Bot) write(p *discordgo.Packet) {
+	seg := b.streams[p.SSRC]
+	if seg == nil {
+		path := filepath.Join(b.dir, fmt.Sprintf("discord_%d_%d.ogg", p.SSRC, time.Now().UnixNano()))
+		f, err := os.Create(path)
+		if err != nil {
+			slog.Warn("Discord: failed to create segment", "err", err)
+			return
+		}
+		ogg, err := newOggWriter(f, p.SSRC, 2)
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+			slog.Warn("Discord: failed to write segment", "err", err)
+			return
+		}
+		seg = &segment{path: path, file: f, ogg: ogg, started: time.Now()}
+		b.streams[p.SSRC] = seg
+	}
+
+	if err := seg.ogg.writePacket(p.Opus); err != nil {
+		slog.Warn("Discord: failed to write segment", "err", err)
+	}
+	seg.packets++
+	seg.last = time.Now()
+	if seg.last.Sub(seg.started) >= maxSegment {
+		b.finish(p.SSRC, true)
+	}
+}
+
+// finish closes a segment and submits it if it is long enough
+func (b *Bot) finish(ssrc uint32, submit bool) {
+	seg := b.streams[ssrc]
+	delete(b.streams, ssrc)
+
+	seg.ogg.close()
+	seg.file.Close()
+	if !submit || seg.packets < minSegmentPackets {
+		os.Remove(seg.path)
+		return
+	}
+	b.sink(seg.path, b.speaker(ssrc))
+}
+
+// Close leaves the channel and disconnects
+func (b *Bot) Close() {
+	close(b.done)
+	<-b.stopped
+	b.vc.Disconnect()
+	b.session.Close()
+}
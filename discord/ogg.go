@@ -0,0 +1,125 @@
+package discord
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// oggWriter packs Opus packets into an Ogg Opus file (RFC 7845), which
+// ffmpeg and Whisper read directly. Each packet gets its own page.
+type oggWriter struct {
+	w       io.Writer
+	serial  uint32
+	seq     uint32
+	granule uint64
+	pending []byte // held back so the last page can carry the EOS flag
+}
+
+const (
+	oggBOS = 0x02
+	oggEOS = 0x04
+
+	// Discord sends 20ms frames at 48kHz
+	samplesPerPacket = 960
+)
+
+var oggCRCTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+func oggCRC(b []byte) uint32 {
+	var crc uint32
+	for _, c := range b {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^c]
+	}
+	return crc
+}
+
+func newOggWriter(w io.Writer, serial uint32, channels byte) (*oggWriter, error) {
+	o := &oggWriter{w: w, serial: serial}
+
+	head := []byte("OpusHead")
+	head = append(head, 1, channels)
+	head = binary.LittleEndian.AppendUint16(head, 0)     // pre-skip
+	head = binary.LittleEndian.AppendUint32(head, 48000) // original rate
+	head = binary.LittleEndian.AppendUint16(head, 0)     // output gain
+	head = append(head, 0)                               // channel mapping family
+	if err := o.writePage(head, oggBOS); err != nil {
+		return nil, err
+	}
+
+	vendor := "cs-translate"
+	tags := []byte("OpusTags")
+	tags = binary.LittleEndian.AppendUint32(tags, uint32(len(vendor)))
+	tags = append(tags, vendor...)
+	tags = binary.LittleEndian.AppendUint32(tags, 0) // no user comments
+	if err := o.writePage(tags, 0); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// writePacket adds one Opus packet
+func (o *oggWriter) writePacket(p []byte) error {
+	if o.pending != nil {
+		if err := o.flushPending(0); err != nil {
+			return err
+		}
+	}
+	o.pending = append([]byte(nil), p...)
+	return nil
+}
+
+// close ends the stream; the writer itself is left open
+func (o *oggWriter) close() error {
+	if o.pending == nil {
+		return nil
+	}
+	return o.flushPending(oggEOS)
+}
+
+func (o *oggWriter) flushPending(flags byte) error {
+	o.granule += samplesPerPacket
+	err := o.writePage(o.pending, flags)
+	o.pending = nil
+	return err
+}
+
+func (o *oggWriter) writePage(packet []byte, flags byte) error {
+	// Lacing: 255-byte segments, ended by one shorter (possibly empty)
+	var lacing []byte
+	n := len(packet)
+	for n >= 255 {
+		lacing = append(lacing, 255)
+		n -= 255
+	}
+	lacing = append(lacing, byte(n))
+
+	page := make([]byte, 0, 27+len(lacing)+len(packet))
+	page = append(page, "OggS"...)
+	page = append(page, 0, flags)
+	page = binary.LittleEndian.AppendUint64(page, o.granule)
+	page = binary.LittleEndian.AppendUint32(page, o.serial)
+	page = binary.LittleEndian.AppendUint32(page, o.seq)
+	page = binary.LittleEndian.AppendUint32(page, 0) // CRC, filled in below
+	page = append(page, byte(len(lacing)))
+	page = append(page, lacing...)
+	page = append(page, packet...)
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(page))
+
+	o.seq++
+	_, err := o.w.Write(page)
+	return err
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/discord"
+)
+
+// startDiscord joins the configured voice channel and feeds every speaker to
+// the transcriber. It returns nil when Discord is not configured or fails.
+func startDiscord(cfg config.DiscordConfig, listener *audio.Listener) *discord.Bot {
+	if cfg.ChannelID == "" {
+		return nil
+	}
+	if listener == nil {
+		slog.Warn("Discord voice needs voice transcription; start with -voice")
+		return nil
+	}
+	bot, err := discord.Start(discord.Config{
+		Token:     os.Getenv(discord.TokenEnv),
+		GuildID:   cfg.GuildID,
+		ChannelID: cfg.ChannelID,
+	}, listener.OutputDir(), listener.SubmitSpeech)
+	if err != nil {
+		slog.Warn("Discord voice disabled", "err", err)
+		return nil
+	}
+	fmt.Println("Joined Discord voice channel; speakers are transcribed by name.")
+	return bot
+}
@@ -0,0 +1,55 @@
+package display
+
+import "os"
+
+// Style is an SGR attribute list such as "1;32" for bold green
+type Style string
+
+const (
+	Dim        Style = "2"
+	Yellow     Style = "33"
+	BoldRed    Style = "1;31"
+	BoldGreen  Style = "1;32"
+	BoldYellow Style = "1;33"
+	BoldCyan   Style = "1;36"
+)
+
+// colors is whether Paint emits escape sequences; set by InitConsole
+var colors = true
+
+// CheckMark and CrossMark start lines reporting success and failure. Legacy
+// Windows consoles get ASCII instead.
+var (
+	CheckMark = "✔"
+	CrossMark = "✘"
+)
+
+// InitConsole prepares the terminal for colored UTF-8 output. On Windows it
+// turns on virtual terminal processing, which older consoles lack; where
+// that fails, when NO_COLOR is set or when stdout is not a terminal, Paint
+// returns plain text.
+func InitConsole() {
+	vt := !isTerminal(os.Stdout) || enableVT()
+	if !vt {
+		CheckMark, CrossMark = "[ok]", "[x]"
+	}
+	colors = vt && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+}
+
+// Colors reports whether output is colored
+func Colors() bool {
+	return colors
+}
+
+// Paint returns text in style, or text as is when colors are off
+func Paint(s Style, text string) string {
+	if !colors {
+		return text
+	}
+	return "\033[" + string(s) + "m" + text + "\033[0m"
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
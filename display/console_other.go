@@ -0,0 +1,8 @@
+//go:build !windows
+
+package display
+
+// enableVT reports that escapes render; Unix terminals support them
+func enableVT() bool {
+	return true
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package display
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// utf8CodePage is CP_UTF8
+const utf8CodePage = 65001
+
+// enableVT switches the console to UTF-8 and turns on escape sequence
+// processing; it reports whether escapes will render
+func enableVT() bool {
+	windows.SetConsoleOutputCP(utf8CodePage)
+	h := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
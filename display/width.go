@@ -0,0 +1,144 @@
+// Package display measures and formats text by terminal cell width, so CJK
+// text and emoji do not break column alignment, and colors it where the
+// terminal supports it.
+package display
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	zeroWidthJoiner = 0x200D
+	variationEmoji  = 0xFE0F // VS16, requests emoji presentation
+)
+
+// wideRanges are East Asian Wide/Fullwidth code points and emoji that
+// terminals render two cells wide (sorted, inclusive)
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202}, {0x1F210, 0x1F23B},
+	{0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC},
+	{0x1F7E0, 0x1F7EB}, {0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945},
+	{0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+func isWide(r rune) bool {
+	lo, hi := 0, len(wideRanges)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid][0]:
+			hi = mid - 1
+		case r > wideRanges[mid][1]:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+// RuneWidth returns the cells a single rune occupies on its own
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0:
+		return 0
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0xFE00 && r <= 0xFE0F:
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// StringWidth returns the cells s occupies, treating emoji ZWJ sequences,
+// skin tone modifiers and VS16 the way modern terminals do
+func StringWidth(s string) int {
+	width := 0
+	prev := rune(0)
+	prevWidth := 0
+	for _, r := range s {
+		w := RuneWidth(r)
+		switch {
+		case prev == zeroWidthJoiner:
+			// Joined onto the previous emoji
+			w = 0
+		case isSkinTone(r) && prevWidth == 2:
+			w = 0
+		case r == variationEmoji && prevWidth == 1:
+			// Text-default symbol switched to emoji presentation
+			w = 1
+		}
+		width += w
+		prev = r
+		if w > 0 {
+			prevWidth = w
+		}
+	}
+	return width
+}
+
+// Truncate shortens s to at most width cells, ending with tail (e.g. "…")
+// when something was cut. Wide characters are never split.
+func Truncate(s string, width int, tail string) string {
+	if StringWidth(s) <= width {
+		return s
+	}
+	tailWidth := StringWidth(tail)
+	if width <= tailWidth {
+		tail, tailWidth = "", 0
+	}
+
+	var sb strings.Builder
+	used := 0
+	for _, r := range s {
+		w := RuneWidth(r)
+		if used+w > width-tailWidth {
+			break
+		}
+		sb.WriteRune(r)
+		used += w
+	}
+	sb.WriteString(tail)
+	return sb.String()
+}
+
+// PadRight pads s with spaces to width cells
+func PadRight(s string, width int) string {
+	if pad := width - StringWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// Fit truncates or pads s to exactly width cells
+func Fit(s string, width int) string {
+	return PadRight(Truncate(s, width, "…"), width)
+}
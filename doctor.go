@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// doctorCheck is a single environment check; name is what hints refer to
+type doctorCheck struct {
+	name string
+	run  func() (string, error)
+}
+
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	ollamaModel := fs.String("model", translator.DefaultOllamaModel, "Ollama model to check for")
+	audioDevice := fs.String("audiodevice", "", "Audio device to check (default: auto-detect)")
+	fs.Parse(args)
+	cfg, _ := config.Load()
+	useFFmpeg(cfg.FFmpeg)
+
+	failed := 0
+	for _, c := range doctorChecks(*ollamaModel, *audioDevice) {
+		detail, err := c.run()
+		if err != nil {
+			failed++
+			fmt.Printf("%s: %v\n", display.Paint(display.BoldRed, display.CrossMark+" "+c.name), err)
+			if h, ok := lookupHint(err); ok {
+				for _, step := range h.steps {
+					fmt.Printf("    - %s\n", step)
+				}
+			}
+			continue
+		}
+		fmt.Printf("%s: %s\n", display.Paint(display.BoldGreen, display.CheckMark+" "+c.name), detail)
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d check(s) failed.\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("\nAll checks passed.")
+}
+
+// doctorChecks returns the environment checks for model and the audio
+// device, in the order they are shown
+func doctorChecks(model, device string) []doctorCheck {
+	return []doctorCheck{
+		{"ollama", checkOllama},
+		{"model", func() (string, error) { return checkModel(model) }},
+		{"ffmpeg", checkFFmpeg},
+		{"audio", func() (string, error) { return checkAudio(device) }},
+		{"transcriber", checkTranscriber},
+		{"logfile", checkLogFile},
+		{"condebug", checkCondebugConfigured},
+	}
+}
+
+func checkOllama() (string, error) {
+	resp, err := translator.GetWithTimeout(translator.OllamaHost+"/api/version", 5*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("%w at %s", translator.ErrOllamaUnavailable, translator.OllamaHost)
+	}
+	defer resp.Body.Close()
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	json.NewDecoder(resp.Body).Decode(&version)
+	return fmt.Sprintf("reachable at %s (version %s)", translator.OllamaHost, version.Version), nil
+}
+
+func checkModel(model string) (string, error) {
+	resp, err := translator.GetWithTimeout(translator.OllamaHost+"/api/tags", 5*time.Second)
+	if err != nil {
+		return "", translator.ErrOllamaUnavailable
+	}
+	defer resp.Body.Close()
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return "", fmt.Errorf("could not parse installed models: %v", err)
+	}
+	for _, m := range tags.Models {
+		if strings.HasPrefix(m.Name, model) {
+			return fmt.Sprintf("'%s' is installed", m.Name), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", translator.ErrModelNotFound, model)
+}
+
+func checkFFmpeg() (string, error) {
+	path, err := execwrap.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg not found in PATH; run cs-translate setup ffmpeg or set ffmpeg in the settings")
+	}
+	return path, nil
+}
+
+func checkAudio(device string) (string, error) {
+	source, err := audio.ResolveDevice(device)
+	if err != nil {
+		return "", err
+	}
+	if source == "" || source == "default" {
+		if runtime.GOOS == "windows" {
+			source = audio.GetDefaultDeviceName()
+		} else {
+			source = audio.GetDefaultMonitorSource()
+		}
+	}
+	if err := audio.CheckSource(source); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("capture source '%s'", source), nil
+}
+
+func checkTranscriber() (string, error) {
+	if os.Getenv("USE_DOCKER_WHISPER") == "0" {
+		cwd, _ := os.Getwd()
+		python := filepath.Join(cwd, "venv", "bin", "python3")
+		if runtime.GOOS == "windows" {
+			python = filepath.Join(cwd, "venv", "Scripts", "python.exe")
+		}
+		if err := execwrap.Command(python, "-c", "import whisper").Run(); err != nil {
+			return "", fmt.Errorf("%w: openai-whisper is not importable from %s", audio.ErrTranscriberNotReady, python)
+		}
+		return "openai-whisper installed in venv", nil
+	}
+
+	out, err := execwrap.Command("docker", "ps", "--filter", "name=cs-translate", "--format", "{{.Names}}").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "cs-translate" {
+		return "", fmt.Errorf("%w: docker container 'cs-translate' is not running", audio.ErrTranscriberNotReady)
+	}
+	return "docker container 'cs-translate' is running", nil
+}
+
+func checkLogFile() (string, error) {
+	path, err := findLogFile()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errCondebugMissing, err)
+	}
+	return path, nil
+}
+
+func checkCondebugConfigured() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	foundConfig, configured := findCondebugInConfigs(getUserdataPaths(home))
+	if !foundConfig {
+		return "", fmt.Errorf("could not find Steam localconfig.vdf")
+	}
+	if !configured {
+		return "", errCondebugMissing
+	}
+	return "-condebug is set", nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+const (
+	// maxEchoCapture bounds a capture between two F9 marks
+	maxEchoCapture = 2 * time.Minute
+	// doublePressWindow is how quickly a second F9 must follow to set a mark
+	doublePressWindow = 400 * time.Millisecond
+	// minHold is the shortest F9 hold that captures with hold_to_capture;
+	// anything shorter is taken for a slip
+	minHold = 300 * time.Millisecond
+
+	// echoSegment is the length of each file written by the ffmpeg segment
+	// muxer; a capture waits at most this long for the newest audio
+	echoSegment = time.Second
+	// echoRestartDelay is the pause before restarting a recording ffmpeg
+	// that exited on its own
+	echoRestartDelay = 2 * time.Second
+)
+
+// echoRun is one ffmpeg process of the recorder. Its segments are named
+// after the run and numbered from the moment it started.
+type echoRun struct {
+	id    int
+	start time.Time
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	exited chan struct{}
+}
+
+func (r *echoRun) segment(dir string, i int) string {
+	return filepath.Join(dir, fmt.Sprintf("seg_%d_%06d.wav", r.id, i))
+}
+
+// heldSpan is where the capture of F9 held from down until up starts,
+// bounded by maxEchoCapture; false if it was let go within minHold
+func heldSpan(down, up time.Time) (time.Time, bool) {
+	if up.Sub(down) < minHold {
+		return time.Time{}, false
+	}
+	if up.Sub(down) > maxEchoCapture {
+		return up.Add(-maxEchoCapture), true
+	}
+	return down, true
+}
+
+// echoRecorder records system audio continuously into short segments with a
+// single ffmpeg process. Any span of the last maxEchoCapture can be cut out
+// without interrupting the recording.
+type echoRecorder struct {
+	ctx   context.Context
+	dir   string
+	input audio.Input
+
+	mu      sync.Mutex
+	runs    []*echoRun
+	current *echoRun // nil while waiting to restart
+	stopped bool
+
+	done chan struct{}
+}
+
+// newEchoRecorder starts recording device, or the system output if it is
+// empty, into segments in dir
+func newEchoRecorder(ctx context.Context, dir, device string) (*echoRecorder, error) {
+	input, err := captureInput(device)
+	if err != nil {
+		return nil, err
+	}
+	return newRecorder(ctx, dir, input)
+}
+
+// newRecorder starts recording the ffmpeg input into segments in dir
+func newRecorder(ctx context.Context, dir string, input audio.Input) (*echoRecorder, error) {
+	r := &echoRecorder{ctx: ctx, dir: dir, input: input, done: make(chan struct{})}
+	r.mu.Lock()
+	err := r.startRun()
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	go r.prune()
+	return r, nil
+}
+
+// startRun starts a new ffmpeg process; r.mu must be held
+func (r *echoRecorder) startRun() error {
+	run := &echoRun{id: len(r.runs) + 1, exited: make(chan struct{})}
+	pattern := filepath.Join(r.dir, fmt.Sprintf("seg_%d_%%06d.wav", run.id))
+	cmd, stdin, err := startAudioRecording(r.ctx, r.input,
+		"-f", "segment", "-segment_time", fmt.Sprintf("%.3f", echoSegment.Seconds()),
+		"-segment_format", "wav", "-reset_timestamps", "1", pattern)
+	if err != nil {
+		return err
+	}
+	run.cmd, run.stdin, run.start = cmd, stdin, time.Now()
+	r.runs = append(r.runs, run)
+	r.current = run
+	go r.supervise(run)
+	return nil
+}
+
+// supervise reaps ffmpeg and restarts it when it exits without being
+// stopped, e.g. after the audio device went away
+func (r *echoRecorder) supervise(run *echoRun) {
+	err := run.cmd.Wait()
+	close(run.exited)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped || r.current != run {
+		return
+	}
+	slog.Warn("Audio recording stopped, restarting", "err", err, "delay", echoRestartDelay)
+	r.current = nil
+	time.AfterFunc(echoRestartDelay, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.stopped {
+			return
+		}
+		if err := r.startRun(); err != nil {
+			slog.Error("Failed to restart audio recording", "err", err)
+		}
+	})
+}
+
+// prune deletes segments that no capture can reach anymore
+func (r *echoRecorder) prune() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		}
+		cutoff := time.Now().Add(-maxEchoCapture - 10*time.Second)
+		for _, run := range r.snapshot() {
+			for i := int(cutoff.Sub(run.start) / echoSegment); i >= 0; i-- {
+				if err := os.Remove(run.segment(r.dir, i)); err != nil {
+					break // older segments are gone already
+				}
+			}
+		}
+	}
+}
+
+func (r *echoRecorder) snapshot() []*echoRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*echoRun(nil), r.runs...)
+}
+
+// capture cuts the audio since from into a WAV file in outDir and hands it
+// to submit. Recording goes on without interruption.
+func (r *echoRecorder) capture(from time.Time, outDir string, submit func(path string)) error {
+	to := time.Now()
+	r.mu.Lock()
+	cur := r.current
+	r.mu.Unlock()
+	if cur == nil {
+		return fmt.Errorf("audio recording is restarting, try again in a moment")
+	}
+	runs := r.snapshot()
+
+	go func() {
+		// wait for the segment holding the newest audio to be closed
+		next := cur.segment(r.dir, int(to.Sub(cur.start)/echoSegment)+1)
+		deadline := time.Now().Add(2*echoSegment + time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := os.Stat(next); err == nil {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		list, ok := r.concatList(runs, from, to)
+		if !ok {
+			slog.Error("Capture failed: no audio was recorded in the capture window (audio capture might have failed to start)")
+			return
+		}
+		listPath := filepath.Join(r.dir, fmt.Sprintf("capture_%d.ffconcat", to.UnixNano()))
+		if err := os.WriteFile(listPath, []byte(list), 0o644); err != nil {
+			slog.Error("Capture failed", "err", err)
+			return
+		}
+		defer os.Remove(listPath)
+
+		out := filepath.Join(outDir, fmt.Sprintf("slice_%d.wav", to.UnixNano()))
+		cmd := execwrap.Command("ffmpeg", "-f", "concat", "-safe", "0", "-i", listPath, "-c:a", "pcm_s16le", "-y", out)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			slog.Error("Slice failed", "err", err, "output", string(output))
+			return
+		}
+		absPath, _ := filepath.Abs(out)
+		submit(absPath)
+	}()
+	return nil
+}
+
+// concatList describes the segments covering from..to for ffmpeg's concat
+// demuxer, trimming the first and last one to the exact window
+func (r *echoRecorder) concatList(runs []*echoRun, from, to time.Time) (string, bool) {
+	var list strings.Builder
+	list.WriteString("ffconcat version 1.0\n")
+	n := 0
+	for _, run := range runs {
+		first := max(int(from.Sub(run.start)/echoSegment), 0)
+		last := int(to.Sub(run.start) / echoSegment)
+		for i := first; i <= last; i++ {
+			path := run.segment(r.dir, i)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			segStart := run.start.Add(time.Duration(i) * echoSegment)
+			fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(path, "'", `'\''`))
+			if from.After(segStart) {
+				fmt.Fprintf(&list, "inpoint %.3f\n", from.Sub(segStart).Seconds())
+			}
+			if to.Before(segStart.Add(echoSegment)) {
+				fmt.Fprintf(&list, "outpoint %.3f\n", to.Sub(segStart).Seconds())
+			}
+			n++
+		}
+	}
+	return list.String(), n > 0
+}
+
+// stop ends recording and removes the segments
+func (r *echoRecorder) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	cur := r.current
+	r.mu.Unlock()
+
+	close(r.done)
+	if cur != nil {
+		cur.stop()
+	}
+	segments, _ := filepath.Glob(filepath.Join(r.dir, "seg_*.wav"))
+	for _, p := range segments {
+		os.Remove(p)
+	}
+}
+
+// stop asks ffmpeg to finish the current segment and kills it if it hangs
+func (run *echoRun) stop() {
+	if _, err := run.stdin.Write([]byte("q")); err != nil {
+		if runtime.GOOS == "windows" {
+			run.cmd.Process.Kill()
+		} else {
+			run.cmd.Process.Signal(syscall.SIGTERM)
+		}
+	}
+	run.stdin.Close()
+
+	select {
+	case <-run.exited:
+	case <-time.After(500 * time.Millisecond):
+		slog.Warn("ffmpeg did not exit in time, killing it")
+		run.cmd.Process.Kill()
+		select {
+		case <-run.exited:
+		case <-time.After(1 * time.Second):
+			slog.Error("ffmpeg is stuck even after being killed")
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+var (
+	// keptEntities are the weapons, callouts and names kept untranslated;
+	// nil when there are none
+	keptEntities *translator.Entities
+	// keepNames is keep_names: player names seen in chat join keptEntities
+	keepNames bool
+)
+
+// setKeptEntities sets up keptEntities from cfg
+func setKeptEntities(cfg config.Config) {
+	keepNames = cfg.KeepNames
+	if !keepNames && len(cfg.KeepTerms) == 0 {
+		return
+	}
+	keptEntities = translator.NewEntities(cfg.KeepTerms...)
+	if keepNames {
+		keptEntities.Add(cfg.NameHints...)
+		keptEntities.Add(cfg.PlayerName)
+	}
+}
+
+// keepEntities returns tr keeping keptEntities as they are
+func keepEntities(tr translator.Translator) translator.Translator {
+	if keptEntities == nil {
+		return tr
+	}
+	return translator.Preserve(tr, keptEntities)
+}
+
+// keepName keeps the name of a player seen in chat from now on
+func keepName(name string) {
+	if keepNames {
+		keptEntities.Add(name)
+	}
+}
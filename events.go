@@ -0,0 +1,221 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/hooks"
+	"github.com/micha/cs-ingame-translate/monitor"
+	"github.com/micha/cs-ingame-translate/output"
+	"github.com/micha/cs-ingame-translate/parser"
+)
+
+// bus carries events from the mode loops to the outputs in subscribeSinks
+var bus = events.NewBus()
+
+// hookRunner runs the configured automation hooks; nil when there are none
+var hookRunner *hooks.Runner
+
+// targetLang is reported with translation events
+var targetLang string
+
+// rounds is the current round per console log; 0 before the first round
+// start is seen
+var rounds = map[string]int{}
+
+// Modes of -clipboard and -game-chat
+const (
+	clipboardOff  = ""
+	clipboardAll  = "all"  // every chat, voice and talk translation
+	clipboardTalk = "talk" // only your own translated speech, ready to send
+)
+
+// Modes of -notify
+const (
+	notifyOff        = ""
+	notifyBackground = "background" // only while CS2 does not have the focus
+	notifyAlways     = "always"
+)
+
+// subscribeSinks attaches the outputs to the bus and returns a function that
+// closes them
+func subscribeSinks(echoMode bool, clipboard, notify string, gameChat config.GameChatConfig) (closeSinks func()) {
+	console := consoleSink{echo: echoMode}
+	closers := []func(){
+		output.Subscribe(bus, "console", output.Func(console.handle)),
+		output.Subscribe(bus, "web", output.Func(deckSink)),
+		output.Subscribe(bus, "hooks", output.Func(hookSink)),
+	}
+	if sink := clipboardSink(clipboard); sink != nil {
+		closers = append(closers, output.Subscribe(bus, "clipboard", sink))
+	}
+	if sink := notifySink(notify); sink != nil {
+		closers = append(closers, output.Subscribe(bus, "notifications", sink))
+	}
+	if sink := gameChatSink(gameChat); sink != nil {
+		closers = append(closers, output.Subscribe(bus, "game chat", sink))
+	}
+	return func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+}
+
+// clipboardSink returns the clipboard output for mode, or nil when it is off
+// or cannot run
+func clipboardSink(mode string) output.Sink {
+	keep := translationFilter("clipboard", mode)
+	if keep == nil {
+		return nil
+	}
+	sink, err := output.NewClipboard(keep)
+	if err != nil {
+		slog.Warn("Not copying translations", "err", err)
+		return nil
+	}
+	return sink
+}
+
+// translationFilter returns the translations the output named takes in
+// mode, a -clipboard mode; nil when it is off or unknown
+func translationFilter(name, mode string) func(events.TranslationDone) bool {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case clipboardOff, "off":
+		return nil
+	case clipboardAll:
+		return func(t events.TranslationDone) bool { return !untranslatedChat(t.Via) }
+	case clipboardTalk:
+		return func(t events.TranslationDone) bool { return t.Source == "talk" }
+	}
+	slog.Warn("Unknown output mode; not using it", "output", name, "mode", mode, "use", clipboardAll+" or "+clipboardTalk)
+	return nil
+}
+
+// publishLogLine publishes what a console or server log line announces:
+// chat, a new map, the end of a match or a round boundary, tagged with the
+// log it came from. Client logs only give chat.
+func publishLogLine(line *monitor.Line) {
+	if format := clientFormat(line.Source); format != nil {
+		if msg := format.Parse(line.Text); msg != nil {
+			bus.Publish(chatEvent(msg, line.Source))
+		}
+		return
+	}
+	var msg *parser.ChatMessage
+	if line.Server {
+		msg = parser.ParseServerLine(line.Text)
+	} else {
+		msg = parseChat(line.Text, line.Source)
+	}
+	if msg != nil {
+		bus.Publish(chatEvent(msg, line.Source))
+		return
+	}
+	mapName, ok := parser.ParseMatchStart(line.Text)
+	if line.Server {
+		mapName, ok = parser.ParseServerMap(line.Text)
+	}
+	if ok {
+		rounds[line.Source] = 0
+		bus.Publish(events.MatchStarted{Map: mapName, Log: line.Source})
+		return
+	}
+	if mapName, score, ok := parser.ParseGameOver(line.Text); ok {
+		bus.Publish(events.MatchEnded{Map: mapName, Score: score, Log: line.Source})
+		return
+	}
+	switch ev, _ := parser.ParseRoundEvent(line.Text); ev {
+	case parser.MatchRestart:
+		rounds[line.Source] = 0
+	case parser.RoundStart:
+		rounds[line.Source]++
+		bus.Publish(events.RoundStarted{Round: rounds[line.Source], Log: line.Source})
+	case parser.RoundEnd:
+		bus.Publish(events.RoundEnded{Round: rounds[line.Source], Log: line.Source})
+	}
+}
+
+func chatEvent(msg *parser.ChatMessage, logPath string) events.ChatReceived {
+	return events.ChatReceived{
+		Player: msg.PlayerName,
+		Team:   msg.Team,
+		Dead:   msg.IsDead,
+		Text:   msg.MessageContent,
+		Line:   msg.OriginalText,
+		Log:    logPath,
+	}
+}
+
+// teamChat reports whether c was sent to the player's team only
+func teamChat(c events.ChatReceived) bool {
+	return !strings.HasPrefix(strings.ToUpper(c.Team), "ALL")
+}
+
+// forwarded reports whether a translation goes to plugins and hooks: chat
+// and voice that were translated, not the player's own talk lines or
+// messages kept private or held back as spam
+func forwarded(t events.TranslationDone) bool {
+	return t.Err == nil && !t.Superseded && !untranslatedChat(t.Via) && (t.Source == "chat" || t.Source == "voice")
+}
+
+// untranslatedChat reports whether via marks a message that was shown
+// without going to the translator
+func untranslatedChat(via string) bool {
+	return via == viaPrivate || via == viaRepeated || via == viaRateLimited
+}
+
+func deckSink(e events.Event) {
+	if t, ok := e.(events.TranslationDone); ok && forwarded(t) {
+		deck.translation(t.Source, t.Player, t.Translated)
+	}
+}
+
+func hookSink(e events.Event) {
+	switch e := e.(type) {
+	case events.TranslationDone:
+		if !forwarded(e) {
+			return
+		}
+		hookRunner.Fire(hooks.OnTranslation, hooks.Translation{
+			Source:     e.Source,
+			Player:     e.Player,
+			Team:       e.Team,
+			Capture:    e.Capture,
+			Original:   e.Original,
+			Translated: e.Translated,
+			Language:   e.Language,
+			Detected:   e.Detected,
+			Confidence: e.Confidence,
+			Tone:       e.Tone,
+			Log:        e.Log,
+		})
+	case events.MatchStarted:
+		hookRunner.Fire(hooks.OnMatchStart, hooks.MatchStart{Map: e.Map, Log: e.Log})
+	}
+}
+
+// notifySink returns the desktop notification output for mode, or nil when
+// it is off or cannot run
+func notifySink(mode string) output.Sink {
+	var background bool
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case notifyOff, "off":
+		return nil
+	case notifyBackground:
+		background = true
+	case notifyAlways:
+	default:
+		slog.Warn("Unknown notify mode; not showing notifications", "mode", mode, "use", notifyBackground+" or "+notifyAlways)
+		return nil
+	}
+	keep := func(t events.TranslationDone) bool { return forwarded(t) && !hidden(t) }
+	sink, err := output.NewNotifier(keep, background, []string{"cs2"})
+	if err != nil {
+		slog.Warn("Not showing notifications", "err", err)
+		return nil
+	}
+	return sink
+}
@@ -0,0 +1,246 @@
+// Package events carries what happens during a session from the parts that
+// notice it (log monitor, transcriber, translator) to the sinks that show or
+// forward it (console, Stream Deck, hooks, text-to-speech).
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is one of the event types below. Each has an ID that Publish gives
+// it, counting up from 1 in the order events are published.
+type Event interface {
+	// Kind names the event, e.g. "chat_received"
+	Kind() string
+}
+
+// ChatReceived is a chat line read from the console log
+type ChatReceived struct {
+	ID     uint64
+	Player string
+	Team   string // "CT", "T", or "ALL"
+	Dead   bool
+	Text   string // the message without the player name
+	Line   string // the whole console line
+	Log    string // path of the console log it was read from
+}
+
+// TranscriptDone is speech that the transcriber turned into text
+type TranscriptDone struct {
+	ID       uint64
+	Speaker  string // who spoke, when the audio source knows it
+	Capture  string // label of the capture source, e.g. "discord"; "" for one unlabelled device
+	Text     string
+	Language string        // detected source language
+	Elapsed  time.Duration // time spent transcribing
+}
+
+// TranslationDone is a finished (or failed) translation of chat or speech
+type TranslationDone struct {
+	ID         uint64
+	MessageID  uint64 // ID of the ChatReceived or TranscriptDone translated, 0 when there was none
+	Source     string // "chat", "voice" or "talk"
+	Player     string // player or speaker, if known
+	Capture    string // label of the capture source of voice, e.g. "discord"
+	Team       string
+	Dead       bool
+	Line       string // console line of a chat message
+	Log        string // console log of a chat message
+	Original   string
+	Translated string
+	Language   string  // target language code, e.g. "de" or "pt-BR"
+	Detected   string  // language the backend found the original in, "" when unknown
+	Confidence float64 // how sure the backend was of the translation, 0 to 1; 0 when it did not say
+	Tone       string  // "friendly", "neutral" or "toxic" when chat was tagged, "" otherwise
+
+	// Via says how a message was handled when the LLM was skipped:
+	// "whisper", "own language", "latency budget", "private", "repeated",
+	// "rate limited" or "as is"
+	Via       string
+	Elapsed   time.Duration // time spent translating
+	Truncated int           // characters cut off an overlong message
+	Spoken    time.Time     // when speech began, for voice; zero when unknown
+	Duration  time.Duration // how long the speech lasted, for voice
+
+	Superseded bool  // the player sent a newer message first
+	Highlight  bool  // a filter marked the message, e.g. it mentions the player
+	Err        error // the translation failed; Translated is empty
+}
+
+// Error is a failure not tied to a single message
+type Error struct {
+	ID     uint64
+	Source string // what failed, e.g. "voice" or "talk"
+	Err    error
+}
+
+// Status is a change in the health of a component
+type Status struct {
+	ID      uint64
+	Source  string // e.g. "voice" for the transcriber
+	State   string // e.g. "crashed", "ready"
+	Message string
+	Err     error
+}
+
+// MatchStarted is a map load seen in the console log
+type MatchStarted struct {
+	ID  uint64
+	Map string
+	Log string // path of the console log
+}
+
+// MatchEnded is the end of a match, with its final score, seen in a console
+// or server log
+type MatchEnded struct {
+	ID    uint64
+	Map   string
+	Score string // e.g. "13:7"
+	Log   string
+}
+
+// SummaryDone is the model's summary of a match, saved next to its
+// transcript
+type SummaryDone struct {
+	ID      uint64
+	Summary string
+	Path    string // file the summary was saved to
+}
+
+// Recording is OBS starting, pausing, resuming or stopping a recording
+type Recording struct {
+	ID    uint64
+	State string    // "started", "paused", "resumed" or "stopped"
+	Path  string    // the video file, when OBS said
+	At    time.Time // when it happened
+}
+
+// SubtitlesSaved is the subtitle track written for a recording
+type SubtitlesSaved struct {
+	ID    uint64
+	Path  string
+	Video string // the recording it belongs to, when known
+	Cues  int
+}
+
+// RoundStarted and RoundEnded are round boundaries seen in the console log.
+// Round counts from 1 since the map loaded or the match restarted in Log.
+type RoundStarted struct {
+	ID    uint64
+	Round int
+	Log   string
+}
+
+type RoundEnded struct {
+	ID    uint64
+	Round int
+	Log   string
+}
+
+func (ChatReceived) Kind() string    { return "chat_received" }
+func (TranscriptDone) Kind() string  { return "transcript_done" }
+func (TranslationDone) Kind() string { return "translation_done" }
+func (Error) Kind() string           { return "error" }
+func (Status) Kind() string          { return "status" }
+func (MatchStarted) Kind() string    { return "match_started" }
+func (MatchEnded) Kind() string      { return "match_ended" }
+func (SummaryDone) Kind() string     { return "summary_done" }
+func (Recording) Kind() string       { return "recording" }
+func (SubtitlesSaved) Kind() string  { return "subtitles_saved" }
+func (RoundStarted) Kind() string    { return "round_started" }
+func (RoundEnded) Kind() string      { return "round_ended" }
+
+// Handler receives published events
+type Handler func(Event)
+
+// Filter sees an event before the handlers and returns it, changed or not,
+// or false to drop it
+type Filter func(Event) (Event, bool)
+
+// Bus delivers every published event to every subscriber. Handlers run in
+// the publisher's goroutine, in subscription order, so sinks see events in
+// the order they happened; a handler with slow work must hand it off.
+type Bus struct {
+	ids     atomic.Uint64
+	mu      sync.RWMutex
+	subs    []subscriber
+	filters []filter
+	next    int
+}
+
+type subscriber struct {
+	id int
+	h  Handler
+}
+
+type filter struct {
+	id int
+	f  Filter
+}
+
+// NewBus returns a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds h and returns a function that removes it again
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subs = append(b.subs, subscriber{id, h})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == id {
+				// Copy so a Publish in progress keeps its snapshot
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// AddFilter adds f and returns a function that removes it again. Filters
+// run in the order they were added.
+func (b *Bus) AddFilter(f Filter) (remove func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.filters = append(b.filters, filter{id, f})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, fl := range b.filters {
+			if fl.id == id {
+				b.filters = append(b.filters[:i:i], b.filters[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish gives e the next ID unless it has one, passes it through the
+// filters and delivers what is left to every subscriber before it returns.
+// It returns the ID, also when a filter dropped e.
+func (b *Bus) Publish(e Event) uint64 {
+	e, id := withID(e, &b.ids)
+	b.mu.RLock()
+	subs, filters := b.subs, b.filters
+	b.mu.RUnlock()
+	for _, fl := range filters {
+		var ok bool
+		if e, ok = fl.f(e); !ok {
+			return id
+		}
+	}
+	for _, s := range subs {
+		s.h(e)
+	}
+	return id
+}
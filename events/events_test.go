@@ -0,0 +1,23 @@
+package events
+
+import "testing"
+
+// Every event gets the next ID, and a translation names the message it
+// translates
+func TestIDs(t *testing.T) {
+	b := NewBus()
+	var ids []uint64
+	b.Subscribe(func(e Event) {
+		if td, ok := e.(TranslationDone); ok {
+			ids = append(ids, td.ID, td.MessageID)
+		}
+	})
+	chat := b.Publish(ChatReceived{Player: "ivan", Text: "раш б"})
+	b.Publish(TranslationDone{MessageID: chat, Source: "chat", Translated: "rush b"})
+	if len(ids) != 2 || chat != 1 || ids[0] != 2 || ids[1] != chat {
+		t.Fatalf("chat got ID %d and its translation ID and message %d", chat, ids)
+	}
+	if f := Fields(TranslationDone{ID: 2, MessageID: 1}); f["id"] != uint64(2) || f["message_id"] != uint64(1) {
+		t.Fatalf("event fields are %v", f)
+	}
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Fields returns e for JSON clients: its kind as "event" and its fields
+// keyed in snake_case, without empty ones. Durations are given in
+// milliseconds as "<name>_ms" and an error as its message under "error".
+func Fields(e Event) map[string]any {
+	out := map[string]any{"event": e.Kind()}
+	v := reflect.ValueOf(e)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, value := t.Field(i), v.Field(i)
+		if !f.IsExported() || value.IsZero() {
+			continue
+		}
+		switch {
+		case f.Type == durationType:
+			out[snakeCase(f.Name)+"_ms"] = value.Interface().(time.Duration).Milliseconds()
+		case f.Type == errorType:
+			out["error"] = value.Interface().(error).Error()
+		default:
+			out[snakeCase(f.Name)] = value.Interface()
+		}
+	}
+	return out
+}
+
+// withID returns e with its ID field set to the next of ids, or as it is
+// when it already has one
+func withID(e Event, ids *atomic.Uint64) (Event, uint64) {
+	v := reflect.ValueOf(e)
+	if v.Kind() != reflect.Struct {
+		return e, 0
+	}
+	f, ok := v.Type().FieldByName("ID")
+	if !ok || f.Type.Kind() != reflect.Uint64 {
+		return e, 0
+	}
+	if id := v.FieldByIndex(f.Index).Uint(); id != 0 {
+		return e, id
+	}
+	id := ids.Add(1)
+	stamped := reflect.New(v.Type()).Elem()
+	stamped.Set(v)
+	stamped.FieldByIndex(f.Index).SetUint(id)
+	return stamped.Interface().(Event), id
+}
+
+// snakeCase turns a field name such as "MessageID" into "message_id"
+func snakeCase(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
@@ -0,0 +1,105 @@
+// Package execwrap starts the external programs the tool drives (ffmpeg,
+// docker, pactl, package managers) through a replaceable Runner, so code
+// building their command lines can be exercised with a Fake that records
+// the commands and answers them without running anything.
+package execwrap
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+// Runner creates commands and finds programs
+type Runner interface {
+	CommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd
+	LookPath(file string) (string, error)
+}
+
+// System is the Runner of the os/exec package. Programs given a path with
+// SetPath are run from there.
+type System struct{}
+
+func (System) CommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, Path(name), arg...)
+	// Error messages built from Args name the program, not the path
+	cmd.Args[0] = name
+	return cmd
+}
+
+func (System) LookPath(file string) (string, error) {
+	if path := Path(file); path != file {
+		if _, err := os.Stat(path); err != nil {
+			return "", &exec.Error{Name: path, Err: err}
+		}
+		return path, nil
+	}
+	return exec.LookPath(file)
+}
+
+var (
+	pathsMu sync.RWMutex
+	paths   = make(map[string]string)
+)
+
+// SetPath makes System run the program name from path, e.g. an ffmpeg that
+// is not in PATH; an empty path looks name up in PATH again
+func SetPath(name, path string) {
+	pathsMu.Lock()
+	defer pathsMu.Unlock()
+	if path == "" {
+		delete(paths, name)
+		return
+	}
+	paths[name] = path
+}
+
+// Path is what System runs for the program name: the path SetPath gave it,
+// or name itself
+func Path(name string) string {
+	pathsMu.RLock()
+	defer pathsMu.RUnlock()
+	if path, ok := paths[name]; ok {
+		return path
+	}
+	return name
+}
+
+// runner holds the Runner of every command of this package's functions;
+// goroutines start commands while a test swaps it
+type runner struct{ Runner }
+
+var current atomic.Pointer[runner]
+
+func init() {
+	current.Store(&runner{System{}})
+}
+
+// Default returns the Runner this package's functions use
+func Default() Runner {
+	return current.Load().Runner
+}
+
+// Command is exec.Command through Default
+func Command(name string, arg ...string) *exec.Cmd {
+	return Default().CommandContext(context.Background(), name, arg...)
+}
+
+// CommandContext is exec.CommandContext through Default
+func CommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	return Default().CommandContext(ctx, name, arg...)
+}
+
+// LookPath is exec.LookPath through Default
+func LookPath(file string) (string, error) {
+	return Default().LookPath(file)
+}
+
+// Use makes r the Default until the returned function restores the
+// previous one
+func Use(r Runner) (restore func()) {
+	prev := current.Swap(&runner{r})
+	return func() { current.Store(prev) }
+}
@@ -0,0 +1,105 @@
+package execwrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeEnv carries the canned Response to the process a Fake command starts
+const fakeEnv = "CS_TRANSLATE_EXECWRAP_FAKE"
+
+// Response is what a faked program prints and how it exits
+type Response struct {
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+	Exit   int    `json:"exit,omitempty"`
+	// Wait keeps the program running this long before it exits, e.g. a
+	// capture that has to stay up while a test drives it
+	Wait time.Duration `json:"wait,omitempty"`
+}
+
+// Fake is a Runner that records every command line and answers it with a
+// canned Response. Its commands are real *exec.Cmd values that start this
+// executable again, so callers wiring up pipes, Env or Run work unchanged;
+// the test binary has to call ServeFake first thing in TestMain.
+type Fake struct {
+	// Responses are keyed by the command line, program and arguments joined
+	// by spaces; lines without one exit 0 printing nothing
+	Responses map[string]Response
+	// Missing programs are not found by LookPath; everything else is
+	Missing map[string]bool
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+// CommandContext records the command and returns one that prints its
+// Response
+func (f *Fake) CommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	line := append([]string{name}, arg...)
+	f.mu.Lock()
+	f.calls = append(f.calls, line)
+	resp := f.Responses[strings.Join(line, " ")]
+	f.mu.Unlock()
+
+	self, err := os.Executable()
+	if err != nil {
+		self = os.Args[0]
+	}
+	data, _ := json.Marshal(resp)
+	cmd := exec.CommandContext(ctx, self)
+	// Error messages built from Args name the faked program
+	cmd.Args = line
+	cmd.Env = append(os.Environ(), fakeEnv+"="+string(data))
+	return cmd
+}
+
+// LookPath finds every program not in Missing
+func (f *Fake) LookPath(file string) (string, error) {
+	if f.Missing[file] {
+		return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+	}
+	return file, nil
+}
+
+// Calls returns the command lines created so far
+func (f *Fake) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.calls...)
+}
+
+// Ran reports whether a command line starting with prefix was created
+func (f *Fake) Ran(prefix ...string) bool {
+	want := strings.Join(prefix, " ")
+	for _, call := range f.Calls() {
+		if line := strings.Join(call, " "); line == want || strings.HasPrefix(line, want+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeFake answers for a program faked by a Fake and exits; in any other
+// process it returns at once
+func ServeFake() {
+	data, ok := os.LookupEnv(fakeEnv)
+	if !ok {
+		return
+	}
+	var resp Response
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		fmt.Fprintf(os.Stderr, "execwrap: bad fake response: %v\n", err)
+		os.Exit(2)
+	}
+	os.Stdout.WriteString(resp.Stdout)
+	os.Stderr.WriteString(resp.Stderr)
+	time.Sleep(resp.Wait)
+	os.Exit(resp.Exit)
+}
@@ -0,0 +1,89 @@
+// Package fakegame stands in for CS2, Ollama and the Whisper transcriber so
+// the pipeline can be exercised end to end without any of them installed:
+// a console.log that is written, truncated and rotated like the game does,
+// a mock Ollama HTTP server and a transcriber with canned answers. Only
+// tests import it.
+package fakegame
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/monitor"
+)
+
+// ConsoleLog writes chat to a console.log the way CS2 does
+type ConsoleLog struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewConsoleLog creates an empty log at path
+func NewConsoleLog(path string) (*ConsoleLog, error) {
+	if err := monitor.CreateDummyFile(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsoleLog{path: path, f: f}, nil
+}
+
+// Path returns the path of the log
+func (l *ConsoleLog) Path() string {
+	return l.path
+}
+
+// Say writes a chat line from player. team is ALL, T or CT; empty is ALL.
+func (l *ConsoleLog) Say(team, player, text string) error {
+	if team == "" {
+		team = "ALL"
+	}
+	return l.Write(fmt.Sprintf("%s  [%s] %s: %s", time.Now().Format("01/02 15:04:05"), team, player, text))
+}
+
+// Write appends a raw console line
+func (l *ConsoleLog) Write(line string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.f.WriteString(line + "\n")
+	return err
+}
+
+// Truncate empties the log in place, like CS2 started with -conclearlog
+func (l *ConsoleLog) Truncate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Truncate(0)
+}
+
+// Rotate moves the log to path.1 and starts a new one, like tools that
+// archive console.log
+func (l *ConsoleLog) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	return nil
+}
+
+// Close closes the log file
+func (l *ConsoleLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
@@ -0,0 +1,185 @@
+package fakegame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/mqtt"
+)
+
+// MQTT is a mock MQTT 3.1.1 broker. It logs clients in with Username and
+// Password and records what they publish; Drop cuts their connections.
+type MQTT struct {
+	Addr     string // host:port to connect to, without TLS
+	Username string
+	Password string
+	ln       net.Listener
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	messages []mqtt.Message
+	connects int
+}
+
+// NewMQTT starts the mock on a loopback port
+func NewMQTT(username, password string) (*MQTT, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	m := &MQTT{Addr: ln.Addr().String(), Username: username, Password: password, ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	return m, nil
+}
+
+// Messages are what clients published, in order
+func (m *MQTT) Messages() []mqtt.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mqtt.Message(nil), m.messages...)
+}
+
+// Connects counts the logins the mock accepted
+func (m *MQTT) Connects() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connects
+}
+
+// Drop closes every client connection, as a broker restart would
+func (m *MQTT) Drop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.conns {
+		c.Close()
+	}
+	m.conns = nil
+}
+
+// Close drops every client and stops the mock
+func (m *MQTT) Close() {
+	m.ln.Close()
+	m.Drop()
+}
+
+func (m *MQTT) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	first, body, err := readPacket(r)
+	if err != nil || first>>4 != 1 {
+		return
+	}
+	user, password, err := parseConnect(body)
+	if err != nil {
+		return
+	}
+	if user != m.Username || password != m.Password {
+		conn.Write([]byte{0x20, 2, 0, 5}) // not authorized
+		return
+	}
+	if _, err := conn.Write([]byte{0x20, 2, 0, 0}); err != nil {
+		return
+	}
+	m.mu.Lock()
+	m.conns = append(m.conns, conn)
+	m.connects++
+	m.mu.Unlock()
+
+	for {
+		first, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch first >> 4 {
+		case 3: // PUBLISH, QoS 0
+			topic, payload, err := readString(body)
+			if err != nil {
+				return
+			}
+			m.mu.Lock()
+			m.messages = append(m.messages, mqtt.Message{Topic: topic, Payload: payload, Retain: first&0x01 != 0})
+			m.mu.Unlock()
+		case 12: // PINGREQ
+			conn.Write([]byte{0xd0, 0})
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+// parseConnect reads the login of a CONNECT packet
+func parseConnect(body []byte) (user, password string, err error) {
+	_, rest, err := readString(body) // protocol name
+	if err != nil || len(rest) < 4 {
+		return "", "", errors.New("malformed CONNECT")
+	}
+	flags := rest[1]
+	rest = rest[4:]
+	fields := 1 // client ID
+	if flags&0x04 != 0 {
+		fields += 2 // will topic and message
+	}
+	for ; fields > 0; fields-- {
+		if _, rest, err = readString(rest); err != nil {
+			return "", "", err
+		}
+	}
+	if flags&0x80 != 0 {
+		if user, rest, err = readString(rest); err != nil {
+			return "", "", err
+		}
+	}
+	if flags&0x40 != 0 {
+		if password, _, err = readString(rest); err != nil {
+			return "", "", err
+		}
+	}
+	return user, password, nil
+}
+
+// readPacket reads one packet's first byte and body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return first, body, err
+}
+
+// readString splits a length-prefixed string off b
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("truncated MQTT string")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, errors.New("truncated MQTT string")
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
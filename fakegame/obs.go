@@ -0,0 +1,275 @@
+package fakegame
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/micha/cs-ingame-translate/obs"
+)
+
+// OBS is a mock obs-websocket 5 server. It asks clients for Password,
+// answers GetRecordStatus and sends RecordStateChanged events when a test
+// starts, pauses, resumes or stops recording. Text sources can be created
+// and set, and stream captions are kept while SetStreaming is on.
+type OBS struct {
+	Addr     string // host:port to dial
+	Password string
+	srv      *httptest.Server
+
+	wmu       sync.Mutex // the request loop and events share connections
+	mu        sync.Mutex
+	conns     []*websocket.Conn
+	synced    chan struct{} // closed once the first client asked for the record status
+	once      sync.Once
+	recording bool
+	paused    bool
+	started   time.Time
+	streaming bool
+	inputs    map[string]string // text of each text source
+	scenes    map[string][]string
+	captions  []string
+}
+
+// NewOBS starts the mock on a loopback port
+func NewOBS(password string) *OBS {
+	o := &OBS{Password: password, synced: make(chan struct{}), inputs: map[string]string{}, scenes: map[string][]string{}}
+	o.srv = httptest.NewServer(http.HandlerFunc(o.serve))
+	o.Addr = strings.TrimPrefix(o.srv.URL, "http://")
+	return o
+}
+
+// Synced is closed once a client has identified and asked whether OBS is
+// recording, so it sees every change from then on
+func (o *OBS) Synced() <-chan struct{} {
+	return o.synced
+}
+
+// StartRecording starts recording to path
+func (o *OBS) StartRecording(path string) {
+	o.mu.Lock()
+	o.recording, o.paused, o.started = true, false, time.Now()
+	o.mu.Unlock()
+	o.event("OBS_WEBSOCKET_OUTPUT_STARTED", path)
+}
+
+// PauseRecording pauses the recording
+func (o *OBS) PauseRecording() {
+	o.mu.Lock()
+	o.paused = true
+	o.mu.Unlock()
+	o.event("OBS_WEBSOCKET_OUTPUT_PAUSED", "")
+}
+
+// ResumeRecording resumes the paused recording
+func (o *OBS) ResumeRecording() {
+	o.mu.Lock()
+	o.paused = false
+	o.mu.Unlock()
+	o.event("OBS_WEBSOCKET_OUTPUT_RESUMED", "")
+}
+
+// StopRecording stops the recording, which was saved to path
+func (o *OBS) StopRecording(path string) {
+	o.mu.Lock()
+	o.recording, o.paused = false, false
+	o.mu.Unlock()
+	o.event("OBS_WEBSOCKET_OUTPUT_STOPPED", path)
+}
+
+// SetStreaming starts or stops streaming, without which captions fail
+func (o *OBS) SetStreaming(on bool) {
+	o.mu.Lock()
+	o.streaming = on
+	o.mu.Unlock()
+}
+
+// Text is the text of the text source named input; false when there is none
+func (o *OBS) Text(input string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	text, ok := o.inputs[input]
+	return text, ok
+}
+
+// Scene lists the sources clients added to scene
+func (o *OBS) Scene(scene string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.scenes[scene]...)
+}
+
+// Captions are the stream captions sent while streaming
+func (o *OBS) Captions() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.captions...)
+}
+
+// Close disconnects every client and stops the mock
+func (o *OBS) Close() {
+	o.mu.Lock()
+	for _, c := range o.conns {
+		c.Close()
+	}
+	o.mu.Unlock()
+	o.srv.Close()
+}
+
+func (o *OBS) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	salt, challenge := randomString(), randomString()
+	hello := map[string]any{"obsWebSocketVersion": "5.0.0-fake", "rpcVersion": 1}
+	if o.Password != "" {
+		hello["authentication"] = map[string]string{"challenge": challenge, "salt": salt}
+	}
+	if o.send(conn, 0, hello) != nil {
+		return
+	}
+	var identify struct {
+		Op int `json:"op"`
+		D  struct {
+			Authentication string `json:"authentication"`
+		} `json:"d"`
+	}
+	if conn.ReadJSON(&identify) != nil || identify.Op != 1 {
+		return
+	}
+	if o.Password != "" && identify.D.Authentication != obs.Auth(o.Password, salt, challenge) {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4009, "Authentication failed."))
+		return
+	}
+	if o.send(conn, 2, map[string]int{"negotiatedRpcVersion": 1}) != nil {
+		return
+	}
+	o.mu.Lock()
+	o.conns = append(o.conns, conn)
+	o.mu.Unlock()
+
+	for {
+		var req struct {
+			Op int `json:"op"`
+			D  struct {
+				RequestType string          `json:"requestType"`
+				RequestID   string          `json:"requestId"`
+				RequestData json.RawMessage `json:"requestData"`
+			} `json:"d"`
+		}
+		if conn.ReadJSON(&req) != nil {
+			return
+		}
+		if req.Op != 6 {
+			continue
+		}
+		data, code, comment := o.request(req.D.RequestType, req.D.RequestData)
+		resp := map[string]any{
+			"requestType":   req.D.RequestType,
+			"requestId":     req.D.RequestID,
+			"requestStatus": map[string]any{"result": code == 100, "code": code, "comment": comment},
+		}
+		if data != nil {
+			resp["responseData"] = data
+		}
+		if o.send(conn, 7, resp) != nil {
+			return
+		}
+		if req.D.RequestType == "GetRecordStatus" {
+			o.once.Do(func() { close(o.synced) })
+		}
+	}
+}
+
+// request answers one request with its response data and status code
+func (o *OBS) request(requestType string, raw json.RawMessage) (data any, code int, comment string) {
+	var d struct {
+		InputName     string `json:"inputName"`
+		InputKind     string `json:"inputKind"`
+		SceneName     string `json:"sceneName"`
+		CaptionText   string `json:"captionText"`
+		InputSettings struct {
+			Text string `json:"text"`
+		} `json:"inputSettings"`
+	}
+	json.Unmarshal(raw, &d)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch requestType {
+	case "GetRecordStatus":
+		var duration int64
+		if o.recording {
+			duration = time.Since(o.started).Milliseconds()
+		}
+		return map[string]any{"outputActive": o.recording, "outputPaused": o.paused, "outputDuration": duration}, 100, ""
+	case "GetInputKindList":
+		return map[string]any{"inputKinds": []string{"image_source", "text_ft2_source_v2"}}, 100, ""
+	case "GetInputSettings":
+		text, ok := o.inputs[d.InputName]
+		if !ok {
+			return nil, 600, "No source was found by the name of `" + d.InputName + "`."
+		}
+		return map[string]any{"inputKind": "text_ft2_source_v2", "inputSettings": map[string]string{"text": text}}, 100, ""
+	case "CreateInput":
+		if _, ok := o.inputs[d.InputName]; ok {
+			return nil, 601, "A source already exists by that input name."
+		}
+		o.inputs[d.InputName] = d.InputSettings.Text
+		o.scenes[d.SceneName] = append(o.scenes[d.SceneName], d.InputName)
+		return map[string]any{"sceneItemId": len(o.inputs)}, 100, ""
+	case "SetInputSettings":
+		if _, ok := o.inputs[d.InputName]; !ok {
+			return nil, 600, "No source was found by the name of `" + d.InputName + "`."
+		}
+		o.inputs[d.InputName] = d.InputSettings.Text
+		return nil, 100, ""
+	case "SendStreamCaption":
+		if !o.streaming {
+			return nil, 501, "The stream output is not active."
+		}
+		o.captions = append(o.captions, d.CaptionText)
+		return nil, 100, ""
+	}
+	return nil, 204, "Your request type is not valid."
+}
+
+// event sends a RecordStateChanged event to every client
+func (o *OBS) event(state, path string) {
+	data := map[string]any{"outputActive": state != "OBS_WEBSOCKET_OUTPUT_STOPPED", "outputState": state}
+	if path != "" {
+		data["outputPath"] = path
+	}
+	o.mu.Lock()
+	conns := append([]*websocket.Conn(nil), o.conns...)
+	o.mu.Unlock()
+	for _, c := range conns {
+		o.send(c, 5, map[string]any{"eventType": "RecordStateChanged", "eventIntent": 64, "eventData": data})
+	}
+}
+
+// send writes one message
+func (o *OBS) send(conn *websocket.Conn, op int, d any) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	o.wmu.Lock()
+	defer o.wmu.Unlock()
+	return conn.WriteJSON(map[string]any{"op": op, "d": json.RawMessage(b)})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
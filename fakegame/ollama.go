@@ -0,0 +1,239 @@
+package fakegame
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Translation is what the mock Ollama answers for text
+func Translation(text string) string {
+	return "[fake] " + text
+}
+
+// Ollama is a mock Ollama server answering /api/generate with Translation
+// of the text at the end of the prompt, in JSON when a format is asked for,
+// tone classifications with SetTone, and practice grades of 7 that correct
+// nothing unless SetAnswer answers them. /api/ps lists the models SetLoaded
+// loaded.
+// Delays, failures, a missing model and other answers can be switched on
+// while it runs.
+type Ollama struct {
+	URL string
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	delay    time.Duration
+	fail     int  // next generate requests answered with 500
+	missing  bool // answer every generate request with 404
+	answer   func(text string) string
+	thinking bool    // a generate request did not turn thinking off
+	language string  // detected_language of structured answers
+	sureness float64 // confidence of structured answers
+	tone     func(text string) string
+	texts    []string
+	prompts  []string
+	inFlight int
+	peak     int
+	loaded   []loadedModel
+}
+
+// loadedModel is a model /api/ps lists
+type loadedModel struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	VRAM int64  `json:"size_vram"`
+}
+
+// NewOllama starts the mock on a loopback port
+func NewOllama() *Ollama {
+	o := &Ollama{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"version": "0.0.0-fake"})
+	})
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"models": []any{}})
+	})
+	mux.HandleFunc("/api/ps", func(w http.ResponseWriter, r *http.Request) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"models": append([]loadedModel{}, o.loaded...)})
+	})
+	mux.HandleFunc("/api/generate", o.generate)
+	o.srv = httptest.NewServer(mux)
+	o.URL = o.srv.URL
+	return o
+}
+
+// SetDelay makes every translation take d
+func (o *Ollama) SetDelay(d time.Duration) {
+	o.mu.Lock()
+	o.delay = d
+	o.mu.Unlock()
+}
+
+// FailNext answers the next n translations with a server error
+func (o *Ollama) FailNext(n int) {
+	o.mu.Lock()
+	o.fail = n
+	o.mu.Unlock()
+}
+
+// SetMissingModel answers translations as if the model was not pulled
+func (o *Ollama) SetMissingModel(missing bool) {
+	o.mu.Lock()
+	o.missing = missing
+	o.mu.Unlock()
+}
+
+// SetAnswer answers translations with answer(text) instead of Translation,
+// e.g. to imitate a chatty model; nil goes back to Translation
+func (o *Ollama) SetAnswer(answer func(text string) string) {
+	o.mu.Lock()
+	o.answer = answer
+	o.mu.Unlock()
+}
+
+// SetLoaded lists model in /api/ps as taking size bytes, vram of them on
+// the GPU; a size of 0 unloads it
+func (o *Ollama) SetLoaded(model string, size, vram int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.loaded = slices.DeleteFunc(o.loaded, func(m loadedModel) bool { return m.Name == model })
+	if size > 0 {
+		o.loaded = append(o.loaded, loadedModel{Name: model, Size: size, VRAM: vram})
+	}
+}
+
+// SetDetection sets the language and confidence structured answers report
+func (o *Ollama) SetDetection(language string, confidence float64) {
+	o.mu.Lock()
+	o.language, o.sureness = language, confidence
+	o.mu.Unlock()
+}
+
+// SetTone sets the tone classification requests are answered with, by
+// their text; without it every message is neutral
+func (o *Ollama) SetTone(tone func(text string) string) {
+	o.mu.Lock()
+	o.tone = tone
+	o.mu.Unlock()
+}
+
+// Thinking reports whether a translation was asked for without "think":
+// false, which lets reasoning models think before they answer
+func (o *Ollama) Thinking() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.thinking
+}
+
+// Texts returns the texts asked to be translated, in arrival order
+func (o *Ollama) Texts() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.texts...)
+}
+
+// Prompts returns the whole prompts of the texts, in arrival order
+func (o *Ollama) Prompts() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.prompts...)
+}
+
+// Peak returns the most translations that ran at the same time
+func (o *Ollama) Peak() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.peak
+}
+
+// Close stops the server; translators using it see Ollama as down
+func (o *Ollama) Close() {
+	o.srv.CloseClientConnections()
+	o.srv.Close()
+}
+
+func (o *Ollama) generate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model  string          `json:"model"`
+		Prompt string          `json:"prompt"`
+		Think  *bool           `json:"think"`
+		Format json.RawMessage `json:"format"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	// An empty prompt unloads the model
+	if req.Prompt == "" {
+		writeJSON(w, http.StatusOK, map[string]any{"model": req.Model, "done": true})
+		return
+	}
+
+	// The text to translate is the prompt's last paragraph
+	text := req.Prompt
+	if i := strings.LastIndex(text, "\n\n"); i >= 0 {
+		text = text[i+2:]
+	}
+
+	o.mu.Lock()
+	o.texts = append(o.texts, text)
+	o.prompts = append(o.prompts, req.Prompt)
+	o.thinking = o.thinking || req.Think == nil || *req.Think
+	delay, missing, answer := o.delay, o.missing, o.answer
+	language, sureness, tone := o.language, o.sureness, o.tone
+	failing := o.fail > 0
+	if failing {
+		o.fail--
+	}
+	o.inFlight++
+	o.peak = max(o.peak, o.inFlight)
+	o.mu.Unlock()
+	defer func() {
+		o.mu.Lock()
+		o.inFlight--
+		o.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+	switch {
+	case missing:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "model '" + req.Model + "' not found"})
+	case failing:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "fake failure"})
+	case bytes.Contains(req.Format, []byte(`"tone"`)):
+		classified := "neutral"
+		if tone != nil {
+			classified = tone(text)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"model": req.Model, "response": `{"tone": "` + classified + `"}`, "done": true})
+	case answer != nil:
+		writeJSON(w, http.StatusOK, map[string]any{"model": req.Model, "response": answer(text), "done": true})
+	case bytes.Contains(req.Format, []byte(`"corrected"`)):
+		graded, _ := json.Marshal(map[string]any{"score": 7, "corrected": text, "tip": Translation("tip")})
+		writeJSON(w, http.StatusOK, map[string]any{"model": req.Model, "response": string(graded), "done": true})
+	case len(req.Format) > 0:
+		structured, _ := json.Marshal(map[string]any{"translation": Translation(text), "detected_language": language, "confidence": sureness})
+		writeJSON(w, http.StatusOK, map[string]any{"model": req.Model, "response": string(structured), "done": true})
+	default:
+		writeJSON(w, http.StatusOK, map[string]any{"model": req.Model, "response": Translation(text), "done": true})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,124 @@
+package fakegame
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TelegramMessage is a message a bot sent through the mock Telegram API
+type TelegramMessage struct {
+	Chat string
+	Text string
+}
+
+// Telegram is a mock Telegram Bot API server for the bot with Token. It
+// records what the bot sends and lets a test type messages in a chat, which
+// the bot gets through getUpdates.
+type Telegram struct {
+	URL   string // API server to use instead of api.telegram.org
+	Token string
+	srv   *httptest.Server
+
+	mu      sync.Mutex
+	sent    []TelegramMessage
+	updates []map[string]any
+	wake    chan struct{} // closed and replaced when an update arrives
+}
+
+// NewTelegram starts the mock on a loopback port
+func NewTelegram(token string) *Telegram {
+	t := &Telegram{Token: token, wake: make(chan struct{})}
+	t.srv = httptest.NewServer(http.HandlerFunc(t.serve))
+	t.URL = t.srv.URL
+	return t
+}
+
+// Type has text typed in the chat with id, e.g. a command like "/lang German"
+func (t *Telegram) Type(chat int64, text string) {
+	t.mu.Lock()
+	t.updates = append(t.updates, map[string]any{
+		"update_id": len(t.updates) + 1,
+		"message": map[string]any{
+			"message_id": len(t.updates) + 1,
+			"date":       time.Now().Unix(),
+			"chat":       map[string]any{"id": chat, "type": "private"},
+			"text":       text,
+		},
+	})
+	close(t.wake)
+	t.wake = make(chan struct{})
+	t.mu.Unlock()
+}
+
+// Sent are the messages the bot sent, in order
+func (t *Telegram) Sent() []TelegramMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TelegramMessage(nil), t.sent...)
+}
+
+// Close stops the mock
+func (t *Telegram) Close() {
+	t.srv.CloseClientConnections()
+	t.srv.Close()
+}
+
+func (t *Telegram) serve(w http.ResponseWriter, r *http.Request) {
+	token, method, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+	if token != t.Token {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+	var params struct {
+		Offset  int             `json:"offset"`
+		Timeout int             `json:"timeout"`
+		ChatID  json.RawMessage `json:"chat_id"`
+		Text    string          `json:"text"`
+	}
+	json.NewDecoder(r.Body).Decode(&params)
+	switch method {
+	case "getMe":
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "result": map[string]any{"id": 1, "is_bot": true, "username": "cs_translate_bot"}})
+	case "sendMessage":
+		var chat string
+		if json.Unmarshal(params.ChatID, &chat) != nil {
+			chat = string(params.ChatID)
+		}
+		t.mu.Lock()
+		t.sent = append(t.sent, TelegramMessage{Chat: chat, Text: params.Text})
+		t.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "result": map[string]any{"message_id": 1}})
+	case "getUpdates":
+		deadline := time.After(time.Duration(params.Timeout) * time.Second)
+		for {
+			t.mu.Lock()
+			var updates []map[string]any
+			for i, u := range t.updates {
+				if i+1 >= params.Offset {
+					updates = append(updates, u)
+				}
+			}
+			wake := t.wake
+			t.mu.Unlock()
+			if len(updates) > 0 {
+				writeJSON(w, http.StatusOK, map[string]any{"ok": true, "result": updates})
+				return
+			}
+			select {
+			case <-wake:
+			case <-deadline:
+				writeJSON(w, http.StatusOK, map[string]any{"ok": true, "result": []any{}})
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error_code": 404, "description": fmt.Sprintf("Not Found: method %s", method)})
+	}
+}
@@ -0,0 +1,132 @@
+package fakegame
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/audio"
+)
+
+// transcriberEnv names the answers file of a transcriber started by
+// TranscriberCommand
+const transcriberEnv = "CS_TRANSLATE_FAKE_TRANSCRIBER"
+
+// Answer is the canned transcription of one clip
+type Answer struct {
+	Text     string `json:"text"`
+	Language string `json:"language,omitempty"`
+	Error    string `json:"error,omitempty"` // transcribing this clip fails
+	Crash    bool   `json:"crash,omitempty"` // the transcriber exits instead of answering
+}
+
+// WriteAnswers saves answers, keyed by clip file name without extension,
+// for a transcriber started with TranscriberCommand
+func WriteAnswers(path string, answers map[string]Answer) error {
+	data, err := json.Marshal(answers)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// TranscriberCommand returns a command that starts this executable again as
+// a canned transcriber answering from the file WriteAnswers wrote; the
+// executable has to call ServeTranscriberCommand first thing in TestMain.
+// It is a plain exec.Cmd, so a test faking programs with execwrap still
+// gets the transcriber.
+func TranscriberCommand(answersPath string) *exec.Cmd {
+	self, err := os.Executable()
+	if err != nil {
+		self = os.Args[0]
+	}
+	cmd := exec.Command(self)
+	cmd.Env = append(os.Environ(), transcriberEnv+"="+answersPath)
+	return cmd
+}
+
+// ServeTranscriberCommand runs the transcriber of a TranscriberCommand and
+// exits; in any other process it returns at once
+func ServeTranscriberCommand() {
+	path, ok := os.LookupEnv(transcriberEnv)
+	if !ok {
+		return
+	}
+	if err := ServeTranscriberFile(path); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// ServeTranscriberFile runs ServeTranscriber on stdin and stdout with the
+// answers from the file WriteAnswers wrote
+func ServeTranscriberFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var answers map[string]Answer
+	if err := json.Unmarshal(data, &answers); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return ServeTranscriber(os.Stdin, os.Stdout, answers)
+}
+
+// ServeTranscriber speaks the transcriber.py protocol on in and out,
+// answering every clip from answers by its file name without extension, or
+// by its name when its audio comes in the request.
+// Clips without an answer fail. It returns an error when a clip asks it to
+// crash.
+func ServeTranscriber(in io.Reader, out io.Writer, answers map[string]Answer) error {
+	enc := json.NewEncoder(out)
+	if err := enc.Encode(map[string]any{"type": "ready", "protocol": audio.ProtocolVersion}); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(in)
+	// Audio sent in the request makes for long lines
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var req struct {
+			ID   uint64 `json:"id"`
+			Path string `json:"path"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		name := req.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(req.Path), filepath.Ext(req.Path))
+		}
+		answer, ok := answers[name]
+		if !ok {
+			answer.Error = "no canned answer for " + name
+		}
+		if answer.Crash {
+			return fmt.Errorf("crashing on %s as asked", name)
+		}
+		resp := map[string]any{
+			"type":           "result",
+			"id":             req.ID,
+			"text":           answer.Text,
+			"language":       answer.Language,
+			"confidence":     0.9,
+			"avg_logprob":    -0.2,
+			"no_speech_prob": 0.01,
+			"duration":       1.0,
+		}
+		if answer.Error != "" {
+			resp["error"] = answer.Error
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,110 @@
+package fakegame
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Post is a message a client posted to the mock Twitch chat
+type Post struct {
+	Text string
+	At   time.Time
+}
+
+// Twitch is a mock Twitch IRC server. It logs clients in with Token,
+// records what they post and lets a test chat as a viewer.
+type Twitch struct {
+	Addr  string // host:port to connect to, without TLS
+	Token string
+	ln    net.Listener
+
+	mu     sync.Mutex
+	conns  []net.Conn
+	posts  []Post
+	joined chan struct{} // closed when the first client joined its channel
+	once   sync.Once
+}
+
+// NewTwitch starts the mock on a loopback port
+func NewTwitch(token string) (*Twitch, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	t := &Twitch{Addr: ln.Addr().String(), Token: token, ln: ln, joined: make(chan struct{})}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go t.serve(conn)
+		}
+	}()
+	return t, nil
+}
+
+// Joined is closed once a client has logged in and joined a channel
+func (t *Twitch) Joined() <-chan struct{} {
+	return t.joined
+}
+
+// Posts are the messages clients posted, in order
+func (t *Twitch) Posts() []Post {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Post(nil), t.posts...)
+}
+
+// Chat sends a message from user to every client, with badges such as
+// "broadcaster/1" or "moderator/1"
+func (t *Twitch) Chat(user, badges, text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.conns {
+		fmt.Fprintf(c, "@badges=%s;display-name=%s :%s!%s@%s.tmi.twitch.tv PRIVMSG #channel :%s\r\n", badges, user, user, user, user, text)
+	}
+}
+
+// Close disconnects every client and stops the mock
+func (t *Twitch) Close() {
+	t.ln.Close()
+	t.mu.Lock()
+	for _, c := range t.conns {
+		c.Close()
+	}
+	t.mu.Unlock()
+}
+
+func (t *Twitch) serve(conn net.Conn) {
+	defer conn.Close()
+	var pass string
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		command, rest, _ := strings.Cut(scanner.Text(), " ")
+		switch command {
+		case "PASS":
+			pass = rest
+		case "NICK":
+			if pass != "oauth:"+t.Token {
+				fmt.Fprint(conn, ":tmi.twitch.tv NOTICE * :Login authentication failed\r\n")
+				return
+			}
+			fmt.Fprintf(conn, ":tmi.twitch.tv 001 %s :Welcome, GLHF!\r\n", rest)
+		case "JOIN":
+			t.mu.Lock()
+			t.conns = append(t.conns, conn)
+			t.mu.Unlock()
+			t.once.Do(func() { close(t.joined) })
+		case "PRIVMSG":
+			_, text, _ := strings.Cut(rest, " :")
+			t.mu.Lock()
+			t.posts = append(t.posts, Post{Text: text, At: time.Now()})
+			t.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/setup"
+)
+
+// useFFmpeg makes every ffmpeg started run the one LocateFFmpeg finds for
+// the configured path. Without one the name is left to PATH, so setup
+// offers to install it and errors name ffmpeg.
+func useFFmpeg(configured string) {
+	path, err := setup.LocateFFmpeg(configured)
+	if err != nil {
+		slog.Warn("Looking for ffmpeg in PATH instead", "err", err)
+		path, _ = setup.LocateFFmpeg("")
+	}
+	if path != "" {
+		slog.Debug("Using ffmpeg", "path", path)
+	}
+	execwrap.SetPath("ffmpeg", path)
+}
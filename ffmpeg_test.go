@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/setup"
+)
+
+// A configured ffmpeg wins over PATH and the one setup downloaded, which is
+// only used without one in PATH; whichever is found runs for every ffmpeg
+// command
+func TestFFmpeg(t *testing.T) {
+	dir := t.TempDir()
+	defer execwrap.SetPath("ffmpeg", "")
+	// Nothing in PATH to find
+	t.Setenv("PATH", filepath.Join(dir, "empty"))
+	t.Setenv(config.DirEnv, dir)
+
+	if _, err := setup.LocateFFmpeg(filepath.Join(dir, "missing", "ffmpeg")); err == nil {
+		t.Fatal("a configured ffmpeg that does not exist was accepted")
+	}
+	if path, err := setup.LocateFFmpeg(""); err != nil || path != "" {
+		t.Fatalf("found ffmpeg %q (%v) where there is none", path, err)
+	}
+	bundled, err := setup.BundledFFmpeg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configured := filepath.Join(dir, "tools", filepath.Base(bundled))
+	for _, path := range []string{bundled, configured} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if path, err := setup.LocateFFmpeg(""); err != nil || path != bundled {
+		t.Fatalf("found ffmpeg %q (%v), want the downloaded %s", path, err, bundled)
+	}
+	useFFmpeg(configured)
+	if path, err := execwrap.LookPath("ffmpeg"); err != nil || path != configured {
+		t.Fatalf("ffmpeg is looked up as %q (%v), want %s", path, err, configured)
+	}
+	cmd := execwrap.Command("ffmpeg", "-version")
+	if cmd.Path != configured || cmd.Args[0] != "ffmpeg" {
+		t.Fatalf("ffmpeg runs %s as %q, want %s", cmd.Path, cmd.Args[0], configured)
+	}
+}
@@ -0,0 +1,53 @@
+// Package game notices when CS2 starts and exits, so a session can follow
+// the game instead of holding models and capture while nobody plays.
+package game
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultInterval is how often the process list is checked
+const DefaultInterval = 5 * time.Second
+
+// Process names the CS2 executable, "cs2" on every system; on Windows
+// the ".exe" is left off
+const Process = "cs2"
+
+// Watch reports whether CS2 runs: once right away and then on every change,
+// checking every interval. The channel is closed when ctx ends.
+func Watch(ctx context.Context, interval time.Duration) <-chan bool {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	changes := make(chan bool, 1)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last, known, failed := false, false, false
+		for {
+			running, err := Running()
+			switch {
+			case err != nil && !failed:
+				slog.Warn("Could not check whether CS2 runs", "err", err)
+				failed = true
+			case err == nil && (!known || running != last):
+				failed = false
+				last, known = running, true
+				select {
+				case changes <- running:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
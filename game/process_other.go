@@ -0,0 +1,41 @@
+//go:build !windows
+
+package game
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// Running reports whether a cs2 process exists: from /proc on Linux, where
+// Steam's runtime starts the game under its own name, and pgrep elsewhere
+func Running() (bool, error) {
+	if runtime.GOOS != "linux" {
+		err := execwrap.Command("pgrep", "-x", Process).Run()
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 1 {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	comms, err := filepath.Glob("/proc/[0-9]*/comm")
+	if err != nil {
+		return false, err
+	}
+	for _, path := range comms {
+		comm, err := os.ReadFile(path)
+		if err != nil {
+			continue // exited meanwhile
+		}
+		if strings.TrimSpace(string(comm)) == Process {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package game
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Running reports whether a cs2.exe process exists
+func Running() (bool, error) {
+	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return false, err
+	}
+	defer windows.CloseHandle(snap)
+
+	entry := windows.ProcessEntry32{Size: uint32(unsafe.Sizeof(windows.ProcessEntry32{}))}
+	for err = windows.Process32First(snap, &entry); err == nil; err = windows.Process32Next(snap, &entry) {
+		if strings.EqualFold(windows.UTF16ToString(entry.ExeFile[:]), Process+".exe") {
+			return true, nil
+		}
+	}
+	if err == windows.ERROR_NO_MORE_FILES {
+		return false, nil
+	}
+	return false, err
+}
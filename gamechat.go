@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/output"
+)
+
+// gameChatMarker ends the autoexec.cfg line -game-chat-bind manages
+const gameChatMarker = "// cs-translate game chat"
+
+// bindKeyRegex is a CS2 key name, e.g. kp_enter or mouse5
+var bindKeyRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// gameChatSink returns the game chat output for c, or nil when it is off or
+// cannot run
+func gameChatSink(c config.GameChatConfig) output.Sink {
+	keep := translationFilter("game chat", c.Mode)
+	if keep == nil {
+		return nil
+	}
+	dir := c.CfgDir
+	if dir == "" {
+		var err error
+		if dir, err = cs2CfgDir(); err != nil {
+			slog.Warn("Not relaying translations to the game chat", "err", err)
+			return nil
+		}
+	}
+	sink, err := output.NewGameChat(dir, c.Command, c.MaxBytes, keep)
+	if err != nil {
+		slog.Warn("Not relaying translations to the game chat", "err", err)
+		return nil
+	}
+	execLine := "exec " + strings.TrimSuffix(output.GameChatFile, ".cfg")
+	if c.Bind == "" {
+		fmt.Print(i18n.T("Game chat: bind a key in the CS2 console to post the latest translation, e.g. bind \"kp_enter\" \"%s\"\n", execLine))
+		return sink
+	}
+	autoexec := filepath.Join(dir, "autoexec.cfg")
+	if err := bindGameChat(autoexec, c.Bind, execLine); err != nil {
+		slog.Warn("Could not bind the game chat key; bind it yourself", "key", c.Bind, "err", err)
+		return sink
+	}
+	fmt.Print(i18n.T("Game chat: %s posts the latest translation (bound in %s; type exec autoexec in the CS2 console if the game is running).\n", c.Bind, autoexec))
+	return sink
+}
+
+// bindGameChat binds key to command in autoexec. Only the line an earlier
+// run added is replaced, in place; every other line stays exactly as it
+// was. A changed autoexec is backed up next to it first, as the launch
+// options are.
+func bindGameChat(autoexec, key, command string) error {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if !bindKeyRegex.MatchString(key) {
+		return fmt.Errorf("%q is not a CS2 key name", key)
+	}
+	original, err := os.ReadFile(autoexec)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	eol := "\n"
+	if strings.Contains(string(original), "\r\n") {
+		eol = "\r\n"
+	}
+	bind := fmt.Sprintf("bind \"%s\" \"%s\" %s", key, command, gameChatMarker)
+
+	var b strings.Builder
+	placed := false
+	for _, line := range strings.SplitAfter(string(original), "\n") {
+		text := strings.TrimRight(line, "\r\n")
+		if !strings.HasSuffix(text, gameChatMarker) {
+			b.WriteString(line)
+			continue
+		}
+		if !placed {
+			b.WriteString(bind + line[len(text):])
+			placed = true
+		}
+	}
+	if !placed {
+		if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteString(eol)
+		}
+		b.WriteString(bind + eol)
+	}
+	updated := b.String()
+	if exists && updated == string(original) {
+		return nil
+	}
+
+	if exists {
+		backup := fmt.Sprintf("%s.%s.bak", autoexec, time.Now().Format("20060102-150405"))
+		if err := os.WriteFile(backup, original, 0o644); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", autoexec, err)
+		}
+	}
+	tmp := autoexec + ".tmp"
+	if err := os.WriteFile(tmp, []byte(updated), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, autoexec); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", autoexec, err)
+	}
+	return nil
+}
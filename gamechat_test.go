@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/output"
+)
+
+// The game chat cfg holds the newest translation as one say_team command
+// that a message cannot break out of, cut to fit CS2's chat, and the bind is
+// kept in autoexec.cfg
+func TestGameChat(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := output.NewGameChat(dir, "quit", 120, nil); err == nil {
+		t.Fatalf("a command other than say and say_team was accepted")
+	}
+	sink, err := output.NewGameChat(dir, "say_team", 40, translationFilter("game chat", clipboardAll))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfgFile := func() string {
+		data, _ := os.ReadFile(sink.Path())
+		return string(data)
+	}
+	steps := []struct {
+		t    events.TranslationDone
+		want string
+	}{
+		{events.TranslationDone{Source: "chat", Player: "Ivan", Original: "раш б", Translated: `rush "B"; quit`},
+			"say_team \"Ivan: rush 'B', quit\"\n"},
+		{events.TranslationDone{Source: "talk", Original: "давай", Translated: "let's go"},
+			"say_team \"let's go\"\n"},
+		// The posted line coming back as the player's own chat
+		{events.TranslationDone{Source: "chat", Player: "me", Original: "let's go", Translated: "пошли"},
+			"say_team \"let's go\"\n"},
+		{events.TranslationDone{Source: "voice", Translated: "стоим на бомбе, ждём остальных"},
+			"say_team \"стоим на бомбе, ждём...\"\n"},
+		{events.TranslationDone{Source: "chat", Player: "Ivan", Translated: "gg", Via: viaRepeated},
+			"say_team \"стоим на бомбе, ждём...\"\n"},
+	}
+	for _, s := range steps {
+		if err := sink.Handle(s.t); err != nil {
+			t.Fatal(err)
+		}
+		if got := cfgFile(); got != s.want {
+			t.Fatalf("after %q the cfg is %q, want %q", s.t.Translated, got, s.want)
+		}
+	}
+	sink.Close()
+	if strings.Contains(cfgFile(), "say") {
+		t.Fatalf("the cfg still posts after closing: %q", cfgFile())
+	}
+
+	// The player's lines, blank ones and a missing last newline included,
+	// are kept; the bind is replaced where it is
+	autoexec := filepath.Join(dir, "autoexec.cfg")
+	mine := "// mine\r\n\r\ncl_showfps 1"
+	if err := os.WriteFile(autoexec, []byte(mine), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := bindGameChat(autoexec, "kp_enter", "exec cs_translate_chat"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(autoexec)
+	if err := os.WriteFile(autoexec, append(data, "bind \"f1\" \"buy ak47\"\r\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := bindGameChat(autoexec, "MOUSE5", "exec cs_translate_chat"); err != nil {
+		t.Fatal(err)
+	}
+	want := mine + "\r\nbind \"mouse5\" \"exec cs_translate_chat\" " + gameChatMarker + "\r\nbind \"f1\" \"buy ak47\"\r\n"
+	if data, _ := os.ReadFile(autoexec); string(data) != want {
+		t.Fatalf("autoexec.cfg is %q, want %q", data, want)
+	}
+	backups, _ := filepath.Glob(autoexec + ".*.bak")
+	if len(backups) == 0 {
+		t.Fatalf("autoexec.cfg was changed without a backup")
+	}
+	for _, b := range backups {
+		os.Remove(b)
+	}
+	if err := bindGameChat(autoexec, "mouse5", "exec cs_translate_chat"); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := filepath.Glob(autoexec + ".*.bak"); len(backups) != 0 {
+		t.Fatalf("an unchanged autoexec.cfg was backed up again")
+	}
+	if err := bindGameChat(autoexec, `x" "quit`, "exec cs_translate_chat"); err == nil {
+		t.Fatalf("a key name that breaks the bind was accepted")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/game"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// gameWatch pauses the session while CS2 is not running (-game-watch)
+var gameWatch bool
+
+// gameFollower pauses the session when CS2 exits, unloading the models so
+// their VRAM is free between sessions, and resumes it when the game starts
+// again. A session paused by hand stays paused.
+type gameFollower struct {
+	changes <-chan bool // nil without -game-watch
+	paused  bool        // the session was paused because the game exited
+}
+
+func followGame(ctx context.Context) *gameFollower {
+	g := &gameFollower{}
+	if gameWatch {
+		g.changes = game.Watch(ctx, game.DefaultInterval)
+		fmt.Println("Following CS2: capture pauses while the game is not running.")
+	}
+	return g
+}
+
+// Changes reports CS2 starting (true) and exiting (false)
+func (g *gameFollower) Changes() <-chan bool {
+	return g.changes
+}
+
+// handle applies a change from Changes to a session whose pause state is
+// paused and is changed with setPaused
+func (g *gameFollower) handle(running, paused bool, setPaused func(bool), tr translator.Translator) {
+	if running {
+		if g.paused && paused {
+			setPaused(false)
+			bus.Publish(events.Status{Source: "game", State: "running", Message: "CS2 started; capture resumed"})
+		}
+		g.paused = false
+		return
+	}
+	if !paused {
+		setPaused(true)
+		g.paused = true
+	}
+	if err := translator.Unload(tr); err != nil {
+		bus.Publish(events.Error{Source: "game", Err: fmt.Errorf("failed to unload the translation model: %w", err)})
+	}
+	bus.Publish(events.Status{Source: "game", State: "stopped", Message: "CS2 is not running; capture paused and models unloaded until it starts"})
+}
@@ -5,12 +5,11 @@ go 1.24.0
 toolchain go1.24.12
 
 require (
+	github.com/bwmarrin/discordgo v0.29.0
 	github.com/fsnotify/fsnotify v1.6.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/moutend/go-hook v0.1.0
-	github.com/nxadm/tail v1.4.11
+	golang.org/x/sys v0.35.0
 )
 
-require (
-	golang.org/x/sys v0.35.0 // indirect
-	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
-)
+require golang.org/x/crypto v0.41.0 // indirect
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+var errCondebugMissing = errors.New("CS2 launch option -condebug is not set")
+
+// hint is a remediation entry for a recognizable failure
+type hint struct {
+	err   error
+	title string
+	steps []string
+	check string // doctor check that verifies the fix
+}
+
+var hintCatalog = []hint{
+	{
+		err:   audio.ErrNoMonitorSource,
+		title: "No audio monitor source found",
+		steps: []string{
+			"Run 'cs-translate -list-audio-devices' and pass a '.monitor' source with -audiodevice",
+			"Make sure PulseAudio or PipeWire (with pipewire-pulse) is running",
+			"On Windows: run 'cs-translate setup audio' to install virtual-audio-capturer",
+		},
+		check: "audio",
+	},
+	{
+		err:   audio.ErrAppCaptureUnsupported,
+		title: "A single program's audio cannot be recorded on this system",
+		steps: []string{
+			"On Windows: run 'cs-translate setup cable', then set CS2's output to 'CABLE Input' under Settings > System > Sound > Volume mixer",
+			"Record it with -audiodevice \"CABLE Output (VB-Audio Virtual Cable)\" and enable 'Listen to this device' for CABLE Output to keep hearing the game",
+		},
+		check: "audio",
+	},
+	{
+		err:   audio.ErrWhisperOOM,
+		title: "Whisper ran out of GPU memory",
+		steps: []string{
+			"Close other GPU-heavy applications (the game itself uses VRAM too)",
+			"Use a smaller Ollama model with -model so Whisper and the LLM fit together",
+		},
+		check: "transcriber",
+	},
+	{
+		err:   audio.ErrTranscriberNotReady,
+		title: "The transcriber failed to start",
+		steps: []string{
+			"Check the transcriber output above for the Python error",
+			"Native mode: make sure 'openai-whisper' is installed in ./venv",
+			"Docker mode: make sure the 'cs-translate' container is running",
+		},
+		check: "transcriber",
+	},
+	{
+		err:   translator.ErrModelNotFound,
+		title: "The Ollama model is not installed",
+		steps: []string{
+			"Pull it with 'ollama pull <model>' (or 'docker exec cs-translate ollama pull <model>')",
+			"Or choose an installed model with -model",
+		},
+		check: "model",
+	},
+	{
+		err:   translator.ErrOllamaUnavailable,
+		title: "Ollama is not reachable",
+		steps: []string{
+			"Start Ollama ('ollama serve') or the 'cs-translate' Docker container",
+			"If Ollama runs on another port, set OLLAMA_HOST",
+		},
+		check: "ollama",
+	},
+	{
+		err:   errCondebugMissing,
+		title: "CS2 is not writing console.log",
+		steps: []string{
+			"In Steam: CS2 > Properties > Launch Options, add '-condebug'",
+			"Or close Steam and start cs-translate again to have it added automatically",
+			"Restart CS2 afterwards",
+		},
+		check: "condebug",
+	},
+}
+
+// lookupHint returns the catalog entry matching err, if any
+func lookupHint(err error) (hint, bool) {
+	for _, h := range hintCatalog {
+		if errors.Is(err, h.err) {
+			return h, true
+		}
+	}
+	return hint{}, false
+}
+
+// printHint prints targeted remediation for err. It returns false when the
+// error is not recognized so callers can fall back to printing it raw.
+func printHint(err error) bool {
+	h, ok := lookupHint(err)
+	if !ok {
+		return false
+	}
+	fmt.Printf("%s (%v)\n", display.Paint(display.BoldYellow, h.title), err)
+	for _, step := range h.steps {
+		fmt.Printf("  - %s\n", step)
+	}
+	fmt.Printf("  Run 'cs-translate doctor' to verify (check: %s)\n", h.check)
+	return true
+}
+
+var (
+	hintsShownMu sync.Mutex
+	hintsShown   = map[error]bool{}
+)
+
+// printHintOnce is printHint for errors that repeat per message, such as a
+// missing model; each catalog entry is only shown once per session
+func printHintOnce(err error) bool {
+	h, ok := lookupHint(err)
+	if !ok {
+		return false
+	}
+	hintsShownMu.Lock()
+	shown := hintsShown[h.err]
+	hintsShown[h.err] = true
+	hintsShownMu.Unlock()
+	if shown {
+		return true
+	}
+	return printHint(err)
+}
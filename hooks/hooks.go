@@ -0,0 +1,173 @@
+// Package hooks runs user commands on pipeline events. Each command gets the
+// event as JSON on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// Event names hooks can subscribe to
+const (
+	OnTranslation = "on_translation"
+	OnMatchStart  = "on_match_start"
+)
+
+// Events lists every event name, for validation and docs
+var Events = []string{OnTranslation, OnMatchStart}
+
+// DefaultTimeout applies to hooks without their own timeout
+const DefaultTimeout = 5 * time.Second
+
+// Hook runs Command whenever Event fires
+type Hook struct {
+	Event   string
+	Command []string
+	Timeout time.Duration
+}
+
+// Event is what a hook command reads from stdin
+type Event struct {
+	Name string    `json:"event"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+// Translation is the data of an on_translation event
+type Translation struct {
+	Source     string  `json:"source"` // "chat" or "voice"
+	Player     string  `json:"player,omitempty"`
+	Team       string  `json:"team,omitempty"`
+	Capture    string  `json:"capture,omitempty"` // labelled capture source of voice, e.g. "discord"
+	Original   string  `json:"original"`
+	Translated string  `json:"translated"`
+	Language   string  `json:"language"`             // target language code, e.g. "de"
+	Detected   string  `json:"detected,omitempty"`   // language of the original, when the backend reported it
+	Confidence float64 `json:"confidence,omitempty"` // how sure the backend was, 0 to 1
+	Tone       string  `json:"tone,omitempty"`       // friendly, neutral or toxic, when chat was tagged
+	Log        string  `json:"log,omitempty"`        // console log of a chat message
+}
+
+// MatchStart is the data of an on_match_start event
+type MatchStart struct {
+	Map string `json:"map"`
+	Log string `json:"log,omitempty"` // console log that announced it
+}
+
+// Runner dispatches events to the configured hooks. A nil Runner ignores
+// all events.
+type Runner struct {
+	ctx   context.Context
+	hooks map[string][]Hook
+	sem   chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewRunner prepares hooks to run with at most concurrency commands at once.
+// Commands still running when ctx ends are killed.
+func NewRunner(ctx context.Context, hooks []Hook, concurrency int) *Runner {
+	if len(hooks) == 0 {
+		return nil
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	r := &Runner{
+		ctx:   ctx,
+		hooks: make(map[string][]Hook),
+		sem:   make(chan struct{}, concurrency),
+	}
+	for _, h := range hooks {
+		if !validEvent(h.Event) {
+			slog.Warn("Ignoring hook for unknown event", "event", h.Event, "known", strings.Join(Events, ", "))
+			continue
+		}
+		if len(h.Command) == 0 {
+			slog.Warn("Ignoring hook without a command", "event", h.Event)
+			continue
+		}
+		if h.Timeout <= 0 {
+			h.Timeout = DefaultTimeout
+		}
+		r.hooks[h.Event] = append(r.hooks[h.Event], h)
+	}
+	return r
+}
+
+func validEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Fire starts every hook for the event without waiting for them. When the
+// concurrency limit is reached the hook is skipped rather than queued, so a
+// slow command cannot build up a backlog.
+func (r *Runner) Fire(name string, data any) {
+	if r == nil || len(r.hooks[name]) == 0 {
+		return
+	}
+	payload, err := json.Marshal(Event{Name: name, Time: time.Now(), Data: data})
+	if err != nil {
+		slog.Error("Failed to encode event", "event", name, "err", err)
+		return
+	}
+
+	for _, h := range r.hooks[name] {
+		select {
+		case r.sem <- struct{}{}:
+		default:
+			slog.Warn("Skipping hook, too many running", "event", name, "command", h.Command[0], "running", cap(r.sem))
+			continue
+		}
+		r.wg.Add(1)
+		go func(h Hook) {
+			defer r.wg.Done()
+			defer func() { <-r.sem }()
+			r.run(h, payload)
+		}(h)
+	}
+}
+
+func (r *Runner) run(h Hook, payload []byte) {
+	ctx, cancel := context.WithTimeout(r.ctx, h.Timeout)
+	defer cancel()
+
+	cmd := execwrap.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(cmd.Environ(), "CS_TRANSLATE_EVENT="+h.Event)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		slog.Warn("Hook timed out", "event", h.Event, "command", h.Command[0], "timeout", h.Timeout)
+		return
+	}
+	if r.ctx.Err() != nil {
+		return
+	}
+	msg := strings.TrimSpace(string(out))
+	if i := strings.LastIndexByte(msg, '\n'); i != -1 {
+		msg = msg[i+1:]
+	}
+	slog.Warn("Hook failed", "event", h.Event, "command", h.Command[0], "err", err, "output", msg)
+}
+
+// Wait blocks until running hooks have finished
+func (r *Runner) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
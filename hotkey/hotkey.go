@@ -1,8 +1,12 @@
-// Package hotkey provides global hotkey detection using Linux evdev.
+// Package hotkey provides global hotkey detection using Linux evdev: keys,
+// mouse side buttons and gamepad buttons.
 package hotkey
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 )
 
 // Key codes (Linux evdev KEY_* constants)
@@ -21,17 +25,96 @@ const (
 	KeyF12 = 88
 )
 
+// Mouse side buttons (Linux evdev BTN_SIDE and BTN_EXTRA)
+const (
+	BtnSide  = 0x113 // mouse button 4, usually back
+	BtnExtra = 0x114 // mouse button 5, usually forward
+)
+
+// Gamepad buttons, named as on an Xbox controller (Linux evdev BTN_SOUTH and
+// the others, by position)
+const (
+	BtnPadA      = 0x130
+	BtnPadB      = 0x131
+	BtnPadY      = 0x133
+	BtnPadX      = 0x134
+	BtnPadLB     = 0x136
+	BtnPadRB     = 0x137
+	BtnPadBack   = 0x13a
+	BtnPadStart  = 0x13b
+	BtnPadLStick = 0x13d
+	BtnPadRStick = 0x13e
+)
+
+// names are what Parse accepts
+var names = map[string]uint16{
+	"f1": KeyF1, "f2": KeyF2, "f3": KeyF3, "f4": KeyF4, "f5": KeyF5, "f6": KeyF6,
+	"f7": KeyF7, "f8": KeyF8, "f9": KeyF9, "f10": KeyF10, "f11": KeyF11, "f12": KeyF12,
+	"mouse4": BtnSide, "mouse5": BtnExtra,
+	"pad-a": BtnPadA, "pad-b": BtnPadB, "pad-x": BtnPadX, "pad-y": BtnPadY,
+	"pad-lb": BtnPadLB, "pad-rb": BtnPadRB, "pad-back": BtnPadBack, "pad-start": BtnPadStart,
+	"pad-ls": BtnPadLStick, "pad-rs": BtnPadRStick,
+}
+
+// Parse returns the code of a key or button by name: f1 to f12, mouse4 and
+// mouse5, or pad-a, pad-lb and the other gamepad buttons
+func Parse(name string) (uint16, error) {
+	code, ok := names[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("unknown key %q (use %s)", name, strings.Join(Names(), ", "))
+	}
+	return code, nil
+}
+
+// Names lists what Parse accepts, sorted
+func Names() []string {
+	var list []string
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Name is how code is shown to the user, e.g. F9, Mouse4 or Pad-LB
+func Name(code uint16) string {
+	for name, c := range names {
+		if c != code {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(name, "pad-"); ok && len(rest) <= 2 {
+			return "Pad-" + strings.ToUpper(rest)
+		} else if ok {
+			return "Pad-" + strings.ToUpper(rest[:1]) + rest[1:]
+		}
+		return strings.ToUpper(name[:1]) + name[1:]
+	}
+	return fmt.Sprintf("key %d", code)
+}
+
+// IsMouse reports whether code is a mouse button
+func IsMouse(code uint16) bool {
+	return code == BtnSide || code == BtnExtra
+}
+
+// IsGamepad reports whether code is a gamepad button
+func IsGamepad(code uint16) bool {
+	return code >= BtnPadA && code <= BtnPadRStick
+}
+
 // Listener watches for a specific key press and sends on a channel.
 type Listener struct {
-	keyChan chan struct{}
-	keyCode uint16
+	keyChan     chan struct{}
+	releaseChan chan struct{}
+	keyCode     uint16
 }
 
 // NewListener creates a hotkey listener for the given key code.
 func NewListener(keyCode uint16) *Listener {
 	return &Listener{
-		keyChan: make(chan struct{}, 1),
-		keyCode: keyCode,
+		keyChan:     make(chan struct{}, 1),
+		releaseChan: make(chan struct{}, 1),
+		keyCode:     keyCode,
 	}
 }
 
@@ -40,6 +123,25 @@ func (l *Listener) KeyPressed() <-chan struct{} {
 	return l.keyChan
 }
 
+// KeyReleased returns a channel that receives a value each time the hotkey is
+// let go. A key held down is pressed once, however long it is held.
+func (l *Listener) KeyReleased() <-chan struct{} {
+	return l.releaseChan
+}
+
+// send delivers a press or release without blocking; one that nobody took
+// yet is enough
+func (l *Listener) send(down bool) {
+	ch := l.releaseChan
+	if down {
+		ch = l.keyChan
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
 // Start begins listening for the hotkey. It blocks until the context is cancelled.
 // Call this in a goroutine.
 func (l *Listener) Start(ctx context.Context) error {
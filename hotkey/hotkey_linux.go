@@ -6,9 +6,11 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/bits"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"unsafe"
 )
@@ -30,55 +32,68 @@ type inputEvent struct {
 }
 
 const (
-	evKey     = 1 // EV_KEY
-	keyPress  = 1 // key down
-	inputSize = int(unsafe.Sizeof(inputEvent{}))
+	evKey      = 1 // EV_KEY
+	keyRelease = 0 // key up
+	keyPress   = 1 // key down; 2 is autorepeat
+	inputSize  = int(unsafe.Sizeof(inputEvent{}))
 )
 
-// findKeyboardDevices returns paths to keyboard event devices.
-func findKeyboardDevices() ([]string, error) {
+// findDevices returns paths to the event devices that have code: keyboards
+// for a key, mice for a side button, gamepads for a pad button.
+func findDevices(code uint16) ([]string, error) {
 	matches, err := filepath.Glob("/dev/input/event*")
 	if err != nil {
 		return nil, err
 	}
 
-	var keyboards []string
+	var found []string
 	for _, dev := range matches {
-		// Check if this device is a keyboard by reading its name from /sys
+		// The device's key capabilities in /sys are a bitmap of the codes it
+		// reports
 		base := filepath.Base(dev)
-		namePath := filepath.Join("/sys/class/input", base, "device/name")
-		nameBytes, err := os.ReadFile(namePath)
+		capsPath := filepath.Join("/sys/class/input", base, "device/capabilities/key")
+		caps, err := os.ReadFile(capsPath)
 		if err != nil {
 			continue
 		}
-		name := strings.ToLower(strings.TrimSpace(string(nameBytes)))
-		// Look for devices that are likely keyboards
-		if strings.Contains(name, "keyboard") || strings.Contains(name, "kbd") {
-			keyboards = append(keyboards, dev)
+		if hasCode(string(caps), code) {
+			found = append(found, dev)
 		}
 	}
 
-	if len(keyboards) == 0 {
+	if len(found) == 0 {
 		// Fallback: try all event devices
 		return matches, nil
 	}
 
-	return keyboards, nil
+	return found, nil
+}
+
+// hasCode reports whether the capability bitmap caps, hex words of the
+// kernel's long size with the highest first, has code set
+func hasCode(caps string, code uint16) bool {
+	words := strings.Fields(caps)
+	i := len(words) - 1 - int(code)/bits.UintSize
+	if i < 0 {
+		return false
+	}
+	word, err := strconv.ParseUint(words[i], 16, bits.UintSize)
+	return err == nil && word&(1<<(uint(code)%bits.UintSize)) != 0
 }
 
 func (l *Listener) listen(ctx context.Context) error {
-	devices, err := findKeyboardDevices()
+	devices, err := findDevices(l.keyCode)
 	if err != nil {
-		return fmt.Errorf("failed to find keyboard devices: %w", err)
+		return fmt.Errorf("failed to find input devices: %w", err)
 	}
 
 	if len(devices) == 0 {
 		return fmt.Errorf("no input devices found in /dev/input/")
 	}
 
-	log.Printf("Hotkey listener: monitoring %d device(s) for F9 key", len(devices))
+	slog.Info("Hotkey listener: monitoring input devices for "+Name(l.keyCode), "devices", len(devices))
 
-	// Open all keyboard devices and multiplex
+	// Open all the devices and multiplex
 	type devReader struct {
 		file *os.File
 		name string
@@ -88,7 +103,7 @@ func (l *Listener) listen(ctx context.Context) error {
 	for _, dev := range devices {
 		f, err := os.Open(dev)
 		if err != nil {
-			log.Printf("Hotkey: cannot open %s: %v (need root or 'input' group)", dev, err)
+			slog.Warn("Hotkey: cannot open input device (need root or the 'input' group)", "device", dev, "err", err)
 			continue
 		}
 		readers = append(readers, devReader{file: f, name: dev})
@@ -104,8 +119,10 @@ func (l *Listener) listen(ctx context.Context) error {
 		}
 	}()
 
-	// Start a goroutine for each device; all send to the same channel
-	eventChan := make(chan struct{}, 1)
+	// Start a goroutine for each device; all send to the same channel, true
+	// for a press and false for a release. It holds a tap's press and release
+	// both, so the release is not dropped.
+	eventChan := make(chan bool, 8)
 	for _, r := range readers {
 		go func(f *os.File) {
 			buf := make([]byte, inputSize)
@@ -123,9 +140,9 @@ func (l *Listener) listen(ctx context.Context) error {
 				ev.Code = binary.LittleEndian.Uint16(buf[18:20])
 				ev.Value = int32(binary.LittleEndian.Uint32(buf[20:24]))
 
-				if ev.Type == evKey && ev.Code == l.keyCode && ev.Value == keyPress {
+				if ev.Type == evKey && ev.Code == l.keyCode && (ev.Value == keyPress || ev.Value == keyRelease) {
 					select {
-					case eventChan <- struct{}{}:
+					case eventChan <- ev.Value == keyPress:
 					default:
 					}
 				}
@@ -137,11 +154,8 @@ func (l *Listener) listen(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-eventChan:
-			select {
-			case l.keyChan <- struct{}{}:
-			default:
-			}
+		case down := <-eventChan:
+			l.send(down)
 		}
 	}
 }
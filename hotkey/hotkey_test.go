@@ -0,0 +1,23 @@
+package hotkey
+
+import (
+	"strings"
+	"testing"
+)
+
+// Every key and button is parsed from the name it is shown as, and mouse
+// and gamepad buttons are told apart from keys
+func TestNames(t *testing.T) {
+	for _, name := range Names() {
+		code, err := Parse(strings.ToUpper(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shown := Name(code); strings.ToLower(shown) != name {
+			t.Fatalf("%s is shown as %s", name, shown)
+		}
+	}
+	if !IsMouse(BtnSide) || !IsGamepad(BtnPadRStick) || IsGamepad(KeyF9) {
+		t.Fatalf("buttons are not told apart")
+	}
+}
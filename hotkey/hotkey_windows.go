@@ -5,10 +5,14 @@ package hotkey
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/moutend/go-hook/pkg/keyboard"
+	"github.com/moutend/go-hook/pkg/mouse"
 	"github.com/moutend/go-hook/pkg/types"
+	"golang.org/x/sys/windows"
 )
 
 // Map Linux evdev key codes to Windows virtual key codes (approximate)
@@ -45,34 +49,262 @@ func mapLinuxToWindows(linuxCode uint16) types.VKCode {
 }
 
 func (l *Listener) listen(ctx context.Context) error {
-	// Create channel for keyboard events
-	keyboardChan := make(chan types.KeyboardEvent, 100)
-
-	// Install hook
-	if err := keyboard.Install(nil, keyboardChan); err != nil {
-		return fmt.Errorf("failed to install keyboard hook: %w", err)
+	src := sourceOf(l.keyCode)
+	buttons, err := subscribe(src)
+	if err != nil {
+		return err
 	}
-	defer keyboard.Uninstall()
+	defer unsubscribe(src, buttons)
 
-	targetVK := mapLinuxToWindows(l.keyCode)
+	// Windows repeats WM_KEYDOWN while a key is held; only the first one
+	// after a release is a press
+	held := false
 
 	// Keep processing events until context is cancelled
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case event := <-keyboardChan:
-			// Check for key down event (WM_KEYDOWN = 0x0100, WM_SYSKEYDOWN = 0x0104) and matching key code
-			if (event.Message == types.WM_KEYDOWN || event.Message == types.WM_SYSKEYDOWN) && event.VKCode == targetVK {
-				// Non-blocking send to keyChan
-				select {
-				case l.keyChan <- struct{}{}:
-					// Simple debounce to prevent rapid firing if key is held down
-					// In a real loop we might want to track key state, but for F9 trigger this is usually fine
-					time.Sleep(300 * time.Millisecond)
-				default:
+		case b := <-buttons:
+			if b.code != l.keyCode || b.down == held {
+				continue
+			}
+			held = b.down
+			l.send(b.down)
+		}
+	}
+}
+
+// button is a press or release of a key or button, by its evdev code
+type button struct {
+	code uint16
+	down bool
+}
+
+// source is one of the inputs Windows reports buttons from
+type source int
+
+const (
+	keyboardSource source = iota
+	mouseSource
+	gamepadSource
+)
+
+// sourceOf is the input that reports code
+func sourceOf(code uint16) source {
+	switch {
+	case IsMouse(code):
+		return mouseSource
+	case IsGamepad(code):
+		return gamepadSource
+	}
+	return keyboardSource
+}
+
+// hub shares each source between listeners, as Windows allows one low-level
+// hook of a kind per process
+var hub = struct {
+	sync.Mutex
+	subs  map[source]map[chan button]bool
+	stops map[source]func()
+}{subs: map[source]map[chan button]bool{}, stops: map[source]func(){}}
+
+// subscribe starts src if no listener uses it yet and returns its buttons
+func subscribe(src source) (chan button, error) {
+	hub.Lock()
+	defer hub.Unlock()
+	if len(hub.subs[src]) == 0 {
+		start := map[source]func() (func(), error){
+			keyboardSource: startKeyboard,
+			mouseSource:    startMouse,
+			gamepadSource:  startGamepad,
+		}[src]
+		stop, err := start()
+		if err != nil {
+			return nil, err
+		}
+		hub.stops[src] = stop
+		hub.subs[src] = map[chan button]bool{}
+	}
+	ch := make(chan button, 16)
+	hub.subs[src][ch] = true
+	return ch, nil
+}
+
+// unsubscribe stops src once its last listener is gone
+func unsubscribe(src source, ch chan button) {
+	hub.Lock()
+	defer hub.Unlock()
+	delete(hub.subs[src], ch)
+	if len(hub.subs[src]) == 0 {
+		hub.stops[src]()
+		delete(hub.stops, src)
+	}
+}
+
+// publish hands b to every listener of src without blocking
+func publish(src source, b button) {
+	hub.Lock()
+	defer hub.Unlock()
+	for ch := range hub.subs[src] {
+		select {
+		case ch <- b:
+		default:
+		}
+	}
+}
+
+// windowsKeys maps the virtual key codes back to evdev codes
+var windowsKeys = func() map[types.VKCode]uint16 {
+	m := map[types.VKCode]uint16{}
+	for _, code := range []uint16{KeyF1, KeyF2, KeyF3, KeyF4, KeyF5, KeyF6, KeyF7, KeyF8, KeyF9, KeyF10, KeyF11, KeyF12} {
+		m[mapLinuxToWindows(code)] = code
+	}
+	return m
+}()
+
+// startKeyboard installs the keyboard hook
+func startKeyboard() (func(), error) {
+	events := make(chan types.KeyboardEvent, 100)
+	if err := keyboard.Install(nil, events); err != nil {
+		return nil, fmt.Errorf("failed to install keyboard hook: %w", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case event := <-events:
+				code, ok := windowsKeys[event.VKCode]
+				if !ok {
+					continue
+				}
+				switch event.Message {
+				case types.WM_KEYDOWN, types.WM_SYSKEYDOWN:
+					publish(keyboardSource, button{code, true})
+				case types.WM_KEYUP, types.WM_SYSKEYUP:
+					publish(keyboardSource, button{code, false})
+				}
+			}
+		}
+	}()
+	return func() {
+		keyboard.Uninstall()
+		close(done)
+	}, nil
+}
+
+// Mouse messages of the side buttons; the high word of MouseData says which
+const (
+	wmXButtonDown types.Message = 0x020B
+	wmXButtonUp   types.Message = 0x020C
+	xButton2                    = 2
+)
+
+// startMouse installs the mouse hook
+func startMouse() (func(), error) {
+	events := make(chan types.MouseEvent, 100)
+	if err := mouse.Install(nil, events); err != nil {
+		return nil, fmt.Errorf("failed to install mouse hook: %w", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case event := <-events:
+				if event.Message != wmXButtonDown && event.Message != wmXButtonUp {
+					continue
 				}
+				code := uint16(BtnSide)
+				if event.MouseData>>16 == xButton2 {
+					code = BtnExtra
+				}
+				publish(mouseSource, button{code, event.Message == wmXButtonDown})
 			}
 		}
+	}()
+	return func() {
+		mouse.Uninstall()
+		close(done)
+	}, nil
+}
+
+// XInput reports controllers by polling; xinput1_4 ships with Windows 10
+var xinputGetState = windows.NewLazySystemDLL("xinput1_4.dll").NewProc("XInputGetState")
+
+// xinputState matches XINPUT_STATE
+type xinputState struct {
+	PacketNumber uint32
+	Buttons      uint16
+	LeftTrigger  uint8
+	RightTrigger uint8
+	ThumbLX      int16
+	ThumbLY      int16
+	ThumbRX      int16
+	ThumbRY      int16
+}
+
+// padButtons maps the XINPUT_GAMEPAD_* bits to evdev codes
+var padButtons = map[uint16]uint16{
+	0x1000: BtnPadA,
+	0x2000: BtnPadB,
+	0x4000: BtnPadX,
+	0x8000: BtnPadY,
+	0x0100: BtnPadLB,
+	0x0200: BtnPadRB,
+	0x0020: BtnPadBack,
+	0x0010: BtnPadStart,
+	0x0040: BtnPadLStick,
+	0x0080: BtnPadRStick,
+}
+
+const (
+	xinputControllers = 4 // XUSER_MAX_COUNT
+	// padPoll is how often connected controllers are read; asking for a
+	// missing one is slow, so those are only checked every padRetry
+	padPoll  = 15 * time.Millisecond
+	padRetry = 2 * time.Second
+)
+
+// startGamepad polls the XInput controllers for button changes
+func startGamepad() (func(), error) {
+	if err := xinputGetState.Find(); err != nil {
+		return nil, fmt.Errorf("gamepad buttons need XInput: %w", err)
 	}
+	done := make(chan struct{})
+	go func() {
+		var pressed [xinputControllers]uint16
+		var retry [xinputControllers]time.Time
+		ticker := time.NewTicker(padPoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				for i := range xinputControllers {
+					if now.Before(retry[i]) {
+						continue
+					}
+					var state xinputState
+					if r, _, _ := xinputGetState.Call(uintptr(i), uintptr(unsafe.Pointer(&state))); r != 0 {
+						// ERROR_DEVICE_NOT_CONNECTED
+						retry[i] = now.Add(padRetry)
+						state.Buttons = 0
+					}
+					changed := pressed[i] ^ state.Buttons
+					for bit, code := range padButtons {
+						if changed&bit != 0 {
+							publish(gamepadSource, button{code, state.Buttons&bit != 0})
+						}
+					}
+					pressed[i] = state.Buttons
+				}
+			}
+		}
+	}()
+	return func() { close(done) }, nil
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/hotkey"
+	"github.com/micha/cs-ingame-translate/i18n"
+)
+
+// The hotkeys of both modes. Messages name them by their default keys;
+// the hotkeys settings bind them to other keys, mouse side buttons or
+// gamepad buttons.
+var (
+	pauseKey   uint16 = hotkey.KeyF8  // pauses and resumes the session
+	captureKey uint16 = hotkey.KeyF9  // captures voice in echo mode and with -voice-mode key
+	talkKey    uint16 = hotkey.KeyF10 // starts and stops talk mode
+)
+
+// bindHotkeys applies the hotkeys settings and says where each moved hotkey
+// is now
+func bindHotkeys(c config.HotkeyConfig) error {
+	for _, b := range []struct {
+		name, setting string
+		key           *uint16
+	}{
+		{"F8", c.Pause, &pauseKey},
+		{"F9", c.Capture, &captureKey},
+		{"F10", c.Talk, &talkKey},
+	} {
+		if b.setting == "" {
+			continue
+		}
+		code, err := hotkey.Parse(b.setting)
+		if err != nil {
+			return fmt.Errorf("hotkey for %s: %w", b.name, err)
+		}
+		*b.key = code
+		if hotkey.Name(code) != b.name {
+			fmt.Print(i18n.T("%s is bound to %s.\n", b.name, hotkey.Name(code)))
+		}
+	}
+	if pauseKey == captureKey || pauseKey == talkKey || captureKey == talkKey {
+		return fmt.Errorf("the pause, capture and talk hotkeys need three different keys")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/hotkey"
+)
+
+// The hotkeys settings move F8, F9 and F10 to other keys, mouse side buttons
+// and gamepad buttons, and refuse unknown or shared ones
+func TestBindHotkeys(t *testing.T) {
+	defer func(pause, capture, talk uint16) {
+		pauseKey, captureKey, talkKey = pause, capture, talk
+	}(pauseKey, captureKey, talkKey)
+	if err := bindHotkeys(config.HotkeyConfig{Capture: "mouse5", Talk: "pad-lb"}); err != nil {
+		t.Fatal(err)
+	}
+	if pauseKey != hotkey.KeyF8 || captureKey != hotkey.BtnExtra || talkKey != hotkey.BtnPadLB {
+		t.Fatalf("bound pause %s, capture %s, talk %s", hotkey.Name(pauseKey), hotkey.Name(captureKey), hotkey.Name(talkKey))
+	}
+	if err := bindHotkeys(config.HotkeyConfig{Capture: "f8"}); err == nil {
+		t.Fatalf("capture was bound to the pause key")
+	}
+	if err := bindHotkeys(config.HotkeyConfig{Pause: "mouse9"}); err == nil {
+		t.Fatalf("an unknown button was bound")
+	}
+}
@@ -0,0 +1,282 @@
+package i18n
+
+// de holds the German messages
+var de = map[string]string{
+	"\nVoice mode will work with -audiodevice '%s'.\n":                   "\nDer Sprachmodus funktioniert mit -audiodevice '%s'.\n",
+	"%s Heard in %s: %q\n":                                               "%s In %s gehört: %q\n",
+	"Transcribing the recording...":                                      "Die Aufnahme wird transkribiert...",
+	"%s Audio captured (peak %.0f dB)\n":                                 "%s Ton aufgenommen (Spitze %.0f dB)\n",
+	"Recording %s from '%s'; say something as you would in a match...\n": "%s lang wird von '%s' aufgenommen; jetzt etwas sagen wie im Match...\n",
+	"auto-detect": "automatisch",
+	"  Whisper %s skipped: transcriber did not start\n": "  Whisper %s übersprungen: Transkription ist nicht gestartet\n",
+	"Voice: %d clips": "Sprache: %d Clips",
+	"Chat: %d lines, %d prompt variants, %d models\n": "Chat: %d Zeilen, %d Prompt-Varianten, %d Modelle\n",
+	"First error: %v\n":              "Erster Fehler: %v\n",
+	"  Whisper %s skipped: %v\n":     "  Whisper %s übersprungen: %v\n",
+	"Voice skipped: %v\n":            "Sprache übersprungen: %v\n",
+	"  %s skipped: %v\n":             "  %s übersprungen: %v\n",
+	"Warning: %v (using defaults)\n": "Warnung: %v (Standardwerte werden verwendet)\n",
+	"Usage: cs-translate devices <list|test> [-audiodevice name|number] [-duration 5s]":                   "Verwendung: cs-translate devices <list|test> [-audiodevice Name|Nummer] [-duration 5s]",
+	"(heard: chrF of the transcription against the spoken line; latency: transcription plus translation)": "(heard: chrF der Transkription gegenüber der gesprochenen Zeile; Latenz: Transkription plus Übersetzung)",
+	"Programs with the log open: %s": "Programme, die das Log geöffnet haben: %s",
+	"Warning: console.log was truncated while CS2 is running. Another tool is probably clearing it; close it so chat lines are not lost.": "Warnung: console.log wurde gekürzt, während CS2 läuft. Wahrscheinlich leert ein anderes Programm es; dieses schließen, damit keine Chatzeilen verloren gehen.",
+	"(%d lines had no text-to-speech voice)":                              "(%d Zeilen hatten keine Sprachausgabe-Stimme)",
+	"Port %d is already in use by another program.\n":                     "Port %d wird bereits von einem anderen Programm genutzt.\n",
+	"Usage: cs-translate container <stop|rm|logs|update> [-f] [-dry-run]": "Verwendung: cs-translate container <stop|rm|logs|update> [-f] [-dry-run]",
+	"Using the console log from the last run: %s\n":                       "Konsolenlog des letzten Laufs wird verwendet: %s\n",
+	"Port %d is used by CS2 game state integration (%s).\n":               "Port %d wird von der CS2-Game-State-Integration genutzt (%s).\n",
+	"  - Use -log-wait 0 to keep waiting indefinitely":                    "  - Mit -log-wait 0 unbegrenzt weiter warten",
+	"  - Run 'cs-translate doctor' to check the setup":                    "  - 'cs-translate doctor' ausführen, um die Einrichtung zu prüfen",
+	"console.log did not appear within %s; chat translation is disabled.": "console.log ist innerhalb von %s nicht erschienen; die Chat-Übersetzung ist deaktiviert.",
+	"Started plugin %s\n": "Plugin %s gestartet\n",
+	"Wrote %s. Secrets are redacted; it still holds file paths and log messages, so look through it before attaching it to an issue.\n": "%s geschrieben. Geheimnisse sind entfernt; Dateipfade und Lognachrichten sind noch enthalten, also vor dem Anhängen an ein Issue durchsehen.\n",
+	"Collecting logs, settings and the environment...": "Logs, Einstellungen und Umgebung werden gesammelt...",
+	"Settings imported.":                                          "Einstellungen importiert.",
+	"Error creating translator: %v\n":                             "Fehler beim Erstellen des Übersetzers: %v\n",
+	"Serving the web API on port %d instead.\n":                   "Die Web-API läuft stattdessen auf Port %d.\n",
+	"Waiting for logs matching %s\n":                              "Warte auf Logs passend zu %s\n",
+	"Monitoring log file: %s\n":                                   "Logdatei wird überwacht: %s\n",
+	"Waiting for client logs matching %s\n":                       "Warte auf Client-Logs passend zu %s\n",
+	"Monitoring client log: %s\n":                                 "Client-Log wird überwacht: %s\n",
+	"Nothing changed.":                                            "Nichts geändert.",
+	"Apply these changes? [y/N]: ":                                "Diese Änderungen übernehmen? [j/N]: ",
+	"  %s: replaced from the bundle\n":                            "  %s: aus dem Paket ersetzt\n",
+	"The bundle matches your settings; nothing to do.":            "Das Paket entspricht den Einstellungen; nichts zu tun.",
+	"Usage: cs-translate config import [-y] <bundle.zip>":         "Verwendung: cs-translate config import [-y] <bundle.zip>",
+	"Usage: cs-translate config export <bundle.zip>":              "Verwendung: cs-translate config export <bundle.zip>",
+	"Wrote default config to %s\n":                                "Standardeinstellungen nach %s geschrieben\n",
+	"Config file already exists: %s\n":                            "Die Einstellungsdatei existiert bereits: %s\n",
+	"Usage: cs-translate config <path|init|schema|export|import>": "Verwendung: cs-translate config <path|init|schema|export|import>",
+	"  logaddress_add <address>:%s   or   logaddress_add_http \"http://<address>:%s%s\"\n": "  logaddress_add <adresse>:%s   oder   logaddress_add_http \"http://<adresse>:%s%s\"\n",
+	"API listening on http://%s (reference at /docs). Press Ctrl+C to exit.\n":             "API lauscht auf http://%s (Referenz unter /docs). Strg+C beendet.\n",
+	"(toxic)": "(toxisch)",
+	"[truncated: %d more characters not translated]": "[gekürzt: %d weitere Zeichen nicht übersetzt]",
+	"[talk] You: ":    "[talk] Du: ",
+	"voice (%s): ":    "Sprache (%s): ",
+	"voice %.2fs: ":   "Sprache %.2fs: ",
+	"Translated: ":    "Übersetzt: ",
+	"(toxic, hidden)": "(toxisch, ausgeblendet)",
+	"Note: other programs also have console.log open: %s. If one of them clears the log, chat lines can be missed.": "Hinweis: Auch andere Programme haben console.log geöffnet: %s. Leert eines davon das Log, können Chatzeilen verloren gehen.",
+	"%d subtitles for the recording saved to %s":              "%d Untertitel für die Aufnahme unter %s gespeichert",
+	"OBS is recording; its subtitles are saved when it stops": "OBS nimmt auf; die Untertitel werden gespeichert, wenn die Aufnahme endet",
+	"(unsure, %.0f%%)":                                          "(unsicher, %.0f%%)",
+	"voice translation is disabled":                             "Sprachübersetzung ist deaktiviert",
+	"[talk] Say (%s): %s":                                       "[talk] Sag (%s): %s",
+	"Voice %.2fs: %s \n":                                        "Sprache %.2fs: %s \n",
+	"(own language, not translated)":                            "(eigene Sprache, nicht übersetzt)",
+	"(rate limited, not translated)":                            "(Limit erreicht, nicht übersetzt)",
+	"(private, not translated)":                                 "(privat, nicht übersetzt)",
+	"[Translation Pending/Error]":                               "[Übersetzung ausstehend/Fehler]",
+	"(repeated; further repeats are hidden)":                    "(wiederholt; weitere Wiederholungen werden ausgeblendet)",
+	"(superseded by a newer message)":                           "(durch eine neuere Nachricht ersetzt)",
+	"\nOriginal: %s\n":                                          "\nOriginal: %s\n",
+	"Error listing devices: %v\n":                               "Fehler beim Auflisten der Geräte: %v\n",
+	"Available audio devices:":                                  "Verfügbare Audiogeräte:",
+	"Error listing PipeWire nodes: %v\n":                        "Fehler beim Auflisten der PipeWire-Knoten: %v\n",
+	"\nPipeWire nodes:":                                         "\nPipeWire-Knoten:",
+	"\nApplications playing audio (records only that program):": "\nProgramme, die Audio abspielen (nimmt nur dieses Programm auf):",
+	"Saved to %s":                                               "Gespeichert unter %s",
+	"Match summary:":                                            "Zusammenfassung des Matches:",
+	"Select Mode:":                                              "Modus wählen:",
+	"1. CS2 In-Game Translate (Monitor Console Log)":            "1. CS2 In-Game-Übersetzung (Konsolenlog überwachen)",
+	"Receiving server logs on port %s. On the server, with this machine's address:\n":                                                             "Serverlogs werden auf Port %s empfangen. Auf dem Server, mit der Adresse dieses Rechners:\n",
+	"  - Or point to the log directly: cs-translate -log /path/to/console.log":                                                                    "  - Oder das Log direkt angeben: cs-translate -log /pfad/zu/console.log",
+	"  - Add -condebug to CS2's launch options in Steam and start a match":                                                                        "  - -condebug in Steam zu den Startoptionen von CS2 hinzufügen und ein Match starten",
+	"Wrote %s. Log paths, audio devices, hooks and the web address are left out.\n":                                                               "%s geschrieben. Logpfade, Audiogeräte, Hooks und die Webadresse sind nicht enthalten.\n",
+	"2. Additionally listening to system output audio \nPress F9 to capture the last %s, transcribe, and translate.\n":                            "2. Zusätzlich die Audioausgabe des Systems mithören \nF9 nimmt die letzten %s auf, transkribiert und übersetzt sie.\n",
+	"Finishing translations in progress... (Ctrl+C again quits now)":                                                                              "Laufende Übersetzungen werden abgeschlossen... (erneut Strg+C beendet sofort)",
+	"3. CS2 In-Game Translate + talk to your team \nPress F10, speak, and press F10 again to translate your microphone into the team's language.": "3. CS2 In-Game-Übersetzung + mit dem Team sprechen \nF10 drücken, sprechen und erneut F10 drücken, um das Mikrofon in die Sprache des Teams zu übersetzen.",
+	"Enter choice [%s]: ": "Auswahl eingeben [%s]: ",
+	"Enable Voice Transcription (uses Docker by default)? [y/N]: ":                                      "Sprachtranskription aktivieren (nutzt standardmäßig Docker)? [j/N]: ",
+	"Warning: Could not verify launch options.":                                                         "Warnung: Die Startoptionen konnten nicht geprüft werden.",
+	"CS2 launch option '-condebug' not detected.":                                                       "CS2-Startoption '-condebug' nicht gefunden.",
+	"Add it to the launch options now (localconfig.vdf is backed up first)? [Y/n]: ":                    "Jetzt zu den Startoptionen hinzufügen (localconfig.vdf wird vorher gesichert)? [J/n]: ",
+	"Could not edit the launch options: %v\n":                                                           "Die Startoptionen konnten nicht geändert werden: %v\n",
+	"Steam is running, so it cannot be added automatically (close Steam to have cs-translate edit it).": "Steam läuft, daher kann die Option nicht automatisch hinzugefügt werden (Steam schließen, damit cs-translate sie einträgt).",
+	"Do you want to open Steam properties for CS2 to set it? [Y/n]: ":                                   "Die Steam-Eigenschaften von CS2 öffnen, um sie zu setzen? [J/n]: ",
+	"[dry run] %s\n":                     "[Probelauf] %s\n",
+	"Stopping Docker container...":       "Docker-Container wird gestoppt...",
+	"CS2 launch option -condebug is set": "CS2-Startoption -condebug ist gesetzt",
+	"[dry run] add -condebug to the CS2 launch options in %s (backed up first)\n":                              "[Probelauf] -condebug zu den CS2-Startoptionen in %s hinzufügen (vorher gesichert)\n",
+	"Added -condebug for Steam account %s (backup: %s)\n":                                                      "-condebug für Steam-Konto %s hinzugefügt (Sicherung: %s)\n",
+	"Background recording started.":                                                                            "Aufnahme im Hintergrund gestartet.",
+	"Dry run finished; nothing was installed or changed.":                                                      "Probelauf beendet; nichts wurde installiert oder geändert.",
+	"Using %s for translation to %s\n":                                                                         "Übersetzung mit %s nach %s\n",
+	"Translating %s messages to %s instead\n":                                                                  "%s-Nachrichten werden stattdessen nach %s übersetzt\n",
+	"Tagging the tone of chat with %s\n":                                                                       "Der Ton des Chats wird mit %s eingeordnet\n",
+	"Connecting to OBS at %s\n":                                                                                "Verbindung zu OBS unter %s\n",
+	"Posting translations to the Twitch chat of %s\n":                                                          "Übersetzungen werden im Twitch-Chat von %s gepostet\n",
+	"Mirroring translations to the Telegram chat %s\n":                                                         "Übersetzungen werden in den Telegram-Chat %s gespiegelt\n",
+	"Publishing events to the MQTT broker %s\n":                                                                "Ereignisse werden an den MQTT-Broker %s gesendet\n",
+	"Web server listening on http://%s (reference at /docs)\n":                                                 "Webserver läuft auf http://%s (Referenz unter /docs)\n",
+	"\n=== Echo Mode Started ===":                                                                              "\n=== Echo-Modus gestartet ===",
+	"Listening to system output audio + Monitoring CS2 Console...":                                             "Audioausgabe des Systems wird mitgehört + CS2-Konsole wird überwacht...",
+	"Press F9 to capture the last %s, transcribe, and translate.\n":                                            "F9 nimmt die letzten %s auf, transkribiert und übersetzt sie.\n",
+	"Double-press F9 to mark a start, then press it again to capture everything since.":                        "F9 doppelt drücken markiert einen Anfang; erneutes Drücken nimmt alles seitdem auf.",
+	"Press F8 or type /pause to pause capture and translation.":                                                "F8 oder /pause hält Aufnahme und Übersetzung an.",
+	"%s is bound to %s.\n":                                                                                     "%s liegt auf %s.\n",
+	"Game chat: bind a key in the CS2 console to post the latest translation, e.g. bind \"kp_enter\" \"%s\"\n": "Spielchat: Belege in der CS2-Konsole eine Taste, die die letzte Übersetzung sendet, z. B. bind \"kp_enter\" \"%s\"\n",
+	"Game chat: %s posts the latest translation (bound in %s; type exec autoexec in the CS2 console if the game is running).\n": "Spielchat: %s sendet die letzte Übersetzung (belegt in %s; tippe exec autoexec in die CS2-Konsole, wenn das Spiel läuft).\n",
+	"Press Ctrl+C to exit.":                                                     "Strg+C beendet das Programm.",
+	"Auto-detecting log file location in the background...":                     "Logdatei wird im Hintergrund gesucht...",
+	"\nStopping...":                                                             "\nWird beendet...",
+	"\nFound log file: %s\n":                                                    "\nLogdatei gefunden: %s\n",
+	"\n[F9] Paused; press F8 or type /resume first.":                            "\n[F9] Angehalten; zuerst F8 drücken oder /resume eingeben.",
+	"\n[F9] Capturing %.0fs since mark...\n":                                    "\n[F9] %.0fs seit der Markierung werden aufgenommen...\n",
+	"\n[F9] Start marked; press F9 again to capture up to now.":                 "\n[F9] Anfang markiert; F9 erneut drücken, um bis jetzt aufzunehmen.",
+	"\n[F9] Capturing...":                                                       "\n[F9] Aufnahme...",
+	"\n[F9] Transcribing the last %s of voice...\n":                             "\n[F9] Die letzten %s Sprache werden transkribiert...\n",
+	"\n[F9] Voice capture is not running.":                                      "\n[F9] Die Sprachaufnahme läuft nicht.",
+	"\n[F9] Recording; let go of F9 to transcribe.":                             "\n[F9] Aufnahme läuft; F9 loslassen zum Transkribieren.",
+	"\n[F9] Hold F9 while speaking and let go when done.":                       "\n[F9] F9 beim Sprechen gedrückt halten und danach loslassen.",
+	"\n[F9] Capturing %.0fs while F9 was held...\n":                             "\n[F9] %.0f s, in denen F9 gedrückt war, werden aufgenommen...\n",
+	"Hold F9 while speaking; it is transcribed and translated when you let go.": "F9 beim Sprechen gedrückt halten; beim Loslassen wird das Gesagte transkribiert und übersetzt.",
+	"\n[Stream Deck] Capturing...":                                              "\n[Stream Deck] Aufnahme...",
+	"Auto-detecting log file location...":                                       "Logdatei wird gesucht...",
+	"Log file not found yet. Waiting for CS2 to start...":                       "Logdatei noch nicht gefunden. Warte auf den Start von CS2...",
+	"Local Audio transcription enabled (Whisper '%s' model).\n":                 "Lokale Audiotranskription aktiviert (Whisper-Modell '%s').\n",
+	"Capturing %s; speech is tagged with its label.\n":                          "Aufnahme von %s; Gesprochenes wird mit der Bezeichnung seiner Quelle markiert.\n",
+	"Waiting for chat messages... (type /fix if a player name was cut at a colon, /pause or F8 to pause)": "Warte auf Chatnachrichten... (/fix, wenn ein Spielername am Doppelpunkt abgeschnitten wurde, /pause oder F8 zum Anhalten)",
+	"Found log file: %s\n":                                   "Logdatei gefunden: %s\n",
+	"[talk] Paused; press F8 or type /resume first.":         "[talk] Angehalten; zuerst F8 drücken oder /resume eingeben.",
+	"[Stream Deck] Voice capture paused":                     "[Stream Deck] Sprachaufnahme angehalten",
+	"[Stream Deck] Voice capture resumed":                    "[Stream Deck] Sprachaufnahme fortgesetzt",
+	"Container '%s' is not running\n":                        "Container '%s' läuft nicht\n",
+	"Container '%s' does not exist\n":                        "Container '%s' existiert nicht\n",
+	"Docker is running":                                      "Docker läuft",
+	"Docker container is running":                            "Docker-Container läuft",
+	"Setting up Docker container with Ollama and Whisper...": "Docker-Container mit Ollama und Whisper wird eingerichtet...",
+	"Docker container already running":                       "Docker-Container läuft bereits",
+	"Starting existing Docker container...":                  "Vorhandener Docker-Container wird gestartet...",
+	"Install Docker Desktop from https://www.docker.com/products/docker-desktop/ or set USE_DOCKER_OLLAMA=0 for a native installation.": "Docker Desktop von https://www.docker.com/products/docker-desktop/ installieren oder USE_DOCKER_OLLAMA=0 für eine native Installation setzen.",
+	"Docker Desktop is not installed. It runs on WSL2, which needs a restart after installing.":                                         "Docker Desktop ist nicht installiert. Es läuft auf WSL2, das nach der Installation einen Neustart braucht.",
+	"Install WSL2 and Docker Desktop now? [Y/n]: ":                                                                                      "WSL2 und Docker Desktop jetzt installieren? [J/n]: ",
+	"Installing WSL2 (confirm the administrator prompt)...":                                                                             "WSL2 wird installiert (Administratorabfrage bestätigen)...",
+	"Installing Docker Desktop...":                                                                                                      "Docker Desktop wird installiert...",
+	"Docker Desktop installed. Restart Windows, start Docker Desktop once and run cs-translate again.":                                  "Docker Desktop installiert. Windows neu starten, Docker Desktop einmal starten und cs-translate erneut ausführen.",
+	"Building Docker container (first time only, this may take a few minutes)...":                                                       "Docker-Container wird gebaut (nur beim ersten Mal, das kann einige Minuten dauern)...",
+	"Port %d is already in use. Looking for an available port...\n":                                                                     "Port %d ist bereits belegt. Suche einen freien Port...\n",
+	"Using alternative port: %d\n":                                                                                                      "Verwende alternativen Port: %d\n",
+	"Note: You'll need to set OLLAMA_HOST to use this port.":                                                                            "Hinweis: Für diesen Port muss OLLAMA_HOST gesetzt werden.",
+	"Run: export OLLAMA_HOST=http://localhost:%d\n":                                                                                     "Ausführen: export OLLAMA_HOST=http://localhost:%d\n",
+	"Limiting container memory to %s\n":                                                                                                 "Arbeitsspeicher des Containers wird auf %s begrenzt\n",
+	"Limiting container CPUs to %s\n":                                                                                                   "CPUs des Containers werden auf %s begrenzt\n",
+	"Waiting for Ollama to be ready...":                                                                                                 "Warte, bis Ollama bereit ist...",
+	"%s Ollama is running in Docker (version: %s)\n":                                                                                    "%s Ollama läuft in Docker (Version: %s)\n",
+	"Ollama is running in Docker":                                                                                                       "Ollama läuft in Docker",
+	"[dry run] %s &\n":                                                                                                                  "[Probelauf] %s &\n",
+	"Please install Python 3.9+ from python.org":                                                                                        "Bitte Python 3.9+ von python.org installieren",
+	"Package manager '%s' detected.\n":                                                                                                  "Paketmanager '%s' gefunden.\n",
+	"Do you want to install '%s' using %s? [Y/n]: ":                                                                                     "'%s' mit %s installieren? [J/n]: ",
+	"y":                                     "j",
+	"Running: %s\n":                         "Ausführen: %s\n",
+	"nvidia-container-toolkit is installed": "nvidia-container-toolkit ist installiert",
+	"nvidia-container-toolkit is required for GPU support in Docker.":                 "nvidia-container-toolkit wird für GPU-Unterstützung in Docker benötigt.",
+	"Do you want to install it now? [Y/n]: ":                                          "Jetzt installieren? [J/n]: ",
+	"Installing nvidia-container-toolkit on Linux...":                                 "nvidia-container-toolkit wird unter Linux installiert...",
+	"curl is required for installation.":                                              "Für die Installation wird curl benötigt.",
+	"Do you want to install curl? [Y/n]: ":                                            "curl installieren? [J/n]: ",
+	"Unsupported distribution: %s\n":                                                  "Nicht unterstützte Distribution: %s\n",
+	"Please install nvidia-container-toolkit manually.":                               "Bitte nvidia-container-toolkit manuell installieren.",
+	"Setting up nvidia-container-toolkit for Ubuntu/Debian...":                        "nvidia-container-toolkit wird für Ubuntu/Debian eingerichtet...",
+	"Restarting Docker service...":                                                    "Docker-Dienst wird neu gestartet...",
+	"nvidia-container-toolkit installed successfully":                                 "nvidia-container-toolkit erfolgreich installiert",
+	"Setting up nvidia-container-toolkit for Fedora/RHEL...":                          "nvidia-container-toolkit wird für Fedora/RHEL eingerichtet...",
+	"Setting up nvidia-container-toolkit for Arch Linux...":                           "nvidia-container-toolkit wird für Arch Linux eingerichtet...",
+	"Docker not detected. Defaulting to native installation.":                         "Docker nicht gefunden. Standard ist die native Installation.",
+	"Select installation method:":                                                     "Installationsart wählen:",
+	"1. Docker (Recommended - Unified container; installs Docker Desktop if missing)": "1. Docker (empfohlen - ein gemeinsamer Container; installiert Docker Desktop, falls es fehlt)",
+	"2. Native (Run Ollama and Python directly on Windows)":                           "2. Nativ (Ollama und Python direkt unter Windows ausführen)",
+	"Ollama is running": "Ollama läuft",
+	"Ollama is not running or not accessible at %s\n":                                                     "Ollama läuft nicht oder ist unter %s nicht erreichbar\n",
+	"Ollama is required for translation.":                                                                 "Ollama wird für die Übersetzung benötigt.",
+	"you can set USE_DOCKER_OLLAMA=0 for no isolation in docker (more performant).":                       "Mit USE_DOCKER_OLLAMA=0 läuft es ohne Isolation in Docker (schneller).",
+	"Do you want to install Ollama? [Y/n]: ":                                                              "Ollama installieren? [J/n]: ",
+	"Model '%s' is installed":                                                                             "Modell '%s' ist installiert",
+	"Model '%s' not found.\n":                                                                             "Modell '%s' nicht gefunden.\n",
+	"Do you want to download '%s'? (required for translation) [Y/n]: ":                                    "'%s' herunterladen? (für die Übersetzung nötig) [J/n]: ",
+	"Pulling model '%s' in Docker... (this may take a few minutes)\n":                                     "Modell '%s' wird in Docker geladen... (das kann einige Minuten dauern)\n",
+	"Pulling model '%s'... (this may take a few minutes)\n":                                               "Modell '%s' wird geladen... (das kann einige Minuten dauern)\n",
+	"%s Model '%s' downloaded successfully\n":                                                             "%s Modell '%s' erfolgreich heruntergeladen\n",
+	"Installing Ollama for Windows...":                                                                    "Ollama für Windows wird installiert...",
+	"Downloading Ollama installer...":                                                                     "Ollama-Installationsprogramm wird heruntergeladen...",
+	"Failed to download installer: %v\n":                                                                  "Installationsprogramm konnte nicht heruntergeladen werden: %v\n",
+	"Please download Ollama manually from: https://ollama.com":                                            "Bitte Ollama manuell herunterladen: https://ollama.com",
+	"Running Ollama installer...":                                                                         "Ollama-Installationsprogramm wird ausgeführt...",
+	"Ollama installed. Starting service...":                                                               "Ollama installiert. Dienst wird gestartet...",
+	"Installing Ollama for Linux...":                                                                      "Ollama für Linux wird installiert...",
+	"Automatic installation failed: %v\n":                                                                 "Automatische Installation fehlgeschlagen: %v\n",
+	"Please install Ollama manually:":                                                                     "Bitte Ollama manuell installieren:",
+	"Ollama installed successfully":                                                                       "Ollama erfolgreich installiert",
+	"Starting Ollama service...":                                                                          "Ollama-Dienst wird gestartet...",
+	"Warning: Could not start Ollama service: %v\n":                                                       "Warnung: Ollama-Dienst konnte nicht gestartet werden: %v\n",
+	"[dry run] download %s to %s\n":                                                                       "[Probelauf] %s nach %s herunterladen\n",
+	"'openai-whisper' is installed in venv":                                                               "'openai-whisper' ist in venv installiert",
+	"%s Python interpreter found (%s).\n":                                                                 "%s Python-Interpreter gefunden (%s).\n",
+	"Python virtual environment 'venv' not found.\n":                                                      "Virtuelle Python-Umgebung 'venv' nicht gefunden.\n",
+	"Do you want to create it automatically? [Y/n]: ":                                                     "Automatisch anlegen? [J/n]: ",
+	"Virtual environment created.":                                                                        "Virtuelle Umgebung angelegt.",
+	"Virtual environment 'venv' exists.":                                                                  "Virtuelle Umgebung 'venv' ist vorhanden.",
+	"Checking for 'openai-whisper' package...":                                                            "Paket 'openai-whisper' wird gesucht...",
+	"'openai-whisper' is already installed.":                                                              "'openai-whisper' ist bereits installiert.",
+	"'openai-whisper' package not found in venv.":                                                         "Paket 'openai-whisper' in venv nicht gefunden.",
+	"Do you want to install it now? (This will download PyTorch ~1GB) [Y/n]: ":                            "Jetzt installieren? (Lädt PyTorch herunter, ~1 GB) [J/n]: ",
+	"Installing openai-whisper...":                                                                        "openai-whisper wird installiert...",
+	"'openai-whisper' installed successfully.":                                                            "'openai-whisper' erfolgreich installiert.",
+	"Error: Python interpreter (%s) not found.\n":                                                         "Fehler: Python-Interpreter (%s) nicht gefunden.\n",
+	"Creating virtual environment...":                                                                     "Virtuelle Umgebung wird angelegt...",
+	"Error: Failed to create venv. You might need to install 'python3-venv'.":                             "Fehler: venv konnte nicht angelegt werden. Eventuell muss 'python3-venv' installiert werden.",
+	"Retrying venv creation...":                                                                           "Neuer Versuch, venv anzulegen...",
+	"Audio capture device '%s' found":                                                                     "Aufnahmegerät '%s' gefunden",
+	"VB-Audio Virtual Cable found":                                                                        "VB-Audio Virtual Cable gefunden",
+	"%s Audio capture device '%s' found.\n":                                                               "%s Aufnahmegerät '%s' gefunden.\n",
+	"Audio capture device '%s' not found.\n":                                                              "Aufnahmegerät '%s' nicht gefunden.\n",
+	"It comes with screen-capture-recorder and lets cs-translate hear the game's audio.":                  "Es gehört zu screen-capture-recorder und lässt cs-translate den Ton des Spiels hören.",
+	"Download and install it now? [Y/n]: ":                                                                "Jetzt herunterladen und installieren? [J/n]: ",
+	"Downloading screen-capture-recorder...":                                                              "screen-capture-recorder wird heruntergeladen...",
+	"Installing (confirm the administrator prompt)...":                                                    "Installation läuft (Administratorabfrage bestätigen)...",
+	"VB-Audio Virtual Cable found.":                                                                       "VB-Audio Virtual Cable gefunden.",
+	"VB-Audio Virtual Cable not found. It lets teammates hear text-to-speech as your microphone.":         "VB-Audio Virtual Cable nicht gefunden. Damit hört das Team die Sprachausgabe über das Mikrofon.",
+	"Downloading VB-Audio Virtual Cable...":                                                               "VB-Audio Virtual Cable wird heruntergeladen...",
+	"[dry run] extract %s\n":                                                                              "[Probelauf] %s entpacken\n",
+	"FFmpeg is installed":                                                                                 "FFmpeg ist installiert",
+	"%s FFmpeg found (%s).\n":                                                                             "%s FFmpeg gefunden (%s).\n",
+	"FFmpeg not found; it is needed to capture and convert audio.":                                        "FFmpeg nicht gefunden; es wird für Audioaufnahme und -umwandlung gebraucht.",
+	"Download FFmpeg %s now? [Y/n]: ":                                                                     "FFmpeg %s jetzt herunterladen? [J/n]: ",
+	"Downloading FFmpeg... (about 150 MB)":                                                                "FFmpeg wird heruntergeladen... (etwa 150 MB)",
+	"%s FFmpeg installed to %s\n":                                                                         "%s FFmpeg nach %s installiert\n",
+	"Audio capture device chosen":                                                                         "Audioaufnahmegerät ausgewählt",
+	"[dry run] record %s from %d audio devices and ask which one to capture\n":                            "[Probelauf] %s von %d Audiogeräten aufnehmen und fragen, welches aufgenommen wird\n",
+	"Play some game audio or music now; each audio device is recorded for %s...\n":                        "Jetzt Spielton oder Musik abspielen; jedes Audiogerät wird %s lang aufgenommen...\n",
+	"Number of the device that plays the game [Enter: auto-detect]: ":                                     "Nummer des Geräts, das den Spielton ausgibt [Enter: automatisch]: ",
+	"Enter a number from 1 to %d.\n":                                                                      "Eine Zahl von 1 bis %d eingeben.\n",
+	"(not recordable: %v)":                                                                                "(nicht aufnehmbar: %v)",
+	"(silent)":                                                                                            "(still)",
+	"Capturing '%s'; saved as audio_device in the settings.\n":                                            "'%s' wird aufgenommen; als audio_device in den Einstellungen gespeichert.\n",
+	"Only one audio device found: %s\n":                                                                   "Nur ein Audiogerät gefunden: %s\n",
+	"Pass the number or the name to -audiodevice, e.g. -audiodevice 2.":                                   "Nummer oder Namen an -audiodevice übergeben, z. B. -audiodevice 2.",
+	"Installing the driver (confirm the administrator prompt)...":                                         "Treiber wird installiert (Administratorabfrage bestätigen)...",
+	"Make 'CABLE Input' the playback device for text-to-speech and 'CABLE Output' the microphone in CS2.": "'CABLE Input' als Wiedergabegerät für die Sprachausgabe und 'CABLE Output' als Mikrofon in CS2 einstellen.",
+	"%s Audio device '%s' installed.\n":                                                                   "%s Audiogerät '%s' installiert.\n",
+	"Resuming setup at '%s'.\n":                                                                           "Einrichtung wird bei '%s' fortgesetzt.\n",
+	"[dry run] %s: %v\n":                                                                                  "[Probelauf] %s: %v\n",
+	"Using Docker for Whisper transcription (already running in unified container)":                       "Whisper-Transkription über Docker (läuft bereits im gemeinsamen Container)",
+	"Usage: cs-translate setup <status|reset|audio|cable|ffmpeg|device> [-dry-run]":                       "Verwendung: cs-translate setup <status|reset|audio|cable|ffmpeg|device> [-dry-run]",
+	"Error: %v\n": "Fehler: %v\n",
+	"Setup progress cleared; every step is checked and asked again on the next start.":                             "Einrichtungsfortschritt gelöscht; beim nächsten Start wird jeder Schritt erneut geprüft und abgefragt.",
+	"Virtual audio devices only need to be installed on Windows; PulseAudio and PipeWire provide monitor sources.": "Virtuelle Audiogeräte werden nur unter Windows gebraucht; PulseAudio und PipeWire bieten Monitor-Quellen.",
+	"Unknown setup action: %s\n":                                       "Unbekannte Einrichtungsaktion: %s\n",
+	"Setup has not run yet; it starts with cs-translate.":              "Die Einrichtung lief noch nicht; sie beginnt mit dem Start von cs-translate.",
+	"Installation method: %s\n":                                        "Installationsart: %s\n",
+	"Waiting for a restart at '%s'; start cs-translate to continue.\n": "Warte bei '%s' auf einen Neustart; cs-translate starten, um fortzufahren.\n",
+	"AMD GPU found; the container uses ROCm":                           "AMD-GPU gefunden; der Container nutzt ROCm",
+	"Intel Arc GPU found; the container uses Vulkan and XPU":           "Intel-Arc-GPU gefunden; der Container nutzt Vulkan und XPU",
+	"No supported GPU found; Ollama and Whisper run on the CPU":        "Keine unterstützte GPU gefunden; Ollama und Whisper laufen auf der CPU",
+	"Docker Desktop passes only NVIDIA GPUs to containers; choose the native installation after 'cs-translate setup reset' to use this GPU.": "Docker Desktop reicht nur NVIDIA-GPUs an Container weiter; für diese GPU nach 'cs-translate setup reset' die native Installation wählen.",
+	"GPU support is not available: %v\n":                                                              "GPU-Unterstützung ist nicht verfügbar: %v\n",
+	"Continuing without a GPU; translation and transcription run on the CPU and are slower.":          "Weiter ohne GPU; Übersetzung und Transkription laufen auf der CPU und sind langsamer.",
+	"Docker Desktop cannot use this GPU, but a native Ollama can. Defaulting to native installation.": "Docker Desktop kann diese GPU nicht nutzen, ein natives Ollama schon. Standard ist die native Installation.",
+	"Container GPU: %s\n": "Container-GPU: %s\n",
+	"CPU profile: %s with Whisper %s (live) and %s (F9). Expect chat translations 2-5s and voice 5-10s behind the speaker.\n": "CPU-Profil: %s mit Whisper %s (live) und %s (F9). Chat-Übersetzungen brauchen etwa 2-5 s, Sprache liegt 5-10 s hinter dem Sprecher.\n",
+	"Setup found no usable GPU; add -cpu for models that keep up on a CPU.":                                                   "Die Einrichtung hat keine nutzbare GPU gefunden; -cpu wählt Modelle, die auf der CPU mithalten.",
+}
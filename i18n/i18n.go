@@ -0,0 +1,97 @@
+// Package i18n translates the messages the program prints for the person
+// at the keyboard, such as setup questions and progress. The English text
+// is the key, so a message without a translation is shown in English.
+// Errors stay English, so they can be searched for in reports and logs.
+// The catalogs are Go maps in this package rather than go-i18n message
+// files: the messages have no plurals or placeholders beyond Printf verbs,
+// and nothing has to be loaded at run time.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Languages lists the supported UI languages, English first
+var Languages = []string{"en", "de", "ru"}
+
+var catalogs = map[string]map[string]string{
+	"de": de,
+	"ru": ru,
+}
+
+// current is the UI language, taken from the environment until Set
+var current = Detect()
+
+// Set selects the UI language by code ("de") or locale ("de_DE.UTF-8"); an
+// unsupported language is refused and the current one kept
+func Set(lang string) error {
+	code := normalize(lang)
+	if code == "en" && !strings.HasPrefix(strings.ToLower(lang), "en") {
+		return fmt.Errorf("unsupported UI language %q (supported: %s)", lang, strings.Join(Languages, ", "))
+	}
+	current = code
+	return nil
+}
+
+// Lang returns the selected UI language
+func Lang() string {
+	return current
+}
+
+// Detect picks the UI language from LC_ALL, LC_MESSAGES or LANG, then the
+// system's display language
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" && v != "C" && v != "POSIX" {
+			return normalize(v)
+		}
+	}
+	return normalize(systemLanguage())
+}
+
+// normalize reduces a locale like "ru_RU.UTF-8" or "de-AT" to a supported
+// language code, falling back to English
+func normalize(locale string) string {
+	code := strings.ToLower(locale)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := catalogs[code]; ok {
+		return code
+	}
+	return "en"
+}
+
+// yes holds the answers that agree besides English y and yes
+var yes = map[string][]string{
+	"de": {"j", "ja"},
+	"ru": {"д", "да"},
+}
+
+// Yes reports whether answer agrees, in English or the UI language
+func Yes(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "y" || answer == "yes" {
+		return true
+	}
+	for _, w := range yes[current] {
+		if answer == w {
+			return true
+		}
+	}
+	return false
+}
+
+// T returns msg in the UI language. With args the message is a format, as
+// for fmt.Sprintf.
+func T(msg string, args ...any) string {
+	if translated, ok := catalogs[current][msg]; ok {
+		msg = translated
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
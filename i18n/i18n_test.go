@@ -0,0 +1,25 @@
+package i18n
+
+import "testing"
+
+// An unsupported language is refused without leaving the current one
+func TestSet(t *testing.T) {
+	defer func(lang string) { current = lang }(current)
+
+	tests := []struct {
+		lang, want string
+		ok         bool
+	}{
+		{"de_DE.UTF-8", "de", true},
+		{"fr", "de", false},
+		{"ru-RU", "ru", true},
+		{"", "ru", false},
+		{"en_GB", "en", true},
+	}
+	for _, tt := range tests {
+		err := Set(tt.lang)
+		if (err == nil) != tt.ok || Lang() != tt.want {
+			t.Errorf("Set(%q): %v, language %q, want %q", tt.lang, err, Lang(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package i18n
+
+// systemLanguage is only consulted on Windows; elsewhere the locale
+// variables say it all
+func systemLanguage() string {
+	return ""
+}
@@ -0,0 +1,282 @@
+package i18n
+
+// ru holds the Russian messages
+var ru = map[string]string{
+	"\nVoice mode will work with -audiodevice '%s'.\n":                   "\nГолосовой режим будет работать с -audiodevice '%s'.\n",
+	"%s Heard in %s: %q\n":                                               "%s Распознано за %s: %q\n",
+	"Transcribing the recording...":                                      "Транскрибирование записи...",
+	"%s Audio captured (peak %.0f dB)\n":                                 "%s Звук записан (пик %.0f дБ)\n",
+	"Recording %s from '%s'; say something as you would in a match...\n": "Запись %s с '%s'; скажите что-нибудь, как в матче...\n",
+	"auto-detect": "автоопределение",
+	"  Whisper %s skipped: transcriber did not start\n": "  Whisper %s пропущен: транскрибер не запустился\n",
+	"Voice: %d clips": "Голос: клипов %d",
+	"Chat: %d lines, %d prompt variants, %d models\n": "Чат: строк %d, вариантов промпта %d, моделей %d\n",
+	"First error: %v\n":              "Первая ошибка: %v\n",
+	"  Whisper %s skipped: %v\n":     "  Whisper %s пропущен: %v\n",
+	"Voice skipped: %v\n":            "Голос пропущен: %v\n",
+	"  %s skipped: %v\n":             "  %s пропущена: %v\n",
+	"Warning: %v (using defaults)\n": "Предупреждение: %v (используются значения по умолчанию)\n",
+	"Usage: cs-translate devices <list|test> [-audiodevice name|number] [-duration 5s]":                   "Использование: cs-translate devices <list|test> [-audiodevice имя|номер] [-duration 5s]",
+	"(heard: chrF of the transcription against the spoken line; latency: transcription plus translation)": "(heard: chrF транскрипции относительно произнесённой строки; задержка: транскрипция плюс перевод)",
+	"Programs with the log open: %s": "Программы, открывшие лог: %s",
+	"Warning: console.log was truncated while CS2 is running. Another tool is probably clearing it; close it so chat lines are not lost.": "Предупреждение: console.log был обрезан во время работы CS2. Вероятно, его очищает другая программа; закройте её, чтобы не терять строки чата.",
+	"(%d lines had no text-to-speech voice)":                              "(для %d строк не нашлось голоса синтеза речи)",
+	"Port %d is already in use by another program.\n":                     "Порт %d уже занят другой программой.\n",
+	"Usage: cs-translate container <stop|rm|logs|update> [-f] [-dry-run]": "Использование: cs-translate container <stop|rm|logs|update> [-f] [-dry-run]",
+	"Using the console log from the last run: %s\n":                       "Используется консольный лог прошлого запуска: %s\n",
+	"Port %d is used by CS2 game state integration (%s).\n":               "Порт %d занят интеграцией состояния игры CS2 (%s).\n",
+	"  - Use -log-wait 0 to keep waiting indefinitely":                    "  - С -log-wait 0 ожидание не ограничено по времени",
+	"  - Run 'cs-translate doctor' to check the setup":                    "  - Запустите 'cs-translate doctor', чтобы проверить настройку",
+	"console.log did not appear within %s; chat translation is disabled.": "console.log не появился за %s; перевод чата отключён.",
+	"Started plugin %s\n": "Плагин %s запущен\n",
+	"Wrote %s. Secrets are redacted; it still holds file paths and log messages, so look through it before attaching it to an issue.\n": "Записан %s. Секреты скрыты, но пути к файлам и сообщения логов остались, поэтому просмотрите его, прежде чем прикладывать к issue.\n",
+	"Collecting logs, settings and the environment...": "Сбор логов, настроек и сведений о системе...",
+	"Settings imported.":                                          "Настройки импортированы.",
+	"Error creating translator: %v\n":                             "Ошибка создания переводчика: %v\n",
+	"Serving the web API on port %d instead.\n":                   "Веб-API работает вместо этого на порту %d.\n",
+	"Waiting for logs matching %s\n":                              "Ожидание логов по шаблону %s\n",
+	"Monitoring log file: %s\n":                                   "Отслеживается файл лога: %s\n",
+	"Waiting for client logs matching %s\n":                       "Ожидание логов клиента по шаблону %s\n",
+	"Monitoring client log: %s\n":                                 "Отслеживается лог клиента: %s\n",
+	"Nothing changed.":                                            "Ничего не изменено.",
+	"Apply these changes? [y/N]: ":                                "Применить эти изменения? [д/Н]: ",
+	"  %s: replaced from the bundle\n":                            "  %s: заменён из пакета\n",
+	"The bundle matches your settings; nothing to do.":            "Пакет совпадает с вашими настройками; делать нечего.",
+	"Usage: cs-translate config import [-y] <bundle.zip>":         "Использование: cs-translate config import [-y] <bundle.zip>",
+	"Usage: cs-translate config export <bundle.zip>":              "Использование: cs-translate config export <bundle.zip>",
+	"Wrote default config to %s\n":                                "Настройки по умолчанию записаны в %s\n",
+	"Config file already exists: %s\n":                            "Файл настроек уже существует: %s\n",
+	"Usage: cs-translate config <path|init|schema|export|import>": "Использование: cs-translate config <path|init|schema|export|import>",
+	"  logaddress_add <address>:%s   or   logaddress_add_http \"http://<address>:%s%s\"\n": "  logaddress_add <адрес>:%s   или   logaddress_add_http \"http://<адрес>:%s%s\"\n",
+	"API listening on http://%s (reference at /docs). Press Ctrl+C to exit.\n":             "API слушает на http://%s (справка в /docs). Ctrl+C для выхода.\n",
+	"(toxic)": "(токсично)",
+	"[truncated: %d more characters not translated]": "[обрезано: ещё %d символов не переведено]",
+	"[talk] You: ":    "[talk] Вы: ",
+	"voice (%s): ":    "голос (%s): ",
+	"voice %.2fs: ":   "голос %.2fs: ",
+	"Translated: ":    "Перевод: ",
+	"(toxic, hidden)": "(токсично, скрыто)",
+	"Note: other programs also have console.log open: %s. If one of them clears the log, chat lines can be missed.": "Примечание: console.log открыт и другими программами: %s. Если одна из них очистит лог, строки чата могут быть пропущены.",
+	"%d subtitles for the recording saved to %s":              "Субтитры к записи (%d) сохранены в %s",
+	"OBS is recording; its subtitles are saved when it stops": "OBS записывает; субтитры сохранятся, когда запись остановится",
+	"(unsure, %.0f%%)":                                          "(неуверенно, %.0f%%)",
+	"voice translation is disabled":                             "перевод голоса отключён",
+	"[talk] Say (%s): %s":                                       "[talk] Скажите (%s): %s",
+	"Voice %.2fs: %s \n":                                        "Голос %.2fs: %s \n",
+	"(own language, not translated)":                            "(свой язык, не переведено)",
+	"(rate limited, not translated)":                            "(превышен лимит, не переведено)",
+	"(private, not translated)":                                 "(личное, не переведено)",
+	"[Translation Pending/Error]":                               "[Перевод ожидается/ошибка]",
+	"(repeated; further repeats are hidden)":                    "(повтор; дальнейшие повторы скрыты)",
+	"(superseded by a newer message)":                           "(заменено более новым сообщением)",
+	"\nOriginal: %s\n":                                          "\nОригинал: %s\n",
+	"Error listing devices: %v\n":                               "Не удалось получить список устройств: %v\n",
+	"Available audio devices:":                                  "Доступные аудиоустройства:",
+	"Error listing PipeWire nodes: %v\n":                        "Не удалось получить список узлов PipeWire: %v\n",
+	"\nPipeWire nodes:":                                         "\nУзлы PipeWire:",
+	"\nApplications playing audio (records only that program):": "\nПрограммы, воспроизводящие звук (запись только этой программы):",
+	"Saved to %s":                                               "Сохранено в %s",
+	"Match summary:":                                            "Итоги матча:",
+	"Select Mode:":                                              "Выберите режим:",
+	"1. CS2 In-Game Translate (Monitor Console Log)":            "1. Перевод в CS2 (отслеживание лога консоли)",
+	"Receiving server logs on port %s. On the server, with this machine's address:\n":                                                             "Логи сервера принимаются на порту %s. На сервере, с адресом этого компьютера:\n",
+	"  - Or point to the log directly: cs-translate -log /path/to/console.log":                                                                    "  - Или укажите лог напрямую: cs-translate -log /путь/к/console.log",
+	"  - Add -condebug to CS2's launch options in Steam and start a match":                                                                        "  - Добавьте -condebug в параметры запуска CS2 в Steam и начните матч",
+	"Wrote %s. Log paths, audio devices, hooks and the web address are left out.\n":                                                               "Записан %s. Пути к логам, аудиоустройства, хуки и веб-адрес не включены.\n",
+	"2. Additionally listening to system output audio \nPress F9 to capture the last %s, transcribe, and translate.\n":                            "2. Дополнительно прослушивать системный звук \nF9 записывает последние %s, распознаёт и переводит их.\n",
+	"Finishing translations in progress... (Ctrl+C again quits now)":                                                                              "Завершаются текущие переводы... (ещё раз Ctrl+C — выйти сразу)",
+	"3. CS2 In-Game Translate + talk to your team \nPress F10, speak, and press F10 again to translate your microphone into the team's language.": "3. Перевод в CS2 + разговор с командой \nНажмите F10, говорите и снова нажмите F10, чтобы перевести речь с микрофона на язык команды.",
+	"Enter choice [%s]: ": "Ваш выбор [%s]: ",
+	"Enable Voice Transcription (uses Docker by default)? [y/N]: ":                                      "Включить распознавание речи (по умолчанию через Docker)? [д/Н]: ",
+	"Warning: Could not verify launch options.":                                                         "Предупреждение: не удалось проверить параметры запуска.",
+	"CS2 launch option '-condebug' not detected.":                                                       "Параметр запуска CS2 '-condebug' не найден.",
+	"Add it to the launch options now (localconfig.vdf is backed up first)? [Y/n]: ":                    "Добавить его в параметры запуска сейчас (сначала создаётся копия localconfig.vdf)? [Д/н]: ",
+	"Could not edit the launch options: %v\n":                                                           "Не удалось изменить параметры запуска: %v\n",
+	"Steam is running, so it cannot be added automatically (close Steam to have cs-translate edit it).": "Steam запущен, поэтому параметр нельзя добавить автоматически (закройте Steam, чтобы cs-translate внёс его).",
+	"Do you want to open Steam properties for CS2 to set it? [Y/n]: ":                                   "Открыть свойства CS2 в Steam, чтобы задать его? [Д/н]: ",
+	"[dry run] %s\n":                     "[пробный запуск] %s\n",
+	"Stopping Docker container...":       "Остановка контейнера Docker...",
+	"CS2 launch option -condebug is set": "Параметр запуска CS2 -condebug задан",
+	"[dry run] add -condebug to the CS2 launch options in %s (backed up first)\n":                              "[пробный запуск] добавить -condebug в параметры запуска CS2 в %s (сначала копия)\n",
+	"Added -condebug for Steam account %s (backup: %s)\n":                                                      "-condebug добавлен для аккаунта Steam %s (копия: %s)\n",
+	"Background recording started.":                                                                            "Фоновая запись запущена.",
+	"Dry run finished; nothing was installed or changed.":                                                      "Пробный запуск завершён; ничего не установлено и не изменено.",
+	"Using %s for translation to %s\n":                                                                         "Перевод через %s на %s\n",
+	"Translating %s messages to %s instead\n":                                                                  "Сообщения %s вместо этого переводятся на %s\n",
+	"Tagging the tone of chat with %s\n":                                                                       "Тон чата определяется моделью %s\n",
+	"Connecting to OBS at %s\n":                                                                                "Подключение к OBS по адресу %s\n",
+	"Posting translations to the Twitch chat of %s\n":                                                          "Переводы публикуются в чате Twitch канала %s\n",
+	"Mirroring translations to the Telegram chat %s\n":                                                         "Переводы дублируются в чат Telegram %s\n",
+	"Publishing events to the MQTT broker %s\n":                                                                "События публикуются на MQTT-брокере %s\n",
+	"Web server listening on http://%s (reference at /docs)\n":                                                 "Веб-сервер доступен на http://%s (справка в /docs)\n",
+	"\n=== Echo Mode Started ===":                                                                              "\n=== Режим эха запущен ===",
+	"Listening to system output audio + Monitoring CS2 Console...":                                             "Прослушивание системного звука + отслеживание консоли CS2...",
+	"Press F9 to capture the last %s, transcribe, and translate.\n":                                            "F9 записывает последние %s, распознаёт и переводит их.\n",
+	"Double-press F9 to mark a start, then press it again to capture everything since.":                        "Двойное нажатие F9 ставит метку начала; следующее нажатие записывает всё с этого момента.",
+	"Press F8 or type /pause to pause capture and translation.":                                                "F8 или /pause приостанавливают запись и перевод.",
+	"%s is bound to %s.\n":                                                                                     "%s назначена на %s.\n",
+	"Game chat: bind a key in the CS2 console to post the latest translation, e.g. bind \"kp_enter\" \"%s\"\n": "Игровой чат: назначьте в консоли CS2 клавишу, отправляющую последний перевод, например bind \"kp_enter\" \"%s\"\n",
+	"Game chat: %s posts the latest translation (bound in %s; type exec autoexec in the CS2 console if the game is running).\n": "Игровой чат: %s отправляет последний перевод (назначено в %s; введите exec autoexec в консоли CS2, если игра запущена).\n",
+	"Press Ctrl+C to exit.":                                                     "Ctrl+C для выхода.",
+	"Auto-detecting log file location in the background...":                     "Поиск файла лога в фоне...",
+	"\nStopping...":                                                             "\nОстановка...",
+	"\nFound log file: %s\n":                                                    "\nНайден файл лога: %s\n",
+	"\n[F9] Paused; press F8 or type /resume first.":                            "\n[F9] Пауза; сначала нажмите F8 или введите /resume.",
+	"\n[F9] Capturing %.0fs since mark...\n":                                    "\n[F9] Запись %.0f с от метки...\n",
+	"\n[F9] Start marked; press F9 again to capture up to now.":                 "\n[F9] Начало отмечено; нажмите F9 ещё раз, чтобы записать всё до этого момента.",
+	"\n[F9] Capturing...":                                                       "\n[F9] Запись...",
+	"\n[F9] Transcribing the last %s of voice...\n":                             "\n[F9] Распознавание последних %s речи...\n",
+	"\n[F9] Voice capture is not running.":                                      "\n[F9] Запись голоса не запущена.",
+	"\n[F9] Recording; let go of F9 to transcribe.":                             "\n[F9] Идёт запись; отпустите F9 для распознавания.",
+	"\n[F9] Hold F9 while speaking and let go when done.":                       "\n[F9] Удерживайте F9, пока говорите, и отпустите в конце.",
+	"\n[F9] Capturing %.0fs while F9 was held...\n":                             "\n[F9] Захват %.0f с, пока была нажата F9...\n",
+	"Hold F9 while speaking; it is transcribed and translated when you let go.": "Удерживайте F9, пока говорите; сказанное будет распознано и переведено, когда вы её отпустите.",
+	"\n[Stream Deck] Capturing...":                                              "\n[Stream Deck] Запись...",
+	"Auto-detecting log file location...":                                       "Поиск файла лога...",
+	"Log file not found yet. Waiting for CS2 to start...":                       "Файл лога пока не найден. Ожидание запуска CS2...",
+	"Local Audio transcription enabled (Whisper '%s' model).\n":                 "Локальное распознавание речи включено (модель Whisper '%s').\n",
+	"Capturing %s; speech is tagged with its label.\n":                          "Захват: %s; речь помечается меткой источника.\n",
+	"Waiting for chat messages... (type /fix if a player name was cut at a colon, /pause or F8 to pause)": "Ожидание сообщений чата... (/fix, если имя игрока обрезано на двоеточии, /pause или F8 для паузы)",
+	"Found log file: %s\n":                                   "Найден файл лога: %s\n",
+	"[talk] Paused; press F8 or type /resume first.":         "[talk] Пауза; сначала нажмите F8 или введите /resume.",
+	"[Stream Deck] Voice capture paused":                     "[Stream Deck] Запись голоса приостановлена",
+	"[Stream Deck] Voice capture resumed":                    "[Stream Deck] Запись голоса возобновлена",
+	"Container '%s' is not running\n":                        "Контейнер '%s' не запущен\n",
+	"Container '%s' does not exist\n":                        "Контейнер '%s' не существует\n",
+	"Docker is running":                                      "Docker запущен",
+	"Docker container is running":                            "Контейнер Docker запущен",
+	"Setting up Docker container with Ollama and Whisper...": "Настройка контейнера Docker с Ollama и Whisper...",
+	"Docker container already running":                       "Контейнер Docker уже запущен",
+	"Starting existing Docker container...":                  "Запуск существующего контейнера Docker...",
+	"Install Docker Desktop from https://www.docker.com/products/docker-desktop/ or set USE_DOCKER_OLLAMA=0 for a native installation.": "Установите Docker Desktop с https://www.docker.com/products/docker-desktop/ или задайте USE_DOCKER_OLLAMA=0 для нативной установки.",
+	"Docker Desktop is not installed. It runs on WSL2, which needs a restart after installing.":                                         "Docker Desktop не установлен. Он работает на WSL2, после установки которого нужна перезагрузка.",
+	"Install WSL2 and Docker Desktop now? [Y/n]: ":                                                                                      "Установить WSL2 и Docker Desktop сейчас? [Д/н]: ",
+	"Installing WSL2 (confirm the administrator prompt)...":                                                                             "Установка WSL2 (подтвердите запрос администратора)...",
+	"Installing Docker Desktop...":                                                                                                      "Установка Docker Desktop...",
+	"Docker Desktop installed. Restart Windows, start Docker Desktop once and run cs-translate again.":                                  "Docker Desktop установлен. Перезагрузите Windows, один раз запустите Docker Desktop и снова запустите cs-translate.",
+	"Building Docker container (first time only, this may take a few minutes)...":                                                       "Сборка контейнера Docker (только в первый раз, это может занять несколько минут)...",
+	"Port %d is already in use. Looking for an available port...\n":                                                                     "Порт %d уже занят. Поиск свободного порта...\n",
+	"Using alternative port: %d\n":                                                                                                      "Используется другой порт: %d\n",
+	"Note: You'll need to set OLLAMA_HOST to use this port.":                                                                            "Примечание: для этого порта нужно задать OLLAMA_HOST.",
+	"Run: export OLLAMA_HOST=http://localhost:%d\n":                                                                                     "Выполните: export OLLAMA_HOST=http://localhost:%d\n",
+	"Limiting container memory to %s\n":                                                                                                 "Память контейнера ограничена до %s\n",
+	"Limiting container CPUs to %s\n":                                                                                                   "Процессоры контейнера ограничены до %s\n",
+	"Waiting for Ollama to be ready...":                                                                                                 "Ожидание готовности Ollama...",
+	"%s Ollama is running in Docker (version: %s)\n":                                                                                    "%s Ollama работает в Docker (версия: %s)\n",
+	"Ollama is running in Docker":                                                                                                       "Ollama работает в Docker",
+	"[dry run] %s &\n":                                                                                                                  "[пробный запуск] %s &\n",
+	"Please install Python 3.9+ from python.org":                                                                                        "Установите Python 3.9+ с python.org",
+	"Package manager '%s' detected.\n":                                                                                                  "Найден менеджер пакетов '%s'.\n",
+	"Do you want to install '%s' using %s? [Y/n]: ":                                                                                     "Установить '%s' через %s? [Д/н]: ",
+	"y":                                     "д",
+	"Running: %s\n":                         "Выполняется: %s\n",
+	"nvidia-container-toolkit is installed": "nvidia-container-toolkit установлен",
+	"nvidia-container-toolkit is required for GPU support in Docker.":                 "Для поддержки GPU в Docker нужен nvidia-container-toolkit.",
+	"Do you want to install it now? [Y/n]: ":                                          "Установить сейчас? [Д/н]: ",
+	"Installing nvidia-container-toolkit on Linux...":                                 "Установка nvidia-container-toolkit в Linux...",
+	"curl is required for installation.":                                              "Для установки нужен curl.",
+	"Do you want to install curl? [Y/n]: ":                                            "Установить curl? [Д/н]: ",
+	"Unsupported distribution: %s\n":                                                  "Неподдерживаемый дистрибутив: %s\n",
+	"Please install nvidia-container-toolkit manually.":                               "Установите nvidia-container-toolkit вручную.",
+	"Setting up nvidia-container-toolkit for Ubuntu/Debian...":                        "Настройка nvidia-container-toolkit для Ubuntu/Debian...",
+	"Restarting Docker service...":                                                    "Перезапуск службы Docker...",
+	"nvidia-container-toolkit installed successfully":                                 "nvidia-container-toolkit успешно установлен",
+	"Setting up nvidia-container-toolkit for Fedora/RHEL...":                          "Настройка nvidia-container-toolkit для Fedora/RHEL...",
+	"Setting up nvidia-container-toolkit for Arch Linux...":                           "Настройка nvidia-container-toolkit для Arch Linux...",
+	"Docker not detected. Defaulting to native installation.":                         "Docker не найден. По умолчанию используется нативная установка.",
+	"Select installation method:":                                                     "Выберите способ установки:",
+	"1. Docker (Recommended - Unified container; installs Docker Desktop if missing)": "1. Docker (рекомендуется - единый контейнер; при необходимости устанавливает Docker Desktop)",
+	"2. Native (Run Ollama and Python directly on Windows)":                           "2. Нативно (Ollama и Python напрямую в Windows)",
+	"Ollama is running": "Ollama запущена",
+	"Ollama is not running or not accessible at %s\n":                                                     "Ollama не запущена или недоступна по адресу %s\n",
+	"Ollama is required for translation.":                                                                 "Для перевода нужна Ollama.",
+	"you can set USE_DOCKER_OLLAMA=0 for no isolation in docker (more performant).":                       "USE_DOCKER_OLLAMA=0 отключает изоляцию в Docker (быстрее).",
+	"Do you want to install Ollama? [Y/n]: ":                                                              "Установить Ollama? [Д/н]: ",
+	"Model '%s' is installed":                                                                             "Модель '%s' установлена",
+	"Model '%s' not found.\n":                                                                             "Модель '%s' не найдена.\n",
+	"Do you want to download '%s'? (required for translation) [Y/n]: ":                                    "Скачать '%s'? (нужна для перевода) [Д/н]: ",
+	"Pulling model '%s' in Docker... (this may take a few minutes)\n":                                     "Загрузка модели '%s' в Docker... (это может занять несколько минут)\n",
+	"Pulling model '%s'... (this may take a few minutes)\n":                                               "Загрузка модели '%s'... (это может занять несколько минут)\n",
+	"%s Model '%s' downloaded successfully\n":                                                             "%s Модель '%s' успешно загружена\n",
+	"Installing Ollama for Windows...":                                                                    "Установка Ollama для Windows...",
+	"Downloading Ollama installer...":                                                                     "Загрузка установщика Ollama...",
+	"Failed to download installer: %v\n":                                                                  "Не удалось скачать установщик: %v\n",
+	"Please download Ollama manually from: https://ollama.com":                                            "Скачайте Ollama вручную: https://ollama.com",
+	"Running Ollama installer...":                                                                         "Запуск установщика Ollama...",
+	"Ollama installed. Starting service...":                                                               "Ollama установлена. Запуск службы...",
+	"Installing Ollama for Linux...":                                                                      "Установка Ollama для Linux...",
+	"Automatic installation failed: %v\n":                                                                 "Автоматическая установка не удалась: %v\n",
+	"Please install Ollama manually:":                                                                     "Установите Ollama вручную:",
+	"Ollama installed successfully":                                                                       "Ollama успешно установлена",
+	"Starting Ollama service...":                                                                          "Запуск службы Ollama...",
+	"Warning: Could not start Ollama service: %v\n":                                                       "Предупреждение: не удалось запустить службу Ollama: %v\n",
+	"[dry run] download %s to %s\n":                                                                       "[пробный запуск] скачать %s в %s\n",
+	"'openai-whisper' is installed in venv":                                                               "'openai-whisper' установлен в venv",
+	"%s Python interpreter found (%s).\n":                                                                 "%s Найден интерпретатор Python (%s).\n",
+	"Python virtual environment 'venv' not found.\n":                                                      "Виртуальное окружение Python 'venv' не найдено.\n",
+	"Do you want to create it automatically? [Y/n]: ":                                                     "Создать его автоматически? [Д/н]: ",
+	"Virtual environment created.":                                                                        "Виртуальное окружение создано.",
+	"Virtual environment 'venv' exists.":                                                                  "Виртуальное окружение 'venv' уже есть.",
+	"Checking for 'openai-whisper' package...":                                                            "Проверка пакета 'openai-whisper'...",
+	"'openai-whisper' is already installed.":                                                              "'openai-whisper' уже установлен.",
+	"'openai-whisper' package not found in venv.":                                                         "Пакет 'openai-whisper' не найден в venv.",
+	"Do you want to install it now? (This will download PyTorch ~1GB) [Y/n]: ":                            "Установить сейчас? (Будет скачан PyTorch, ~1 ГБ) [Д/н]: ",
+	"Installing openai-whisper...":                                                                        "Установка openai-whisper...",
+	"'openai-whisper' installed successfully.":                                                            "'openai-whisper' успешно установлен.",
+	"Error: Python interpreter (%s) not found.\n":                                                         "Ошибка: интерпретатор Python (%s) не найден.\n",
+	"Creating virtual environment...":                                                                     "Создание виртуального окружения...",
+	"Error: Failed to create venv. You might need to install 'python3-venv'.":                             "Ошибка: не удалось создать venv. Возможно, нужно установить 'python3-venv'.",
+	"Retrying venv creation...":                                                                           "Повторное создание venv...",
+	"Audio capture device '%s' found":                                                                     "Устройство записи '%s' найдено",
+	"VB-Audio Virtual Cable found":                                                                        "VB-Audio Virtual Cable найден",
+	"%s Audio capture device '%s' found.\n":                                                               "%s Устройство записи '%s' найдено.\n",
+	"Audio capture device '%s' not found.\n":                                                              "Устройство записи '%s' не найдено.\n",
+	"It comes with screen-capture-recorder and lets cs-translate hear the game's audio.":                  "Оно входит в screen-capture-recorder и позволяет cs-translate слышать звук игры.",
+	"Download and install it now? [Y/n]: ":                                                                "Скачать и установить сейчас? [Д/н]: ",
+	"Downloading screen-capture-recorder...":                                                              "Загрузка screen-capture-recorder...",
+	"Installing (confirm the administrator prompt)...":                                                    "Установка (подтвердите запрос администратора)...",
+	"VB-Audio Virtual Cable found.":                                                                       "VB-Audio Virtual Cable найден.",
+	"VB-Audio Virtual Cable not found. It lets teammates hear text-to-speech as your microphone.":         "VB-Audio Virtual Cable не найден. С ним команда слышит синтезированную речь через ваш микрофон.",
+	"Downloading VB-Audio Virtual Cable...":                                                               "Загрузка VB-Audio Virtual Cable...",
+	"[dry run] extract %s\n":                                                                              "[пробный запуск] распаковать %s\n",
+	"FFmpeg is installed":                                                                                 "FFmpeg установлен",
+	"%s FFmpeg found (%s).\n":                                                                             "%s FFmpeg найден (%s).\n",
+	"FFmpeg not found; it is needed to capture and convert audio.":                                        "FFmpeg не найден; он нужен для записи и преобразования звука.",
+	"Download FFmpeg %s now? [Y/n]: ":                                                                     "Скачать FFmpeg %s сейчас? [Д/н]: ",
+	"Downloading FFmpeg... (about 150 MB)":                                                                "Загрузка FFmpeg... (около 150 МБ)",
+	"%s FFmpeg installed to %s\n":                                                                         "%s FFmpeg установлен в %s\n",
+	"Audio capture device chosen":                                                                         "Устройство записи звука выбрано",
+	"[dry run] record %s from %d audio devices and ask which one to capture\n":                            "[пробный запуск] записать %s с %d аудиоустройств и спросить, какое использовать\n",
+	"Play some game audio or music now; each audio device is recorded for %s...\n":                        "Включите звук игры или музыку; каждое аудиоустройство записывается %s...\n",
+	"Number of the device that plays the game [Enter: auto-detect]: ":                                     "Номер устройства, на котором звучит игра [Enter: автоопределение]: ",
+	"Enter a number from 1 to %d.\n":                                                                      "Введите число от 1 до %d.\n",
+	"(not recordable: %v)":                                                                                "(запись невозможна: %v)",
+	"(silent)":                                                                                            "(тишина)",
+	"Capturing '%s'; saved as audio_device in the settings.\n":                                            "Запись с '%s'; сохранено как audio_device в настройках.\n",
+	"Only one audio device found: %s\n":                                                                   "Найдено только одно аудиоустройство: %s\n",
+	"Pass the number or the name to -audiodevice, e.g. -audiodevice 2.":                                   "Передайте номер или имя в -audiodevice, например -audiodevice 2.",
+	"Installing the driver (confirm the administrator prompt)...":                                         "Установка драйвера (подтвердите запрос администратора)...",
+	"Make 'CABLE Input' the playback device for text-to-speech and 'CABLE Output' the microphone in CS2.": "Выберите 'CABLE Input' устройством воспроизведения для синтеза речи, а 'CABLE Output' - микрофоном в CS2.",
+	"%s Audio device '%s' installed.\n":                                                                   "%s Аудиоустройство '%s' установлено.\n",
+	"Resuming setup at '%s'.\n":                                                                           "Продолжение настройки с шага '%s'.\n",
+	"[dry run] %s: %v\n":                                                                                  "[пробный запуск] %s: %v\n",
+	"Using Docker for Whisper transcription (already running in unified container)":                       "Распознавание Whisper через Docker (уже работает в едином контейнере)",
+	"Usage: cs-translate setup <status|reset|audio|cable|ffmpeg|device> [-dry-run]":                       "Использование: cs-translate setup <status|reset|audio|cable|ffmpeg|device> [-dry-run]",
+	"Error: %v\n": "Ошибка: %v\n",
+	"Setup progress cleared; every step is checked and asked again on the next start.":                             "Прогресс настройки сброшен; при следующем запуске каждый шаг будет проверен и запрошен заново.",
+	"Virtual audio devices only need to be installed on Windows; PulseAudio and PipeWire provide monitor sources.": "Виртуальные аудиоустройства нужны только в Windows; PulseAudio и PipeWire предоставляют мониторы.",
+	"Unknown setup action: %s\n":                                       "Неизвестное действие настройки: %s\n",
+	"Setup has not run yet; it starts with cs-translate.":              "Настройка ещё не запускалась; она начнётся при запуске cs-translate.",
+	"Installation method: %s\n":                                        "Способ установки: %s\n",
+	"Waiting for a restart at '%s'; start cs-translate to continue.\n": "Ожидание перезагрузки на шаге '%s'; запустите cs-translate, чтобы продолжить.\n",
+	"AMD GPU found; the container uses ROCm":                           "Найден GPU AMD; контейнер использует ROCm",
+	"Intel Arc GPU found; the container uses Vulkan and XPU":           "Найден GPU Intel Arc; контейнер использует Vulkan и XPU",
+	"No supported GPU found; Ollama and Whisper run on the CPU":        "Поддерживаемый GPU не найден; Ollama и Whisper работают на CPU",
+	"Docker Desktop passes only NVIDIA GPUs to containers; choose the native installation after 'cs-translate setup reset' to use this GPU.": "Docker Desktop передаёт контейнерам только GPU NVIDIA; чтобы использовать этот GPU, выберите нативную установку после 'cs-translate setup reset'.",
+	"GPU support is not available: %v\n":                                                              "Поддержка GPU недоступна: %v\n",
+	"Continuing without a GPU; translation and transcription run on the CPU and are slower.":          "Продолжение без GPU; перевод и распознавание работают на CPU и медленнее.",
+	"Docker Desktop cannot use this GPU, but a native Ollama can. Defaulting to native installation.": "Docker Desktop не может использовать этот GPU, а нативная Ollama может. По умолчанию нативная установка.",
+	"Container GPU: %s\n": "GPU контейнера: %s\n",
+	"CPU profile: %s with Whisper %s (live) and %s (F9). Expect chat translations 2-5s and voice 5-10s behind the speaker.\n": "Профиль CPU: %s с Whisper %s (в реальном времени) и %s (F9). Перевод чата займёт около 2-5 с, речь отстаёт на 5-10 с.\n",
+	"Setup found no usable GPU; add -cpu for models that keep up on a CPU.":                                                   "Настройка не нашла подходящий GPU; -cpu выбирает модели, которые успевают на CPU.",
+}
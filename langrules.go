@@ -0,0 +1,35 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+)
+
+// langRules are lang_rules: target languages for some kinds of messages
+// instead of targetLang
+var langRules []config.LangRule
+
+// chatLang returns the target language of chat message c: the first rule
+// for its channel, or targetLang
+func chatLang(c events.ChatReceived) string {
+	if teamChat(c) {
+		return ruleLang("team", "chat")
+	}
+	return ruleLang("all", "chat")
+}
+
+// voiceLang returns the target language of voice
+func voiceLang() string {
+	return ruleLang("voice")
+}
+
+func ruleLang(sources ...string) string {
+	for _, r := range langRules {
+		if slices.Contains(sources, r.Source) {
+			return r.Lang
+		}
+	}
+	return targetLang
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/setup"
+	"github.com/micha/cs-ingame-translate/vdf"
+)
+
+// cs2AppID is Counter-Strike 2's Steam app ID
+const cs2AppID = "730"
+
+// cs2AppPath leads from a localconfig.vdf root to CS2's settings
+var cs2AppPath = []string{"UserLocalConfigStore", "Software", "Valve", "Steam", "apps", cs2AppID}
+
+// readLocalConfig parses one localconfig.vdf
+func readLocalConfig(path string) (*vdf.Node, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err := vdf.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return root, data, nil
+}
+
+// hasCondebug reports whether CS2's launch options in root contain -condebug
+func hasCondebug(root *vdf.Node) bool {
+	opts := root.Find(append(cs2AppPath, "LaunchOptions")...)
+	if opts == nil {
+		return false
+	}
+	for _, f := range strings.Fields(opts.Value) {
+		if strings.EqualFold(f, "-condebug") {
+			return true
+		}
+	}
+	return false
+}
+
+// steamRunning reports whether the Steam client is running. Steam rewrites
+// localconfig.vdf when it exits, so edits made while it runs are lost.
+func steamRunning() bool {
+	switch runtime.GOOS {
+	case "windows":
+		out, err := execwrap.Command("tasklist", "/FI", "IMAGENAME eq steam.exe", "/NH").Output()
+		return err == nil && bytes.Contains(bytes.ToLower(out), []byte("steam.exe"))
+	case "darwin":
+		return execwrap.Command("pgrep", "-x", "steam_osx").Run() == nil
+	default:
+		return execwrap.Command("pgrep", "-x", "steam").Run() == nil
+	}
+}
+
+// injectCondebug adds -condebug to CS2's launch options in one
+// localconfig.vdf. The original file is kept as a timestamped backup and
+// restored if the written file does not read back as expected.
+func injectCondebug(path string) (string, error) {
+	root, original, err := readLocalConfig(path)
+	if err != nil {
+		return "", err
+	}
+	if hasCondebug(root) {
+		return "", nil
+	}
+
+	app := root
+	for _, key := range cs2AppPath {
+		app = app.Ensure(key)
+	}
+	current := ""
+	if c := app.Child("LaunchOptions"); c != nil {
+		current = c.Value
+	}
+	app.Set("LaunchOptions", strings.TrimSpace(current+" -condebug"))
+
+	var buf bytes.Buffer
+	if err := vdf.Write(&buf, root); err != nil {
+		return "", err
+	}
+
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, original, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	if check, _, err := readLocalConfig(path); err != nil || !hasCondebug(check) {
+		os.WriteFile(path, original, 0644)
+		return "", fmt.Errorf("verifying %s failed, restored the original", path)
+	}
+	return backup, nil
+}
+
+// condebugTargets returns the localconfig.vdf files to edit: those of
+// accounts that have CS2, or all of them if none does
+func condebugTargets(dataPaths []string) []string {
+	var all, withCS2 []string
+	for _, path := range getConfigFilePaths(dataPaths) {
+		root, _, err := readLocalConfig(path)
+		if err != nil {
+			continue
+		}
+		all = append(all, path)
+		if root.Find(cs2AppPath...) != nil {
+			withCS2 = append(withCS2, path)
+		}
+	}
+	if len(withCS2) > 0 {
+		return withCS2
+	}
+	return all
+}
+
+// addCondebug edits every relevant localconfig.vdf and reports the backups
+func addCondebug(dataPaths []string) error {
+	targets := condebugTargets(dataPaths)
+	if len(targets) == 0 {
+		return fmt.Errorf("no readable localconfig.vdf found")
+	}
+	if setup.DryRun {
+		for _, path := range targets {
+			fmt.Print(i18n.T("[dry run] add -condebug to the CS2 launch options in %s (backed up first)\n", path))
+		}
+		return nil
+	}
+	for _, path := range targets {
+		backup, err := injectCondebug(path)
+		if err != nil {
+			return err
+		}
+		if backup != "" {
+			fmt.Print(i18n.T("Added -condebug for Steam account %s (backup: %s)\n", filepath.Base(filepath.Dir(filepath.Dir(path))), backup))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package logging sets up the slog logger the tool logs to: a handler on
+// stderr at the chosen level and a rotated log file that keeps at least the
+// informational messages, so a bug report can include what happened even
+// when the console stayed quiet.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// FileName is the log file inside the settings directory
+const FileName = "cs-translate.log"
+
+// Formats of the console and the log file
+const (
+	FormatText = "text"
+	FormatJSON = "json" // one JSON object per line
+)
+
+// Options configure Setup
+type Options struct {
+	Level  slog.Level // least severe console message
+	Format string     // FormatText or FormatJSON (empty: text)
+	// File is the log file, rotated once it grows past MaxSize; empty
+	// writes none
+	File    string
+	MaxSize int64 // 0: DefaultMaxSize
+	Keep    int   // rotated files kept besides File (0: DefaultKeep)
+}
+
+// ParseLevel reads warn, info, debug or error; empty is warn
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "warn", "warning":
+		return slog.LevelWarn, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelWarn, fmt.Errorf("unknown log level %q (use warn, info or debug)", s)
+}
+
+// Setup makes the configured logger the slog default. The standard log
+// package, still used for fatal errors, logs through it at error level.
+// The returned function closes the log file. An unknown format or a file
+// that cannot be opened is reported in the error, with the logger set up
+// as text or without the file.
+func Setup(opts Options) (func() error, error) {
+	var errs []error
+	switch opts.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		errs = append(errs, fmt.Errorf("unknown log format %q (use %s or %s)", opts.Format, FormatText, FormatJSON))
+		opts.Format = FormatText
+	}
+	debug := opts.Level <= slog.LevelDebug
+
+	handlers := []slog.Handler{newHandler(os.Stderr, opts.Format, opts.Level, debug, true)}
+	closeFile := func() error { return nil }
+	if opts.File != "" {
+		f, err := openRotating(opts.File, opts.MaxSize, opts.Keep)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("log file disabled: %w", err))
+		} else {
+			closeFile = f.Close
+			handlers = append(handlers, newHandler(f, opts.Format, min(opts.Level, slog.LevelInfo), debug, false))
+		}
+	}
+
+	slog.SetDefault(slog.New(fanout(handlers)))
+	slog.SetLogLoggerLevel(slog.LevelError)
+	return closeFile, errors.Join(errs...)
+}
+
+// newHandler returns the handler for one output. The console gets a compact
+// text layout; the file the full slog text format with dates.
+func newHandler(w io.Writer, format string, level slog.Level, source, console bool) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level, AddSource: source}
+	switch {
+	case format == FormatJSON:
+		return slog.NewJSONHandler(w, opts)
+	case console:
+		return newConsoleHandler(w, level)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// fanout sends records to every handler that wants them
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
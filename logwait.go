@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/monitor"
+)
+
+const (
+	logPollMin = 2 * time.Second
+	logPollMax = 30 * time.Second
+)
+
+// discoverLogFile looks for console.log in the background. Steam directories
+// are watched so the log is picked up as soon as CS2 creates it, with a
+// polling fallback that slows down while nothing changes. The channel
+// receives the path once and is closed; it is closed without a value after
+// timeout (0 waits forever) or when ctx ends.
+func discoverLogFile(ctx context.Context, timeout time.Duration) <-chan string {
+	found := make(chan string, 1)
+	go func() {
+		defer close(found)
+
+		home, err := os.UserHomeDir()
+		if err != nil {
+			slog.Warn("Could not get the home directory", "err", err)
+			return
+		}
+
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			slog.Warn("Watching Steam directories failed, polling only", "err", err)
+		} else {
+			defer watcher.Close()
+		}
+		watched := make(map[string]bool)
+
+		// check returns the log path if it exists and otherwise watches the
+		// deepest existing directory on the way to each candidate
+		check := func() string {
+			for _, p := range getLogFilePaths(home) {
+				if _, err := os.Stat(p); err == nil {
+					return p
+				}
+				if watcher == nil {
+					continue
+				}
+				if dir := existingAncestor(filepath.Dir(p)); dir != "" && !watched[dir] {
+					if err := watcher.Add(dir); err == nil {
+						watched[dir] = true
+					}
+				}
+			}
+			return ""
+		}
+
+		var events <-chan fsnotify.Event
+		var watchErrs <-chan error
+		if watcher != nil {
+			events = watcher.Events
+			watchErrs = watcher.Errors
+		}
+		var deadline <-chan time.Time
+		if timeout > 0 {
+			t := time.NewTimer(timeout)
+			defer t.Stop()
+			deadline = t.C
+		}
+
+		interval := logPollMin
+		poll := time.NewTimer(0)
+		defer poll.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline:
+				return
+			case <-poll.C:
+				if p := check(); p != "" {
+					found <- p
+					return
+				}
+				poll.Reset(interval)
+				interval = min(interval*2, logPollMax)
+			case <-events:
+				if p := check(); p != "" {
+					found <- p
+					return
+				}
+				// something is happening under Steam; poll eagerly again
+				interval = logPollMin
+			case err := <-watchErrs:
+				slog.Warn("Watching Steam directories", "err", err)
+			}
+		}
+	}()
+	return found
+}
+
+// existingAncestor returns dir or its closest parent that exists
+func existingAncestor(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// printLogWaitTimeout explains what to check when console.log never appeared
+func printLogWaitTimeout(timeout time.Duration) {
+	fmt.Println("\n" + display.Paint(display.Yellow, i18n.T("console.log did not appear within %s; chat translation is disabled.", timeout)))
+	fmt.Println(i18n.T("  - Add -condebug to CS2's launch options in Steam and start a match"))
+	fmt.Println(i18n.T("  - Or point to the log directly: cs-translate -log /path/to/console.log"))
+	fmt.Println(i18n.T("  - Run 'cs-translate doctor' to check the setup"))
+	fmt.Println(i18n.T("  - Use -log-wait 0 to keep waiting indefinitely"))
+}
+
+// rememberedLogFile returns the console log used on the last run if it is
+// still there, so auto-detection can be skipped
+func rememberedLogFile() string {
+	path := config.LoadState().LastLogPath
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	fmt.Print(i18n.T("Using the console log from the last run: %s\n", path))
+	return path
+}
+
+// rememberLogFile stores a console log that could be opened for next time
+func rememberLogFile(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	state := config.LoadState()
+	if state.LastLogPath == path {
+		return
+	}
+	state.LastLogPath = path
+	if err := config.SaveState(state); err != nil {
+		slog.Warn("Could not remember the console log path", "err", err)
+	}
+}
+
+// splitLogPaths splits a log_path setting into paths and glob patterns,
+// separated like PATH entries
+func splitLogPaths(s string) []string {
+	var paths []string
+	for _, p := range filepath.SplitList(s) {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func joinLogPaths(paths []string) string {
+	return strings.Join(paths, string(os.PathListSeparator))
+}
+
+// tagLogs is set when several logs may be followed, so chat is shown with
+// the log it came from
+var tagLogs bool
+
+// logLabel names a followed log briefly, e.g. "csgo/console.log", or the
+// server that streams it
+func logLabel(path string) string {
+	if _, addr, ok := strings.Cut(path, "://"); ok {
+		return addr
+	}
+	return filepath.Join(filepath.Base(filepath.Dir(path)), filepath.Base(path))
+}
+
+// followLogs starts following the console logs patterns name. A single
+// plain path is remembered for the next start.
+func followLogs(ctx context.Context, patterns []string) (*monitor.Group, error) {
+	remember := len(patterns) == 1 && !monitor.IsGlob(patterns[0])
+	tagLogs = !remember || serverLogs != nil
+	logs, err := monitor.NewGroup(patterns, func(path string) {
+		fmt.Print(i18n.T("Monitoring log file: %s\n", path))
+		if remember {
+			rememberLogFile(path)
+		}
+		warnLogConflicts(path)
+		go watchLogTruncation(ctx, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(logs.Files()) == 0 {
+		fmt.Print(i18n.T("Waiting for logs matching %s\n", strings.Join(patterns, ", ")))
+	}
+	return logs, nil
+}
+
+// serverLogs receives the logs game servers stream with logaddress_add; nil
+// unless -log-listen is set
+var serverLogs *monitor.Listener
+
+// listenServerLogs starts receiving server logs on addr
+func listenServerLogs(addr, secret string) {
+	l, err := monitor.Listen(addr, secret)
+	if err != nil {
+		slog.Warn("Not receiving server logs", "err", err)
+		return
+	}
+	serverLogs, tagLogs = l, true
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	path := ""
+	if secret != "" {
+		path = "/" + secret
+	}
+	fmt.Print(i18n.T("Receiving server logs on port %s. On the server, with this machine's address:\n", port))
+	fmt.Print(i18n.T("  logaddress_add <address>:%s   or   logaddress_add_http \"http://<address>:%s%s\"\n", port, port, path))
+}
+
+// serverLogLines returns the lines of serverLogs, or nil when it is not set
+func serverLogLines() chan *monitor.Line {
+	if serverLogs == nil {
+		return nil
+	}
+	return serverLogs.Lines()
+}
@@ -4,113 +4,556 @@ import (
 	"bufio"
 	"context"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
-	"path/filepath"
 	"runtime"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/hooks"
 	"github.com/micha/cs-ingame-translate/hotkey"
+	"github.com/micha/cs-ingame-translate/i18n"
 	"github.com/micha/cs-ingame-translate/monitor"
-	"github.com/micha/cs-ingame-translate/parser"
+	"github.com/micha/cs-ingame-translate/mqtt"
+	"github.com/micha/cs-ingame-translate/obs"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/setup"
+	"github.com/micha/cs-ingame-translate/speakers"
+	"github.com/micha/cs-ingame-translate/subtitle"
+	"github.com/micha/cs-ingame-translate/telegram"
+	"github.com/micha/cs-ingame-translate/tempdir"
 	"github.com/micha/cs-ingame-translate/translator"
-	"github.com/nxadm/tail"
+	"github.com/micha/cs-ingame-translate/twitch"
 )
 
 //go:embed transcriber.py
 var transcriberScript []byte
 
 func main() {
-	logPath := flag.String("log", "", "Path to the CS2 console log file")
-	ollamaModel := flag.String("model", translator.DefaultOllamaModel, "Ollama model to use for translation")
-	targetLang := flag.String("lang", "English", "Target language for translation")
-	audioDevice := flag.String("audiodevice", "", "Audio device to monitor (default: auto-detect)")
+	if serveWindowsService(run) {
+		return
+	}
+	run()
+}
+
+func run() {
+	display.InitConsole()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "doctor":
+			runDoctor(os.Args[2:])
+			return
+		case "devices":
+			runDevicesCommand(os.Args[2:])
+			return
+		case "container":
+			runContainerCommand(os.Args[2:])
+			return
+		case "config":
+			runConfigCommand(os.Args[2:])
+			return
+		case "setup":
+			runSetupCommand(os.Args[2:])
+			return
+		case "bench":
+			runBenchCommand(os.Args[2:])
+			return
+		case "tune":
+			runTuneCommand(os.Args[2:])
+			return
+		case "practice":
+			runPracticeCommand(os.Args[2:])
+			return
+		case "report":
+			runReportCommand(os.Args[2:])
+			return
+		case "transcript":
+			runTranscriptCommand(os.Args[2:])
+			return
+		case "api":
+			runAPICommand(os.Args[2:])
+			return
+		case "install-service":
+			runInstallService(os.Args[2:])
+			return
+		case "uninstall-service":
+			runUninstallService(os.Args[2:])
+			return
+		}
+	}
+
+	cfg, loadErr := config.Load()
+	cfg.ApplyEnv()
+
+	logFlagSet := false
+	flag.Func("log", "Path or glob of a CS2 console log; repeat it to follow several logs as one (default: auto-detect)", func(v string) error {
+		if !logFlagSet {
+			cfg.LogPath, logFlagSet = "", true
+		}
+		cfg.LogPath = joinLogPaths(append(splitLogPaths(cfg.LogPath), v))
+		return nil
+	})
+	flag.DurationVar((*time.Duration)(&cfg.LogWait), "log-wait", time.Duration(cfg.LogWait), "Stop waiting for an auto-detected console log after this long (0 waits forever)")
+	flag.StringVar(&cfg.LogListen, "log-listen", cfg.LogListen, "Receive server logs sent with logaddress_add or logaddress_add_http on this address, e.g. :27500")
+	flag.StringVar(&cfg.LogSecret, "log-secret", cfg.LogSecret, "Secret received server logs must carry (sv_logsecret, or the URL path for HTTP)")
+	flag.Func("client-log", "Also follow the chat log of a matchmaking client or Steam chat: faceit, esea or steam, or client=path; may be repeated", func(v string) error {
+		cfg.ClientLogs = append(cfg.ClientLogs, parseClientLogFlag(v))
+		return nil
+	})
+	flag.StringVar(&cfg.Model, "model", cfg.Model, "Ollama model to use for translation")
+	flag.BoolVar(&cfg.CPU, "cpu", cfg.CPU, "Use small models and longer timeouts for machines without a usable GPU")
+	flag.StringVar(&cfg.Lang, "lang", cfg.Lang, "Target language for translation")
+	flag.StringVar(&cfg.UILang, "ui-lang", cfg.UILang, "Language of setup and console messages: en, de or ru (default: from LANG)")
+	flag.StringVar(&cfg.AudioDevice, "audiodevice", cfg.AudioDevice, "Audio device to monitor, by name or by its number in -list-audio-devices (default: auto-detect)")
+	flag.StringVar(&cfg.CaptureApp, "capture-app", cfg.CaptureApp, "Record only this program's audio, e.g. cs2 (ignored with -audiodevice)")
+	flag.Func("capture", "Capture this device as well, tagged with a label: label=device, e.g. discord=app:discord; may be repeated, and replaces -audiodevice", func(v string) error {
+		source, err := parseCaptureFlag(v)
+		if err != nil {
+			return err
+		}
+		cfg.CaptureSources = append(cfg.CaptureSources, source)
+		return nil
+	})
+	flag.StringVar(&cfg.FFmpeg, "ffmpeg", cfg.FFmpeg, "ffmpeg executable to use (default: from PATH, else the one setup downloaded)")
 	listDevices := flag.Bool("list-audio-devices", false, "List available audio devices and exit")
-	useVoice := flag.Bool("voice", false, "Enable voice transcription (local Whisper)")
+	flag.BoolVar(&cfg.Voice, "voice", cfg.Voice, "Enable voice transcription (local Whisper)")
+	flag.StringVar(&cfg.VoiceMode, "voice-mode", cfg.VoiceMode, "How CS2 mode transcribes voice: live, key (only the last -echo-window when F9 is pressed) or both")
+	flag.DurationVar((*time.Duration)(&cfg.HTTP.RequestTimeout), "request-timeout", time.Duration(cfg.HTTP.RequestTimeout), "Timeout for a single translation request")
+	flag.DurationVar((*time.Duration)(&cfg.HTTP.ClientTimeout), "http-timeout", time.Duration(cfg.HTTP.ClientTimeout), "Upper bound for any request to Ollama")
+	flag.IntVar(&cfg.HTTP.Pool, "http-pool", cfg.HTTP.Pool, "Keep-alive connections kept open to Ollama")
+	flag.IntVar(&cfg.HTTP.Concurrency, "ollama-concurrency", cfg.HTTP.Concurrency, "Requests sent to Ollama at once; the rest wait their turn without using up -request-timeout (0: no limit)")
+	flag.IntVar(&cfg.HTTP.VoiceWeight, "voice-weight", cfg.HTTP.VoiceWeight, "While voice and chat both wait for Ollama, voice gets this many of the freed places for every -chat-weight chat gets")
+	flag.IntVar(&cfg.HTTP.ChatWeight, "chat-weight", cfg.HTTP.ChatWeight, "Freed Ollama places chat gets for every -voice-weight voice gets")
+	flag.BoolVar(&cfg.HTTP.HTTP2, "http2", cfg.HTTP.HTTP2, "Talk HTTP/2 to Ollama (h2c for http:// hosts; the server must support it)")
+	flag.BoolVar(&cfg.GameWatch, "game-watch", cfg.GameWatch, "Pause capture and unload the translation model while CS2 is not running")
+	flag.DurationVar((*time.Duration)(&cfg.ResourcePoll), "resource-interval", time.Duration(cfg.ResourcePoll), "Sample GPU load, VRAM and Ollama's loaded models this often and show changes in the status line (0 disables)")
+	flag.BoolVar(&cfg.KeepContainer, "keep-container", cfg.KeepContainer, "Leave the Docker container running on exit")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "Concurrent translations; voice goes first, then team chat, then all-chat")
+	flag.DurationVar((*time.Duration)(&cfg.SupersedeWindow), "supersede-window", time.Duration(cfg.SupersedeWindow), "Cancel a player's pending translation if they send another message within this window (0 disables)")
+	flag.DurationVar((*time.Duration)(&cfg.ShutdownTimeout), "shutdown-timeout", time.Duration(cfg.ShutdownTimeout), "On Ctrl+C, wait this long for chat translations still running (0 quits at once)")
+	flag.DurationVar((*time.Duration)(&cfg.ReorderWindow), "reorder-window", time.Duration(cfg.ReorderWindow), "Hold a chat translation that finished before an earlier message's up to this long, keeping chat in order (0 disables)")
+	flag.DurationVar((*time.Duration)(&cfg.Spam.DedupeWindow), "dedupe-window", time.Duration(cfg.Spam.DedupeWindow), "Show a player's message that repeats their previous one within this window once, untranslated (0 disables)")
+	flag.Float64Var(&cfg.Spam.Rate, "chat-rate", cfg.Spam.Rate, "Chat translations per second one player gets on average; more are shown untranslated (0 is unlimited)")
+	flag.IntVar(&cfg.Spam.Burst, "chat-burst", cfg.Spam.Burst, "Messages a player may send at once before -chat-rate applies")
+	flag.StringVar(&cfg.Clipboard, "clipboard", cfg.Clipboard, "Copy the latest translation to the clipboard: all, or talk for your own translated speech")
+	flag.StringVar(&cfg.GameChat.Mode, "game-chat", cfg.GameChat.Mode, "Write the latest translation to a cfg that a key bound in CS2 posts to the chat: all, or talk for your own translated speech")
+	flag.StringVar(&cfg.GameChat.Command, "game-chat-command", cfg.GameChat.Command, "say_team to post translations to your team, or say for everyone")
+	flag.StringVar(&cfg.GameChat.Bind, "game-chat-bind", cfg.GameChat.Bind, "CS2 key that posts the latest translation, bound in autoexec.cfg, e.g. kp_enter")
+	flag.StringVar(&cfg.Notify, "notify", cfg.Notify, "Show translations as desktop notifications: background (only while CS2 is not focused) or always")
+	flag.Func("alert", "Comma-separated words, e.g. your name,rush,plant A, that highlight chat and voice mentioning them; /.../ is a regular expression", func(v string) error {
+		cfg.Alerts.Keywords = splitList(v)
+		return nil
+	})
+	flag.StringVar(&cfg.Alerts.Sound, "alert-sound", cfg.Alerts.Sound, "Sound for -alert: bell, or the path of a WAV file (empty is silent)")
+	flag.StringVar(&cfg.Tone.Model, "tone-model", cfg.Tone.Model, "Ollama model that tags translated chat as friendly, neutral or toxic, e.g. gemma3:1b (empty is off)")
+	flag.BoolVar(&cfg.Tone.HideToxic, "hide-toxic", cfg.Tone.HideToxic, "Hide chat that -tone-model tags as toxic")
+	flag.BoolVar(&cfg.Transcript.Save, "transcript", cfg.Transcript.Save, "Write the chat and voice of each match with their translations to the transcripts folder of the settings directory")
+	flag.BoolVar(&cfg.Transcript.Summary, "summary", cfg.Transcript.Summary, "Summarize each match with the model when it ends and on exit, saved next to its transcript (implies -transcript)")
+	flag.StringVar(&cfg.OBS.Addr, "obs", cfg.OBS.Addr, "obs-websocket address, e.g. localhost:4455, to subtitle recordings and show translations in OBS (password in CS_TRANSLATE_OBS_PASSWORD)")
+	flag.BoolVar(&cfg.OBS.Subtitles, "obs-subtitles", cfg.OBS.Subtitles, "Save subtitles of the voice next to each OBS recording")
+	flag.StringVar(&cfg.OBS.Format, "obs-format", cfg.OBS.Format, "Subtitle format of OBS recordings: srt or vtt")
+	flag.StringVar(&cfg.OBS.Source, "obs-source", cfg.OBS.Source, "OBS text source that shows the latest translations")
+	flag.StringVar(&cfg.OBS.Scene, "obs-scene", cfg.OBS.Scene, "OBS scene the -obs-source text source is added to if missing")
+	flag.IntVar(&cfg.OBS.Lines, "obs-lines", cfg.OBS.Lines, "Translations -obs-source shows at once")
+	flag.DurationVar((*time.Duration)(&cfg.OBS.Hold), "obs-hold", time.Duration(cfg.OBS.Hold), "Take a translation off -obs-source after this long (0 keeps it until pushed out)")
+	flag.BoolVar(&cfg.OBS.Captions, "obs-captions", cfg.OBS.Captions, "Send translations as closed captions while OBS streams")
+	flag.BoolVar(&cfg.OBS.Chat, "obs-chat", cfg.OBS.Chat, "Also subtitle and show chat in OBS, not only voice")
+	flag.StringVar(&cfg.Twitch.Channel, "twitch", cfg.Twitch.Channel, "Twitch channel whose chat gets the translations of all-chat (token in CS_TRANSLATE_TWITCH_TOKEN)")
+	flag.StringVar(&cfg.Twitch.Nick, "twitch-nick", cfg.Twitch.Nick, "Twitch account that posts (default: the channel)")
+	flag.BoolVar(&cfg.Twitch.Team, "twitch-team", cfg.Twitch.Team, "Also post team chat and voice to Twitch, which tells viewers your team's calls")
+	flag.IntVar(&cfg.Twitch.Rate, "twitch-rate", cfg.Twitch.Rate, "Messages posted to Twitch per 30 seconds at most")
+	flag.StringVar(&cfg.MQTT.Broker, "mqtt", cfg.MQTT.Broker, "MQTT broker that gets every event as JSON, e.g. localhost:1883 (password in CS_TRANSLATE_MQTT_PASSWORD)")
+	flag.StringVar(&cfg.MQTT.Username, "mqtt-user", cfg.MQTT.Username, "Username on the MQTT broker")
+	flag.StringVar(&cfg.MQTT.Prefix, "mqtt-prefix", cfg.MQTT.Prefix, "MQTT topic prefix; events go to <prefix>/<event kind>")
+	flag.StringVar(&cfg.Telegram.Chat, "telegram", cfg.Telegram.Chat, "Telegram chat ID or @channel that gets the translations and can switch the language (token in CS_TRANSLATE_TELEGRAM_TOKEN)")
+	flag.StringVar(&cfg.Privacy, "privacy", cfg.Privacy, "Keep team chat, voice and your own messages private: local (only backends on this machine) or all-chat (not translated)")
+	flag.StringVar(&cfg.PlayerName, "name", cfg.PlayerName, "Your in-game name, so -privacy recognizes your own messages")
+	flag.DurationVar((*time.Duration)(&cfg.MaxLatency), "max-latency", time.Duration(cfg.MaxLatency), "Degrade voice translation while translations take longer than this end to end (0 disables)")
+	flag.StringVar(&cfg.FastModel, "fast-model", cfg.FastModel, "Smaller Ollama model used while -max-latency is exceeded")
+	flag.BoolVar(&cfg.Structured, "structured", cfg.Structured, "Ask Ollama for JSON answers with the language and the model's confidence; chat in the target language is shown as written")
+	flag.Float64Var(&cfg.MinConfidence, "min-confidence", cfg.MinConfidence, "Mark structured chat translations the model is less sure of than this, from 0 to 1 (0 never marks)")
+	flag.BoolVar(&cfg.KeepNames, "keep-names", cfg.KeepNames, "Keep the names of players seen in chat untranslated when messages mention them")
+	flag.IntVar(&cfg.ChunkChars, "chunk-chars", cfg.ChunkChars, "Split longer chat messages into translation requests of this many characters (0 never splits)")
+	flag.IntVar(&cfg.MaxMessageChars, "max-message-chars", cfg.MaxMessageChars, "Translate at most this many characters of one message (0 is unlimited)")
+	flag.Func("whisper-lang", "Expected spoken languages as comma-separated Whisper codes, e.g. de,ru (default: detect any)", func(v string) error {
+		cfg.Whisper.Languages = splitList(v)
+		return nil
+	})
+	flag.StringVar(&cfg.Whisper.Model, "whisper-model", cfg.Whisper.Model, "Whisper model for live voice segments in CS2 mode")
+	flag.StringVar(&cfg.Whisper.CaptureModel, "whisper-capture-model", cfg.Whisper.CaptureModel, "Whisper model for F9 captures in echo mode")
+	flag.Float64Var(&cfg.Whisper.MinAvgLogProb, "whisper-min-logprob", cfg.Whisper.MinAvgLogProb, "Drop transcriptions whose average log probability is below this (0 disables)")
+	flag.Float64Var(&cfg.Whisper.MaxNoSpeechProb, "whisper-max-no-speech", cfg.Whisper.MaxNoSpeechProb, "Drop transcriptions whose no-speech probability is above this (0 disables)")
+	flag.BoolVar(&cfg.Whisper.BandPass, "band-pass", cfg.Whisper.BandPass, "Keep only the 300-3400 Hz voice band of captured audio")
+	flag.BoolVar(&cfg.Whisper.Denoise, "denoise", cfg.Whisper.Denoise, "Remove steady background noise from captured audio")
+	flag.BoolVar(&cfg.Whisper.Normalize, "normalize", cfg.Whisper.Normalize, "Even out the loudness of captured audio")
+	flag.StringVar(&cfg.Whisper.AudioFilter, "audio-filter", cfg.Whisper.AudioFilter, "Extra ffmpeg -af filter chain for captured audio")
+	flag.DurationVar((*time.Duration)(&cfg.Whisper.MaxBacklog), "max-backlog", time.Duration(cfg.Whisper.MaxBacklog), "Drop live voice segments that waited longer than this for the transcriber")
+	flag.DurationVar((*time.Duration)(&cfg.Whisper.Segment), "whisper-segment", time.Duration(cfg.Whisper.Segment), "Length of live voice segments; longer is more accurate but adds latency")
+	flag.IntVar(&cfg.Whisper.TempMaxMB, "temp-max-mb", cfg.Whisper.TempMaxMB, "Megabytes of audio files the temp folder may hold before the oldest are deleted")
+	flag.DurationVar((*time.Duration)(&cfg.Whisper.TempMaxAge), "temp-max-age", time.Duration(cfg.Whisper.TempMaxAge), "Delete audio files left in the temp folder and the container's /tmp this long")
+	flag.StringVar(&cfg.Whisper.Intermediate, "whisper-intermediate", cfg.Whisper.Intermediate, "How live voice segments reach Whisper: wav, flac or opus files, or pipe to keep them in memory")
+	flag.DurationVar((*time.Duration)(&cfg.VoiceContext.Window), "context-window", time.Duration(cfg.VoiceContext.Window), "How far back recent speech is given to voice translation as context (0 disables)")
+	flag.IntVar(&cfg.VoiceContext.MaxEntries, "context-entries", cfg.VoiceContext.MaxEntries, "Most recent transcriptions kept as voice context (0: no limit)")
+	flag.IntVar(&cfg.VoiceContext.MaxTokens, "context-tokens", cfg.VoiceContext.MaxTokens, "Estimated tokens voice context may take; the oldest lines are dropped first (0: no limit)")
+	flag.StringVar(&cfg.Whisper.Task, "whisper-task", cfg.Whisper.Task, "Whisper task: transcribe, or translate to output English directly")
+	flag.StringVar(&cfg.Discord.ChannelID, "discord-channel", cfg.Discord.ChannelID, "Discord voice channel ID to transcribe (needs discord.guild_id and CS_TRANSLATE_DISCORD_TOKEN)")
+	flag.BoolVar(&cfg.SpeakerProfiles, "speaker-profiles", cfg.SpeakerProfiles, "Remember each voice speaker's language and skip translating speakers who use the target language")
+	flag.DurationVar((*time.Duration)(&cfg.EchoWindow), "echo-window", time.Duration(cfg.EchoWindow), "Audio captured by F9 in echo mode")
+	flag.BoolVar(&cfg.HoldToCapture, "hold-to-capture", cfg.HoldToCapture, "Capture exactly while F9 is held, push-to-talk style, instead of the last -echo-window")
+	flag.StringVar(&cfg.Hotkeys.Pause, "pause-key", cfg.Hotkeys.Pause, "Key or button that does F8's pause, e.g. f6, mouse4 or pad-back")
+	flag.StringVar(&cfg.Hotkeys.Capture, "capture-key", cfg.Hotkeys.Capture, "Key or button that does F9's capture, e.g. mouse5 or pad-rb")
+	flag.StringVar(&cfg.Hotkeys.Talk, "talk-key", cfg.Hotkeys.Talk, "Key or button that does F10's talk mode, e.g. mouse4 or pad-lb")
+	flag.BoolVar(&cfg.Talk.Enabled, "talk", cfg.Talk.Enabled, "Press F10 to translate your microphone for your team (CS2 mode)")
+	flag.StringVar(&cfg.Talk.Lang, "talk-lang", cfg.Talk.Lang, "Language your team speaks; your speech is translated into it")
+	flag.StringVar(&cfg.Talk.Mic, "mic", cfg.Talk.Mic, "Microphone to record in talk mode (default: system default on Linux)")
+	flag.BoolVar(&cfg.Talk.TTS, "tts", cfg.Talk.TTS, "Speak talk mode translations with text-to-speech")
+	flag.StringVar(&cfg.Talk.TTSDevice, "tts-device", cfg.Talk.TTSDevice, "Output device for text-to-speech, e.g. a virtual cable used as microphone")
+	flag.StringVar(&cfg.HTTPAddr, "http-addr", cfg.HTTPAddr, "Serve the web API and /docs on this address, e.g. localhost:8787")
+	flag.BoolVar(&setup.DryRun, "dry-run", false, "Print the commands setup would run (package managers, docker, installers) without running them, then exit")
+	soakDuration := flag.Duration("soak", 0, "Run a synthetic load test for the given duration")
+	flag.StringVar(&cfg.Logging.Level, "log-level", cfg.Logging.Level, "Messages shown in the console: warn, info or debug")
+	flag.BoolFunc("v", "Show informational messages (-log-level info)", func(string) error {
+		if cfg.Logging.Level != "debug" {
+			cfg.Logging.Level = "info"
+		}
+		return nil
+	})
+	flag.BoolFunc("debug", "Show debug messages; cs-translate.log also records where they come from (-log-level debug)", func(string) error {
+		cfg.Logging.Level = "debug"
+		return nil
+	})
+	flag.StringVar(&cfg.Logging.Format, "log-format", cfg.Logging.Format, "Format of log messages and cs-translate.log: text or json")
 
+	flag.Usage = usage
 	flag.Parse()
 
+	defer setupLogging(cfg.Logging)()
+	if loadErr != nil {
+		slog.Warn("Using default settings", "err", loadErr)
+	}
+	if err := cfg.NormalizeLanguages(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	voiceMode, err := parseVoiceMode(cfg.VoiceMode)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if cfg.UILang != "" {
+		if err := i18n.Set(cfg.UILang); err != nil {
+			slog.Warn("Keeping the system language for messages", "err", err)
+		}
+	}
+	if err := bindHotkeys(cfg.Hotkeys); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if cfg.CPU {
+		cfg.ApplyCPUProfile()
+		fmt.Print(i18n.T("CPU profile: %s with Whisper %s (live) and %s (F9). Expect chat translations 2-5s and voice 5-10s behind the speaker.\n",
+			cfg.Model, cfg.Whisper.Model, cfg.Whisper.CaptureModel))
+	} else if setup.LoadWizardState().GPU == setup.GPUCPU {
+		fmt.Println(i18n.T("Setup found no usable GPU; add -cpu for models that keep up on a CPU."))
+	}
+	translator.Configure(cfg.HTTPSettings())
+	translator.ConfigureContext(cfg.ContextSettings())
+	voiceContextWindow = time.Duration(cfg.VoiceContext.Window)
+	shutdownTimeout = time.Duration(cfg.ShutdownTimeout)
+	removeOrphanedTemp()
+	useFFmpeg(cfg.FFmpeg)
+	keepContainer = cfg.KeepContainer
+	gameWatch = cfg.GameWatch
+	resourceInterval = time.Duration(cfg.ResourcePoll)
+	audioDevice := cfg.CaptureDevice()
+	audioPreprocess = cfg.AudioPreprocess()
+	// Undo routing done to record a single program; fatalf does so too
+	defer audio.ReleaseAppCapture()
+
 	// List audio devices if requested
 	if *listDevices {
 		listAudioDevices()
 	}
+	// -audiodevice 2 is the second device -list-audio-devices shows
+	for _, device := range []*string{&audioDevice, &cfg.Talk.Mic} {
+		resolved, err := audio.ResolveDevice(*device)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		*device = resolved
+	}
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	mode := selectMode(scanner)
+	if *soakDuration > 0 {
+		if err := ensureEnvironment(scanner, cfg.Model, cfg.Voice); err != nil {
+			fatalf("Setup failed: %v", err)
+		}
+		tr, err := translator.NewOllamaTranslator(context.Background(), cfg.Model, cfg.Lang)
+		if err != nil {
+			fatalf("Error creating translator: %v", err)
+		}
+		defer tr.Close()
+		audioListener := initAudioListener(cfg.Voice, cfg.WhisperSettings(false))
+		if audioListener != nil {
+			defer audioListener.Stop()
+		}
+		runSoak(context.Background(), *soakDuration, tr, audioListener)
+		return
+	}
+
+	mode := selectMode(scanner, time.Duration(cfg.EchoWindow))
 	isEchoMode := mode == "2"
+	if mode == "3" {
+		cfg.Talk.Enabled = true
+	}
 
-	var preRecCmd *exec.Cmd
-	var preRecStdin io.WriteCloser
+	var preRec *echoRecorder
 	var preRecDir string
-	var preRecPath string
 
 	// Voice setup logic
 	if isEchoMode {
-		*useVoice = true
+		cfg.Voice = true
+	}
+	if isEchoMode && !setup.DryRun {
 		// Start recording immediately
 		var err error
-		preRecDir, err = os.MkdirTemp("", "cs-echo-rec")
+		preRecDir, err = tempdir.New("", "cs-echo-rec")
 		if err != nil {
-			log.Fatalf("Failed to create temp dir: %v", err)
+			fatalf("Failed to create temp dir: %v", err)
 		}
-		preRecPath = filepath.Join(preRecDir, "current.wav")
 
 		// Context for recording (separate from main ctx which might be cancelled?)
 		// Actually use background context for now
-		preRecCmd, preRecStdin, err = startAudioRecording(context.Background(), preRecPath, *audioDevice)
+		preRec, err = newEchoRecorder(context.Background(), preRecDir, audioDevice)
 		if err != nil {
-			log.Printf("Warning: Failed to start early recording: %v", err)
+			if !printHint(err) {
+				slog.Warn("Failed to start early recording", "err", err)
+			}
 		} else {
-			fmt.Println("Background recording started.")
+			fmt.Println(i18n.T("Background recording started."))
 		}
-	} else if !*useVoice {
-		*useVoice = promptVoiceEnable(scanner)
+	} else if !cfg.Voice {
+		cfg.Voice = promptVoiceEnable(scanner)
 	}
 
 	// --- Environment Check & Setup ---
-	if err := ensureEnvironment(scanner, *useVoice); err != nil {
-		log.Fatalf("Setup failed: %v", err)
+	needWhisper := cfg.Voice || (cfg.Talk.Enabled && !isEchoMode)
+	var steps []setup.Step
+	if needWhisper {
+		steps = append(steps, setup.FFmpegStep())
+	}
+	if cfg.Voice && audioDevice == "" && len(cfg.CaptureSources) == 0 {
+		steps = append(steps, setup.AudioDeviceStep())
+		// Echo mode is already recording the default device
+		if preRec == nil {
+			steps = append(steps, audioPickStep(&audioDevice))
+		}
+	}
+	if cfg.Talk.Enabled && cfg.Talk.TTS && cfg.Talk.TTSDevice == "" {
+		steps = append(steps, setup.VirtualCableStep())
+	}
+	// Unless the server streams its log, CS2 has to write one
+	if !isEchoMode && (cfg.LogPath != "" || cfg.LogListen == "") {
+		steps = append(steps, condebugStep())
+	}
+	if err := ensureEnvironment(scanner, cfg.Model, needWhisper, steps...); err != nil {
+		fatalf("Setup failed: %v", err)
+	}
+	if setup.DryRun {
+		fmt.Println(i18n.T("Dry run finished; nothing was installed or changed."))
+		return
 	}
 
 	ctx := context.Background()
-	tr, err := translator.NewOllamaTranslator(ctx, *ollamaModel, *targetLang)
+	chain, err := translator.NewChain(ctx, cfg.BackendSettings(), cfg.Lang)
 	if err != nil {
-		log.Fatalf("Error creating translator: %v", err)
+		fatalf("Error creating translator: %v", err)
 	}
+	fmt.Print(i18n.T("Using %s for translation to %s\n", chain, translator.LanguageName(cfg.Lang)))
+	for _, r := range cfg.LangRules {
+		fmt.Print(i18n.T("Translating %s messages to %s instead\n", r.Source, translator.LanguageName(r.Lang)))
+	}
+	if err := setPrivacy(cfg.Privacy, cfg.PlayerName); err != nil {
+		fatalf("Error: %v", err)
+	}
+	if privacy == privacyLocal && !chain.HasLocal() {
+		slog.Warn("No translation backend runs on this machine; team chat, voice and your own messages are shown untranslated (mark a trusted backend with \"local\": true)")
+	}
+	setKeptEntities(cfg)
+	tr := keepEntities(withLatencyBudget(ctx, chain, cfg))
 	defer tr.Close()
 
-	fmt.Printf("Using Ollama model '%s' for translation to %s\n", *ollamaModel, *targetLang)
-
-	audioListener := initAudioListener(*useVoice)
+	audioListener := initAudioListener(needWhisper, cfg.WhisperSettings(isEchoMode))
 	if audioListener != nil {
 		defer audioListener.Stop()
 	}
 
+	// Voice and the chat workers share the backend, voice first
+	gate := pipeline.NewGate(cfg.Workers)
+	disp := pipeline.NewDispatcher(ctx, chatTranslator(tr), pipeline.Options{
+		Workers:         cfg.Workers,
+		SupersedeWindow: time.Duration(cfg.SupersedeWindow),
+		ReorderWindow:   time.Duration(cfg.ReorderWindow),
+		ChunkSize:       cfg.ChunkChars,
+		MaxSize:         cfg.MaxMessageChars,
+		Gate:            gate,
+	})
+	defer disp.Close()
+
+	hookRunner = hooks.NewRunner(ctx, cfg.HookSettings(), cfg.HookConcurrency)
+	targetLang = cfg.Lang
+	nameHints = cfg.NameHints
+	chatLimiter = pipeline.NewLimiter(cfg.SpamSettings())
+	structured, minConfidence = cfg.Structured, cfg.MinConfidence
+	langRules = cfg.LangRules
+	hideToxic = cfg.Tone.HideToxic
+	if cfg.Tone.Model != "" {
+		classifier = translator.NewClassifier(ollamaBackend(cfg, cfg.Tone.Model))
+		fmt.Print(i18n.T("Tagging the tone of chat with %s\n", cfg.Tone.Model))
+		defer startToneSummary()()
+	}
+	if cfg.Transcript.Save || cfg.Transcript.Summary {
+		var summarizer *translator.Summarizer
+		if cfg.Transcript.Summary {
+			summarizer = translator.NewSummarizer(ollamaBackend(cfg, cfg.Model))
+		}
+		defer startTranscript("", summarizer)()
+	}
+	if cfg.OBS.Addr != "" {
+		if cfg.OBS.Format != subtitle.FormatSRT && cfg.OBS.Format != subtitle.FormatVTT {
+			fatalf("Error: unknown -obs-format %q (use srt or vtt)", cfg.OBS.Format)
+		}
+		if cfg.OBS.Subtitles || cfg.OBS.Source != "" || cfg.OBS.Captions {
+			fmt.Print(i18n.T("Connecting to OBS at %s\n", cfg.OBS.Addr))
+			defer startOBS(cfg.OBS, os.Getenv(obs.PasswordEnv), "")()
+		} else {
+			slog.Warn("Not connecting to OBS: -obs-subtitles, -obs-source and -obs-captions are all off")
+		}
+	}
+	if cfg.Twitch.Channel != "" {
+		tc := cfg.TwitchSettings()
+		tc.Token = os.Getenv(twitch.TokenEnv)
+		if tc.Token == "" {
+			fatalf("Error: -twitch needs the OAuth token of the account that posts in %s", twitch.TokenEnv)
+		}
+		fmt.Print(i18n.T("Posting translations to the Twitch chat of %s\n", cfg.Twitch.Channel))
+		defer startTwitch(tc, cfg.Twitch.Team)()
+	}
+	if cfg.Telegram.Chat != "" {
+		tc := cfg.TelegramSettings()
+		tc.Token = os.Getenv(telegram.TokenEnv)
+		if tc.Token == "" {
+			fatalf("Error: -telegram needs the token of the bot from @BotFather in %s", telegram.TokenEnv)
+		}
+		if stop, err := startTelegram(tc, modeName(isEchoMode), cfg.Lang); err != nil {
+			slog.Warn("Not mirroring to Telegram", "err", err)
+		} else {
+			fmt.Print(i18n.T("Mirroring translations to the Telegram chat %s\n", cfg.Telegram.Chat))
+			defer stop()
+		}
+	}
+	if cfg.MQTT.Broker != "" {
+		mc := cfg.MQTTSettings()
+		mc.Password = os.Getenv(mqtt.PasswordEnv)
+		stop, err := startMQTT(mc, cfg.MQTT.Prefix, cfg.MQTT.Events)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		fmt.Print(i18n.T("Publishing events to the MQTT broker %s\n", cfg.MQTT.Broker))
+		defer stop()
+	}
+	defer hookRunner.Wait()
+	bus.Subscribe(submitChat(disp))
+	defer subscribeSinks(isEchoMode, cfg.Clipboard, cfg.Notify, cfg.GameChat)()
+	defer startPlugins(ctx, cfg.PluginSettings())()
+	defer startAlerts(cfg.Alerts)()
+
+	if cfg.HTTPAddr != "" {
+		cfg.HTTPAddr = pickHTTPAddr(cfg.HTTPAddr)
+		srv := newWebServer(cfg, modeName(isEchoMode))
+		if err := srv.Start(); err != nil {
+			slog.Warn("Web server not started", "err", err)
+		} else {
+			fmt.Print(i18n.T("Web server listening on http://%s (reference at /docs)\n", srv.Addr()))
+			defer srv.Shutdown()
+			defer deck.close()
+		}
+	}
+
+	if cfg.LogListen != "" {
+		listenServerLogs(cfg.LogListen, cfg.LogSecret)
+		if serverLogs != nil {
+			defer serverLogs.Stop()
+		}
+	}
+	if len(cfg.ClientLogs) > 0 {
+		if err := followClientLogs(cfg.ClientLogs); err != nil {
+			fatalf("Error: %v", err)
+		}
+		defer clientLogs.Stop()
+	}
+
+	voiceTr := gatedTranslator{Translator: tr, gate: gate, prio: pipeline.PriorityVoice}
 	if isEchoMode {
 		if audioListener == nil {
-			log.Fatal("Echo mode requires working audio transcription. Please ensure dependencies are met.")
+			fatalf("Echo mode requires working audio transcription. Please ensure dependencies are met.")
 		}
-		runEchoMode(ctx, scanner, tr, audioListener, *logPath, *audioDevice, preRecCmd, preRecStdin, preRecDir, preRecPath)
+		runEchoMode(ctx, scanner, voiceTr, disp, audioListener, cfg.LogPath, time.Duration(cfg.LogWait), audioDevice, preRec, preRecDir, time.Duration(cfg.EchoWindow), cfg.HoldToCapture)
 	} else {
 		// Clean up pre-recording if it happened (shouldn't happen here but safe)
-		stopRecordingGracefully(preRecCmd, preRecStdin)
+		if preRec != nil {
+			preRec.stop()
+		}
 		if preRecDir != "" {
 			os.RemoveAll(preRecDir)
 		}
-		runCS2Mode(ctx, scanner, tr, audioListener, *logPath, *audioDevice, *useVoice)
+		if cfg.SpeakerProfiles {
+			speakerProfiles = loadSpeakerProfiles()
+		}
+		if bot := startDiscord(cfg.Discord, audioListener); bot != nil {
+			defer bot.Close()
+		}
+		talk := startTalk(ctx, cfg.Talk, ollamaBackend(cfg, cfg.Model), audioListener)
+		defer talk.close()
+		sources, err := captureSources(cfg.CaptureSources, audioDevice)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		var voice *voiceCapture
+		if cfg.Voice && audioListener != nil {
+			if voice, err = newVoiceCapture(ctx, audioListener, sources, voiceMode, time.Duration(cfg.EchoWindow), cfg.HoldToCapture); err != nil {
+				fatalf("Error: %v", err)
+			}
+			defer voice.close()
+		}
+		runCS2Mode(ctx, scanner, voiceTr, disp, audioListener, talk, cfg.LogPath, time.Duration(cfg.LogWait), voice)
 	}
 }
 
-func startAudioRecording(ctx context.Context, path, device string) (*exec.Cmd, io.WriteCloser, error) {
+// fatalf is log.Fatalf for run and the modes it starts. Fatal exits skip
+// deferred calls, so it undoes the audio routing of -capture-app itself,
+// which would otherwise keep the program playing into a private sink.
+func fatalf(format string, v ...any) {
+	audio.ReleaseAppCapture()
+	log.Fatalf(format, v...)
+}
+
+// captureInput returns the ffmpeg input for recording device, or the system
+// output if none is set
+func captureInput(device string) (audio.Input, error) {
+	if audio.IsAppDevice(device) {
+		return audio.AppInput(device)
+	}
 	source := device
 	if source == "" || source == "default" {
 		if runtime.GOOS == "linux" {
@@ -121,17 +564,56 @@ func startAudioRecording(ctx context.Context, path, device string) (*exec.Cmd, i
 		}
 	}
 
-	args := []string{}
 	if runtime.GOOS == "linux" {
-		args = []string{"-f", "pulse", "-i", source}
-	} else {
-		args = []string{"-f", "dshow", "-i", "audio=" + source}
+		if err := audio.CheckSource(source); err != nil {
+			return audio.Input{}, err
+		}
+		if audio.IsPipeWireDevice(source) {
+			return audio.PipeWireInput(source), nil
+		}
+		return audio.Input{Args: []string{"-f", "pulse", "-i", source}}, nil
+	}
+	if runtime.GOOS == "windows" {
+		if err := audio.CheckSource(source); err != nil {
+			return audio.Input{}, err
+		}
 	}
+	return audio.Input{Args: []string{"-f", "dshow", "-i", "audio=" + source}}, nil
+}
 
-	// Add output format
-	args = append(args, "-c:a", "pcm_s16le", "-ar", "16000", "-ac", "1", "-y", path)
+// micInput returns the ffmpeg input for a microphone. PulseAudio's default
+// source is the microphone; elsewhere the device must be named.
+func micInput(device string) (audio.Input, error) {
+	if runtime.GOOS == "linux" {
+		if device == "" || device == "default" {
+			return audio.Input{Args: []string{"-f", "pulse", "-i", "default"}}, nil
+		}
+		if err := audio.CheckSource(device); err != nil {
+			return audio.Input{}, err
+		}
+		if audio.IsPipeWireDevice(device) {
+			return audio.PipeWireInput(device), nil
+		}
+		return audio.Input{Args: []string{"-f", "pulse", "-i", device}}, nil
+	}
+	if device == "" {
+		return audio.Input{}, fmt.Errorf("no microphone set; pass -mic with a device name from -list-audio-devices")
+	}
+	return audio.Input{Args: []string{"-f", "dshow", "-i", "audio=" + device}}, nil
+}
+
+// audioPreprocess filters every recording before it is transcribed
+var audioPreprocess audio.Preprocess
+
+// startAudioRecording starts ffmpeg recording input as 16 kHz mono PCM into
+// the given output options
+func startAudioRecording(ctx context.Context, input audio.Input, output ...string) (*exec.Cmd, io.WriteCloser, error) {
+	args := append([]string{}, input.Args...)
+	args = append(args, audioPreprocess.Args()...)
+	args = append(args, "-c:a", "pcm_s16le", "-ar", "16000", "-ac", "1", "-y")
+	args = append(args, output...)
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd := execwrap.CommandContext(ctx, "ffmpeg", args...)
 	// Suppress stderr to avoid spam, but keep it for debugging if needed
 	// cmd.Stderr = os.Stderr
 
@@ -140,79 +622,85 @@ func startAudioRecording(ctx context.Context, path, device string) (*exec.Cmd, i
 		return nil, nil, fmt.Errorf("failed to get stdin pipe: %w", err)
 	}
 
-	if err := cmd.Start(); err != nil {
+	if err := input.Start(cmd); err != nil {
 		return nil, nil, fmt.Errorf("failed to start ffmpeg: %w", err)
 	}
 	return cmd, stdin, nil
 }
 
-func runEchoMode(ctx context.Context, scanner *bufio.Scanner, tr *translator.OllamaTranslator, listener *audio.Listener, logPath string, device string, initialCmd *exec.Cmd, initialStdin io.WriteCloser, tmpDir string, initialPath string) {
-	fmt.Println("\n=== Echo Mode Started ===")
-	fmt.Println("Listening to system output audio + Monitoring CS2 Console...")
-	fmt.Println("Press F9 to capture the last 15 seconds, transcribe, and translate.")
-	fmt.Println("Press Ctrl+C to exit.")
+func runEchoMode(ctx context.Context, scanner *bufio.Scanner, tr translator.Translator, disp *pipeline.Dispatcher, listener *audio.Listener, logPath string, logWait time.Duration, device string, rec *echoRecorder, tmpDir string, window time.Duration, hold bool) {
+	fmt.Println(i18n.T("\n=== Echo Mode Started ==="))
+	fmt.Println(i18n.T("Listening to system output audio + Monitoring CS2 Console..."))
+	if hold {
+		fmt.Println(i18n.T("Hold F9 while speaking; it is transcribed and translated when you let go."))
+	} else {
+		fmt.Print(i18n.T("Press F9 to capture the last %s, transcribe, and translate.\n", window))
+		fmt.Println(i18n.T("Double-press F9 to mark a start, then press it again to capture everything since."))
+	}
+	fmt.Println(i18n.T("Press F8 or type /pause to pause capture and translation."))
+	fmt.Println(i18n.T("Press Ctrl+C to exit."))
 
 	// --- Console Monitor Setup ---
-	// Find log file
-	path := logPath
-	if path == "" {
-		fmt.Println("Auto-detecting log file location...")
-		path, _ = findLogFile() // Ignore error, just try once silently or use empty
-		if path != "" {
-			fmt.Printf("Found log file: %s\n", path)
-		} else {
-			fmt.Println("Warning: Could not auto-detect log file. Console translation disabled until restart with -log flag.")
+	var logs *monitor.Group
+	var logLines chan *monitor.Line
+	defer func() {
+		if logs != nil {
+			logs.Stop()
 		}
-	}
-
-	var mon *monitor.Monitor
-	var logLines chan *tail.Line
-	if path != "" {
-		fmt.Printf("Monitoring log file: %s\n", path)
+	}()
+	openMonitor := func(patterns []string) {
 		var err error
-		mon, err = monitor.NewMonitor(path)
-		if err != nil {
-			log.Printf("Error creating monitor: %v", err)
-		} else {
-			// defer mon.Stop() // Cannot defer in loop/long running function easily if not careful, but okay here as we return on exit
-			// Actually we should handle stop manually on exit
-			logLines = mon.Lines()
+		if logs, err = followLogs(ctx, patterns); err != nil {
+			slog.Error("Failed to follow the console log", "err", err)
+			return
 		}
+		logLines = logs.Lines()
+	}
+
+	var logFound <-chan string
+	if logPath == "" && serverLogs == nil {
+		logPath = rememberedLogFile()
 	}
+	switch {
+	case logPath != "":
+		openMonitor(splitLogPaths(logPath))
+	case serverLogs != nil:
+		// The server streams its log; there is no console.log to look for
+	default:
+		fmt.Println(i18n.T("Auto-detecting log file location in the background..."))
+		logFound = discoverLogFile(ctx, logWait)
+	}
+	serverLines := serverLogLines()
+	clientLines := clientLogLines()
 	// -----------------------------
 
 	if tmpDir == "" {
 		// Fallback if pre-recording failed or didn't run
 		var err error
-		tmpDir, err = os.MkdirTemp("", "cs-echo-rec")
+		tmpDir, err = tempdir.New("", "cs-echo-rec")
 		if err != nil {
-			log.Fatalf("Failed to create temp dir: %v", err)
+			fatalf("Failed to create temp dir: %v", err)
 		}
 	}
 	defer os.RemoveAll(tmpDir)
 
-	currentRecPath := initialPath
-	if currentRecPath == "" {
-		currentRecPath = filepath.Join(tmpDir, "current.wav")
-	}
-
-	currentCmd := initialCmd
-	currentStdin := initialStdin
-	if currentCmd == nil {
+	if rec == nil {
 		var err error
-		currentCmd, currentStdin, err = startAudioRecording(ctx, currentRecPath, device)
-		if err != nil {
-			log.Printf("Failed to start recording: %v", err)
+		rec, err = newEchoRecorder(ctx, tmpDir, device)
+		if err != nil && !printHint(err) {
+			slog.Error("Failed to start recording", "err", err)
 		}
 	}
 
 	defer func() {
-		stopRecordingGracefully(currentCmd, currentStdin)
+		if rec != nil {
+			rec.stop()
+		}
 		stopDockerContainer()
 	}()
 
 	// Hotkey Listener
-	hk := hotkey.NewListener(hotkey.KeyF9)
+	hk := hotkey.NewListener(captureKey)
 	hkErr := make(chan error, 1)
 	go func() {
 		if err := hk.Start(ctx); err != nil {
@@ -221,183 +709,611 @@ func runEchoMode(ctx context.Context, scanner *bufio.Scanner, tr *translator.Oll
 	}()
 
 	transcriptions := listener.Transcriptions()
+	transcriberStatus := listener.Status()
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer shutDown(disp, listener, interrupt)
+	deck.setVoice(true)
+
+	// capture hands the audio since from to the transcriber
+	capture := func(from time.Time) {
+		if rec == nil {
+			var err error
+			if rec, err = newEchoRecorder(ctx, tmpDir, device); err != nil {
+				slog.Error("Audio capture is not running", "err", err)
+			} else {
+				slog.Info("Audio capture was not running; started it now")
+			}
+			return
+		}
+		if err := rec.capture(from, listener.OutputDir(), listener.SubmitFile); err != nil {
+			slog.Error("Capture failed", "err", err)
+		}
+	}
+
+	// A single F9 captures the last window. A double press marks a start
+	// instead, and the next press captures everything since the mark. With
+	// hold, F9 captures exactly while it is held.
+	var mark time.Time
+	var firstPress time.Time
+	var pressTimer *time.Timer
+	var pressWait <-chan time.Time
+	var heldSince time.Time
+	var releases <-chan struct{}
+	if hold {
+		releases = hk.KeyReleased()
+	}
+
+	// /fix and other commands typed while running
+	commands := readCommands(scanner)
+	var fixer nameFixer
+
+	// Pausing stops the recording; F9 and chat are ignored until resumed
+	pauseKeys := startPauseKey(ctx)
+	paused := false
+	setPaused := func(p bool) {
+		if p == paused {
+			return
+		}
+		paused = p
+		if paused {
+			mark = time.Time{}
+			heldSince = time.Time{}
+			if pressWait != nil {
+				pressTimer.Stop()
+				pressWait = nil
+			}
+			if rec != nil {
+				rec.stop()
+				rec = nil
+			}
+		} else {
+			var err error
+			if rec, err = newEchoRecorder(ctx, tmpDir, device); err != nil && !printHint(err) {
+				slog.Error("Failed to restart recording", "err", err)
+			}
+		}
+		publishPause(paused)
+	}
+	following := followGame(ctx)
+	defer watchResources(ctx)()
 
 	for {
 		select {
 		case <-interrupt:
-			fmt.Println("\nStopping...")
+			fmt.Println(i18n.T("\nStopping..."))
 			return
 		case err := <-hkErr:
-			log.Printf("Hotkey error: %v", err)
+			slog.Error("Hotkey failed", "err", err)
 			return
 
+		case path, ok := <-logFound:
+			logFound = nil
+			if !ok {
+				if ctx.Err() == nil {
+					printLogWaitTimeout(logWait)
+				}
+				continue
+			}
+			fmt.Print(i18n.T("\nFound log file: %s\n", path))
+			openMonitor([]string{path})
+
 		// Console Monitor Case
 		case line, ok := <-logLines:
 			if !ok {
 				logLines = nil // Stop listening if closed
 				continue
 			}
-			if line.Err != nil {
+			if line.Err != nil || paused {
 				continue
 			}
-			msg := parser.ParseLine(line.Text)
-			if msg != nil {
-				translated, err := tr.Translate(ctx, msg.MessageContent)
-				if err != nil {
-					translated = "[Translation Pending/Error]"
-				}
-				outputChat(msg.PlayerName, translated, msg.IsDead, msg.OriginalText)
+			publishLogLine(line)
+
+		case line := <-serverLines:
+			if !paused {
+				publishLogLine(line)
 			}
 
-		case <-hk.KeyPressed():
-			fmt.Println("\n[F9] Capturing...")
+		case line := <-clientLines:
+			if line.Err == nil && !paused {
+				publishLogLine(line)
+			}
 
-			stopRecordingGracefully(currentCmd, currentStdin)
+		case res := <-disp.Results():
+			handleChatResult(res)
 
-			if _, err := os.Stat(currentRecPath); os.IsNotExist(err) {
-				log.Printf("Recording file not found: %s (Audio capture might have failed to start)", currentRecPath)
-				currentCmd, currentStdin, _ = startAudioRecording(ctx, currentRecPath, device)
+		case cmd, ok := <-commands:
+			if !ok {
+				commands = nil
 				continue
 			}
-
-			lastRecPath := filepath.Join(tmpDir, fmt.Sprintf("rec_%d.wav", time.Now().UnixNano()))
-
-			if err := renameWithRetry(currentRecPath, lastRecPath); err != nil {
-				log.Printf("Failed to rename recording file: %v", err)
-				os.Remove(currentRecPath)
-				currentCmd, currentStdin, _ = startAudioRecording(ctx, currentRecPath, device)
+			if p, ok := parsePauseCommand(cmd); ok {
+				setPaused(p)
 				continue
 			}
+			if handleTwitchCommand(cmd) || handleTelegramCommand(cmd) {
+				continue
+			}
+			fixer.handle(cmd)
 
-			currentCmd, currentStdin, _ = startAudioRecording(ctx, currentRecPath, device)
+		case <-pauseKeys.KeyPressed():
+			setPaused(!paused)
 
-			sliceAudioFile(lastRecPath, tmpDir, listener)
+		case running, ok := <-following.Changes():
+			if !ok {
+				following.changes = nil
+				continue
+			}
+			following.handle(running, paused, setPaused, tr)
 
-		case text := <-transcriptions:
-			parts := strings.Split(text, "|")
-			content := parts[0]
-			fmt.Printf("\nOriginal: %s\n", content)
+		case <-hk.KeyPressed():
+			if paused {
+				fmt.Println(i18n.T("\n[F9] Paused; press F8 or type /resume first."))
+				continue
+			}
+			now := time.Now()
+			switch {
+			case hold:
+				heldSince = now
+				fmt.Println(i18n.T("\n[F9] Recording; let go of F9 to transcribe."))
+			case !mark.IsZero():
+				from := mark
+				if now.Sub(from) > maxEchoCapture {
+					from = now.Add(-maxEchoCapture)
+				}
+				mark = time.Time{}
+				fmt.Print(i18n.T("\n[F9] Capturing %.0fs since mark...\n", now.Sub(from).Seconds()))
+				capture(from)
+			case pressWait != nil:
+				pressTimer.Stop()
+				pressWait = nil
+				mark = firstPress
+				fmt.Println(i18n.T("\n[F9] Start marked; press F9 again to capture up to now."))
+			default:
+				firstPress = now
+				pressTimer = time.NewTimer(doublePressWindow)
+				pressWait = pressTimer.C
+			}
 
-			translated, err := tr.Translate(ctx, content)
-			if err != nil {
-				log.Printf("Translation error: %v", err)
+		case <-releases:
+			if heldSince.IsZero() {
 				continue
 			}
-			// Color output
-			fmt.Printf("\033[1;32mTranslated: %s\033[0m\n", translated)
-		}
-	}
-}
+			from, ok := heldSpan(heldSince, time.Now())
+			heldSince = time.Time{}
+			if !ok {
+				fmt.Println(i18n.T("\n[F9] Hold F9 while speaking and let go when done."))
+				continue
+			}
+			fmt.Print(i18n.T("\n[F9] Capturing %.0fs while F9 was held...\n", time.Since(from).Seconds()))
+			capture(from)
 
-func runCS2Mode(ctx context.Context, scanner *bufio.Scanner, tr *translator.OllamaTranslator, audioListener *audio.Listener, logPath string, audioDevice string, useVoice bool) {
-	// Check if -condebug is configured
-	if err := checkCondebug(scanner); err != nil {
-		fmt.Printf("Warning: Could not verify launch options: %v\n", err)
-	}
+		case <-pressWait:
+			pressWait = nil
+			fmt.Println(i18n.T("\n[F9] Capturing..."))
+			capture(firstPress.Add(-window))
 
-	// Find log file
-	path := logPath
-	if path == "" {
-		fmt.Println("Auto-detecting log file location...")
-		firstAttempt := true
-		for {
-			var err error
-			path, err = findLogFile()
-			if err == nil {
-				if !firstAttempt {
-					fmt.Println("")
+		case a := <-deck.Actions():
+			switch a.Action {
+			case deckCapture:
+				if paused {
+					deck.fail("paused; resume first")
+					continue
 				}
-				fmt.Printf("Found log file: %s\n", path)
-				break
+				fmt.Println(i18n.T("\n[Stream Deck] Capturing..."))
+				capture(time.Now().Add(-window))
+			case deckPause, deckResume, deckTogglePause:
+				setPaused(pauseRequested(a.Action, paused))
+			case deckSetLang:
+				switchLang(tr, a.Lang)
+			case deckToggleVoice:
+				deck.fail("voice capture is always on in echo mode")
 			}
-			if firstAttempt {
-				fmt.Println("Log file not found yet. Waiting for CS2 to start...")
-				firstAttempt = false
+
+		case status := <-transcriberStatus:
+			bus.Publish(transcriberEvent(status))
+
+		case t, ok := <-transcriptions:
+			if !ok {
+				slog.Error("Transcriber stopped; F9 capture is no longer available")
+				transcriptions = nil
+				continue
+			}
+			if paused {
+				continue
+			}
+			id := bus.Publish(transcriptEvent(t))
+			spoken := spokenAt(t, time.Now())
+
+			translated := t.Text
+			via := ""
+			var took time.Duration
+			switch {
+			case whisperTranslated(t):
+				via = "whisper"
+			case latencyBudget.Level() >= pipeline.LevelNoVoice:
+				via = viaBudget
+				skipLatency()
+			default:
+				var err error
+				start := time.Now()
+				translated, err = tr.TranslateWithContext(ctx, translator.Request{
+					Text: t.Text, Kind: translator.KindVoice, SourceLang: t.Language,
+					TargetLang: voiceLang(), Private: privateVoice(),
+				})
+				if errors.Is(err, translator.ErrNoLocalBackend) {
+					translated, via, err = t.Text, viaPrivate, nil
+				}
+				if err != nil {
+					bus.Publish(events.Error{Source: "voice", Err: err})
+					continue
+				}
+				took = time.Since(start)
+				observeLatency(t.Waited + t.Elapsed + took)
 			}
-			fmt.Print(".")
-			time.Sleep(2 * time.Second)
+			bus.Publish(events.TranslationDone{
+				MessageID: id, Source: "voice", Original: t.Text, Translated: translated,
+				Language: voiceLang(), Via: via, Elapsed: took, Spoken: spoken, Duration: t.Duration,
+			})
 		}
 	}
+}
 
-	fmt.Printf("Monitoring log file: %s\n", path)
+func runCS2Mode(ctx context.Context, scanner *bufio.Scanner, tr translator.Translator, disp *pipeline.Dispatcher, audioListener *audio.Listener, talk *talker, logPath string, logWait time.Duration, voice *voiceCapture) {
+	var logs *monitor.Group
+	var logLines chan *monitor.Line
+	defer func() {
+		if logs != nil {
+			logs.Stop()
+		}
+	}()
+	openMonitor := func(patterns []string) {
+		var err error
+		if logs, err = followLogs(ctx, patterns); err != nil {
+			fatalf("Error creating monitor: %v", err)
+		}
+		logLines = logs.Lines()
+	}
 
-	mon, err := monitor.NewMonitor(path)
-	if err != nil {
-		log.Fatalf("Error creating monitor: %v", err)
+	// Find log file without holding up voice transcription
+	var logFound <-chan string
+	if logPath == "" && serverLogs == nil {
+		logPath = rememberedLogFile()
 	}
-	defer mon.Stop()
+	switch {
+	case logPath != "":
+		openMonitor(splitLogPaths(logPath))
+	case serverLogs != nil:
+		// The server streams its log; there is no console.log to look for
+	default:
+		fmt.Println(i18n.T("Auto-detecting log file location..."))
+		if _, err := findLogFile(); err != nil {
+			fmt.Println(i18n.T("Log file not found yet. Waiting for CS2 to start..."))
+		}
+		logFound = discoverLogFile(ctx, logWait)
+	}
+	serverLines := serverLogLines()
+	clientLines := clientLogLines()
 
-	if useVoice && audioListener != nil {
-		if err := audioListener.Start(ctx, audioDevice); err != nil {
-			log.Printf("Warning: Failed to start audio capture: %v", err)
+	voiceOn := false
+	if voice != nil {
+		if err := voice.start(ctx); err != nil {
+			if !printHint(err) {
+				slog.Warn("Failed to start audio capture", "err", err)
+			}
 		} else {
-			fmt.Printf("Local Audio transcription enabled (Whisper '%s' model).\n", translator.DefaultWhisperModel)
+			voiceOn = true
+			fmt.Print(i18n.T("Local Audio transcription enabled (Whisper '%s' model).\n", audioListener.Model()))
+			if voice.mode != voiceKey && len(voice.sources) > 1 {
+				fmt.Print(i18n.T("Capturing %s; speech is tagged with its label.\n", describeSources(voice.sources)))
+			}
+			switch {
+			case voice.mode == voiceLive:
+			case voice.hold:
+				fmt.Println(i18n.T("Hold F9 while speaking; it is transcribed and translated when you let go."))
+			default:
+				fmt.Print(i18n.T("Press F9 to capture the last %s, transcribe, and translate.\n", voice.window))
+			}
 		}
 	}
+	deck.setVoice(voiceOn)
 
 	// Handle Ctrl+C
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
-	logLines := mon.Lines()
-	var audioChan <-chan string
+	var audioChan <-chan audio.Transcription
+	var transcriberStatus <-chan audio.Status
 	if audioListener != nil {
 		audioChan = audioListener.Transcriptions()
+		transcriberStatus = audioListener.Status()
 	}
 
-	// Voice context buffer logic
-	var voiceContext []voiceContextItem
+	// Recent speech, reset on round boundaries
+	voiceCtx := &voiceContext{}
+	defer bus.Subscribe(voiceCtx.handle)()
 
-	fmt.Println("Waiting for chat messages...")
+	fmt.Println(i18n.T("Waiting for chat messages... (type /fix if a player name was cut at a colon, /pause or F8 to pause)"))
 
+	// /fix and other commands typed while running
+	commands := readCommands(scanner)
+	var fixer nameFixer
+
+	// Pausing stops voice capture; chat and voice are ignored until resumed.
+	// voiceOn keeps the choice to restore on resume.
+	pauseKeys := startPauseKey(ctx)
+	paused := false
+	setPaused := func(p bool) {
+		if p == paused {
+			return
+		}
+		paused = p
+		if voiceOn && paused {
+			voice.stop()
+		} else if voiceOn {
+			if err := voice.start(ctx); err != nil {
+				if !printHint(err) {
+					slog.Warn("Failed to restart audio capture", "err", err)
+				}
+				voiceOn = false
+				deck.setVoice(false)
+			}
+		}
+		publishPause(paused)
+	}
+	following := followGame(ctx)
+	defer watchResources(ctx)()
+
+	interrupted := false
+	defer func() {
+		shutDown(disp, audioListener, c)
+		if interrupted {
+			stopDockerContainer()
+		}
+	}()
 loop:
 	for {
 		select {
 		case <-c:
-			fmt.Println("\nStopping...")
-			stopDockerContainer()
+			fmt.Println(i18n.T("\nStopping..."))
+			interrupted = true
 			break loop
 
+		case path, ok := <-logFound:
+			logFound = nil
+			if !ok {
+				if ctx.Err() != nil {
+					break loop
+				}
+				printLogWaitTimeout(logWait)
+				if audioListener == nil {
+					break loop
+				}
+				continue
+			}
+			fmt.Print(i18n.T("Found log file: %s\n", path))
+			openMonitor([]string{path})
+
 		case line, ok := <-logLines:
 			if !ok {
 				break loop
 			}
-			if line.Err != nil {
+			if line.Err != nil || paused {
 				continue
 			}
-			msg := parser.ParseLine(line.Text)
-			if msg != nil {
-				translated, err := tr.Translate(ctx, msg.MessageContent)
-				if err != nil {
-					translated = "[Translation Pending/Error]"
-				}
-				outputChat(msg.PlayerName, translated, msg.IsDead, msg.OriginalText)
+			publishLogLine(line)
+
+		case line := <-serverLines:
+			if !paused {
+				publishLogLine(line)
+			}
+
+		case line := <-clientLines:
+			if line.Err == nil && !paused {
+				publishLogLine(line)
+			}
+
+		case res := <-disp.Results():
+			handleChatResult(res)
+
+		case cmd, ok := <-commands:
+			if !ok {
+				commands = nil
+				continue
+			}
+			if p, ok := parsePauseCommand(cmd); ok {
+				setPaused(p)
+				continue
+			}
+			if handleTwitchCommand(cmd) || handleTelegramCommand(cmd) {
+				continue
+			}
+			fixer.handle(cmd)
+
+		case <-pauseKeys.KeyPressed():
+			setPaused(!paused)
+
+		case running, ok := <-following.Changes():
+			if !ok {
+				following.changes = nil
+				continue
 			}
+			following.handle(running, paused, setPaused, tr)
 
-		case text, ok := <-audioChan:
+		case t, ok := <-audioChan:
 			if !ok {
 				audioChan = nil
 				continue
 			}
+			if paused {
+				continue
+			}
+			if t.Speaker == talkSpeaker {
+				talk.handle(ctx, t)
+				continue
+			}
 
-			translated, prefix, transcribeDuration := handleVoiceTranscription(ctx, tr, text, voiceContext)
-			fmt.Printf("Voice %.2fs: %s \n", transcribeDuration, text)
-			outputChat(prefix, translated, false, "")
+			id := bus.Publish(transcriptEvent(t))
+			spoken := spokenAt(t, time.Now())
+			translated, via, took := handleVoiceTranscription(ctx, tr, t, voiceCtx)
+			if via == viaBudget {
+				skipLatency()
+			} else {
+				observeLatency(t.Waited + t.Elapsed + took)
+			}
+			bus.Publish(events.TranslationDone{
+				MessageID: id, Source: "voice", Player: t.Speaker, Capture: t.Capture, Original: t.Text, Translated: translated,
+				Language: voiceLang(), Via: via, Elapsed: took, Spoken: spoken, Duration: t.Duration,
+			})
+
+		case status := <-transcriberStatus:
+			bus.Publish(transcriberEvent(status))
+
+		case <-talk.Keys():
+			if paused {
+				fmt.Println(i18n.T("[talk] Paused; press F8 or type /resume first."))
+				continue
+			}
+			talk.toggle()
+
+		case <-voice.Keys():
+			if paused {
+				fmt.Println(i18n.T("\n[F9] Paused; press F8 or type /resume first."))
+				continue
+			}
+			voice.press()
+
+		case <-voice.Releases():
+			voice.release()
+
+		case a := <-deck.Actions():
+			switch a.Action {
+			case deckToggleVoice:
+				if voice == nil {
+					deck.fail("voice transcription is not available; start with -voice")
+					continue
+				}
+				if paused {
+					deck.fail("paused; resume first")
+					continue
+				}
+				if voiceOn {
+					voice.stop()
+					voiceOn = false
+					fmt.Println(i18n.T("[Stream Deck] Voice capture paused"))
+				} else if err := voice.start(ctx); err != nil {
+					deck.fail(err.Error())
+					continue
+				} else {
+					voiceOn = true
+					fmt.Println(i18n.T("[Stream Deck] Voice capture resumed"))
+				}
+				deck.setVoice(voiceOn)
+			case deckSetLang:
+				switchLang(tr, a.Lang)
+			case deckCapture:
+				if voice.Keys() == nil {
+					deck.fail("capture needs echo mode or -voice-mode key")
+					continue
+				}
+				if paused {
+					deck.fail("paused; resume first")
+					continue
+				}
+				voice.capture()
+			case deckPause, deckResume, deckTogglePause:
+				setPaused(pauseRequested(a.Action, paused))
+			}
 		}
 	}
 }
 
 // ... Helper functions (copied from original) ...
 
-func outputChat(name, text string, isDead bool, originalLine string) {
-	if originalLine != "" {
-		fmt.Println(originalLine)
+// handleChatResult publishes a finished chat translation
+func handleChatResult(res pipeline.Result) {
+	job := res.Job.Payload.(*chatJob)
+	msg, detail, tone := job.msg, job.detail, job.tone
+	lang := chatLang(msg)
+	if res.Err == nil && !res.Superseded {
+		observeLatency(res.Latency)
+		if detail.Confidence >= minConfidence {
+			chatLanguages.Observe(msg.Player, detail.Language)
+		}
+	}
+	var via string
+	switch {
+	case errors.Is(res.Err, translator.ErrNoLocalBackend):
+		res.Text, res.Err, via = msg.Text, nil, viaPrivate
+	case res.Err == nil && ownLanguage(detail, lang):
+		res.Text, via = msg.Text, "own language"
+	}
+	bus.Publish(events.TranslationDone{
+		MessageID:  msg.ID,
+		Source:     "chat",
+		Player:     msg.Player,
+		Team:       msg.Team,
+		Dead:       msg.Dead,
+		Line:       msg.Line,
+		Log:        msg.Log,
+		Original:   msg.Text,
+		Translated: res.Text,
+		Language:   lang,
+		Detected:   detail.Language,
+		Confidence: detail.Confidence,
+		Tone:       string(tone),
+		Via:        via,
+		Truncated:  res.Truncated,
+		Superseded: res.Superseded,
+		Err:        res.Err,
+	})
+}
+
+// chatLanguages remembers the language each player wrote in this session,
+// so short messages that do not give their language away still get a hint
+var chatLanguages = speakers.New()
+
+// chatTranslator translates chat with tr, telling it the text is chat and
+// which language the player has been writing, and tags its tone with
+// -tone-model
+func chatTranslator(tr translator.Translator) pipeline.TranslateFunc {
+	return func(ctx context.Context, job pipeline.Job, text string) (string, error) {
+		cj := job.Payload.(*chatJob)
+		out, err := tr.TranslateWithContext(ctx, translator.Request{
+			Text:       text,
+			Kind:       translator.KindChat,
+			SourceLang: translator.DetectLanguage(text),
+			PlayerLang: chatLanguages.Likely(job.Key),
+			TargetLang: chatLang(cj.msg),
+			Private:    privateChat(cj.msg),
+			Detail:     &cj.detail,
+		})
+		if err == nil {
+			classify(ctx, cj, out)
+		}
+		return out, err
 	}
-	prefix := ""
-	if isDead {
-		prefix = "*DEAD* "
+}
+
+// submitChat hands chat lines from the bus to the translation pool
+func submitChat(disp *pipeline.Dispatcher) events.Handler {
+	return func(e events.Event) {
+		if c, ok := e.(events.ChatReceived); ok {
+			keepName(c.Player)
+			if skipPrivate() && privateChat(c) {
+				bus.Publish(events.TranslationDone{
+					MessageID: c.ID, Source: "chat", Player: c.Player, Team: c.Team, Dead: c.Dead, Line: c.Line,
+					Log: c.Log, Original: c.Text, Translated: c.Text, Language: chatLang(c), Via: viaPrivate,
+				})
+				return
+			}
+			if limitChat(c) || passArt(c) {
+				return
+			}
+			if !structured {
+				chatLanguages.Observe(c.Player, translator.DetectLanguage(c.Text))
+			}
+			disp.Submit(pipeline.Job{Key: c.Player, Text: c.Text, Priority: chatPriority(c), Payload: &chatJob{msg: c}})
+		}
 	}
-	fmt.Printf("\033[1;32m%s%s : %s\033[0m\n", prefix, name, text)
 }
@@ -0,0 +1,177 @@
+package monitor
+
+import (
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rescanInterval is how often glob patterns are matched again for logs that
+// appeared since
+const rescanInterval = 5 * time.Second
+
+// Group follows several logs, e.g. of two Steam accounts or console.log and
+// a server log, as one stream of lines tagged with their source
+type Group struct {
+	patterns []string
+	onOpen   func(path string)
+
+	lines chan *Line
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	mu       sync.Mutex
+	monitors map[string]*Monitor
+}
+
+// NewGroup follows every path in patterns. A plain path is followed even
+// before it exists; a glob pattern such as ".../*/console.log" follows the
+// files it matches now and those that appear later. onOpen, if set, is
+// called for each file as following starts.
+func NewGroup(patterns []string, onOpen func(path string)) (*Group, error) {
+	g := &Group{
+		patterns: patterns,
+		onOpen:   onOpen,
+		lines:    make(chan *Line, 100),
+		stop:     make(chan struct{}),
+		monitors: map[string]*Monitor{},
+	}
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.scan(true); err != nil {
+		g.Stop()
+		return nil, err
+	}
+	if g.hasGlob() {
+		g.wg.Add(1)
+		go g.rescan()
+	}
+	return g, nil
+}
+
+// Lines returns the merged lines. It is never closed while the group runs.
+func (g *Group) Lines() chan *Line {
+	return g.lines
+}
+
+// Files returns the followed files, sorted
+func (g *Group) Files() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	files := make([]string, 0, len(g.monitors))
+	for path := range g.monitors {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Stop stops following every file
+func (g *Group) Stop() {
+	g.mu.Lock()
+	close(g.stop)
+	for _, m := range g.monitors {
+		m.Stop()
+	}
+	g.mu.Unlock()
+	g.wg.Wait()
+}
+
+func (g *Group) stopped() bool {
+	select {
+	case <-g.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (g *Group) hasGlob() bool {
+	for _, p := range g.patterns {
+		if IsGlob(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGlob reports whether pattern has glob metacharacters
+func IsGlob(pattern string) bool {
+	for _, c := range pattern {
+		switch c {
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
+
+// scan starts following files that are not followed yet. Files found on a
+// rescan are new and read from their start; at startup only what is
+// written from now on is read.
+func (g *Group) scan(startup bool) error {
+	for _, p := range g.patterns {
+		paths := []string{p}
+		if IsGlob(p) {
+			paths, _ = filepath.Glob(p)
+		}
+		for _, path := range paths {
+			if err := g.follow(filepath.Clean(path), startup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Group) follow(path string, fromEnd bool) error {
+	g.mu.Lock()
+	if _, ok := g.monitors[path]; ok || g.stopped() {
+		g.mu.Unlock()
+		return nil
+	}
+	m, err := newMonitor(path, fromEnd)
+	if err != nil {
+		g.mu.Unlock()
+		return err
+	}
+	g.monitors[path] = m
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		for l := range m.Lines() {
+			select {
+			case g.lines <- l:
+			case <-g.stop:
+				return
+			}
+		}
+	}()
+	if g.onOpen != nil {
+		g.onOpen(path)
+	}
+	return nil
+}
+
+func (g *Group) rescan() {
+	defer g.wg.Done()
+	ticker := time.NewTicker(rescanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+		}
+		if err := g.scan(false); err != nil {
+			slog.Warn("Looking for console logs", "err", err)
+		}
+	}
+}
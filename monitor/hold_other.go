@@ -0,0 +1,23 @@
+//go:build !windows
+
+package monitor
+
+import "os"
+
+// keep holds on to f, the file as of this poll, until the next one: if the
+// log is renamed or deleted in between, what was written to it last can
+// still be read from the handle. keep(nil) lets go of it.
+func (fl *follower) keep(f *os.File) {
+	if fl.held != nil && fl.held != f {
+		fl.held.Close()
+	}
+	fl.held = f
+}
+
+// previous returns the file followed until the log was replaced, for the
+// caller to read to its end and close
+func (fl *follower) previous() *os.File {
+	f := fl.held
+	fl.held = nil
+	return f
+}
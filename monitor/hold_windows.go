@@ -0,0 +1,35 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// keep closes f: an open handle would keep CS2 from deleting or replacing
+// the log
+func (fl *follower) keep(f *os.File) {
+	if f != nil {
+		f.Close()
+	}
+}
+
+// previous opens the file followed until the log was replaced, when it was
+// renamed within its folder, for the caller to read to its end and close.
+// A log that was deleted is gone with what it had left.
+func (fl *follower) previous() *os.File {
+	dir := filepath.Dir(fl.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() || !os.SameFile(info, fl.info) {
+			continue
+		}
+		if f, err := os.Open(filepath.Join(dir, e.Name())); err == nil {
+			return f
+		}
+	}
+	return nil
+}
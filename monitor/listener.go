@@ -0,0 +1,178 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxPacket is the largest UDP log packet read
+	maxPacket = 64 << 10
+	// maxPost bounds the body of one logaddress_add_http request
+	maxPost = 1 << 20
+)
+
+// Listener receives the log a game server streams to it, so chat can be
+// read where there is no console.log to follow: with "logaddress_add
+// host:port" the server sends UDP packets, with "logaddress_add_http
+// http://host:port" HTTP requests. Both are accepted on the same port.
+type Listener struct {
+	secret string
+
+	udp  net.PacketConn
+	http *http.Server
+
+	lines chan *Line
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// Listen receives server logs on addr, e.g. ":27500". With a secret, UDP
+// packets must carry it (sv_logsecret) and HTTP requests must be sent to
+// http://host:port/<secret>.
+func Listen(addr, secret string) (*Listener, error) {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for server logs: %w", err)
+	}
+	// HTTP on the same port, also when addr left it to the system
+	host, _, _ := net.SplitHostPort(addr)
+	_, port, _ := net.SplitHostPort(udp.LocalAddr().String())
+	tcp, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		udp.Close()
+		return nil, fmt.Errorf("failed to listen for server logs: %w", err)
+	}
+	l := &Listener{
+		secret: secret,
+		udp:    udp,
+		lines:  make(chan *Line, 100),
+		stop:   make(chan struct{}),
+	}
+	l.http = &http.Server{Handler: http.HandlerFunc(l.receiveHTTP), ReadHeaderTimeout: 10 * time.Second}
+
+	l.wg.Add(2)
+	go func() {
+		defer l.wg.Done()
+		l.receiveUDP()
+	}()
+	go func() {
+		defer l.wg.Done()
+		if err := l.http.Serve(tcp); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("Receiving server logs over HTTP", "err", err)
+		}
+	}()
+	return l, nil
+}
+
+// Addr returns the address the listener receives on
+func (l *Listener) Addr() net.Addr {
+	return l.udp.LocalAddr()
+}
+
+// Lines returns the received lines. It is never closed while the listener
+// runs.
+func (l *Listener) Lines() chan *Line {
+	return l.lines
+}
+
+// Stop stops receiving
+func (l *Listener) Stop() {
+	close(l.stop)
+	l.udp.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	l.http.Shutdown(ctx)
+	l.wg.Wait()
+}
+
+func (l *Listener) receiveUDP() {
+	buf := make([]byte, maxPacket)
+	for {
+		n, from, err := l.udp.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-l.stop:
+			default:
+				slog.Warn("Receiving server logs over UDP", "err", err)
+			}
+			return
+		}
+		text, ok := l.packet(buf[:n])
+		if !ok {
+			continue
+		}
+		if !l.send("udp://"+from.String(), text) {
+			return
+		}
+	}
+}
+
+// packet returns the log line in a logaddress_add packet: four 0xff bytes,
+// 'R' or 'S' followed by the secret, then the line ending in a newline and
+// a NUL
+func (l *Listener) packet(p []byte) (string, bool) {
+	p, ok := bytes.CutPrefix(p, []byte("\xff\xff\xff\xff"))
+	if !ok || len(p) == 0 {
+		return "", false
+	}
+	kind, p := p[0], p[1:]
+	switch {
+	case kind == 'S' && l.secret != "":
+		if p, ok = bytes.CutPrefix(p, []byte(l.secret)); !ok {
+			return "", false
+		}
+	case kind == 'R' && l.secret == "":
+	default:
+		return "", false
+	}
+	return strings.TrimRight(string(p), "\x00\r\n"), true
+}
+
+func (l *Listener) receiveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.Trim(r.URL.Path, "/") != l.secret {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPost))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	// The port changes with each connection; the host names the server
+	source := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	source = "http://" + source
+	for _, text := range strings.Split(string(body), "\n") {
+		if text = strings.TrimRight(text, "\r"); text == "" {
+			continue
+		}
+		if !l.send(source, text) {
+			return
+		}
+	}
+}
+
+func (l *Listener) send(source, text string) bool {
+	select {
+	case l.lines <- &Line{Text: text, Time: time.Now(), Source: source, Server: true}:
+		return true
+	case <-l.stop:
+		return false
+	}
+}
@@ -1,51 +1,262 @@
+// Package monitor follows the CS2 console log. CS2 with -conclearlog, and
+// other tools, truncate or recreate console.log mid-session; the monitor
+// notices both and keeps delivering lines from the new contents. A Listener
+// receives the log a game server streams over the network instead.
 package monitor
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
 
-	"github.com/nxadm/tail"
+const (
+	// pollInterval is how often the file is checked for new data, a
+	// truncation or replacement
+	pollInterval = 250 * time.Millisecond
+	// readSize is the size of each read
+	readSize = 32 << 10
+	// maxLine bounds a line without a newline; longer ones are cut
+	maxLine = 64 << 10
+	// checkSize is how many bytes before the read position are compared on
+	// each poll to notice a rewritten file
+	checkSize = 64
 )
 
+// Line is one line of the log, without its line ending
+type Line struct {
+	Text   string
+	Time   time.Time
+	Source string // path of the log the line is from, or the server that sent it
+	Server bool   // the line is in server log format, see Listener
+	Err    error  // reading failed; Text is empty
+}
+
 // Monitor watches a file for new lines
 type Monitor struct {
 	filePath string
-	tail     *tail.Tail
+	lines    chan *Line
+	cancel   context.CancelFunc
+	done     chan struct{}
 }
 
-// NewMonitor creates a new file monitor
+// NewMonitor starts following filePath from its current end. The file need
+// not exist yet; lines are delivered once it appears.
 func NewMonitor(filePath string) (*Monitor, error) {
-	t, err := tail.TailFile(filePath, tail.Config{
-		Follow:    true,
-		ReOpen:    true,
-		MustExist: false,
-		Poll:      true, // Polling might be necessary for some setups, especially if file is rotated/recreated
-		Location:  &tail.SeekInfo{Offset: 0, Whence: io.SeekEnd},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to tail file: %w", err)
-	}
+	return newMonitor(filePath, true)
+}
 
-	return &Monitor{
+// newMonitor starts following filePath from its end, or from its start
+// when it is a log that just appeared
+func newMonitor(filePath string, fromEnd bool) (*Monitor, error) {
+	fl := &follower{path: filePath}
+	f, err := os.Open(filePath)
+	switch {
+	case err == nil && !fromEnd:
+		f.Close()
+	case err == nil:
+		err = fl.skipToEnd(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Monitor{
 		filePath: filePath,
-		tail:     t,
-	}, nil
+		lines:    make(chan *Line, 100),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(m.done)
+		defer close(m.lines)
+		fl.run(ctx, m.lines)
+	}()
+	return m, nil
 }
 
-// Lines returns the channel of new lines
-func (m *Monitor) Lines() chan *tail.Line {
-	return m.tail.Lines
+// Lines returns the channel of new lines. It is closed by Stop.
+func (m *Monitor) Lines() chan *Line {
+	return m.lines
 }
 
 // Stop stops the monitor
 func (m *Monitor) Stop() {
-	m.tail.Cleanup()
-	m.tail.Stop()
+	m.cancel()
+	<-m.done
 }
 
-// CreateDummyFile creates a dummy log file for testing
+// follower tracks the followed file and what of it has been read. On
+// Windows the file is opened for each poll only, because an open handle
+// would keep CS2 from deleting or replacing it; elsewhere the handle is
+// held until the next poll, see keep.
+type follower struct {
+	path    string
+	held    *os.File    // the file as of the last poll, if kept open
+	info    os.FileInfo // of the followed file; nil until it exists
+	offset  int64       // read position
+	last    []byte      // bytes just before offset, to notice a rewrite
+	partial []byte      // text after the last newline
+}
+
+// skipToEnd starts following f from its end
+func (fl *follower) skipToEnd(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	fl.info, fl.offset = info, info.Size()
+	fl.last = make([]byte, min(fl.offset, checkSize))
+	_, err = f.ReadAt(fl.last, fl.offset-int64(len(fl.last)))
+	return err
+}
+
+func (fl *follower) run(ctx context.Context, out chan<- *Line) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	defer fl.keep(nil)
+	for {
+		if !fl.poll(ctx, out) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads what is new and handles a truncated or replaced file. It
+// returns false once ctx is done.
+func (fl *follower) poll(ctx context.Context, out chan<- *Line) bool {
+	f, err := os.Open(fl.path)
+	if err != nil {
+		return true // not created yet, or deleted and not back yet
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return true
+	}
+	defer fl.keep(f)
+	name := filepath.Base(fl.path)
+	switch {
+	case fl.info == nil:
+		// Created after we started, so it holds only new lines
+	case !os.SameFile(info, fl.info):
+		slog.Info("Log was replaced; following the new file", "log", name)
+		// Lines written to the old file since the last poll come first
+		if old := fl.previous(); old != nil {
+			ok := fl.read(ctx, old, out)
+			old.Close()
+			if !ok {
+				return false
+			}
+		}
+		if !fl.flush(ctx, out) {
+			return false
+		}
+		fl.restart()
+	case info.Size() < fl.offset || !fl.unchanged(f):
+		slog.Info("Log was truncated; reading it from the start", "log", name)
+		fl.restart()
+	}
+	fl.info = info
+	return fl.read(ctx, f, out)
+}
+
+// restart reads the file from the start again
+func (fl *follower) restart() {
+	fl.offset, fl.last, fl.partial = 0, nil, nil
+}
+
+// unchanged reports whether the bytes before offset are still those read,
+// which they are not when the file was truncated and rewritten past offset
+// between two polls
+func (fl *follower) unchanged(f *os.File) bool {
+	if len(fl.last) == 0 {
+		return true
+	}
+	buf := make([]byte, len(fl.last))
+	if _, err := f.ReadAt(buf, fl.offset-int64(len(buf))); err != nil {
+		return false
+	}
+	return bytes.Equal(buf, fl.last)
+}
+
+// read delivers the complete lines written since the last read
+func (fl *follower) read(ctx context.Context, f *os.File, out chan<- *Line) bool {
+	buf := make([]byte, readSize)
+	for {
+		n, err := f.ReadAt(buf, fl.offset)
+		fl.offset += int64(n)
+		if n > 0 {
+			fl.last = append(fl.last, buf[:n]...)
+			fl.last = append([]byte(nil), fl.last[max(0, len(fl.last)-checkSize):]...)
+		}
+		data := append(fl.partial, buf[:n]...)
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if !fl.send(ctx, out, &Line{Text: string(bytes.TrimRight(data[:i], "\r")), Time: time.Now()}) {
+				return false
+			}
+			data = data[i+1:]
+		}
+		if len(data) > maxLine {
+			data = data[:0]
+		}
+		fl.partial = append([]byte(nil), data...)
+
+		switch {
+		case err == nil && n == len(buf):
+			continue
+		case err == nil || errors.Is(err, io.EOF):
+			return true
+		default:
+			return fl.send(ctx, out, &Line{Err: err, Time: time.Now()})
+		}
+	}
+}
+
+// flush delivers a last line that had no newline, before the file is
+// replaced
+func (fl *follower) flush(ctx context.Context, out chan<- *Line) bool {
+	if len(fl.partial) == 0 {
+		return true
+	}
+	text := string(bytes.TrimRight(fl.partial, "\r"))
+	fl.partial = nil
+	return fl.send(ctx, out, &Line{Text: text, Time: time.Now()})
+}
+
+func (fl *follower) send(ctx context.Context, out chan<- *Line, l *Line) bool {
+	l.Source = fl.path
+	select {
+	case out <- l:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// CreateDummyFile creates an empty log file for testing, and the directory
+// it is in. An existing file is emptied.
 func CreateDummyFile(path string) error {
-	// This is a helper for testing
-	// Implementation deferred
-	return nil
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	return os.WriteFile(path, nil, 0644)
 }
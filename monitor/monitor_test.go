@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// appendFile adds text to the end of path
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expectLines reads the next lines of m and fails unless they are want
+func expectLines(t *testing.T, m *Monitor, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case l := <-m.Lines():
+			if l.Err != nil {
+				t.Fatalf("reading failed: %v", l.Err)
+			}
+			if l.Text != w {
+				t.Fatalf("got line %q, want %q", l.Text, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no line %q", w)
+		}
+	}
+}
+
+// expectNoLine fails if m delivers another line soon
+func expectNoLine(t *testing.T, m *Monitor) {
+	t.Helper()
+	select {
+	case l := <-m.Lines():
+		t.Fatalf("unexpected line %q", l.Text)
+	case <-time.After(3 * pollInterval):
+	}
+}
+
+func TestMonitorRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "console.log")
+	if err := os.WriteFile(path, []byte("before the start\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMonitor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	appendFile(t, path, "first\r\n")
+	expectLines(t, m, "first")
+
+	// Truncated and rewritten to the same size between two polls
+	if err := os.WriteFile(path, []byte("again\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, m, "again")
+
+	// Renamed right after a write, with a last line that has no newline
+	appendFile(t, path, "last of old\nno newline")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("new file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, m, "last of old", "no newline", "new file")
+	expectNoLine(t, m)
+
+	// Deleted right after a write and created again
+	appendFile(t, path, "before delete\n")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("recreated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS == "windows" {
+		// Without a handle held, the lines of a deleted log are gone
+		expectLines(t, m, "recreated")
+	} else {
+		expectLines(t, m, "before delete", "recreated")
+	}
+	expectNoLine(t, m)
+
+	appendFile(t, path, "still following\n")
+	expectLines(t, m, "still following")
+}
+
+func TestMonitorCreatedLater(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "console.log")
+	m, err := NewMonitor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	time.Sleep(2 * pollInterval)
+	if err := os.WriteFile(path, []byte("once it exists\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, m, "once it exists")
+}
@@ -0,0 +1,368 @@
+// Package mqtt publishes messages to an MQTT 3.1.1 broker at QoS 0, e.g. for
+// home automation reacting to what is said in a match. It only publishes;
+// nothing is subscribed to.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PasswordEnv holds the broker password. It is never read from the settings
+// file, so it cannot leak through /api/config.
+const PasswordEnv = "CS_TRANSLATE_MQTT_PASSWORD"
+
+// DefaultRetry is the wait before reconnecting to the broker
+const DefaultRetry = 5 * time.Second
+
+const (
+	// keepAlive is how long the broker waits for a packet before it
+	// drops the connection; a ping is sent after half of it
+	keepAlive = 60 * time.Second
+	// dialTimeout bounds connecting and the broker's answer
+	dialTimeout = 10 * time.Second
+	// maxQueue bounds messages waiting while the broker is not connected;
+	// older ones are dropped
+	maxQueue = 100
+)
+
+// Packet types, shifted into the high nibble of the first byte
+const (
+	packetConnect    = 1 << 4
+	packetConnack    = 2 << 4
+	packetPublish    = 3 << 4
+	packetPingreq    = 12 << 4
+	packetPingresp   = 13 << 4
+	packetDisconnect = 14 << 4
+)
+
+// ErrAuth is returned when the broker refuses the username or password
+var ErrAuth = errors.New("MQTT broker refused the login; check the username and " + PasswordEnv)
+
+// Config selects the broker
+type Config struct {
+	Broker   string // host:port, mqtt://host[:port] or mqtts://host[:port] for TLS
+	ClientID string
+	Username string
+	Password string
+	// Status, if set, is a topic that reads "online" while connected and
+	// "offline" after, both retained
+	Status string
+	Retry  time.Duration // (0: DefaultRetry)
+}
+
+// Message is one PUBLISH
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Client publishes to the broker, reconnecting when the connection drops.
+// All methods are safe for concurrent use.
+type Client struct {
+	cfg    Config
+	addr   string
+	tls    bool
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	wake   chan struct{}
+
+	mu    sync.Mutex
+	queue []Message
+}
+
+// Start connects to the broker in the background
+func Start(cfg Config) (*Client, error) {
+	addr, useTLS, err := brokerAddr(cfg.Broker)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Retry <= 0 {
+		cfg.Retry = DefaultRetry
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		cfg: cfg, addr: addr, tls: useTLS, ctx: ctx, cancel: cancel,
+		done: make(chan struct{}), wake: make(chan struct{}, 1),
+	}
+	go c.run()
+	return c, nil
+}
+
+// brokerAddr reads host:port from broker and whether it needs TLS
+func brokerAddr(broker string) (addr string, useTLS bool, err error) {
+	port := "1883"
+	if strings.Contains(broker, "://") {
+		u, err := url.Parse(broker)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid MQTT broker %q: %w", broker, err)
+		}
+		switch u.Scheme {
+		case "mqtt", "tcp":
+		case "mqtts", "ssl", "tls":
+			useTLS, port = true, "8883"
+		default:
+			return "", false, fmt.Errorf("invalid MQTT broker %q: use mqtt:// or mqtts://", broker)
+		}
+		broker = u.Host
+	}
+	if broker == "" {
+		return "", false, errors.New("no MQTT broker given")
+	}
+	if _, _, err := net.SplitHostPort(broker); err != nil {
+		broker = net.JoinHostPort(broker, port)
+	}
+	return broker, useTLS, nil
+}
+
+// Publish queues a message; it is sent once the broker is connected
+func (c *Client) Publish(m Message) {
+	c.mu.Lock()
+	c.queue = append(c.queue, m)
+	if len(c.queue) > maxQueue {
+		c.queue = c.queue[len(c.queue)-maxQueue:]
+	}
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close disconnects; while connected, it sends what is queued and marks the
+// status offline first
+func (c *Client) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+// run keeps a connection up and sends the queue until Close
+func (c *Client) run() {
+	defer close(c.done)
+	warned := false
+	for c.ctx.Err() == nil {
+		conn, err := c.connect()
+		if errors.Is(err, ErrAuth) {
+			slog.Warn("Not publishing to MQTT", "err", err)
+			return
+		}
+		if err != nil {
+			if !warned && c.ctx.Err() == nil {
+				slog.Warn("MQTT broker not reachable; trying again", "broker", c.addr, "err", err)
+				warned = true
+			}
+		} else {
+			warned = false
+			slog.Info("Publishing events to MQTT", "broker", c.addr)
+			c.session(conn)
+		}
+		select {
+		case <-c.ctx.Done():
+		case <-time.After(c.cfg.Retry):
+		}
+	}
+}
+
+// connect dials the broker and logs in
+func (c *Client) connect() (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, dialTimeout)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	if c.tls {
+		conn, err = (&tls.Dialer{}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	if _, err := conn.Write(c.connectPacket()); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to log in to %s: %w", c.addr, err)
+	}
+	var ack [4]byte
+	if _, err := io.ReadFull(conn, ack[:]); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no answer from MQTT broker %s: %w", c.addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	switch {
+	case ack[0] != packetConnack || ack[1] != 2:
+		conn.Close()
+		return nil, fmt.Errorf("%s is not an MQTT broker", c.addr)
+	case ack[3] == 4 || ack[3] == 5:
+		conn.Close()
+		return nil, ErrAuth
+	case ack[3] != 0:
+		conn.Close()
+		return nil, fmt.Errorf("MQTT broker %s refused the connection (code %d)", c.addr, ack[3])
+	}
+	return conn, nil
+}
+
+// connectPacket is CONNECT with a clean session, the login and the offline
+// status as last will
+func (c *Client) connectPacket() []byte {
+	var flags byte = 0x02
+	var payload []byte
+	payload = appendString(payload, c.cfg.ClientID)
+	if c.cfg.Status != "" {
+		flags |= 0x04 | 0x20 // will, retained
+		payload = appendString(payload, c.cfg.Status)
+		payload = appendString(payload, "offline")
+	}
+	if c.cfg.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, c.cfg.Username)
+		if c.cfg.Password != "" {
+			flags |= 0x40
+			payload = appendString(payload, c.cfg.Password)
+		}
+	}
+	header := appendString(nil, "MQTT")
+	header = append(header, 4, flags)
+	header = binary.BigEndian.AppendUint16(header, uint16(keepAlive/time.Second))
+	return packet(packetConnect, append(header, payload...))
+}
+
+// session sends the queue and pings until the connection ends. On Close it
+// sends the rest, the offline status and DISCONNECT first.
+func (c *Client) session(conn net.Conn) {
+	defer conn.Close()
+	lost := make(chan struct{})
+	go func() {
+		defer close(lost)
+		c.read(conn)
+	}()
+	if c.cfg.Status != "" {
+		c.mu.Lock()
+		c.queue = append([]Message{{Topic: c.cfg.Status, Payload: []byte("online"), Retain: true}}, c.queue...)
+		c.mu.Unlock()
+	}
+	ping := time.NewTicker(keepAlive / 2)
+	defer ping.Stop()
+	for {
+		if err := c.send(conn); err != nil {
+			slog.Info("Lost connection to MQTT broker", "err", err)
+			return
+		}
+		select {
+		case <-c.ctx.Done():
+			if c.cfg.Status != "" {
+				c.Publish(Message{Topic: c.cfg.Status, Payload: []byte("offline"), Retain: true})
+			}
+			if c.send(conn) == nil {
+				conn.Write([]byte{packetDisconnect, 0})
+			}
+			return
+		case <-c.wake:
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+			if _, err := conn.Write([]byte{packetPingreq, 0}); err != nil {
+				return
+			}
+		case <-lost:
+			slog.Info("Lost connection to MQTT broker")
+			return
+		}
+	}
+}
+
+// send writes the queued messages; those not written stay queued
+func (c *Client) send(conn net.Conn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.queue) > 0 {
+		m := c.queue[0]
+		var first byte = packetPublish
+		if m.Retain {
+			first |= 0x01
+		}
+		conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+		if _, err := conn.Write(packet(first, append(appendString(nil, m.Topic), m.Payload...))); err != nil {
+			return err
+		}
+		c.queue = c.queue[1:]
+	}
+	return nil
+}
+
+// read takes what the broker sends, ping responses, until the connection
+// ends
+func (c *Client) read(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(keepAlive * 3 / 2))
+		first, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		n, err := readLength(r)
+		if err != nil {
+			return
+		}
+		if _, err := r.Discard(n); err != nil {
+			return
+		}
+		if first&0xf0 != packetPingresp {
+			slog.Debug("Unexpected MQTT packet", "type", first>>4)
+		}
+	}
+}
+
+// packet frames body with its fixed header
+func packet(first byte, body []byte) []byte {
+	b := []byte{first}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(b, body...)
+}
+
+// readLength reads the remaining length of a fixed header
+func readLength(r io.ByteReader) (int, error) {
+	n, shift := 0, 0
+	for i := 0; i < 4; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			return n, nil
+		}
+		shift += 7
+	}
+	return 0, errors.New("malformed MQTT packet length")
+}
+
+// appendString appends s with its 16-bit length
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
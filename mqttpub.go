@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/mqtt"
+	"github.com/micha/cs-ingame-translate/output"
+)
+
+// mqttAlert is the topic, under the prefix, that also gets translations a
+// keyword alert highlighted
+const mqttAlert = "alert"
+
+// startMQTT publishes events as JSON to <prefix>/<kind>, all of them or
+// those of kinds, and highlighted translations to <prefix>/alert as well.
+// <prefix>/status reads online while connected. The returned function
+// disconnects.
+func startMQTT(cfg mqtt.Config, prefix string, kinds []string) (stop func(), err error) {
+	if cfg.ClientID == "" {
+		cfg.ClientID = fmt.Sprintf("cs-translate-%d", os.Getpid())
+	}
+	cfg.Status = prefix + "/status"
+	client, err := mqtt.Start(cfg)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool)
+	for _, k := range kinds {
+		allowed[k] = true
+	}
+	publish := func(topic string, e events.Event) {
+		if len(allowed) > 0 && !allowed[topic] {
+			return
+		}
+		payload, err := json.Marshal(events.Fields(e))
+		if err != nil {
+			return
+		}
+		client.Publish(mqtt.Message{Topic: prefix + "/" + topic, Payload: payload})
+	}
+	closeSink := output.Subscribe(bus, "mqtt", output.Func(func(e events.Event) {
+		publish(e.Kind(), e)
+		if t, ok := e.(events.TranslationDone); ok && t.Highlight {
+			publish(mqttAlert, e)
+		}
+	}))
+	return func() {
+		closeSink()
+		client.Close()
+	}, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/mqtt"
+)
+
+// Events are published as JSON to a topic per kind on a mock broker,
+// highlighted translations to the alert topic as well, and what is published
+// after the broker restarts arrives once reconnected
+func TestMQTTPublish(t *testing.T) {
+	fake, err := fakegame.NewMQTT("home", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	stop, err := startMQTT(mqtt.Config{Broker: fake.Addr, Username: "home", Password: "secret", Retry: 50 * time.Millisecond}, "cs", []string{"translation_done", "alert", "round_started"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped := false
+	defer func() {
+		if !stopped {
+			stop()
+		}
+	}()
+
+	// published waits for n messages and lists their topics
+	published := func(n int) ([]mqtt.Message, []string, error) {
+		deadline := time.Now().Add(testTimeout)
+		for {
+			messages := fake.Messages()
+			var topics []string
+			for _, m := range messages {
+				topics = append(topics, m.Topic)
+			}
+			if len(messages) >= n {
+				return messages, topics, nil
+			}
+			if time.Now().After(deadline) {
+				return messages, topics, fmt.Errorf("broker got %q, want %d messages", topics, n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	bus.Publish(events.MatchStarted{Map: "de_dust2"})
+	bus.Publish(events.TranslationDone{Source: "voice", Player: "ivan", Original: "бомба на А", Translated: "bomb on A", Highlight: true})
+	messages, topics, err := published(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cs/status", "cs/translation_done", "cs/alert"}; !slices.Equal(topics, want) {
+		t.Fatalf("published to %q, want %q", topics, want)
+	}
+	if string(messages[0].Payload) != "online" || !messages[0].Retain {
+		t.Fatalf("status %q (retained %v), want online retained", messages[0].Payload, messages[0].Retain)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(messages[1].Payload, &fields); err != nil {
+		t.Fatalf("payload %q: %v", messages[1].Payload, err)
+	}
+	if fields["event"] != "translation_done" || fields["translated"] != "bomb on A" || fields["highlight"] != true {
+		t.Fatalf("payload %s is not the translation", messages[1].Payload)
+	}
+
+	fake.Drop()
+	deadline := time.Now().Add(testTimeout)
+	for fake.Connects() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("did not reconnect after the broker dropped the connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	bus.Publish(events.RoundStarted{Round: 3})
+	if _, topics, err = published(5); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cs/status", "cs/round_started"}; !slices.Equal(topics[3:], want) {
+		t.Fatalf("published %q after reconnecting, want %q", topics[3:], want)
+	}
+	stop()
+	stopped = true
+	if messages, _, err = published(6); err != nil {
+		t.Fatal(err)
+	}
+	if last := messages[len(messages)-1]; last.Topic != "cs/status" || string(last.Payload) != "offline" {
+		t.Fatalf("last message %s %q, want cs/status offline", last.Topic, last.Payload)
+	}
+}
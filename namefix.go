@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/parser"
+)
+
+// nameHints are player names containing ':' that were taught with /fix
+var nameHints []string
+
+// lastChat is the most recent chat message, the one /fix corrects, and
+// lastChatLog the console log it came from
+var (
+	lastChat    *parser.ChatMessage
+	lastChatLog string
+)
+
+// parseChat parses a line of logPath as chat, applying the learned name
+// hints
+func parseChat(line, logPath string) *parser.ChatMessage {
+	msg := parser.ParseLine(line)
+	if msg != nil {
+		parser.ApplyNameHints(msg, nameHints)
+		lastChat, lastChatLog = msg, logPath
+	}
+	return msg
+}
+
+// readCommands delivers lines typed into the terminal while a mode runs
+func readCommands(scanner *bufio.Scanner) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+	}()
+	return lines
+}
+
+// nameFixer corrects a chat line whose player name was cut at a colon. It is
+// driven by /fix typed into the terminal.
+type nameFixer struct {
+	asking *parser.ChatMessage // waiting for the user to type the name
+}
+
+func (f *nameFixer) handle(line string) {
+	if f.asking != nil {
+		msg := f.asking
+		f.asking = nil
+		if line == "" {
+			fmt.Println("Fix cancelled.")
+			return
+		}
+		f.teach(msg, line)
+		return
+	}
+
+	cmd, arg, _ := strings.Cut(line, " ")
+	switch cmd {
+	case "":
+	case "/fix":
+		if lastChat == nil {
+			fmt.Println("No chat message to fix yet.")
+			return
+		}
+		if arg = strings.TrimSpace(arg); arg != "" {
+			f.teach(lastChat, arg)
+			return
+		}
+		fmt.Printf("Last chat line: %s\n", parser.ChatBody(lastChat.OriginalText))
+		fmt.Print("Type the player's full name as it appears before the message (empty cancels): ")
+		f.asking = lastChat
+	default:
+		if strings.HasPrefix(cmd, "/") {
+			fmt.Println("Commands: /fix [player name]  correct where the last chat line's name ends")
+			fmt.Println("          /pause, /resume    stop and restart capture and translation (or press F8)")
+			fmt.Println("          /twitch [on|off]   pause or resume posting translations to Twitch chat")
+			fmt.Println("          /telegram [on|off] pause or resume mirroring translations to Telegram")
+		}
+	}
+}
+
+// teach re-splits msg with name, remembers the name for later lines and
+// translates the corrected message again
+func (f *nameFixer) teach(msg *parser.ChatMessage, name string) {
+	fixed := *msg
+	if !parser.ApplyNameHints(&fixed, []string{name}) {
+		fmt.Printf("The last line does not start with '%s: '; nothing changed.\n", name)
+		return
+	}
+	if !slices.Contains(nameHints, name) {
+		nameHints = append(nameHints, name)
+		saveNameHints()
+	}
+	fmt.Printf("Learned player name '%s'.\n", name)
+	lastChat = &fixed
+	bus.Publish(chatEvent(&fixed, lastChatLog))
+}
+
+// saveNameHints stores the learned names in the settings file. The file is
+// read again so command line flags are not written into it.
+func saveNameHints() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Warn("Player name not saved", "err", err)
+		return
+	}
+	cfg.NameHints = nameHints
+	if err := config.Save(cfg); err != nil {
+		slog.Warn("Player name not saved", "err", err)
+	}
+}
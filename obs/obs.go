@@ -0,0 +1,429 @@
+// Package obs talks to OBS Studio through its built-in obs-websocket server
+// (protocol version 5): it follows the recording state, so what is said
+// during a match can be timed against the video, and shows text in a text
+// source or as stream captions.
+package obs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// PasswordEnv holds the obs-websocket password. It is never read from the
+// settings file, so it cannot leak through /api/config.
+const PasswordEnv = "CS_TRANSLATE_OBS_PASSWORD"
+
+// DefaultAddr is where OBS listens unless changed in Tools > WebSocket
+// Server Settings
+const DefaultAddr = "localhost:4455"
+
+// ErrAuth is returned when OBS asks for a password and the one given is
+// missing or wrong
+var ErrAuth = errors.New("obs-websocket password missing or wrong (set " + PasswordEnv + ")")
+
+// Message opcodes and the event subscription of obs-websocket 5
+const (
+	opHello           = 0
+	opIdentify        = 1
+	opIdentified      = 2
+	opEvent           = 5
+	opRequest         = 6
+	opRequestResponse = 7
+
+	rpcVersion = 1
+	subOutputs = 1 << 6
+
+	// closeAuthFailed is the close code OBS sends after a wrong password
+	closeAuthFailed = 4009
+)
+
+// dialTimeout bounds connecting and identifying; requestTimeout one request
+const (
+	dialTimeout    = 5 * time.Second
+	requestTimeout = 5 * time.Second
+)
+
+// State is a change of the recording output
+type State string
+
+// Recording states; OBS also reports starting and stopping, which are left
+// out
+const (
+	Started State = "started"
+	Paused  State = "paused"
+	Resumed State = "resumed"
+	Stopped State = "stopped"
+)
+
+var outputStates = map[string]State{
+	"OBS_WEBSOCKET_OUTPUT_STARTED": Started,
+	"OBS_WEBSOCKET_OUTPUT_PAUSED":  Paused,
+	"OBS_WEBSOCKET_OUTPUT_RESUMED": Resumed,
+	"OBS_WEBSOCKET_OUTPUT_STOPPED": Stopped,
+}
+
+// RecordEvent is a change of the recording, stamped when it arrived
+type RecordEvent struct {
+	State State
+	Path  string // the video file; OBS sends it on start and stop
+	At    time.Time
+}
+
+// RecordStatus is the recording as OBS reports it on request
+type RecordStatus struct {
+	Active   bool
+	Paused   bool
+	Duration time.Duration // recorded so far, without pauses
+}
+
+type message struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+type hello struct {
+	Authentication *struct {
+		Challenge string `json:"challenge"`
+		Salt      string `json:"salt"`
+	} `json:"authentication"`
+}
+
+type identify struct {
+	RPCVersion         int    `json:"rpcVersion"`
+	Authentication     string `json:"authentication,omitempty"`
+	EventSubscriptions int    `json:"eventSubscriptions"`
+}
+
+type event struct {
+	EventType string          `json:"eventType"`
+	EventData json.RawMessage `json:"eventData"`
+}
+
+type recordStateChanged struct {
+	OutputState string `json:"outputState"`
+	OutputPath  string `json:"outputPath"`
+}
+
+type request struct {
+	RequestType string `json:"requestType"`
+	RequestID   string `json:"requestId"`
+	RequestData any    `json:"requestData,omitempty"`
+}
+
+type response struct {
+	RequestID     string `json:"requestId"`
+	RequestStatus struct {
+		Result  bool   `json:"result"`
+		Code    int    `json:"code"`
+		Comment string `json:"comment"`
+	} `json:"requestStatus"`
+	ResponseData json.RawMessage `json:"responseData"`
+}
+
+// Request status codes callers act on
+const (
+	StatusOutputNotRunning = 501 // e.g. a caption while not streaming
+	StatusResourceNotFound = 600 // e.g. no input of that name
+)
+
+// RequestError is a request OBS refused
+type RequestError struct {
+	Type    string // the request, e.g. "SetInputSettings"
+	Code    int
+	Comment string
+}
+
+func (e *RequestError) Error() string {
+	if e.Comment == "" {
+		return fmt.Sprintf("%s failed (code %d)", e.Type, e.Code)
+	}
+	return fmt.Sprintf("%s failed: %s (code %d)", e.Type, e.Comment, e.Code)
+}
+
+// IsStatus reports whether err is a request OBS refused with code
+func IsStatus(err error, code int) bool {
+	var re *RequestError
+	return errors.As(err, &re) && re.Code == code
+}
+
+// Client is a connection to obs-websocket
+type Client struct {
+	conn   *websocket.Conn
+	events chan RecordEvent
+
+	wmu     sync.Mutex
+	mu      sync.Mutex
+	next    int
+	pending map[string]chan response
+	err     error // why the connection ended
+	done    chan struct{}
+	quit    chan struct{} // closed by Close
+	once    sync.Once
+}
+
+// Dial connects to obs-websocket at addr, e.g. localhost:4455, and
+// identifies with password, which may be empty when OBS does not ask for
+// one
+func Dial(ctx context.Context, addr, password string) (*Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, "ws://"+addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to OBS at %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+	if err := handshake(conn, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	c := &Client{
+		conn:    conn,
+		events:  make(chan RecordEvent, 8),
+		pending: make(map[string]chan response),
+		done:    make(chan struct{}),
+		quit:    make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// handshake answers Hello with Identify and waits for Identified
+func handshake(conn *websocket.Conn, password string) error {
+	var h hello
+	if err := readOp(conn, opHello, &h); err != nil {
+		return err
+	}
+	id := identify{RPCVersion: rpcVersion, EventSubscriptions: subOutputs}
+	if h.Authentication != nil {
+		if password == "" {
+			return ErrAuth
+		}
+		id.Authentication = Auth(password, h.Authentication.Salt, h.Authentication.Challenge)
+	}
+	if err := conn.WriteJSON(message{Op: opIdentify, D: mustJSON(id)}); err != nil {
+		return fmt.Errorf("failed to identify to OBS: %w", err)
+	}
+	err := readOp(conn, opIdentified, nil)
+	if websocket.IsCloseError(err, closeAuthFailed) {
+		return ErrAuth
+	}
+	return err
+}
+
+// Auth is the authentication string obs-websocket expects for password
+func Auth(password, salt, challenge string) string {
+	secret := sha256.Sum256([]byte(password + salt))
+	auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
+
+func readOp(conn *websocket.Conn, op int, v any) error {
+	var m message
+	if err := conn.ReadJSON(&m); err != nil {
+		return err
+	}
+	if m.Op != op {
+		return fmt.Errorf("OBS sent opcode %d, want %d", m.Op, op)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(m.D, v)
+}
+
+func mustJSON(v any) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// read hands recording events to Events and responses to their requests
+// until the connection ends
+func (c *Client) read() {
+	var err error
+	defer func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+		close(c.events)
+	}()
+	for {
+		var m message
+		if err = c.conn.ReadJSON(&m); err != nil {
+			return
+		}
+		switch m.Op {
+		case opEvent:
+			var e event
+			if json.Unmarshal(m.D, &e) != nil || e.EventType != "RecordStateChanged" {
+				continue
+			}
+			var data recordStateChanged
+			if json.Unmarshal(e.EventData, &data) != nil {
+				continue
+			}
+			if state, ok := outputStates[data.OutputState]; ok {
+				select {
+				case c.events <- RecordEvent{State: state, Path: data.OutputPath, At: time.Now()}:
+				case <-c.quit:
+					return
+				}
+			}
+		case opRequestResponse:
+			var r response
+			if json.Unmarshal(m.D, &r) != nil {
+				continue
+			}
+			c.mu.Lock()
+			ch := c.pending[r.RequestID]
+			delete(c.pending, r.RequestID)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- r
+			}
+		}
+	}
+}
+
+// Events delivers changes of the recording. It is closed when the
+// connection ends; Err then says why.
+func (c *Client) Events() <-chan RecordEvent {
+	return c.events
+}
+
+// Err is why the connection ended, once Events is closed
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// RecordStatus asks OBS whether it is recording and for how long
+func (c *Client) RecordStatus(ctx context.Context) (RecordStatus, error) {
+	var data struct {
+		OutputActive   bool  `json:"outputActive"`
+		OutputPaused   bool  `json:"outputPaused"`
+		OutputDuration int64 `json:"outputDuration"` // milliseconds
+	}
+	if err := c.call(ctx, "GetRecordStatus", nil, &data); err != nil {
+		return RecordStatus{}, err
+	}
+	return RecordStatus{
+		Active:   data.OutputActive,
+		Paused:   data.OutputPaused,
+		Duration: time.Duration(data.OutputDuration) * time.Millisecond,
+	}, nil
+}
+
+// textKinds are the text source kinds of Windows and of Linux and macOS,
+// without their version suffix
+var textKinds = []string{"text_gdiplus", "text_ft2_source"}
+
+// SetText sets the text of the text source named input
+func (c *Client) SetText(ctx context.Context, input, text string) error {
+	return c.call(ctx, "SetInputSettings", map[string]any{
+		"inputName":     input,
+		"inputSettings": map[string]string{"text": text},
+	}, nil)
+}
+
+// EnsureTextSource checks that an input named input exists and, when it
+// does not and scene is set, adds an empty text source of that name to
+// scene
+func (c *Client) EnsureTextSource(ctx context.Context, scene, input string) error {
+	err := c.call(ctx, "GetInputSettings", map[string]string{"inputName": input}, nil)
+	if scene == "" || !IsStatus(err, StatusResourceNotFound) {
+		return err
+	}
+	var kinds struct {
+		InputKinds []string `json:"inputKinds"`
+	}
+	if err := c.call(ctx, "GetInputKindList", nil, &kinds); err != nil {
+		return err
+	}
+	for _, kind := range kinds.InputKinds {
+		for _, text := range textKinds {
+			if strings.HasPrefix(kind, text) {
+				return c.call(ctx, "CreateInput", map[string]any{
+					"sceneName":     scene,
+					"inputName":     input,
+					"inputKind":     kind,
+					"inputSettings": map[string]string{"text": ""},
+				}, nil)
+			}
+		}
+	}
+	return fmt.Errorf("OBS has no text source kind among %v", kinds.InputKinds)
+}
+
+// SendCaption sends text as a closed caption of the stream; it fails with
+// StatusOutputNotRunning while OBS is not streaming
+func (c *Client) SendCaption(ctx context.Context, text string) error {
+	return c.call(ctx, "SendStreamCaption", map[string]string{"captionText": text}, nil)
+}
+
+// call sends a request with data, which may be nil, and decodes its
+// response data into v
+func (c *Client) call(ctx context.Context, requestType string, data, v any) error {
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	c.next++
+	id := strconv.Itoa(c.next)
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	c.wmu.Lock()
+	err := c.conn.WriteJSON(message{Op: opRequest, D: mustJSON(request{RequestType: requestType, RequestID: id, RequestData: data})})
+	c.wmu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", requestType, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	select {
+	case r := <-ch:
+		if !r.RequestStatus.Result {
+			return &RequestError{Type: requestType, Code: r.RequestStatus.Code, Comment: r.RequestStatus.Comment}
+		}
+		if v == nil || len(r.ResponseData) == 0 {
+			return nil
+		}
+		return json.Unmarshal(r.ResponseData, v)
+	case <-c.done:
+		return fmt.Errorf("%s failed: connection to OBS closed", requestType)
+	case <-ctx.Done():
+		return fmt.Errorf("%s failed: %w", requestType, ctx.Err())
+	}
+}
+
+// Close ends the connection
+func (c *Client) Close() error {
+	c.once.Do(func() { close(c.quit) })
+	c.wmu.Lock()
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.wmu.Unlock()
+	return c.conn.Close()
+}
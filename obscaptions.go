@@ -0,0 +1,195 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/obs"
+)
+
+// obsRequestTimeout bounds one update of OBS, so a stalled OBS does not hold
+// up newer translations
+const obsRequestTimeout = 2 * time.Second
+
+// obsCaptions shows the latest translations in an OBS text source and sends
+// each as a closed caption of the stream. Updates go out from run, in the
+// order they happened; a translation arriving while OBS is not connected
+// only shows once it is.
+type obsCaptions struct {
+	link     *obsLink
+	source   string // text source; "" for none
+	scene    string
+	captions bool
+	chat     bool
+	lines    int
+	hold     time.Duration
+
+	mu      sync.Mutex
+	shown   []captionLine // newest last
+	text    string        // what the text source was last set to
+	stale   bool          // the text source needs setting even if text is unchanged
+	pending []string      // captions not sent yet
+	wake    chan struct{}
+}
+
+type captionLine struct {
+	text string
+	at   time.Time
+}
+
+func newOBSCaptions(cfg config.OBSConfig, link *obsLink) *obsCaptions {
+	return &obsCaptions{
+		link:     link,
+		source:   cfg.Source,
+		scene:    cfg.Scene,
+		captions: cfg.Captions,
+		chat:     cfg.Chat,
+		lines:    max(cfg.Lines, 1),
+		hold:     time.Duration(cfg.Hold),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+func (c *obsCaptions) handle(e events.Event) {
+	t, ok := e.(events.TranslationDone)
+	if !ok || hidden(t) {
+		return
+	}
+	entry, ok := newTranscriptEntry(t, time.Now())
+	if !ok || entry.Source == "chat" && !c.chat {
+		return
+	}
+	text := cmp.Or(entry.Translated, entry.Original)
+	if entry.Player != "" {
+		text = entry.Player + ": " + text
+	}
+	text = strings.Join(strings.Fields(text), " ")
+
+	c.mu.Lock()
+	c.shown = append(c.shown, captionLine{text, time.Now()})
+	if len(c.shown) > c.lines {
+		c.shown = c.shown[len(c.shown)-c.lines:]
+	}
+	if c.captions {
+		c.pending = append(c.pending, text)
+	}
+	c.mu.Unlock()
+	c.poke()
+}
+
+func (c *obsCaptions) poke() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// connected makes sure the text source exists and has it shown again on a
+// new connection
+func (c *obsCaptions) connected(ctx context.Context, client *obs.Client) {
+	if c.source != "" {
+		ctx, cancel := context.WithTimeout(ctx, obsRequestTimeout)
+		err := client.EnsureTextSource(ctx, c.scene, c.source)
+		cancel()
+		if err != nil {
+			slog.Warn("OBS text source not available; create a text source of that name or set -obs-scene", "source", c.source, "err", err)
+		}
+	}
+	c.mu.Lock()
+	c.stale = true
+	c.mu.Unlock()
+	c.poke()
+}
+
+// run sends updates to OBS as translations arrive and lines expire, until
+// ctx ends
+func (c *obsCaptions) run(ctx context.Context) {
+	for {
+		var expire <-chan time.Time
+		if next := c.nextExpiry(); !next.IsZero() {
+			expire = time.After(time.Until(next))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.wake:
+		case <-expire:
+		}
+		c.update(ctx)
+	}
+}
+
+// nextExpiry is when the oldest line shown is taken off; zero when none is
+func (c *obsCaptions) nextExpiry() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hold <= 0 || len(c.shown) == 0 {
+		return time.Time{}
+	}
+	return c.shown[0].at.Add(c.hold)
+}
+
+// update sets the text source to the lines still shown and sends the
+// pending captions
+func (c *obsCaptions) update(ctx context.Context) {
+	client := c.link.get()
+	c.mu.Lock()
+	if c.hold > 0 {
+		for len(c.shown) > 0 && time.Since(c.shown[0].at) >= c.hold {
+			c.shown = c.shown[1:]
+		}
+	}
+	lines := make([]string, len(c.shown))
+	for i, l := range c.shown {
+		lines[i] = l.text
+	}
+	text := strings.Join(lines, "\n")
+	changed := text != c.text || c.stale
+	captions := c.pending
+	c.pending = nil // captions of the past are not sent late
+	c.mu.Unlock()
+	if client == nil {
+		return
+	}
+
+	if c.source != "" && changed {
+		ctx, cancel := context.WithTimeout(ctx, obsRequestTimeout)
+		err := client.SetText(ctx, c.source, text)
+		cancel()
+		if err != nil {
+			slog.Debug("OBS text source not updated", "source", c.source, "err", err)
+		} else {
+			c.mu.Lock()
+			c.text, c.stale = text, false
+			c.mu.Unlock()
+		}
+	}
+	for _, caption := range captions {
+		ctx, cancel := context.WithTimeout(ctx, obsRequestTimeout)
+		err := client.SendCaption(ctx, caption)
+		cancel()
+		if err != nil && !obs.IsStatus(err, obs.StatusOutputNotRunning) {
+			slog.Debug("OBS caption not sent", "err", err)
+		}
+	}
+}
+
+// clear empties the text source, so no translation is left on screen after
+// cs-translate exits
+func (c *obsCaptions) clear() {
+	client := c.link.get()
+	if client == nil || c.source == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), obsRequestTimeout)
+	defer cancel()
+	if err := client.SetText(ctx, c.source, ""); err != nil {
+		slog.Debug("OBS text source not cleared", "source", c.source, "err", err)
+	}
+}
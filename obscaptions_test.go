@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/fakegame"
+)
+
+// Translations show in an OBS text source, which is added to its scene, the
+// newest last and each taken off after the hold; they are also stream
+// captions, chat is left out and the source is cleared on exit
+func TestOBSCaptions(t *testing.T) {
+	dir := t.TempDir()
+	fake := fakegame.NewOBS("")
+	defer fake.Close()
+	fake.SetStreaming(true)
+	stop := sync.OnceFunc(startOBS(config.OBSConfig{
+		Addr: fake.Addr, Source: "Translations", Scene: "Game",
+		Lines: 2, Hold: config.Duration(300 * time.Millisecond), Captions: true,
+	}, "", dir))
+	defer stop()
+	select {
+	case <-fake.Synced():
+	case <-time.After(testTimeout):
+		t.Fatal("cs-translate did not connect to OBS")
+	}
+	if got := fake.Scene("Game"); !slices.Equal(got, []string{"Translations"}) {
+		t.Fatalf("scene Game has %v, want the text source", got)
+	}
+
+	// showing waits until the text source reads want
+	showing := func(want string) error {
+		deadline := time.Now().Add(testTimeout)
+		for {
+			text, _ := fake.Text("Translations")
+			if text == want {
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("text source reads %q, want %q", text, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	for _, td := range []events.TranslationDone{
+		{Source: "voice", Player: "ivan", Original: "давай на б", Translated: "let's go B"},
+		{Source: "voice", Original: "one left", Translated: "one left"},
+		{Source: "chat", Team: "ALL", Player: "bob", Original: "gg", Translated: "gg"},
+		{Source: "voice", Player: "bob", Original: "rotate", Translated: "rotate"},
+	} {
+		bus.Publish(td)
+	}
+	if err := showing("one left\nbob: rotate"); err != nil {
+		t.Fatal(err)
+	}
+	if err := showing(""); err != nil {
+		t.Fatalf("after the hold: %v", err)
+	}
+	want := []string{"ivan: let's go B", "one left", "bob: rotate"}
+	if got := fake.Captions(); !slices.Equal(got, want) {
+		t.Fatalf("captions %q, want %q", got, want)
+	}
+
+	bus.Publish(events.TranslationDone{Source: "voice", Original: "last", Translated: "last"})
+	if err := showing("last"); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	if text, _ := fake.Text("Translations"); text != "" {
+		t.Fatalf("text source reads %q after exit, want it cleared", text)
+	}
+}
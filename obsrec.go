@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/obs"
+	"github.com/micha/cs-ingame-translate/subtitle"
+)
+
+// obsRetry is how often OBS is looked for while it is not running
+const obsRetry = 5 * time.Second
+
+// startOBS connects to OBS whenever it runs. With subtitles, it writes a
+// subtitle track next to each video when the recording stops; recordings OBS
+// does not name go to dir, or to the transcripts folder of the settings
+// directory when dir is empty. With a text source or captions, it shows the
+// latest translations. The returned function disconnects and saves the
+// subtitles of a recording still going.
+func startOBS(cfg config.OBSConfig, password, dir string) (stop func()) {
+	if dir == "" {
+		if settings, err := config.Dir(); err == nil {
+			dir = filepath.Join(settings, transcriptDir)
+		}
+	}
+	link := &obsLink{}
+	var unsubscribe []func()
+	var rec *vodRecorder
+	if cfg.Subtitles {
+		rec = &vodRecorder{dir: dir, format: cfg.Format, chat: cfg.Chat}
+		unsubscribe = append(unsubscribe, bus.Subscribe(rec.handle))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// Captions stop first, so the text source is cleared while connected
+	capsCtx, stopCaps := context.WithCancel(ctx)
+	capsDone := make(chan struct{})
+	var caps *obsCaptions
+	var connected func(context.Context, *obs.Client)
+	if cfg.Source != "" || cfg.Captions {
+		caps = newOBSCaptions(cfg, link)
+		unsubscribe = append(unsubscribe, bus.Subscribe(caps.handle))
+		connected = caps.connected
+		go func() {
+			defer close(capsDone)
+			caps.run(capsCtx)
+		}()
+	} else {
+		close(capsDone)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		followOBS(ctx, cfg.Addr, password, link, connected)
+	}()
+	return func() {
+		for _, u := range unsubscribe {
+			u()
+		}
+		stopCaps()
+		<-capsDone
+		if caps != nil {
+			caps.clear()
+		}
+		cancel()
+		<-done
+		if rec != nil {
+			rec.save()
+			rec.wg.Wait()
+		}
+	}
+}
+
+// obsLink is the current connection to OBS, nil while it is not connected
+type obsLink struct {
+	mu     sync.Mutex
+	client *obs.Client
+}
+
+func (l *obsLink) get() *obs.Client {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.client
+}
+
+func (l *obsLink) set(c *obs.Client) {
+	l.mu.Lock()
+	l.client = c
+	l.mu.Unlock()
+}
+
+// followOBS keeps link connected to OBS and publishes its recording state as
+// Recording events until ctx ends, reconnecting after OBS quits. connected,
+// if set, is called with each new connection.
+func followOBS(ctx context.Context, addr, password string, link *obsLink, connected func(context.Context, *obs.Client)) {
+	recording, warned := false, false
+	for {
+		c, err := obs.Dial(ctx, addr, password)
+		switch {
+		case errors.Is(err, obs.ErrAuth):
+			slog.Warn("Not connecting to OBS", "err", err)
+			return
+		case err != nil:
+			if !warned && ctx.Err() == nil {
+				slog.Warn("OBS not reachable; trying again while it starts", "addr", addr, "err", err)
+				warned = true
+			}
+		default:
+			warned = false
+			link.set(c)
+			if connected != nil {
+				connected(ctx, c)
+			}
+			recording = followRecording(ctx, c, recording)
+			link.set(nil)
+			c.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(obsRetry):
+		}
+	}
+}
+
+// followRecording publishes the recording state of one connection until it
+// ends, starting from a recording already going or stopped while OBS was not
+// connected. It returns whether OBS is still recording as far as it knows.
+func followRecording(ctx context.Context, c *obs.Client, recording bool) bool {
+	status, err := c.RecordStatus(ctx)
+	if err != nil {
+		slog.Warn("OBS recording state unknown", "err", err)
+		return recording
+	}
+	slog.Info("Connected to OBS", "recording", status.Active)
+	now := time.Now()
+	switch {
+	case status.Active && !recording:
+		bus.Publish(events.Recording{State: string(obs.Started), At: now.Add(-status.Duration)})
+		if status.Paused {
+			bus.Publish(events.Recording{State: string(obs.Paused), At: now})
+		}
+	case !status.Active && recording:
+		bus.Publish(events.Recording{State: string(obs.Stopped), At: now})
+	}
+	recording = status.Active
+
+	for {
+		select {
+		case <-ctx.Done():
+			return recording
+		case e, ok := <-c.Events():
+			if !ok {
+				slog.Info("Lost connection to OBS", "err", c.Err())
+				return recording
+			}
+			if e.State == obs.Started && recording {
+				continue // already told by the record status
+			}
+			bus.Publish(events.Recording{State: string(e.State), Path: e.Path, At: e.At})
+			recording = e.State != obs.Stopped
+		}
+	}
+}
+
+// vodRecorder times chat and voice against the video OBS is recording, the
+// pauses left out, and writes them as subtitles when it stops
+type vodRecorder struct {
+	dir    string
+	format string
+	chat   bool // chat too, not only voice
+
+	mu       sync.Mutex
+	active   bool
+	start    time.Time
+	path     string
+	pausedAt time.Time // zero unless paused
+	pauses   [][2]time.Time
+	entries  []transcriptEntry // timed on the video's clock: start plus the offset
+	wg       sync.WaitGroup
+}
+
+func (r *vodRecorder) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.Recording:
+		r.recording(e)
+	case events.TranslationDone:
+		entry, ok := newTranscriptEntry(e, time.Now())
+		if !ok || entry.Source == "chat" && !r.chat {
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if !r.active {
+			return
+		}
+		if offset, ok := r.offset(entry.Time); ok {
+			entry.Time = r.start.Add(offset)
+			r.entries = append(r.entries, entry)
+		}
+	}
+}
+
+func (r *vodRecorder) recording(e events.Recording) {
+	switch obs.State(e.State) {
+	case obs.Started:
+		r.save()
+		r.mu.Lock()
+		r.active, r.start, r.path = true, e.At, e.Path
+		r.mu.Unlock()
+	case obs.Paused:
+		r.mu.Lock()
+		if r.pausedAt.IsZero() {
+			r.pausedAt = e.At
+		}
+		r.mu.Unlock()
+	case obs.Resumed:
+		r.mu.Lock()
+		if !r.pausedAt.IsZero() {
+			r.pauses = append(r.pauses, [2]time.Time{r.pausedAt, e.At})
+			r.pausedAt = time.Time{}
+		}
+		r.mu.Unlock()
+	case obs.Stopped:
+		r.mu.Lock()
+		if e.Path != "" {
+			r.path = e.Path
+		}
+		r.mu.Unlock()
+		r.save()
+	}
+}
+
+// offset is where t falls in the video; false while it was paused or before
+// it started
+func (r *vodRecorder) offset(t time.Time) (time.Duration, bool) {
+	if t.Before(r.start) || !r.pausedAt.IsZero() && !t.Before(r.pausedAt) {
+		return 0, false
+	}
+	offset := t.Sub(r.start)
+	for _, p := range r.pauses {
+		switch {
+		case !t.Before(p[1]):
+			offset -= p[1].Sub(p[0])
+		case !t.Before(p[0]):
+			return 0, false
+		}
+	}
+	return offset, true
+}
+
+// save ends the current recording and writes its subtitles in the
+// background
+func (r *vodRecorder) save() {
+	r.mu.Lock()
+	active, start, path, entries := r.active, r.start, r.path, r.entries
+	r.active, r.start, r.path, r.entries = false, time.Time{}, "", nil
+	r.pausedAt, r.pauses = time.Time{}, nil
+	r.mu.Unlock()
+	if !active || len(entries) == 0 {
+		return
+	}
+
+	dest := filepath.Join(r.dir, start.Format("2006-01-02_150405")+"_recording."+r.format)
+	if path != "" {
+		dest = strings.TrimSuffix(path, filepath.Ext(path)) + "." + r.format
+	}
+	cues := transcriptCues(entries, start, "both")
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := writeSubtitles(dest, r.format, cues); err != nil {
+			slog.Warn("Subtitles of the recording not saved", "err", err)
+			return
+		}
+		bus.Publish(events.SubtitlesSaved{Path: dest, Video: path, Cues: len(cues)})
+	}()
+}
+
+// writeSubtitles writes cues to a new file at path
+func writeSubtitles(path, format string, cues []subtitle.Cue) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := subtitle.Write(f, format, cues); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
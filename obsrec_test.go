@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/obs"
+	"github.com/micha/cs-ingame-translate/subtitle"
+)
+
+// A mock OBS asks for a password and records with a pause; voice said while
+// it records is timed against the video, what is said while paused is left
+// out, and the subtitles are saved next to the video
+func TestOBSRecording(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	fake := fakegame.NewOBS("hunter2")
+	defer fake.Close()
+	if _, err := obs.Dial(ctx, fake.Addr, "wrong"); !errors.Is(err, obs.ErrAuth) {
+		t.Fatalf("wrong password gave %v, want ErrAuth", err)
+	}
+
+	stop := startOBS(config.OBSConfig{Addr: fake.Addr, Subtitles: true, Format: subtitle.FormatSRT}, "hunter2", dir)
+	defer stop()
+	// After the recorder, so it has seen each event when the test goes on
+	recordings := make(chan events.Event, 8)
+	unsubscribe := bus.Subscribe(func(e events.Event) {
+		switch e.(type) {
+		case events.Recording, events.SubtitlesSaved:
+			recordings <- e
+		}
+	})
+	defer unsubscribe()
+	next := func() (events.Event, error) {
+		select {
+		case e := <-recordings:
+			return e, nil
+		case <-time.After(testTimeout):
+			return nil, errors.New("timed out waiting for OBS")
+		}
+	}
+	select {
+	case <-fake.Synced():
+	case <-time.After(testTimeout):
+		t.Fatal("cs-translate did not identify to OBS")
+	}
+
+	video := filepath.Join(dir, "2026-02-02 21-00-00.mkv")
+	var at []time.Time // when each recording event arrived
+	for _, step := range []func(){
+		func() { fake.StartRecording(video) },
+		func() {
+			bus.Publish(events.TranslationDone{Source: "voice", Player: "ivan", Original: "давай на б", Translated: "let's go B", Spoken: at[0].Add(2 * time.Second), Duration: 1500 * time.Millisecond})
+			fake.PauseRecording()
+		},
+		func() {
+			bus.Publish(events.TranslationDone{Source: "voice", Original: "while paused", Translated: "while paused", Spoken: at[1]})
+			fake.ResumeRecording()
+		},
+		func() {
+			bus.Publish(events.TranslationDone{Source: "voice", Original: "one left", Translated: "one left", Spoken: at[2].Add(time.Second), Duration: time.Second})
+			bus.Publish(events.TranslationDone{Source: "chat", Team: "ALL", Player: "bob", Original: "gg", Translated: "gg"})
+			fake.StopRecording(video)
+		},
+	} {
+		step()
+		e, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, ok := e.(events.Recording)
+		if !ok {
+			t.Fatalf("got %T before the recording stopped", e)
+		}
+		at = append(at, rec.At)
+	}
+	e, err := next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, ok := e.(events.SubtitlesSaved)
+	want := strings.TrimSuffix(video, ".mkv") + ".srt"
+	if !ok || saved.Path != want || saved.Video != video || saved.Cues != 2 {
+		t.Fatalf("got %+v, want 2 subtitles saved to %s", e, want)
+	}
+	text, err := os.ReadFile(saved.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := at[1].Sub(at[0]) + time.Second // the pause is cut from the video
+	var sb strings.Builder
+	subtitle.Write(&sb, subtitle.FormatSRT, []subtitle.Cue{
+		{Start: 2 * time.Second, End: 3500 * time.Millisecond, Text: "ivan: давай на б\nlet's go B"},
+		{Start: resumed, End: resumed + time.Second, Text: "one left"},
+	})
+	if string(text) != sb.String() {
+		t.Fatalf("subtitles are %q, want %q", text, sb.String())
+	}
+}
@@ -0,0 +1,126 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// ErrNoClipboard is returned when no clipboard tool is installed
+var ErrNoClipboard = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+// Clipboard copies the most recent translation to the system clipboard, so
+// it can be pasted into the game. Copying runs in the background; when
+// translations arrive faster than they can be copied only the newest is.
+type Clipboard struct {
+	keep func(events.TranslationDone) bool
+
+	mu      sync.Mutex
+	pending string
+	copyErr error // from the last copy, reported by Handle
+	wake    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewClipboard returns a clipboard sink for the translations keep accepts
+func NewClipboard(keep func(events.TranslationDone) bool) (*Clipboard, error) {
+	if _, err := clipboardCommand(); err != nil {
+		return nil, err
+	}
+	c := &Clipboard{keep: keep, wake: make(chan struct{}, 1), done: make(chan struct{})}
+	c.wg.Add(1)
+	go c.run()
+	return c, nil
+}
+
+// Handle queues t for copying. It returns the error of the last copy until
+// a copy succeeds again.
+func (c *Clipboard) Handle(e events.Event) error {
+	c.mu.Lock()
+	err := c.copyErr
+	c.mu.Unlock()
+	t, ok := e.(events.TranslationDone)
+	if !ok || t.Err != nil || t.Superseded || t.Translated == "" || !c.keep(t) {
+		return err
+	}
+	c.mu.Lock()
+	c.pending = t.Translated
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+func (c *Clipboard) run() {
+	defer c.wg.Done()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.wake:
+		}
+		c.mu.Lock()
+		text := c.pending
+		c.mu.Unlock()
+		err := Copy(text)
+		c.mu.Lock()
+		c.copyErr = err
+		c.mu.Unlock()
+	}
+}
+
+// Close waits for a copy in progress to finish
+func (c *Clipboard) Close() error {
+	close(c.done)
+	c.wg.Wait()
+	return nil
+}
+
+// Copy puts text on the system clipboard
+func Copy(text string) error {
+	args, err := clipboardCommand()
+	if err != nil {
+		return err
+	}
+	cmd := execwrap.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// clipboardCommand returns the command that reads the clipboard contents
+// from stdin on this platform
+func clipboardCommand() ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"pbcopy"}, nil
+	case "windows":
+		// clip.exe mangles UTF-8, so read stdin as UTF-8 in PowerShell
+		return []string{"powershell", "-NoProfile", "-Command",
+			"[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())"}, nil
+	}
+	candidates := [][]string{
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append([][]string{{"wl-copy"}}, candidates...)
+	}
+	for _, c := range candidates {
+		if _, err := execwrap.LookPath(c[0]); err == nil {
+			return c, nil
+		}
+	}
+	return nil, ErrNoClipboard
+}
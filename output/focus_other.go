@@ -0,0 +1,37 @@
+//go:build !windows
+
+package output
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// focusedProcess returns the process name of the focused window: from
+// xdotool on X11 and System Events on macOS. Wayland does not tell.
+func focusedProcess() (string, error) {
+	if runtime.GOOS == "darwin" {
+		out, err := execwrap.Command("osascript", "-e",
+			`tell application "System Events" to get name of first process whose frontmost is true`).Output()
+		if err != nil {
+			return "", fmt.Errorf("osascript: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if os.Getenv("DISPLAY") == "" {
+		return "", fmt.Errorf("no X11 display")
+	}
+	out, err := execwrap.Command("xdotool", "getactivewindow", "getwindowpid").Output()
+	if err != nil {
+		return "", fmt.Errorf("xdotool: %w", err)
+	}
+	comm, err := os.ReadFile("/proc/" + strings.TrimSpace(string(out)) + "/comm")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(comm)), nil
+}
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/micha/cs-ingame-translate/events"
+)
+
+// GameChatFile is the cfg GameChat writes: exec cs_translate_chat in CS2
+// posts its translation
+const GameChatFile = "cs_translate_chat.cfg"
+
+// gameChatIdle is the cfg while there is no translation to post, so the
+// bound key does not repeat a stale one after cs-translate exits
+const gameChatIdle = "echo \"cs-translate: no translation to post\"\n"
+
+// GameChat writes the most recent translation into a CS2 cfg file as a say
+// or say_team command, so that a key bound to exec it relays the translation
+// to teammates who do not run cs-translate
+type GameChat struct {
+	path     string
+	command  string
+	maxBytes int
+	keep     func(events.TranslationDone) bool
+
+	mu     sync.Mutex
+	posted []string // recent lines written, skipped when they come back as chat
+}
+
+// NewGameChat returns a game chat sink writing GameChatFile into cfgDir, the
+// game's game/csgo/cfg folder. command is say or say_team; longer lines are
+// cut to maxBytes.
+func NewGameChat(cfgDir, command string, maxBytes int, keep func(events.TranslationDone) bool) (*GameChat, error) {
+	if command != "say" && command != "say_team" {
+		return nil, fmt.Errorf("game chat command must be say or say_team, not %q", command)
+	}
+	g := &GameChat{path: filepath.Join(cfgDir, GameChatFile), command: command, maxBytes: maxBytes, keep: keep}
+	if err := g.write(gameChatIdle); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Path is the cfg file written
+func (g *GameChat) Path() string {
+	return g.path
+}
+
+// Handle writes t as the line the bound key posts
+func (g *GameChat) Handle(e events.Event) error {
+	t, ok := e.(events.TranslationDone)
+	if !ok || t.Err != nil || t.Superseded || t.Translated == "" || !g.keep(t) {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// What the key posted shows up in the console log as the player's own
+	// chat; translating it again would replace the line with itself
+	if t.Source == "chat" && slices.Contains(g.posted, chatSafe(t.Original)) {
+		return nil
+	}
+	text := t.Translated
+	if t.Player != "" && t.Source != "talk" {
+		text = t.Player + ": " + text
+	}
+	line := cutBytes(chatSafe(text), g.maxBytes)
+	g.posted = append(g.posted, line)
+	if len(g.posted) > 8 {
+		g.posted = g.posted[1:]
+	}
+	return g.write(fmt.Sprintf("%s \"%s\"\n", g.command, line))
+}
+
+// Close leaves the idle cfg behind
+func (g *GameChat) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.write(gameChatIdle)
+}
+
+// write replaces the cfg at once, so the game never runs half of it
+func (g *GameChat) write(content string) error {
+	tmp := g.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, g.path)
+}
+
+// chatSafe makes s one quoted console argument: quotes would end it and
+// semicolons start another command, which a message could use to run any
+// console command
+func chatSafe(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '"':
+			return '\''
+		case r == ';':
+			return ','
+		case r < ' ' || r == 0x7f:
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// cutBytes shortens s to at most n bytes, ending in "..." when cut, without
+// splitting a character
+func cutBytes(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	end := max(n-3, 0)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return strings.TrimSpace(s[:end]) + "..."
+}
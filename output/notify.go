@@ -0,0 +1,155 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// notifyQueue bounds notifications waiting to be shown; more are dropped
+const notifyQueue = 8
+
+// ErrNoNotifier is returned when desktop notifications cannot be sent
+var ErrNoNotifier = errors.New("no desktop notification tool found (install notify-send from libnotify)")
+
+// notification is one message to pop up
+type notification struct {
+	title, body string
+}
+
+// Notifier shows translations as desktop notifications. With Background set
+// it only does so while none of the Game processes has the focused window,
+// e.g. when the game is alt-tabbed.
+type Notifier struct {
+	keep       func(events.TranslationDone) bool
+	background bool
+	game       []string
+
+	queue chan notification
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	showErr error // from the last notification, reported by Handle
+	warned  bool  // focus detection failure was logged
+}
+
+// NewNotifier returns a notification sink for the translations keep accepts.
+// With background true, notifications are skipped while a process named in
+// game, such as "cs2", has the focused window.
+func NewNotifier(keep func(events.TranslationDone) bool, background bool, game []string) (*Notifier, error) {
+	if runtime.GOOS == "linux" {
+		if _, err := execwrap.LookPath("notify-send"); err != nil {
+			return nil, ErrNoNotifier
+		}
+	}
+	n := &Notifier{keep: keep, background: background, game: game, queue: make(chan notification, notifyQueue)}
+	n.wg.Add(1)
+	go n.run()
+	return n, nil
+}
+
+// Handle queues a notification for t. It returns the error of the last
+// notification until one succeeds again.
+func (n *Notifier) Handle(e events.Event) error {
+	n.mu.Lock()
+	err := n.showErr
+	n.mu.Unlock()
+	t, ok := e.(events.TranslationDone)
+	if !ok || t.Err != nil || t.Superseded || t.Translated == "" || !n.keep(t) {
+		return err
+	}
+	title := t.Source
+	if t.Player != "" {
+		title = fmt.Sprintf("%s (%s)", t.Player, t.Source)
+	}
+	select {
+	case n.queue <- notification{title: title, body: t.Translated}:
+	default:
+		// Too many at once; the console still has them
+	}
+	return err
+}
+
+func (n *Notifier) run() {
+	defer n.wg.Done()
+	for msg := range n.queue {
+		if n.background && n.gameFocused() {
+			continue
+		}
+		err := notify(msg.title, msg.body)
+		n.mu.Lock()
+		n.showErr = err
+		n.mu.Unlock()
+	}
+}
+
+// gameFocused reports whether the game has the focused window. When that
+// cannot be told it reports false, so notifications are shown.
+func (n *Notifier) gameFocused() bool {
+	name, err := focusedProcess()
+	if err != nil {
+		n.mu.Lock()
+		if !n.warned {
+			n.warned = true
+			slog.Warn("Cannot tell which window is focused, showing every notification", "err", err)
+		}
+		n.mu.Unlock()
+		return false
+	}
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	for _, g := range n.game {
+		if name == strings.TrimSuffix(strings.ToLower(g), ".exe") {
+			return true
+		}
+	}
+	return false
+}
+
+// Close shows the notifications still queued and stops
+func (n *Notifier) Close() error {
+	close(n.queue)
+	n.wg.Wait()
+	return nil
+}
+
+// windowsToast shows a toast under PowerShell's app ID, which Windows knows
+// without registering one; title and body come from the environment so they
+// need no quoting
+const windowsToast = `$null = [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime]
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$null = $text.Item(0).AppendChild($xml.CreateTextNode($env:CS_TRANSLATE_TITLE))
+$null = $text.Item(1).AppendChild($xml.CreateTextNode($env:CS_TRANSLATE_BODY))
+$app = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($app).Show([Windows.UI.Notifications.ToastNotification]::new($xml))`
+
+// notify pops up a desktop notification. Title and body come from chat, so
+// "--" keeps one starting with a dash from being read as an option.
+func notify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = execwrap.Command("notify-send", "--app-name=cs-translate", "--", title, body)
+	case "darwin":
+		cmd = execwrap.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run", "--", title, body)
+	case "windows":
+		cmd = execwrap.Command("powershell", "-NoProfile", "-Command", windowsToast)
+		cmd.Env = append(cmd.Environ(), "CS_TRANSLATE_TITLE="+title, "CS_TRANSLATE_BODY="+body)
+	default:
+		return ErrNoNotifier
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
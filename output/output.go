@@ -0,0 +1,56 @@
+// Package output defines where session events end up: the console, the web
+// server and Stream Deck, hook commands, text-to-speech, the clipboard and
+// the game chat.
+// Further outputs such as files or Discord implement the same Sink.
+package output
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/events"
+)
+
+// Sink receives events from the bus. Handle runs in the publisher's
+// goroutine and must hand slow work off; an error is logged and does not
+// stop later events.
+type Sink interface {
+	Handle(e events.Event) error
+	Close() error
+}
+
+// Func is a Sink that calls a function and has nothing to close
+type Func func(events.Event)
+
+func (f Func) Handle(e events.Event) error {
+	f(e)
+	return nil
+}
+
+func (f Func) Close() error { return nil }
+
+// Subscribe attaches s to bus under name. The returned function detaches
+// and closes it. A failing sink is logged once and again when it recovers.
+func Subscribe(bus *events.Bus, name string, s Sink) (closeSink func()) {
+	var mu sync.Mutex
+	failing := false
+	unsubscribe := bus.Subscribe(func(e events.Event) {
+		err := s.Handle(e)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err != nil && !failing:
+			failing = true
+			slog.Warn("Output failed", "output", name, "err", err)
+		case err == nil && failing:
+			failing = false
+			slog.Info("Output is working again", "output", name)
+		}
+	})
+	return func() {
+		unsubscribe()
+		if err := s.Close(); err != nil {
+			slog.Warn("Closing output failed", "output", name, "err", err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// SoundBell is the Sound path that rings the terminal bell instead of
+// playing a file
+const SoundBell = "bell"
+
+// ErrNoPlayer is returned when no program to play sound files is found
+var ErrNoPlayer = errors.New("no sound player found (install paplay from pulseaudio-utils or aplay from alsa-utils)")
+
+// windowsSound plays the file named by the environment, so the path needs
+// no quoting
+const windowsSound = `(New-Object Media.SoundPlayer $env:CS_TRANSLATE_SOUND).PlaySync()`
+
+// Sound plays an alert sound in the background. While it plays, at most one
+// more is queued, so a burst of alerts does not keep it going for long.
+type Sound struct {
+	path  string
+	queue chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewSound returns a player for the WAV file path, or for the terminal bell
+// when path is SoundBell
+func NewSound(path string) (*Sound, error) {
+	if path != SoundBell {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		if runtime.GOOS == "linux" && linuxPlayer() == "" {
+			return nil, ErrNoPlayer
+		}
+	}
+	s := &Sound{path: path, queue: make(chan struct{}, 1)}
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+// Play plays the sound unless one is already waiting
+func (s *Sound) Play() {
+	select {
+	case s.queue <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Sound) run() {
+	defer s.wg.Done()
+	for range s.queue {
+		if err := playSound(s.path); err != nil {
+			slog.Warn("Alert sound not played", "err", err)
+		}
+	}
+}
+
+// Close plays a sound still queued and stops
+func (s *Sound) Close() error {
+	close(s.queue)
+	s.wg.Wait()
+	return nil
+}
+
+// linuxPlayer returns paplay or aplay, whichever is installed, or ""
+func linuxPlayer() string {
+	for _, p := range []string{"paplay", "aplay"} {
+		if _, err := execwrap.LookPath(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// playSound plays the file path, or rings the bell, and returns when done
+func playSound(path string) error {
+	if path == SoundBell {
+		_, err := fmt.Fprint(os.Stdout, "\a")
+		return err
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		player, args := linuxPlayer(), []string{path}
+		if player == "aplay" {
+			args = []string{"-q", path}
+		}
+		cmd = execwrap.Command(player, args...)
+	case "darwin":
+		cmd = execwrap.Command("afplay", path)
+	case "windows":
+		cmd = execwrap.Command("powershell", "-NoProfile", "-Command", windowsSound)
+		cmd.Env = append(cmd.Environ(), "CS_TRANSLATE_SOUND="+path)
+	default:
+		return ErrNoPlayer
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", cmd.Args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matchmaking clients such as FACEIT's and ESEA's keep a log of the match
+// room and lobby chat, which never reaches console.log, and Steam's friends
+// and party chat can be logged to a file. Their chat lines look like
+//
+//	[2026-10-14 21:03:11.250] [info] chat: [match] l1ght: gl hf
+//	2026-10-14 21:03:11 CHAT [TEAM] l1ght: rush b
+//	[2026-10-14 21:03:11] l1ght: invite me
+//
+// (FACEIT, ESEA, then Steam). ClientFormats holds their patterns; other
+// clients are read with a pattern of the same kind.
+var ClientFormats = map[string]ClientPattern{
+	"faceit": {Pattern: `^\[[^\]]+\] \[\w+\]\s+chat: \[(?P<team>[^\]]+)\] (?P<player>[^:]+): (?P<text>.+)$`},
+	"esea":   {Pattern: `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} CHAT \[(?P<team>[^\]]+)\] (?P<player>[^:]+): (?P<text>.+)$`},
+	// Friends and the party read Steam chat, not the lobby's other team
+	"steam": {Pattern: `^\[\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\] (?P<player>[^:]+): (?P<text>.+)$`, Room: "PARTY"},
+}
+
+// ClientPattern is how a client writes chat lines: Pattern, a regular
+// expression with the named groups player and text and, optionally, team,
+// and Room, the team of lines the pattern has none for (empty: ALL)
+type ClientPattern struct {
+	Pattern string
+	Room    string
+}
+
+// ClientFormat reads chat lines from a matchmaking client's log
+type ClientFormat struct {
+	name string
+	re   *regexp.Regexp
+	team int    // index of the team group; -1 without one
+	room string // the team without a team group
+}
+
+// NewClientFormat reads chat as p says. Lines are shown as chat of name,
+// e.g. "[FACEIT] [ALL] l1ght: gl hf".
+func NewClientFormat(name string, p ClientPattern) (*ClientFormat, error) {
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat pattern for %s: %w", name, err)
+	}
+	if re.SubexpIndex("player") < 0 || re.SubexpIndex("text") < 0 {
+		return nil, fmt.Errorf("chat pattern for %s needs the groups (?P<player>...) and (?P<text>...)", name)
+	}
+	room := strings.ToUpper(strings.TrimSpace(p.Room))
+	if room == "" {
+		room = "ALL"
+	}
+	return &ClientFormat{name: name, re: re, team: re.SubexpIndex("team"), room: room}, nil
+}
+
+// Parse parses a line of the client's log
+// Returns nil if the line is not a chat message
+func (f *ClientFormat) Parse(line string) *ChatMessage {
+	line = StripControl(strings.TrimSpace(line))
+	m := f.re.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	player := strings.TrimSpace(m[f.re.SubexpIndex("player")])
+	text := strings.TrimSpace(m[f.re.SubexpIndex("text")])
+	if player == "" || text == "" {
+		return nil
+	}
+	team := f.room
+	if f.team >= 0 {
+		team = clientTeam(m[f.team])
+	}
+	return &ChatMessage{
+		OriginalText:   fmt.Sprintf("[%s] [%s] %s: %s", strings.ToUpper(f.name), team, player, text),
+		PlayerName:     player,
+		MessageContent: text,
+		Team:           team,
+	}
+}
+
+// clientTeam names a client's chat room the way the console log does: ALL
+// for the match room or lobby everyone in it reads, TEAM for the team's own
+func clientTeam(room string) string {
+	switch room = strings.ToUpper(strings.TrimSpace(room)); room {
+	case "", "ALL", "MATCH", "ROOM", "LOBBY":
+		return "ALL"
+	case "PARTY", "FACTION":
+		return "TEAM"
+	}
+	return room
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CS2 prints the map on connect, e.g.
+// 02/02 00:35:12  Map: de_dust2
+var mapRegex = regexp.MustCompile(`^(?:\d{2}/\d{2}\s+\d{2}:\d{2}:\d{2}\s+)?Map:\s*(\S+)\s*$`)
+
+// ParseMatchStart returns the map name if the line announces a new map
+func ParseMatchStart(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.Contains(line, "Map:") {
+		return "", false
+	}
+	m := mapRegex.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Servers print the result when a match ends, which reaches the console log
+// on local servers, e.g.
+// L 02/02/2026 - 01:20:44: Game Over: competitive mg_active de_dust2 score 13:7 after 41 min
+var gameOverRegex = regexp.MustCompile(`Game Over:\s+\S+\s+\S+\s+(\S+)\s+score\s+(\d+:\d+)`)
+
+// ParseGameOver returns the map and final score if the line announces the
+// end of a match
+func ParseGameOver(line string) (mapName, score string, ok bool) {
+	if !strings.Contains(line, "Game Over:") {
+		return "", "", false
+	}
+	m := gameOverRegex.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// RoundEvent is a round boundary announced in the console log
+type RoundEvent int
+
+const (
+	RoundStart RoundEvent = iota + 1
+	RoundEnd
+	MatchRestart // warmup ended or the match was restarted; rounds count from 1
+)
+
+// Servers with logging enabled print round boundaries, which reach the
+// console log on local servers, e.g.
+// 02/02 00:41:03  L 02/02/2026 - 00:41:03: World triggered "Round_Start"
+var roundTriggers = map[string]RoundEvent{
+	`World triggered "Round_Start"`: RoundStart,
+	`World triggered "Round_End"`:   RoundEnd,
+	`World triggered "Match_Start"`: MatchRestart,
+}
+
+// ParseRoundEvent reports whether the line is a round boundary
+func ParseRoundEvent(line string) (RoundEvent, bool) {
+	if !strings.Contains(line, "World triggered") {
+		return 0, false
+	}
+	for trigger, ev := range roundTriggers {
+		if strings.Contains(line, trigger) {
+			return ev, true
+		}
+	}
+	return 0, false
+}
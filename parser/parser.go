@@ -1,8 +1,8 @@
 package parser
 
 import (
-	"regexp"
 	"strings"
+	"unicode"
 )
 
 // ChatMessage represents a parsed chat message
@@ -14,67 +14,61 @@ type ChatMessage struct {
 	Team           string // "CT", "T", or empty for all
 }
 
-var (
-	// Regex for standard chat: "PlayerName : Message"
-	// Taking into account potential unicode characters in names
-	// And *DEAD* prefix
-	// And team chat: PlayerName @ Team : Message (Check actual CS2 format)
-	// CS2 console output format example needed.
-	// Usually:
-	// " PlayerName : Message"
-	// " *DEAD* PlayerName : Message"
-
-	// We will use a flexible regex
-	// ^\s*(\*DEAD\*)?\s*([^:]+?)\s*:\s*(.+)$
-
-	// Updated text from user:
-	// 02/02 00:35:34  [ALL] l1ght: testing
-	// 02/02 00:35:34  [T] l1ght: testing hello
-	chatRegex = regexp.MustCompile(`^\d{2}/\d{2}\s+\d{2}:\d{2}:\d{2}\s+\[(?P<Team>[^\]]+)\]\s+(?P<Name>[^:]+):\s+(?P<Message>.+)$`)
-)
+// Chat lines look like
+//
+//	02/02 00:35:34  [ALL] l1ght: testing
+//	02/02 00:35:34  [T] l1ght: testing hello
+//
+// which is `^\d{2}/\d{2}\s+\d{2}:\d{2}:\d{2}\s+\[([^\]]+)\]\s+([^:]+):\s+(.+)$`.
+// ParseLine runs on every console line, so it matches that by hand instead
+// of with a regexp and does not allocate for lines that are not chat.
 
 // ParseLine parses a line from the loop
 // Returns nil if the line is not a chat message
 func ParseLine(line string) *ChatMessage {
 	// Clean up empty chars
-	line = strings.TrimSpace(line)
+	line = StripControl(strings.TrimSpace(line))
 
-	// Optimization: Chat lines usually contain ": "
-	// This filters out many system messages like "Map:de_dust2" (no space after colon)
-	if !strings.Contains(line, ": ") {
+	rest, ok := skipTimestamp(line)
+	if !ok {
 		return nil
 	}
 
-	// Optimization: Chat lines must contain brackets for Team info (e.g. [ALL])
-	// User requested to skip lines not containing something with square brackets
-	if !strings.Contains(line, "[") {
+	// [Team]
+	if len(rest) == 0 || rest[0] != '[' {
 		return nil
 	}
-	if !strings.Contains(line, "[ALL") && !strings.Contains(line, "[T") && !strings.Contains(line, "[CT") {
+	end := strings.IndexByte(rest, ']')
+	if end < 2 {
 		return nil
 	}
-
-	if !chatRegex.MatchString(line) {
+	team := rest[1:end]
+	rest, ok = skipSpace(rest[end+1:])
+	if !ok {
 		return nil
 	}
 
-	matches := chatRegex.FindStringSubmatch(line)
-	result := make(map[string]string)
-	names := chatRegex.SubexpNames()
-
-	// Safe extraction
-	for i, match := range matches {
-		if i < len(names) && names[i] != "" {
-			result[names[i]] = match
-		}
+	// Name: Message
+	colon := strings.IndexByte(rest, ':')
+	if colon < 1 {
+		return nil
+	}
+	name := strings.TrimSpace(rest[:colon])
+	message, ok := skipSpace(rest[colon+1:])
+	if !ok || message == "" || strings.IndexByte(message, '\n') >= 0 {
+		return nil
 	}
 
-	name := strings.TrimSpace(result["Name"])
-	message := result["Message"]
-	team := result["Team"]
+	// Only team and all chat; other bracketed lines are system output
+	if !strings.Contains(line, ": ") {
+		return nil
+	}
+	if !strings.Contains(line, "[ALL") && !strings.Contains(line, "[T") && !strings.Contains(line, "[CT") {
+		return nil
+	}
 
 	// skip if missing name or message or team
-	if name == "" || message == "" || team == "" {
+	if name == "" {
 		return nil
 	}
 
@@ -86,3 +80,111 @@ func ParseLine(line string) *ChatMessage {
 		IsDead:         false, // Not explicitly captured in this format yet
 	}
 }
+
+// StripControl removes control characters, such as the color codes CS2 puts
+// around names and in chat, from s. Tabs and newlines are kept.
+func StripControl(s string) string {
+	if !strings.ContainsFunc(s, strippedControl) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if strippedControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func strippedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n'
+}
+
+// ApplyNameHints re-splits msg for player names that contain ':' themselves,
+// which ParseLine cuts at the first colon. When the chat line starts with
+// one of names followed by ": ", that name wins; the longest match is used.
+// It reports whether msg changed.
+func ApplyNameHints(msg *ChatMessage, names []string) bool {
+	if msg == nil || len(names) == 0 {
+		return false
+	}
+	body, ok := chatBody(msg.OriginalText)
+	if !ok {
+		return false
+	}
+	best := ""
+	for _, name := range names {
+		if len(name) <= len(best) || len(name) <= len(msg.PlayerName) || !strings.HasPrefix(body, name) {
+			continue
+		}
+		after := strings.TrimLeft(body[len(name):], " \t")
+		if strings.HasPrefix(after, ":") {
+			if message, ok := skipSpace(after[1:]); ok && message != "" {
+				best = name
+			}
+		}
+	}
+	if best == "" {
+		return false
+	}
+	after := strings.TrimLeft(body[len(best):], " \t")
+	msg.PlayerName = best
+	msg.MessageContent, _ = skipSpace(after[1:])
+	return true
+}
+
+// ChatBody returns the "Name: Message" part of a chat line
+func ChatBody(line string) string {
+	body, _ := chatBody(line)
+	return body
+}
+
+func chatBody(line string) (string, bool) {
+	rest, ok := skipTimestamp(strings.TrimSpace(line))
+	if !ok || len(rest) == 0 || rest[0] != '[' {
+		return "", false
+	}
+	end := strings.IndexByte(rest, ']')
+	if end < 2 {
+		return "", false
+	}
+	return skipSpace(rest[end+1:])
+}
+
+// skipTimestamp consumes "MM/DD hh:mm:ss" and the whitespace after it
+func skipTimestamp(s string) (string, bool) {
+	// MM/DD
+	if len(s) < 5 || !digits(s[0:2]) || s[2] != '/' || !digits(s[3:5]) {
+		return "", false
+	}
+	s, ok := skipSpace(s[5:])
+	if !ok {
+		return "", false
+	}
+	// hh:mm:ss
+	if len(s) < 8 || !digits(s[0:2]) || s[2] != ':' || !digits(s[3:5]) || s[5] != ':' || !digits(s[6:8]) {
+		return "", false
+	}
+	return skipSpace(s[8:])
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// skipSpace consumes at least one whitespace character as matched by \s
+func skipSpace(s string) (string, bool) {
+	i := 0
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	return s[i:], i > 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
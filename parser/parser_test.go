@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// chatRegex is the pattern ParseLine matched with before it did so by hand
+var chatRegex = regexp.MustCompile(`^\d{2}/\d{2}\s+\d{2}:\d{2}:\d{2}\s+\[(?P<Team>[^\]]+)\]\s+(?P<Name>[^:]+):\s+(?P<Message>.+)$`)
+
+// regexpParseLine is ParseLine as it was with chatRegex, color codes
+// stripped first as ParseLine does now
+func regexpParseLine(line string) *ChatMessage {
+	line = StripControl(strings.TrimSpace(line))
+	if !strings.Contains(line, ": ") || !strings.Contains(line, "[") {
+		return nil
+	}
+	if !strings.Contains(line, "[ALL") && !strings.Contains(line, "[T") && !strings.Contains(line, "[CT") {
+		return nil
+	}
+	m := chatRegex.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	team := m[chatRegex.SubexpIndex("Team")]
+	name := strings.TrimSpace(m[chatRegex.SubexpIndex("Name")])
+	message := m[chatRegex.SubexpIndex("Message")]
+	if name == "" || message == "" || team == "" {
+		return nil
+	}
+	return &ChatMessage{OriginalText: line, PlayerName: name, MessageContent: message, Team: team}
+}
+
+// corpus is console output from a match: chat of every kind, the system
+// lines around it and near misses of the chat format
+var corpus = []string{
+	"02/02 00:35:34  [ALL] l1ght: testing",
+	"02/02 00:35:34  [T] l1ght: testing hello",
+	"02/02 00:35:34  [CT] Дима: раш б",
+	"02/02 00:35:34  [ALL] *DEAD* vova: nice",
+	"02/02 00:35:34  [T] 玩家: 你好",
+	"  02/02 00:35:34  [ALL] padded: with spaces around  \r\n",
+	"02/02 00:35:34\t[ALL]\tl1ght:\ttabs between",
+	"02/02 00:35:34  [ALL] l1ght :  spaced colon",
+	"02/02 00:35:34  [ALL] name: with: colons: inside",
+	"02/02 00:35:34  [ALL] l1ght: ",
+	"02/02 00:35:34  [ALL] l1ght:no space",
+	"02/02 00:35:34  [ALL] : no name",
+	"02/02 00:35:34  [ALL]  : blank name",
+	"02/02 00:35:34  [] l1ght: no team",
+	"02/02 00:35:34  [ALL l1ght: unclosed team",
+	"02/02 00:35:34  [TEAM] olga: team from a client",
+	"02/02 00:35:34  [Tt] odd: team",
+	"02/02 00:35:34 [ALL] one space: before the team",
+	"02/02 00:35:34[ALL] l1ght: no space before the team",
+	"02/02 00:35:34  [ALL]l1ght: no space after the team",
+	"2/02 00:35:34  [ALL] l1ght: short date",
+	"02/02 0:35:34  [ALL] l1ght: short time",
+	"02-02 00:35:34  [ALL] l1ght: dashes",
+	"02/02 00:35:34  [ALL] \x03l1ght\x01: \x07colored\x01 :)",
+	"02/02 00:35:34  [ALL] l1ght: line one\nline two",
+	"02/02 00:35:34  [SPEC] coach: spectators",
+	"02/02 00:35:34  [ALL] [CT] nested: brackets",
+	"02/02 00:35:34  Map: de_dust2",
+	"02/02 00:35:34  [Client] Connected to server",
+	"02/02 00:35:34  [NetSteamConn] Ping: 32ms",
+	"02/02 00:35:34  [Tier0] Map:de_mirage",
+	"02/02 00:35:34  ChangeGameUIState: CSGO_GAME_UI_STATE_INGAME -> CSGO_GAME_UI_STATE_PAUSEMENU",
+	"L 02/02/2026 - 01:20:44: Game Over: competitive mg_active de_dust2 score 13:7 after 41 min",
+	"Map: de_dust2",
+	"[ALL] l1ght: without a timestamp",
+	"",
+	"   ",
+}
+
+func TestParseLineMatchesRegexp(t *testing.T) {
+	for _, line := range corpus {
+		got, want := ParseLine(line), regexpParseLine(line)
+		if (got == nil) != (want == nil) || got != nil && *got != *want {
+			t.Errorf("ParseLine(%q) = %+v, the regexp gives %+v", line, got, want)
+		}
+	}
+}
+
+func BenchmarkParseLine(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, line := range corpus {
+			ParseLine(line)
+		}
+	}
+}
+
+func BenchmarkParseLineRegexp(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, line := range corpus {
+			regexpParseLine(line)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Game servers stream their log with logaddress_add (UDP) and
+// logaddress_add_http, with chat as
+//
+//	L 10/14/2026 - 21:03:11: "l1ght<2><[U:1:12345]><CT>" say_team "rush b"
+//	10/14/2026 - 21:03:11.250 - "l1ght<2><[U:1:12345]><TERRORIST>" say "gl hf"
+//
+// The first form is UDP, the second HTTP.
+const serverTimestamp = `^(?:L )?\d{2}/\d{2}/\d{4} - \d{2}:\d{2}:\d{2}(?:\.\d+)?(?::| -)\s+`
+
+var (
+	serverChatRegex = regexp.MustCompile(serverTimestamp + `"(.+)<\d+><[^>]*><([^>]*)>" (say|say_team) "(.*)"( \(dead\))?$`)
+	serverMapRegex  = regexp.MustCompile(serverTimestamp + `Started map "([^"]+)"`)
+)
+
+// ParseServerLine parses a line of a server log
+// Returns nil if the line is not a chat message
+func ParseServerLine(line string) *ChatMessage {
+	line = StripControl(strings.TrimSpace(line))
+	if !strings.Contains(line, `" say`) {
+		return nil
+	}
+	m := serverChatRegex.FindStringSubmatch(line)
+	if m == nil || m[1] == "" || m[4] == "" {
+		return nil
+	}
+	team := "ALL"
+	if m[3] == "say_team" {
+		team = serverTeam(m[2])
+	}
+	return &ChatMessage{
+		OriginalText:   line,
+		PlayerName:     m[1],
+		MessageContent: m[4],
+		IsDead:         m[5] != "",
+		Team:           team,
+	}
+}
+
+// serverTeam names a server log team the way the console log does
+func serverTeam(team string) string {
+	switch team {
+	case "TERRORIST":
+		return "T"
+	case "Spectator":
+		return "SPEC"
+	}
+	return team
+}
+
+// ParseServerMap returns the map name if a server log line announces a map
+// change
+func ParseServerMap(line string) (string, bool) {
+	if !strings.Contains(line, `Started map "`) {
+		return "", false
+	}
+	m := serverMapRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
@@ -5,6 +5,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/vdf"
 )
 
 func findLogFile() (string, error) {
@@ -22,41 +26,104 @@ func findLogFile() (string, error) {
 	return "", fmt.Errorf("could not find console.log in common locations for %s", runtime.GOOS)
 }
 
-func getLogFilePaths(home string) []string {
-	switch runtime.GOOS {
-	case "windows":
-		return []string{
-			`C:\Program Files (x86)\Steam\steamapps\common\Counter-Strike Global Offensive\game\csgo\console.log`,
-			`D:\SteamLibrary\steamapps\common\Counter-Strike Global Offensive\game\csgo\console.log`,
-		}
-	case "linux":
-		return []string{
-			filepath.Join(home, ".steam/steam/steamapps/common/Counter-Strike Global Offensive/game/csgo/console.log"),
-			filepath.Join(home, ".local/share/Steam/steamapps/common/Counter-Strike Global Offensive/game/csgo/console.log"),
+// cs2LogPath is console.log relative to a Steam library folder
+var cs2LogPath = filepath.Join("steamapps", "common", "Counter-Strike Global Offensive", "game", "csgo", "console.log")
+
+// cs2CfgDir returns the game's cfg folder: next to the console log of the
+// last run, else in the first Steam library with CS2 installed
+func cs2CfgDir() (string, error) {
+	var candidates []string
+	if last := config.LoadState().LastLogPath; last != "" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(last), "cfg"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, lib := range steamLibraries(home) {
+			candidates = append(candidates, filepath.Join(lib, filepath.Dir(cs2LogPath), "cfg"))
 		}
-	case "darwin":
-		return []string{
-			filepath.Join(home, "Library/Application Support/Steam/steamapps/common/Counter-Strike Global Offensive/game/csgo/console.log"),
+	}
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
 		}
 	}
-	return nil
+	return "", fmt.Errorf("could not find the CS2 cfg folder; set game_chat.cfg_dir in the settings")
 }
 
-func getUserdataPaths(home string) []string {
+// steamRoots returns the directories Steam may be installed in
+func steamRoots(home string) []string {
 	switch runtime.GOOS {
 	case "windows":
-		return []string{`C:\Program Files (x86)\Steam\userdata`}
+		return []string{`C:\Program Files (x86)\Steam`}
 	case "linux":
 		return []string{
-			filepath.Join(home, ".steam/steam/userdata"),
-			filepath.Join(home, ".local/share/Steam/userdata"),
+			filepath.Join(home, ".steam/steam"),
+			filepath.Join(home, ".local/share/Steam"),
+			filepath.Join(home, ".var/app/com.valvesoftware.Steam/.local/share/Steam"),
 		}
 	case "darwin":
-		return []string{filepath.Join(home, "Library/Application Support/Steam/userdata")}
+		return []string{filepath.Join(home, "Library/Application Support/Steam")}
 	}
 	return nil
 }
 
+// steamLibraries returns the Steam roots plus every library folder they list
+// in libraryfolders.vdf
+func steamLibraries(home string) []string {
+	var libs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		key := filepath.Clean(dir)
+		if resolved, err := filepath.EvalSymlinks(key); err == nil {
+			key = resolved
+		}
+		if runtime.GOOS == "windows" {
+			key = strings.ToLower(key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			libs = append(libs, dir)
+		}
+	}
+
+	for _, root := range steamRoots(home) {
+		add(root)
+		f, err := os.Open(filepath.Join(root, "steamapps", "libraryfolders.vdf"))
+		if err != nil {
+			continue
+		}
+		doc, err := vdf.Parse(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		for _, folder := range doc.Find("libraryfolders").Children {
+			if path := folder.Child("path"); path != nil && path.Value != "" {
+				add(path.Value)
+			}
+		}
+	}
+	if runtime.GOOS == "windows" {
+		add(`D:\SteamLibrary`)
+	}
+	return libs
+}
+
+func getLogFilePaths(home string) []string {
+	var paths []string
+	for _, lib := range steamLibraries(home) {
+		paths = append(paths, filepath.Join(lib, cs2LogPath))
+	}
+	return paths
+}
+
+func getUserdataPaths(home string) []string {
+	var paths []string
+	for _, root := range steamRoots(home) {
+		paths = append(paths, filepath.Join(root, "userdata"))
+	}
+	return paths
+}
+
 func getConfigFilePaths(dataPaths []string) []string {
 	var configs []string
 	for _, dataPath := range dataPaths {
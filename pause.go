@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/hotkey"
+)
+
+// Terminal commands that pause and resume the session
+const (
+	pauseCommand  = "/pause"
+	resumeCommand = "/resume"
+)
+
+// startPauseKey listens for pauseKey until ctx ends
+func startPauseKey(ctx context.Context) *hotkey.Listener {
+	hk := hotkey.NewListener(pauseKey)
+	go func() {
+		if err := hk.Start(ctx); err != nil {
+			slog.Error("Pause hotkey failed", "err", err)
+		}
+	}()
+	return hk
+}
+
+// parsePauseCommand reports whether line is /pause or /resume, and which
+func parsePauseCommand(line string) (pause, ok bool) {
+	switch line {
+	case pauseCommand:
+		return true, true
+	case resumeCommand:
+		return false, true
+	}
+	return false, false
+}
+
+// pauseRequested returns the pause state a Stream Deck action asks for
+func pauseRequested(action string, paused bool) bool {
+	switch action {
+	case deckPause:
+		return true
+	case deckResume:
+		return false
+	}
+	return !paused
+}
+
+// publishPause tells the sinks that the session was paused or resumed
+func publishPause(paused bool) {
+	deck.setPaused(paused)
+	if paused {
+		bus.Publish(events.Status{Source: "session", State: "paused",
+			Message: "paused: capture and translation stopped (F8 or /resume to continue)"})
+		return
+	}
+	bus.Publish(events.Status{Source: "session", State: "resumed", Message: "resumed"})
+}
@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+)
+
+// Level is how much translation quality is given up to stay within a
+// latency budget. Each level includes the ones before it.
+type Level int
+
+const (
+	LevelFull      Level = iota
+	LevelNoContext       // voice is translated without recent speech as context
+	LevelFastModel       // the smaller fallback model translates
+	LevelNoVoice         // voice is shown untranslated
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelNoContext:
+		return "voice context off"
+	case LevelFastModel:
+		return "smaller model"
+	case LevelNoVoice:
+		return "voice shown untranslated"
+	}
+	return "full quality"
+}
+
+const (
+	// budgetSmoothing weighs each new latency in the running average
+	budgetSmoothing = 0.3
+	// budgetStepDown is the least time between two steps down in quality
+	budgetStepDown = 5 * time.Second
+	// budgetStepUp is how long latency must stay well under the budget
+	// before quality is restored by a level
+	budgetStepUp = 30 * time.Second
+)
+
+// Budget tracks end-to-end latency against a maximum and steps quality down
+// while it is exceeded, and back up once latency stays under half of it. A
+// nil Budget always reports LevelFull.
+type Budget struct {
+	max    time.Duration
+	levels []Level // LevelFull first
+
+	mu      sync.Mutex
+	avg     time.Duration
+	step    int       // index into levels
+	changed time.Time // last level change
+	under   time.Time // since when avg has been under half the budget
+}
+
+// NewBudget returns a budget of max that steps through the given levels
+// after LevelFull, or nil when max is zero
+func NewBudget(max time.Duration, levels ...Level) *Budget {
+	if max <= 0 {
+		return nil
+	}
+	return &Budget{max: max, levels: append([]Level{LevelFull}, levels...)}
+}
+
+// Observe records the latency of one message. It returns the level and
+// whether it changed.
+func (b *Budget) Observe(d time.Duration) (Level, bool) {
+	if b == nil {
+		return LevelFull, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.avg == 0 {
+		b.avg = d
+	} else {
+		b.avg += time.Duration(budgetSmoothing * float64(d-b.avg))
+	}
+
+	switch {
+	case b.avg > b.max:
+		b.under = time.Time{}
+		if b.step < len(b.levels)-1 && now.Sub(b.changed) >= budgetStepDown {
+			b.step++
+			b.changed = now
+			return b.levels[b.step], true
+		}
+	case b.avg < b.max/2:
+		if b.under.IsZero() {
+			b.under = now
+		}
+		if b.step > 0 && now.Sub(b.under) >= budgetStepUp && now.Sub(b.changed) >= budgetStepUp {
+			b.step--
+			b.changed = now
+			b.under = now
+			return b.levels[b.step], true
+		}
+	default:
+		b.under = time.Time{}
+	}
+	return b.levels[b.step], false
+}
+
+// Skip records a message the current level let through untranslated, so
+// nothing was learned about the translator. A session of only voice would
+// stay at LevelNoVoice for good, so after budgetStepUp there quality is
+// stepped up by a level regardless and the running average dropped; the
+// next translations then measure the translator afresh. It returns the
+// level and whether it changed.
+func (b *Budget) Skip() (Level, bool) {
+	if b == nil {
+		return LevelFull, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.step > 0 && now.Sub(b.changed) >= budgetStepUp {
+		b.step--
+		b.changed = now
+		b.avg = 0
+		b.under = time.Time{}
+		return b.levels[b.step], true
+	}
+	return b.levels[b.step], false
+}
+
+// Level returns the current level
+func (b *Budget) Level() Level {
+	if b == nil {
+		return LevelFull
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.levels[b.step]
+}
+
+// Average returns the running average latency
+func (b *Budget) Average() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.avg
+}
+
+// Max returns the budget
+func (b *Budget) Max() time.Duration {
+	if b == nil {
+		return 0
+	}
+	return b.max
+}
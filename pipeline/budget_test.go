@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetSteps(t *testing.T) {
+	b := NewBudget(time.Second, LevelNoContext, LevelNoVoice)
+	for _, want := range []Level{LevelNoContext, LevelNoVoice} {
+		b.changed = time.Now().Add(-budgetStepDown)
+		if level, changed := b.Observe(3 * time.Second); !changed || level != want {
+			t.Fatalf("over the budget the level is %s (changed %t), want %s", level, changed, want)
+		}
+	}
+	if level, changed := b.Observe(3 * time.Second); changed {
+		t.Fatalf("stepped to %s past the last level", level)
+	}
+
+	// Voice shown untranslated says nothing new until the budget tries again
+	if level, changed := b.Skip(); changed || level != LevelNoVoice {
+		t.Fatalf("a fresh level was left for %s", level)
+	}
+	b.changed = time.Now().Add(-budgetStepUp)
+	if level, changed := b.Skip(); !changed || level != LevelNoContext {
+		t.Fatalf("a voice-only session stays at %s (changed %t), want %s", level, changed, LevelNoContext)
+	}
+	if avg := b.Average(); avg != 0 {
+		t.Fatalf("the old average %s was kept after stepping up", avg)
+	}
+	// A fast translation right after is not outweighed by the old average
+	b.changed = time.Now().Add(-budgetStepDown)
+	if level, changed := b.Observe(200 * time.Millisecond); changed || level != LevelNoContext {
+		t.Fatalf("a fast translation moved the level to %s", level)
+	}
+
+	var none *Budget
+	if level, changed := none.Skip(); changed || level != LevelFull {
+		t.Fatalf("a nil budget reports %s", level)
+	}
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"strings"
+	"unicode"
+)
+
+// limitText cuts text to max runes at a word boundary where possible and
+// returns how many runes were dropped. Zero max means no limit.
+func limitText(text string, max int) (string, int) {
+	runes := []rune(text)
+	if max <= 0 || len(runes) <= max {
+		return text, 0
+	}
+	cut := breakPoint(runes[:max])
+	return strings.TrimSpace(string(runes[:cut])), len(runes) - cut
+}
+
+// splitChunks splits text into pieces of at most size runes, preferring to
+// break after a sentence and then between words. Zero size keeps it whole.
+func splitChunks(text string, size int) []string {
+	runes := []rune(text)
+	if size <= 0 || len(runes) <= size {
+		return []string{text}
+	}
+	var chunks []string
+	for len(runes) > size {
+		cut := breakPoint(runes[:size])
+		if chunk := strings.TrimSpace(string(runes[:cut])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		runes = runes[cut:]
+	}
+	if rest := strings.TrimSpace(string(runes)); rest != "" {
+		chunks = append(chunks, rest)
+	}
+	return chunks
+}
+
+// breakPoint finds where to end a piece of runes: after the last sentence
+// end in its second half, else at the last space, else at its end
+func breakPoint(runes []rune) int {
+	half := len(runes) / 2
+	for i := len(runes) - 1; i >= half; i-- {
+		switch runes[i] {
+		case '.', '!', '?', '。', '！', '？', '\n':
+			return i + 1
+		}
+	}
+	for i := len(runes) - 1; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return len(runes)
+}
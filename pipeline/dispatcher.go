@@ -0,0 +1,280 @@
+// Package pipeline runs translations concurrently in a worker pool and
+// delivers their results back to the caller's event loop.
+package pipeline
+
+import (
+	"container/heap"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TranslateFunc performs the actual translation of a job's text; text is
+// the whole text or one chunk of it
+type TranslateFunc func(ctx context.Context, job Job, text string) (string, error)
+
+// Job is a unit of translation work
+type Job struct {
+	Key  string // supersession key, e.g. the player name; empty never supersedes
+	Text string
+	// Priority picks the next job for a free worker: the highest first,
+	// in submission order among equal ones
+	Priority Priority
+	Payload  any // carried through to the Result untouched
+}
+
+// Result is the outcome of a Job
+type Result struct {
+	Job        Job
+	Text       string
+	Err        error
+	Superseded bool // a newer job with the same key replaced this one
+	Truncated  int  // characters beyond Options.MaxSize left untranslated
+
+	Latency time.Duration // from Submit until the result was ready
+}
+
+// Options configures a Dispatcher
+type Options struct {
+	Workers int // concurrent translations
+	// SupersedeWindow is how soon a newer message with the same key must
+	// follow for the older one to be cancelled. Zero disables supersession.
+	SupersedeWindow time.Duration
+	// ChunkSize splits longer texts into several translation requests of at
+	// most this many characters, joined again in order. Zero disables it.
+	ChunkSize int
+	// MaxSize is the most characters of one job that are translated; the
+	// rest is dropped and reported in Result.Truncated. Zero is unlimited.
+	MaxSize int
+	// Gate, when set, is shared with other users of the translator; each
+	// job waits for a place in it at the job's priority
+	Gate *Gate
+	// ReorderWindow delivers results in submission order, holding one that
+	// finished before an earlier job at most this long. Zero delivers
+	// results as they finish.
+	ReorderWindow time.Duration
+}
+
+type pending struct {
+	seq       uint64 // submission order
+	job       Job
+	ctx       context.Context
+	cancel    context.CancelFunc
+	submitted time.Time
+}
+
+// queueSize is how many jobs wait for a worker before Submit blocks
+const queueSize = 100
+
+// Dispatcher fans jobs out to workers by priority and cancels superseded
+// ones
+type Dispatcher struct {
+	translate TranslateFunc
+	opts      Options
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	space   chan struct{} // holds a token per queued job, bounding the queue
+	ready   chan struct{} // holds a token per queued job, waking a worker
+	results chan Result
+	wg      sync.WaitGroup
+
+	finished  chan finished // results for reorder; nil without ReorderWindow
+	reordered chan struct{} // closed when reorder returns
+
+	mu          sync.Mutex
+	latest      map[string]*pending
+	queue       prioQueue[*pending]
+	seq         uint64
+	outstanding int           // jobs submitted whose results are not out yet
+	drained     chan struct{} // made by Drain, closed when outstanding is 0
+}
+
+// NewDispatcher starts the worker pool
+func NewDispatcher(ctx context.Context, translate TranslateFunc, opts Options) *Dispatcher {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	d := &Dispatcher{
+		translate: translate,
+		opts:      opts,
+		ctx:       ctx,
+		cancel:    cancel,
+		space:     make(chan struct{}, queueSize),
+		ready:     make(chan struct{}, queueSize),
+		results:   make(chan Result, 100),
+		latest:    make(map[string]*pending),
+	}
+
+	if opts.ReorderWindow > 0 {
+		d.finished = make(chan finished, opts.Workers)
+		d.reordered = make(chan struct{})
+		go d.reorder()
+	}
+
+	d.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// Submit queues a job. A still-running job with the same key submitted within
+// the supersede window is cancelled in favour of this one. After Drain it
+// drops the job.
+func (d *Dispatcher) Submit(job Job) {
+	d.mu.Lock()
+	if d.drained != nil {
+		d.mu.Unlock()
+		return
+	}
+	d.outstanding++
+	d.mu.Unlock()
+
+	jobCtx, jobCancel := context.WithCancel(d.ctx)
+	p := &pending{job: job, ctx: jobCtx, cancel: jobCancel, submitted: time.Now()}
+
+	if job.Key != "" && d.opts.SupersedeWindow > 0 {
+		d.mu.Lock()
+		if prev, ok := d.latest[job.Key]; ok && p.submitted.Sub(prev.submitted) < d.opts.SupersedeWindow {
+			prev.cancel()
+		}
+		d.latest[job.Key] = p
+		d.mu.Unlock()
+	}
+
+	select {
+	case d.space <- struct{}{}:
+	case <-d.ctx.Done():
+		jobCancel()
+		d.delivered()
+		return
+	}
+	d.mu.Lock()
+	p.seq = d.seq
+	heap.Push(&d.queue, &prioItem[*pending]{value: p, prio: job.Priority, seq: d.seq})
+	d.seq++
+	d.mu.Unlock()
+	d.ready <- struct{}{}
+}
+
+// Results returns the channel of finished jobs. It is closed by Close.
+func (d *Dispatcher) Results() <-chan Result {
+	return d.results
+}
+
+// Drain stops taking jobs. The jobs already submitted still run, and the
+// returned channel is closed once the last of their results is in Results.
+func (d *Dispatcher) Drain() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.drained == nil {
+		d.drained = make(chan struct{})
+		if d.outstanding == 0 {
+			close(d.drained)
+		}
+	}
+	return d.drained
+}
+
+// delivered counts a job's result as handed out
+func (d *Dispatcher) delivered() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.outstanding--
+	if d.outstanding == 0 && d.drained != nil {
+		close(d.drained)
+	}
+}
+
+// Close cancels outstanding work and waits for the workers to exit
+func (d *Dispatcher) Close() {
+	d.cancel()
+	d.wg.Wait()
+	if d.reordered != nil {
+		<-d.reordered
+	}
+	close(d.results)
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.ready:
+		case <-d.ctx.Done():
+			return
+		}
+		d.mu.Lock()
+		p := heap.Pop(&d.queue).(*prioItem[*pending]).value
+		d.mu.Unlock()
+		<-d.space
+
+		// A job cancelled before its turn fails rather than coming out as
+		// an empty translation
+		res := Result{Job: p.job, Err: p.ctx.Err()}
+		if res.Err == nil {
+			var release func()
+			if release, res.Err = d.opts.Gate.Acquire(p.ctx, p.job.Priority); res.Err == nil {
+				res.Text, res.Truncated, res.Err = d.translateLong(p.ctx, p.job)
+				release()
+			}
+		}
+		// Cancelled by a newer job rather than by shutdown
+		if p.ctx.Err() != nil && d.ctx.Err() == nil {
+			res.Superseded = true
+			res.Err = p.ctx.Err()
+		}
+		res.Latency = time.Since(p.submitted)
+		p.cancel()
+		d.forget(p)
+
+		if d.finished != nil {
+			select {
+			case d.finished <- finished{seq: p.seq, res: res, arrived: time.Now()}:
+			case <-d.ctx.Done():
+				return
+			}
+			continue
+		}
+		select {
+		case d.results <- res:
+			d.delivered()
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// translateLong applies MaxSize and ChunkSize to one job's text
+func (d *Dispatcher) translateLong(ctx context.Context, job Job) (string, int, error) {
+	text, dropped := limitText(job.Text, d.opts.MaxSize)
+	chunks := splitChunks(text, d.opts.ChunkSize)
+	if len(chunks) == 1 {
+		out, err := d.translate(ctx, job, chunks[0])
+		return out, dropped, err
+	}
+	parts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		out, err := d.translate(ctx, job, chunk)
+		if err != nil {
+			return "", dropped, err
+		}
+		parts = append(parts, out)
+	}
+	return strings.Join(parts, " "), dropped, nil
+}
+
+// forget drops the job from the supersession table if it is still the latest
+func (d *Dispatcher) forget(p *pending) {
+	if p.job.Key == "" {
+		return
+	}
+	d.mu.Lock()
+	if d.latest[p.job.Key] == p {
+		delete(d.latest, p.job.Key)
+	}
+	d.mu.Unlock()
+}
@@ -0,0 +1,362 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/monitor"
+	"github.com/micha/cs-ingame-translate/parser"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// testTimeout bounds the wait for a job to come out of the dispatcher
+const testTimeout = 10 * time.Second
+
+// testRig is a dispatcher translating the chat of a fake console.log with
+// a mock Ollama, fed by the real monitor and parser
+type testRig struct {
+	ollama  *fakegame.Ollama
+	console *fakegame.ConsoleLog
+	mon     *monitor.Monitor
+	tr      translator.Translator
+	disp    *pipeline.Dispatcher
+	done    chan struct{}
+}
+
+func newTestRig(t *testing.T, opts pipeline.Options) *testRig {
+	t.Helper()
+	r := &testRig{ollama: fakegame.NewOllama(), done: make(chan struct{})}
+	translator.OllamaHost = r.ollama.URL
+
+	console, err := fakegame.NewConsoleLog(filepath.Join(t.TempDir(), "console.log"))
+	if err != nil {
+		r.ollama.Close()
+		t.Fatal(err)
+	}
+	r.console = console
+	if r.mon, err = monitor.NewMonitor(console.Path()); err != nil {
+		r.ollama.Close()
+		console.Close()
+		t.Fatal(err)
+	}
+	if r.tr, err = translator.NewOllamaTranslator(t.Context(), "fake", "English"); err != nil {
+		r.close()
+		t.Fatal(err)
+	}
+	r.disp = pipeline.NewDispatcher(t.Context(), func(ctx context.Context, job pipeline.Job, text string) (string, error) {
+		return r.tr.Translate(ctx, text)
+	}, opts)
+
+	go func() {
+		defer close(r.done)
+		for line := range r.mon.Lines() {
+			if line.Err != nil {
+				continue
+			}
+			if msg := parser.ParseLine(line.Text); msg != nil {
+				r.disp.Submit(pipeline.Job{Key: msg.PlayerName, Text: msg.MessageContent})
+			}
+		}
+	}()
+	return r
+}
+
+func (r *testRig) close() {
+	r.mon.Stop()
+	if r.disp != nil {
+		r.disp.Close()
+		<-r.done
+	}
+	if r.tr != nil {
+		r.tr.Close()
+	}
+	r.ollama.Close()
+	r.console.Close()
+}
+
+// say writes each text as chat from its own player
+func (r *testRig) say(prefix string, texts ...string) error {
+	for i, text := range texts {
+		if err := r.console.Say("ALL", fmt.Sprintf("%s%d", prefix, i), text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect waits for n results
+func (r *testRig) collect(n int) ([]pipeline.Result, error) {
+	var results []pipeline.Result
+	timeout := time.After(testTimeout)
+	for len(results) < n {
+		select {
+		case res := <-r.disp.Results():
+			results = append(results, res)
+		case <-timeout:
+			return results, fmt.Errorf("got %d of %d results within %s", len(results), n, testTimeout)
+		}
+	}
+	return results, nil
+}
+
+// expectTranslations checks that results are the translations of texts, in
+// that order
+func expectTranslations(results []pipeline.Result, texts []string) error {
+	if len(results) != len(texts) {
+		return fmt.Errorf("got %d results, want %d", len(results), len(texts))
+	}
+	for i, res := range results {
+		if res.Err != nil {
+			return fmt.Errorf("result %d (%q): %w", i, res.Job.Text, res.Err)
+		}
+		if want := fakegame.Translation(texts[i]); res.Text != want {
+			return fmt.Errorf("result %d is %q, want %q", i, res.Text, want)
+		}
+	}
+	return nil
+}
+
+func numberedLines(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s message %d", prefix, i)
+	}
+	return lines
+}
+
+// With one worker, lines come out in the order they were written, and a long
+// message split into chunks is joined in order
+func TestOrdering(t *testing.T) {
+	r := newTestRig(t, pipeline.Options{Workers: 1, ChunkSize: 40})
+	defer r.close()
+
+	lines := numberedLines("ordered", 20)
+	if err := r.say("p", lines...); err != nil {
+		t.Fatal(err)
+	}
+	results, err := r.collect(len(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := expectTranslations(results, lines); err != nil {
+		t.Fatal(err)
+	}
+
+	long := "first we smoke the window, then they flash over the wall and the rest of us wait on short until the bomb is down"
+	before := len(r.ollama.Texts())
+	if err := r.console.Say("ALL", "caller", long); err != nil {
+		t.Fatal(err)
+	}
+	results, err = r.collect(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := r.ollama.Texts()[before:]
+	if len(chunks) < 2 {
+		t.Fatalf("long message was sent in %d request(s), want it chunked", len(chunks))
+	}
+	var want []string
+	for _, c := range chunks {
+		want = append(want, fakegame.Translation(c))
+	}
+	if strings.Join(strings.Fields(strings.Join(chunks, " ")), " ") != long {
+		t.Fatalf("chunks %q do not make up the message", chunks)
+	}
+	if got := results[0].Text; got != strings.Join(want, " ") {
+		t.Fatalf("chunked translation is %q, want %q", got, strings.Join(want, " "))
+	}
+}
+
+// Results of a pool finishing out of order come out in the order they were
+// submitted, unless an earlier one takes longer than the reorder window
+func TestReorder(t *testing.T) {
+	ctx := t.Context()
+	slow := func(ctx context.Context, job pipeline.Job, text string) (string, error) {
+		if strings.HasPrefix(text, "slow") {
+			select {
+			case <-time.After(400 * time.Millisecond):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		return text, nil
+	}
+	order := func(window time.Duration) ([]string, error) {
+		d := pipeline.NewDispatcher(ctx, slow, pipeline.Options{Workers: 3, ReorderWindow: window})
+		defer d.Close()
+		for _, text := range []string{"slow first", "second", "third"} {
+			d.Submit(pipeline.Job{Text: text})
+		}
+		var got []string
+		for range 3 {
+			select {
+			case res := <-d.Results():
+				got = append(got, res.Text)
+			case <-time.After(testTimeout):
+				return got, fmt.Errorf("only %q came out", got)
+			}
+		}
+		return got, nil
+	}
+	got, err := order(2 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "slow first|second|third"; strings.Join(got, "|") != want {
+		t.Fatalf("with a window the results are %q, want %s", got, want)
+	}
+	if got, err = order(100 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if want := "second|third|slow first"; strings.Join(got, "|") != want {
+		t.Fatalf("past the window the results are %q, want %s", got, want)
+	}
+}
+
+// A burst larger than the translation queue, read late and translated
+// slowly, is neither dropped nor run wider than the pool
+func TestBackpressure(t *testing.T) {
+	const workers = 2
+	r := newTestRig(t, pipeline.Options{Workers: workers})
+	defer r.close()
+	r.ollama.SetDelay(20 * time.Millisecond)
+
+	lines := numberedLines("burst", 150)
+	if err := r.say("p", lines...); err != nil {
+		t.Fatal(err)
+	}
+	// Nobody reads results for a while, so the pool backs up
+	time.Sleep(time.Second)
+	results, err := r.collect(len(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, res := range results {
+		if res.Err != nil {
+			t.Fatalf("%q: %v", res.Job.Text, res.Err)
+		}
+		seen[res.Job.Text] = true
+	}
+	for _, line := range lines {
+		if !seen[line] {
+			t.Fatalf("%q was lost", line)
+		}
+	}
+	if peak := r.ollama.Peak(); peak > workers {
+		t.Fatalf("%d translations ran at once with %d workers", peak, workers)
+	}
+}
+
+// Lines keep coming, each exactly once, after the log is truncated in place
+// and after it is moved away and recreated
+func TestRotation(t *testing.T) {
+	r := newTestRig(t, pipeline.Options{Workers: 1})
+	defer r.close()
+
+	phases := []struct {
+		name   string
+		before func() error
+	}{
+		{"start", func() error { return nil }},
+		{"truncated", r.console.Truncate},
+		{"rotated", r.console.Rotate},
+	}
+	for _, phase := range phases {
+		if err := phase.before(); err != nil {
+			t.Fatalf("%s: %v", phase.name, err)
+		}
+		lines := numberedLines(phase.name, 3)
+		if err := r.say(phase.name, lines...); err != nil {
+			t.Fatalf("%s: %v", phase.name, err)
+		}
+		results, err := r.collect(len(lines))
+		if err != nil {
+			t.Fatalf("%s: %v", phase.name, err)
+		}
+		if err := expectTranslations(results, lines); err != nil {
+			t.Fatalf("%s: %v", phase.name, err)
+		}
+	}
+	// Nothing may be delivered twice after the log changed under the monitor
+	select {
+	case res := <-r.disp.Results():
+		t.Fatalf("unexpected extra result for %q", res.Job.Text)
+	case <-time.After(time.Second):
+	}
+}
+
+// Failed requests are reported per message and the pool keeps going; a
+// missing model, a superseded message and Ollama going away are told apart
+func TestErrors(t *testing.T) {
+	r := newTestRig(t, pipeline.Options{Workers: 1, SupersedeWindow: 2 * time.Second})
+	defer r.close()
+
+	r.ollama.FailNext(2)
+	lines := numberedLines("flaky", 3)
+	if err := r.say("p", lines...); err != nil {
+		t.Fatal(err)
+	}
+	results, err := r.collect(len(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results[:2] {
+		if res.Err == nil {
+			t.Fatalf("%q succeeded although the server failed", res.Job.Text)
+		}
+	}
+	if err := expectTranslations(results[2:], lines[2:]); err != nil {
+		t.Fatalf("after failures: %v", err)
+	}
+
+	r.ollama.SetMissingModel(true)
+	if err := r.say("missing", "is the model there"); err != nil {
+		t.Fatal(err)
+	}
+	if results, err = r.collect(1); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, translator.ErrModelNotFound) {
+		t.Fatalf("missing model reported as %v", results[0].Err)
+	}
+	r.ollama.SetMissingModel(false)
+
+	// A player correcting themselves replaces the message still translating
+	r.ollama.SetDelay(500 * time.Millisecond)
+	if err := r.console.Say("ALL", "typo", "rush a"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(400 * time.Millisecond) // the monitor has picked up the first line
+	if err := r.console.Say("ALL", "typo", "rush b"); err != nil {
+		t.Fatal(err)
+	}
+	if results, err = r.collect(2); err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Superseded || results[0].Job.Text != "rush a" {
+		t.Fatalf("first message not superseded: %+v", results[0])
+	}
+	if err := expectTranslations(results[1:], []string{"rush b"}); err != nil {
+		t.Fatal(err)
+	}
+	r.ollama.SetDelay(0)
+
+	r.ollama.Close()
+	if err := r.say("down", "anyone there"); err != nil {
+		t.Fatal(err)
+	}
+	if results, err = r.collect(1); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, translator.ErrOllamaUnavailable) {
+		t.Fatalf("stopped server reported as %v", results[0].Err)
+	}
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verdict is what a Limiter decided about a message
+type Verdict int
+
+const (
+	Allow       Verdict = iota
+	Repeat              // the same text as the key's previous message, within the dedupe window
+	RateLimited         // the key sent more than its rate allows
+)
+
+// maxLimiterKeys is how many keys a Limiter tracks before it forgets the
+// idle ones
+const maxLimiterKeys = 1024
+
+// LimitOptions configures a Limiter
+type LimitOptions struct {
+	// DedupeWindow is how soon an identical message must follow the key's
+	// previous one to count as a repeat. Zero disables deduplication.
+	DedupeWindow time.Duration
+	// Rate is how many messages per second one key may have translated on
+	// average. Zero is unlimited.
+	Rate float64
+	// Burst is how many messages a key may send at once before Rate applies
+	// (0: 1)
+	Burst int
+}
+
+// Limiter collapses repeated messages and caps the rate of translations per
+// key, so chat spam does not queue up work for the translator. A nil
+// Limiter allows everything.
+type Limiter struct {
+	opts LimitOptions
+
+	mu   sync.Mutex
+	keys map[string]*limitState
+}
+
+type limitState struct {
+	text    string    // previous message, normalized
+	seen    time.Time // when it was sent
+	repeats int       // repeats of text in a row
+	tokens  float64   // messages the key may send right now
+	filled  time.Time // when tokens was last topped up
+}
+
+// NewLimiter returns a limiter for opts, or nil when it limits nothing
+func NewLimiter(opts LimitOptions) *Limiter {
+	if opts.DedupeWindow <= 0 && opts.Rate <= 0 {
+		return nil
+	}
+	if opts.Burst < 1 {
+		opts.Burst = 1
+	}
+	return &Limiter{opts: opts, keys: make(map[string]*limitState)}
+}
+
+// Check decides about a message of key and records it. For a Repeat it also
+// returns how many repeats in a row the message is, starting at 1. Repeats
+// do not count against the rate.
+func (l *Limiter) Check(key, text string) (Verdict, int) {
+	if l == nil {
+		return Allow, 0
+	}
+	now := time.Now()
+	norm := normalizeRepeat(text)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.keys[key]
+	if !ok {
+		if len(l.keys) >= maxLimiterKeys {
+			l.forgetIdle(now)
+		}
+		s = &limitState{tokens: float64(l.opts.Burst), filled: now}
+		l.keys[key] = s
+	}
+
+	repeat := l.opts.DedupeWindow > 0 && norm == s.text && now.Sub(s.seen) < l.opts.DedupeWindow
+	s.text, s.seen = norm, now
+	if repeat {
+		s.repeats++
+		return Repeat, s.repeats
+	}
+	s.repeats = 0
+	if l.opts.Rate > 0 {
+		s.tokens = min(float64(l.opts.Burst), s.tokens+now.Sub(s.filled).Seconds()*l.opts.Rate)
+		s.filled = now
+		if s.tokens < 1 {
+			return RateLimited, 0
+		}
+		s.tokens--
+	}
+	return Allow, 0
+}
+
+// forgetIdle drops keys whose state no longer matters: past the dedupe
+// window and with a full bucket again; l.mu must be held
+func (l *Limiter) forgetIdle(now time.Time) {
+	idle := l.opts.DedupeWindow
+	if l.opts.Rate > 0 {
+		idle = max(idle, time.Duration(float64(l.opts.Burst)/l.opts.Rate*float64(time.Second)))
+	}
+	for key, s := range l.keys {
+		if now.Sub(s.seen) >= idle {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// normalizeRepeat folds case, spacing and runs of one character, so "?????"
+// and "???", or "GG" and "gg ", count as the same message
+func normalizeRepeat(text string) string {
+	var b strings.Builder
+	prev := rune(-1)
+	for _, r := range strings.ToLower(strings.Join(strings.Fields(text), " ")) {
+		if r != prev {
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Priority orders work when the translator is saturated; higher goes first
+// and equal priorities go in the order they arrived
+type Priority int
+
+const (
+	PriorityAll   Priority = iota // all-chat banter
+	PriorityTeam                  // team chat
+	PriorityVoice                 // voice, the most time-critical
+)
+
+// prioItem is a value waiting in a prioQueue
+type prioItem[T any] struct {
+	value T
+	prio  Priority
+	seq   uint64 // arrival order
+	index int    // position in the heap, kept by prioQueue
+}
+
+// prioQueue is a container/heap of items, highest priority first
+type prioQueue[T any] []*prioItem[T]
+
+func (q prioQueue[T]) Len() int { return len(q) }
+
+func (q prioQueue[T]) Less(i, j int) bool {
+	if q[i].prio != q[j].prio {
+		return q[i].prio > q[j].prio
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q prioQueue[T]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *prioQueue[T]) Push(x any) {
+	item := x.(*prioItem[T])
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *prioQueue[T]) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	item.index = -1
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Gate bounds the requests in flight to a translator shared by several
+// callers, such as the chat workers and voice, and hands each freed place
+// to the waiter with the highest priority. A nil Gate admits everything.
+type Gate struct {
+	mu      sync.Mutex
+	free    int
+	seq     uint64
+	waiting prioQueue[chan struct{}]
+}
+
+// NewGate returns a gate for n requests at a time
+func NewGate(n int) *Gate {
+	return &Gate{free: max(n, 1)}
+}
+
+// Acquire waits for a place and returns the function that frees it again,
+// or ctx's error when ctx ends first
+func (g *Gate) Acquire(ctx context.Context, prio Priority) (release func(), err error) {
+	if g == nil {
+		return func() {}, nil
+	}
+	g.mu.Lock()
+	if g.free > 0 && g.waiting.Len() == 0 {
+		g.free--
+		g.mu.Unlock()
+		return g.releaser(), nil
+	}
+	item := &prioItem[chan struct{}]{value: make(chan struct{}), prio: prio, seq: g.seq}
+	g.seq++
+	heap.Push(&g.waiting, item)
+	g.mu.Unlock()
+
+	select {
+	case <-item.value:
+		return g.releaser(), nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if item.index >= 0 {
+			heap.Remove(&g.waiting, item.index)
+		} else {
+			// The place was handed over just as ctx ended
+			g.handOver()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// releaser returns a release function that is safe to call twice
+func (g *Gate) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.handOver()
+		})
+	}
+}
+
+// handOver gives a freed place to the first waiter; g.mu must be held
+func (g *Gate) handOver() {
+	if g.waiting.Len() == 0 {
+		g.free++
+		return
+	}
+	close(heap.Pop(&g.waiting).(*prioItem[chan struct{}]).value)
+}
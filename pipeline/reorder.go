@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// finished is a result with the submission order of its job
+type finished struct {
+	seq     uint64
+	res     Result
+	arrived time.Time
+}
+
+// reorder delivers results in the order their jobs were submitted. A result
+// that finished before an earlier job's waits at most Options.ReorderWindow;
+// then it goes out and the earlier result follows when it is done.
+func (d *Dispatcher) reorder() {
+	defer close(d.reordered)
+	var (
+		next  uint64     // seq of the result due next
+		held  []finished // results waiting for an earlier one, by seq
+		timer = time.NewTimer(time.Hour)
+	)
+	timer.Stop()
+	defer timer.Stop()
+
+	send := func(res Result) bool {
+		select {
+		case d.results <- res:
+			d.delivered()
+			return true
+		case <-d.ctx.Done():
+			return false
+		}
+	}
+	for {
+		for len(held) > 0 && held[0].seq == next {
+			if !send(held[0].res) {
+				return
+			}
+			held = held[1:]
+			next++
+		}
+		if len(held) > 0 {
+			oldest := held[0].arrived
+			for _, h := range held[1:] {
+				if h.arrived.Before(oldest) {
+					oldest = h.arrived
+				}
+			}
+			timer.Reset(time.Until(oldest.Add(d.opts.ReorderWindow)))
+		}
+
+		select {
+		case f := <-d.finished:
+			timer.Stop()
+			if f.seq < next {
+				// Its turn was skipped; it is late already
+				if !send(f.res) {
+					return
+				}
+				continue
+			}
+			i, _ := slices.BinarySearchFunc(held, f.seq, func(h finished, seq uint64) int {
+				return cmp.Compare(h.seq, seq)
+			})
+			held = slices.Insert(held, i, f)
+
+		case <-timer.C:
+			// Give up on the jobs before the results that waited too long
+			cutoff := time.Now().Add(-d.opts.ReorderWindow)
+			last := -1
+			for i, h := range held {
+				if !h.arrived.After(cutoff) {
+					last = i
+				}
+			}
+			for _, h := range held[:last+1] {
+				if !send(h.res) {
+					return
+				}
+			}
+			if last >= 0 {
+				next = held[last].seq + 1
+				held = held[last+1:]
+			}
+
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/plugins"
+)
+
+// startPlugins starts the configured plugins, subscribes them to the bus
+// and puts the filters in front of every sink. The returned function stops
+// them again.
+func startPlugins(ctx context.Context, configs []plugins.Config) (stop func()) {
+	var started []*plugins.Plugin
+	var undo []func()
+	for _, c := range configs {
+		p, err := plugins.Start(ctx, c)
+		if err != nil {
+			slog.Warn("Plugin not started", "err", err)
+			continue
+		}
+		fmt.Print(i18n.T("Started plugin %s\n", p.Name()))
+		if p.IsFilter() {
+			undo = append(undo, bus.AddFilter(p.Filter))
+		}
+		undo = append(undo, bus.Subscribe(p.Send))
+		started = append(started, p)
+	}
+	return func() {
+		for _, u := range undo {
+			u()
+		}
+		for _, p := range started {
+			p.Close()
+		}
+	}
+}
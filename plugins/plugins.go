@@ -0,0 +1,287 @@
+// Package plugins runs long-lived external programs that extend the
+// pipeline without changing it. A plugin speaks JSON Lines on stdin and
+// stdout: it is sent the events it subscribed to and, as a filter, each
+// chat and voice translation before it is shown, and answers whether to
+// keep, change, highlight or drop it. Anything it writes to stderr is
+// logged.
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+const (
+	// DefaultTimeout is how long a filter may take to answer before the
+	// translation is shown unchanged
+	DefaultTimeout = 500 * time.Millisecond
+
+	// queueSize bounds events waiting to be written to a plugin; a plugin
+	// that falls further behind misses events
+	queueSize = 64
+	// maxReply bounds one line a plugin writes
+	maxReply = 1 << 20
+)
+
+// Config starts one plugin
+type Config struct {
+	Name    string
+	Command []string
+	Events  []string      // event kinds to send, e.g. "translation_done"; empty sends all
+	Filter  bool          // translations wait for the plugin's answer
+	Timeout time.Duration // for a filter answer
+}
+
+// Message is one line written to a plugin. Event holds the fields of the
+// event as events.Fields returns them.
+type Message struct {
+	Type  string         `json:"type"` // "event", or "filter" for an answer
+	ID    uint64         `json:"id,omitempty"`
+	Event map[string]any `json:"event"`
+}
+
+// Reply is a filter's answer to the Message with the same ID
+type Reply struct {
+	ID         uint64  `json:"id"`
+	Drop       bool    `json:"drop,omitempty"`       // do not show the translation
+	Translated *string `json:"translated,omitempty"` // replaces the translation
+	Highlight  bool    `json:"highlight,omitempty"`
+}
+
+// Plugin is a running plugin process
+type Plugin struct {
+	cfg   Config
+	kinds map[string]bool
+
+	cmd      *exec.Cmd
+	queue    chan []byte
+	stop     chan struct{} // closed by Close
+	stopOnce sync.Once
+	done     chan struct{} // closed when the process exited
+
+	mu      sync.Mutex
+	next    uint64
+	waiting map[uint64]chan Reply
+}
+
+// Start runs the plugin's command. It is killed when ctx ends.
+func Start(ctx context.Context, cfg Config) (*Plugin, error) {
+	if len(cfg.Command) == 0 {
+		return nil, fmt.Errorf("plugin %s has no command", cfg.Name)
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Command[0]
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	p := &Plugin{
+		cfg:     cfg,
+		kinds:   make(map[string]bool),
+		queue:   make(chan []byte, queueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		waiting: make(map[uint64]chan Reply),
+	}
+	for _, k := range cfg.Events {
+		p.kinds[k] = true
+	}
+
+	p.cmd = execwrap.CommandContext(ctx, cfg.Command[0], cfg.Command[1:]...)
+	p.cmd.Env = append(p.cmd.Environ(), "CS_TRANSLATE_PLUGIN="+cfg.Name)
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := p.cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", cfg.Name, err)
+	}
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		p.readReplies(stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		p.logOutput(stderr)
+	}()
+	go p.write(stdin)
+	go func() {
+		readers.Wait()
+		err := p.cmd.Wait()
+		close(p.done)
+		select {
+		case <-p.stop:
+		default:
+			slog.Warn("Plugin exited", "plugin", cfg.Name, "err", err)
+		}
+	}()
+	return p, nil
+}
+
+// Name returns the plugin's name
+func (p *Plugin) Name() string {
+	return p.cfg.Name
+}
+
+// IsFilter reports whether translations wait for the plugin
+func (p *Plugin) IsFilter() bool {
+	return p.cfg.Filter
+}
+
+// Send queues e for the plugin if it subscribed to its kind. It does not
+// wait; the event is skipped when the plugin is too far behind.
+func (p *Plugin) Send(e events.Event) {
+	if len(p.kinds) > 0 && !p.kinds[e.Kind()] {
+		return
+	}
+	p.enqueue(Message{Type: "event", Event: events.Fields(e)})
+}
+
+// Filter asks the plugin about a chat or voice translation and applies its
+// answer. Other events, and translations the plugin does not answer in
+// time, pass unchanged.
+func (p *Plugin) Filter(e events.Event) (events.Event, bool) {
+	t, ok := e.(events.TranslationDone)
+	if !ok || t.Err != nil || t.Superseded || (t.Source != "chat" && t.Source != "voice") {
+		return e, true
+	}
+
+	p.mu.Lock()
+	p.next++
+	id := p.next
+	reply := make(chan Reply, 1)
+	p.waiting[id] = reply
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.waiting, id)
+		p.mu.Unlock()
+	}()
+
+	if !p.enqueue(Message{Type: "filter", ID: id, Event: events.Fields(t)}) {
+		return e, true
+	}
+	timer := time.NewTimer(p.cfg.Timeout)
+	defer timer.Stop()
+	select {
+	case r := <-reply:
+		if r.Drop {
+			return e, false
+		}
+		if r.Translated != nil {
+			t.Translated = *r.Translated
+		}
+		t.Highlight = t.Highlight || r.Highlight
+		return t, true
+	case <-timer.C:
+		slog.Warn("Plugin did not answer in time; showing the translation unchanged", "plugin", p.cfg.Name, "timeout", p.cfg.Timeout)
+	case <-p.done:
+	}
+	return e, true
+}
+
+// enqueue hands m to the writer; false when the plugin is behind or gone
+func (p *Plugin) enqueue(m Message) bool {
+	line, err := json.Marshal(m)
+	if err != nil {
+		slog.Error("Plugin: failed to encode message", "plugin", p.cfg.Name, "type", m.Type, "err", err)
+		return false
+	}
+	select {
+	case <-p.stop:
+		return false
+	case <-p.done:
+		return false
+	default:
+	}
+	select {
+	case p.queue <- append(line, '\n'):
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Plugin) write(stdin io.WriteCloser) {
+	defer stdin.Close()
+	for {
+		select {
+		case line := <-p.queue:
+			if _, err := stdin.Write(line); err != nil {
+				return
+			}
+		case <-p.stop:
+			return
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *Plugin) readReplies(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64<<10), maxReply)
+	for scanner.Scan() {
+		var r Reply
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			slog.Warn("Plugin: invalid reply", "plugin", p.cfg.Name, "err", err)
+			continue
+		}
+		p.mu.Lock()
+		ch, ok := p.waiting[r.ID]
+		p.mu.Unlock()
+		if ok {
+			select {
+			case ch <- r:
+			default: // answered twice
+			}
+		}
+	}
+	// An overlong line stops the scanner; a plugin nobody reads from would
+	// block on its next write and leave every filter call to time out
+	if err := scanner.Err(); err != nil {
+		slog.Error("Plugin: failed to read its replies; stopping it", "plugin", p.cfg.Name, "err", err)
+		p.cmd.Process.Kill()
+	}
+}
+
+func (p *Plugin) logOutput(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		slog.Info(scanner.Text(), "plugin", p.cfg.Name)
+	}
+}
+
+// Close stops the plugin by closing its stdin and kills it if it has not
+// exited a second later
+func (p *Plugin) Close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	select {
+	case <-p.done:
+	case <-time.After(time.Second):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/events"
+)
+
+// testTimeout bounds the wait for a plugin that is expected to answer
+const testTimeout = 10 * time.Second
+
+// As with execwrap.Fake, plugins are this test binary run again; the name
+// the plugin is started with, which it gets as CS_TRANSLATE_PLUGIN, picks
+// how it answers
+func TestMain(m *testing.M) {
+	if name := os.Getenv("CS_TRANSLATE_PLUGIN"); name != "" {
+		servePlugin(name)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// servePlugin answers filter messages on stdin until it is closed
+func servePlugin(name string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var m Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil || m.Type != "filter" {
+			continue
+		}
+		r := Reply{ID: m.ID}
+		switch name {
+		case "drop":
+			r.Drop = true
+		case "replace":
+			translated := strings.ToUpper(m.Event["translated"].(string))
+			r.Translated = &translated
+		case "highlight":
+			r.Highlight = true
+		case "silent":
+			continue
+		case "overlong":
+			os.Stdout.WriteString(strings.Repeat("x", 2*maxReply) + "\n")
+		}
+		out.Encode(r)
+	}
+}
+
+// startPlugin runs the test binary as the plugin name, closed when the
+// test ends
+func startPlugin(t *testing.T, name string, timeout time.Duration) *Plugin {
+	t.Helper()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Start(t.Context(), Config{Name: name, Command: []string{self}, Filter: true, Timeout: timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func chat(translated string) events.TranslationDone {
+	return events.TranslationDone{Source: "chat", Original: "го б", Translated: translated}
+}
+
+// Filters drop, replace and highlight chat translations, and pass on
+// translations from elsewhere and other events without asking
+func TestFilter(t *testing.T) {
+	e, keep := startPlugin(t, "drop", testTimeout).Filter(chat("go b"))
+	if keep {
+		t.Fatalf("dropped translation was kept: %+v", e)
+	}
+
+	p := startPlugin(t, "replace", testTimeout)
+	e, keep = p.Filter(chat("go b"))
+	if td := e.(events.TranslationDone); !keep || td.Translated != "GO B" || td.Highlight {
+		t.Fatalf("replaced translation came out as %+v, kept %t", td, keep)
+	}
+	talk := chat("go b")
+	talk.Source = "talk"
+	if e, keep = p.Filter(talk); !keep || e.(events.TranslationDone).Translated != "go b" {
+		t.Fatalf("own talk was filtered: %+v", e)
+	}
+	status := events.Status{Message: "ready"}
+	if e, keep = p.Filter(status); !keep || e != events.Event(status) {
+		t.Fatalf("status event was filtered: %+v", e)
+	}
+
+	e, keep = startPlugin(t, "highlight", testTimeout).Filter(chat("go b"))
+	if td := e.(events.TranslationDone); !keep || td.Translated != "go b" || !td.Highlight {
+		t.Fatalf("highlighted translation came out as %+v, kept %t", td, keep)
+	}
+}
+
+// A filter that does not answer in time lets the translation through as it
+// was
+func TestFilterTimeout(t *testing.T) {
+	p := startPlugin(t, "silent", 100*time.Millisecond)
+	start := time.Now()
+	e, keep := p.Filter(chat("go b"))
+	if !keep || e.(events.TranslationDone).Translated != "go b" {
+		t.Fatalf("unanswered translation came out as %+v, kept %t", e, keep)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > testTimeout {
+		t.Fatalf("gave up after %v, want the 100ms timeout", elapsed)
+	}
+}
+
+// A reply longer than maxReply stops the plugin, so the calls after it do
+// not wait out the timeout one by one
+func TestFilterOverlongReply(t *testing.T) {
+	p := startPlugin(t, "overlong", testTimeout)
+	start := time.Now()
+	for range 3 {
+		if e, keep := p.Filter(chat("go b")); !keep || e.(events.TranslationDone).Translated != "go b" {
+			t.Fatalf("translation came out as %+v, kept %t", e, keep)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= testTimeout {
+		t.Fatalf("filter calls took %v, the plugin was left blocked", elapsed)
+	}
+	select {
+	case <-p.done:
+	case <-time.After(testTimeout):
+		t.Fatal("plugin still running after an overlong reply")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/tempdir"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// practiceTimeout bounds the wait for the transcription of one sentence
+const practiceTimeout = time.Minute
+
+// practiceResult is what practice mode shows for one sentence
+type practiceResult struct {
+	heard      audio.Transcription
+	translated string
+	feedback   translator.Feedback
+}
+
+// runPracticeCommand is practice mode, a language-learning aid between
+// matches: say a sentence in the language you are learning and see what
+// Whisper heard, its translation and a grade of the phrasing
+func runPracticeCommand(args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Warning: %v (using defaults)\n", err)
+	}
+	cfg.ApplyEnv()
+	translator.Configure(cfg.HTTPSettings())
+
+	fs := flag.NewFlagSet("practice", flag.ExitOnError)
+	lang := fs.String("lang", cfg.Talk.Lang, "Language you are practicing")
+	native := fs.String("native", cfg.Lang, "Language of the translations and tips")
+	mic := fs.String("mic", cfg.Talk.Mic, "Microphone to record (default: system default on Linux)")
+	fs.Parse(args)
+	useFFmpeg(cfg.FFmpeg)
+	if err := setPrivacy(cfg.Privacy, cfg.PlayerName); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := practice(ctx, cfg, *lang, *native, *mic); err != nil && ctx.Err() == nil {
+		fmt.Printf("%s: %v\n", display.Paint(display.BoldRed, display.CrossMark+" practice"), err)
+		printHint(err)
+		os.Exit(1)
+	}
+}
+
+// practice records sentences from mic until ctx ends; Enter starts one and
+// Enter again ends it
+func practice(ctx context.Context, cfg config.Config, lang, native, mic string) error {
+	input, err := micInput(mic)
+	if err != nil {
+		return err
+	}
+	dir, err := tempdir.New("", "cs-practice-rec")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	rec, err := newRecorder(ctx, dir, input)
+	if err != nil {
+		return err
+	}
+	defer rec.stop()
+
+	// Whisper listens for the language practiced, and keeps what it is
+	// unsure of: an accent is graded, not dropped
+	opts := cfg.WhisperSettings(true)
+	opts.Languages = []string{translator.LanguageCode(lang)}
+	opts.Filter.MinAvgLogProb = 0
+	listener := initAudioListener(true, opts)
+	if listener == nil {
+		return errors.New("the transcriber did not start; run cs-translate doctor")
+	}
+	defer listener.Stop()
+
+	backend := ollamaBackend(cfg, cfg.Model)
+	tr, err := translator.NewChain(ctx, []translator.BackendConfig{backend}, native)
+	if err != nil {
+		return err
+	}
+	defer tr.Close()
+	tutor := translator.NewTutor(backend)
+
+	fmt.Printf("Practice mode: press Enter, say a sentence in %s, and press Enter again. Ctrl+C ends practice.\n", translator.LanguageName(lang))
+	lines := readCommands(bufio.NewScanner(os.Stdin))
+	next := func() bool {
+		select {
+		case _, ok := <-lines:
+			return ok
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for next() {
+		from := time.Now()
+		fmt.Println(display.Paint(display.BoldCyan, "[practice] Listening... press Enter when done"))
+		if !next() {
+			return nil
+		}
+		if time.Since(from) < minHold {
+			continue
+		}
+		heard, err := practiceTranscribe(ctx, rec, listener, from)
+		if err != nil {
+			fmt.Printf("%s %v\n", display.CrossMark, err)
+			continue
+		}
+		result, err := gradeSpeech(ctx, heard, tr, tutor, lang, native)
+		if err != nil {
+			fmt.Printf("%s %v\n", display.CrossMark, err)
+			continue
+		}
+		printPractice(result)
+	}
+	return nil
+}
+
+// practiceTranscribe hands the recording since from to the transcriber and
+// waits for what it heard
+func practiceTranscribe(ctx context.Context, rec *echoRecorder, listener *audio.Listener, from time.Time) (audio.Transcription, error) {
+	fmt.Println(display.Paint(display.BoldCyan, "[practice] Transcribing..."))
+	err := rec.capture(from, listener.OutputDir(), func(path string) {
+		listener.SubmitSpeech(path, talkSpeaker)
+	})
+	if err != nil {
+		return audio.Transcription{}, err
+	}
+	select {
+	case t, ok := <-listener.Transcriptions():
+		if !ok {
+			return audio.Transcription{}, errors.New("the transcriber stopped")
+		}
+		if strings.TrimSpace(t.Text) == "" {
+			return audio.Transcription{}, errors.New("Whisper heard no speech; speak closer to the microphone")
+		}
+		return t, nil
+	case <-time.After(practiceTimeout):
+		return audio.Transcription{}, fmt.Errorf("no transcription within %s", practiceTimeout)
+	case <-ctx.Done():
+		return audio.Transcription{}, ctx.Err()
+	}
+}
+
+// gradeSpeech translates what was heard into native and has the tutor grade
+// it as said in lang
+func gradeSpeech(ctx context.Context, heard audio.Transcription, tr translator.Translator, tutor *translator.Tutor, lang, native string) (practiceResult, error) {
+	translated, err := tr.TranslateWithContext(ctx, translator.Request{
+		Text: heard.Text, Kind: translator.KindVoice, SourceLang: translator.LanguageCode(lang),
+		Private: privateVoice(),
+	})
+	if err != nil {
+		return practiceResult{}, fmt.Errorf("translation failed: %w", err)
+	}
+	feedback, err := tutor.Grade(ctx, heard.Text, lang, native, heard.Confidence, privateVoice())
+	if err != nil {
+		return practiceResult{}, fmt.Errorf("grading failed: %w", err)
+	}
+	return practiceResult{heard: heard, translated: translated, feedback: feedback}, nil
+}
+
+// printPractice shows what was heard, its translation and the grade
+func printPractice(r practiceResult) {
+	fmt.Printf("  Heard:      %s (clarity %.0f%%)\n", r.heard.Text, r.heard.Confidence*100)
+	fmt.Printf("  Translated: %s\n", r.translated)
+	fmt.Printf("  Phrasing:   %d/10\n", r.feedback.Score)
+	if r.feedback.Corrected != "" && !strings.EqualFold(strings.Trim(r.feedback.Corrected, " .!?"), strings.Trim(r.heard.Text, " .!?")) {
+		fmt.Printf("  Better:     %s\n", r.feedback.Corrected)
+	}
+	if r.feedback.Tip != "" {
+		fmt.Printf("  Tip:        %s\n", r.feedback.Tip)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// A sentence said in practice mode is translated and graded, and a grade
+// without a score is an error rather than 0/10
+func TestPractice(t *testing.T) {
+	ctx := t.Context()
+	o := fakegame.NewOllama()
+	defer o.Close()
+	translator.OllamaHost = o.URL
+	backend := translator.BackendConfig{Type: translator.BackendOllama, Model: "fake"}
+	tr, err := translator.NewChain(ctx, []translator.BackendConfig{backend}, "English")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+	tutor := translator.NewTutor(backend)
+
+	heard := audio.Transcription{Text: "где бомба", Confidence: 0.4}
+	result, err := gradeSpeech(ctx, heard, tr, tutor, "Russian", "English")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.translated != fakegame.Translation(heard.Text) {
+		t.Fatalf("translation is %q, want %q", result.translated, fakegame.Translation(heard.Text))
+	}
+	f := result.feedback
+	if f.Score != 7 || f.Corrected != heard.Text || f.Tip != fakegame.Translation("tip") {
+		t.Fatalf("grade is %+v", f)
+	}
+	if texts := o.Texts(); len(texts) != 2 || texts[1] != heard.Text {
+		t.Fatalf("the tutor was asked about %q", texts)
+	}
+
+	o.SetAnswer(func(text string) string { return `{"score": 0, "corrected": "", "tip": ""}` })
+	if _, err := gradeSpeech(ctx, heard, tr, tutor, "Russian", "English"); err == nil {
+		t.Fatalf("a grade without a score was accepted")
+	}
+}
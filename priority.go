@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// gatedTranslator waits for a place in the gate it shares with the chat
+// workers before each translation, so while the backend is saturated voice
+// is translated before queued chat
+type gatedTranslator struct {
+	translator.Translator
+	gate *pipeline.Gate
+	prio pipeline.Priority
+}
+
+func (g gatedTranslator) Translate(ctx context.Context, text string) (string, error) {
+	release, err := g.gate.Acquire(ctx, g.prio)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return g.Translator.Translate(ctx, text)
+}
+
+func (g gatedTranslator) TranslateWithContext(ctx context.Context, req translator.Request) (string, error) {
+	release, err := g.gate.Acquire(ctx, g.prio)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return g.Translator.TranslateWithContext(ctx, req)
+}
+
+func (g gatedTranslator) Unload() error {
+	return translator.Unload(g.Translator)
+}
+
+// chatPriority puts team chat ahead of all-chat
+func chatPriority(c events.ChatReceived) pipeline.Priority {
+	if teamChat(c) {
+		return pipeline.PriorityTeam
+	}
+	return pipeline.PriorityAll
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// Privacy modes for -privacy
+const (
+	privacyOff     = ""
+	privacyLocal   = "local"    // private messages only go to local backends
+	privacyAllChat = "all-chat" // private messages are not translated at all
+)
+
+// viaPrivate marks a message shown untranslated because of privacy mode
+const viaPrivate = "private"
+
+var (
+	// privacy is the -privacy mode
+	privacy string
+	// playerName is the user's own in-game name, "" when not set
+	playerName string
+)
+
+// setPrivacy checks and applies the privacy settings
+func setPrivacy(mode, name string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "off":
+		mode = privacyOff
+	case privacyOff, privacyLocal, privacyAllChat:
+	default:
+		return fmt.Errorf("unknown privacy mode %q (use off, %s or %s)", mode, privacyLocal, privacyAllChat)
+	}
+	privacy = mode
+	playerName = strings.TrimSpace(name)
+	return nil
+}
+
+// privateChat reports whether c must stay on this machine: team chat, which
+// only the user's own team sees, and the user's own messages
+func privateChat(c events.ChatReceived) bool {
+	if privacy == privacyOff {
+		return false
+	}
+	if playerName != "" && strings.EqualFold(c.Player, playerName) {
+		return true
+	}
+	return teamChat(c)
+}
+
+// privateVoice reports whether voice must stay on this machine; in-game
+// voice is heard from the user's own team
+func privateVoice() bool {
+	return privacy != privacyOff
+}
+
+// skipPrivate reports whether private messages are left untranslated
+func skipPrivate() bool {
+	return privacy == privacyAllChat
+}
+
+// ollamaBackend is the configured Ollama backend with model, for translators
+// outside the main chain, so they share its prompt and privacy setting
+func ollamaBackend(cfg config.Config, model string) translator.BackendConfig {
+	backend := translator.BackendConfig{Type: translator.BackendOllama, Prompt: cfg.Prompt, Structured: cfg.Structured}
+	for _, b := range cfg.BackendSettings() {
+		if b.Type == translator.BackendOllama {
+			backend = b
+			break
+		}
+	}
+	backend.Model = model
+	backend.Timeout = 0
+	return backend
+}
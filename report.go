@@ -0,0 +1,268 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/discord"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/logging"
+	"github.com/micha/cs-ingame-translate/mqtt"
+	"github.com/micha/cs-ingame-translate/obs"
+	"github.com/micha/cs-ingame-translate/setup"
+	"github.com/micha/cs-ingame-translate/telegram"
+	"github.com/micha/cs-ingame-translate/translator"
+	"github.com/micha/cs-ingame-translate/twitch"
+)
+
+// reportLogs are the files from the settings directory put in a report: the
+// log and the one rotated before it
+var reportLogs = []string{logging.FileName, logging.FileName + ".1"}
+
+// reportEnv are the environment variables shown in a report; reportSecretEnv
+// are only shown as set or not
+var (
+	reportEnv = []string{
+		"OLLAMA_HOST", "USE_DOCKER_OLLAMA", "USE_DOCKER_WHISPER", "LANG",
+		config.LogEnv, config.ProfileEnv, config.DirEnv, setup.GPUEnv,
+		"CS_TRANSLATE_DOCKER_MEMORY", "CS_TRANSLATE_DOCKER_CPUS",
+	}
+	reportSecretEnv = []string{discord.TokenEnv, obs.PasswordEnv, twitch.TokenEnv, telegram.TokenEnv, mqtt.PasswordEnv, translator.LibreTranslateKeyEnv}
+)
+
+func runReportCommand(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	out := fs.String("o", "", "Zip file to write (default: cs-translate-report-<time>.zip)")
+	fs.Parse(args)
+
+	path := *out
+	if path == "" {
+		path = "cs-translate-report-" + time.Now().Format("20060102-150405") + ".zip"
+	}
+	fmt.Println(i18n.T("Collecting logs, settings and the environment..."))
+	if err := writeReport(path); err != nil {
+		os.Remove(path)
+		fmt.Print(i18n.T("Error: %v\n", err))
+		os.Exit(1)
+	}
+	fmt.Print(i18n.T("Wrote %s. Secrets are redacted; it still holds file paths and log messages, so look through it before attaching it to an issue.\n", path))
+}
+
+// writeReport writes the bug report zip: environment.txt, the settings with
+// secrets redacted, the recent logs and those of the container
+func writeReport(path string) error {
+	cfg, cfgErr := config.Load()
+	useFFmpeg(cfg.FFmpeg)
+	secrets := config.Secrets(cfg)
+	for _, name := range reportSecretEnv {
+		if v := os.Getenv(name); v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	var pairs []string
+	for _, s := range secrets {
+		pairs = append(pairs, s, config.RedactedValue)
+	}
+	redact := strings.NewReplacer(pairs...)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	add := func(name string, data []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(redact.Replace(string(data))))
+		return err
+	}
+
+	if err := add("environment.txt", []byte(reportEnvironment(cfg, cfgErr))); err != nil {
+		return err
+	}
+	var settings bytes.Buffer
+	enc := json.NewEncoder(&settings)
+	enc.SetEscapeHTML(false) // keeps <redacted> readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(config.Redacted(cfg)); err != nil {
+		return err
+	}
+	if err := add(config.FileName, settings.Bytes()); err != nil {
+		return err
+	}
+	if dir, err := config.Dir(); err == nil {
+		for _, name := range reportLogs {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			if err := add(name, data); err != nil {
+				return err
+			}
+		}
+	}
+	if logs, err := reportCommand("docker", "logs", "--tail", "500", setup.ContainerName); err == nil {
+		if err := add("container.log", []byte(logs+"\n")); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// reportEnvironment describes the build, the system, the tools cs-translate
+// uses and the results of the doctor checks
+func reportEnvironment(cfg config.Config, cfgErr error) string {
+	var b strings.Builder
+	line := func(key, value string) { fmt.Fprintf(&b, "%-13s %s\n", key+":", value) }
+	tool := func(key, name string, arg ...string) {
+		out, err := reportCommand(name, arg...)
+		if err != nil {
+			line(key, err.Error())
+			return
+		}
+		line(key, firstLine(out))
+	}
+
+	line("cs-translate", buildVersion())
+	line("Go", fmt.Sprintf("%s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	line("OS", osVersion())
+	line("Created", time.Now().Format(time.RFC3339))
+	if path, err := config.Path(); err == nil {
+		line("Settings", path)
+	}
+	if cfgErr != nil {
+		line("Settings", cfgErr.Error())
+	}
+
+	gpu := setup.DetectGPU()
+	if chosen := setup.LoadWizardState().GPU; chosen != "" && chosen != gpu {
+		gpu += ", setup chose " + chosen
+	}
+	line("GPU", gpu)
+	if _, err := execwrap.LookPath("nvidia-smi"); err == nil {
+		tool("NVIDIA", "nvidia-smi", "--query-gpu=name,driver_version,memory.total", "--format=csv,noheader")
+	}
+	if runtime.GOOS == "windows" {
+		tool("Adapters", "powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"((Get-CimInstance Win32_VideoController).Name) -join ', '")
+	}
+	tool("Docker", "docker", "version", "--format", "client {{.Client.Version}}, server {{.Server.Version}}")
+	tool("Container", "docker", "ps", "-a", "--filter", "name="+setup.ContainerName, "--format", "{{.Names}} {{.Status}} ({{.Image}})")
+	tool("Ollama CLI", "ollama", "--version")
+	tool("FFmpeg", "ffmpeg", "-hide_banner", "-version")
+	line("Ollama", translator.OllamaHost)
+
+	b.WriteString("\nEnvironment:\n")
+	for _, name := range reportEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			fmt.Fprintf(&b, "  %s=%s\n", name, v)
+		}
+	}
+	for _, name := range reportSecretEnv {
+		if _, ok := os.LookupEnv(name); ok {
+			fmt.Fprintf(&b, "  %s is set\n", name)
+		}
+	}
+
+	b.WriteString("\nChecks:\n")
+	for _, c := range doctorChecks(cfg.Model, cfg.AudioDevice) {
+		detail, err := c.run()
+		if err != nil {
+			fmt.Fprintf(&b, "  FAIL %s: %v\n", c.name, err)
+			continue
+		}
+		fmt.Fprintf(&b, "  ok   %s: %s\n", c.name, detail)
+	}
+	return b.String()
+}
+
+// reportCommand runs a program for the report and returns its output. A
+// program that fails is reported with what it printed.
+func reportCommand(name string, arg ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := execwrap.CommandContext(ctx, name, arg...).CombinedOutput()
+	text := strings.TrimSpace(string(out))
+	if err != nil {
+		if text != "" {
+			return "", fmt.Errorf("%v: %s", err, firstLine(text))
+		}
+		return "", err
+	}
+	return text, nil
+}
+
+func firstLine(s string) string {
+	first, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(first)
+}
+
+// buildVersion returns the module version and VCS revision the binary was
+// built from
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision":
+			version += " " + s.Value[:min(12, len(s.Value))]
+		case s.Key == "vcs.modified" && s.Value == "true":
+			version += " (modified)"
+		}
+	}
+	return version
+}
+
+// osVersion names the operating system release
+func osVersion() string {
+	switch runtime.GOOS {
+	case "windows":
+		if out, err := reportCommand("cmd", "/c", "ver"); err == nil {
+			return out
+		}
+	case "darwin":
+		if out, err := reportCommand("sw_vers", "-productVersion"); err == nil {
+			return "macOS " + out
+		}
+	case "linux":
+		name := "Linux"
+		if data, err := os.ReadFile("/etc/os-release"); err == nil {
+			for _, l := range strings.Split(string(data), "\n") {
+				if v, ok := strings.CutPrefix(l, "PRETTY_NAME="); ok {
+					name = strings.Trim(v, `"`)
+				}
+			}
+		}
+		if kernel, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+			name += ", kernel " + strings.TrimSpace(string(kernel))
+		}
+		return name
+	}
+	return runtime.GOOS
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/resources"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// resourceInterval is how often the GPU and Ollama's models are sampled
+// for the status line (-resource-interval, 0 disables)
+var resourceInterval = resources.DefaultInterval
+
+// watchResources announces the GPU and model load as a status line when
+// models load, unload or spill onto the CPU, or VRAM fills up, until ctx
+// ends or the returned function, which waits for the sampling to end, is
+// called
+func watchResources(ctx context.Context) (stop func()) {
+	if resourceInterval <= 0 {
+		return func() {}
+	}
+	changes, stopWatch := resources.Watch(ctx, resourceInterval, translator.OllamaHost)
+	announced := make(chan struct{})
+	go func() {
+		defer close(announced)
+		for s := range changes {
+			bus.Publish(resourceStatus(s))
+		}
+	}()
+	return func() {
+		stopWatch()
+		<-announced
+	}
+}
+
+// resourceStatus is the status line announcing s
+func resourceStatus(s resources.Snapshot) events.Status {
+	state := "ok"
+	if s.Strained() {
+		state = "strained"
+	}
+	return events.Status{Source: "resources", State: state, Message: s.String()}
+}
+
+// sampleResources is the GPU and model load right now, and false when
+// sampling is disabled
+func sampleResources(ctx context.Context) (resources.Snapshot, bool) {
+	if resourceInterval <= 0 {
+		return resources.Snapshot{}, false
+	}
+	return resources.Sample(ctx, translator.OllamaHost), true
+}
+
+// explainLatency shows the GPU and model load after translations slowed
+// down, in the background as nvidia-smi can take a moment
+func explainLatency() {
+	go func() {
+		if s, ok := sampleResources(context.Background()); ok && s.String() != "" {
+			bus.Publish(resourceStatus(s))
+		}
+	}()
+}
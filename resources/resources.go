@@ -0,0 +1,279 @@
+// Package resources samples what the GPU is busy with: its load and memory
+// from nvidia-smi and the models Ollama holds from /api/ps. A latency spike
+// can then be told apart from Whisper and the LLM competing for VRAM, or a
+// model that no longer fits and runs partly on the CPU.
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// DefaultInterval is how often the GPU and Ollama are sampled
+const DefaultInterval = 10 * time.Second
+
+// strainedVRAM is the share of VRAM in use above which models start to
+// push each other out
+const strainedVRAM = 0.9
+
+// GPU is the state of one graphics card
+type GPU struct {
+	Name    string `json:"name"`
+	Load    int    `json:"load_percent"`
+	UsedMB  int    `json:"vram_used_mb"`
+	TotalMB int    `json:"vram_total_mb"`
+}
+
+// Process is a program holding VRAM, e.g. ollama or Whisper's python
+type Process struct {
+	Name   string `json:"name"`
+	UsedMB int    `json:"vram_mb"`
+}
+
+// Model is a model Ollama has loaded
+type Model struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`      // bytes it takes in all
+	VRAM int64  `json:"size_vram"` // bytes of it on the GPU
+}
+
+// OnCPU is the share of m that did not fit in VRAM, from 0 to 1
+func (m Model) OnCPU() float64 {
+	if m.Size <= 0 || m.VRAM >= m.Size {
+		return 0
+	}
+	return 1 - float64(m.VRAM)/float64(m.Size)
+}
+
+// Snapshot is one sample; parts that could not be read are empty
+type Snapshot struct {
+	GPUs      []GPU     `json:"gpus"`
+	Processes []Process `json:"gpu_processes"`
+	Models    []Model   `json:"models"`
+	Ollama    bool      `json:"ollama"` // /api/ps answered
+}
+
+// Strained reports whether VRAM is nearly full or a model runs partly on
+// the CPU, the usual reasons translations suddenly slow down
+func (s Snapshot) Strained() bool {
+	for _, g := range s.GPUs {
+		if g.TotalMB > 0 && float64(g.UsedMB) >= strainedVRAM*float64(g.TotalMB) {
+			return true
+		}
+	}
+	for _, m := range s.Models {
+		if m.OnCPU() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String is the sample in one line, e.g. "GPU 87%, VRAM 7.1/8.0 GB (ollama
+// 5.1 GB, python3 1.2 GB) | gemma3:4b on GPU"; "" when nothing was read
+func (s Snapshot) String() string {
+	var parts []string
+	for _, g := range s.GPUs {
+		part := fmt.Sprintf("GPU %d%%, VRAM %.1f/%s", g.Load, float64(g.UsedMB)/1024, gigabytes(int64(g.TotalMB)<<20))
+		var procs []string
+		for _, p := range s.Processes {
+			procs = append(procs, p.Name+" "+gigabytes(int64(p.UsedMB)<<20))
+		}
+		if len(procs) > 0 && len(s.GPUs) == 1 {
+			part += " (" + strings.Join(procs, ", ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	if s.Ollama {
+		parts = append(parts, s.models())
+	}
+	return strings.Join(parts, " | ")
+}
+
+// models says where each loaded model runs
+func (s Snapshot) models() string {
+	if len(s.Models) == 0 {
+		return "no model loaded"
+	}
+	var out []string
+	for _, m := range s.Models {
+		switch cpu := m.OnCPU(); {
+		case m.VRAM == 0:
+			out = append(out, m.Name+" on CPU")
+		case cpu > 0:
+			out = append(out, fmt.Sprintf("%s %.0f%% on CPU", m.Name, cpu*100))
+		default:
+			out = append(out, m.Name+" on GPU")
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// Key identifies what is worth announcing about s: which models are loaded
+// and where, which programs hold VRAM and whether it is strained, but not
+// the load or memory that change with every sample
+func (s Snapshot) Key() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%t|%t|%s|", s.Strained(), s.Ollama, s.models())
+	for _, p := range s.Processes {
+		b.WriteString(p.Name + ",")
+	}
+	return b.String()
+}
+
+// Sample reads the GPU, if nvidia-smi is installed, and the models Ollama
+// at host holds
+func Sample(ctx context.Context, host string) Snapshot {
+	var s Snapshot
+	if _, err := execwrap.LookPath("nvidia-smi"); err == nil {
+		s.GPUs = gpus(ctx)
+		s.Processes = processes(ctx)
+	}
+	if models, err := loadedModels(host); err == nil {
+		s.Models, s.Ollama = models, true
+	}
+	return s
+}
+
+// Watch samples every interval and sends the first sample and then each
+// one whose Key changed. The channel is closed when ctx ends or stop is
+// called; stop returns once the last sample has finished.
+func Watch(ctx context.Context, interval time.Duration, host string) (changes <-chan Snapshot, stop func()) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan Snapshot, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(out)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := ""
+		for {
+			s := Sample(ctx, host)
+			if key := s.Key(); key != last && s.String() != "" {
+				last = key
+				select {
+				case out <- s:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, func() {
+		cancel()
+		<-done
+	}
+}
+
+// gpus asks nvidia-smi for the load and memory of each card
+func gpus(ctx context.Context) []GPU {
+	rows := query(ctx, "--query-gpu=name,utilization.gpu,memory.used,memory.total")
+	var out []GPU
+	for _, f := range rows {
+		if len(f) < 4 {
+			continue
+		}
+		g := GPU{Name: f[0]}
+		g.Load, _ = strconv.Atoi(f[1])
+		g.UsedMB, _ = strconv.Atoi(f[2])
+		g.TotalMB, _ = strconv.Atoi(f[3])
+		out = append(out, g)
+	}
+	return out
+}
+
+// processes asks nvidia-smi for the programs holding VRAM, largest first.
+// Inside Docker the names are those of the container's processes.
+func processes(ctx context.Context) []Process {
+	rows := query(ctx, "--query-compute-apps=process_name,used_memory")
+	used := map[string]int{}
+	for _, f := range rows {
+		if len(f) < 2 {
+			continue
+		}
+		mb, err := strconv.Atoi(f[1])
+		if err != nil {
+			continue
+		}
+		// Windows names the whole path, with either separator
+		name := filepath.Base(strings.ReplaceAll(f[0], `\`, "/"))
+		used[strings.TrimSuffix(name, ".exe")] += mb
+	}
+	var out []Process
+	for name, mb := range used {
+		out = append(out, Process{Name: name, UsedMB: mb})
+	}
+	slices.SortFunc(out, func(a, b Process) int {
+		if a.UsedMB != b.UsedMB {
+			return b.UsedMB - a.UsedMB
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+// query runs nvidia-smi with a --query option and returns its CSV rows
+func query(ctx context.Context, q string) [][]string {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	out, err := execwrap.CommandContext(ctx, "nvidia-smi", q, "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil
+	}
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		rows = append(rows, fields)
+	}
+	return rows
+}
+
+// loadedModels asks Ollama which models it holds
+func loadedModels(host string) ([]Model, error) {
+	resp, err := translator.GetWithTimeout(host+"/api/ps", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama /api/ps: %s", resp.Status)
+	}
+	var body struct {
+		Models []Model `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("ollama /api/ps: %w", err)
+	}
+	slices.SortFunc(body.Models, func(a, b Model) int { return strings.Compare(a.Name, b.Name) })
+	return body.Models, nil
+}
+
+// gigabytes formats n bytes to one decimal
+func gigabytes(n int64) string {
+	return strconv.FormatFloat(float64(n)/(1<<30), 'f', 1, 64) + " GB"
+}
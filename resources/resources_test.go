@@ -0,0 +1,82 @@
+package resources_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/resources"
+)
+
+// testTimeout bounds the wait for a sample
+const testTimeout = 10 * time.Second
+
+func TestMain(m *testing.M) {
+	// Commands faked by execwrap run the test binary again to answer
+	execwrap.ServeFake()
+	os.Exit(m.Run())
+}
+
+// The status line shows GPU load, VRAM and who holds it from nvidia-smi and
+// where Ollama's models run from /api/ps, and is announced again only when a
+// model spills onto the CPU, which strains it
+func TestWatch(t *testing.T) {
+	ctx := t.Context()
+	o := fakegame.NewOllama()
+	defer o.Close()
+	o.SetLoaded("gemma3:4b", 4<<30, 4<<30)
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"nvidia-smi --query-gpu=name,utilization.gpu,memory.used,memory.total --format=csv,noheader,nounits": {Stdout: "NVIDIA GeForce RTX 3070, 87, 7270, 8192\n"},
+		"nvidia-smi --query-compute-apps=process_name,used_memory --format=csv,noheader,nounits":             {Stdout: "/usr/bin/ollama, 5222\npython3, 1229\n"},
+	}}
+	defer execwrap.Use(fake)()
+
+	changes, stop := resources.Watch(ctx, 50*time.Millisecond, o.URL)
+	// Sampling has to end before the runner is restored
+	defer stop()
+	next := func() (resources.Snapshot, error) {
+		select {
+		case s := <-changes:
+			return s, nil
+		case <-time.After(testTimeout):
+			return resources.Snapshot{}, fmt.Errorf("no sample within %s", testTimeout)
+		}
+	}
+	s, err := next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "GPU 87%, VRAM 7.1/8.0 GB (ollama 5.1 GB, python3 1.2 GB) | gemma3:4b on GPU"; s.String() != want {
+		t.Fatalf("status line is %q, want %q", s.String(), want)
+	}
+	if s.Strained() {
+		t.Fatalf("a model fully on the GPU is strained")
+	}
+	select {
+	case s := <-changes:
+		t.Fatalf("announced again without a change: %q", s.String())
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	o.SetLoaded("gemma3:4b", 4<<30, 3<<30)
+	if s, err = next(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(s.String(), "| gemma3:4b 25% on CPU") {
+		t.Fatalf("spilled model shown as %q", s.String())
+	}
+	if !s.Strained() {
+		t.Fatalf("a model partly on the CPU is not strained")
+	}
+	o.SetLoaded("gemma3:4b", 0, 0)
+	if s, err = next(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(s.String(), "| no model loaded") {
+		t.Fatalf("unloaded model shown as %q", s.String())
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/micha/cs-ingame-translate/config"
+)
+
+var docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>cs-translate reference</title>
+<style>
+body { font-family: sans-serif; margin: 2em; max-width: 70em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #eee; }
+code { background: #f4f4f4; padding: 0 0.2em; }
+</style>
+</head>
+<body>
+<h1>cs-translate reference</h1>
+
+<h2>Configuration</h2>
+<p>Settings file: <code>{{.ConfigPath}}</code> (JSON). Command line flags override it.
+Point your editor at <a href="/schema.json">/schema.json</a> for autocompletion.</p>
+<table>
+<tr><th>Key</th><th>Type</th><th>Default</th><th>Flag</th><th>Description</th></tr>
+{{range .Options}}<tr><td><code>{{.Key}}</code></td><td>{{.Type}}</td><td><code>{{.Default}}</code></td><td>{{if .Flag}}<code>-{{.Flag}}</code>{{end}}</td><td>{{.Doc}}</td></tr>
+{{end}}</table>
+
+<h2>API endpoints</h2>
+<table>
+<tr><th>Method</th><th>Path</th><th>Description</th></tr>
+{{range .Endpoints}}<tr><td>{{.Method}}</td><td><code>{{.Path}}</code></td><td>{{.Description}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	path, err := config.Path()
+	if err != nil {
+		path = config.FileName
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	docsTemplate.Execute(w, struct {
+		ConfigPath string
+		Options    []config.Option
+		Endpoints  []Endpoint
+	}{path, config.Options(), s.Endpoints()})
+}
+
+func handleSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	WriteJSON(w, http.StatusOK, config.Schema())
+}
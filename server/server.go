@@ -0,0 +1,199 @@
+// Package server is the embedded web server. Endpoints are registered with
+// a description so the /docs page can list them.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint describes a registered route for the reference page
+type Endpoint struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// Server serves the API, reference docs and config schema
+type Server struct {
+	addr string
+	mux  *http.ServeMux
+	srv  *http.Server
+
+	mu        sync.Mutex
+	endpoints []Endpoint
+}
+
+// New creates a server for addr with /docs and /schema.json registered
+func New(addr string) *Server {
+	s := &Server{
+		addr: addr,
+		mux:  http.NewServeMux(),
+	}
+	s.Handle("GET", "/docs", "Reference of every config option and API endpoint", s.handleDocs)
+	s.Handle("GET", "/schema.json", "JSON schema of the config file, for editor autocompletion", handleSchema)
+	s.Handle("GET", "/api/endpoints", "List of API endpoints as JSON", s.handleEndpoints)
+	s.mux.HandleFunc("GET /{$}", localHost(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs", http.StatusFound)
+	}))
+	return s
+}
+
+// Handle registers h for method and path and records it for /docs. Every
+// handler is only called for requests to localhost (see localHost), and
+// POST handlers only for local requests with a JSON body or none at all;
+// see localPost.
+func (s *Server) Handle(method, path, description string, h http.HandlerFunc) {
+	if method == http.MethodPost {
+		h = localPost(h, isJSON, true)
+	}
+	s.handle(method, path, description, h)
+}
+
+// HandleUpload registers a POST handler whose body is a file, such as a
+// recording, of any type but those an HTML form can send
+func (s *Server) HandleUpload(path, description string, h http.HandlerFunc) {
+	s.handle(http.MethodPost, path, description, localPost(h, isUpload, false))
+}
+
+func (s *Server) handle(method, path, description string, h http.HandlerFunc) {
+	s.mux.HandleFunc(method+" "+path, localHost(h))
+
+	s.mu.Lock()
+	s.endpoints = append(s.endpoints, Endpoint{Method: method, Path: path, Description: description})
+	s.mu.Unlock()
+}
+
+// Endpoints returns the registered routes sorted by path
+func (s *Server) Endpoints() []Endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := append([]Endpoint(nil), s.endpoints...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path == out[j].Path {
+			return out[i].Method < out[j].Method
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// Start listens on the configured address and serves in the background
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	s.srv = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Web server failed", "err", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server was configured with
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Shutdown stops the server, waiting briefly for open requests
+func (s *Server) Shutdown() {
+	if s.srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	s.srv.Shutdown(ctx)
+}
+
+// WriteJSON writes v as an indented JSON response
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// localHost only passes requests on to h whose Host names this machine, so
+// a DNS rebinding page can neither read the status and config nor reach the
+// other endpoints
+func localHost(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !loopbackHost(r.Host) {
+			WriteJSON(w, http.StatusForbidden, map[string]string{"error": "host not allowed; use localhost"})
+			return
+		}
+		h(w, r)
+	}
+}
+
+// localPost only passes requests on to h that a web page on another site
+// cannot make: Origin, when sent, must name this machine, and the body type
+// must pass mediaType; browsers send none of JSON or audio cross-site
+// without asking first, and we never say yes. Actions such as /api/pause
+// take no body, so with bodyless a request without body and Content-Type
+// passes too; browsers send an Origin with those.
+func localPost(h http.HandlerFunc, mediaType func(string) bool, bodyless bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !localOrigin(origin) {
+			WriteJSON(w, http.StatusForbidden, map[string]string{"error": "origin not allowed"})
+			return
+		}
+		contentType := r.Header.Get("Content-Type")
+		if bodyless && contentType == "" && r.ContentLength == 0 {
+			h(w, r)
+			return
+		}
+		t, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !mediaType(t) {
+			WriteJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "unsupported Content-Type"})
+			return
+		}
+		h(w, r)
+	}
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json"
+}
+
+// isUpload refuses the types a form or a plain fetch may post cross-site
+func isUpload(mediaType string) bool {
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data", "text/plain":
+		return false
+	}
+	return true
+}
+
+// loopbackHost reports whether host, with or without a port, is localhost
+// or a loopback address
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+func (s *Server) handleEndpoints(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, s.Endpoints())
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalPost(t *testing.T) {
+	s := New("localhost:0")
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	s.Handle("POST", "/api/lang", "", ok)
+	s.Handle("POST", "/api/pause", "", ok)
+	s.HandleUpload("/api/transcribe", "", ok)
+
+	tests := []struct {
+		path, host, origin, contentType string
+		want                            int
+		noBody                          bool
+	}{
+		{"/api/lang", "localhost:8787", "", "application/json", http.StatusNoContent, false},
+		{"/api/lang", "127.0.0.1:8787", "http://localhost:8787", "application/json; charset=utf-8", http.StatusNoContent, false},
+		{"/api/lang", "[::1]:8787", "", "application/json", http.StatusNoContent, false},
+		{"/api/lang", "localhost:8787", "https://example.com", "application/json", http.StatusForbidden, false},
+		{"/api/lang", "localhost:8787", "null", "application/json", http.StatusForbidden, false},
+		{"/api/lang", "attacker.example:8787", "http://attacker.example:8787", "application/json", http.StatusForbidden, false},
+		{"/api/lang", "attacker.example:8787", "", "application/json", http.StatusForbidden, false},
+		{"/api/lang", "localhost:8787", "", "", http.StatusUnsupportedMediaType, false},
+		{"/api/lang", "localhost:8787", "", "text/plain", http.StatusUnsupportedMediaType, false},
+		{"/api/lang", "localhost:8787", "", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType, false},
+		{"/api/transcribe", "localhost:8787", "", "audio/wav", http.StatusNoContent, false},
+		{"/api/transcribe", "localhost:8787", "", "application/octet-stream", http.StatusNoContent, false},
+		{"/api/transcribe", "localhost:8787", "", "multipart/form-data; boundary=x", http.StatusUnsupportedMediaType, false},
+		{"/api/transcribe", "localhost:8787", "", "", http.StatusUnsupportedMediaType, false},
+		{"/api/transcribe", "localhost:8787", "https://example.com", "audio/wav", http.StatusForbidden, false},
+		// Actions take no body
+		{"/api/pause", "localhost:8787", "", "", http.StatusNoContent, true},
+		{"/api/pause", "127.0.0.1:8787", "http://localhost:8787", "", http.StatusNoContent, true},
+		{"/api/pause", "localhost:8787", "https://example.com", "", http.StatusForbidden, true},
+		{"/api/pause", "attacker.example:8787", "", "", http.StatusForbidden, true},
+		{"/api/pause", "localhost:8787", "", "text/plain", http.StatusUnsupportedMediaType, true},
+		{"/api/transcribe", "localhost:8787", "", "", http.StatusUnsupportedMediaType, true},
+	}
+	for _, tt := range tests {
+		var body io.Reader = strings.NewReader("{}")
+		if tt.noBody {
+			body = nil
+		}
+		r := httptest.NewRequest("POST", tt.path, body)
+		r.Host = tt.host
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s from %q, origin %q, type %q: %d, want %d", tt.path, tt.host, tt.origin, tt.contentType, w.Code, tt.want)
+		}
+	}
+}
+
+// GET routes, the redirect to /docs included, are refused to a rebound name
+// like POSTs, so such a page cannot read the status or config
+func TestLocalHost(t *testing.T) {
+	s := New("localhost:0")
+	s.Handle("GET", "/api/status", "", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]string{"mode": "cs2"})
+	})
+
+	tests := []struct {
+		path, host string
+		want       int
+	}{
+		{"/api/status", "localhost:8787", http.StatusOK},
+		{"/api/status", "[::1]:8787", http.StatusOK},
+		{"/api/status", "attacker.example:8787", http.StatusForbidden},
+		{"/docs", "attacker.example:8787", http.StatusForbidden},
+		{"/api/endpoints", "attacker.example:8787", http.StatusForbidden},
+		{"/", "localhost:8787", http.StatusFound},
+		{"/", "attacker.example:8787", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.Host = tt.host
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("GET %s from %q: %d, want %d", tt.path, tt.host, w.Code, tt.want)
+		}
+	}
+}
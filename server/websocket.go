@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSockets use gorilla/websocket, as the OBS client does. Clients only
+// need text messages, ping/pong and close.
+
+const (
+	maxMessageSize = 64 << 10
+	writeTimeout   = 2 * time.Second
+)
+
+// Web pages may open sockets to localhost too; only let local clients in
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || localOrigin(origin)
+	},
+}
+
+// Conn is the server side of a WebSocket connection
+type Conn struct {
+	ws *websocket.Conn
+
+	wmu       sync.Mutex // gorilla allows one writer at a time
+	closeOnce sync.Once
+}
+
+// Upgrade completes the WebSocket handshake. On failure an HTTP error has
+// already been written.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	ws.SetReadLimit(maxMessageSize)
+	return &Conn{ws: ws}, nil
+}
+
+// localOrigin reports whether origin is a page served from this machine:
+// its hostname must be localhost or a loopback address. Clients without an
+// origin (plugins, tools) are told apart by the caller; "null" origins come
+// from sandboxed pages of any site and are refused like file:// pages.
+func localOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// ReadMessage returns the next text or binary message; pings are answered
+// on the way. io.EOF means the peer closed the connection.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	_, msg, err := c.ws.ReadMessage()
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return nil, io.EOF
+	}
+	return msg, err
+}
+
+func (c *Conn) write(kind int, msg []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.ws.WriteMessage(kind, msg)
+}
+
+// WriteText sends one text message
+func (c *Conn) WriteText(msg []byte) error {
+	return c.write(websocket.TextMessage, msg)
+}
+
+// WriteJSON sends v as a text message
+func (c *Conn) WriteJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteText(b)
+}
+
+// Close sends a close frame and closes the connection
+func (c *Conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		err = c.ws.Close()
+	})
+	return err
+}
+
+// Hub fans messages out to every connected client
+type Hub struct {
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{conns: make(map[*Conn]struct{})}
+}
+
+// Add registers c for broadcasts
+func (h *Hub) Add(c *Conn) {
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+// Remove unregisters and closes c
+func (h *Hub) Remove(c *Conn) {
+	h.mu.Lock()
+	delete(h.conns, c)
+	h.mu.Unlock()
+	c.Close()
+}
+
+// Broadcast sends v to every client. Clients that cannot keep up are
+// dropped.
+func (h *Hub) Broadcast(v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.conns {
+		if err := c.WriteText(b); err != nil {
+			delete(h.conns, c)
+			c.Close()
+		}
+	}
+}
+
+// Close disconnects every client
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.conns {
+		c.Close()
+		delete(h.conns, c)
+	}
+}
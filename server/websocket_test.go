@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestUpgradeOrigin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteText(msg)
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	tests := []struct {
+		origin string
+		host   string
+		ok     bool
+	}{
+		{"", "", true},
+		{"http://localhost:8787", "", true},
+		{"http://127.0.0.1", "", true},
+		{"http://[::1]:8787", "", true},
+		{"https://example.com", "", false},
+		{"http://localhost.example.com", "", false},
+		{"null", "", false},
+		{"file://", "", false},
+		// A page on a rebound name is not local because Host matches
+		{"http://attacker.example:8787", "attacker.example:8787", false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.origin != "" {
+			h.Set("Origin", tt.origin)
+		}
+		if tt.host != "" {
+			h.Set("Host", tt.host)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(url, h)
+		if !tt.ok {
+			if err == nil {
+				conn.Close()
+				t.Errorf("origin %q was let in", tt.origin)
+			} else if resp == nil || resp.StatusCode != http.StatusForbidden {
+				t.Errorf("origin %q: %v, want 403", tt.origin, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("origin %q: %v", tt.origin, err)
+			continue
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+		if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != "ping" {
+			t.Errorf("origin %q: echo %q, %v", tt.origin, msg, err)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,117 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// On Linux a systemd user unit runs cs-translate in the login session, so
+// audio capture works as in a terminal. The .path unit starts the service
+// once console.log exists.
+
+// unitDir is where systemd looks for the user's own units
+func unitDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "systemd", "user"), nil
+}
+
+func installService(s service) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("services can only be installed on Windows and on Linux with systemd")
+	}
+	dir, err := unitDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	cmdline := append([]string{s.exe}, s.args...)
+	for i, a := range cmdline {
+		// ExecStart expands $VARIABLES as well
+		cmdline[i] = systemdQuote(strings.ReplaceAll(a, "$", "$$"))
+	}
+	unit := fmt.Sprintf(`[Unit]
+Description=cs-translate in-game chat translation
+
+[Service]
+Environment=%s
+ExecStart=%s
+Restart=on-failure
+RestartSec=10
+`, systemdQuote("CS_TRANSLATE_CONFIG_DIR="+s.configDir), strings.Join(cmdline, " "))
+	path := fmt.Sprintf(`[Unit]
+Description=Start cs-translate when CS2 writes its console log
+
+[Path]
+PathExists=%s
+
+[Install]
+WantedBy=default.target
+`, systemdEscape(s.logPath))
+
+	if err := os.WriteFile(filepath.Join(dir, serviceName+".service"), []byte(unit), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, serviceName+".path"), []byte(path), 0644); err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", serviceName+".path")
+}
+
+func uninstallService() error {
+	dir, err := unitDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, serviceName+".path")); os.IsNotExist(err) {
+		return fmt.Errorf("the %s service is not installed", serviceName)
+	}
+	// Stopping fails when it is not running, which is fine
+	systemctl("disable", "--now", serviceName+".path")
+	systemctl("stop", serviceName+".service")
+	for _, name := range []string{serviceName + ".path", serviceName + ".service"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return systemctl("daemon-reload")
+}
+
+func systemctl(args ...string) error {
+	out, err := execwrap.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// systemdEscape keeps systemd from expanding % specifiers
+func systemdEscape(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// systemdQuote quotes one word of a unit setting
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(systemdEscape(s))
+	return `"` + s + `"`
+}
+
+// serveWindowsService runs run as a Windows service when started by the
+// service manager; elsewhere it never does
+func serveWindowsService(run func()) bool {
+	return false
+}
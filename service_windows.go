@@ -0,0 +1,147 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// The Windows service starts with the system and runs as LocalSystem, so it
+// is given the installing user's settings directory and waits for the
+// console log named on its command line. It has no console; its output goes
+// to service.log in the settings directory.
+
+func installService(s service) error {
+	m, err := connectServices()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	if existing, err := m.OpenService(serviceName); err == nil {
+		existing.Close()
+		return fmt.Errorf("the %s service is already installed; run 'cs-translate uninstall-service' first", serviceName)
+	}
+	ws, err := m.CreateService(serviceName, s.exe, mgr.Config{
+		DisplayName:      "cs-translate",
+		Description:      "Translates CS2 chat once the console log appears",
+		StartType:        mgr.StartAutomatic,
+		DelayedAutoStart: true,
+	}, s.args...)
+	if err != nil {
+		return fmt.Errorf("failed to create the service: %w", err)
+	}
+	defer ws.Close()
+
+	// Services read their environment from the registry
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+serviceName, registry.SET_VALUE)
+	if err == nil {
+		err = key.SetStringsValue("Environment", []string{config.DirEnv + "=" + s.configDir})
+		key.Close()
+	}
+	if err != nil {
+		ws.Delete()
+		return fmt.Errorf("failed to configure the service: %w", err)
+	}
+	if err := ws.Start(); err != nil {
+		return fmt.Errorf("installed, but the service did not start: %w", err)
+	}
+	return nil
+}
+
+func uninstallService() error {
+	m, err := connectServices()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	ws, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("the %s service is not installed", serviceName)
+	}
+	defer ws.Close()
+	// Stopping fails when it is not running, which is fine
+	if status, err := ws.Control(svc.Stop); err == nil {
+		for deadline := time.Now().Add(10 * time.Second); status.State != svc.Stopped && time.Now().Before(deadline); {
+			time.Sleep(300 * time.Millisecond)
+			if status, err = ws.Query(); err != nil {
+				break
+			}
+		}
+	}
+	if err := ws.Delete(); err != nil {
+		return fmt.Errorf("failed to remove the service: %w", err)
+	}
+	return nil
+}
+
+func connectServices() (*mgr.Mgr, error) {
+	m, err := mgr.Connect()
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return nil, fmt.Errorf("managing services needs an administrator prompt")
+	}
+	return m, err
+}
+
+// serveWindowsService runs run as a Windows service when started by the
+// service manager; it returns false when started any other way
+func serveWindowsService(run func()) bool {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return false
+	}
+	if dir, err := config.Dir(); err == nil {
+		if f, err := os.OpenFile(filepath.Join(dir, "service.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+			os.Stdout, os.Stderr = f, f
+			log.SetOutput(f)
+		}
+	}
+	if err := svc.Run(serviceName, serviceHandler{run}); err != nil {
+		slog.Error("Service failed", "err", err)
+	}
+	return true
+}
+
+type serviceHandler struct {
+	run func()
+}
+
+// Execute reports the service running while run runs. On stop the process
+// ends with it, after stopping the Docker container like Ctrl+C does.
+func (h serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.run()
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-done:
+			return false, 0
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				stopDockerContainer()
+				return false, 0
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+)
+
+// serviceName names the Windows service and the systemd units
+const serviceName = "cs-translate"
+
+// service is what gets installed: the program with the flags it runs with,
+// the console log whose appearance starts it and the settings directory of
+// the user who installed it
+type service struct {
+	exe       string
+	args      []string
+	logPath   string
+	configDir string
+}
+
+// runInstallService registers cs-translate to start on its own once CS2
+// writes console.log: as a Windows service or a systemd user unit. Flags
+// after the command are passed on, e.g. install-service -http-addr :8787.
+func runInstallService(args []string) {
+	s, err := newService(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := installService(s); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Installed the %s service; it starts once %s appears.\n", serviceName, s.logPath)
+	fmt.Println("Remove it again with 'cs-translate uninstall-service'.")
+}
+
+// runUninstallService stops and removes what install-service set up
+func runUninstallService(args []string) {
+	if err := uninstallService(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed the %s service.\n", serviceName)
+}
+
+// newService resolves everything the service needs now, while running as
+// the user: it may run as another account that has neither the settings
+// nor the Steam library in its home
+func newService(args []string) (service, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return service{}, err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return service{}, err
+	}
+	configDir, err := config.Dir()
+	if err != nil {
+		return service{}, err
+	}
+	logPath := flagValue(args, "log")
+	if logPath == "" {
+		if logPath, err = serviceLogPath(); err != nil {
+			return service{}, err
+		}
+		args = append([]string{"-log", logPath}, args...)
+	}
+	if abs, err := filepath.Abs(logPath); err == nil {
+		logPath = abs
+	}
+	return service{exe: exe, args: args, logPath: logPath, configDir: configDir}, nil
+}
+
+// serviceLogPath picks the console log to wait for: the configured one,
+// the one used last, or where the first Steam library would have it
+func serviceLogPath() (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	cfg.ApplyEnv()
+	if paths := splitLogPaths(cfg.LogPath); len(paths) == 1 {
+		return paths[0], nil
+	} else if len(paths) > 1 {
+		return "", fmt.Errorf("log_path names several logs; pass the one that starts the service with -log")
+	}
+	if p := config.LoadState().LastLogPath; p != "" {
+		return p, nil
+	}
+	if p, err := findLogFile(); err == nil {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if paths := getLogFilePaths(home); len(paths) > 0 {
+		return paths[0], nil
+	}
+	return "", fmt.Errorf("no Steam library found; pass the console log with -log")
+}
+
+// flagValue returns the value of -name or --name in args
+func flagValue(args []string, name string) string {
+	for i, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			continue
+		}
+		a = strings.TrimPrefix(strings.TrimPrefix(a, "-"), "-")
+		if v, ok := strings.CutPrefix(a, name+"="); ok {
+			return v
+		}
+		if a == name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package setup
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
+)
+
+// StopContainer stops the unified container if it is running
+func StopContainer() error {
+	if !checkContainerRunning(ContainerName) {
+		fmt.Print(i18n.T("Container '%s' is not running\n", ContainerName))
+		return nil
+	}
+	cmd := execwrap.Command("docker", "stop", ContainerName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := run(cmd); err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+	return nil
+}
+
+// RemoveContainer deletes the container. The model volume is kept, so
+// downloaded models survive.
+func RemoveContainer() error {
+	if !checkContainerExists(ContainerName) {
+		fmt.Print(i18n.T("Container '%s' does not exist\n", ContainerName))
+		return nil
+	}
+	cmd := execwrap.Command("docker", "rm", "-f", ContainerName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := run(cmd); err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	return nil
+}
+
+// ContainerLogs prints the container logs, following them if requested
+func ContainerLogs(follow bool) error {
+	args := []string{"logs", "--tail", "200"}
+	if follow {
+		args = append(args, "-f")
+	}
+	args = append(args, ContainerName)
+
+	cmd := execwrap.Command("docker", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// UpdateContainer rebuilds the image from the embedded Dockerfile for the
+// GPU setup chose and recreates the container with it
+func UpdateContainer() error {
+	if err := CheckDocker(); err != nil {
+		return fmt.Errorf("docker is required: %w", err)
+	}
+	if err := buildAndRunContainer(ContainerName, selectedGPU(LoadWizardState())); err != nil {
+		return err
+	}
+	return waitForOllama()
+}
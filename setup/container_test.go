@@ -0,0 +1,30 @@
+package setup_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/setup"
+)
+
+func TestMain(m *testing.M) {
+	// Commands faked by execwrap run the test binary again to answer
+	execwrap.ServeFake()
+	os.Exit(m.Run())
+}
+
+// A running container is found and stopped through docker
+func TestStopContainer(t *testing.T) {
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"docker ps --filter name=" + setup.ContainerName + " --format {{.Names}}": {Stdout: setup.ContainerName + "\n"},
+	}}
+	defer execwrap.Use(fake)()
+
+	if err := setup.StopContainer(); err != nil {
+		t.Fatal(err)
+	}
+	if !fake.Ran("docker", "stop", setup.ContainerName) {
+		t.Fatalf("running container was not stopped: %q", fake.Calls())
+	}
+}
@@ -5,80 +5,138 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
 	"github.com/micha/cs-ingame-translate/translator"
 )
 
-func SetupDockerContainer(scanner *bufio.Scanner) error {
-	fmt.Println("Setting up Docker container with Ollama and Whisper...")
+// ContainerName is the unified Ollama + Whisper container
+const ContainerName = "cs-translate"
 
-	if err := CheckDocker(); err != nil {
-		return fmt.Errorf("docker is required: %w", err)
-	}
+// startedContainer records whether this process started the container, so
+// it only stops containers it owns
+var startedContainer bool
 
-	if err := CheckAndInstallNvidiaContainerToolkit(scanner); err != nil {
-		return fmt.Errorf("nvidia-container-toolkit is required for GPU support: %w", err)
-	}
-
-	containerName := "cs-translate"
+// StartedContainer reports whether the container was started by this run
+func StartedContainer() bool {
+	return startedContainer
+}
 
-	if running := checkContainerRunning(containerName); running {
-		fmt.Println("Docker container already running")
-	} else if exists := checkContainerExists(containerName); exists {
-		fmt.Println("Starting existing Docker container...")
-		if err := startContainer(containerName); err != nil {
-			return fmt.Errorf("failed to start container: %w", err)
-		}
-	} else {
-		if err := buildAndRunContainer(containerName); err != nil {
-			return err
-		}
+// dockerStep makes sure Docker runs, offering to install Docker Desktop on
+// Windows
+func dockerStep() Step {
+	return Step{
+		Name:  "docker",
+		Title: i18n.T("Docker is running"),
+		Check: CheckDocker,
+		Run:   installDocker,
 	}
+}
 
-	if err := waitForOllama(); err != nil {
-		return err
+// containerStep starts the unified container, building it the first time
+// for the GPU chosen before, and waits for its Ollama
+func (w *wizard) containerStep() Step {
+	return Step{
+		Name:  "container",
+		Title: i18n.T("Docker container is running"),
+		Check: func() error {
+			if !checkContainerRunning(ContainerName) {
+				return fmt.Errorf("container '%s' is not running", ContainerName)
+			}
+			return checkOllama()
+		},
+		Run: func(*bufio.Scanner) error {
+			fmt.Println(i18n.T("Setting up Docker container with Ollama and Whisper..."))
+			if checkContainerRunning(ContainerName) {
+				fmt.Println(i18n.T("Docker container already running"))
+			} else if checkContainerExists(ContainerName) {
+				fmt.Println(i18n.T("Starting existing Docker container..."))
+				if err := startContainer(ContainerName); err != nil {
+					return fmt.Errorf("failed to start container: %w", err)
+				}
+				startedContainer = true
+			} else {
+				if err := buildAndRunContainer(ContainerName, w.state.GPU); err != nil {
+					return err
+				}
+				startedContainer = true
+			}
+			return waitForOllama()
+		},
 	}
-
-	model := translator.DefaultOllamaModel
-	fmt.Printf("Checking for Ollama model '%s'...\n", model)
-	return CheckAndPullDockerModel(scanner, model)
 }
 
 func CheckDocker() error {
-	cmd := exec.Command("docker", "ps")
+	cmd := execwrap.Command("docker", "ps")
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("docker is not running or not installed: %w", err)
 	}
 	return nil
 }
 
+// installDocker installs Docker Desktop with WSL2 on Windows, which needs a
+// restart before Docker runs. Elsewhere Docker has to be installed by hand.
+func installDocker(scanner *bufio.Scanner) error {
+	err := CheckDocker()
+	if runtime.GOOS != "windows" {
+		return fmt.Errorf("docker is required: %w", err)
+	}
+	if _, lookErr := execwrap.LookPath("docker"); lookErr == nil {
+		return fmt.Errorf("Docker is installed but not running; start Docker Desktop and run cs-translate again: %w", err)
+	}
+	if _, lookErr := execwrap.LookPath("winget"); lookErr != nil {
+		fmt.Println(i18n.T("Install Docker Desktop from https://www.docker.com/products/docker-desktop/ or set USE_DOCKER_OLLAMA=0 for a native installation."))
+		return fmt.Errorf("docker is required: %w", err)
+	}
+	fmt.Println(i18n.T("Docker Desktop is not installed. It runs on WSL2, which needs a restart after installing."))
+	if !confirm(scanner, i18n.T("Install WSL2 and Docker Desktop now? [Y/n]: ")) {
+		return fmt.Errorf("docker is required: %w", err)
+	}
+
+	fmt.Println(i18n.T("Installing WSL2 (confirm the administrator prompt)..."))
+	if err := runElevated("wsl", "--install", "--no-distribution"); err != nil {
+		return fmt.Errorf("failed to install WSL2: %w", err)
+	}
+	fmt.Println(i18n.T("Installing Docker Desktop..."))
+	cmd := execwrap.Command("winget", "install", "-e", "--id", "Docker.DockerDesktop", "--accept-package-agreements", "--accept-source-agreements")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err := run(cmd); err != nil {
+		return fmt.Errorf("failed to install Docker Desktop: %w", err)
+	}
+	fmt.Println(display.CheckMark, i18n.T("Docker Desktop installed. Restart Windows, start Docker Desktop once and run cs-translate again."))
+	return ErrRestartRequired
+}
+
 func checkContainerRunning(name string) bool {
-	cmd := exec.Command("docker", "ps", "--filter", "name="+name, "--format", "{{.Names}}")
+	cmd := execwrap.Command("docker", "ps", "--filter", "name="+name, "--format", "{{.Names}}")
 	output, err := cmd.Output()
 	return err == nil && strings.TrimSpace(string(output)) == name
 }
 
 func checkContainerExists(name string) bool {
-	cmd := exec.Command("docker", "ps", "-a", "--filter", "name="+name, "--format", "{{.Names}}")
+	cmd := execwrap.Command("docker", "ps", "-a", "--filter", "name="+name, "--format", "{{.Names}}")
 	output, err := cmd.Output()
 	return err == nil && strings.TrimSpace(string(output)) == name
 }
 
 func startContainer(name string) error {
-	cmd := exec.Command("docker", "start", name)
+	cmd := execwrap.Command("docker", "start", name)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return run(cmd)
 }
 
-func buildAndRunContainer(name string) error {
-	fmt.Println("Building Docker container (first time only, this may take a few minutes)...")
+func buildAndRunContainer(name, gpu string) error {
+	fmt.Println(i18n.T("Building Docker container (first time only, this may take a few minutes)..."))
 
 	tmpDir, err := os.MkdirTemp("", "cs-translate-docker")
 	if err != nil {
@@ -94,60 +152,99 @@ func buildAndRunContainer(name string) error {
 		return fmt.Errorf("failed to write transcriber.py: %w", err)
 	}
 
-	buildCmd := exec.Command("docker", "build", "-t", "cs-translate:latest", ".")
+	buildCmd := execwrap.Command("docker", "build", "-t", "cs-translate:latest",
+		"--build-arg", "GPU="+gpu,
+		"--build-arg", "TORCH_INDEX="+torchIndexURL(gpu),
+		"--label", "cs-translate.gpu="+gpu, ".")
 	buildCmd.Dir = tmpDir
 	buildCmd.Stdout = os.Stdout
 	buildCmd.Stderr = os.Stderr
-	if err := buildCmd.Run(); err != nil {
+	if err := run(buildCmd); err != nil {
 		return fmt.Errorf("failed to build docker image: %w", err)
 	}
 
-	rmCmd := exec.Command("docker", "rm", "-f", name)
-	rmCmd.Run()
+	rmCmd := execwrap.Command("docker", "rm", "-f", name)
+	run(rmCmd)
+
+	volCreateCmd := execwrap.Command("docker", "volume", "create", "cs-translate-models")
+	run(volCreateCmd)
 
-	volCreateCmd := exec.Command("docker", "volume", "create", "cs-translate-models")
-	volCreateCmd.Run()
+	// Volumes created by older root images contain root-owned model files that
+	// the unprivileged container user could not update
+	chownCmd := execwrap.Command("docker", "run", "--rm", "--user", "root",
+		"-v", "cs-translate-models:/data",
+		"--entrypoint", "chown",
+		"cs-translate:latest", "-R", "cstranslate:cstranslate", "/data")
+	run(chownCmd)
 
 	hostPort := translator.DefaultOllamaPort
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", hostPort))
 	if err != nil {
-		fmt.Printf("Port %d is already in use. Looking for an available port...\n", hostPort)
+		fmt.Print(i18n.T("Port %d is already in use. Looking for an available port...\n", hostPort))
 		hostPort, err = translator.FindAvailablePort(hostPort + 1)
 		if err != nil {
 			return fmt.Errorf("could not find an available port: %w", err)
 		}
-		fmt.Printf("Using alternative port: %d\n", hostPort)
-		fmt.Println("Note: You'll need to set OLLAMA_HOST to use this port.")
-		fmt.Printf("Run: export OLLAMA_HOST=http://localhost:%d\n", hostPort)
+		fmt.Print(i18n.T("Using alternative port: %d\n", hostPort))
+		fmt.Println(i18n.T("Note: You'll need to set OLLAMA_HOST to use this port."))
+		fmt.Print(i18n.T("Run: export OLLAMA_HOST=http://localhost:%d\n", hostPort))
 	} else {
 		ln.Close()
 	}
 
+	audioDir, err := translator.HostAudioDir()
+	if err != nil {
+		return err
+	}
+
 	portStr := fmt.Sprintf("%d:%d", hostPort, translator.DefaultOllamaPort)
-	runCmd := exec.Command("docker", "run", "-d",
-		"--gpus", "all",
+	runArgs := []string{"run", "-d",
 		"--name", name,
 		"-p", portStr,
 		"-v", "cs-translate-models:/data",
-		"--privileged",
-		"cs-translate:latest")
+		"-v", audioDir + ":" + translator.ContainerAudioDir + ":ro",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+	}
+	runArgs = append(runArgs, gpuRunArgs(gpu)...)
+	runArgs = append(runArgs, containerResourceArgs()...)
+	runArgs = append(runArgs, "cs-translate:latest")
+
+	runCmd := execwrap.Command("docker", runArgs...)
 	runCmd.Stdout = os.Stdout
 	runCmd.Stderr = os.Stderr
-	if err := runCmd.Run(); err != nil {
+	if err := run(runCmd); err != nil {
 		return fmt.Errorf("failed to start docker container: %w", err)
 	}
 
-	time.Sleep(5 * time.Second)
+	wait(5 * time.Second)
 	return nil
 }
 
+// containerResourceArgs returns docker run limits from CS_TRANSLATE_DOCKER_MEMORY
+// (e.g. "12g") and CS_TRANSLATE_DOCKER_CPUS (e.g. "4"). Unset means unlimited.
+func containerResourceArgs() []string {
+	var args []string
+	if memory := os.Getenv("CS_TRANSLATE_DOCKER_MEMORY"); memory != "" {
+		args = append(args, "--memory", memory)
+		fmt.Print(i18n.T("Limiting container memory to %s\n", memory))
+	}
+	if cpus := os.Getenv("CS_TRANSLATE_DOCKER_CPUS"); cpus != "" {
+		args = append(args, "--cpus", cpus)
+		fmt.Print(i18n.T("Limiting container CPUs to %s\n", cpus))
+	}
+	return args
+}
+
 func waitForOllama() error {
-	client := &http.Client{Timeout: 10 * time.Second}
+	if DryRun {
+		return nil
+	}
 	ollamaURL := translator.OllamaHost
 
-	fmt.Println("Waiting for Ollama to be ready...")
+	fmt.Println(i18n.T("Waiting for Ollama to be ready..."))
 	for i := 0; i < 30; i++ {
-		resp, err := client.Get(ollamaURL + "/api/version")
+		resp, err := translator.GetWithTimeout(ollamaURL+"/api/version", 10*time.Second)
 		if err == nil {
 			resp.Body.Close()
 			break
@@ -155,7 +252,7 @@ func waitForOllama() error {
 		time.Sleep(2 * time.Second)
 	}
 
-	resp, err := client.Get(ollamaURL + "/api/version")
+	resp, err := translator.GetWithTimeout(ollamaURL+"/api/version", 10*time.Second)
 	if err != nil {
 		return fmt.Errorf("Ollama Docker container not responding: %w", err)
 	}
@@ -163,112 +260,9 @@ func waitForOllama() error {
 
 	var versionResp OllamaVersionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&versionResp); err == nil {
-		fmt.Printf("✔ Ollama is running in Docker (version: %s)\n", versionResp.Version)
-	} else {
-		fmt.Println("✔ Ollama is running in Docker")
-	}
-	return nil
-}
-
-func CheckAndPullDockerModel(scanner *bufio.Scanner, model string) error {
-	ollamaURL := translator.OllamaHost
-
-	modelURL := fmt.Sprintf("%s/api/tags", ollamaURL)
-	resp, err := http.Get(modelURL)
-	if err == nil {
-		defer resp.Body.Close()
-
-		var tagsResp struct {
-			Models []struct {
-				Name string `json:"name"`
-			} `json:"models"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err == nil {
-			for _, m := range tagsResp.Models {
-				if strings.HasPrefix(m.Name, model) {
-					fmt.Printf("✔ Model '%s' is already installed\n", model)
-					return nil
-				}
-			}
-		}
-	}
-
-	fmt.Printf("Model '%s' not found.\n", model)
-	fmt.Printf("Do you want to download '%s'? (~2GB, required for translation) [Y/n]: ", model)
-	if scanner.Scan() {
-		input := strings.TrimSpace(scanner.Text())
-		if input == "" || strings.ToLower(input) == "y" || strings.ToLower(input) == "yes" {
-			fmt.Printf("Pulling model '%s' in Docker... (this may take a few minutes)\n", model)
-			pullCmd := exec.Command("docker", "exec", "cs-translate", "ollama", "pull", model)
-			pullCmd.Stdout = os.Stdout
-			pullCmd.Stderr = os.Stderr
-			if err := pullCmd.Run(); err != nil {
-				return fmt.Errorf("failed to pull model: %w", err)
-			}
-			fmt.Printf("✔ Model '%s' downloaded successfully\n", model)
-		} else {
-			return fmt.Errorf("model '%s' is required for translation", model)
-		}
-	}
-
-	return nil
-}
-
-func CheckAndPullModel(scanner *bufio.Scanner, model string) error {
-	ollamaURL := translator.OllamaHost
-
-	checkCmd := exec.Command("ollama", "list")
-	output, err := checkCmd.CombinedOutput()
-	if err != nil {
-		modelURL := fmt.Sprintf("%s/api/tags", ollamaURL)
-		resp, err := http.Get(modelURL)
-		if err != nil {
-			fmt.Println("Warning: Could not check installed models")
-			goto PullModel
-		}
-		defer resp.Body.Close()
-
-		var tagsResp struct {
-			Models []struct {
-				Name string `json:"name"`
-			} `json:"models"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err != nil {
-			fmt.Println("Warning: Could not parse installed models")
-			goto PullModel
-		}
-
-		for _, m := range tagsResp.Models {
-			if strings.HasPrefix(m.Name, model) {
-				fmt.Printf("✔ Model '%s' is already installed\n", model)
-				return nil
-			}
-		}
+		fmt.Print(i18n.T("%s Ollama is running in Docker (version: %s)\n", display.CheckMark, versionResp.Version))
 	} else {
-		if strings.Contains(string(output), model) {
-			fmt.Printf("✔ Model '%s' is already installed\n", model)
-			return nil
-		}
+		fmt.Println(display.CheckMark, i18n.T("Ollama is running in Docker"))
 	}
-
-PullModel:
-	fmt.Printf("Model '%s' not found.\n", model)
-	fmt.Printf("Do you want to download '%s'? (~2GB, required for translation) [Y/n]: ", model)
-	if scanner.Scan() {
-		input := strings.TrimSpace(scanner.Text())
-		if input == "" || strings.ToLower(input) == "y" || strings.ToLower(input) == "yes" {
-			fmt.Printf("Pulling model '%s'... (this may take a few minutes)\n", model)
-			pullCmd := exec.Command("ollama", "pull", model)
-			pullCmd.Stdout = os.Stdout
-			pullCmd.Stderr = os.Stderr
-			if err := pullCmd.Run(); err != nil {
-				return fmt.Errorf("failed to pull model: %w", err)
-			}
-			fmt.Printf("✔ Model '%s' downloaded successfully\n", model)
-		} else {
-			return fmt.Errorf("model '%s' is required for translation", model)
-		}
-	}
-
 	return nil
 }
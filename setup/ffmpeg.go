@@ -0,0 +1,193 @@
+package setup
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
+)
+
+// FFmpegRelease is the ffmpeg release line setup downloads on Windows: the
+// static GPL build of BtbN/FFmpeg-Builds, which follows its patch releases
+const FFmpegRelease = "7.1"
+
+// ffmpegBuilds is where the builds and their checksums.sha256 are published
+const ffmpegBuilds = "https://github.com/BtbN/FFmpeg-Builds/releases/download/latest/"
+
+// BundledFFmpeg is where setup puts the ffmpeg it downloads, under
+// config.Dir
+func BundledFFmpeg() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	exe := "ffmpeg"
+	if runtime.GOOS == "windows" {
+		exe += ".exe"
+	}
+	return filepath.Join(dir, "ffmpeg", exe), nil
+}
+
+// LocateFFmpeg returns the ffmpeg to run: configured, which has to exist,
+// else the one in PATH, else the one setup downloaded. It is "" when there
+// is none, so that setup offers to install it.
+func LocateFFmpeg(configured string) (string, error) {
+	if configured != "" {
+		if _, err := os.Stat(configured); err != nil {
+			return "", fmt.Errorf("configured ffmpeg not found: %w", err)
+		}
+		return configured, nil
+	}
+	if path, err := execwrap.LookPath("ffmpeg"); err == nil {
+		return path, nil
+	}
+	if bundled, err := BundledFFmpeg(); err == nil {
+		if _, err := os.Stat(bundled); err == nil {
+			return bundled, nil
+		}
+	}
+	return "", nil
+}
+
+// FFmpegStep is the wizard step for SetupFFmpeg
+func FFmpegStep() Step {
+	return Step{
+		Name:  "ffmpeg",
+		Title: i18n.T("FFmpeg is installed"),
+		Check: func() error {
+			_, err := execwrap.LookPath("ffmpeg")
+			return err
+		},
+		Run:      SetupFFmpeg,
+		Optional: true,
+	}
+}
+
+// SetupFFmpeg installs ffmpeg, which records and converts all audio: on
+// Windows by downloading a static build into config.Dir, elsewhere with the
+// package manager
+func SetupFFmpeg(scanner *bufio.Scanner) error {
+	if path, err := execwrap.LookPath("ffmpeg"); err == nil {
+		fmt.Print(i18n.T("%s FFmpeg found (%s).\n", display.CheckMark, path))
+		return nil
+	}
+	fmt.Println(i18n.T("FFmpeg not found; it is needed to capture and convert audio."))
+	if runtime.GOOS != "windows" {
+		return InstallDependency(scanner, "ffmpeg")
+	}
+	arch := map[string]string{"amd64": "win64", "arm64": "winarm64"}[runtime.GOARCH]
+	if arch == "" {
+		return fmt.Errorf("no ffmpeg build for windows/%s; install it and set ffmpeg in the settings", runtime.GOARCH)
+	}
+	if !confirm(scanner, i18n.T("Download FFmpeg %s now? [Y/n]: ", FFmpegRelease)) {
+		return errors.New("ffmpeg is needed for voice; install it or set ffmpeg in the settings")
+	}
+	dest, err := BundledFFmpeg()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("ffmpeg-n%s-latest-%s-gpl-%s.zip", FFmpegRelease, arch, FFmpegRelease)
+	if err := downloadFFmpeg(name, dest); err != nil {
+		return err
+	}
+	if !DryRun {
+		execwrap.SetPath("ffmpeg", dest)
+		fmt.Print(i18n.T("%s FFmpeg installed to %s\n", display.CheckMark, dest))
+	}
+	return nil
+}
+
+// downloadFFmpeg downloads the build name, checks it against the release's
+// checksums and unpacks its ffmpeg to dest
+func downloadFFmpeg(name, dest string) error {
+	tmp, err := os.MkdirTemp("", "cs-ffmpeg")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	archive := filepath.Join(tmp, name)
+	sums := filepath.Join(tmp, "checksums.sha256")
+	fmt.Println(i18n.T("Downloading FFmpeg... (about 150 MB)"))
+	if err := DownloadFile(ffmpegBuilds+name, archive); err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if err := DownloadFile(ffmpegBuilds+"checksums.sha256", sums); err != nil {
+		return fmt.Errorf("failed to download the ffmpeg checksums: %w", err)
+	}
+	if DryRun {
+		fmt.Print(i18n.T("[dry run] extract %s\n", dest))
+		return nil
+	}
+	if err := verifySHA256(archive, sums, name); err != nil {
+		return err
+	}
+	return unzipFile(archive, "bin/"+filepath.Base(dest), dest)
+}
+
+// verifySHA256 checks path against the entry for name in the sha256sum
+// listing sums
+func verifySHA256(path, sums, name string) error {
+	listing, err := os.ReadFile(sums)
+	if err != nil {
+		return err
+	}
+	var want string
+	for _, line := range strings.Split(string(listing), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			want = strings.ToLower(fields[0])
+		}
+	}
+	if want == "" {
+		return fmt.Errorf("no checksum published for %s", name)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%s is corrupt: checksum %s, expected %s", name, got, want)
+	}
+	return nil
+}
+
+// unzipFile writes the entry of archive whose path ends in suffix to dest
+func unzipFile(archive, suffix, dest string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, "/"+suffix) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		// Unpack next to dest first, so an interrupted download leaves no
+		// half-written ffmpeg behind
+		if err := extractFile(f, dest+".part"); err != nil {
+			os.Remove(dest + ".part")
+			return err
+		}
+		return os.Rename(dest+".part", dest)
+	}
+	return fmt.Errorf("%s has no %s", filepath.Base(archive), suffix)
+}
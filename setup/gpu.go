@@ -0,0 +1,149 @@
+package setup
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/micha/cs-ingame-translate/i18n"
+)
+
+// GPU vendors the container can use; GPUCPU runs Ollama and Whisper on the
+// processor
+const (
+	GPUNvidia = "nvidia" // CUDA through the NVIDIA container toolkit
+	GPUAMD    = "amd"    // ROCm through /dev/kfd
+	GPUIntel  = "intel"  // Arc through /dev/dri: Vulkan for Ollama, XPU for Whisper
+	GPUCPU    = "cpu"
+)
+
+// GPUEnv overrides the detected GPU, e.g. CS_TRANSLATE_GPU=cpu
+const GPUEnv = "CS_TRANSLATE_GPU"
+
+// selectedGPU returns the GPU to build the container for: GPUEnv when set,
+// then the one remembered by the wizard, then the detected one
+func selectedGPU(state WizardState) string {
+	if gpu := os.Getenv(GPUEnv); gpu != "" {
+		if validGPU(gpu) {
+			return gpu
+		}
+		slog.Warn("Unknown GPU (use nvidia, amd, intel or cpu); detecting it", GPUEnv, gpu)
+	} else if validGPU(state.GPU) {
+		return state.GPU
+	}
+	return DetectGPU()
+}
+
+func validGPU(gpu string) bool {
+	switch gpu {
+	case GPUNvidia, GPUAMD, GPUIntel, GPUCPU:
+		return true
+	}
+	return false
+}
+
+// chooseGPU picks the container's GPU and remembers it, so a fallback to the
+// CPU is not asked again
+func (w *wizard) chooseGPU() string {
+	gpu := selectedGPU(w.state)
+	if gpu != w.state.GPU {
+		w.state.GPU = gpu
+		w.save()
+	}
+	return gpu
+}
+
+// gpuStep makes sure Docker can hand the GPU to the container. When it
+// cannot, the container is built for the CPU instead of failing setup.
+func (w *wizard) gpuStep() Step {
+	step := Step{Name: "gpu", Check: func() error { return checkGPU(w.state.GPU) }}
+	switch w.state.GPU {
+	case GPUNvidia:
+		step.Title = i18n.T("nvidia-container-toolkit is installed")
+	case GPUAMD:
+		step.Title = i18n.T("AMD GPU found; the container uses ROCm")
+	case GPUIntel:
+		step.Title = i18n.T("Intel Arc GPU found; the container uses Vulkan and XPU")
+	default:
+		step.Title = i18n.T("No supported GPU found; Ollama and Whisper run on the CPU")
+	}
+	step.Run = func(scanner *bufio.Scanner) error {
+		err := checkGPU(w.state.GPU)
+		if w.state.GPU == GPUNvidia && runtime.GOOS == "linux" {
+			fmt.Println(i18n.T("nvidia-container-toolkit is required for GPU support in Docker."))
+			if confirm(scanner, i18n.T("Do you want to install it now? [Y/n]: ")) {
+				if err = installNvidiaContainerToolkitLinux(scanner); err == nil {
+					return nil
+				}
+			}
+		} else if runtime.GOOS == "windows" {
+			fmt.Println(i18n.T("Docker Desktop passes only NVIDIA GPUs to containers; choose the native installation after 'cs-translate setup reset' to use this GPU."))
+		}
+		if err != nil {
+			fmt.Print(i18n.T("GPU support is not available: %v\n", err))
+		}
+		fmt.Println(i18n.T("Continuing without a GPU; translation and transcription run on the CPU and are slower."))
+		w.state.GPU = GPUCPU
+		return nil
+	}
+	return step
+}
+
+// checkGPU reports whether the container can use gpu
+func checkGPU(gpu string) error {
+	switch gpu {
+	case GPUNvidia:
+		return checkNvidiaContainerToolkit()
+	case GPUAMD, GPUIntel:
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("the container can use %s GPUs only on Linux", gpu)
+		}
+		if gpu == GPUAMD {
+			if _, err := os.Stat("/dev/kfd"); err != nil {
+				return fmt.Errorf("ROCm needs the amdgpu kernel driver: %w", err)
+			}
+		}
+		if _, err := os.Stat("/dev/dri"); err != nil {
+			return fmt.Errorf("no GPU render devices: %w", err)
+		}
+	}
+	return nil
+}
+
+// gpuRunArgs returns the docker run flags that hand gpu to the container.
+// The container user joins the groups owning the devices, as it runs
+// without root.
+func gpuRunArgs(gpu string) []string {
+	var args []string
+	switch gpu {
+	case GPUNvidia:
+		return []string{"--gpus", "all"}
+	case GPUAMD:
+		args = append(args, "--device", "/dev/kfd", "--device", "/dev/dri")
+		args = append(args, deviceGroupArgs("/dev/kfd")...)
+	case GPUIntel:
+		args = append(args, "--device", "/dev/dri",
+			"-e", "OLLAMA_VULKAN=1", "-e", "WHISPER_DEVICE=xpu")
+	default:
+		return nil
+	}
+	renderNodes, _ := filepath.Glob("/dev/dri/renderD*")
+	return append(args, deviceGroupArgs(renderNodes...)...)
+}
+
+// torchIndexURL returns the PyTorch wheel index matching gpu; empty keeps
+// pip's default, the CUDA build on Linux
+func torchIndexURL(gpu string) string {
+	switch gpu {
+	case GPUAMD:
+		return "https://download.pytorch.org/whl/rocm6.2"
+	case GPUIntel:
+		return "https://download.pytorch.org/whl/xpu"
+	case GPUCPU:
+		return "https://download.pytorch.org/whl/cpu"
+	}
+	return ""
+}
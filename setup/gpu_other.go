@@ -0,0 +1,84 @@
+//go:build !windows
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// PCI vendor IDs in /sys/class/drm/card*/device/vendor
+const (
+	pciNvidia = "0x10de"
+	pciAMD    = "0x1002"
+	pciIntel  = "0x8086"
+)
+
+// DetectGPU names the GPU the container should use, preferring NVIDIA, then
+// AMD, then a discrete Intel Arc card. Integrated Intel graphics are not
+// faster than the CPU for this, and macOS has no GPU in Docker.
+func DetectGPU() string {
+	found := make(map[string]bool)
+	cards, _ := filepath.Glob("/sys/class/drm/card*")
+	for _, card := range cards {
+		if strings.Contains(filepath.Base(card), "-") {
+			continue // a connector such as card0-DP-1
+		}
+		vendor, err := os.ReadFile(filepath.Join(card, "device", "vendor"))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(string(vendor)) {
+		case pciNvidia:
+			found[GPUNvidia] = true
+		case pciAMD:
+			found[GPUAMD] = true
+		case pciIntel:
+			device, _ := os.ReadFile(filepath.Join(card, "device", "device"))
+			// Integrated graphics next to an Arc card must not hide it
+			if isArc(strings.TrimSpace(string(device))) {
+				found[GPUIntel] = true
+			}
+		}
+	}
+	// The proprietary driver in WSL2 has no DRM device
+	if _, err := execwrap.LookPath("nvidia-smi"); err == nil {
+		found[GPUNvidia] = true
+	}
+	for _, gpu := range []string{GPUNvidia, GPUAMD, GPUIntel} {
+		if found[gpu] {
+			return gpu
+		}
+	}
+	return GPUCPU
+}
+
+// isArc reports whether a PCI device ID belongs to a discrete Arc card:
+// Alchemist (0x56xx) or Battlemage (0xe2xx)
+func isArc(device string) bool {
+	return strings.HasPrefix(device, "0x56") || strings.HasPrefix(device, "0xe2")
+}
+
+// deviceGroupArgs returns --group-add for each distinct group owning paths
+func deviceGroupArgs(paths ...string) []string {
+	var args []string
+	seen := make(map[uint32]bool)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		if !ok || seen[st.Gid] {
+			continue
+		}
+		seen[st.Gid] = true
+		args = append(args, "--group-add", fmt.Sprint(st.Gid))
+	}
+	return args
+}
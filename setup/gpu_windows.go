@@ -0,0 +1,34 @@
+//go:build windows
+
+package setup
+
+import (
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// DetectGPU names the installed GPU from the video controllers Windows
+// lists. Docker Desktop can only use an NVIDIA one.
+func DetectGPU() string {
+	out, err := execwrap.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"(Get-CimInstance Win32_VideoController).Name").Output()
+	if err != nil {
+		return GPUCPU
+	}
+	names := strings.ToLower(string(out))
+	switch {
+	case strings.Contains(names, "nvidia"):
+		return GPUNvidia
+	case strings.Contains(names, "radeon"), strings.Contains(names, "amd"):
+		return GPUAMD
+	case strings.Contains(names, "intel(r) arc"):
+		return GPUIntel
+	}
+	return GPUCPU
+}
+
+// deviceGroupArgs is not needed on Windows, where Docker has no device files
+func deviceGroupArgs(paths ...string) []string {
+	return nil
+}
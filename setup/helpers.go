@@ -6,11 +6,63 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
 )
 
+// DryRun makes setup print the commands, downloads and installers it would
+// run instead of running them. Checks that only look, like docker ps, still
+// run, and every question is answered with yes.
+var DryRun bool
+
+// run runs cmd, or prints it in a dry run
+func run(cmd *exec.Cmd) error {
+	if DryRun {
+		fmt.Print(i18n.T("[dry run] %s\n", commandLine(cmd)))
+		return nil
+	}
+	return cmd.Run()
+}
+
+// start starts cmd without waiting, or prints it in a dry run
+func start(cmd *exec.Cmd) error {
+	if DryRun {
+		fmt.Print(i18n.T("[dry run] %s &\n", commandLine(cmd)))
+		return nil
+	}
+	return cmd.Start()
+}
+
+// wait gives something just installed or started time to come up; a dry
+// run has nothing to wait for
+func wait(d time.Duration) {
+	if !DryRun {
+		time.Sleep(d)
+	}
+}
+
+// commandLine formats cmd as it could be typed into a shell
+func commandLine(cmd *exec.Cmd) string {
+	args := make([]string, len(cmd.Args))
+	for i, a := range cmd.Args {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'$|&;<>*?(){}\\") {
+			a = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+		args[i] = a
+	}
+	line := strings.Join(args, " ")
+	if cmd.Dir != "" {
+		line = "(cd " + cmd.Dir + " && " + line + ")"
+	}
+	return line
+}
+
 func PrintManualInstallInstructions(pkg string) {
 	if pkg == "python" {
-		fmt.Println("Please install Python 3.9+ from python.org")
+		fmt.Println(i18n.T("Please install Python 3.9+ from python.org"))
 	}
 }
 
@@ -20,46 +72,52 @@ func InstallDependency(scanner *bufio.Scanner, pkgName string) error {
 		return fmt.Errorf("no supported package manager found")
 	}
 
-	fmt.Printf("Package manager '%s' detected.\n", pm)
-	fmt.Printf("Do you want to install '%s' using %s? [Y/n]: ", pkgName, pm)
-	fmt.Scanln()
+	fmt.Print(i18n.T("Package manager '%s' detected.\n", pm))
+	fmt.Print(i18n.T("Do you want to install '%s' using %s? [Y/n]: ", pkgName, pm))
+	if DryRun {
+		fmt.Println(i18n.T("y"))
+	} else {
+		fmt.Scanln()
+	}
 
-	fmt.Printf("Running: %s %s\n", pm, cmdArgs)
-	cmd := exec.Command(pm, cmdArgs...)
+	cmd := execwrap.Command(pm, cmdArgs...)
+	if !DryRun {
+		fmt.Print(i18n.T("Running: %s\n", commandLine(cmd)))
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	return cmd.Run()
+	return run(cmd)
 }
 
 func detectPackageManager(pkgName string) (string, []string) {
 	if runtime.GOOS == "windows" {
-		if _, err := exec.LookPath("winget"); err == nil {
+		if _, err := execwrap.LookPath("winget"); err == nil {
 			id := pkgName
 			if pkgName == "python" {
 				id = "Python.Python.3.11"
 			}
 			return "winget", []string{"install", "-e", "--id", id}
 		}
-		if _, err := exec.LookPath("choco"); err == nil {
+		if _, err := execwrap.LookPath("choco"); err == nil {
 			return "choco", []string{"install", pkgName, "-y"}
 		}
-		if _, err := exec.LookPath("scoop"); err == nil {
+		if _, err := execwrap.LookPath("scoop"); err == nil {
 			return "scoop", []string{"install", pkgName}
 		}
 	} else {
-		if _, err := exec.LookPath("apt-get"); err == nil {
+		if _, err := execwrap.LookPath("apt-get"); err == nil {
 			return "sudo", []string{"apt-get", "install", "-y", pkgName}
 		}
-		if _, err := exec.LookPath("dnf"); err == nil {
+		if _, err := execwrap.LookPath("dnf"); err == nil {
 			return "sudo", []string{"dnf", "install", "-y", pkgName}
 		}
-		if _, err := exec.LookPath("pacman"); err == nil {
+		if _, err := execwrap.LookPath("pacman"); err == nil {
 			target := pkgName
 			return "sudo", []string{"pacman", "-S", "--noconfirm", target}
 		}
-		if _, err := exec.LookPath("zypper"); err == nil {
+		if _, err := execwrap.LookPath("zypper"); err == nil {
 			return "sudo", []string{"zypper", "install", "-y", pkgName}
 		}
 	}
@@ -4,56 +4,31 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
+
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
 )
 
-func CheckAndInstallNvidiaContainerToolkit(scanner *bufio.Scanner) error {
+func checkNvidiaContainerToolkit() error {
 	if runtime.GOOS != "linux" {
 		return nil
 	}
-
-	fmt.Println("Checking for nvidia-container-toolkit...")
-
-	checkCmd := exec.Command("nvidia-container-runtime", "--version")
-	if err := checkCmd.Run(); err == nil {
-		fmt.Println("✔ nvidia-container-toolkit is already installed")
-		return nil
-	}
-
-	fmt.Println("nvidia-container-toolkit is required for GPU support in Docker.")
-	fmt.Println("Do you want to install it now? [Y/n]: ")
-	if scanner.Scan() {
-		input := strings.TrimSpace(scanner.Text())
-		if input != "" && strings.ToLower(input) != "y" && strings.ToLower(input) != "yes" {
-			return fmt.Errorf("nvidia-container-toolkit is required for GPU support")
-		}
-	}
-
-	if runtime.GOOS == "linux" {
-		return installNvidiaContainerToolkitLinux(scanner)
-	}
-
-	fmt.Println("nvidia-container-toolkit installation is only supported on Linux.")
-	return fmt.Errorf("nvidia-container-toolkit is required for GPU support")
+	return execwrap.Command("nvidia-container-runtime", "--version").Run()
 }
 
 func installNvidiaContainerToolkitLinux(scanner *bufio.Scanner) error {
-	fmt.Println("Installing nvidia-container-toolkit on Linux...")
-
-	if _, err := exec.LookPath("curl"); err != nil {
-		fmt.Println("curl is required for installation.")
-		fmt.Print("Do you want to install curl? [Y/n]: ")
-		if scanner.Scan() {
-			input := strings.TrimSpace(scanner.Text())
-			if input == "" || strings.ToLower(input) == "y" || strings.ToLower(input) == "yes" {
-				if err := InstallDependency(scanner, "curl"); err != nil {
-					return fmt.Errorf("failed to install curl: %w", err)
-				}
-			} else {
-				return fmt.Errorf("curl is required for installation")
-			}
+	fmt.Println(i18n.T("Installing nvidia-container-toolkit on Linux..."))
+
+	if _, err := execwrap.LookPath("curl"); err != nil {
+		fmt.Println(i18n.T("curl is required for installation."))
+		if !confirm(scanner, i18n.T("Do you want to install curl? [Y/n]: ")) {
+			return fmt.Errorf("curl is required for installation")
+		}
+		if err := InstallDependency(scanner, "curl"); err != nil {
+			return fmt.Errorf("failed to install curl: %w", err)
 		}
 	}
 
@@ -77,13 +52,13 @@ func installNvidiaContainerToolkitLinux(scanner *bufio.Scanner) error {
 		return installNvidiaContainerToolkitArch(scanner)
 	}
 
-	fmt.Printf("Unsupported distribution: %s\n", distribution)
-	fmt.Println("Please install nvidia-container-toolkit manually.")
+	fmt.Print(i18n.T("Unsupported distribution: %s\n", distribution))
+	fmt.Println(i18n.T("Please install nvidia-container-toolkit manually."))
 	return fmt.Errorf("unsupported distribution for automatic installation")
 }
 
 func installNvidiaContainerToolkitUbuntu(scanner *bufio.Scanner) error {
-	fmt.Println("Setting up nvidia-container-toolkit for Ubuntu/Debian...")
+	fmt.Println(i18n.T("Setting up nvidia-container-toolkit for Ubuntu/Debian..."))
 
 	distribution := "$(. /etc/os-release;echo $ID$VERSION_ID)"
 
@@ -92,39 +67,39 @@ func installNvidiaContainerToolkitUbuntu(scanner *bufio.Scanner) error {
 		curl -s -L https://nvidia.github.io/nvidia-docker/$distribution/nvidia-docker.list | \
 		sudo tee /etc/apt/sources.list.d/nvidia-docker.list > /dev/null`, distribution)
 
-	cmd := exec.Command("sh", "-c", addRepoCmd)
+	cmd := execwrap.Command("sh", "-c", addRepoCmd)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := run(cmd); err != nil {
 		return fmt.Errorf("failed to add nvidia-docker repository: %w", err)
 	}
 
-	updateCmd := exec.Command("sudo", "apt-get", "update")
+	updateCmd := execwrap.Command("sudo", "apt-get", "update")
 	updateCmd.Stdout = os.Stdout
 	updateCmd.Stderr = os.Stderr
-	if err := updateCmd.Run(); err != nil {
+	if err := run(updateCmd); err != nil {
 		return fmt.Errorf("failed to update apt: %w", err)
 	}
 
-	installCmd := exec.Command("sudo", "apt-get", "install", "-y", "nvidia-container-toolkit")
+	installCmd := execwrap.Command("sudo", "apt-get", "install", "-y", "nvidia-container-toolkit")
 	installCmd.Stdout = os.Stdout
 	installCmd.Stderr = os.Stderr
-	if err := installCmd.Run(); err != nil {
+	if err := run(installCmd); err != nil {
 		return fmt.Errorf("failed to install nvidia-container-toolkit: %w", err)
 	}
 
-	fmt.Println("Restarting Docker service...")
-	restartCmd := exec.Command("sudo", "systemctl", "restart", "docker")
+	fmt.Println(i18n.T("Restarting Docker service..."))
+	restartCmd := execwrap.Command("sudo", "systemctl", "restart", "docker")
 	restartCmd.Stdout = os.Stdout
 	restartCmd.Stderr = os.Stderr
-	restartCmd.Run()
+	run(restartCmd)
 
-	fmt.Println("✔ nvidia-container-toolkit installed successfully")
+	fmt.Println(display.CheckMark, i18n.T("nvidia-container-toolkit installed successfully"))
 	return nil
 }
 
 func installNvidiaContainerToolkitFedora(scanner *bufio.Scanner) error {
-	fmt.Println("Setting up nvidia-container-toolkit for Fedora/RHEL...")
+	fmt.Println(i18n.T("Setting up nvidia-container-toolkit for Fedora/RHEL..."))
 
 	distribution := "$(. /etc/os-release;echo $ID$VERSION_ID)"
 
@@ -133,46 +108,46 @@ func installNvidiaContainerToolkitFedora(scanner *bufio.Scanner) error {
 		curl -s -L https://nvidia.github.io/nvidia-docker/$distribution/nvidia-docker.list | \
 		sudo tee /etc/apt/sources.list.d/nvidia-docker.list > /dev/null`, distribution)
 
-	cmd := exec.Command("sh", "-c", addRepoCmd)
+	cmd := execwrap.Command("sh", "-c", addRepoCmd)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := run(cmd); err != nil {
 		return fmt.Errorf("failed to add nvidia-docker repository: %w", err)
 	}
 
-	installCmd := exec.Command("sudo", "dnf", "install", "-y", "nvidia-container-toolkit")
+	installCmd := execwrap.Command("sudo", "dnf", "install", "-y", "nvidia-container-toolkit")
 	installCmd.Stdout = os.Stdout
 	installCmd.Stderr = os.Stderr
-	if err := installCmd.Run(); err != nil {
+	if err := run(installCmd); err != nil {
 		return fmt.Errorf("failed to install nvidia-container-toolkit: %w", err)
 	}
 
-	fmt.Println("Restarting Docker service...")
-	restartCmd := exec.Command("sudo", "systemctl", "restart", "docker")
+	fmt.Println(i18n.T("Restarting Docker service..."))
+	restartCmd := execwrap.Command("sudo", "systemctl", "restart", "docker")
 	restartCmd.Stdout = os.Stdout
 	restartCmd.Stderr = os.Stderr
-	restartCmd.Run()
+	run(restartCmd)
 
-	fmt.Println("✔ nvidia-container-toolkit installed successfully")
+	fmt.Println(display.CheckMark, i18n.T("nvidia-container-toolkit installed successfully"))
 	return nil
 }
 
 func installNvidiaContainerToolkitArch(scanner *bufio.Scanner) error {
-	fmt.Println("Setting up nvidia-container-toolkit for Arch Linux...")
+	fmt.Println(i18n.T("Setting up nvidia-container-toolkit for Arch Linux..."))
 
-	installCmd := exec.Command("sudo", "pacman", "-S", "--noconfirm", "nvidia-container-toolkit")
+	installCmd := execwrap.Command("sudo", "pacman", "-S", "--noconfirm", "nvidia-container-toolkit")
 	installCmd.Stdout = os.Stdout
 	installCmd.Stderr = os.Stderr
-	if err := installCmd.Run(); err != nil {
+	if err := run(installCmd); err != nil {
 		return fmt.Errorf("failed to install nvidia-container-toolkit: %w", err)
 	}
 
-	fmt.Println("Restarting Docker service...")
-	restartCmd := exec.Command("sudo", "systemctl", "restart", "docker")
+	fmt.Println(i18n.T("Restarting Docker service..."))
+	restartCmd := execwrap.Command("sudo", "systemctl", "restart", "docker")
 	restartCmd.Stdout = os.Stdout
 	restartCmd.Stderr = os.Stderr
-	restartCmd.Run()
+	run(restartCmd)
 
-	fmt.Println("✔ nvidia-container-toolkit installed successfully")
+	fmt.Println(display.CheckMark, i18n.T("nvidia-container-toolkit installed successfully"))
 	return nil
 }
@@ -8,12 +8,14 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
 	"github.com/micha/cs-ingame-translate/translator"
 )
 
@@ -21,157 +23,236 @@ type OllamaVersionResponse struct {
 	Version string `json:"version"`
 }
 
-func SetupOllama(scanner *bufio.Scanner) error {
-	fmt.Println("Checking Ollama installation...")
-
-	useDocker := os.Getenv("USE_DOCKER_OLLAMA") != "0"
-
-	if os.Getenv("USE_DOCKER_OLLAMA") == "" && runtime.GOOS == "windows" {
-		if err := CheckDocker(); err != nil {
-			fmt.Println("Docker not detected. Defaulting to native installation.")
-			useDocker = false
-		} else {
-			fmt.Println("Select installation method:")
-			fmt.Println("1. Docker (Recommended - Unified container)")
-			fmt.Println("2. Native (Run Ollama and Python directly on Windows)")
-			fmt.Print("Enter choice [1]: ")
-			if scanner.Scan() {
-				input := strings.TrimSpace(scanner.Text())
-				if input == "2" {
-					useDocker = false
-				}
-			}
+// chooseMethod picks Docker or a native installation: USE_DOCKER_OLLAMA
+// decides when set, then the choice remembered from an earlier run. Only
+// Windows asks; elsewhere the container is used.
+func (w *wizard) chooseMethod() string {
+	method := w.state.Method
+	switch os.Getenv("USE_DOCKER_OLLAMA") {
+	case "0":
+		return MethodNative
+	case "":
+		if method == "" && runtime.GOOS == "windows" {
+			method = w.askMethod()
 		}
+	default:
+		method = MethodDocker
+	}
+	if method == "" {
+		method = MethodDocker
+	}
+	if method == MethodNative {
+		os.Setenv("USE_DOCKER_OLLAMA", "0")
+		os.Setenv("USE_DOCKER_WHISPER", "0")
+	}
+	if method != w.state.Method {
+		w.state.Method = method
+		w.save()
+	}
+	return method
+}
 
-		if !useDocker {
-			os.Setenv("USE_DOCKER_OLLAMA", "0")
-			os.Setenv("USE_DOCKER_WHISPER", "0")
+func (w *wizard) askMethod() string {
+	choice := "1"
+	if err := CheckDocker(); err != nil {
+		fmt.Println(i18n.T("Docker not detected. Defaulting to native installation."))
+		choice = "2"
+	} else if gpu := DetectGPU(); gpu == GPUAMD || gpu == GPUIntel {
+		fmt.Println(i18n.T("Docker Desktop cannot use this GPU, but a native Ollama can. Defaulting to native installation."))
+		choice = "2"
+	}
+	fmt.Println(i18n.T("Select installation method:"))
+	fmt.Println(i18n.T("1. Docker (Recommended - Unified container; installs Docker Desktop if missing)"))
+	fmt.Println(i18n.T("2. Native (Run Ollama and Python directly on Windows)"))
+	fmt.Print(i18n.T("Enter choice [%s]: ", choice))
+	if w.scanner.Scan() {
+		if input := strings.TrimSpace(w.scanner.Text()); input != "" {
+			choice = input
 		}
 	}
-
-	if useDocker {
-		return SetupDockerContainer(scanner)
+	if choice == "2" {
+		return MethodNative
 	}
+	return MethodDocker
+}
 
-	ollamaURL := translator.OllamaHost
+// ollamaStep makes sure a native Ollama answers, offering to install it
+func ollamaStep() Step {
+	return Step{
+		Name:  "ollama",
+		Title: i18n.T("Ollama is running"),
+		Check: func() error { return checkOllama() },
+		Run: func(scanner *bufio.Scanner) error {
+			fmt.Print(i18n.T("Ollama is not running or not accessible at %s\n", translator.OllamaHost))
+			fmt.Println(i18n.T("Ollama is required for translation."))
+			fmt.Println(i18n.T("you can set USE_DOCKER_OLLAMA=0 for no isolation in docker (more performant)."))
+			if !confirm(scanner, i18n.T("Do you want to install Ollama? [Y/n]: ")) {
+				return fmt.Errorf("Ollama is required for translation")
+			}
+			if err := InstallOllama(scanner); err != nil {
+				return err
+			}
+			if err := checkOllama(); err != nil {
+				return fmt.Errorf("Ollama still not accessible after installation")
+			}
+			return nil
+		},
+	}
+}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(ollamaURL + "/api/version")
+// checkOllama reports whether Ollama answers
+func checkOllama() error {
+	resp, err := translator.GetWithTimeout(translator.OllamaHost+"/api/version", 5*time.Second)
 	if err != nil {
-		fmt.Printf("Ollama is not running or not accessible at %s\n", ollamaURL)
-		fmt.Println("Ollama is required for translation.")
-		fmt.Println("you can set USE_DOCKER_OLLAMA=0 for no isolation in docker (more performant).")
-		fmt.Print("Do you want to install Ollama (with docker)? [Y/n]: ")
-		if scanner.Scan() {
-			input := strings.TrimSpace(scanner.Text())
-			if input == "" || strings.ToLower(input) == "y" || strings.ToLower(input) == "yes" {
-				if err := InstallOllama(scanner); err != nil {
-					return err
-				}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// modelStep makes sure the translation model is pulled, in the container
+// or the native Ollama
+func modelStep(method, model string) Step {
+	if model == "" {
+		model = translator.DefaultOllamaModel
+	}
+	return Step{
+		Name:  "model",
+		Title: i18n.T("Model '%s' is installed", model),
+		Check: func() error { return checkModel(model) },
+		Run: func(scanner *bufio.Scanner) error {
+			fmt.Print(i18n.T("Model '%s' not found.\n", model))
+			if !confirm(scanner, i18n.T("Do you want to download '%s'? (required for translation) [Y/n]: ", model)) {
+				return fmt.Errorf("model '%s' is required for translation", model)
+			}
+			pull := execwrap.Command("ollama", "pull", model)
+			if method == MethodDocker {
+				fmt.Print(i18n.T("Pulling model '%s' in Docker... (this may take a few minutes)\n", model))
+				pull = execwrap.Command("docker", "exec", ContainerName, "ollama", "pull", model)
 			} else {
-				return fmt.Errorf("Ollama is required for translation")
+				fmt.Print(i18n.T("Pulling model '%s'... (this may take a few minutes)\n", model))
 			}
-		}
-		resp, err = client.Get(ollamaURL + "/api/version")
-		if err != nil {
-			return fmt.Errorf("Ollama still not accessible after installation")
-		}
+			pull.Stdout = os.Stdout
+			pull.Stderr = os.Stderr
+			if err := run(pull); err != nil {
+				return fmt.Errorf("failed to pull model: %w", err)
+			}
+			fmt.Print(i18n.T("%s Model '%s' downloaded successfully\n", display.CheckMark, model))
+			return nil
+		},
 	}
-	defer resp.Body.Close()
+}
 
-	var versionResp OllamaVersionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&versionResp); err == nil {
-		fmt.Printf("✔ Ollama is running (version: %s)\n", versionResp.Version)
-	} else {
-		fmt.Println("✔ Ollama is running")
+// checkModel asks Ollama whether model is installed
+func checkModel(model string) error {
+	resp, err := translator.GetWithTimeout(translator.OllamaHost+"/api/tags", 10*time.Second)
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
 
-	model := translator.DefaultOllamaModel
-	fmt.Printf("Checking for Ollama model '%s'...\n", model)
-	return CheckAndPullModel(scanner, model)
+	var tagsResp struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err != nil {
+		return fmt.Errorf("could not parse installed models: %w", err)
+	}
+	for _, m := range tagsResp.Models {
+		if strings.HasPrefix(m.Name, model) {
+			return nil
+		}
+	}
+	return fmt.Errorf("model '%s' is not installed", model)
 }
 
 func InstallOllama(scanner *bufio.Scanner) error {
 	if runtime.GOOS == "windows" {
-		fmt.Println("Installing Ollama for Windows...")
-		fmt.Println("Downloading Ollama installer...")
+		fmt.Println(i18n.T("Installing Ollama for Windows..."))
+		fmt.Println(i18n.T("Downloading Ollama installer..."))
 
 		installerURL := "https://ollama.com/download/OllamaSetup.exe"
 		tmpDir := os.TempDir()
 		installerPath := filepath.Join(tmpDir, "OllamaSetup.exe")
 
 		if err := DownloadFile(installerURL, installerPath); err != nil {
-			fmt.Printf("Failed to download installer: %v\n", err)
-			fmt.Println("Please download Ollama manually from: https://ollama.com")
+			fmt.Print(i18n.T("Failed to download installer: %v\n", err))
+			fmt.Println(i18n.T("Please download Ollama manually from: https://ollama.com"))
 			return fmt.Errorf("failed to download Ollama")
 		}
 		defer os.Remove(installerPath)
 
-		fmt.Println("Running Ollama installer...")
+		fmt.Println(i18n.T("Running Ollama installer..."))
 		// Use start command to ensure the installer window gets focus
-		cmd := exec.Command("cmd", "/c", "start", "/wait", "Ollama Setup", installerPath)
+		cmd := execwrap.Command("cmd", "/c", "start", "/wait", "Ollama Setup", installerPath)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
+		if err := run(cmd); err != nil {
 			return fmt.Errorf("installer failed: %w", err)
 		}
 
-		fmt.Println("✔ Ollama installed. Starting service...")
-		time.Sleep(3 * time.Second)
+		fmt.Println(display.CheckMark, i18n.T("Ollama installed. Starting service..."))
+		wait(3 * time.Second)
 		return nil
 	}
 
-	fmt.Println("Installing Ollama for Linux...")
-	cmd := exec.Command("sh", "-c", "curl -fsSL https://ollama.com/install.sh | sh")
+	fmt.Println(i18n.T("Installing Ollama for Linux..."))
+	cmd := execwrap.Command("sh", "-c", "curl -fsSL https://ollama.com/install.sh | sh")
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Printf("Automatic installation failed: %v\n", err)
-		fmt.Println("Please install Ollama manually:")
+	if err := run(cmd); err != nil {
+		fmt.Print(i18n.T("Automatic installation failed: %v\n", err))
+		fmt.Println(i18n.T("Please install Ollama manually:"))
 		fmt.Println("  curl -fsSL https://ollama.com/install.sh | sh")
 		return fmt.Errorf("failed to install Ollama")
 	}
 
-	fmt.Println("✔ Ollama installed successfully")
-	fmt.Println("Starting Ollama service...")
+	fmt.Println(display.CheckMark, i18n.T("Ollama installed successfully"))
+	fmt.Println(i18n.T("Starting Ollama service..."))
 
 	port := translator.DefaultOllamaPort
 	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
 	if err != nil {
-		fmt.Printf("Port %d is already in use. Looking for an available port...\n", port)
+		fmt.Print(i18n.T("Port %d is already in use. Looking for an available port...\n", port))
 		port, err = translator.FindAvailablePort(port + 1)
 		if err != nil {
 			return fmt.Errorf("could not find an available port: %w", err)
 		}
-		fmt.Printf("Using alternative port: %d\n", port)
-		fmt.Println("Note: You'll need to set OLLAMA_HOST to use this port.")
-		fmt.Printf("Run: export OLLAMA_HOST=http://localhost:%d\n", port)
+		fmt.Print(i18n.T("Using alternative port: %d\n", port))
+		fmt.Println(i18n.T("Note: You'll need to set OLLAMA_HOST to use this port."))
+		fmt.Print(i18n.T("Run: export OLLAMA_HOST=http://localhost:%d\n", port))
 	} else {
 		ln.Close()
 	}
 
-	env := os.Environ()
-	env = append(env, fmt.Sprintf("OLLAMA_HOST=localhost:%d", port))
-	ollamaCmd := exec.Command("ollama", "serve")
-	ollamaCmd.Env = env
+	ollamaCmd := execwrap.Command("ollama", "serve")
+	ollamaCmd.Env = append(ollamaCmd.Environ(), fmt.Sprintf("OLLAMA_HOST=localhost:%d", port))
 	ollamaCmd.Stdout = os.Stdout
 	ollamaCmd.Stderr = os.Stderr
-	if err := ollamaCmd.Start(); err != nil {
-		fmt.Printf("Warning: Could not start Ollama service: %v\n", err)
+	if err := start(ollamaCmd); err != nil {
+		fmt.Print(i18n.T("Warning: Could not start Ollama service: %v\n", err))
 	}
-	time.Sleep(2 * time.Second)
+	wait(2 * time.Second)
 
 	return nil
 }
 
 func DownloadFile(url string, dest string) error {
+	if DryRun {
+		fmt.Print(i18n.T("[dry run] download %s to %s\n", url, dest))
+		return nil
+	}
 	resp, err := http.Get(url)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
 
 	out, err := os.Create(dest)
 	if err != nil {
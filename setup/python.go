@@ -4,118 +4,149 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
+
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
 )
 
-func SetupPythonEnv(scanner *bufio.Scanner) error {
+// whisperStep makes sure the venv in the working directory has Whisper,
+// for transcription without Docker
+func whisperStep() Step {
+	return Step{
+		Name:  "whisper",
+		Title: i18n.T("'openai-whisper' is installed in venv"),
+		Check: func() error {
+			python, _, err := venvPaths()
+			if err != nil {
+				return err
+			}
+			return execwrap.Command(python, "-c", "import whisper").Run()
+		},
+		Run: SetupPythonEnv,
+	}
+}
+
+// venvPaths returns the Python and pip of the venv in the working directory
+func venvPaths() (python, pip string, err error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return err
+		return "", "", err
 	}
-
-	pythonExe := "python3"
+	venvDir := filepath.Join(cwd, "venv")
 	if runtime.GOOS == "windows" {
-		pythonExe = "python"
+		return filepath.Join(venvDir, "Scripts", "python.exe"), filepath.Join(venvDir, "Scripts", "pip.exe"), nil
 	}
+	return filepath.Join(venvDir, "bin", "python3"), filepath.Join(venvDir, "bin", "pip"), nil
+}
 
-	if _, err := exec.LookPath(pythonExe); err != nil {
-		found := false
-		if runtime.GOOS == "linux" {
-			if _, err := exec.LookPath("python"); err == nil {
-				pythonExe = "python"
-				found = true
-			}
-		}
-
-		if !found {
-			fmt.Printf("Error: Python interpreter (%s) not found.\n", pythonExe)
-			if err := InstallDependency(scanner, "python"); err != nil {
-				PrintManualInstallInstructions("python")
-				return err
-			}
-			if _, err := exec.LookPath(pythonExe); err != nil {
-				if runtime.GOOS == "windows" {
-					pythonExe = "python"
-				}
-				if _, err := exec.LookPath(pythonExe); err != nil {
-					return fmt.Errorf("python still not found after installation")
-				}
-			}
-		}
+// SetupPythonEnv finds Python, creates the venv and installs openai-whisper
+// into it, asking before each
+func SetupPythonEnv(scanner *bufio.Scanner) error {
+	pythonExe, err := findPython(scanner)
+	if err != nil {
+		return err
 	}
-	fmt.Printf("✔ Python interpreter found (%s).\n", pythonExe)
+	fmt.Print(i18n.T("%s Python interpreter found (%s).\n", display.CheckMark, pythonExe))
 
-	venvDir := filepath.Join(cwd, "venv")
-	if _, err := os.Stat(venvDir); os.IsNotExist(err) {
-		fmt.Printf("Python virtual environment 'venv' not found.\n")
-		fmt.Print("Do you want to create it automatically? [Y/n]: ")
-		if scanner.Scan() {
-			input := strings.TrimSpace(scanner.Text())
-			if input == "" || strings.ToLower(input) == "y" || strings.ToLower(input) == "yes" {
-				fmt.Println("Creating virtual environment...")
-				cmd := exec.Command(pythonExe, "-m", "venv", "venv")
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				if err := cmd.Run(); err != nil {
-					if runtime.GOOS == "linux" {
-						fmt.Println("Error: Failed to create venv. You might need to install 'python3-venv'.")
-						if err := InstallDependency(scanner, "python3-venv"); err == nil {
-							fmt.Println("Retrying venv creation...")
-							if err := cmd.Run(); err != nil {
-								return fmt.Errorf("failed to create venv after installing package: %w", err)
-							}
-							fmt.Println("✔ Virtual environment created.")
-							goto VenvCreated
-						}
-					}
-					return fmt.Errorf("failed to create venv: %w", err)
-				}
-				fmt.Println("✔ Virtual environment created.")
-			} else {
-				return fmt.Errorf("virtual environment is required for voice transcription")
-			}
+	if _, err := os.Stat("venv"); os.IsNotExist(err) {
+		fmt.Print(i18n.T("Python virtual environment 'venv' not found.\n"))
+		if !confirm(scanner, i18n.T("Do you want to create it automatically? [Y/n]: ")) {
+			return fmt.Errorf("virtual environment is required for voice transcription")
+		}
+		if err := createVenv(scanner, pythonExe); err != nil {
+			return err
 		}
+		fmt.Println(display.CheckMark, i18n.T("Virtual environment created."))
 	} else {
-		fmt.Println("✔ Virtual environment 'venv' exists.")
+		fmt.Println(display.CheckMark, i18n.T("Virtual environment 'venv' exists."))
 	}
 
-VenvCreated:
-	pipExe := filepath.Join(venvDir, "bin", "pip")
-	if runtime.GOOS == "windows" {
-		pipExe = filepath.Join(venvDir, "Scripts", "pip.exe")
+	pythonVenvExe, pipExe, err := venvPaths()
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("Checking for 'openai-whisper' package..."))
+	if err := execwrap.Command(pythonVenvExe, "-c", "import whisper; print('ok')").Run(); err == nil {
+		fmt.Println(display.CheckMark, i18n.T("'openai-whisper' is already installed."))
+		return nil
+	}
+	fmt.Println(i18n.T("'openai-whisper' package not found in venv."))
+	if !confirm(scanner, i18n.T("Do you want to install it now? (This will download PyTorch ~1GB) [Y/n]: ")) {
+		return fmt.Errorf("openai-whisper is required for voice transcription")
+	}
+	fmt.Println(i18n.T("Installing openai-whisper..."))
+	// pip's default PyTorch on Linux is the CUDA build
+	if index := torchIndexURL(selectedGPU(LoadWizardState())); index != "" && runtime.GOOS == "linux" {
+		torchCmd := execwrap.Command(pipExe, "install", "torch", "--index-url", index)
+		torchCmd.Stdout = os.Stdout
+		torchCmd.Stderr = os.Stderr
+		if err := run(torchCmd); err != nil {
+			return fmt.Errorf("failed to install PyTorch: %w", err)
+		}
 	}
+	installCmd := execwrap.Command(pipExe, "install", "openai-whisper")
+	installCmd.Stdout = os.Stdout
+	installCmd.Stderr = os.Stderr
+	if err := run(installCmd); err != nil {
+		return fmt.Errorf("failed to install openai-whisper: %w", err)
+	}
+	fmt.Println(display.CheckMark, i18n.T("'openai-whisper' installed successfully."))
+	return nil
+}
 
-	pythonVenvExe := filepath.Join(venvDir, "bin", "python3")
+// findPython returns the Python interpreter, offering to install it
+func findPython(scanner *bufio.Scanner) (string, error) {
+	pythonExe := "python3"
 	if runtime.GOOS == "windows" {
-		pythonVenvExe = filepath.Join(venvDir, "Scripts", "python.exe")
+		pythonExe = "python"
 	}
-
-	fmt.Println("Checking for 'openai-whisper' package...")
-	checkCmd := exec.Command(pythonVenvExe, "-c", "import whisper; print('ok')")
-	if err := checkCmd.Run(); err != nil {
-		fmt.Println("'openai-whisper' package not found in venv.")
-		fmt.Print("Do you want to install it now? (This will download PyTorch ~1GB) [Y/n]: ")
-		if scanner.Scan() {
-			input := strings.TrimSpace(scanner.Text())
-			if input == "" || strings.ToLower(input) == "y" || strings.ToLower(input) == "yes" {
-				fmt.Println("Installing openai-whisper...")
-				installCmd := exec.Command(pipExe, "install", "openai-whisper")
-				installCmd.Stdout = os.Stdout
-				installCmd.Stderr = os.Stderr
-				if err := installCmd.Run(); err != nil {
-					return fmt.Errorf("failed to install openai-whisper: %w", err)
-				}
-				fmt.Println("✔ 'openai-whisper' installed successfully.")
-			} else {
-				return fmt.Errorf("openai-whisper is required for voice transcription")
-			}
+	if _, err := execwrap.LookPath(pythonExe); err == nil {
+		return pythonExe, nil
+	}
+	if runtime.GOOS == "linux" {
+		if _, err := execwrap.LookPath("python"); err == nil {
+			return "python", nil
 		}
-	} else {
-		fmt.Println("✔ 'openai-whisper' is already installed.")
 	}
 
+	fmt.Print(i18n.T("Error: Python interpreter (%s) not found.\n", pythonExe))
+	if err := InstallDependency(scanner, "python"); err != nil {
+		PrintManualInstallInstructions("python")
+		return "", err
+	}
+	if _, err := execwrap.LookPath(pythonExe); err != nil {
+		return "", fmt.Errorf("python still not found after installation")
+	}
+	return pythonExe, nil
+}
+
+// createVenv creates the venv, installing python3-venv on Linux if that is
+// what is missing
+func createVenv(scanner *bufio.Scanner, pythonExe string) error {
+	fmt.Println(i18n.T("Creating virtual environment..."))
+	create := func() error {
+		cmd := execwrap.Command(pythonExe, "-m", "venv", "venv")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return run(cmd)
+	}
+	err := create()
+	if err == nil {
+		return nil
+	}
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("failed to create venv: %w", err)
+	}
+	fmt.Println(i18n.T("Error: Failed to create venv. You might need to install 'python3-venv'."))
+	if InstallDependency(scanner, "python3-venv") != nil {
+		return fmt.Errorf("failed to create venv: %w", err)
+	}
+	fmt.Println(i18n.T("Retrying venv creation..."))
+	if err := create(); err != nil {
+		return fmt.Errorf("failed to create venv after installing package: %w", err)
+	}
 	return nil
 }
@@ -1,56 +1,117 @@
 
 import sys
 import os
+import json
+import base64
+import math
 import signal
 import warnings
 
 # Suppress unimportant warnings
 warnings.filterwarnings("ignore")
 
+# JSON-lines protocol spoken with the Go side, see audio/protocol.go
+PROTOCOL_VERSION = 2
+
 def handle_sigterm(*args):
     sys.exit(0)
 
 signal.signal(signal.SIGTERM, handle_sigterm)
 
+def pick_language(model, audio, candidates):
+    """Restrict language detection to the expected languages"""
+    if not candidates:
+        return None
+    if len(candidates) == 1:
+        return candidates[0]
+    import whisper
+    mel = whisper.log_mel_spectrogram(whisper.pad_or_trim(audio), model.dims.n_mels).to(model.device)
+    _, probs = model.detect_language(mel)
+    return max(candidates, key=lambda c: probs.get(c, 0.0))
+
 def main():
+    # Keep the real stdout for protocol messages only; anything else that
+    # gets printed (by us or by libraries) ends up on stderr
+    out = sys.stdout
+    sys.stdout = sys.stderr
+
+    def send(msg):
+        out.write(json.dumps(msg) + "\n")
+        out.flush()
+
     try:
         import whisper
     except ImportError:
         print("Error: 'openai-whisper' python package not found. Please install it: pip install openai-whisper", file=sys.stderr)
         sys.exit(1)
 
-    # Print to stderr so main program can differentiate logs from data
     whisper_model = os.environ.get("WHISPER_MODEL", "base")
     print(f"Loading Whisper model '{whisper_model}'...", file=sys.stderr)
 
     try:
-        model = whisper.load_model(whisper_model)
+        # WHISPER_DEVICE picks a device PyTorch does not choose on its own,
+        # e.g. xpu for Intel Arc; ROCm shows up as cuda
+        model = whisper.load_model(whisper_model, device=os.environ.get("WHISPER_DEVICE") or None)
         print("Whisper model loaded.", file=sys.stderr)
     except Exception as e:
         print(f"Failed to load model: {e}", file=sys.stderr)
         sys.exit(1)
 
-    print("READY", flush=True)
+    send({"type": "ready", "protocol": PROTOCOL_VERSION})
 
     for line in sys.stdin:
-        path = line.strip()
-        if not path:
+        line = line.strip()
+        if not line:
             continue
-            
+
         try:
-            # Check if file exists
-            if not os.path.exists(path):
-                print(f"File not found: {path}", file=sys.stderr)
+            req = json.loads(line)
+        except ValueError:
+            send({"type": "result", "id": 0, "error": f"invalid request: {line}"})
+            continue
+
+        req_id = req.get("id", 0)
+        path = req.get("path", "")
+        pcm = req.get("pcm")
+        name = req.get("name") or path
+
+        try:
+            if pcm:
+                # 16-bit mono samples at 16 kHz, sent instead of a file
+                import numpy as np
+                audio = np.frombuffer(base64.b64decode(pcm), np.int16).flatten().astype(np.float32) / 32768.0
+            elif not os.path.exists(path):
+                send({"type": "result", "id": req_id, "error": f"file not found: {path}"})
                 continue
+            else:
+                audio = whisper.load_audio(path)
+
+            task = req.get("task") or "transcribe"
+            language = pick_language(model, audio, req.get("languages") or [])
+            result = model.transcribe(audio, language=language, task=task)
+            segments = result.get("segments") or []
+
+            avg_logprob = 0.0
+            no_speech_prob = 0.0
+            if segments:
+                avg_logprob = sum(s["avg_logprob"] for s in segments) / len(segments)
+                no_speech_prob = sum(s["no_speech_prob"] for s in segments) / len(segments)
+            confidence = math.exp(avg_logprob) if segments else 0.0
 
-            result = model.transcribe(path)
-            text = result["text"].strip().replace("\n", " ")
-            print(text, flush=True)
-            
-            # Optional: remove file after processing? Go code does it.
+            send({
+                "type": "result",
+                "id": req_id,
+                "text": result["text"].strip().replace("\n", " "),
+                "language": result.get("language", ""),
+                "task": task,
+                "confidence": round(confidence, 4),
+                "avg_logprob": round(avg_logprob, 4),
+                "no_speech_prob": round(no_speech_prob, 4),
+                "duration": round(len(audio) / whisper.audio.SAMPLE_RATE, 3),
+            })
+            # Go code removes the file after processing
         except Exception as e:
-            print(f"Error processing {path}: {e}", file=sys.stderr)
-            print("", flush=True) # Send empty line to signal done/error
+            send({"type": "result", "id": req_id, "error": f"error processing {name}: {e}"})
 
 if __name__ == "__main__":
     # Force UTF-8 for Windows console
@@ -0,0 +1,253 @@
+package setup
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/i18n"
+)
+
+const (
+	screenCaptureReleaseAPI = "https://api.github.com/repos/rdp/screen-capture-recorder-to-video-windows-free/releases/latest"
+	vbCableURL              = "https://download.vb-audio.com/Download_CABLE/VBCABLE_Driver_Pack43.zip"
+)
+
+// AudioDeviceStep is the wizard step for SetupVirtualAudio
+func AudioDeviceStep() Step {
+	return Step{
+		Name:     "audio",
+		Title:    i18n.T("Audio capture device '%s' found", audio.ScreenCaptureRecorderDevice),
+		Check:    func() error { return checkDShowDevice(audio.ScreenCaptureRecorderDevice) },
+		Run:      SetupVirtualAudio,
+		Optional: true,
+	}
+}
+
+// VirtualCableStep is the wizard step for SetupVirtualCable
+func VirtualCableStep() Step {
+	return Step{
+		Name:     "cable",
+		Title:    i18n.T("VB-Audio Virtual Cable found"),
+		Check:    func() error { return checkDShowDevice(audio.VBCableDevice) },
+		Run:      SetupVirtualCable,
+		Optional: true,
+	}
+}
+
+// checkDShowDevice passes on Windows when the device is installed and
+// everywhere else
+func checkDShowDevice(name string) error {
+	if runtime.GOOS == "windows" && !audio.HasDShowDevice(name) {
+		return fmt.Errorf("audio device '%s' is not installed", name)
+	}
+	return nil
+}
+
+// SetupVirtualAudio makes sure the Windows capture device for the system
+// output is installed, offering to download and install
+// screen-capture-recorder. Other platforms capture through PulseAudio and
+// need nothing.
+func SetupVirtualAudio(scanner *bufio.Scanner) error {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	if audio.HasDShowDevice(audio.ScreenCaptureRecorderDevice) {
+		fmt.Print(i18n.T("%s Audio capture device '%s' found.\n", display.CheckMark, audio.ScreenCaptureRecorderDevice))
+		return nil
+	}
+
+	fmt.Print(i18n.T("Audio capture device '%s' not found.\n", audio.ScreenCaptureRecorderDevice))
+	fmt.Println(i18n.T("It comes with screen-capture-recorder and lets cs-translate hear the game's audio."))
+	if !confirm(scanner, i18n.T("Download and install it now? [Y/n]: ")) {
+		return fmt.Errorf("%w: %s", audio.ErrNoMonitorSource, audio.ScreenCaptureRecorderDevice)
+	}
+
+	url, err := screenCaptureInstallerURL()
+	if err != nil {
+		return fmt.Errorf("could not find the screen-capture-recorder installer: %w", err)
+	}
+	installer := filepath.Join(os.TempDir(), "screen-capture-recorder-setup.exe")
+	fmt.Println(i18n.T("Downloading screen-capture-recorder..."))
+	if err := DownloadFile(url, installer); err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer os.Remove(installer)
+
+	fmt.Println(i18n.T("Installing (confirm the administrator prompt)..."))
+	if err := runElevated(installer, "/VERYSILENT", "/SUPPRESSMSGBOXES", "/NORESTART"); err != nil {
+		return fmt.Errorf("installer failed: %w", err)
+	}
+	return verifyDevice(audio.ScreenCaptureRecorderDevice)
+}
+
+// SetupVirtualCable makes sure VB-Audio's virtual cable is installed on
+// Windows, so text-to-speech can be played into a virtual microphone
+func SetupVirtualCable(scanner *bufio.Scanner) error {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	if audio.HasDShowDevice(audio.VBCableDevice) {
+		fmt.Println(display.CheckMark, i18n.T("VB-Audio Virtual Cable found."))
+		return nil
+	}
+
+	fmt.Println(i18n.T("VB-Audio Virtual Cable not found. It lets teammates hear text-to-speech as your microphone."))
+	if !confirm(scanner, i18n.T("Download and install it now? [Y/n]: ")) {
+		return fmt.Errorf("VB-Audio Virtual Cable is not installed")
+	}
+
+	dir, err := os.MkdirTemp("", "vbcable")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "vbcable.zip")
+	fmt.Println(i18n.T("Downloading VB-Audio Virtual Cable..."))
+	if err := DownloadFile(vbCableURL, archive); err != nil {
+		return fmt.Errorf("failed to download %s: %w", vbCableURL, err)
+	}
+	if DryRun {
+		fmt.Print(i18n.T("[dry run] extract %s\n", archive))
+	} else if err := unzip(archive, dir); err != nil {
+		return fmt.Errorf("failed to extract the driver pack: %w", err)
+	}
+
+	installer := filepath.Join(dir, "VBCABLE_Setup_x64.exe")
+	if runtime.GOARCH == "386" {
+		installer = filepath.Join(dir, "VBCABLE_Setup.exe")
+	}
+	fmt.Println(i18n.T("Installing the driver (confirm the administrator prompt)..."))
+	if err := runElevated(installer, "-i", "-h"); err != nil {
+		return fmt.Errorf("driver installer failed: %w", err)
+	}
+	if err := verifyDevice(audio.VBCableDevice); err != nil {
+		return fmt.Errorf("%w (a reboot may be needed before Windows shows the cable)", err)
+	}
+	fmt.Println(i18n.T("Make 'CABLE Input' the playback device for text-to-speech and 'CABLE Output' the microphone in CS2."))
+	return nil
+}
+
+// screenCaptureInstallerURL finds the installer of the latest release
+func screenCaptureInstallerURL() (string, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(screenCaptureReleaseAPI)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub answered %s", resp.Status)
+	}
+
+	var release struct {
+		Assets []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	for _, a := range release.Assets {
+		name := strings.ToLower(a.Name)
+		if strings.HasPrefix(name, "setup") && strings.HasSuffix(name, ".exe") {
+			return a.URL, nil
+		}
+	}
+	return "", fmt.Errorf("no installer in the latest release")
+}
+
+// runElevated runs an installer through a UAC prompt and waits for it
+func runElevated(path string, args ...string) error {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = "'" + a + "'"
+	}
+	script := fmt.Sprintf("$p = Start-Process -FilePath '%s' -ArgumentList %s -Verb RunAs -Wait -PassThru; exit $p.ExitCode",
+		strings.ReplaceAll(path, "'", "''"), strings.Join(quoted, ","))
+	cmd := execwrap.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return run(cmd)
+}
+
+// verifyDevice waits briefly for a freshly installed device to show up
+func verifyDevice(name string) error {
+	if DryRun {
+		return nil
+	}
+	for i := 0; i < 5; i++ {
+		if audio.HasDShowDevice(name) {
+			fmt.Print(i18n.T("%s Audio device '%s' installed.\n", display.CheckMark, name))
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("%w: '%s' is still missing after installation", audio.ErrNoMonitorSource, name)
+}
+
+func unzip(archive, dir string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		dest := filepath.Join(dir, f.Name)
+		if !strings.HasPrefix(dest, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			os.MkdirAll(dest, 0755)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := extractFile(f, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, dest string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, rc)
+	return err
+}
+
+func confirm(scanner *bufio.Scanner, prompt string) bool {
+	fmt.Print(prompt)
+	if DryRun {
+		fmt.Println(i18n.T("y"))
+		return true
+	}
+	if !scanner.Scan() {
+		return false
+	}
+	input := strings.TrimSpace(scanner.Text())
+	return input == "" || i18n.Yes(input)
+}
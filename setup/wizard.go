@@ -0,0 +1,221 @@
+package setup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/i18n"
+)
+
+// WizardFileName holds the setup progress inside config.Dir
+const WizardFileName = "setup.json"
+
+// ErrRestartRequired is returned by a step that only finishes after the
+// computer restarts, as installing WSL2 for Docker does. The next run
+// resumes at that step.
+var ErrRestartRequired = errors.New("restart the computer to finish the installation")
+
+// Installation methods
+const (
+	MethodDocker = "docker" // Ollama and Whisper in the unified container
+	MethodNative = "native" // Ollama and Python installed directly
+)
+
+// Step is one stage of the setup wizard
+type Step struct {
+	Name  string // recorded in the wizard state, e.g. "docker"
+	Title string // shown when the step passes, e.g. "Docker is running"
+	// Check verifies the step without asking anything; nil means it passes.
+	// Steps without a check run once and are then remembered as done.
+	Check func() error
+	// Run asks and installs until Check would pass
+	Run func(scanner *bufio.Scanner) error
+	// Optional steps report a failure and let setup continue
+	Optional bool
+}
+
+// Options selects the steps of a wizard run
+type Options struct {
+	Model string      // translation model to pull (empty: the default)
+	Voice bool        // voice transcription needs Whisper
+	Steps []Step      // run after the built-in steps, e.g. the audio device
+	Warn  func(error) // reports failed optional steps (default: log)
+}
+
+// WizardState is the setup progress remembered between runs
+type WizardState struct {
+	Method    string               `json:"method,omitempty"`
+	GPU       string               `json:"gpu,omitempty"` // what the container is built for
+	Completed map[string]time.Time `json:"completed,omitempty"`
+	Pending   string               `json:"pending,omitempty"` // the step waiting for a restart
+}
+
+// Done reports whether the step named name has passed before
+func (s WizardState) Done(name string) bool {
+	_, ok := s.Completed[name]
+	return ok
+}
+
+func wizardPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, WizardFileName), nil
+}
+
+// LoadWizardState reads the setup progress; a missing or broken file yields
+// a fresh start
+func LoadWizardState() WizardState {
+	var s WizardState
+	if path, err := wizardPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &s)
+		}
+	}
+	return s
+}
+
+// SaveWizardState writes the setup progress for the next run
+func SaveWizardState(s WizardState) error {
+	path, err := wizardPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ResetWizard forgets the setup progress, so every step asks again
+func ResetWizard() error {
+	path, err := wizardPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// wizard walks through the steps, saving after each one that changes the
+// state so an interrupted setup picks up where it stopped
+type wizard struct {
+	scanner *bufio.Scanner
+	state   WizardState
+	warn    func(error)
+}
+
+// RunWizard runs the setup steps in order: the installation method,
+// Docker, the GPU and the container, Ollama, the model, Whisper and
+// then opts.Steps. Steps that still pass are not asked again.
+func RunWizard(scanner *bufio.Scanner, opts Options) error {
+	w := &wizard{scanner: scanner, state: LoadWizardState(), warn: opts.Warn}
+	if w.warn == nil {
+		w.warn = func(err error) { slog.Warn("Setup", "err", err) }
+	}
+	if w.state.Pending != "" {
+		fmt.Print(i18n.T("Resuming setup at '%s'.\n", w.state.Pending))
+	}
+
+	method := w.chooseMethod()
+	for _, step := range append(w.builtinSteps(method, opts.Model, opts.Voice), opts.Steps...) {
+		if err := w.run(step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *wizard) run(step Step) error {
+	if step.Check == nil && w.state.Done(step.Name) && w.state.Pending != step.Name {
+		return nil
+	}
+	if step.Check != nil && step.Check() == nil {
+		fmt.Println(display.CheckMark, step.Title)
+		w.complete(step.Name)
+		return nil
+	}
+
+	err := step.Run(w.scanner)
+	switch {
+	case err != nil && DryRun:
+		// Nothing was installed, so checks after an install fail; show
+		// the remaining steps anyway
+		fmt.Print(i18n.T("[dry run] %s: %v\n", step.Name, err))
+		return nil
+	case errors.Is(err, ErrRestartRequired):
+		w.state.Pending = step.Name
+		w.save()
+		return fmt.Errorf("%s: %w; run cs-translate again afterwards to continue the setup", step.Name, err)
+	case err != nil && step.Optional:
+		w.warn(err)
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to set up %s: %w", step.Name, err)
+	}
+	w.complete(step.Name)
+	return nil
+}
+
+// complete records a passed step, writing the state only when it changed
+func (w *wizard) complete(name string) {
+	if w.state.Done(name) && w.state.Pending != name {
+		return
+	}
+	if w.state.Completed == nil {
+		w.state.Completed = make(map[string]time.Time)
+	}
+	if !w.state.Done(name) {
+		w.state.Completed[name] = time.Now()
+	}
+	if w.state.Pending == name {
+		w.state.Pending = ""
+	}
+	w.save()
+}
+
+func (w *wizard) save() {
+	if DryRun {
+		return
+	}
+	if err := SaveWizardState(w.state); err != nil {
+		slog.Warn("Could not save the setup progress", "err", err)
+	}
+}
+
+// builtinSteps returns the steps of method; Whisper needs one only when it
+// runs natively
+func (w *wizard) builtinSteps(method, model string, voice bool) []Step {
+	var steps []Step
+	if method == MethodDocker {
+		w.chooseGPU()
+		steps = append(steps, dockerStep(), w.gpuStep(), w.containerStep())
+	} else {
+		steps = append(steps, ollamaStep())
+	}
+	steps = append(steps, modelStep(method, model))
+	if voice {
+		if os.Getenv("USE_DOCKER_WHISPER") != "0" {
+			fmt.Println(i18n.T("Using Docker for Whisper transcription (already running in unified container)"))
+			os.Setenv("USE_DOCKER_WHISPER", "1")
+		} else {
+			steps = append(steps, whisperStep())
+		}
+	}
+	return steps
+}
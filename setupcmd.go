@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/setup"
+)
+
+func runSetupCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println(i18n.T("Usage: cs-translate setup <status|reset|audio|cable|ffmpeg|device> [-dry-run]"))
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "status":
+		printSetupStatus()
+		return
+	case "reset":
+		if err := setup.ResetWizard(); err != nil {
+			fmt.Print(i18n.T("Error: %v\n", err))
+			os.Exit(1)
+		}
+		fmt.Println(i18n.T("Setup progress cleared; every step is checked and asked again on the next start."))
+		return
+	}
+	fs := flag.NewFlagSet("setup", flag.ExitOnError)
+	fs.BoolVar(&setup.DryRun, "dry-run", false, "Print the downloads and installers instead of running them")
+	fs.Parse(args[1:])
+	if args[0] == "ffmpeg" || args[0] == "device" {
+		cfg, _ := config.Load()
+		useFFmpeg(cfg.FFmpeg)
+		scanner := bufio.NewScanner(os.Stdin)
+		var err error
+		if args[0] == "ffmpeg" {
+			err = setup.SetupFFmpeg(scanner)
+		} else {
+			device := cfg.AudioDevice
+			err = pickAudioDevice(scanner, &device)
+		}
+		if err != nil {
+			if !printHint(err) {
+				fmt.Print(i18n.T("Error: %v\n", err))
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	if runtime.GOOS != "windows" {
+		fmt.Println(i18n.T("Virtual audio devices only need to be installed on Windows; PulseAudio and PipeWire provide monitor sources."))
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	var err error
+	switch args[0] {
+	case "audio":
+		err = setup.SetupVirtualAudio(scanner)
+	case "cable":
+		err = setup.SetupVirtualCable(scanner)
+	default:
+		fmt.Print(i18n.T("Unknown setup action: %s\n", args[0]))
+		os.Exit(2)
+	}
+	if err != nil {
+		if !printHint(err) {
+			fmt.Print(i18n.T("Error: %v\n", err))
+		}
+		os.Exit(1)
+	}
+}
+
+// printSetupStatus lists the setup steps passed so far
+func printSetupStatus() {
+	state := setup.LoadWizardState()
+	if len(state.Completed) == 0 && state.Pending == "" {
+		fmt.Println(i18n.T("Setup has not run yet; it starts with cs-translate."))
+		return
+	}
+	if state.Method != "" {
+		fmt.Print(i18n.T("Installation method: %s\n", state.Method))
+	}
+	if state.GPU != "" {
+		fmt.Print(i18n.T("Container GPU: %s\n", state.GPU))
+	}
+	names := make([]string, 0, len(state.Completed))
+	for name := range state.Completed {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return state.Completed[names[i]].Before(state.Completed[names[j]]) })
+	for _, name := range names {
+		fmt.Printf("%s %-10s %s\n", display.CheckMark, name, state.Completed[name].Format("2006-01-02 15:04"))
+	}
+	if state.Pending != "" {
+		fmt.Print(i18n.T("Waiting for a restart at '%s'; start cs-translate to continue.\n", state.Pending))
+	}
+}
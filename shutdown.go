@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/pipeline"
+	"github.com/micha/cs-ingame-translate/tempdir"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// shutdownTimeout is how long chat translations still running at exit may
+// take to be shown
+var shutdownTimeout = 5 * time.Second
+
+// shutDown stops taking in chat and voice, then shows the chat translations
+// still running, for at most shutdownTimeout. Another Ctrl+C on interrupt
+// stops waiting. Sinks are closed by the caller's defers afterwards.
+func shutDown(disp *pipeline.Dispatcher, listener *audio.Listener, interrupt <-chan os.Signal) {
+	if listener != nil {
+		listener.StopCapture()
+	}
+	drained := disp.Drain()
+	select {
+	case <-drained:
+		flushResults(disp)
+		return
+	default:
+	}
+	if shutdownTimeout <= 0 {
+		return
+	}
+	fmt.Println(i18n.T("Finishing translations in progress... (Ctrl+C again quits now)"))
+	deadline := time.NewTimer(shutdownTimeout)
+	defer deadline.Stop()
+	for {
+		select {
+		case res := <-disp.Results():
+			handleChatResult(res)
+		case <-drained:
+			flushResults(disp)
+			return
+		case <-deadline.C:
+			slog.Warn("Chat translations still running at exit were dropped", "timeout", shutdownTimeout)
+			return
+		case <-interrupt:
+			return
+		}
+	}
+}
+
+// flushResults shows the results already waiting in disp
+func flushResults(disp *pipeline.Dispatcher) {
+	for {
+		select {
+		case res := <-disp.Results():
+			handleChatResult(res)
+		default:
+			return
+		}
+	}
+}
+
+// removeOrphanedTemp deletes the recording folders of runs that crashed or
+// were killed before they could clean up
+func removeOrphanedTemp() {
+	removed := tempdir.RemoveOrphans("")
+	if dir, err := translator.HostAudioDir(); err == nil {
+		removed = append(removed, tempdir.RemoveOrphans(dir)...)
+	}
+	if len(removed) > 0 {
+		slog.Info("Removed temp folders left by an earlier run", "folders", removed)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/monitor"
+	"github.com/micha/cs-ingame-translate/parser"
+	"github.com/micha/cs-ingame-translate/tempdir"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// hiddenFlags are developer flags left out of -help
+var hiddenFlags = map[string]bool{
+	"soak": true,
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(out)
+	flag.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	visible.PrintDefaults()
+}
+
+const (
+	soakChatInterval  = 250 * time.Millisecond
+	soakAudioInterval = 2 * time.Second
+	soakStatsInterval = 30 * time.Second
+)
+
+var soakPlayers = []string{"l1ght", "Дима", "小明", "João", "m0nesy fan"}
+
+var soakMessages = []string{
+	"rush b no stop",
+	"давай на б",
+	"一起去A点",
+	"vamos rápido, eles estão no meio",
+	"nice shot",
+	"?????",
+	"eco this round pls",
+}
+
+type soakStats struct {
+	goroutines int
+	heapMB     float64
+	sysMB      float64
+	numGC      uint32
+	openFDs    int
+	tempFiles  int
+}
+
+func readSoakStats() soakStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return soakStats{
+		goroutines: runtime.NumGoroutine(),
+		heapMB:     float64(m.HeapAlloc) / (1 << 20),
+		sysMB:      float64(m.Sys) / (1 << 20),
+		numGC:      m.NumGC,
+		openFDs:    countOpenFDs(),
+		tempFiles:  countTempFiles(),
+	}
+}
+
+func (s soakStats) String() string {
+	return fmt.Sprintf("goroutines=%d heap=%.1fMB sys=%.1fMB gc=%d fds=%d temp=%d",
+		s.goroutines, s.heapMB, s.sysMB, s.numGC, s.openFDs, s.tempFiles)
+}
+
+// countOpenFDs returns the number of open file descriptors, or -1 where the
+// platform does not expose them as a directory
+func countOpenFDs() int {
+	dir := "/proc/self/fd"
+	if runtime.GOOS == "darwin" {
+		dir = "/dev/fd"
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
+
+// countTempFiles counts files this tool leaves in the temp directory: audio
+// segments, echo recordings and extracted transcriber scripts
+func countTempFiles() int {
+	count := 0
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		return -1
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "cs-") && !strings.HasPrefix(name, "transcriber-") {
+			continue
+		}
+		if !e.IsDir() {
+			count++
+			continue
+		}
+		sub, err := os.ReadDir(filepath.Join(os.TempDir(), name))
+		if err == nil {
+			count += len(sub)
+		}
+	}
+	return count
+}
+
+// runSoak feeds synthetic chat and audio through the pipeline for the given
+// duration and reports runtime growth, to catch goroutine/fd/memory leaks
+func runSoak(ctx context.Context, duration time.Duration, tr translator.Translator, listener *audio.Listener) {
+	dir, err := tempdir.New("", "cs-soak")
+	if err != nil {
+		log.Fatalf("Failed to create soak dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "console.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		log.Fatalf("Failed to create soak log: %v", err)
+	}
+	defer logFile.Close()
+
+	mon, err := monitor.NewMonitor(logPath)
+	if err != nil {
+		log.Fatalf("Error creating monitor: %v", err)
+	}
+	defer mon.Stop()
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	fmt.Printf("Soak test running for %s (chat every %s", duration, soakChatInterval)
+	if listener != nil {
+		fmt.Printf(", audio every %s", soakAudioInterval)
+	}
+	fmt.Println(")")
+
+	baseline := readSoakStats()
+	fmt.Printf("[soak baseline] %s\n", baseline)
+
+	start := time.Now()
+	chatTicker := time.NewTicker(soakChatInterval)
+	defer chatTicker.Stop()
+	audioTicker := time.NewTicker(soakAudioInterval)
+	defer audioTicker.Stop()
+	statsTicker := time.NewTicker(soakStatsInterval)
+	defer statsTicker.Stop()
+
+	var audioChan <-chan audio.Transcription
+	var transcriberStatus <-chan audio.Status
+	if listener != nil {
+		audioChan = listener.Transcriptions()
+		transcriberStatus = listener.Status()
+	} else {
+		audioTicker.Stop()
+	}
+
+	var written, chats, voices, failures, restarts int
+	logLines := mon.Lines()
+
+	for {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+			final := readSoakStats()
+			fmt.Printf("\n[soak done after %s] %s\n", time.Since(start).Round(time.Second), final)
+			fmt.Printf("Lines written=%d translated chat=%d voice=%d errors=%d transcriber restarts=%d\n", written, chats, voices, failures, restarts)
+			fmt.Printf("Growth: goroutines %+d, heap %+.1fMB, fds %+d, temp files %+d\n",
+				final.goroutines-baseline.goroutines, final.heapMB-baseline.heapMB,
+				final.openFDs-baseline.openFDs, final.tempFiles-baseline.tempFiles)
+			return
+
+		case <-chatTicker.C:
+			line := fmt.Sprintf("%s  [ALL] %s: %s\n", time.Now().Format("01/02 15:04:05"),
+				soakPlayers[rand.Intn(len(soakPlayers))], soakMessages[rand.Intn(len(soakMessages))])
+			if _, err := logFile.WriteString(line); err != nil {
+				slog.Warn("Soak: failed to write log line", "err", err)
+			}
+			written++
+
+		case <-audioTicker.C:
+			path := filepath.Join(dir, fmt.Sprintf("soak_%d.wav", time.Now().UnixNano()))
+			if err := writeSyntheticWAV(path, soakAudioInterval); err != nil {
+				slog.Warn("Soak: failed to write audio", "err", err)
+				continue
+			}
+			listener.SubmitFile(path)
+
+		case line, ok := <-logLines:
+			if !ok {
+				logLines = nil
+				continue
+			}
+			if line.Err != nil {
+				continue
+			}
+			if msg := parser.ParseLine(line.Text); msg != nil {
+				if _, err := tr.Translate(ctx, msg.MessageContent); err != nil && ctx.Err() == nil {
+					failures++
+				}
+				chats++
+			}
+
+		case t, ok := <-audioChan:
+			if !ok {
+				audioChan = nil
+				continue
+			}
+			if _, err := tr.Translate(ctx, t.Text); err != nil && ctx.Err() == nil {
+				failures++
+			}
+			voices++
+
+		case status := <-transcriberStatus:
+			if status.State == audio.StatusReady {
+				restarts++
+			}
+			printStatus(transcriberEvent(status))
+
+		case <-statsTicker.C:
+			fmt.Printf("[soak %s] %s chat=%d voice=%d errors=%d\n",
+				time.Since(start).Round(time.Second), readSoakStats(), chats, voices, failures)
+		}
+	}
+}
+
+// writeSyntheticWAV writes a 16kHz mono PCM WAV with a quiet warbling tone,
+// loud enough to pass the silence check so it reaches the transcriber
+func writeSyntheticWAV(path string, d time.Duration) error {
+	const sampleRate = 16000
+	samples := int(d.Seconds() * sampleRate)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dataSize := uint32(samples * 2)
+	header := []any{
+		[]byte("RIFF"), 36 + dataSize, []byte("WAVE"),
+		[]byte("fmt "), uint32(16), uint16(1), uint16(1), uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16),
+		[]byte("data"), dataSize,
+	}
+	for _, v := range header {
+		if err := binary.Write(f, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+
+	pcm := make([]int16, samples)
+	freq := 200 + rand.Float64()*200
+	for i := range pcm {
+		t := float64(i) / sampleRate
+		pcm[i] = int16(3000 * math.Sin(2*math.Pi*freq*t*(1+0.1*math.Sin(2*math.Pi*3*t))))
+	}
+	return binary.Write(f, binary.LittleEndian, pcm)
+}
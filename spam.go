@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/pipeline"
+)
+
+// Marks of chat that spam protection kept from the translator
+const (
+	viaRepeated    = "repeated"     // the player repeated their previous message
+	viaRateLimited = "rate limited" // the player is over -chat-rate
+)
+
+// chatLimiter collapses repeated chat and caps translations per player; nil
+// when spam protection is off
+var chatLimiter *pipeline.Limiter
+
+// limitChat reports whether spam protection keeps c from the translator. The
+// first repeat of a message is shown once and later ones are hidden; a
+// message over the rate is shown untranslated.
+func limitChat(c events.ChatReceived) bool {
+	verdict, repeats := chatLimiter.Check(c.Player, c.Text)
+	if verdict == pipeline.Allow {
+		return false
+	}
+	via := viaRateLimited
+	if verdict == pipeline.Repeat {
+		slog.Debug("Repeated message not translated", "player", c.Player, "repeats", repeats)
+		if repeats > 1 {
+			return true
+		}
+		via = viaRepeated
+	}
+	bus.Publish(events.TranslationDone{
+		MessageID: c.ID, Source: "chat", Player: c.Player, Team: c.Team, Dead: c.Dead, Line: c.Line,
+		Log: c.Log, Original: c.Text, Translated: c.Text, Language: chatLang(c), Via: via,
+	})
+	return true
+}
@@ -0,0 +1,152 @@
+// Package speakers remembers which language each voice speaker uses, so
+// speakers who already talk in the target language are not translated, and
+// which language each player writes chat in during a session.
+package speakers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// recentSize is how many detections a profile keeps
+	recentSize = 10
+	// minObservations before a profile settles on a language
+	minObservations = 3
+)
+
+// Profile is what we know about one speaker
+type Profile struct {
+	Language string    `json:"language"` // settled language, "" until known
+	Recent   []string  `json:"recent"`   // last detected languages, oldest first
+	Updated  time.Time `json:"updated"`
+}
+
+// Store keeps profiles by speaker name and persists them to a JSON file
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	profiles map[string]*Profile
+}
+
+// New returns an empty store that is never written to disk
+func New() *Store {
+	return &Store{profiles: make(map[string]*Profile)}
+}
+
+// Load reads the profiles at path. A missing file gives an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, profiles: make(map[string]*Profile)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.profiles); err != nil {
+		return s, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Observe records a detected language for speaker and returns the language
+// the speaker is settled on. The file is rewritten when that changes.
+func (s *Store) Observe(speaker, lang string) string {
+	if speaker == "" || lang == "" {
+		return s.Language(speaker)
+	}
+
+	s.mu.Lock()
+	p := s.profiles[speaker]
+	if p == nil {
+		p = &Profile{}
+		s.profiles[speaker] = p
+	}
+	p.Recent = append(p.Recent, lang)
+	if len(p.Recent) > recentSize {
+		p.Recent = p.Recent[len(p.Recent)-recentSize:]
+	}
+	p.Updated = time.Now()
+
+	settled := majority(p.Recent)
+	changed := settled != p.Language
+	p.Language = settled
+	s.mu.Unlock()
+
+	if changed {
+		s.Save()
+	}
+	return settled
+}
+
+// majority is the most frequent language once there are enough samples and
+// it holds more than half of them
+func majority(langs []string) string {
+	if len(langs) < minObservations {
+		return ""
+	}
+	return leading(langs)
+}
+
+// leading is the language holding more than half of langs, or ""
+func leading(langs []string) string {
+	counts := make(map[string]int)
+	best, bestCount := "", 0
+	for _, l := range langs {
+		counts[l]++
+		if counts[l] > bestCount {
+			best, bestCount = l, counts[l]
+		}
+	}
+	if bestCount*2 <= len(langs) {
+		return ""
+	}
+	return best
+}
+
+// Language returns the settled language of speaker, or ""
+func (s *Store) Language(speaker string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := s.profiles[speaker]; p != nil {
+		return p.Language
+	}
+	return ""
+}
+
+// Likely returns the language most of the speaker's recent detections
+// agree on, without waiting for the profile to settle; "" when they do not
+// agree
+func (s *Store) Likely(speaker string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := s.profiles[speaker]; p != nil {
+		return leading(p.Recent)
+	}
+	return ""
+}
+
+// Save writes all profiles to the store's file; a store from New is not
+// saved
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.profiles, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(s.path), err)
+	}
+	return os.WriteFile(s.path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/server"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// Stream Deck plugins connect to /streamdeck over a WebSocket. They send
+// actions as {"action": "..."} and receive the state whenever it changes and
+// every translation, with a snippet short enough for a key face.
+
+// deckSnippetWidth fits three short lines on a 72px key
+const deckSnippetWidth = 24
+
+// Stream Deck actions
+const (
+	deckToggleVoice = "toggle_voice"
+	deckSetLang     = "set_lang"
+	deckCapture     = "capture"
+	deckPause       = "pause"
+	deckResume      = "resume"
+	deckTogglePause = "toggle_pause"
+	deckState       = "state"
+)
+
+type deckAction struct {
+	Action string `json:"action"`
+	Lang   string `json:"lang,omitempty"` // for set_lang
+}
+
+type deckStateMsg struct {
+	Event  string `json:"event"` // "state"
+	Mode   string `json:"mode"`
+	Voice  bool   `json:"voice"`
+	Lang   string `json:"lang"`
+	Paused bool   `json:"paused"`
+}
+
+type deckTranslationMsg struct {
+	Event   string `json:"event"` // "translation"
+	Source  string `json:"source"`
+	Player  string `json:"player,omitempty"`
+	Text    string `json:"text"`
+	Snippet string `json:"snippet"`
+}
+
+type deckErrorMsg struct {
+	Event   string `json:"event"` // "error"
+	Message string `json:"message"`
+}
+
+// streamDeck connects plugin clients with the mode loop, which handles the
+// actions. All methods are no-ops on nil, i.e. without the web server and
+// Telegram.
+type streamDeck struct {
+	hub     *server.Hub
+	actions chan deckAction
+
+	mu    sync.Mutex
+	state deckStateMsg
+}
+
+// deck is set up by newWebServer, or by startTelegram for its commands
+var deck *streamDeck
+
+func newStreamDeck(mode, lang string) *streamDeck {
+	return &streamDeck{
+		hub:     server.NewHub(),
+		actions: make(chan deckAction, 8),
+		state:   deckStateMsg{Event: deckState, Mode: mode, Lang: lang},
+	}
+}
+
+func (d *streamDeck) handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := server.Upgrade(w, r)
+	if err != nil {
+		slog.Warn("Stream Deck", "err", err)
+		return
+	}
+	d.hub.Add(conn)
+	defer d.hub.Remove(conn)
+
+	conn.WriteJSON(d.currentState())
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var a deckAction
+		if err := json.Unmarshal(msg, &a); err != nil {
+			conn.WriteJSON(deckErrorMsg{Event: "error", Message: "invalid message: " + err.Error()})
+			continue
+		}
+		switch a.Action {
+		case deckState:
+			conn.WriteJSON(d.currentState())
+		case deckToggleVoice, deckSetLang, deckCapture, deckPause, deckResume, deckTogglePause:
+			if !d.request(a) {
+				conn.WriteJSON(deckErrorMsg{Event: "error", Message: "busy, try again"})
+			}
+		default:
+			conn.WriteJSON(deckErrorMsg{Event: "error", Message: "unknown action " + a.Action})
+		}
+	}
+}
+
+// request hands a to the mode loop; false when it is busy
+func (d *streamDeck) request(a deckAction) bool {
+	select {
+	case d.actions <- a:
+		return true
+	default:
+		return false
+	}
+}
+
+// Actions delivers plugin actions to the mode loop
+func (d *streamDeck) Actions() <-chan deckAction {
+	if d == nil {
+		return nil
+	}
+	return d.actions
+}
+
+func (d *streamDeck) currentState() deckStateMsg {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
+
+// setVoice, setPaused and setLang update the state and push it to every plugin
+func (d *streamDeck) setVoice(on bool) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.state.Voice = on
+	state := d.state
+	d.mu.Unlock()
+	d.hub.Broadcast(state)
+}
+
+func (d *streamDeck) setPaused(paused bool) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.state.Paused = paused
+	state := d.state
+	d.mu.Unlock()
+	d.hub.Broadcast(state)
+}
+
+func (d *streamDeck) setLang(lang string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.state.Lang = lang
+	state := d.state
+	d.mu.Unlock()
+	d.hub.Broadcast(state)
+}
+
+func (d *streamDeck) translation(source, player, text string) {
+	if d == nil {
+		return
+	}
+	d.hub.Broadcast(deckTranslationMsg{
+		Event:   "translation",
+		Source:  source,
+		Player:  player,
+		Text:    text,
+		Snippet: display.Truncate(text, deckSnippetWidth, "…"),
+	})
+}
+
+func (d *streamDeck) fail(msg string) {
+	if d == nil {
+		return
+	}
+	d.hub.Broadcast(deckErrorMsg{Event: "error", Message: msg})
+}
+
+func (d *streamDeck) close() {
+	if d == nil {
+		return
+	}
+	d.hub.Close()
+}
+
+// switchLang changes the target language; called from the mode loop
+func switchLang(tr translator.Translator, lang string) {
+	if lang == "" {
+		deck.fail("set_lang needs a lang")
+		return
+	}
+	lang, err := translator.NormalizeLanguage(lang)
+	if err != nil {
+		deck.fail(err.Error())
+		return
+	}
+	tr.SetTargetLang(lang)
+	targetLang = lang
+	fmt.Printf("Target language switched to %s\n", translator.LanguageName(lang))
+	deck.setLang(lang)
+}
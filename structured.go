@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+var (
+	// structured is -structured: the backend reports the language of chat,
+	// which then replaces the guess from its letters
+	structured bool
+	// minConfidence is -min-confidence: structured answers below it are
+	// marked as unsure and their language detection is not trusted
+	minConfidence float64
+)
+
+// chatJob is the payload of a chat translation: the message, what the
+// backend reported about it while translating and its tone
+type chatJob struct {
+	msg    events.ChatReceived
+	detail translator.Detail
+	tone   translator.Tone // "" when not tagged
+}
+
+// ownLanguage reports whether the backend is sure a message is written in
+// its target language lang already, so the original is shown instead of a
+// rephrasing
+func ownLanguage(d translator.Detail, lang string) bool {
+	target := translator.LanguageCode(lang)
+	return target != "" && d.Language == target && d.Confidence >= minConfidence
+}
+
+// unsure reports whether the backend said it is not sure of a translation
+func unsure(t events.TranslationDone) bool {
+	return t.Confidence > 0 && t.Confidence < minConfidence
+}
@@ -0,0 +1,75 @@
+// Package subtitle writes timed text as SRT or WebVTT subtitles, e.g. to
+// subtitle recorded gameplay with what was said during it.
+package subtitle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Formats Write supports
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// ErrUnknownFormat is returned for a format other than FormatSRT and
+// FormatVTT
+var ErrUnknownFormat = errors.New("unknown subtitle format (use srt or vtt)")
+
+// Cue is one subtitle, timed from the start of the video
+type Cue struct {
+	Start, End time.Duration
+	Text       string // may span lines
+}
+
+// Write writes cues in format, in the order given
+func Write(w io.Writer, format string, cues []Cue) error {
+	var sep byte
+	switch format {
+	case FormatSRT:
+		sep = ','
+	case FormatVTT:
+		sep = '.'
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	bw := bufio.NewWriter(w)
+	if format == FormatVTT {
+		bw.WriteString("WEBVTT\n\n")
+	}
+	for i, c := range cues {
+		if format == FormatSRT {
+			fmt.Fprintf(bw, "%d\n", i+1)
+		}
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n", timestamp(c.Start, sep), timestamp(c.End, sep), cueText(c.Text, format))
+	}
+	return bw.Flush()
+}
+
+// timestamp formats d as HH:MM:SS,mmm, with sep before the milliseconds
+func timestamp(d time.Duration, sep byte) string {
+	d = max(d, 0)
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// cueText drops blank lines, which would end the cue early, and escapes
+// what WebVTT reads as markup
+func cueText(text, format string) string {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	text = strings.Join(lines, "\n")
+	if format == FormatVTT {
+		text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	}
+	return text
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/hotkey"
+	"github.com/micha/cs-ingame-translate/output"
+	"github.com/micha/cs-ingame-translate/tempdir"
+	"github.com/micha/cs-ingame-translate/translator"
+	"github.com/micha/cs-ingame-translate/tts"
+)
+
+// talkSpeaker labels transcriptions of the user's own microphone so they are
+// not mistaken for teammates
+const talkSpeaker = "@mic"
+
+// talker records the microphone while F10 is toggled on and translates what
+// was said into the team's language
+type talker struct {
+	cfg      config.TalkConfig
+	rec      *echoRecorder
+	dir      string
+	listener *audio.Listener
+	tr       translator.Translator
+	keys     *hotkey.Listener
+
+	since       time.Time // zero while not talking
+	speech      chan string
+	unsubscribe func() // stops the text-to-speech sink
+}
+
+// startTalk sets up talk mode; nil when it is disabled or cannot run
+func startTalk(ctx context.Context, cfg config.TalkConfig, backend translator.BackendConfig, listener *audio.Listener) *talker {
+	if !cfg.Enabled {
+		return nil
+	}
+	if listener == nil {
+		slog.Warn("Talk mode needs voice transcription; it is disabled")
+		return nil
+	}
+
+	input, err := micInput(cfg.Mic)
+	if err != nil {
+		slog.Warn("Talk mode disabled", "err", err)
+		return nil
+	}
+	dir, err := tempdir.New("", "cs-talk-rec")
+	if err != nil {
+		slog.Warn("Talk mode disabled", "err", err)
+		return nil
+	}
+	rec, err := newRecorder(ctx, dir, input)
+	if err != nil {
+		os.RemoveAll(dir)
+		if !printHint(err) {
+			slog.Warn("Talk mode disabled, could not record the microphone", "err", err)
+		}
+		return nil
+	}
+	tr, err := translator.NewChain(ctx, []translator.BackendConfig{backend}, cfg.Lang)
+	if err != nil {
+		rec.stop()
+		os.RemoveAll(dir)
+		slog.Warn("Talk mode disabled", "err", err)
+		return nil
+	}
+
+	t := &talker{
+		cfg:      cfg,
+		rec:      rec,
+		dir:      dir,
+		listener: listener,
+		tr:       keepEntities(tr),
+		keys:     hotkey.NewListener(talkKey),
+		speech:   make(chan string, 4),
+	}
+	go func() {
+		if err := t.keys.Start(ctx); err != nil {
+			slog.Error("Talk hotkey failed", "err", err)
+		}
+	}()
+	t.unsubscribe = func() {}
+	if cfg.TTS {
+		t.unsubscribe = output.Subscribe(bus, "text-to-speech", output.Func(t.ttsSink))
+		go t.speak(ctx)
+	}
+	fmt.Printf("Talk mode: press F10, speak, and press F10 again to translate into %s.\n", translator.LanguageName(cfg.Lang))
+	return t
+}
+
+// Keys delivers F10 presses; nil when talk mode is off
+func (t *talker) Keys() <-chan struct{} {
+	if t == nil {
+		return nil
+	}
+	return t.keys.KeyPressed()
+}
+
+// toggle starts listening, or hands everything said since then to the
+// transcriber
+func (t *talker) toggle() {
+	if t.since.IsZero() {
+		t.since = time.Now()
+		fmt.Println(display.Paint(display.BoldCyan, "[talk] Listening... press F10 again when done"))
+		return
+	}
+	from := t.since
+	t.since = time.Time{}
+	if time.Since(from) > maxEchoCapture {
+		from = time.Now().Add(-maxEchoCapture)
+	}
+	fmt.Println(display.Paint(display.BoldCyan, "[talk] Translating..."))
+	err := t.rec.capture(from, t.listener.OutputDir(), func(path string) {
+		t.listener.SubmitSpeech(path, talkSpeaker)
+	})
+	if err != nil {
+		slog.Error("Talk capture failed", "err", err)
+	}
+}
+
+// handle translates a transcription of the microphone and publishes it, to
+// be shown ready to paste and, with TTS, spoken
+func (t *talker) handle(ctx context.Context, tr audio.Transcription) {
+	text := tr.Text
+	if tr.Language == "" || tr.Language != translator.LanguageCode(t.cfg.Lang) {
+		translated, err := t.tr.TranslateWithContext(ctx, translator.Request{
+			Text: tr.Text, Kind: translator.KindVoice, SourceLang: tr.Language,
+			Private: privateVoice(),
+		})
+		if err != nil {
+			bus.Publish(events.Error{Source: "talk", Err: err})
+			return
+		}
+		text = translated
+	}
+	bus.Publish(events.TranslationDone{Source: "talk", Original: tr.Text, Translated: text, Language: t.cfg.Lang})
+}
+
+// ttsSink queues talk translations for speak
+func (t *talker) ttsSink(e events.Event) {
+	if d, ok := e.(events.TranslationDone); ok && d.Source == "talk" {
+		select {
+		case t.speech <- d.Translated:
+		default:
+			slog.Info("Talk: still speaking, skipped text-to-speech for this line")
+		}
+	}
+}
+
+// speak plays queued translations one after another
+func (t *talker) speak(ctx context.Context) {
+	lang := translator.LanguageCode(t.cfg.Lang)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case text, ok := <-t.speech:
+			if !ok {
+				return
+			}
+			if err := tts.Speak(ctx, text, lang, t.cfg.TTSDevice); err != nil {
+				slog.Error("Text-to-speech failed", "err", err)
+			}
+		}
+	}
+}
+
+// close stops recording the microphone
+func (t *talker) close() {
+	if t == nil {
+		return
+	}
+	t.unsubscribe()
+	close(t.speech)
+	t.rec.stop()
+	t.tr.Close()
+	os.RemoveAll(t.dir)
+}
@@ -0,0 +1,353 @@
+// Package telegram mirrors messages to a Telegram chat or channel through
+// the Bot API and delivers the bot commands typed there, e.g. by a coach
+// following the match from a phone.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenEnv holds the bot token from @BotFather. It is never read from the
+// settings file, so it cannot leak through /api/config.
+const TokenEnv = "CS_TRANSLATE_TELEGRAM_TOKEN"
+
+// DefaultAPI is the Telegram Bot API server
+const DefaultAPI = "https://api.telegram.org"
+
+// DefaultGap paces messages below Telegram's limit of about one per second
+// in a chat; lines arriving meanwhile go out together
+const DefaultGap = time.Second
+
+const (
+	// maxMessage is the longest message Telegram accepts, in characters
+	maxMessage = 4096
+	// maxQueue bounds lines waiting to be sent; older ones are dropped
+	maxQueue = 64
+	// pollTimeout is how long one getUpdates waits for a command
+	pollTimeout = 25 * time.Second
+	// retryDelay is the wait after a failed request
+	retryDelay = 5 * time.Second
+)
+
+// ErrAuth is returned when Telegram does not know the bot token
+var ErrAuth = errors.New("Telegram refused the bot token; check " + TokenEnv)
+
+// Config selects the bot and the chat it mirrors to
+type Config struct {
+	API   string // Bot API server (empty: DefaultAPI)
+	Token string
+	Chat  string        // chat ID, e.g. -1001234567890, or @channelname
+	Gap   time.Duration // at least this long between messages (0: DefaultGap)
+}
+
+// Command is a bot command typed in the chat, e.g. "/lang German" is
+// {Name: "lang", Arg: "German"}
+type Command struct {
+	Name string
+	Arg  string
+}
+
+// Bot sends to and reads commands from one chat. All methods are safe for
+// concurrent use.
+type Bot struct {
+	cfg      Config
+	client   *http.Client
+	name     string // the bot's username, for commands addressed /lang@name
+	commands chan Command
+	wake     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	queue []string
+}
+
+// Start checks the token and starts sending and polling for commands in
+// the background
+func Start(cfg Config) (*Bot, error) {
+	if cfg.API == "" {
+		cfg.API = DefaultAPI
+	}
+	cfg.API = strings.TrimSuffix(cfg.API, "/")
+	if cfg.Gap <= 0 {
+		cfg.Gap = DefaultGap
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bot{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: pollTimeout + 10*time.Second},
+		commands: make(chan Command, 8),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	var me struct {
+		Username string `json:"username"`
+	}
+	if err := b.call(ctx, "getMe", nil, &me); err != nil {
+		cancel()
+		return nil, err
+	}
+	b.name = me.Username
+	b.wg.Add(2)
+	go b.send()
+	go b.poll()
+	return b, nil
+}
+
+// Name is the bot's username
+func (b *Bot) Name() string {
+	return b.name
+}
+
+// Send queues a line for the chat. Lines queued while a message is on its
+// way are sent together in the next one.
+func (b *Bot) Send(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	b.mu.Lock()
+	b.queue = append(b.queue, text)
+	if len(b.queue) > maxQueue {
+		b.queue = b.queue[len(b.queue)-maxQueue:]
+	}
+	b.mu.Unlock()
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Commands delivers the bot commands typed in the chat. It is closed by
+// Close.
+func (b *Bot) Commands() <-chan Command {
+	return b.commands
+}
+
+// Close stops sending and polling; lines still queued are dropped
+func (b *Bot) Close() error {
+	b.cancel()
+	b.wg.Wait()
+	close(b.commands)
+	return nil
+}
+
+// send posts the queue, as few messages as the length limit allows, at
+// most one per Gap
+func (b *Bot) send() {
+	defer b.wg.Done()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-b.wake:
+		}
+		for {
+			text := b.next()
+			if text == "" {
+				break
+			}
+			for {
+				err := b.call(b.ctx, "sendMessage", map[string]any{"chat_id": b.cfg.Chat, "text": text, "disable_web_page_preview": true}, nil)
+				var limited *rateLimited
+				if errors.As(err, &limited) {
+					if !sleep(b.ctx, limited.wait) {
+						return
+					}
+					continue
+				}
+				if err != nil && b.ctx.Err() == nil {
+					slog.Warn("Telegram message not sent", "err", err)
+				}
+				break
+			}
+			if !sleep(b.ctx, b.cfg.Gap) {
+				return
+			}
+		}
+	}
+}
+
+// next takes the lines that fit one message off the queue; "" when it is
+// empty
+func (b *Bot) next() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var lines []string
+	n := 0
+	for len(b.queue) > 0 {
+		line := b.queue[0]
+		if runes := []rune(line); len(runes) > maxMessage {
+			line = string(runes[:maxMessage-1]) + "…"
+		}
+		size := len([]rune(line)) + 1
+		if len(lines) > 0 && n+size > maxMessage {
+			break
+		}
+		lines = append(lines, line)
+		n += size
+		b.queue = b.queue[1:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// poll long-polls for updates and hands the commands typed in the chat to
+// Commands
+func (b *Bot) poll() {
+	defer b.wg.Done()
+	offset := 0
+	for {
+		var updates []update
+		err := b.call(b.ctx, "getUpdates", map[string]any{
+			"offset":          offset,
+			"timeout":         int(pollTimeout / time.Second),
+			"allowed_updates": []string{"message", "channel_post"},
+		}, &updates)
+		if b.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Debug("Telegram updates not fetched", "err", err)
+			if !sleep(b.ctx, retryDelay) {
+				return
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = max(offset, u.UpdateID+1)
+			m := u.Message
+			if m == nil {
+				m = u.ChannelPost
+			}
+			if m == nil || !b.fromChat(m.Chat) {
+				continue
+			}
+			if c, ok := parseCommand(m.Text, b.name); ok {
+				select {
+				case b.commands <- c:
+				case <-b.ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// fromChat reports whether c is the configured chat
+func (b *Bot) fromChat(c chat) bool {
+	if strings.HasPrefix(b.cfg.Chat, "@") {
+		return strings.EqualFold(b.cfg.Chat[1:], c.Username)
+	}
+	return b.cfg.Chat == strconv.FormatInt(c.ID, 10)
+}
+
+// parseCommand reads "/name[@bot] arg"; commands addressed to another bot
+// are not for this one
+func parseCommand(text, bot string) (Command, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return Command{}, false
+	}
+	name, arg, _ := strings.Cut(text[1:], " ")
+	name, to, addressed := strings.Cut(name, "@")
+	if name == "" || addressed && !strings.EqualFold(to, bot) {
+		return Command{}, false
+	}
+	return Command{Name: strings.ToLower(name), Arg: strings.TrimSpace(arg)}, true
+}
+
+type update struct {
+	UpdateID    int      `json:"update_id"`
+	Message     *message `json:"message"`
+	ChannelPost *message `json:"channel_post"`
+}
+
+type message struct {
+	Chat chat   `json:"chat"`
+	Text string `json:"text"`
+}
+
+type chat struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// rateLimited is Telegram asking to wait before the next request
+type rateLimited struct {
+	wait time.Duration
+}
+
+func (e *rateLimited) Error() string {
+	return fmt.Sprintf("Telegram asks to wait %v", e.wait)
+}
+
+// call posts a Bot API request and decodes its result into v
+func (b *Bot) call(ctx context.Context, method string, params, v any) error {
+	if params == nil {
+		params = struct{}{}
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.cfg.API+"/bot"+b.cfg.Token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// The URL holds the token; keep it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+	var answer struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return fmt.Errorf("%s failed: %s", method, resp.Status)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotFound:
+		return ErrAuth
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &rateLimited{wait: time.Duration(max(answer.Parameters.RetryAfter, 1)) * time.Second}
+	case !answer.OK:
+		return fmt.Errorf("%s failed: %s", method, answer.Description)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(answer.Result, v)
+}
+
+// sleep waits for d; false when ctx ended first
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
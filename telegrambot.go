@@ -0,0 +1,209 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/output"
+	"github.com/micha/cs-ingame-translate/telegram"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// telegramCommand pauses and resumes mirroring to Telegram from the terminal
+const telegramCommand = "/telegram"
+
+// telegramHelp answers /help and /start in the chat
+const telegramHelp = `cs-translate mirrors the match's chat and voice here.
+/lang <language> - translate to another language, e.g. /lang German
+/lang - show the target language
+/pause, /resume - pause or resume translating
+/mute, /unmute - stop or restart mirroring to this chat
+/status - show what cs-translate is doing`
+
+// telegramMirror is -telegram: it mirrors translations to the chat; nil when
+// off
+var telegramMirror *telegramSink
+
+// telegramSink mirrors translations to a Telegram chat and turns the bot
+// commands typed there into the same actions the Stream Deck sends
+type telegramSink struct {
+	bot *telegram.Bot
+	on  atomic.Bool
+}
+
+// startTelegram mirrors translations to the chat cfg names and takes its
+// commands, and returns a function that stops both. Without the web server,
+// it sets up deck so the mode loop gets the commands.
+func startTelegram(cfg telegram.Config, mode, lang string) (stop func(), err error) {
+	bot, err := telegram.Start(cfg)
+	if err != nil {
+		return nil, err
+	}
+	created := deck == nil
+	if created {
+		deck = newStreamDeck(mode, lang)
+	}
+	s := &telegramSink{bot: bot}
+	s.on.Store(true)
+	telegramMirror = s
+	closeSink := output.Subscribe(bus, "telegram", output.Func(s.handle))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for c := range bot.Commands() {
+			s.command(c)
+		}
+	}()
+	return func() {
+		closeSink()
+		bot.Close()
+		wg.Wait()
+		telegramMirror = nil
+		if created {
+			deck = nil
+		}
+	}, nil
+}
+
+func (s *telegramSink) handle(e events.Event) {
+	if !s.on.Load() {
+		return
+	}
+	switch e := e.(type) {
+	case events.TranslationDone:
+		if text, ok := telegramText(e); ok {
+			s.bot.Send(text)
+		}
+	case events.MatchStarted:
+		s.bot.Send("Map: " + e.Map)
+	case events.MatchEnded:
+		text := "Match over"
+		if e.Map != "" {
+			text += " on " + e.Map
+		}
+		if e.Score != "" {
+			text += ", " + e.Score
+		}
+		s.bot.Send(text)
+	case events.SummaryDone:
+		// The summary retells team chat and voice
+		if !privateVoice() {
+			s.bot.Send("Summary: " + e.Summary)
+		}
+	}
+}
+
+// telegramText is the chat line for t, e.g. "[CT] bob: rush B"; false for
+// hidden toxic chat, messages not translated and what privacy mode keeps on
+// this machine
+func telegramText(t events.TranslationDone) (string, bool) {
+	if !forwarded(t) || hidden(t) || transcriptPrivate(t) {
+		return "", false
+	}
+	label := "[voice]"
+	if t.Source == "chat" {
+		label = "[" + t.Team + "]"
+	}
+	if t.Player != "" {
+		label += " " + t.Player + ":"
+	}
+	return label + " " + strings.Join(strings.Fields(cmp.Or(t.Translated, t.Original)), " "), true
+}
+
+// command answers one bot command; actions go to the mode loop through deck
+func (s *telegramSink) command(c telegram.Command) {
+	switch c.Name {
+	case "lang":
+		if c.Arg == "" {
+			s.bot.Send(fmt.Sprintf("Translating to %s; /lang <language> switches", translator.LanguageName(deck.currentState().Lang)))
+			return
+		}
+		lang, err := translator.NormalizeLanguage(c.Arg)
+		if err != nil {
+			s.bot.Send(err.Error())
+			return
+		}
+		if !s.request(deckAction{Action: deckSetLang, Lang: lang}) {
+			return
+		}
+		s.bot.Send("Switching to " + translator.LanguageName(lang))
+	case "pause":
+		if s.request(deckAction{Action: deckPause}) {
+			s.bot.Send("Translation paused; /resume resumes it")
+		}
+	case "resume":
+		if s.request(deckAction{Action: deckResume}) {
+			s.bot.Send("Translation resumed")
+		}
+	case "mute", "unmute":
+		on := c.Name == "unmute"
+		s.on.Store(on)
+		if on {
+			bus.Publish(events.Status{Source: "telegram", State: "resumed", Message: "mirroring resumed from Telegram"})
+			s.bot.Send("Mirroring resumed")
+		} else {
+			bus.Publish(events.Status{Source: "telegram", State: "paused", Message: "mirroring paused from Telegram"})
+			s.bot.Send("Mirroring paused; /unmute resumes it")
+		}
+	case "status":
+		s.bot.Send(s.status())
+	default:
+		s.bot.Send(telegramHelp)
+	}
+}
+
+// request hands a to the mode loop and says so in the chat when it is busy
+func (s *telegramSink) request(a deckAction) bool {
+	if deck.request(a) {
+		return true
+	}
+	s.bot.Send("Busy, try again")
+	return false
+}
+
+// status is e.g. "cs2 mode, translating to German, voice on"
+func (s *telegramSink) status() string {
+	state := deck.currentState()
+	parts := []string{state.Mode + " mode", "translating to " + translator.LanguageName(state.Lang)}
+	if state.Voice {
+		parts = append(parts, "voice on")
+	}
+	if state.Paused {
+		parts = append(parts, "paused")
+	}
+	if !s.on.Load() {
+		parts = append(parts, "mirroring muted")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// handleTelegramCommand handles /telegram [on|off]: false for other lines
+func handleTelegramCommand(line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	if cmd != telegramCommand {
+		return false
+	}
+	if telegramMirror == nil {
+		fmt.Println("Telegram mirroring is off; start with -telegram <chat>.")
+		return true
+	}
+	on := !telegramMirror.on.Load()
+	switch strings.TrimSpace(arg) {
+	case "on":
+		on = true
+	case "off":
+		on = false
+	}
+	telegramMirror.on.Store(on)
+	if on {
+		fmt.Println("Mirroring translations to Telegram.")
+	} else {
+		fmt.Println("Telegram mirroring paused; /telegram on resumes it.")
+	}
+	return true
+}
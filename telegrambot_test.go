@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/telegram"
+)
+
+// Translations are mirrored to a mock Telegram chat, and /lang typed there
+// reaches the mode loop as a language switch. A wrong token is refused,
+// commands from other chats are ignored and /mute stops mirroring.
+func TestTelegramBot(t *testing.T) {
+	fake := fakegame.NewTelegram("secret")
+	defer fake.Close()
+	if _, err := startTelegram(telegram.Config{API: fake.URL, Token: "wrong", Chat: "42"}, "cs2", "en"); !errors.Is(err, telegram.ErrAuth) {
+		t.Fatalf("wrong token: got %v, want %v", err, telegram.ErrAuth)
+	}
+	stop, err := startTelegram(telegram.Config{API: fake.URL, Token: "secret", Chat: "42", Gap: 50 * time.Millisecond}, "cs2", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// sent waits for a message containing text and returns all lines sent
+	sent := func(text string) ([]string, error) {
+		deadline := time.Now().Add(testTimeout)
+		for {
+			var lines []string
+			for _, m := range fake.Sent() {
+				if m.Chat != "42" {
+					return nil, fmt.Errorf("sent %q to chat %s, want 42", m.Text, m.Chat)
+				}
+				lines = append(lines, strings.Split(m.Text, "\n")...)
+			}
+			for _, l := range lines {
+				if strings.Contains(l, text) {
+					return lines, nil
+				}
+			}
+			if time.Now().After(deadline) {
+				return lines, fmt.Errorf("Telegram got %q, want a message with %q", lines, text)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	for _, td := range []events.TranslationDone{
+		{Source: "chat", Team: "ALL", Player: "ivan", Original: "привет", Translated: "hi"},
+		{Source: "chat", Team: "CT", Player: "bob", Original: "раш б", Translated: "rush B"},
+		{Source: "chat", Team: "ALL", Player: "olga", Original: "давай", Err: errors.New("backend down")},
+		{Source: "voice", Player: "bob", Original: "один остался", Translated: "one left"},
+	} {
+		bus.Publish(td)
+	}
+	lines, err := sent("one left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"[ALL] ivan: hi", "[CT] bob: rush B", "[voice] bob: one left"}; !slices.Equal(lines, want) {
+		t.Fatalf("mirrored %q, want %q", lines, want)
+	}
+
+	fake.Type(7, "/lang French")
+	fake.Type(42, "/lang Klingon")
+	fake.Type(42, "/lang@cs_translate_bot German")
+	select {
+	case a := <-deck.Actions():
+		if a.Action != deckSetLang || a.Lang != "de" {
+			t.Fatalf("mode loop got %+v, want set_lang to de", a)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("/lang German did not reach the mode loop")
+	}
+	if _, err := sent("Switching to German"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sent(`unknown language "Klingon"`); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Type(42, "/mute")
+	if _, err := sent("Mirroring paused"); err != nil {
+		t.Fatal(err)
+	}
+	bus.Publish(events.TranslationDone{Source: "chat", Team: "ALL", Player: "ivan", Original: "пока", Translated: "bye"})
+	fake.Type(42, "/status")
+	if lines, err = sent("mirroring muted"); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(lines, "[ALL] ivan: bye") {
+		t.Fatal("mirrored a translation after /mute")
+	}
+	select {
+	case a := <-deck.Actions():
+		t.Fatalf("mode loop got %+v from another chat or a bad command", a)
+	default:
+	}
+}
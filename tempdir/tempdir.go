@@ -0,0 +1,80 @@
+// Package tempdir makes the temporary folders audio is recorded into and
+// removes the ones a crashed or killed run left behind. Each folder names
+// the process that owns it, so a folder of another cs-translate still
+// running is never taken.
+package tempdir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ownerFile holds the PID of the process a folder belongs to
+const ownerFile = ".cs-translate-owner"
+
+// New creates a folder in dir like os.MkdirTemp ("" is the system temp
+// folder) and marks it as this process's
+func New(dir, pattern string) (string, error) {
+	path, err := os.MkdirTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(path, ownerFile), []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		os.RemoveAll(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// RemoveOrphans deletes the folders in dir ("" is the system temp folder)
+// that New made for a process no longer running, and returns them. Folders
+// without an owner are left alone.
+func RemoveOrphans(dir string) []string {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var removed []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(filepath.Join(path, ownerFile))
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid == os.Getpid() || running(pid) {
+			continue
+		}
+		if os.RemoveAll(path) == nil {
+			removed = append(removed, path)
+		}
+	}
+	return removed
+}
+
+// running reports whether a process with the PID exists. A PID taken over
+// by another program counts as running, which only keeps a folder longer.
+func running(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	defer p.Release()
+	// Windows opens the process to find it, which fails once it is gone
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,37 @@
+package tempdir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Recording folders of a run that is gone are removed at the next start;
+// those of this run and folders without an owner are kept
+func TestRemoveOrphans(t *testing.T) {
+	dir := t.TempDir()
+	own, err := New(dir, "cs-echo-rec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crashed, err := New(dir, "cs-voice-rec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A PID above any system's limit belongs to no running process
+	if err := os.WriteFile(filepath.Join(crashed, ".cs-translate-owner"), []byte("2147483000"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unowned := filepath.Join(dir, "cs-translate-docker")
+	if err := os.Mkdir(unowned, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if removed := RemoveOrphans(dir); len(removed) != 1 || removed[0] != crashed {
+		t.Fatalf("removed %q, want only %s", removed, crashed)
+	}
+	for _, kept := range []string{own, unowned} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Fatalf("%s was removed: %v", kept, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/micha/cs-ingame-translate/display"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+var (
+	// classifier is -tone-model: it tags translated chat with its tone; nil
+	// when off
+	classifier *translator.Classifier
+	// hideToxic is -hide-toxic: chat tagged as toxic is not printed
+	hideToxic bool
+)
+
+// classify tags the translation of one part of a chat message and keeps the
+// most hostile tone of the message in cj
+func classify(ctx context.Context, cj *chatJob, translated string) {
+	if classifier == nil {
+		return
+	}
+	tone, err := classifier.Classify(ctx, translated, privateChat(cj.msg))
+	if err != nil {
+		if !errors.Is(err, translator.ErrNoLocalBackend) {
+			slog.Debug("Chat not tagged", "err", err)
+		}
+		return
+	}
+	cj.tone = cj.tone.Worse(tone)
+}
+
+// hidden reports whether t is toxic chat that -hide-toxic keeps off the
+// console
+func hidden(t events.TranslationDone) bool {
+	return hideToxic && t.Tone == string(translator.ToneToxic)
+}
+
+// toneSummary counts the tagged chat of a match and prints how toxic it was
+// when the match ends, the next map loads or on exit
+type toneSummary struct {
+	mu    sync.Mutex
+	tones map[string]int // messages per tone
+	toxic map[string]int // toxic messages per player
+}
+
+// startToneSummary follows the tagged chat and returns a function that
+// stops and prints the summary of the match so far
+func startToneSummary() (stop func()) {
+	s := &toneSummary{}
+	unsubscribe := bus.Subscribe(s.handle)
+	return func() {
+		unsubscribe()
+		s.flush()
+	}
+}
+
+func (s *toneSummary) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.TranslationDone:
+		if e.Tone == "" || e.Superseded {
+			return
+		}
+		s.mu.Lock()
+		if s.tones == nil {
+			s.tones, s.toxic = map[string]int{}, map[string]int{}
+		}
+		s.tones[e.Tone]++
+		if e.Tone == string(translator.ToneToxic) {
+			s.toxic[e.Player]++
+		}
+		s.mu.Unlock()
+	case events.MatchStarted, events.MatchEnded:
+		s.flush()
+	}
+}
+
+// flush prints the summary, if any chat was tagged, and starts over
+func (s *toneSummary) flush() {
+	s.mu.Lock()
+	tones, toxic := s.tones, s.toxic
+	s.tones, s.toxic = nil, nil
+	s.mu.Unlock()
+	if line := summarizeTones(tones, toxic); line != "" {
+		fmt.Println(display.Paint(display.Yellow, line))
+	}
+}
+
+// summarizeTones describes a match's chat, e.g. "Chat this match: 3
+// friendly, 20 neutral, 4 toxic (ivan 3, bob 1)"; "" when nothing was tagged
+func summarizeTones(tones, toxic map[string]int) string {
+	if len(tones) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, t := range []translator.Tone{translator.ToneFriendly, translator.ToneNeutral, translator.ToneToxic} {
+		parts = append(parts, fmt.Sprintf("%d %s", tones[string(t)], t))
+	}
+	line := "Chat this match: " + strings.Join(parts, ", ")
+	if len(toxic) == 0 {
+		return line
+	}
+	players := slices.SortedFunc(maps.Keys(toxic), func(a, b string) int {
+		return cmp.Or(toxic[b]-toxic[a], strings.Compare(a, b))
+	})
+	for i, p := range players {
+		players[i] = fmt.Sprintf("%s %d", p, toxic[p])
+	}
+	return line + " (" + strings.Join(players, ", ") + ")"
+}
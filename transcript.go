@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// transcriptDir is the folder of the settings directory that match
+// transcripts and summaries are written to
+const transcriptDir = "transcripts"
+
+// transcriptEntry is one message in the .jsonl record kept next to each
+// transcript, for tools such as transcript export
+type transcriptEntry struct {
+	Time       time.Time `json:"time"` // when it was said or written
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Source     string    `json:"source"` // "chat", "voice" or "talk"
+	Channel    string    `json:"channel"`
+	Player     string    `json:"player,omitempty"`
+	Original   string    `json:"original"`
+	Translated string    `json:"translated,omitempty"`
+}
+
+// transcript writes the chat and voice of each match to a file of its own
+// and, with a summarizer, has the model summarize the match when it ends
+type transcript struct {
+	dir        string
+	summarizer *translator.Summarizer // nil without summaries
+
+	mu          sync.Mutex
+	file        *os.File // the current match; nil until its first line
+	record      *json.Encoder
+	closeRecord func() error
+	path        string
+	mapName     string
+	lines       []string
+	private     bool // lines include messages privacy mode keeps on this machine
+	wg          sync.WaitGroup
+}
+
+// startTranscript records matches in dir, or in the transcripts folder of
+// the settings directory when dir is empty, and returns a function that
+// ends the current match and waits for its summary
+func startTranscript(dir string, summarizer *translator.Summarizer) (stop func()) {
+	if dir == "" {
+		settings, err := config.Dir()
+		if err != nil {
+			slog.Warn("Not writing transcripts", "err", err)
+			return func() {}
+		}
+		dir = filepath.Join(settings, transcriptDir)
+	}
+	t := &transcript{dir: dir, summarizer: summarizer}
+	unsubscribe := bus.Subscribe(t.handle)
+	return func() {
+		unsubscribe()
+		t.end("")
+		t.wg.Wait()
+	}
+}
+
+func (t *transcript) handle(e events.Event) {
+	switch e := e.(type) {
+	case events.MatchStarted:
+		t.end("")
+		t.mu.Lock()
+		t.mapName = e.Map
+		t.mu.Unlock()
+	case events.MatchEnded:
+		t.end(fmt.Sprintf("%s Game over on %s, %s", time.Now().Format(time.TimeOnly), e.Map, e.Score))
+	case events.TranslationDone:
+		if entry, ok := newTranscriptEntry(e, time.Now()); ok {
+			t.write(entry, transcriptPrivate(e))
+		}
+	}
+}
+
+// write adds a message to the current match, starting its files if needed
+func (t *transcript) write(entry transcriptEntry, private bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		if err := t.open(); err != nil {
+			slog.Warn("Transcript not written", "err", err)
+			return
+		}
+	}
+	line := entry.line()
+	if _, err := fmt.Fprintln(t.file, line); err != nil {
+		slog.Warn("Transcript line not written", "path", t.path, "err", err)
+	}
+	if err := t.record.Encode(entry); err != nil {
+		slog.Warn("Transcript record not written", "path", t.path, "err", err)
+	}
+	t.lines = append(t.lines, line)
+	t.private = t.private || private
+}
+
+// open creates the files of the current match, named after its start and
+// map: the transcript and its .jsonl record
+func (t *transcript) open() error {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	name := time.Now().Format("2006-01-02_150405")
+	if t.mapName != "" {
+		name += "_" + strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`/\:*?"<>|`, r) {
+				return '_'
+			}
+			return r
+		}, t.mapName)
+	}
+	f, err := os.Create(filepath.Join(t.dir, name+".txt"))
+	if err != nil {
+		return err
+	}
+	record, err := os.Create(filepath.Join(t.dir, name+".jsonl"))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	t.file, t.path = f, f.Name()
+	t.record, t.closeRecord = json.NewEncoder(record), record.Close
+	return nil
+}
+
+// end closes the current match, after writing last if set, and has it
+// summarized in the background
+func (t *transcript) end(last string) {
+	t.mu.Lock()
+	if t.file == nil {
+		t.mu.Unlock()
+		return
+	}
+	if last != "" {
+		fmt.Fprintln(t.file, last)
+		t.lines = append(t.lines, last)
+	}
+	t.file.Close()
+	t.closeRecord()
+	path, lines, private := t.path, t.lines, t.private
+	t.file, t.record, t.closeRecord = nil, nil, nil
+	t.path, t.mapName, t.lines, t.private = "", "", nil, false
+	t.mu.Unlock()
+
+	if t.summarizer == nil {
+		return
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.summarize(path, strings.Join(lines, "\n"), private)
+	}()
+}
+
+// summarize writes the summary of a match next to its transcript and
+// publishes it
+func (t *transcript) summarize(path, text string, private bool) {
+	slog.Info("Summarizing the match", "transcript", path)
+	summary, err := t.summarizer.Summarize(context.Background(), text, targetLang, private)
+	if errors.Is(err, translator.ErrNoLocalBackend) {
+		slog.Warn("Match not summarized: privacy mode keeps team chat and voice on this machine, and Ollama is not local", "transcript", path)
+		return
+	}
+	if err != nil {
+		slog.Warn("Match not summarized", "transcript", path, "err", err)
+		return
+	}
+	out := strings.TrimSuffix(path, ".txt") + ".summary.txt"
+	if err := os.WriteFile(out, []byte(summary+"\n"), 0o644); err != nil {
+		slog.Warn("Match summary not saved", "err", err)
+		out = ""
+	}
+	bus.Publish(events.SummaryDone{Summary: summary, Path: out})
+}
+
+// newTranscriptEntry records a translation finished at now; false for what
+// is not worth keeping
+func newTranscriptEntry(t events.TranslationDone, now time.Time) (transcriptEntry, bool) {
+	if t.Err != nil || t.Superseded || t.Via == viaRepeated {
+		return transcriptEntry{}, false
+	}
+	e := transcriptEntry{
+		Time: now, DurationMS: t.Duration.Milliseconds(), Source: t.Source,
+		Channel: t.Team, Player: t.Player, Original: t.Original,
+	}
+	if !t.Spoken.IsZero() {
+		e.Time = t.Spoken
+	}
+	switch t.Source {
+	case "voice":
+		e.Channel = "voice"
+		if t.Capture != "" {
+			e.Channel += ":" + t.Capture
+		}
+	case "talk":
+		e.Channel, e.Player = "talk", "you"
+	}
+	if t.Translated != t.Original {
+		e.Translated = t.Translated
+	}
+	return e, true
+}
+
+// line formats e for the transcript, e.g. "21:03:04 [ALL] ivan: привет → hi"
+func (e transcriptEntry) line() string {
+	text := e.Original
+	if e.Translated != "" {
+		text += " → " + e.Translated
+	}
+	label := "[" + e.Channel + "]"
+	if e.Player != "" {
+		label += " " + e.Player
+	}
+	line := fmt.Sprintf("%s %s: %s", e.Time.Format(time.TimeOnly), label, text)
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// transcriptPrivate reports whether privacy mode keeps t on this machine
+func transcriptPrivate(t events.TranslationDone) bool {
+	if t.Source == "chat" {
+		return privateChat(events.ChatReceived{Player: t.Player, Team: t.Team})
+	}
+	return privateVoice()
+}
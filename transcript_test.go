@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/subtitle"
+)
+
+// Voice recorded in a transcript is exported as SRT and WebVTT, timed from
+// the recording start by when it was spoken
+func TestSubtitles(t *testing.T) {
+	dir := t.TempDir()
+	transcripts := filepath.Join(dir, "transcripts")
+	stop := startTranscript(transcripts, nil)
+	start := time.Date(2026, 2, 2, 21, 0, 0, 0, time.Local)
+	for _, td := range []events.TranslationDone{
+		{Source: "voice", Player: "ivan", Original: "давай на б", Translated: "let's go B", Spoken: start.Add(2 * time.Second), Duration: 1500 * time.Millisecond},
+		{Source: "chat", Team: "ALL", Player: "bob", Original: "gg", Translated: "gg"},
+		{Source: "voice", Original: "one left <low>", Translated: "one left <low>", Spoken: start.Add(3 * time.Second), Duration: 5 * time.Second},
+	} {
+		bus.Publish(td)
+	}
+	stop()
+
+	paths, _ := filepath.Glob(filepath.Join(transcripts, "*.txt"))
+	if len(paths) != 1 {
+		t.Fatalf("found transcripts %v, want one", paths)
+	}
+	entries, err := readTranscript(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries = slices.DeleteFunc(entries, func(e transcriptEntry) bool { return e.Source == "chat" })
+	from, err := parseRecordingStart("21:00:01", entries[0].Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cues := transcriptCues(entries, from, "both")
+	for format, want := range map[string]string{
+		subtitle.FormatSRT: "1\n00:00:01,000 --> 00:00:02,000\nivan: давай на б\nlet's go B\n\n2\n00:00:02,000 --> 00:00:07,000\none left <low>\n\n",
+		subtitle.FormatVTT: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nivan: давай на б\nlet's go B\n\n00:00:02.000 --> 00:00:07.000\none left &lt;low&gt;\n\n",
+	} {
+		var sb strings.Builder
+		if err := subtitle.Write(&sb, format, cues); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != want {
+			t.Fatalf("%s export is %q, want %q", format, sb.String(), want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/subtitle"
+)
+
+// Subtitle lengths of messages whose speech length is not known
+const (
+	voiceCueLength = 3 * time.Second
+	chatCueLength  = 4 * time.Second
+	minCueLength   = time.Second
+)
+
+func runTranscriptCommand(args []string) {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Println("Usage: cs-translate transcript export [-format srt|vtt] [-o file] [transcript]")
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("transcript export", flag.ExitOnError)
+	format := fs.String("format", subtitle.FormatSRT, "Subtitle format: srt or vtt")
+	out := fs.String("o", "", "File to write, - for stdout (default: next to the transcript)")
+	text := fs.String("text", "both", "What a subtitle shows: translated, original, or both")
+	start := fs.String("start", "", "Wall-clock time the recording started, e.g. 21:02:30 or 2026-02-02T21:02:30+01:00 (default: the first message)")
+	chat := fs.Bool("chat", false, "Also subtitle chat, not only voice")
+	fs.Parse(args[1:])
+	if *format != subtitle.FormatSRT && *format != subtitle.FormatVTT {
+		fmt.Printf("Error: unknown -format %q (use srt or vtt)\n", *format)
+		os.Exit(2)
+	}
+	if !slices.Contains([]string{"translated", "original", "both"}, *text) {
+		fmt.Printf("Error: unknown -text %q (use translated, original or both)\n", *text)
+		os.Exit(2)
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		var err error
+		if path, err = latestTranscript(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	entries, err := readTranscript(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !*chat {
+		entries = slices.DeleteFunc(entries, func(e transcriptEntry) bool { return e.Source == "chat" })
+	}
+	if len(entries) == 0 {
+		fmt.Printf("Error: %s has nothing to subtitle (-chat includes chat)\n", path)
+		os.Exit(1)
+	}
+	var from time.Time
+	if *start != "" {
+		if from, err = parseRecordingStart(*start, entries[0].Time); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	cues := transcriptCues(entries, from, *text)
+
+	dest := *out
+	if dest == "" {
+		dest = strings.TrimSuffix(strings.TrimSuffix(path, ".jsonl"), ".txt") + "." + *format
+	}
+	var w io.Writer = os.Stdout
+	if dest != "-" {
+		f, err := os.Create(dest)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := subtitle.Write(w, *format, cues); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if dest != "-" {
+		fmt.Printf("Wrote %d subtitles to %s\n", len(cues), dest)
+	}
+}
+
+// latestTranscript returns the record of the newest transcript in the
+// settings directory
+func latestTranscript() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	// Names start with the time the match started, so they sort by it
+	paths, _ := filepath.Glob(filepath.Join(dir, transcriptDir, "*.jsonl"))
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no transcripts in %s; record some with -transcript", filepath.Join(dir, transcriptDir))
+	}
+	slices.Sort(paths)
+	return paths[len(paths)-1], nil
+}
+
+// readTranscript reads the .jsonl record of a transcript, given the record
+// or the transcript itself
+func readTranscript(path string) ([]transcriptEntry, error) {
+	path = strings.TrimSuffix(path, ".txt")
+	if !strings.HasSuffix(path, ".jsonl") {
+		path += ".jsonl"
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []transcriptEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e transcriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(entries, func(a, b transcriptEntry) int { return a.Time.Compare(b.Time) })
+	return entries, nil
+}
+
+// parseRecordingStart reads -start: an RFC 3339 time, or a clock time on
+// the day of first in its time zone
+func parseRecordingStart(v string, first time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	clock, err := time.Parse(time.TimeOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-start %q is neither a time such as 21:02:30 nor RFC 3339", v)
+	}
+	first = first.Local()
+	return time.Date(first.Year(), first.Month(), first.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, first.Location()), nil
+}
+
+// transcriptCues times entries from the recording start, or from the first
+// entry when from is zero. A subtitle lasts as long as its speech, and ends
+// when the next begins so they do not stack up; messages said before from
+// are left out.
+func transcriptCues(entries []transcriptEntry, from time.Time, show string) []subtitle.Cue {
+	if from.IsZero() {
+		from = entries[0].Time
+	}
+	var cues []subtitle.Cue
+	for _, e := range entries {
+		start := e.Time.Sub(from)
+		if start < 0 {
+			continue
+		}
+		length := time.Duration(e.DurationMS) * time.Millisecond
+		if length == 0 {
+			length = voiceCueLength
+			if e.Source == "chat" {
+				length = chatCueLength
+			}
+		}
+		cues = append(cues, subtitle.Cue{Start: start, End: start + max(length, minCueLength), Text: cueText(e, show)})
+	}
+	for i := range len(cues) - 1 {
+		if next := cues[i+1].Start; cues[i].End > next && next > cues[i].Start {
+			cues[i].End = next
+		}
+	}
+	return cues
+}
+
+// cueText is what the subtitle of e shows, the speaker first when known
+func cueText(e transcriptEntry, show string) string {
+	var text string
+	switch show {
+	case "original":
+		text = e.Original
+	case "translated":
+		text = cmp.Or(e.Translated, e.Original)
+	default:
+		text = e.Original
+		if e.Translated != "" {
+			text += "\n" + e.Translated
+		}
+	}
+	if e.Player != "" {
+		text = e.Player + ": " + text
+	}
+	return text
+}
@@ -0,0 +1,65 @@
+package translator
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// emoticonRe matches Western emoticons such as :D, ;-), xD or <3, which
+// would otherwise count as words
+var emoticonRe = regexp.MustCompile(`^(?:[:;=8][-'^o]?[DPpOoSs03()\[\]{}|/\\*$@]+|[xX][DdPp]+|<3+|\\o/|o/|\\o)$`)
+
+// wordScripts are the scripts a run of letters must stay in to be a word;
+// letters of several scripts next to each other, as in (ノಠ益ಠ)ノ, are
+// kaomoji
+var wordScripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Han, unicode.Hiragana,
+	unicode.Katakana, unicode.Hangul, unicode.Arabic, unicode.Hebrew, unicode.Thai,
+	unicode.Devanagari, unicode.Georgian, unicode.Armenian,
+}
+
+// IsEmojiOrArt reports whether text is only emoji, emoticons, kaomoji, ASCII
+// art, numbers or punctuation, which a translation would return unchanged.
+// A space-separated token is text when it holds two letters of one script in
+// a row, or more letters than other characters and no brackets or symbols.
+func IsEmojiOrArt(text string) bool {
+	tokens := strings.Fields(text)
+	for _, tok := range tokens {
+		if !emoticonRe.MatchString(tok) && isWordToken(tok) {
+			return false
+		}
+	}
+	return len(tokens) > 0
+}
+
+func isWordToken(tok string) bool {
+	letters, others := 0, 0
+	decorated := false
+	prev := -1 // script of the previous rune when it was a letter
+	for _, r := range tok {
+		if !unicode.IsLetter(r) {
+			others++
+			decorated = decorated || unicode.IsSymbol(r) || strings.ContainsRune("()[]{}<>", r)
+			prev = -1
+			continue
+		}
+		letters++
+		s := scriptOf(r)
+		if s >= 0 && s == prev {
+			return true
+		}
+		prev = s
+	}
+	return letters > 0 && letters >= others && !decorated
+}
+
+// scriptOf returns the index of r's script in wordScripts, or -1
+func scriptOf(r rune) int {
+	for i, t := range wordScripts {
+		if unicode.Is(t, r) {
+			return i
+		}
+	}
+	return -1
+}
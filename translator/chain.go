@@ -0,0 +1,294 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend types for NewBackend
+const (
+	BackendOllama         = "ollama"
+	BackendLibreTranslate = "libretranslate"
+	BackendPassthrough    = "passthrough"
+)
+
+// BackendConfig describes one link of a Chain
+type BackendConfig struct {
+	Type       string        // BackendOllama, BackendLibreTranslate or BackendPassthrough
+	URL        string        // LibreTranslate server (empty: DefaultLibreTranslateURL)
+	APIKey     string        // LibreTranslate API key
+	Model      string        // Ollama model
+	Prompt     string        // Ollama chat prompt (empty: DefaultPrompt)
+	Structured bool          // Ollama answers in JSON with language and confidence, see OllamaTranslator.SetStructured
+	Timeout    time.Duration // try the next backend after this long (0: no limit of its own)
+	Local      bool          // runs on a machine the user trusts although its address is not loopback
+}
+
+// local reports whether the backend keeps messages on this machine or one
+// the user marked as trusted
+func (cfg BackendConfig) local() bool {
+	switch {
+	case cfg.Local, cfg.Type == BackendPassthrough:
+		return true
+	case cfg.Type == BackendOllama:
+		return isLoopback(OllamaHost)
+	case cfg.Type == BackendLibreTranslate:
+		addr := cfg.URL
+		if addr == "" {
+			addr = DefaultLibreTranslateURL
+		}
+		return isLoopback(addr)
+	}
+	return false
+}
+
+// isLoopback reports whether addr, a URL or host:port, names this machine
+func isLoopback(addr string) bool {
+	host := addr
+	if u, err := url.Parse(addr); err == nil && u.Host != "" {
+		host = u.Hostname()
+	} else if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (cfg BackendConfig) describe() string {
+	switch cfg.Type {
+	case BackendOllama:
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOllamaModel
+		}
+		return fmt.Sprintf("Ollama model '%s'", model)
+	case BackendLibreTranslate:
+		url := cfg.URL
+		if url == "" {
+			url = DefaultLibreTranslateURL
+		}
+		return "LibreTranslate at " + url
+	}
+	return cfg.Type
+}
+
+// NewBackend creates the translator for one backend
+func NewBackend(ctx context.Context, cfg BackendConfig, targetLang string) (Translator, error) {
+	switch cfg.Type {
+	case BackendOllama:
+		t, err := NewOllamaTranslator(ctx, cfg.Model, targetLang)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Prompt != "" {
+			t.SetPrompt(cfg.Prompt)
+		}
+		t.SetStructured(cfg.Structured)
+		return t, nil
+	case BackendLibreTranslate:
+		return NewLibreTranslator(cfg.URL, cfg.APIKey, targetLang), nil
+	case BackendPassthrough:
+		return &Passthrough{targetLang: targetLang}, nil
+	}
+	return nil, fmt.Errorf("%w %q (use %s, %s or %s)", ErrUnknownBackend, cfg.Type, BackendOllama, BackendLibreTranslate, BackendPassthrough)
+}
+
+// Chain tries its backends in order until one translates a message. A
+// backend that errors or runs out of its timeout hands the message to the
+// next, so the sum of the timeouts bounds how long a message can take.
+type Chain struct {
+	links []*chainLink
+}
+
+type chainLink struct {
+	name    string
+	tr      Translator
+	timeout time.Duration
+	local   bool // may receive private requests
+
+	mu      sync.Mutex
+	failing bool // logged as failing; logged again once it recovers
+}
+
+// NewChain builds a chain from cfgs. The first backend's target language is
+// the chain's.
+func NewChain(ctx context.Context, cfgs []BackendConfig, targetLang string) (*Chain, error) {
+	if len(cfgs) == 0 {
+		return nil, fmt.Errorf("no translation backends configured")
+	}
+	c := &Chain{}
+	for _, cfg := range cfgs {
+		tr, err := NewBackend(ctx, cfg, targetLang)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.links = append(c.links, &chainLink{name: cfg.describe(), tr: tr, timeout: cfg.Timeout, local: cfg.local()})
+	}
+	return c, nil
+}
+
+var _ Translator = (*Chain)(nil)
+
+// String lists the backends in the order they are tried
+func (c *Chain) String() string {
+	names := make([]string, len(c.links))
+	for i, l := range c.links {
+		names[i] = l.name
+	}
+	return strings.Join(names, " → ")
+}
+
+// HasLocal reports whether any backend may receive private requests
+func (c *Chain) HasLocal() bool {
+	for _, l := range c.links {
+		if l.local {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Chain) Translate(ctx context.Context, text string) (string, error) {
+	return c.run(ctx, false, func(ctx context.Context, tr Translator) (string, error) {
+		return tr.Translate(ctx, text)
+	})
+}
+
+func (c *Chain) TranslateWithContext(ctx context.Context, req Request) (string, error) {
+	return c.run(ctx, req.Private, func(ctx context.Context, tr Translator) (string, error) {
+		return tr.TranslateWithContext(ctx, req)
+	})
+}
+
+// run calls f with each backend until one succeeds and returns the last
+// error if none does. It stops early when ctx itself is done. Private
+// requests only go to local backends.
+func (c *Chain) run(ctx context.Context, private bool, f func(context.Context, Translator) (string, error)) (string, error) {
+	links := c.links
+	if private {
+		links = nil
+		for _, l := range c.links {
+			if l.local {
+				links = append(links, l)
+			}
+		}
+		if len(links) == 0 {
+			return "", ErrNoLocalBackend
+		}
+	}
+	var err error
+	for i, l := range links {
+		var out string
+		out, err = l.call(ctx, f)
+		if err == nil {
+			return out, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if i < len(links)-1 {
+			l.fail(links[i+1].name, err)
+		}
+	}
+	return "", err
+}
+
+func (l *chainLink) call(ctx context.Context, f func(context.Context, Translator) (string, error)) (string, error) {
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	out, err := f(ctx, l.tr)
+	if err == nil {
+		l.mu.Lock()
+		if l.failing {
+			l.failing = false
+			slog.Info("Translation backend is answering again", "backend", l.name)
+		}
+		l.mu.Unlock()
+	}
+	return out, err
+}
+
+// fail logs that messages go to next, once until l recovers
+func (l *chainLink) fail(next string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.failing {
+		l.failing = true
+		slog.Warn("Translation backend failed, falling back", "backend", l.name, "next", next, "err", err)
+	}
+}
+
+func (c *Chain) TargetLang() string {
+	return c.links[0].tr.TargetLang()
+}
+
+func (c *Chain) SetTargetLang(lang string) {
+	for _, l := range c.links {
+		l.tr.SetTargetLang(lang)
+	}
+}
+
+// Unload unloads every backend that holds a model and returns the first
+// error
+func (c *Chain) Unload() error {
+	var first error
+	for _, l := range c.links {
+		if err := Unload(l.tr); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close closes every backend and returns the first error
+func (c *Chain) Close() error {
+	var first error
+	for _, l := range c.links {
+		if err := l.tr.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Passthrough is the Translator of last resort: it returns every message
+// unchanged, so the original is at least shown
+type Passthrough struct {
+	mu         sync.RWMutex
+	targetLang string
+}
+
+func (p *Passthrough) Translate(ctx context.Context, text string) (string, error) {
+	return text, nil
+}
+
+func (p *Passthrough) TranslateWithContext(ctx context.Context, req Request) (string, error) {
+	return req.Text, nil
+}
+
+func (p *Passthrough) TargetLang() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.targetLang
+}
+
+func (p *Passthrough) SetTargetLang(lang string) {
+	p.mu.Lock()
+	p.targetLang = lang
+	p.mu.Unlock()
+}
+
+func (p *Passthrough) Close() error { return nil }
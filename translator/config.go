@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 )
 
@@ -11,9 +12,17 @@ const (
 	DefaultOllamaPort    = 11434
 	DefaultOllamaBaseURL = "http://localhost"
 	DefaultOllamaModel   = "hf.co/blackcloud1199/qwen-translation-vi"
-	DefaultWhisperModel  = "turbo"
+
+	// DefaultWhisperModel is used for F9 captures, where accuracy matters
+	// more than latency
+	DefaultWhisperModel = "turbo"
+	// DefaultLiveWhisperModel keeps up with the 2s segments of live capture
+	DefaultLiveWhisperModel = "base"
 )
 
+// ContainerAudioDir is where HostAudioDir is mounted inside the container
+const ContainerAudioDir = "/audio"
+
 var OllamaHost string
 
 func init() {
@@ -39,6 +48,20 @@ func GetOllamaPort() int {
 	return DefaultOllamaPort
 }
 
+// HostAudioDir returns the host directory bind-mounted into the Docker
+// container, so audio segments can be shared without copying
+func HostAudioDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user cache directory: %v", err)
+	}
+	dir := filepath.Join(cacheDir, "cs-translate", "audio")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create audio directory: %v", err)
+	}
+	return dir, nil
+}
+
 func FindAvailablePort(startPort int) (int, error) {
 	for port := startPort; port <= 65535; port++ {
 		ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
@@ -0,0 +1,124 @@
+package translator
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultContextPrompt is the voice prompt when there is recent speech.
+// {when} says where the context comes from, {context} is the speech, one
+// transcription per line, and {lang} and {text} are as in DefaultPrompt.
+const DefaultContextPrompt = `Context from recent speech ({when}):
+{context}
+
+Translate the following text to {lang}. Use the context above to understand the conversation topic and provide a more accurate translation. Output ONLY the translation, nothing else:
+
+{text}`
+
+// ContextSettings bounds the recent speech voice prompts carry
+type ContextSettings struct {
+	MaxEntries int    // most recent transcriptions kept, 0 for no limit
+	MaxTokens  int    // estimated tokens they may take, 0 for no limit
+	Prompt     string // see DefaultContextPrompt; empty is the default
+}
+
+// DefaultContextSettings keeps the last 8 transcriptions within 256 tokens,
+// which leaves a small model room for the message itself
+func DefaultContextSettings() ContextSettings {
+	return ContextSettings{MaxEntries: 8, MaxTokens: 256, Prompt: DefaultContextPrompt}
+}
+
+var (
+	contextMu       sync.RWMutex
+	contextSettings = DefaultContextSettings()
+)
+
+// ConfigureContext replaces the context settings of every translator
+func ConfigureContext(s ContextSettings) {
+	if s.Prompt == "" {
+		s.Prompt = DefaultContextPrompt
+	}
+	contextMu.Lock()
+	contextSettings = s
+	contextMu.Unlock()
+}
+
+// currentContext returns the context settings in use
+func currentContext() ContextSettings {
+	contextMu.RLock()
+	defer contextMu.RUnlock()
+	return contextSettings
+}
+
+// buildContextPrompt fills the context prompt; it is "" when nothing of
+// the context fits, and the plain prompt should be used
+func buildContextPrompt(c VoiceContext, lang, text string) string {
+	s := currentContext()
+	recent := s.trim(c.ContextText)
+	if recent == "" {
+		return ""
+	}
+	return strings.NewReplacer("{when}", c.describe(), "{context}", recent, "{lang}", lang, "{text}", text).Replace(s.Prompt)
+}
+
+// trim keeps the most recent lines of context within MaxEntries and
+// MaxTokens, dropping the oldest first. A newest line over the token budget
+// on its own keeps its last words, which lead into the message.
+func (s ContextSettings) trim(context string) string {
+	var kept []string
+	tokens := 0
+	lines := strings.Split(context, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if s.MaxEntries > 0 && len(kept) == s.MaxEntries {
+			break
+		}
+		cost := estimateTokens(line)
+		if s.MaxTokens > 0 && tokens+cost > s.MaxTokens {
+			if len(kept) == 0 {
+				kept = append(kept, lastWords(line, s.MaxTokens))
+			}
+			break
+		}
+		tokens += cost
+		kept = append(kept, line)
+	}
+	slices.Reverse(kept)
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// lastWords returns the end of line that fits in budget tokens
+func lastWords(line string, budget int) string {
+	words := strings.Fields(line)
+	start := len(words)
+	for tokens := 0; start > 0; start-- {
+		tokens += estimateTokens(words[start-1])
+		if tokens > budget {
+			break
+		}
+	}
+	return strings.Join(words[start:], " ")
+}
+
+// estimateTokens guesses what text costs a model without its tokenizer:
+// about four characters a token in Latin script, two in Cyrillic and other
+// scripts, and at least one a word
+func estimateTokens(text string) int {
+	n := 0
+	for _, word := range strings.Fields(text) {
+		ascii := 0
+		for i := 0; i < len(word); i++ {
+			if word[i] < utf8.RuneSelf {
+				ascii++
+			}
+		}
+		other := utf8.RuneCountInString(word) - ascii
+		n += max(1, (ascii+3)/4+(other+1)/2)
+	}
+	return n
+}
@@ -0,0 +1,82 @@
+package translator
+
+import (
+	"strings"
+	"unicode"
+)
+
+// letterHints are letters that, among the languages in languageCodes, only
+// one language uses
+var letterHints = map[rune]string{
+	'ß': "de",
+	'ą': "pl", 'ę': "pl", 'ł': "pl", 'ś': "pl", 'ź': "pl", 'ż': "pl", 'ń': "pl",
+	'ğ': "tr", 'ı': "tr", 'ş': "tr",
+	'ñ': "es", '¿': "es", '¡': "es",
+	'ã': "pt", 'õ': "pt",
+	'ř': "cs", 'ů': "cs", 'ě': "cs",
+	'ő': "hu", 'ű': "hu",
+	'ț': "ro", 'ș': "ro",
+	'đ': "vi", 'ơ': "vi", 'ư': "vi",
+	'і': "uk", 'ї': "uk", 'є': "uk", 'ґ': "uk",
+	'ы': "ru", 'э': "ru", 'ъ': "ru", 'ё': "ru",
+}
+
+// DetectLanguage guesses the ISO 639-1 code of text from its script and
+// letters unique to one language. It returns "" when the text does not give
+// it away, which is common for short Latin-script chat such as "gg" or "da".
+func DetectLanguage(text string) string {
+	scripts := map[string]int{}
+	hints := map[string]int{}
+	for _, r := range strings.ToLower(text) {
+		if lang, ok := letterHints[r]; ok {
+			hints[lang]++
+		}
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			scripts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["cyrillic"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Latin, r):
+			scripts["latin"]++
+		}
+	}
+	// Japanese mixes kana with Han characters
+	if scripts["ja"] > 0 {
+		return "ja"
+	}
+	switch script := most(scripts); script {
+	case "":
+		return ""
+	case "cyrillic":
+		// Russian unless only Ukrainian letters give it away
+		if hints["uk"] > 0 && hints["ru"] == 0 {
+			return "uk"
+		}
+		return "ru"
+	case "latin":
+		delete(hints, "uk")
+		delete(hints, "ru")
+		return most(hints)
+	default:
+		return script
+	}
+}
+
+// most returns the key with the largest count, "" for an empty map
+func most(counts map[string]int) string {
+	best, bestCount := "", 0
+	for k, n := range counts {
+		if n > bestCount || (n == bestCount && k < best) {
+			best, bestCount = k, n
+		}
+	}
+	return best
+}
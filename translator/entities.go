@@ -0,0 +1,204 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultKeepTerms are weapons and map callouts that players use as they are
+// in every language, so translations keep them too
+var DefaultKeepTerms = []string{
+	"AWP", "AK-47", "AK", "M4A1-S", "M4A1", "M4A4", "M4", "Deagle", "Desert Eagle",
+	"USP-S", "USP", "Glock", "P250", "P2000", "Tec-9", "Five-SeveN", "CZ75", "R8",
+	"MP9", "MAC-10", "MP7", "MP5", "UMP", "P90", "PP-Bizon", "Nova", "XM1014",
+	"MAG-7", "Negev", "M249", "SSG", "Scout", "Galil", "FAMAS", "SG 553", "AUG",
+	"SCAR-20", "G3SG1", "Zeus",
+	"CT spawn", "T spawn", "A site", "B site", "A long", "A short", "B short",
+	"mid", "banana", "apps", "palace", "connector", "jungle", "catwalk", "heaven",
+}
+
+// minEntity is the shortest name or term kept; shorter ones would also
+// match ordinary words
+const minEntity = 2
+
+// maxEntities bounds the terms, as player names keep being added
+const maxEntities = 500
+
+// placeholderRe finds the placeholders of a masked message in a translation,
+// also when the model added spaces inside them
+var placeholderRe = regexp.MustCompile(`\[\[\s*(\d+)\s*\]\]`)
+
+// Entities are names and terms kept as they are in translations: while a
+// message is translated, placeholders stand in for them, so the model cannot
+// turn a nickname like "Дима" into an English word. Matching ignores case and
+// only takes whole words. Safe for concurrent use.
+type Entities struct {
+	mu    sync.Mutex
+	terms map[string]bool // lower case
+	re    *regexp.Regexp  // matches any term; nil when it must be rebuilt
+}
+
+// NewEntities returns entities holding terms
+func NewEntities(terms ...string) *Entities {
+	e := &Entities{terms: map[string]bool{}}
+	e.Add(terms...)
+	return e
+}
+
+// Add keeps terms as well, e.g. a player name seen in chat
+func (e *Entities) Add(terms ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, t := range terms {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if utf8.RuneCountInString(t) < minEntity || e.terms[t] || len(e.terms) >= maxEntities {
+			continue
+		}
+		e.terms[t] = true
+		e.re = nil
+	}
+}
+
+// pattern returns the regexp matching any term, longest first
+func (e *Entities) pattern() *regexp.Regexp {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.re != nil || len(e.terms) == 0 {
+		return e.re
+	}
+	terms := make([]string, 0, len(e.terms))
+	for t := range e.terms {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+	for i, t := range terms {
+		terms[i] = regexp.QuoteMeta(t)
+	}
+	e.re = regexp.MustCompile(`(?i)` + strings.Join(terms, "|"))
+	return e.re
+}
+
+// mask replaces the terms in text by placeholders [[1]], [[2]] and so on,
+// and returns what they stand for
+func (e *Entities) mask(text string) (string, []string) {
+	re := e.pattern()
+	if re == nil {
+		return text, nil
+	}
+	var b strings.Builder
+	var originals []string
+	last := 0
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		if !wordEdge(text, m[0], m[1]) {
+			continue
+		}
+		originals = append(originals, text[m[0]:m[1]])
+		fmt.Fprintf(&b, "%s[[%d]]", text[last:m[0]], len(originals))
+		last = m[1]
+	}
+	if originals == nil {
+		return text, nil
+	}
+	b.WriteString(text[last:])
+	return b.String(), originals
+}
+
+// wordEdge reports whether text[start:end] is not part of a longer word
+func wordEdge(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	inWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	return (start == 0 || !inWord(before)) && (end == len(text) || !inWord(after))
+}
+
+// unmask puts originals back in place of the placeholders. It reports false
+// when the translation lost a placeholder or made one up.
+func unmask(text string, originals []string) (string, bool) {
+	seen := make([]bool, len(originals))
+	ok := true
+	out := placeholderRe.ReplaceAllStringFunc(text, func(m string) string {
+		n, _ := strconv.Atoi(placeholderRe.FindStringSubmatch(m)[1])
+		if n < 1 || n > len(originals) {
+			ok = false
+			return m
+		}
+		seen[n-1] = true
+		return originals[n-1]
+	})
+	for _, s := range seen {
+		ok = ok && s
+	}
+	return out, ok
+}
+
+// onlyPlaceholders reports whether masked holds nothing to translate
+// besides placeholders, e.g. a message that is just a name
+func onlyPlaceholders(masked string) bool {
+	return !strings.ContainsFunc(placeholderRe.ReplaceAllString(masked, ""), unicode.IsLetter)
+}
+
+// Preserving translates with another Translator and keeps its Entities as
+// they are. A translation that loses a placeholder is thrown away and the
+// message is translated again without them.
+type Preserving struct {
+	Translator
+	entities *Entities
+}
+
+// Preserve returns tr keeping entities
+func Preserve(tr Translator, entities *Entities) *Preserving {
+	return &Preserving{Translator: tr, entities: entities}
+}
+
+func (p *Preserving) Translate(ctx context.Context, text string) (string, error) {
+	masked, originals := p.entities.mask(text)
+	if originals == nil {
+		return p.Translator.Translate(ctx, text)
+	}
+	if onlyPlaceholders(masked) {
+		return text, nil
+	}
+	out, err := p.Translator.Translate(ctx, masked)
+	if err != nil {
+		return "", err
+	}
+	if restored, ok := unmask(out, originals); ok {
+		return restored, nil
+	}
+	slog.Debug("Translation lost a kept name; translating it unprotected", "text", text, "translation", out)
+	return p.Translator.Translate(ctx, text)
+}
+
+func (p *Preserving) TranslateWithContext(ctx context.Context, req Request) (string, error) {
+	masked, originals := p.entities.mask(req.Text)
+	if originals == nil {
+		return p.Translator.TranslateWithContext(ctx, req)
+	}
+	if onlyPlaceholders(masked) {
+		return req.Text, nil
+	}
+	maskedReq := req
+	maskedReq.Text, maskedReq.Placeholders = masked, true
+	out, err := p.Translator.TranslateWithContext(ctx, maskedReq)
+	if err != nil {
+		return "", err
+	}
+	if restored, ok := unmask(out, originals); ok {
+		return restored, nil
+	}
+	slog.Debug("Translation lost a kept name; translating it unprotected", "text", req.Text, "translation", out)
+	return p.Translator.TranslateWithContext(ctx, req)
+}
+
+// Unload frees the models of the wrapped translator
+func (p *Preserving) Unload() error {
+	return Unload(p.Translator)
+}
@@ -0,0 +1,26 @@
+package translator
+
+import "errors"
+
+var (
+	// ErrOllamaUnavailable is returned when the Ollama API cannot be reached
+	ErrOllamaUnavailable = errors.New("ollama is not reachable")
+
+	// ErrModelNotFound is returned when Ollama reports the requested model is not installed
+	ErrModelNotFound = errors.New("ollama model not found")
+
+	// ErrLibreTranslateUnavailable is returned when the LibreTranslate server
+	// cannot be reached
+	ErrLibreTranslateUnavailable = errors.New("libretranslate is not reachable")
+
+	// ErrUnknownBackend is returned for a backend type NewBackend does not know
+	ErrUnknownBackend = errors.New("unknown translation backend")
+
+	// ErrUnknownLanguage is returned by NormalizeLanguage for a language it
+	// does not know
+	ErrUnknownLanguage = errors.New("unknown language")
+
+	// ErrNoLocalBackend is returned for a private request when every backend
+	// is remote
+	ErrNoLocalBackend = errors.New("no local translation backend for a private message")
+)
@@ -0,0 +1,115 @@
+package translator
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPConfig tunes the HTTP client shared by all Ollama traffic
+type HTTPConfig struct {
+	MaxIdleConns        int           // idle keep-alive connections across all hosts
+	MaxIdleConnsPerHost int           // idle keep-alive connections to the Ollama host
+	IdleConnTimeout     time.Duration // how long an idle connection is kept
+	ClientTimeout       time.Duration // hard upper bound for any request
+	RequestTimeout      time.Duration // bound for a single translation
+	HTTP2               bool          // use HTTP/2 (h2c prior knowledge for http:// hosts)
+
+	// MaxConcurrent bounds the generate requests in flight to Ollama (0: no
+	// limit); waiting does not count against RequestTimeout. While voice
+	// and chat both wait, freed slots go to them in the ratio of their
+	// weights.
+	MaxConcurrent int
+	VoiceWeight   int
+	ChatWeight    int
+}
+
+// DefaultHTTPConfig returns the settings used unless Configure is called
+func DefaultHTTPConfig() HTTPConfig {
+	return HTTPConfig{
+		MaxIdleConns:        32,
+		MaxIdleConnsPerHost: 8,
+		IdleConnTimeout:     90 * time.Second,
+		ClientTimeout:       2 * time.Minute,
+		RequestTimeout:      30 * time.Second,
+	}
+}
+
+var (
+	httpClient     = NewHTTPClient(DefaultHTTPConfig())
+	requestTimeout = DefaultHTTPConfig().RequestTimeout
+)
+
+// Configure replaces the shared client. Call it once at startup, before any
+// translator is created.
+func Configure(cfg HTTPConfig) {
+	httpClient = NewHTTPClient(cfg)
+	requestTimeout = cfg.RequestTimeout
+	ollamaSlots = newSlots(cfg.MaxConcurrent, cfg.VoiceWeight, cfg.ChatWeight)
+}
+
+// HTTPClient returns the shared client so connections are pooled app-wide
+func HTTPClient() *http.Client {
+	return httpClient
+}
+
+// NewHTTPClient builds a client with a keep-alive pool sized for frequent
+// small requests to a single local host
+func NewHTTPClient(cfg HTTPConfig) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+
+	if cfg.HTTP2 {
+		// Ollama is usually plain http on localhost, which needs h2c with
+		// prior knowledge; https hosts negotiate HTTP/2 via ALPN
+		var protocols http.Protocols
+		protocols.SetUnencryptedHTTP2(true)
+		protocols.SetHTTP2(true)
+		transport.Protocols = &protocols
+		transport.ForceAttemptHTTP2 = true
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.ClientTimeout,
+	}
+}
+
+// GetWithTimeout performs a GET on the shared client bounded by timeout. The
+// timeout covers reading the body, which the caller must close.
+func GetWithTimeout(url string, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,218 @@
+package translator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// languageCodes maps target language names to the ISO 639-1 codes Whisper
+// reports
+var languageCodes = map[string]string{
+	"arabic":     "ar",
+	"bulgarian":  "bg",
+	"catalan":    "ca",
+	"chinese":    "zh",
+	"czech":      "cs",
+	"danish":     "da",
+	"dutch":      "nl",
+	"english":    "en",
+	"finnish":    "fi",
+	"french":     "fr",
+	"german":     "de",
+	"greek":      "el",
+	"hebrew":     "he",
+	"hindi":      "hi",
+	"hungarian":  "hu",
+	"indonesian": "id",
+	"italian":    "it",
+	"japanese":   "ja",
+	"korean":     "ko",
+	"latvian":    "lv",
+	"malay":      "ms",
+	"norwegian":  "no",
+	"persian":    "fa",
+	"polish":     "pl",
+	"portuguese": "pt",
+	"romanian":   "ro",
+	"russian":    "ru",
+	"slovak":     "sk",
+	"slovenian":  "sl",
+	"spanish":    "es",
+	"swedish":    "sv",
+	"thai":       "th",
+	"turkish":    "tr",
+	"ukrainian":  "uk",
+	"vietnamese": "vi",
+}
+
+// languageVariants are the regional and script variants worth telling a
+// model apart, by BCP-47 tag; other regions, as in de-DE, fall back to the
+// language
+var languageVariants = map[string]string{
+	"en-GB":   "British English",
+	"en-US":   "American English",
+	"es-419":  "Latin American Spanish",
+	"es-ES":   "European Spanish",
+	"fr-CA":   "Canadian French",
+	"pt-BR":   "Brazilian Portuguese",
+	"pt-PT":   "European Portuguese",
+	"zh-Hans": "Simplified Chinese",
+	"zh-Hant": "Traditional Chinese",
+}
+
+// languageAliases are other ways to write a language or variant: native
+// names, ISO 639-2 codes and region tags standing for a script, lower case
+var languageAliases = map[string]string{
+	"deutsch": "de", "deu": "de", "ger": "de",
+	"eng":     "en",
+	"español": "es", "espanol": "es", "castellano": "es", "spa": "es",
+	"français": "fr", "francais": "fr", "fra": "fr", "fre": "fr",
+	"italiano": "it", "ita": "it",
+	"nederlands": "nl", "nld": "nl", "dut": "nl",
+	"polski": "pl", "pol": "pl",
+	"português": "pt", "portugues": "pt", "por": "pt",
+	"русский": "ru", "russkiy": "ru", "rus": "ru",
+	"українська": "uk", "ukr": "uk",
+	"türkçe": "tr", "turkce": "tr", "tur": "tr",
+	"čeština": "cs", "cestina": "cs", "ces": "cs", "cze": "cs",
+	"svenska": "sv", "swe": "sv",
+	"norsk": "no", "nor": "no", "nb": "no", "nn": "no",
+	"dansk": "da", "dan": "da",
+	"suomi": "fi", "fin": "fi",
+	"magyar": "hu", "hun": "hu",
+	"română": "ro", "romana": "ro", "ron": "ro", "rum": "ro",
+	"ελληνικά": "el", "ell": "el", "gre": "el",
+	"български": "bg", "bul": "bg",
+	"العربية": "ar", "ara": "ar",
+	"עברית": "he", "heb": "he", "iw": "he",
+	"हिन्दी": "hi", "hin": "hi",
+	"bahasa indonesia": "id", "ind": "id", "in": "id",
+	"bahasa melayu": "ms", "msa": "ms", "may": "ms",
+	"ไทย": "th", "tha": "th",
+	"فارسی": "fa", "farsi": "fa", "fas": "fa", "per": "fa",
+	"tiếng việt": "vi", "vie": "vi",
+	"日本語": "ja", "jpn": "ja",
+	"한국어": "ko", "kor": "ko",
+	"中文": "zh", "zho": "zh", "chi": "zh", "mandarin": "zh",
+	"简体中文": "zh-Hans", "simplified chinese": "zh-Hans",
+	"zh-cn": "zh-Hans", "zh-sg": "zh-Hans",
+	"繁體中文": "zh-Hant", "traditional chinese": "zh-Hant",
+	"zh-tw": "zh-Hant", "zh-hk": "zh-Hant", "zh-mo": "zh-Hant",
+	"brazilian portuguese": "pt-BR", "português brasileiro": "pt-BR",
+	"european portuguese": "pt-PT",
+	"british english":     "en-GB", "american english": "en-US",
+	"latin american spanish": "es-419", "es-mx": "es-419", "es-ar": "es-419",
+	"canadian french": "fr-CA",
+}
+
+// NormalizeLanguage returns the canonical code of a language given as a
+// name, a native name, an ISO 639 code or a BCP-47 tag: "de" for "German",
+// "Deutsch", "deu" or "de-DE", and "pt-BR" for "pt_br" or "Brazilian
+// Portuguese". Prompts, events and sinks use that code. An unknown language
+// is an error listing the supported ones.
+func NormalizeLanguage(lang string) (string, error) {
+	code, ok := normalizeLanguage(lang)
+	if !ok {
+		return "", fmt.Errorf("%w %q; use a name or code such as German, de or pt-BR, one of: %s",
+			ErrUnknownLanguage, strings.TrimSpace(lang), strings.Join(SupportedLanguages(), ", "))
+	}
+	return code, nil
+}
+
+func normalizeLanguage(lang string) (string, bool) {
+	key := strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(lang, "_", "-")), " "))
+	if code, ok := languageAliases[key]; ok {
+		return code, true
+	}
+	if code, ok := languageCodes[key]; ok {
+		return code, true
+	}
+	subtags := strings.Split(key, "-")
+	base := subtags[0]
+	if code, ok := languageAliases[base]; ok && !strings.Contains(code, "-") {
+		base = code
+	}
+	if !knownCode(base) {
+		return "", false
+	}
+	for _, sub := range subtags[1:] {
+		tag := base + "-" + canonicalSubtag(sub)
+		if code, ok := languageAliases[strings.ToLower(tag)]; ok {
+			return code, true
+		}
+		if _, ok := languageVariants[tag]; ok {
+			return tag, true
+		}
+	}
+	return base, true
+}
+
+// canonicalSubtag writes a BCP-47 subtag the usual way: regions in upper
+// case, scripts in title case
+func canonicalSubtag(sub string) string {
+	switch len(sub) {
+	case 2:
+		return strings.ToUpper(sub)
+	case 4:
+		return strings.ToUpper(sub[:1]) + sub[1:]
+	}
+	return sub
+}
+
+func knownCode(code string) bool {
+	for _, c := range languageCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportedLanguages lists the codes NormalizeLanguage returns, each with
+// its name, e.g. "de (German)"
+func SupportedLanguages() []string {
+	var out []string
+	for name, code := range languageCodes {
+		out = append(out, fmt.Sprintf("%s (%s)", code, strings.ToUpper(name[:1])+name[1:]))
+	}
+	for tag, name := range languageVariants {
+		out = append(out, fmt.Sprintf("%s (%s)", tag, name))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LanguageCode returns the ISO 639-1 code for a language in any form
+// NormalizeLanguage takes, e.g. "pt" for "Brazilian Portuguese". Other
+// two-letter codes are returned as is; unknown names give "".
+func LanguageCode(name string) string {
+	code, ok := normalizeLanguage(name)
+	if !ok {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 2 {
+			return name
+		}
+		return ""
+	}
+	base, _, _ := strings.Cut(code, "-")
+	return base
+}
+
+// LanguageName returns the language name for a code such as "de" or
+// "pt-BR", for prompts. A language it does not know is returned as is.
+func LanguageName(code string) string {
+	canonical, ok := normalizeLanguage(code)
+	if !ok {
+		return strings.TrimSpace(code)
+	}
+	if name, ok := languageVariants[canonical]; ok {
+		return name
+	}
+	for name, c := range languageCodes {
+		if c == canonical {
+			return strings.ToUpper(name[:1]) + name[1:]
+		}
+	}
+	return canonical
+}
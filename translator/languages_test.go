@@ -0,0 +1,41 @@
+package translator_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// -lang takes names, codes and tags, prompts name the language, and an
+// unknown one is refused with the supported ones
+func TestLanguages(t *testing.T) {
+	ctx := t.Context()
+	for given, want := range map[string]string{
+		"German": "de", "deutsch": "de", "de": "de", "de-DE": "de", "deu": "de", " DE_at ": "de",
+		"pt-BR": "pt-BR", "pt_br": "pt-BR", "Brazilian Portuguese": "pt-BR", "pt": "pt",
+		"zh-TW": "zh-Hant", "zh-Hans-CN": "zh-Hans", "日本語": "ja", "English": "en",
+	} {
+		got, err := translator.NormalizeLanguage(given)
+		if err != nil || got != want {
+			t.Fatalf("-lang %q became %q (%v), want %q", given, got, err, want)
+		}
+	}
+	if _, err := translator.NormalizeLanguage("klingon"); !errors.Is(err, translator.ErrUnknownLanguage) || !strings.Contains(err.Error(), "pt-BR (Brazilian Portuguese)") {
+		t.Fatalf("unknown language reported as %v", err)
+	}
+
+	_, tr := newOllama(t)
+	// The fake translates the whole prompt, which is only the language
+	tr.SetPrompt("{lang}")
+	tr.SetTargetLang("pt-BR")
+	got, err := tr.Translate(ctx, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fakegame.Translation("Brazilian Portuguese"); got != want {
+		t.Fatalf("the prompt named the language as in %q, want %q", got, want)
+	}
+}
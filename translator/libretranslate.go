@@ -0,0 +1,128 @@
+package translator
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// LibreTranslateKeyEnv holds the API key for LibreTranslate servers that
+// require one
+const LibreTranslateKeyEnv = "CS_TRANSLATE_LIBRETRANSLATE_KEY"
+
+// DefaultLibreTranslateURL is a LibreTranslate server on this machine
+const DefaultLibreTranslateURL = "http://localhost:5000"
+
+// LibreTranslator implements Translator with a LibreTranslate server. It
+// has no use for voice context, but passes the source language on.
+type LibreTranslator struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+
+	mu         sync.RWMutex
+	targetLang string
+}
+
+// NewLibreTranslator talks to the LibreTranslate server at baseURL
+func NewLibreTranslator(baseURL, apiKey, targetLang string) *LibreTranslator {
+	if baseURL == "" {
+		baseURL = DefaultLibreTranslateURL
+	}
+	if targetLang == "" {
+		targetLang = "English"
+	}
+	return &LibreTranslator{
+		httpClient: HTTPClient(),
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		targetLang: targetLang,
+	}
+}
+
+func (t *LibreTranslator) Translate(ctx context.Context, text string) (string, error) {
+	return t.TranslateWithContext(ctx, Request{Text: text})
+}
+
+func (t *LibreTranslator) TranslateWithContext(ctx context.Context, req Request) (string, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" || len(text) < 2 {
+		return text, nil
+	}
+	lang := cmp.Or(req.TargetLang, t.TargetLang())
+	target := LanguageCode(lang)
+	if target == "" {
+		return "", fmt.Errorf("libretranslate needs a known target language, not %q", lang)
+	}
+	source := req.SourceLang
+	if source == "" {
+		source = req.PlayerLang
+	}
+	if source == "" {
+		source = "auto"
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  source,
+		"target":  target,
+		"format":  "text",
+		"api_key": t.apiKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.baseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("%w at %s: %v", ErrLibreTranslateUnavailable, t.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %v", err)
+	}
+	var out struct {
+		TranslatedText string `json:"translatedText"`
+		Error          string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", fmt.Errorf("failed to parse response: %v", err)
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("libretranslate error: %s", out.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("libretranslate returned status %d", resp.StatusCode)
+	}
+	if translation := strings.TrimSpace(out.TranslatedText); translation != "" {
+		return translation, nil
+	}
+	return text, nil
+}
+
+func (t *LibreTranslator) TargetLang() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.targetLang
+}
+
+func (t *LibreTranslator) SetTargetLang(lang string) {
+	t.mu.Lock()
+	t.targetLang = lang
+	t.mu.Unlock()
+}
+
+func (t *LibreTranslator) Close() error { return nil }
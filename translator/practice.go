@@ -0,0 +1,82 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Feedback is a tutor's grade of a sentence said in a language being
+// practiced
+type Feedback struct {
+	Score     int    `json:"score"`     // how natural the phrasing is, 1 to 10
+	Corrected string `json:"corrected"` // the sentence as a native speaker says it
+	Tip       string `json:"tip"`       // one hint on pronunciation or phrasing
+}
+
+// feedbackFormat is the JSON schema Ollama constrains grades to
+var feedbackFormat = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"score": {"type": "integer", "minimum": 1, "maximum": 10},
+		"corrected": {"type": "string"},
+		"tip": {"type": "string"}
+	},
+	"required": ["score", "corrected", "tip"]
+}`)
+
+// practicePrompt grades the sentence after it. {lang}, {native} and
+// {clarity} are replaced by the language practiced, the language to write
+// the tip in and how well speech recognition understood the sentence.
+const practicePrompt = `A Counter-Strike player practicing {lang} said the sentence below. It was transcribed by speech recognition, which understood it {clarity}; words it got wrong were probably mispronounced. Grade how natural the phrasing is from 1 to 10 as score, give the sentence as a native speaker would say it in {lang} as corrected, and give one short tip on pronunciation or phrasing, written in {native}, as tip. Answer in JSON.
+
+`
+
+// Tutor grades sentences said in practice mode with an Ollama model
+type Tutor struct {
+	model ollamaModel
+}
+
+// NewTutor returns a tutor asking the model of the Ollama backend
+func NewTutor(backend BackendConfig) *Tutor {
+	return &Tutor{model: newOllamaModel(backend)}
+}
+
+// Grade grades text, said in lang, with the tip written in native. clarity
+// is the transcriber's confidence from 0 to 1. Private text is only sent to
+// a local Ollama, otherwise the error is ErrNoLocalBackend.
+func (t *Tutor) Grade(ctx context.Context, text, lang, native string, clarity float64, private bool) (Feedback, error) {
+	prompt := strings.NewReplacer("{lang}", LanguageName(lang), "{native}", LanguageName(native),
+		"{clarity}", describeClarity(clarity)).Replace(practicePrompt)
+	answer, err := t.model.ask(ctx, prompt+strings.TrimSpace(text), feedbackFormat, private, requestTimeout)
+	if err != nil {
+		return Feedback{}, err
+	}
+	return parseFeedback(answer)
+}
+
+// describeClarity puts the transcriber's confidence in words for the model
+func describeClarity(clarity float64) string {
+	switch {
+	case clarity >= 0.8:
+		return "clearly"
+	case clarity >= 0.5:
+		return "mostly"
+	}
+	return "only with difficulty"
+}
+
+// parseFeedback reads a grade; one without a score from 1 to 10 is an error
+func parseFeedback(answer string) (Feedback, error) {
+	var f Feedback
+	if err := json.Unmarshal([]byte(strings.TrimSpace(thinkRe.ReplaceAllString(answer, ""))), &f); err != nil {
+		return Feedback{}, fmt.Errorf("no grade in the tutor's answer %q: %w", answer, err)
+	}
+	if f.Score < 1 || f.Score > 10 {
+		return Feedback{}, fmt.Errorf("no score from 1 to 10 in the tutor's answer %q", answer)
+	}
+	f.Corrected = strings.TrimSpace(f.Corrected)
+	f.Tip = strings.TrimSpace(f.Tip)
+	return f, nil
+}
@@ -0,0 +1,73 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is what sort of message is translated
+type Kind string
+
+const (
+	KindChat  Kind = "chat"  // typed in-game chat
+	KindVoice Kind = "voice" // transcribed speech
+)
+
+// Request is a message to translate together with what is known about it.
+// Backends use as much of it as they can; only Text is required.
+type Request struct {
+	Text       string
+	Kind       Kind
+	SourceLang string       // ISO 639-1 code of the message, "" when unknown
+	PlayerLang string       // language the sender used earlier in the session
+	Context    VoiceContext // recent speech, for voice
+	TargetLang string       // language to translate into instead of the translator's TargetLang
+
+	// Private messages, such as team chat in privacy mode, must not leave
+	// this machine; a Chain only hands them to local backends
+	Private bool
+	// Placeholders marks a Text in which [[1]], [[2]] and so on stand for
+	// names to keep, see Preserve
+	Placeholders bool
+	// Detail, when set, receives what the backend found out about the
+	// message; backends without structured output leave it empty
+	Detail *Detail
+}
+
+// Detail is what a backend reports about a message besides its translation
+type Detail struct {
+	Language   string  // ISO 639-1 code the message is written in, "" when not reported
+	Confidence float64 // how sure the backend is of its translation, 0 to 1; 0 when not reported
+}
+
+// hint describes the message for the prompt; "" when nothing is known
+func (r Request) hint() string {
+	var what string
+	switch r.Kind {
+	case KindChat:
+		what = "an in-game chat message from a Counter-Strike match"
+	case KindVoice:
+		what = "transcribed voice chat from a Counter-Strike match, so it may contain transcription errors"
+	}
+	var player string
+	if r.PlayerLang != "" && r.PlayerLang != r.SourceLang {
+		player = fmt.Sprintf(" This player usually writes %s.", LanguageName(r.PlayerLang))
+	}
+	var keep string
+	if r.Placeholders {
+		keep = " Keep placeholders such as [[1]] exactly as written; they stand for names."
+	}
+	return strings.TrimSpace(describeText(what, r.SourceLang) + player + keep)
+}
+
+func describeText(what, lang string) string {
+	switch {
+	case what != "" && lang != "":
+		return fmt.Sprintf("The text is %s, in %s.", what, LanguageName(lang))
+	case what != "":
+		return fmt.Sprintf("The text is %s.", what)
+	case lang != "":
+		return fmt.Sprintf("The text is in %s.", LanguageName(lang))
+	}
+	return ""
+}
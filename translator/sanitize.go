@@ -0,0 +1,155 @@
+package translator
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// thinkRe matches the reasoning of models such as qwen3 and deepseek-r1
+	thinkRe = regexp.MustCompile(`(?is)<(?:think|thinking|reasoning)>.*?</(?:think|thinking|reasoning)>`)
+	// openThinkRe and closeThinkRe find reasoning cut off by the token limit
+	// and reasoning whose opening tag was part of the prompt
+	openThinkRe  = regexp.MustCompile(`(?i)<(?:think|thinking|reasoning)>`)
+	closeThinkRe = regexp.MustCompile(`(?i)</(?:think|thinking|reasoning)>`)
+	// fenceRe matches an answer wrapped in a Markdown code block
+	fenceRe = regexp.MustCompile("(?s)^```[\\w-]*\\s*\n(.*?)\n?```$")
+	// promptRe matches the instruction of the prompt repeated as the first
+	// line, as in "Translate the following text to English:"
+	promptRe = regexp.MustCompile(`(?i)^translate\b[^\n]{0,120}:\s*`)
+	// hereRe matches an introduction such as "Sure! Here is the translation:"
+	hereRe = regexp.MustCompile(`(?i)^(?:sure|okay|ok|of course)?[!,.]?\s*here(?:'s| is| you go)[^:\n]{0,60}:\s*`)
+	// labelRe matches a label such as "Translation:", "Translated text
+	// (English):" or "**Translation:**"
+	labelRe = regexp.MustCompile(`(?i)^\**(?:[\p{L}]+ )?translat(?:ion|ed(?: text| message)?)\b[^:\n]{0,40}:\**\s*`)
+	// noteRe matches a line of commentary after the translation
+	noteRe = regexp.MustCompile(`(?i)^[(*\[]*(?:note|notes|explanation|literally|literal translation|original|context)\b[^:\n]{0,30}:`)
+	// parenNoteRe matches a note in parentheses at the end of a line
+	parenNoteRe = regexp.MustCompile(`(?i)\s*\((?:note|literally|lit\.|i\.e\.|translation)\b[^)]*\)$`)
+)
+
+// quotePairs are the quotes a model wraps an answer in
+var quotePairs = [][2]string{
+	{`"`, `"`}, {`'`, `'`}, {"`", "`"}, {"“", "”"}, {"„", "“"}, {"«", "»"}, {"»", "«"},
+	{"‘", "’"}, {"「", "」"}, {"『", "』"},
+}
+
+// Sanitize cuts a model's answer down to the translation of original:
+// reasoning in <think> tags, a code block, the prompt or original echoed
+// before the translation, an introduction or a label such as "Translation:"
+// or "German:", quotes around the whole answer and notes after it are
+// removed, unless original has them itself. lang is the target
+// language. The result is "" when nothing is left.
+func Sanitize(answer, original, lang string) string {
+	s := thinkRe.ReplaceAllString(answer, "")
+	if loc := openThinkRe.FindStringIndex(s); loc != nil {
+		s = s[:loc[0]]
+	}
+	if locs := closeThinkRe.FindAllStringIndex(s, -1); locs != nil {
+		s = s[locs[len(locs)-1][1]:]
+	}
+	s = strings.TrimSpace(s)
+	original = strings.TrimSpace(original)
+
+	if m := fenceRe.FindStringSubmatch(s); m != nil && !strings.HasPrefix(original, "```") {
+		s = strings.TrimSpace(m[1])
+	}
+	s = stripLead(s, original, promptRe)
+	s = stripEcho(s, original)
+	s = stripLead(s, original, hereRe)
+	s = stripLead(s, original, labelRe)
+	s = stripLang(s, original, lang)
+	s = stripNotes(s, original)
+	return unquote(s, original)
+}
+
+// stripLead removes what re matches at the start of s when there is more
+// after it and original does not start the same way
+func stripLead(s, original string, re *regexp.Regexp) string {
+	loc := re.FindStringIndex(s)
+	if loc == nil || loc[1] == len(s) || re.MatchString(original) {
+		return s
+	}
+	return strings.TrimSpace(s[loc[1]:])
+}
+
+// stripEcho removes original repeated at the start of s when the
+// translation follows on the next line or after an arrow
+func stripEcho(s, original string) string {
+	rest, ok := strings.CutPrefix(s, original)
+	if original == "" || !ok {
+		return s
+	}
+	rest = strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(rest, "\n") {
+		var arrow bool
+		for _, sep := range []string{"→", "->", "=>"} {
+			if rest, arrow = strings.CutPrefix(rest, sep); arrow {
+				break
+			}
+		}
+		if !arrow {
+			return s
+		}
+	}
+	if rest = strings.TrimSpace(rest); rest == "" {
+		return s
+	}
+	return rest
+}
+
+// stripLang removes a label naming the target language, as in "German:
+// Hallo", when original does not start with it
+func stripLang(s, original, lang string) string {
+	label := func(s string) (string, bool) {
+		s = strings.TrimLeft(s, "*")
+		if lang == "" || len(s) <= len(lang) || !strings.EqualFold(s[:len(lang)], lang) {
+			return "", false
+		}
+		rest, ok := strings.CutPrefix(strings.TrimLeft(s[len(lang):], " "), ":")
+		return strings.TrimSpace(strings.TrimLeft(rest, "*")), ok
+	}
+	if _, ok := label(original); ok {
+		return s
+	}
+	if rest, ok := label(s); ok && rest != "" {
+		return rest
+	}
+	return s
+}
+
+// stripNotes removes lines of commentary after the first line and a note in
+// parentheses at the end, when original has no such lines
+func stripNotes(s, original string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if noteRe.MatchString(strings.TrimSpace(lines[i])) && !noteRe.MatchString(original) {
+			lines = lines[:i]
+			break
+		}
+	}
+	s = strings.TrimSpace(strings.Join(lines, "\n"))
+	if loc := parenNoteRe.FindStringIndex(s); loc != nil && loc[0] > 0 && !parenNoteRe.MatchString(original) {
+		s = strings.TrimSpace(s[:loc[0]])
+	}
+	return s
+}
+
+// unquote removes one pair of quotes around all of s, unless original is
+// quoted the same way or the quote also appears inside
+func unquote(s, original string) string {
+	for _, q := range quotePairs {
+		open, close := q[0], q[1]
+		if utf8.RuneCountInString(s) < 3 || !strings.HasPrefix(s, open) || !strings.HasSuffix(s, close) {
+			continue
+		}
+		inner := s[len(open) : len(s)-len(close)]
+		if strings.Contains(inner, open) || strings.Contains(inner, close) ||
+			strings.HasPrefix(original, open) && strings.HasSuffix(original, close) {
+			return s
+		}
+		return strings.TrimSpace(inner)
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package translator
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		answer, original, lang, want string
+	}{
+		// Reasoning
+		{"<think>\nThe user greets.\n</think>\n\nHello", "привет", "English", "Hello"},
+		{"<THINKING>hmm</THINKING>Hello", "привет", "English", "Hello"},
+		{"<reasoning>a</reasoning>Hello<think>b</think>", "привет", "English", "Hello"},
+		{"The user greets.</think>Hello", "привет", "English", "Hello"},
+		{"<think>The user greets, so", "привет", "English", ""},
+
+		// Introductions and labels
+		{"Sure! Here is the translation:\n\nHello", "привет", "English", "Hello"},
+		{"Here's the translation into English: Hello", "привет", "English", "Hello"},
+		{"Okay, here you go: Hello", "привет", "English", "Hello"},
+		{"Translation: Hello", "привет", "English", "Hello"},
+		{"**Translation:** Hello", "привет", "English", "Hello"},
+		{"Translated text (English): Hello", "привет", "English", "Hello"},
+		{"English translation: Hello", "привет", "English", "Hello"},
+		{"English: Hello", "привет", "English", "Hello"},
+		{"**German:** Hallo", "hello", "German", "Hallo"},
+		{"Translation:", "привет", "English", "Translation:"},
+		{"Translation: Übersetzung", "Translation: translation", "German", "Translation: Übersetzung"},
+
+		// Quotes and code blocks
+		{`"Hello"`, "привет", "English", "Hello"},
+		{"«Hello»", "привет", "English", "Hello"},
+		{"“Hello”", "привет", "English", "Hello"},
+		{"「こんにちは」", "hello", "Japanese", "こんにちは"},
+		{"```\nHello\n```", "привет", "English", "Hello"},
+		{"```text\nHello\n```", "привет", "English", "Hello"},
+		{"Translation: \"Hello\"", "привет", "English", "Hello"},
+		{`"Hello"`, `"привет"`, "English", `"Hello"`},
+		{`"go" he said "now"`, "«го» сказал он «сейчас»", "English", `"go" he said "now"`},
+		{"He said 'go'", "он сказал 'го'", "English", "He said 'go'"},
+		{`""`, "привет", "English", `""`},
+
+		// The prompt or the original echoed before the translation
+		{"Translate the following text to English. Output ONLY the translation, nothing else:\n\nпривет\n\nHello", "привет", "English", "Hello"},
+		{"Translate to English: Hello", "привет", "English", "Hello"},
+		{"привет\nHello", "привет", "English", "Hello"},
+		{"привет → Hello", "привет", "English", "Hello"},
+		{"привет -> Hello", "привет", "English", "Hello"},
+		{"gg wp", "gg", "English", "gg wp"},
+		{"привет", "привет", "English", "привет"},
+		{"translate this: Hello", "translate this: привет", "English", "translate this: Hello"},
+
+		// Notes after the translation
+		{"Hello\n\nNote: привет is an informal greeting.", "привет", "English", "Hello"},
+		{"Hello\n(Literally: greetings)", "привет", "English", "Hello"},
+		{"Hello (literally: greetings)", "привет", "English", "Hello"},
+		{"Hello\nExplanation: a greeting", "привет", "English", "Hello"},
+		{"Note: bring water", "Заметка: возьми воду", "English", "Note: bring water"},
+		{"One\nTwo", "раз\nдва", "English", "One\nTwo"},
+
+		// Nothing left
+		{"", "привет", "English", ""},
+		{"  \n\t ", "привет", "English", ""},
+		{"<think>only reasoning</think>", "привет", "English", ""},
+		{"```\n```", "привет", "English", ""},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.answer, tt.original, tt.lang); got != tt.want {
+			t.Errorf("Sanitize(%q, %q, %q) = %q, want %q", tt.answer, tt.original, tt.lang, got, tt.want)
+		}
+	}
+}
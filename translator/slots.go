@@ -0,0 +1,128 @@
+package translator
+
+import (
+	"context"
+	"sync"
+)
+
+// kindBackground is work nobody is waiting to read, such as tone tags,
+// summaries and practice grades
+const kindBackground Kind = "background"
+
+// slotKinds are the sources that take turns, in the order ties are broken
+var slotKinds = []Kind{KindVoice, KindChat, kindBackground}
+
+// slots bounds the generate requests in flight to Ollama, which on a small
+// GPU runs one or two at a time and lets the rest time out in its queue.
+// Freed slots go to the waiting sources in turn, each as often as its
+// weight, so a burst of chat cannot starve voice nor voice starve chat.
+// A nil *slots admits everything.
+type slots struct {
+	mu      sync.Mutex
+	free    int
+	weight  map[Kind]int
+	credit  map[Kind]int // smooth weighted round robin
+	waiting map[Kind][]chan struct{}
+}
+
+// newSlots returns slots for n requests at a time, or nil for no limit.
+// Weights below 1 count as 1.
+func newSlots(n, voiceWeight, chatWeight int) *slots {
+	if n <= 0 {
+		return nil
+	}
+	return &slots{
+		free:    n,
+		weight:  map[Kind]int{KindVoice: max(voiceWeight, 1), KindChat: max(chatWeight, 1), kindBackground: 1},
+		credit:  map[Kind]int{},
+		waiting: map[Kind][]chan struct{}{},
+	}
+}
+
+// ollamaSlots are shared by every request to Ollama
+var ollamaSlots *slots
+
+// acquire waits for a slot for a request of kind and returns the function
+// that frees it again, or ctx's error when ctx ends first
+func (s *slots) acquire(ctx context.Context, kind Kind) (release func(), err error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	if s.weight[kind] == 0 {
+		kind = KindChat
+	}
+	s.mu.Lock()
+	if s.free > 0 && s.waiters() == 0 {
+		s.free--
+		s.mu.Unlock()
+		return s.releaser(), nil
+	}
+	ready := make(chan struct{})
+	s.waiting[kind] = append(s.waiting[kind], ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return s.releaser(), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		queue := s.waiting[kind]
+		for i, c := range queue {
+			if c == ready {
+				s.waiting[kind] = append(queue[:i:i], queue[i+1:]...)
+				return nil, ctx.Err()
+			}
+		}
+		// The slot was handed over just as ctx ended
+		s.handOver()
+		return nil, ctx.Err()
+	}
+}
+
+// releaser returns a release function that is safe to call twice
+func (s *slots) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.handOver()
+		})
+	}
+}
+
+// waiters counts the requests waiting; s.mu must be held
+func (s *slots) waiters() int {
+	n := 0
+	for _, queue := range s.waiting {
+		n += len(queue)
+	}
+	return n
+}
+
+// handOver gives a freed slot to the next source's first waiter; s.mu must
+// be held
+func (s *slots) handOver() {
+	total := 0
+	var next Kind
+	for _, k := range slotKinds {
+		if len(s.waiting[k]) == 0 {
+			// Turns are not saved up while a source is idle
+			s.credit[k] = 0
+			continue
+		}
+		s.credit[k] += s.weight[k]
+		total += s.weight[k]
+		if next == "" || s.credit[k] > s.credit[next] {
+			next = k
+		}
+	}
+	if next == "" {
+		s.free++
+		return
+	}
+	s.credit[next] -= total
+	close(s.waiting[next][0])
+	s.waiting[next] = s.waiting[next][1:]
+}
@@ -0,0 +1,72 @@
+package translator_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// With one request to Ollama at a time, waiting voice and chat take turns
+// two to one, and the wait does not use up the request timeout
+func TestFairness(t *testing.T) {
+	ctx := t.Context()
+	o := fakegame.NewOllama()
+	defer o.Close()
+	translator.OllamaHost = o.URL
+	httpConfig := translator.DefaultHTTPConfig()
+	httpConfig.RequestTimeout = 400 * time.Millisecond
+	httpConfig.MaxConcurrent, httpConfig.VoiceWeight, httpConfig.ChatWeight = 1, 2, 1
+	translator.Configure(httpConfig)
+	defer translator.Configure(translator.DefaultHTTPConfig())
+	tr, err := translator.NewOllamaTranslator(ctx, "fake", "English")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+	o.SetDelay(150 * time.Millisecond)
+
+	errs := make(chan error, 9)
+	send := func(kind translator.Kind, text string) {
+		go func() {
+			_, err := tr.TranslateWithContext(ctx, translator.Request{Text: text, Kind: kind})
+			errs <- err
+		}()
+	}
+	send(translator.KindChat, "chat busy")
+	for deadline := time.Now().Add(testTimeout); len(o.Texts()) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the first message never reached Ollama")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// A chat burst first, then voice; each waits in its own line
+	for i := range 4 {
+		send(translator.KindChat, fmt.Sprintf("chat %d", i))
+		time.Sleep(5 * time.Millisecond)
+	}
+	for i := range 4 {
+		send(translator.KindVoice, fmt.Sprintf("voice %d", i))
+		time.Sleep(5 * time.Millisecond)
+	}
+	for range 9 {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Fatalf("a request that waited its turn failed: %v", err)
+			}
+		case <-time.After(testTimeout):
+			t.Fatalf("requests still waiting after %s", testTimeout)
+		}
+	}
+	want := []string{"chat busy", "voice 0", "chat 0", "voice 1", "voice 2", "chat 1", "voice 3", "chat 2", "chat 3"}
+	if got := o.Texts(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("sent in the order %q, want %q", got, want)
+	}
+	if o.Peak() != 1 {
+		t.Fatalf("%d requests reached Ollama at once, want 1", o.Peak())
+	}
+}
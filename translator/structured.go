@@ -0,0 +1,40 @@
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// structuredFormat is the JSON schema Ollama constrains structured answers
+// to, see SetStructured
+var structuredFormat = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"translation": {"type": "string"},
+		"detected_language": {"type": "string"},
+		"confidence": {"type": "number", "minimum": 0, "maximum": 1}
+	},
+	"required": ["translation", "detected_language", "confidence"]
+}`)
+
+// structuredInstruction tells the model what the fields of structuredFormat
+// mean; Ollama recommends saying it in the prompt as well
+const structuredInstruction = "Answer in JSON: the translation as translation, the ISO 639-1 code of the language the text is written in as detected_language, and how sure you are of the translation, from 0 to 1, as confidence."
+
+// structuredAnswer is an answer in structuredFormat
+type structuredAnswer struct {
+	Translation      string  `json:"translation"`
+	DetectedLanguage string  `json:"detected_language"`
+	Confidence       float64 `json:"confidence"`
+}
+
+// parseStructured reads a structured answer. A model that ignored the
+// format is reported as false, so its answer is used as plain text.
+func parseStructured(response string) (structuredAnswer, Detail, bool) {
+	var a structuredAnswer
+	if err := json.Unmarshal([]byte(strings.TrimSpace(response)), &a); err != nil || a.Translation == "" {
+		return a, Detail{}, false
+	}
+	lang, _, _ := strings.Cut(strings.ReplaceAll(a.DetectedLanguage, "_", "-"), "-")
+	return a, Detail{Language: LanguageCode(lang), Confidence: min(max(a.Confidence, 0), 1)}, true
+}
@@ -0,0 +1,50 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// summaryTimeout bounds one summary; a whole match takes longer to read
+// than a chat message
+const summaryTimeout = 2 * time.Minute
+
+// maxSummaryInput is how much of a transcript the model reads, from the end,
+// so a long match still fits a small context window
+const maxSummaryInput = 16000
+
+// summaryPrompt is the match summary prompt. {lang} and {transcript} are
+// replaced by the language to write in and the transcript.
+const summaryPrompt = `Below is the chat and voice transcript of a Counter-Strike match, one line per message with its time, channel, player and translation. Write a short summary in {lang} of how the team communicated, the key calls (strategies, rotations, enemy positions) and notable moments, as a few bullet points. Output ONLY the summary.
+
+{transcript}`
+
+// Summarizer writes match summaries with an Ollama model
+type Summarizer struct {
+	model ollamaModel
+}
+
+// NewSummarizer returns a summarizer asking the model of the Ollama backend
+func NewSummarizer(backend BackendConfig) *Summarizer {
+	return &Summarizer{model: newOllamaModel(backend)}
+}
+
+// Summarize summarizes a match transcript in lang. A private transcript,
+// one with team chat or voice, is only sent to a local Ollama, otherwise the
+// error is ErrNoLocalBackend.
+func (s *Summarizer) Summarize(ctx context.Context, transcript, lang string, private bool) (string, error) {
+	transcript = strings.TrimSpace(transcript)
+	if len(transcript) > maxSummaryInput {
+		transcript = transcript[len(transcript)-maxSummaryInput:]
+		if i := strings.IndexByte(transcript, '\n'); i >= 0 {
+			transcript = transcript[i+1:]
+		}
+	}
+	prompt := strings.NewReplacer("{lang}", LanguageName(lang), "{transcript}", transcript).Replace(summaryPrompt)
+	answer, err := s.model.ask(ctx, prompt, nil, private, summaryTimeout)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(thinkRe.ReplaceAllString(answer, "")), nil
+}
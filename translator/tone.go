@@ -0,0 +1,80 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tone is how a message comes across
+type Tone string
+
+const (
+	ToneFriendly Tone = "friendly"
+	ToneNeutral  Tone = "neutral"
+	ToneToxic    Tone = "toxic"
+)
+
+// Worse returns the more hostile of t and u, e.g. for a message classified
+// in parts; "" counts as unknown and loses to either
+func (t Tone) Worse(u Tone) Tone {
+	rank := map[Tone]int{ToneFriendly: 1, ToneNeutral: 2, ToneToxic: 3}
+	if rank[u] > rank[t] {
+		return u
+	}
+	return t
+}
+
+// toneFormat is the JSON schema Ollama constrains classifications to
+var toneFormat = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"tone": {"type": "string", "enum": ["friendly", "neutral", "toxic"]}
+	},
+	"required": ["tone"]
+}`)
+
+// tonePrompt asks for the tone of the message after it
+const tonePrompt = `Classify the tone of this Counter-Strike chat message as friendly, neutral or toxic. Insults, slurs, harassment, threats and telling players to quit or die are toxic; callouts, banter, complaints and "gg" are not. Answer in JSON with the tone as tone.
+
+`
+
+// Classifier tags messages with their Tone using an Ollama model, usually a
+// small one next to the translation model
+type Classifier struct {
+	model ollamaModel
+}
+
+// NewClassifier returns a classifier asking the model of the Ollama backend
+func NewClassifier(backend BackendConfig) *Classifier {
+	return &Classifier{model: newOllamaModel(backend)}
+}
+
+// Classify returns the tone of text. Private text is only sent to a local
+// Ollama, otherwise the error is ErrNoLocalBackend. An answer that names no
+// tone is an error.
+func (c *Classifier) Classify(ctx context.Context, text string, private bool) (Tone, error) {
+	answer, err := c.model.ask(ctx, tonePrompt+strings.TrimSpace(text), toneFormat, private, requestTimeout)
+	if err != nil {
+		return "", err
+	}
+	return parseTone(answer)
+}
+
+// parseTone reads a classification, also from a model that ignored the
+// format and answered with just the word
+func parseTone(answer string) (Tone, error) {
+	var a struct {
+		Tone string `json:"tone"`
+	}
+	tone := strings.TrimSpace(answer)
+	if json.Unmarshal([]byte(tone), &a) == nil {
+		tone = a.Tone
+	}
+	switch t := Tone(strings.ToLower(strings.Trim(tone, " .\"'"))); t {
+	case ToneFriendly, ToneNeutral, ToneToxic:
+		return t, nil
+	}
+	return "", fmt.Errorf("no tone in the classifier's answer %q", answer)
+}
@@ -2,34 +2,68 @@ package translator
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Translator defines the interface for translating text
 type Translator interface {
 	Translate(ctx context.Context, text string) (string, error)
+	// TranslateWithContext translates req.Text, using its kind, source
+	// language and recent speech to translate better
+	TranslateWithContext(ctx context.Context, req Request) (string, error)
+	TargetLang() string
+	SetTargetLang(lang string)
 	Close() error
 }
 
+var _ Translator = (*OllamaTranslator)(nil)
+
+// Unloader is a Translator holding a model that can be freed while idle
+type Unloader interface {
+	Unload() error
+}
+
+// Unload frees the models tr holds, if any
+func Unload(tr Translator) error {
+	if u, ok := tr.(Unloader); ok {
+		return u.Unload()
+	}
+	return nil
+}
+
+// DefaultPrompt is the chat translation prompt. {lang} and {text} are
+// replaced by the target language and the message.
+const DefaultPrompt = "Translate the following text to {lang}. Output ONLY the translation, nothing else:\n\n{text}"
+
 // OllamaTranslator implements Translator using local Ollama LLM
 type OllamaTranslator struct {
-	httpClient *http.Client
-	baseURL    string
-	model      string
+	httpClient     *http.Client
+	requestTimeout time.Duration
+	baseURL        string
+	model          string
+
+	mu         sync.RWMutex
 	targetLang string
+	prompt     string
+	structured bool
 }
 
 // OllamaRequest represents the request body for Ollama API
 type OllamaRequest struct {
-	Model   string `json:"model"`
-	Prompt  string `json:"prompt"`
-	Stream  bool   `json:"stream"`
+	Model   string          `json:"model"`
+	Prompt  string          `json:"prompt"`
+	Stream  bool            `json:"stream"`
+	Think   *bool           `json:"think,omitempty"`  // reasoning of models such as qwen3; older Ollama ignores it
+	Format  json.RawMessage `json:"format,omitempty"` // JSON schema the answer must follow
 	Options struct {
 		Temperature float64 `json:"temperature"`
 	} `json:"options,omitempty"`
@@ -45,7 +79,25 @@ type OllamaResponse struct {
 
 // VoiceContext represents recent transcription context for voice translation
 type VoiceContext struct {
-	ContextText string // Recent transcriptions from last 10 seconds
+	ContextText string        // recent transcriptions, one per line, oldest first
+	Window      time.Duration // how far back ContextText goes, 0 when unknown
+	Round       int           // current round, 0 when unknown
+	RoundOver   bool          // the round has ended and the next not started
+}
+
+// describe says where the context comes from, for the prompt
+func (c VoiceContext) describe() string {
+	when := "shortly before"
+	if c.Window > 0 {
+		when = fmt.Sprintf("last %d seconds", int(c.Window.Round(time.Second).Seconds()))
+	}
+	switch {
+	case c.Round > 0 && c.RoundOver:
+		return fmt.Sprintf("%s, after round %d ended", when, c.Round)
+	case c.Round > 0:
+		return fmt.Sprintf("%s of round %d", when, c.Round)
+	}
+	return when
 }
 
 // NewOllamaTranslator creates a new Ollama translator
@@ -61,12 +113,12 @@ func NewOllamaTranslator(ctx context.Context, model, targetLang string) (*Ollama
 	}
 
 	return &OllamaTranslator{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		baseURL:    baseURL,
-		model:      model,
-		targetLang: targetLang,
+		httpClient:     HTTPClient(),
+		requestTimeout: requestTimeout,
+		baseURL:        baseURL,
+		model:          model,
+		targetLang:     targetLang,
+		prompt:         DefaultPrompt,
 	}, nil
 }
 
@@ -78,102 +130,143 @@ func (t *OllamaTranslator) Translate(ctx context.Context, text string) (string,
 		return text, nil
 	}
 
-	// Build the translation prompt
-	prompt := fmt.Sprintf("Translate the following text to %s. Output ONLY the translation, nothing else:\n\n%s", t.targetLang, text)
-
-	reqBody := OllamaRequest{
-		Model:  t.model,
-		Prompt: prompt,
-		Stream: false,
-	}
-	reqBody.Options.Temperature = 0.3 // Low temperature for consistent translations
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/api/generate", t.baseURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := t.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
-	}
+	lang := LanguageName(t.TargetLang())
+	return t.generate(ctx, KindChat, t.buildPrompt(lang, text), text, lang, nil)
+}
 
-	var ollamaResp OllamaResponse
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %v", err)
-	}
+// TargetLang returns the language translations are made into
+func (t *OllamaTranslator) TargetLang() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.targetLang
+}
 
-	if ollamaResp.Error != "" {
-		return "", fmt.Errorf("ollama error: %s", ollamaResp.Error)
-	}
+// SetTargetLang switches the target language for subsequent translations
+func (t *OllamaTranslator) SetTargetLang(lang string) {
+	t.mu.Lock()
+	t.targetLang = lang
+	t.mu.Unlock()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, ollamaResp.Response)
-	}
+// SetPrompt replaces the prompt template used by Translate, see DefaultPrompt
+func (t *OllamaTranslator) SetPrompt(tmpl string) {
+	t.mu.Lock()
+	t.prompt = tmpl
+	t.mu.Unlock()
+}
 
-	translation := strings.TrimSpace(ollamaResp.Response)
-	if translation == "" {
-		return text, nil // Return original if translation is empty
-	}
+// SetStructured asks for answers in a JSON schema that also gives the
+// language of the message and the model's confidence, see Request.Detail
+func (t *OllamaTranslator) SetStructured(on bool) {
+	t.mu.Lock()
+	t.structured = on
+	t.mu.Unlock()
+}
 
-	return translation, nil
+// buildPrompt fills the prompt template for one message
+func (t *OllamaTranslator) buildPrompt(lang, text string) string {
+	t.mu.RLock()
+	tmpl := t.prompt
+	t.mu.RUnlock()
+	return strings.NewReplacer("{lang}", lang, "{text}", text).Replace(tmpl)
 }
 
-// TranslateWithContext translates text with additional context from recent transcriptions
-func (t *OllamaTranslator) TranslateWithContext(ctx context.Context, text string, context VoiceContext) (string, error) {
-	text = strings.TrimSpace(text)
+// TranslateWithContext translates a message with what is known about it:
+// recent speech, its kind and source language
+func (t *OllamaTranslator) TranslateWithContext(ctx context.Context, req Request) (string, error) {
+	text := strings.TrimSpace(req.Text)
 	if text == "" || len(text) < 2 {
 		return text, nil
 	}
 
 	// Build the translation prompt with context
-	var prompt string
-	if context.ContextText != "" {
-		prompt = fmt.Sprintf(`Context from recent speech (last 10 seconds):
-%s
+	targetLang := LanguageName(cmp.Or(req.TargetLang, t.TargetLang()))
+	prompt := buildContextPrompt(req.Context, targetLang, text)
+	if prompt == "" {
+		prompt = t.buildPrompt(targetLang, text)
+	}
+	if hint := req.hint(); hint != "" {
+		prompt = hint + "\n\n" + prompt
+	}
 
-Translate the following text to %s. Use the context above to understand the conversation topic and provide a more accurate translation. Output ONLY the translation, nothing else:
+	return t.generate(ctx, req.Kind, prompt, text, targetLang, req.Detail)
+}
 
-%s`, context.ContextText, t.targetLang, text)
-	} else {
-		prompt = fmt.Sprintf("Translate the following text to %s. Output ONLY the translation, nothing else:\n\n%s", t.targetLang, text)
+// generate sends the prompt to Ollama and returns the response cleaned up by
+// Sanitize, falling back to the original text when the model answers with
+// nothing. With structured output on, detail gets what the model reported.
+// The request waits for a slot of kind first.
+func (t *OllamaTranslator) generate(ctx context.Context, kind Kind, prompt, text, lang string, detail *Detail) (string, error) {
+	release, err := ollamaSlots.acquire(ctx, kind)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if t.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.requestTimeout)
+		defer cancel()
 	}
 
+	think := false // reasoning costs seconds and does not help a translation
 	reqBody := OllamaRequest{
 		Model:  t.model,
 		Prompt: prompt,
 		Stream: false,
+		Think:  &think,
+	}
+	reqBody.Options.Temperature = 0.3 // Low temperature for consistent translations
+	t.mu.RLock()
+	structured := t.structured
+	t.mu.RUnlock()
+	if structured {
+		reqBody.Prompt = structuredInstruction + "\n\n" + prompt
+		reqBody.Format = structuredFormat
+	}
+
+	answer, err := ollamaGenerate(ctx, t.httpClient, t.baseURL, reqBody)
+	if err != nil {
+		return "", err
+	}
+	if structured {
+		if a, d, ok := parseStructured(answer); ok {
+			answer = a.Translation
+			if detail != nil {
+				*detail = d
+			}
+		} else {
+			slog.Debug("Model ignored the JSON format; using its answer as text", "answer", answer)
+		}
 	}
-	reqBody.Options.Temperature = 0.3
+	translation := Sanitize(answer, text, lang)
+	if translation != strings.TrimSpace(answer) {
+		slog.Debug("Cleaned up the model's answer", "answer", answer, "translation", translation)
+	}
+	if translation == "" {
+		return text, nil // Return original if translation is empty
+	}
+
+	return translation, nil
+}
 
+// ollamaGenerate sends one request to Ollama's /api/generate and returns
+// the answer as it is
+func ollamaGenerate(ctx context.Context, client *http.Client, baseURL string, reqBody OllamaRequest) (string, error) {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	url := fmt.Sprintf("%s/api/generate", t.baseURL)
+	url := fmt.Sprintf("%s/api/generate", baseURL)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := t.httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
+		return "", fmt.Errorf("%w: failed to send request: %v", ErrOllamaUnavailable, err)
 	}
 	defer resp.Body.Close()
 
@@ -187,6 +280,10 @@ Translate the following text to %s. Use the context above to understand the conv
 		return "", fmt.Errorf("failed to parse response: %v", err)
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrModelNotFound, ollamaResp.Error)
+	}
+
 	if ollamaResp.Error != "" {
 		return "", fmt.Errorf("ollama error: %s", ollamaResp.Error)
 	}
@@ -195,17 +292,61 @@ Translate the following text to %s. Use the context above to understand the conv
 		return "", fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, ollamaResp.Response)
 	}
 
-	translation := strings.TrimSpace(ollamaResp.Response)
-	if translation == "" {
-		return text, nil
+	return ollamaResp.Response, nil
+}
+
+// ollamaModel is an Ollama model used for something other than translating,
+// such as tagging or summarizing messages
+type ollamaModel struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+	local      bool // private text may be sent to it
+}
+
+func newOllamaModel(backend BackendConfig) ollamaModel {
+	return ollamaModel{
+		httpClient: HTTPClient(),
+		baseURL:    OllamaHost,
+		model:      cmp.Or(backend.Model, DefaultOllamaModel),
+		local:      backend.local(),
 	}
+}
 
-	return translation, nil
+// ask sends prompt, constraining the answer to format when it is set, and
+// returns the answer within timeout (0: no limit). Private prompts are only
+// sent to a local Ollama, otherwise the error is ErrNoLocalBackend.
+func (m ollamaModel) ask(ctx context.Context, prompt string, format json.RawMessage, private bool, timeout time.Duration) (string, error) {
+	if private && !m.local {
+		return "", ErrNoLocalBackend
+	}
+	release, err := ollamaSlots.acquire(ctx, kindBackground)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	think := false
+	return ollamaGenerate(ctx, m.httpClient, m.baseURL, OllamaRequest{
+		Model:  m.model,
+		Prompt: prompt,
+		Think:  &think,
+		Format: format,
+	})
 }
 
 // Close cleans up resources and unloads the model
 func (t *OllamaTranslator) Close() error {
-	// Unload the model from memory
+	return t.Unload()
+}
+
+// Unload frees the model's memory in Ollama. The translator stays usable;
+// the next translation loads the model again.
+func (t *OllamaTranslator) Unload() error {
 	url := fmt.Sprintf("%s/api/generate", t.baseURL)
 	reqBody := map[string]interface{}{
 		"model":      t.model,
@@ -219,14 +360,16 @@ func (t *OllamaTranslator) Close() error {
 		return fmt.Errorf("failed to marshal unload request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create unload request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := t.httpClient.Do(req)
 	if err != nil {
 		// Don't fail if model is already unloaded or server is down
 		return nil
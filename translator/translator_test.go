@@ -0,0 +1,65 @@
+package translator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// testTimeout bounds the wait for an answer
+const testTimeout = 10 * time.Second
+
+// newOllama starts a mock Ollama and a translator talking to it, both
+// closed when the test ends
+func newOllama(t *testing.T) (*fakegame.Ollama, *translator.OllamaTranslator) {
+	t.Helper()
+	o := fakegame.NewOllama()
+	t.Cleanup(o.Close)
+	translator.OllamaHost = o.URL
+	tr, err := translator.NewOllamaTranslator(t.Context(), "fake", "English")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tr.Close() })
+	return o, tr
+}
+
+// Reasoning, labels, quotes and notes a chatty model adds are cut from its
+// answers, and translations ask it not to think
+func TestOllamaSanitize(t *testing.T) {
+	ctx := t.Context()
+	o, tr := newOllama(t)
+
+	answers := []struct{ text, answer, want string }{
+		{"привет", "<think>\nThe user greets.\n</think>\n\nHello", "Hello"},
+		{"привет", "<think>\n\n</think>\n\n\"Hello\"", "Hello"},
+		{"привет", "The user greets.</think>Hello", "Hello"},
+		{"привет", "<think>The user greets, so", "привет"}, // cut off while thinking
+		{"привет", "Translation: Hello", "Hello"},
+		{"привет", "**Translation:** Hello", "Hello"},
+		{"привет", "Sure! Here is the translation:\n\nHello", "Hello"},
+		{"привет", "English: Hello", "Hello"},
+		{"привет", "«Hello»", "Hello"},
+		{"привет", "```\nHello\n```", "Hello"},
+		{"привет", "Hello\n\nNote: привет is an informal greeting.", "Hello"},
+		{"привет", "Hello (literally: greetings)", "Hello"},
+		{`"привет"`, `"Hello"`, `"Hello"`},
+		{"он сказал 'го'", "He said 'go'", "He said 'go'"},
+		{"раз\nдва", "One\nTwo", "One\nTwo"},
+	}
+	for _, a := range answers {
+		o.SetAnswer(func(string) string { return a.answer })
+		got, err := tr.Translate(ctx, a.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != a.want {
+			t.Fatalf("answer %q came out as %q, want %q", a.answer, got, a.want)
+		}
+	}
+	if o.Thinking() {
+		t.Fatalf("translations were asked for with thinking on")
+	}
+}
@@ -0,0 +1,167 @@
+// Package tts speaks text with the platform's speech synthesizer, optionally
+// on a chosen output device such as a virtual audio cable that other apps
+// use as a microphone.
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/execwrap"
+)
+
+// ErrUnavailable is returned when no speech synthesizer is installed
+var ErrUnavailable = errors.New("no text-to-speech engine found")
+
+// Speak says text in lang, an ISO 639-1 code or empty for the default voice,
+// and returns once it has been spoken. device names the output; empty uses
+// the default output.
+func Speak(ctx context.Context, text, lang, device string) error {
+	switch runtime.GOOS {
+	case "linux":
+		return speakLinux(ctx, text, lang, device)
+	case "darwin":
+		args := []string{}
+		if device != "" {
+			args = append(args, "-a", device)
+		}
+		return run(execwrap.CommandContext(ctx, "say", append(args, text)...))
+	case "windows":
+		if device != "" {
+			return fmt.Errorf("choosing a TTS device is not supported on Windows; make the virtual cable the default playback device instead")
+		}
+		return speakWindows(ctx, text, lang, "")
+	}
+	return ErrUnavailable
+}
+
+// Render writes text spoken in lang to the WAV file path instead of playing
+// it, e.g. to test speech recognition. Unlike Speak it fails when no voice
+// speaks lang.
+func Render(ctx context.Context, text, lang, path string) error {
+	switch runtime.GOOS {
+	case "linux":
+		engine, err := espeak()
+		if err != nil {
+			return err
+		}
+		args := []string{"-w", path}
+		if lang != "" {
+			args = append(args, "-v", lang)
+		}
+		return run(execwrap.CommandContext(ctx, engine, append(args, text)...))
+	case "darwin":
+		args := []string{"-o", path, "--file-format=WAVE", "--data-format=LEI16@22050"}
+		if lang != "" {
+			voice, err := macVoice(ctx, lang)
+			if err != nil {
+				return err
+			}
+			args = append(args, "-v", voice)
+		}
+		return run(execwrap.CommandContext(ctx, "say", append(args, text)...))
+	case "windows":
+		return speakWindows(ctx, text, lang, path)
+	}
+	return ErrUnavailable
+}
+
+// speakLinux uses espeak-ng (or espeak) and pipes through paplay when a
+// PulseAudio/PipeWire sink is chosen
+func speakLinux(ctx context.Context, text, lang, device string) error {
+	engine, err := espeak()
+	if err != nil {
+		return err
+	}
+	args := []string{}
+	if lang != "" {
+		args = append(args, "-v", lang)
+	}
+	if device == "" {
+		return run(execwrap.CommandContext(ctx, engine, append(args, text)...))
+	}
+
+	speak := execwrap.CommandContext(ctx, engine, append(args, "--stdout", text)...)
+	play := execwrap.CommandContext(ctx, "paplay", "--device="+device)
+	pipe, err := speak.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	play.Stdin = pipe
+	if err := speak.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", engine, err)
+	}
+	playErr := run(play)
+	pipe.Close() // lets espeak exit if paplay failed early
+	if err := speak.Wait(); err != nil {
+		return fmt.Errorf("%s: %v", engine, err)
+	}
+	return playErr
+}
+
+// espeak finds espeak-ng, or the older espeak
+func espeak() (string, error) {
+	engine, err := execwrap.LookPath("espeak-ng")
+	if err != nil {
+		if engine, err = execwrap.LookPath("espeak"); err != nil {
+			return "", fmt.Errorf("%w: install espeak-ng", ErrUnavailable)
+		}
+	}
+	return engine, nil
+}
+
+// macVoice picks the first voice of lang from say -v '?', whose lines read
+// "Anna    de_DE    # Hallo, ich heiße Anna."
+func macVoice(ctx context.Context, lang string) (string, error) {
+	out, err := execwrap.CommandContext(ctx, "say", "-v", "?").Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		name, _, ok := strings.Cut(line, "#")
+		fields := strings.Fields(name)
+		if !ok || len(fields) < 2 {
+			continue
+		}
+		if strings.HasPrefix(fields[len(fields)-1], lang+"_") {
+			return strings.Join(fields[:len(fields)-1], " "), nil
+		}
+	}
+	return "", fmt.Errorf("no voice for %s installed", lang)
+}
+
+// speakWindows uses the built-in System.Speech synthesizer, which always
+// plays on the default output device, or writes to the WAV file out
+func speakWindows(ctx context.Context, text, lang, out string) error {
+	script := `Add-Type -AssemblyName System.Speech
+$s = New-Object System.Speech.Synthesis.SpeechSynthesizer
+$lang = $env:CS_TRANSLATE_TTS_LANG
+$out = $env:CS_TRANSLATE_TTS_OUT
+if ($lang) {
+  $v = $s.GetInstalledVoices() | Where-Object { $_.VoiceInfo.Culture.TwoLetterISOLanguageName -eq $lang } | Select-Object -First 1
+  if ($v) { $s.SelectVoice($v.VoiceInfo.Name) }
+  elseif ($out) { [Console]::Error.WriteLine("no voice for $lang installed"); exit 1 }
+}
+if ($out) { $s.SetOutputToWaveFile($out) }
+$s.Speak([Console]::In.ReadToEnd())
+$s.Dispose()`
+	cmd := execwrap.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.Env = append(cmd.Environ(), "CS_TRANSLATE_TTS_LANG="+lang, "CS_TRANSLATE_TTS_OUT="+out)
+	cmd.Stdin = strings.NewReader(text)
+	return run(cmd)
+}
+
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %v: %s", cmd.Args[0], err, msg)
+		}
+		return fmt.Errorf("%s: %v", cmd.Args[0], err)
+	}
+	return nil
+}
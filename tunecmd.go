@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/bench"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+const (
+	// tuneSettle is the wait the worker adds before reading a segment
+	tuneSettle = 100 * time.Millisecond
+	// tuneProbeTimeout bounds a single probe transcription
+	tuneProbeTimeout = 2 * time.Minute
+)
+
+// tuneSetup is one combination of settings and its measured latency
+type tuneSetup struct {
+	whisper string
+	rank    int // larger Whisper models are more accurate, see whisperRank
+	segment time.Duration
+	model   string
+	chrF    float64
+	latency time.Duration // estimated p90 from end of speech to translation
+}
+
+// runTuneCommand measures voice latency for combinations of Whisper model,
+// segment length and translation model and recommends the most accurate one
+// that fits the target
+func runTuneCommand(args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Warning: %v (using defaults)\n", err)
+	}
+	cfg.ApplyEnv()
+	translator.Configure(cfg.HTTPSettings())
+
+	fs := flag.NewFlagSet("tune", flag.ExitOnError)
+	target := fs.Duration("target", 2*time.Second, "Latency to stay under, from the end of speech to the translation")
+	whisperList := fs.String("whisper-models", "tiny,base,small", "Comma-separated Whisper models to try")
+	segmentList := fs.String("segments", "1s,2s,3s", "Comma-separated live segment lengths to try")
+	modelList := fs.String("models", cfg.Model, "Comma-separated Ollama models to try")
+	runs := fs.Int("runs", 3, "Transcriptions measured per Whisper model and segment length")
+	apply := fs.Bool("apply", false, "Save the recommended settings to the settings file")
+	fs.Parse(args)
+
+	whispers := withCurrent(splitList(*whisperList), cfg.Whisper.Model)
+	models := withCurrent(splitList(*modelList), cfg.Model)
+	var segments []time.Duration
+	for _, s := range splitList(*segmentList) {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			fmt.Printf("Error: invalid segment length %q\n", s)
+			os.Exit(1)
+		}
+		segments = append(segments, d)
+	}
+	current := time.Duration(cfg.Whisper.Segment)
+	if !containsDuration(segments, current) {
+		segments = append(segments, current)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+
+	if err := ensureEnvironment(bufio.NewScanner(os.Stdin), cfg.Model, true); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Whisper: p90 time to transcribe a segment of each length
+	transcribe := map[string]map[time.Duration]time.Duration{}
+	for _, w := range whispers {
+		fmt.Printf("Measuring Whisper %s...\n", w)
+		times, err := measureWhisper(ctx, cfg, w, segments, *runs)
+		if err != nil {
+			fmt.Printf("  skipped: %v\n", err)
+			continue
+		}
+		transcribe[w] = times
+	}
+	if ctx.Err() != nil {
+		os.Exit(1)
+	}
+
+	// Translation: p90 latency and quality on the bench set
+	samples, err := bench.LoadSamples("")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	prompt := cfg.Prompt
+	if prompt == "" {
+		prompt = translator.DefaultPrompt
+	}
+	translation := map[string]bench.Result{}
+	for _, m := range models {
+		fmt.Printf("Measuring translation with %s...\n", m)
+		tr, err := translator.NewOllamaTranslator(ctx, m, cfg.Lang)
+		if err != nil {
+			fmt.Printf("  skipped: %v\n", err)
+			continue
+		}
+		res := bench.Run(ctx, tr, []bench.Variant{{Name: m, Prompt: prompt}}, samples, 1, nil)[0]
+		tr.Close()
+		if res.Errors > 0 {
+			fmt.Printf("  skipped: %d of %d translations failed\n", res.Errors, len(res.Outputs))
+			continue
+		}
+		translation[m] = res
+	}
+
+	var setups []tuneSetup
+	for _, w := range whispers {
+		for seg, took := range transcribe[w] {
+			for _, m := range models {
+				res, ok := translation[m]
+				if !ok {
+					continue
+				}
+				setups = append(setups, tuneSetup{
+					whisper: w, rank: whisperRank(w), segment: seg, model: m, chrF: res.ChrF,
+					latency: seg + tuneSettle + took + res.Percentile(0.9),
+				})
+			}
+		}
+	}
+	if len(setups) == 0 {
+		fmt.Println("Nothing could be measured; run 'cs-translate doctor' to check the setup.")
+		os.Exit(1)
+	}
+	sort.Slice(setups, func(i, j int) bool { return setups[i].latency < setups[j].latency })
+
+	fmt.Printf("\n  %-10s %8s %-24s %8s %10s\n", "whisper", "segment", "model", "chrF", "latency")
+	for _, s := range setups {
+		mark := " "
+		if s.whisper == cfg.Whisper.Model && s.segment == current && s.model == cfg.Model {
+			mark = "*"
+		}
+		fmt.Printf("%s %-10s %8s %-24s %8.1f %10s\n", mark, s.whisper, s.segment, s.model, s.chrF, s.latency.Round(10*time.Millisecond))
+	}
+	fmt.Println("(* current settings; latency is the estimated p90 from the end of speech to the translation)")
+
+	best, ok := recommendSetup(setups, *target)
+	if !ok {
+		fmt.Printf("\nNo combination stays under %s; the fastest is shown below.\n", *target)
+	}
+	fmt.Printf("\nRecommended: -whisper-model %s -whisper-segment %s -model %s (about %s)\n",
+		best.whisper, best.segment, best.model, best.latency.Round(10*time.Millisecond))
+
+	if !*apply {
+		fmt.Println("Run 'cs-translate tune -apply' to save these settings.")
+		return
+	}
+	// Read the file again so the flags of this command are not saved
+	saved, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	saved.Whisper.Model = best.whisper
+	saved.Whisper.Segment = config.Duration(best.segment)
+	saved.Model = best.model
+	if err := config.Save(saved); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	path, _ := config.Path()
+	fmt.Printf("Saved to %s.\n", path)
+}
+
+// recommendSetup picks the most accurate setup under target: the largest
+// Whisper model, then the best translation, then the longest segment. When
+// none fits it returns the fastest and false.
+func recommendSetup(setups []tuneSetup, target time.Duration) (tuneSetup, bool) {
+	var best tuneSetup
+	found := false
+	for _, s := range setups {
+		if s.latency > target {
+			continue
+		}
+		if !found || betterSetup(s, best) {
+			best, found = s, true
+		}
+	}
+	if !found {
+		fastest := setups[0]
+		for _, s := range setups {
+			if s.latency < fastest.latency {
+				fastest = s
+			}
+		}
+		return fastest, false
+	}
+	return best, true
+}
+
+func betterSetup(a, b tuneSetup) bool {
+	if a.rank != b.rank {
+		return a.rank > b.rank
+	}
+	if a.chrF != b.chrF {
+		return a.chrF > b.chrF
+	}
+	return a.segment > b.segment
+}
+
+// measureWhisper starts a transcriber with model and returns the p90 time it
+// takes for a segment of each length
+func measureWhisper(ctx context.Context, cfg config.Config, model string, segments []time.Duration, runs int) (map[time.Duration]time.Duration, error) {
+	opts := cfg.WhisperSettings(false)
+	opts.Model = model
+	listener := initAudioListener(true, opts)
+	if listener == nil {
+		return nil, fmt.Errorf("transcriber did not start")
+	}
+	defer listener.Stop()
+
+	// The first transcription warms the model up and is not counted
+	if _, err := probeWhisper(ctx, listener, segments[0]); err != nil {
+		return nil, err
+	}
+	times := map[time.Duration]time.Duration{}
+	for _, seg := range segments {
+		var took []time.Duration
+		for i := 0; i < runs; i++ {
+			d, err := probeWhisper(ctx, listener, seg)
+			if err != nil {
+				return nil, err
+			}
+			took = append(took, d)
+		}
+		times[seg] = bench.Percentile(took, 0.9)
+		fmt.Printf("  %s segments: %s\n", seg, times[seg].Round(10*time.Millisecond))
+	}
+	return times, nil
+}
+
+// probeWhisper transcribes a synthetic segment of length d
+func probeWhisper(ctx context.Context, listener *audio.Listener, d time.Duration) (time.Duration, error) {
+	path := filepath.Join(listener.OutputDir(), fmt.Sprintf("tune_%d.wav", time.Now().UnixNano()))
+	if err := writeSyntheticWAV(path, d); err != nil {
+		return 0, err
+	}
+	listener.SubmitProbe(path)
+	select {
+	case t, ok := <-listener.Transcriptions():
+		if !ok {
+			return 0, fmt.Errorf("transcriber stopped")
+		}
+		return t.Elapsed, nil
+	case <-time.After(tuneProbeTimeout):
+		return 0, fmt.Errorf("no transcription within %s", tuneProbeTimeout)
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// whisperSizes are Whisper model families, smallest and least accurate first
+var whisperSizes = []string{"tiny", "base", "small", "medium", "turbo", "large"}
+
+// whisperRank orders models by size; "small.en" ranks as small
+func whisperRank(model string) int {
+	for i, size := range whisperSizes {
+		if strings.HasPrefix(model, size) {
+			return i
+		}
+	}
+	return -1
+}
+
+func withCurrent(list []string, current string) []string {
+	for _, v := range list {
+		if v == current {
+			return list
+		}
+	}
+	return append(list, current)
+}
+
+func containsDuration(list []time.Duration, d time.Duration) bool {
+	for _, v := range list {
+		if v == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,392 @@
+// Package twitch posts to the chat of a Twitch channel over IRC, paced
+// below Twitch's rate limit, and lets the broadcaster and moderators pause
+// and resume it with a chat command.
+package twitch
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenEnv holds the OAuth token of the account that posts. It is never
+// read from the settings file, so it cannot leak through /api/config.
+const TokenEnv = "CS_TRANSLATE_TWITCH_TOKEN"
+
+// DefaultAddr is Twitch's IRC server, spoken to over TLS
+const DefaultAddr = "irc.chat.twitch.tv:6697"
+
+// Twitch allows 20 messages per 30 seconds, 100 for moderators and the
+// broadcaster
+const (
+	DefaultRate   = 20
+	DefaultWindow = 30 * time.Second
+)
+
+const (
+	// maxMessage is the longest message Twitch accepts, in characters
+	maxMessage = 500
+	// maxQueue bounds messages waiting for the rate limit; older ones are
+	// dropped, they are stale by then anyway
+	maxQueue = 20
+	// loginTimeout bounds connecting and logging in
+	loginTimeout = 10 * time.Second
+	// retryDelay is the wait before reconnecting
+	retryDelay = 5 * time.Second
+)
+
+// ErrAuth is returned when Twitch refuses the login
+var ErrAuth = errors.New("Twitch refused the login; check the nick and " + TokenEnv)
+
+// Config selects the channel to post to
+type Config struct {
+	Addr    string // IRC server (empty: DefaultAddr)
+	TLS     bool   // connect with TLS, as DefaultAddr needs
+	Nick    string // account that posts (empty: Channel)
+	Token   string // OAuth token of Nick, with or without "oauth:"
+	Channel string
+	Rate    int           // at most this many messages per Window (0: DefaultRate)
+	Window  time.Duration // (0: DefaultWindow)
+	Command string        // chat command that pauses and resumes the relay, e.g. "!translate" (empty: none)
+}
+
+// Relay posts messages to a channel's chat, reconnecting when the
+// connection drops. All methods are safe for concurrent use.
+type Relay struct {
+	cfg    Config
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	wake   chan struct{}
+
+	mu      sync.Mutex
+	on      bool
+	queue   []string
+	sent    []time.Time // when the messages of the current window went out
+	conn    net.Conn
+	changed func(on bool)
+}
+
+// Start connects to the chat of cfg.Channel in the background. changed, if
+// set, is called when the chat command pauses or resumes the relay.
+func Start(cfg Config, changed func(on bool)) *Relay {
+	cfg.Channel = strings.ToLower(strings.TrimPrefix(cfg.Channel, "#"))
+	if cfg.Addr == "" {
+		cfg.Addr, cfg.TLS = DefaultAddr, true
+	}
+	if cfg.Nick == "" {
+		cfg.Nick = cfg.Channel
+	}
+	cfg.Nick = strings.ToLower(cfg.Nick)
+	if cfg.Token != "" && !strings.HasPrefix(cfg.Token, "oauth:") {
+		cfg.Token = "oauth:" + cfg.Token
+	}
+	if cfg.Rate <= 0 {
+		cfg.Rate = DefaultRate
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Relay{
+		cfg: cfg, ctx: ctx, cancel: cancel,
+		done: make(chan struct{}), wake: make(chan struct{}, 1),
+		on: true, changed: changed,
+	}
+	go r.run()
+	return r
+}
+
+// Say queues text for the chat; false when the relay is paused. Text longer
+// than Twitch allows is cut.
+func (r *Relay) Say(text string) bool {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxMessage {
+		text = string(runes[:maxMessage-1]) + "…"
+	}
+	r.mu.Lock()
+	if !r.on || text == "" {
+		r.mu.Unlock()
+		return false
+	}
+	r.queue = append(r.queue, text)
+	if len(r.queue) > maxQueue {
+		r.queue = r.queue[len(r.queue)-maxQueue:]
+	}
+	r.mu.Unlock()
+	r.poke()
+	return true
+}
+
+// SetEnabled pauses or resumes the relay; messages waiting are dropped on
+// pause
+func (r *Relay) SetEnabled(on bool) {
+	r.mu.Lock()
+	r.on = on
+	if !on {
+		r.queue = nil
+	}
+	r.mu.Unlock()
+}
+
+// Enabled reports whether the relay posts messages
+func (r *Relay) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.on
+}
+
+// Close disconnects; messages still waiting are dropped
+func (r *Relay) Close() error {
+	r.cancel()
+	r.mu.Lock()
+	if r.conn != nil {
+		r.conn.Close()
+	}
+	r.mu.Unlock()
+	<-r.done
+	return nil
+}
+
+func (r *Relay) poke() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run keeps a connection up and posts the queue until Close
+func (r *Relay) run() {
+	defer close(r.done)
+	warned := false
+	for r.ctx.Err() == nil {
+		conn, lines, err := r.connect()
+		if errors.Is(err, ErrAuth) {
+			slog.Warn("Not posting to Twitch chat", "err", err)
+			return
+		}
+		if err != nil {
+			if !warned && r.ctx.Err() == nil {
+				slog.Warn("Twitch chat not reachable; trying again", "err", err)
+				warned = true
+			}
+		} else {
+			warned = false
+			slog.Info("Posting translations to Twitch chat", "channel", r.cfg.Channel)
+			r.session(conn, lines)
+		}
+		select {
+		case <-r.ctx.Done():
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// connect logs in and joins the channel. lines delivers what the server
+// sends from then on and is closed when the connection ends.
+func (r *Relay) connect() (net.Conn, <-chan string, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, loginTimeout)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	if r.cfg.TLS {
+		conn, err = (&tls.Dialer{}).DialContext(ctx, "tcp", r.cfg.Addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", r.cfg.Addr)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", r.cfg.Addr, err)
+	}
+	r.mu.Lock()
+	r.conn = conn
+	r.mu.Unlock()
+
+	lines := make(chan string, 16)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	conn.SetWriteDeadline(time.Now().Add(loginTimeout))
+	fmt.Fprintf(conn, "CAP REQ :twitch.tv/tags twitch.tv/commands\r\nPASS %s\r\nNICK %s\r\n", r.cfg.Token, r.cfg.Nick)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				conn.Close()
+				return nil, nil, fmt.Errorf("Twitch closed the connection while logging in")
+			}
+			m := parseLine(line)
+			switch m.command {
+			case "001":
+				fmt.Fprintf(conn, "JOIN #%s\r\n", r.cfg.Channel)
+				return conn, lines, nil
+			case "NOTICE":
+				if strings.Contains(m.text, "authentication failed") || strings.Contains(m.text, "Improperly formatted auth") {
+					closeConn(conn, lines)
+					return nil, nil, ErrAuth
+				}
+			case "PING":
+				fmt.Fprintf(conn, "PONG :%s\r\n", m.text)
+			}
+		case <-ctx.Done():
+			closeConn(conn, lines)
+			return nil, nil, fmt.Errorf("failed to log in to Twitch chat: %w", ctx.Err())
+		}
+	}
+}
+
+// closeConn closes conn and lets its reader finish
+func closeConn(conn net.Conn, lines <-chan string) {
+	conn.Close()
+	go func() {
+		for range lines {
+		}
+	}()
+}
+
+// session posts the queue and answers the server until the connection ends
+func (r *Relay) session(conn net.Conn, lines <-chan string) {
+	defer closeConn(conn, lines)
+	for {
+		wait := r.post(conn)
+		var next <-chan time.Time
+		if wait > 0 {
+			next = time.After(wait)
+		}
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.wake:
+		case <-next:
+		case line, ok := <-lines:
+			if !ok {
+				slog.Info("Lost connection to Twitch chat")
+				return
+			}
+			if !r.handle(conn, parseLine(line)) {
+				return
+			}
+		}
+	}
+}
+
+// post sends queued messages while the rate allows and returns how long to
+// wait for the next one; 0 when the queue is empty
+func (r *Relay) post(conn net.Conn) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.queue) > 0 {
+		now := time.Now()
+		for len(r.sent) > 0 && now.Sub(r.sent[0]) >= r.cfg.Window {
+			r.sent = r.sent[1:]
+		}
+		if len(r.sent) >= r.cfg.Rate {
+			return r.cfg.Window - now.Sub(r.sent[0])
+		}
+		conn.SetWriteDeadline(now.Add(loginTimeout))
+		if _, err := fmt.Fprintf(conn, "PRIVMSG #%s :%s\r\n", r.cfg.Channel, r.queue[0]); err != nil {
+			return 0 // the reader sees the connection end
+		}
+		r.queue = r.queue[1:]
+		r.sent = append(r.sent, now)
+	}
+	return 0
+}
+
+// handle answers one server line; false when the server asks to reconnect
+func (r *Relay) handle(conn net.Conn, m line) bool {
+	switch m.command {
+	case "PING":
+		fmt.Fprintf(conn, "PONG :%s\r\n", m.text)
+	case "RECONNECT":
+		return false
+	case "PRIVMSG":
+		r.command(m)
+	}
+	return true
+}
+
+// command pauses or resumes the relay when the broadcaster or a moderator
+// types the chat command with on or off, and confirms it in chat
+func (r *Relay) command(m line) {
+	cmd, arg, _ := strings.Cut(strings.TrimSpace(m.text), " ")
+	if r.cfg.Command == "" || !strings.EqualFold(cmd, r.cfg.Command) || !m.privileged() {
+		return
+	}
+	var on bool
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		on = !r.Enabled()
+	}
+	r.SetEnabled(on)
+	if r.changed != nil {
+		r.changed(on)
+	}
+	// Said after resuming, or forced through while paused
+	reply := fmt.Sprintf("Translations paused; %s on resumes them", r.cfg.Command)
+	if on {
+		reply = "Translations resumed"
+	}
+	r.mu.Lock()
+	r.queue = append(r.queue, reply)
+	r.mu.Unlock()
+	r.poke()
+}
+
+// line is one IRC message, the parts the relay needs of it
+type line struct {
+	tags    map[string]string
+	user    string
+	command string
+	text    string // the trailing parameter
+}
+
+// privileged reports whether the sender is the broadcaster or a moderator
+func (m line) privileged() bool {
+	if m.tags["mod"] == "1" {
+		return true
+	}
+	for _, badge := range strings.Split(m.tags["badges"], ",") {
+		name, _, _ := strings.Cut(badge, "/")
+		if name == "broadcaster" || name == "moderator" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLine splits "@tags :prefix COMMAND params :text"
+func parseLine(s string) line {
+	var m line
+	if strings.HasPrefix(s, "@") {
+		var tags string
+		tags, s, _ = strings.Cut(s[1:], " ")
+		m.tags = map[string]string{}
+		for _, tag := range strings.Split(tags, ";") {
+			k, v, _ := strings.Cut(tag, "=")
+			m.tags[k] = v
+		}
+	}
+	if strings.HasPrefix(s, ":") {
+		var prefix string
+		prefix, s, _ = strings.Cut(s[1:], " ")
+		m.user, _, _ = strings.Cut(prefix, "!")
+	}
+	s, m.text, _ = strings.Cut(s, " :")
+	m.command, _, _ = strings.Cut(s, " ")
+	return m
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/output"
+	"github.com/micha/cs-ingame-translate/twitch"
+)
+
+// twitchCommand pauses and resumes the Twitch relay from the terminal
+const twitchCommand = "/twitch"
+
+// twitchRelay is -twitch: it posts translations to the channel's chat; nil
+// when off
+var twitchRelay *twitch.Relay
+
+// startTwitch posts translations to the chat cfg names, all-chat only
+// unless team is set, and returns a function that disconnects
+func startTwitch(cfg twitch.Config, team bool) (stop func()) {
+	twitchRelay = twitch.Start(cfg, func(on bool) {
+		if on {
+			bus.Publish(events.Status{Source: "twitch", State: "resumed", Message: "relay resumed from chat"})
+		} else {
+			bus.Publish(events.Status{Source: "twitch", State: "paused", Message: "relay paused from chat"})
+		}
+	})
+	closeSink := output.Subscribe(bus, "twitch", output.Func(func(e events.Event) {
+		if t, ok := e.(events.TranslationDone); ok {
+			if text, ok := relayText(t, team); ok {
+				twitchRelay.Say(text)
+			}
+		}
+	}))
+	return func() {
+		closeSink()
+		twitchRelay.Close()
+		twitchRelay = nil
+	}
+}
+
+// relayText is the chat message for t, e.g. "[ALL] ivan: hi"; false for
+// what viewers should not get: team chat and voice unless team is set,
+// messages shown untranslated and hidden toxic chat
+func relayText(t events.TranslationDone, team bool) (string, bool) {
+	if !forwarded(t) || hidden(t) || t.Translated == "" || t.Translated == t.Original {
+		return "", false
+	}
+	label := "[voice]"
+	if t.Source == "chat" {
+		if teamChat(events.ChatReceived{Team: t.Team}) && !team {
+			return "", false
+		}
+		label = "[" + t.Team + "]"
+	} else if !team {
+		return "", false
+	}
+	if t.Player != "" {
+		label += " " + t.Player + ":"
+	}
+	return label + " " + t.Translated, true
+}
+
+// handleTwitchCommand handles /twitch [on|off]: false for other lines
+func handleTwitchCommand(line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	if cmd != twitchCommand {
+		return false
+	}
+	if twitchRelay == nil {
+		fmt.Println("The Twitch relay is off; start with -twitch <channel>.")
+		return true
+	}
+	on := !twitchRelay.Enabled()
+	switch strings.TrimSpace(arg) {
+	case "on":
+		on = true
+	case "off":
+		on = false
+	}
+	twitchRelay.SetEnabled(on)
+	if on {
+		fmt.Println("Posting translations to Twitch chat.")
+	} else {
+		fmt.Println("Twitch relay paused; /twitch on resumes it.")
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/twitch"
+)
+
+// Translated all-chat is posted to a mock Twitch chat, paced to the rate
+// limit; team chat, voice and untranslated lines are not, and the
+// broadcaster pauses the relay with the chat command while a viewer cannot
+func TestTwitchRelay(t *testing.T) {
+	fake, err := fakegame.NewTwitch("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	const window = 300 * time.Millisecond
+	stop := startTwitch(twitch.Config{Addr: fake.Addr, Token: "secret", Channel: "streamer", Rate: 2, Window: window, Command: "!translate"}, false)
+	defer stop()
+	select {
+	case <-fake.Joined():
+	case <-time.After(testTimeout):
+		t.Fatal("cs-translate did not join the Twitch chat")
+	}
+
+	// posted waits for n posts
+	posted := func(n int) ([]fakegame.Post, error) {
+		deadline := time.Now().Add(testTimeout)
+		for {
+			posts := fake.Posts()
+			if len(posts) >= n {
+				return posts, nil
+			}
+			if time.Now().After(deadline) {
+				return posts, fmt.Errorf("%d posts in Twitch chat, want %d", len(posts), n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	for _, td := range []events.TranslationDone{
+		{Source: "chat", Team: "ALL", Player: "ivan", Original: "привет", Translated: "hi"},
+		{Source: "chat", Team: "CT", Player: "bob", Original: "раш б", Translated: "rush B"},
+		{Source: "voice", Player: "bob", Original: "один остался", Translated: "one left"},
+		{Source: "chat", Team: "ALL", Player: "olga", Original: "gg", Translated: "gg"},
+		{Source: "chat", Team: "ALL", Player: "olga", Original: "давай", Translated: "come on"},
+		{Source: "chat", Team: "ALL", Player: "ivan", Original: "ещё", Translated: "more"},
+	} {
+		bus.Publish(td)
+	}
+	posts, err := posted(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, p := range posts {
+		texts = append(texts, p.Text)
+	}
+	if want := []string{"[ALL] ivan: hi", "[ALL] olga: come on", "[ALL] ivan: more"}; !slices.Equal(texts, want) {
+		t.Fatalf("posted %q, want %q", texts, want)
+	}
+	if gap := posts[2].At.Sub(posts[0].At); gap < window*9/10 {
+		t.Fatalf("third post %v after the first, want it held back for the rate limit of 2 per %v", gap, window)
+	}
+
+	fake.Chat("viewer", "", "!translate off")
+	fake.Chat("streamer", "broadcaster/1", "!translate off")
+	if posts, err = posted(4); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Translations paused; !translate on resumes them"; posts[3].Text != want {
+		t.Fatalf("relay answered %q, want %q", posts[3].Text, want)
+	}
+	if twitchRelay.Enabled() {
+		t.Fatal("relay still on after the broadcaster paused it")
+	}
+	bus.Publish(events.TranslationDone{Source: "chat", Team: "ALL", Player: "ivan", Original: "пока", Translated: "bye"})
+	time.Sleep(2 * window)
+	if posts = fake.Posts(); len(posts) != 4 {
+		t.Fatalf("posted %q, want nothing more while paused", posts[4:])
+	}
+}
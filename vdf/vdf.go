@@ -0,0 +1,256 @@
+// Package vdf reads and writes Valve's text KeyValues format, as used by
+// Steam for libraryfolders.vdf and localconfig.vdf. Key order and platform
+// conditionals are preserved, so a document survives a round trip.
+package vdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Node is a key with either a string value or child nodes
+type Node struct {
+	Key      string
+	Value    string
+	Children []*Node
+	Cond     string // platform conditional such as "$WIN32", without brackets
+}
+
+// IsObject reports whether the node holds children rather than a value
+func (n *Node) IsObject() bool {
+	return n.Children != nil
+}
+
+// Child returns the first child with key, compared case-insensitively as
+// Steam does, or nil
+func (n *Node) Child(key string) *Node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if strings.EqualFold(c.Key, key) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Find follows a path of keys from n and returns nil if any is missing
+func (n *Node) Find(path ...string) *Node {
+	for _, key := range path {
+		n = n.Child(key)
+	}
+	return n
+}
+
+// Set sets the value of the first child with key, appending one if needed
+func (n *Node) Set(key, value string) *Node {
+	c := n.Child(key)
+	if c == nil {
+		c = &Node{Key: key}
+		n.Children = append(n.Children, c)
+	}
+	c.Value, c.Children = value, nil
+	return c
+}
+
+// Ensure returns the object child with key, appending an empty one if needed
+func (n *Node) Ensure(key string) *Node {
+	c := n.Child(key)
+	if c == nil {
+		c = &Node{Key: key}
+		n.Children = append(n.Children, c)
+	}
+	if c.Children == nil {
+		c.Value, c.Children = "", []*Node{}
+	}
+	return c
+}
+
+// Parse reads a whole document. The returned root has no key; its children
+// are the top-level entries.
+func Parse(r io.Reader) (*Node, error) {
+	p := &parser{r: bufio.NewReader(r), line: 1}
+	root := &Node{Children: []*Node{}}
+	if err := p.parseChildren(root, false); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+type parser struct {
+	r    *bufio.Reader
+	line int
+
+	// one token of lookahead, for conditionals after a value
+	peeked   bool
+	peekKind int
+	peekText string
+}
+
+const (
+	tokString = iota
+	tokOpen
+	tokClose
+	tokCond
+	tokEOF
+)
+
+func (p *parser) parseChildren(parent *Node, nested bool) error {
+	for {
+		kind, key, err := p.next()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case tokEOF:
+			if nested {
+				return p.errorf("unexpected end of file, missing }")
+			}
+			return nil
+		case tokClose:
+			if !nested {
+				return p.errorf("unexpected }")
+			}
+			return nil
+		case tokOpen:
+			return p.errorf("unexpected {")
+		case tokCond:
+			return p.errorf("unexpected condition [%s]", key)
+		}
+
+		kind, value, err := p.next()
+		if err != nil {
+			return err
+		}
+		node := &Node{Key: key}
+		switch kind {
+		case tokString:
+			node.Value = value
+		case tokOpen:
+			node.Children = []*Node{}
+			if err := p.parseChildren(node, true); err != nil {
+				return err
+			}
+		default:
+			return p.errorf("missing value for %q", key)
+		}
+
+		kind, cond, err := p.next()
+		if err != nil {
+			return err
+		}
+		if kind == tokCond {
+			node.Cond = cond
+		} else {
+			p.unread(kind, cond)
+		}
+		parent.Children = append(parent.Children, node)
+	}
+}
+
+func (p *parser) unread(kind int, text string) {
+	p.peeked, p.peekKind, p.peekText = true, kind, text
+}
+
+// next returns the next token, skipping whitespace and // comments
+func (p *parser) next() (int, string, error) {
+	if p.peeked {
+		p.peeked = false
+		return p.peekKind, p.peekText, nil
+	}
+	for {
+		c, err := p.r.ReadByte()
+		if err == io.EOF {
+			return tokEOF, "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		switch {
+		case c == '\n':
+			p.line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '{':
+			return tokOpen, "", nil
+		case c == '}':
+			return tokClose, "", nil
+		case c == '/':
+			if b, _ := p.r.Peek(1); len(b) == 1 && b[0] == '/' {
+				p.r.ReadString('\n')
+				p.line++
+				continue
+			}
+			return p.bare(c)
+		case c == '[':
+			// Platform conditionals like [$WIN32]
+			cond, err := p.r.ReadString(']')
+			if err != nil {
+				return 0, "", p.errorf("unterminated condition")
+			}
+			return tokCond, strings.TrimSuffix(cond, "]"), nil
+		case c == '"':
+			return p.quoted()
+		default:
+			return p.bare(c)
+		}
+	}
+}
+
+func (p *parser) quoted() (int, string, error) {
+	var b strings.Builder
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return 0, "", p.errorf("unterminated string")
+		}
+		switch c {
+		case '"':
+			return tokString, b.String(), nil
+		case '\\':
+			e, err := p.r.ReadByte()
+			if err != nil {
+				return 0, "", p.errorf("unterminated string")
+			}
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				// \\ and \" as well as Windows paths written unescaped
+				if e != '\\' && e != '"' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(e)
+			}
+		case '\n':
+			p.line++
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *parser) bare(first byte) (int, string, error) {
+	b := []byte{first}
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			break
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '{' || c == '}' || c == '"' {
+			p.r.UnreadByte()
+			break
+		}
+		b = append(b, c)
+	}
+	return tokString, string(b), nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("vdf line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
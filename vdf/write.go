@@ -0,0 +1,45 @@
+package vdf
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+// Write formats the children of root the way Steam writes its files: tab
+// indentation, quoted keys and values, braces on their own lines
+func Write(w io.Writer, root *Node) error {
+	bw := bufio.NewWriter(w)
+	writeChildren(bw, root, 0)
+	return bw.Flush()
+}
+
+func writeChildren(w *bufio.Writer, n *Node, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for _, c := range n.Children {
+		w.WriteString(indent)
+		w.WriteString(quote(c.Key))
+		if c.IsObject() {
+			writeCond(w, c)
+			w.WriteString("\n" + indent + "{\n")
+			writeChildren(w, c, depth+1)
+			w.WriteString(indent + "}")
+		} else {
+			w.WriteString("\t\t" + quote(c.Value))
+			writeCond(w, c)
+		}
+		w.WriteString("\n")
+	}
+}
+
+func writeCond(w *bufio.Writer, n *Node) {
+	if n.Cond != "" {
+		w.WriteString(" [" + n.Cond + "]")
+	}
+}
+
+func quote(s string) string {
+	return `"` + escaper.Replace(s) + `"`
+}
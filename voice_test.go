@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/execwrap"
+	"github.com/micha/cs-ingame-translate/fakegame"
+	"github.com/micha/cs-ingame-translate/translator"
+)
+
+// Clips go through the listener to a canned transcriber and are translated
+// in order. A clip that fails is skipped, and one that crashes the
+// transcriber gets it restarted.
+func TestVoice(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	answers := map[string]fakegame.Answer{
+		"clip1": {Text: "давай на б", Language: "ru"},
+		"clip2": {Error: "cannot decode audio"},
+		"clip3": {Crash: true},
+		"clip4": {Text: "nice shot", Language: "en"},
+	}
+	answersPath := filepath.Join(dir, "answers.json")
+	if err := fakegame.WriteAnswers(answersPath, answers); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := audio.NewCommandListener(func() *exec.Cmd { return fakegame.TranscriberCommand(answersPath) }, audio.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Stop()
+
+	ollama := fakegame.NewOllama()
+	defer ollama.Close()
+	translator.OllamaHost = ollama.URL
+	tr, err := translator.NewOllamaTranslator(ctx, "fake", "English")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	for _, name := range []string{"clip1", "clip2", "clip3", "clip4"} {
+		path := filepath.Join(dir, name+".wav")
+		if err := writeSyntheticWAV(path, 500*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		listener.SubmitFile(path)
+	}
+
+	want := []string{"давай на б", "nice shot"}
+	restarted := false
+	timeout := time.After(testTimeout)
+	for i := 0; i < len(want); {
+		select {
+		case heard, ok := <-listener.Transcriptions():
+			if !ok {
+				t.Fatalf("listener stopped after %d transcriptions", i)
+			}
+			if heard.Text != want[i] {
+				t.Fatalf("transcription %d is %q, want %q", i, heard.Text, want[i])
+			}
+			out, err := tr.Translate(ctx, heard.Text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != fakegame.Translation(heard.Text) {
+				t.Fatalf("voice translation is %q", out)
+			}
+			i++
+		case s := <-listener.Status():
+			if s.State == audio.StatusReady {
+				restarted = true
+			}
+		case <-timeout:
+			t.Fatalf("got %d of %d transcriptions within %s", i, len(want), testTimeout)
+		}
+	}
+	// The restart may be reported after the transcription that followed it
+	for !restarted {
+		select {
+		case s := <-listener.Status():
+			restarted = s.State == audio.StatusReady
+			continue
+		default:
+		}
+		break
+	}
+	if !restarted {
+		t.Fatalf("transcriber was not reported restarted after the crash")
+	}
+}
+
+// Two labelled devices are captured at once, each by an ffmpeg of its own,
+// and their segments come back tagged with the label
+func TestCaptureSources(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	answers := map[string]fakegame.Answer{
+		"audio_game_000":    {Text: "rush b", Language: "en"},
+		"audio_discord_000": {Text: "я прикрою", Language: "ru"},
+		"audio_game_001":    {Text: "one left", Language: "en"},
+	}
+	answersPath := filepath.Join(dir, "answers.json")
+	if err := fakegame.WriteAnswers(answersPath, answers); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := audio.NewCommandListener(func() *exec.Cmd { return fakegame.TranscriberCommand(answersPath) }, audio.Options{Segment: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Stop()
+
+	for _, bad := range [][]audio.Source{
+		{{Device: "game.monitor"}, {Device: "discord.monitor"}},
+		{{Label: "game", Device: "game.monitor"}, {Label: "game", Device: "discord.monitor"}},
+		{{Label: "Game Audio", Device: "game.monitor"}},
+	} {
+		if err := listener.StartSources(ctx, bad); err == nil {
+			t.Fatalf("capturing %v was not refused", bad)
+		}
+	}
+
+	capture := func(label string) string {
+		return fmt.Sprintf("ffmpeg -f pulse -i %s.monitor -f segment -segment_time 2 -c:a pcm_s16le -ar 16000 -ac 1 -reset_timestamps 1 %s",
+			label, filepath.Join(listener.OutputDir(), "audio_"+label+"_%03d.wav"))
+	}
+	fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+		"pactl list sources short": {Stdout: "1\tgame.monitor\tmodule-null-sink.c\n2\tdiscord.monitor\tmodule-null-sink.c\n"},
+		capture("game"):            {Wait: time.Minute},
+		capture("discord"):         {Wait: time.Minute},
+	}}
+	defer execwrap.Use(fake)()
+
+	sources, err := captureSources([]config.CaptureSource{{Label: "game", Device: "game.monitor"}, {Label: "discord", Device: "discord.monitor"}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := listener.StartSources(ctx, sources); err != nil {
+		t.Fatal(err)
+	}
+	defer listener.StopCapture()
+	for _, label := range []string{"game", "discord"} {
+		if !fake.Ran(strings.Fields(capture(label))...) {
+			t.Fatalf("no ffmpeg captures %s: %v", label, fake.Calls())
+		}
+	}
+
+	time.Sleep(200 * time.Millisecond) // the watchers are up
+	// A segment is queued once ffmpeg starts the next one
+	for _, name := range []string{"audio_game_000", "audio_discord_000", "audio_game_001", "audio_discord_001", "audio_game_002"} {
+		if err := writeSyntheticWAV(filepath.Join(listener.OutputDir(), name+".wav"), 500*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	want := map[string]string{"rush b": "game", "я прикрою": "discord", "one left": "game"}
+	timeout := time.After(testTimeout)
+	for len(want) > 0 {
+		select {
+		case heard, ok := <-listener.Transcriptions():
+			if !ok {
+				t.Fatalf("listener stopped")
+			}
+			label, expected := want[heard.Text]
+			if !expected {
+				t.Fatalf("unexpected transcription %q", heard.Text)
+			}
+			if heard.Capture != label {
+				t.Fatalf("%q is tagged %q, want %q", heard.Text, heard.Capture, label)
+			}
+			delete(want, heard.Text)
+		case <-timeout:
+			t.Fatalf("%d transcriptions missing after %s", len(want), testTimeout)
+		}
+	}
+}
+
+// With -voice-mode key, CS2 mode only keeps a recording for F9 instead of
+// transcribing every segment, and both does both
+func TestVoiceMode(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	for mode, want := range map[string]string{"": voiceLive, "KEY": voiceKey, "both": voiceBoth} {
+		if got, err := parseVoiceMode(mode); err != nil || got != want {
+			t.Fatalf("voice mode %q is %q (%v), want %q", mode, got, err, want)
+		}
+	}
+	if _, err := parseVoiceMode("always"); err == nil {
+		t.Fatalf("an unknown voice mode was accepted")
+	}
+
+	answersPath := filepath.Join(dir, "answers.json")
+	if err := fakegame.WriteAnswers(answersPath, map[string]fakegame.Answer{}); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := audio.NewCommandListener(func() *exec.Cmd { return fakegame.TranscriberCommand(answersPath) }, audio.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Stop()
+
+	sources := []audio.Source{{Device: "game.monitor"}}
+	for _, mode := range []string{voiceLive, voiceKey, voiceBoth} {
+		fake := &execwrap.Fake{Responses: map[string]execwrap.Response{
+			"pactl list sources short": {Stdout: "1\tgame.monitor\tmodule-null-sink.c\n"},
+		}}
+		restore := execwrap.Use(fake)
+		v, err := newVoiceCapture(ctx, listener, sources, mode, 5*time.Second, false)
+		if err != nil {
+			restore()
+			t.Fatal(err)
+		}
+		err = v.start(ctx)
+		v.close()
+		restore()
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		var live, recorded bool
+		for _, call := range fake.Calls() {
+			line := strings.Join(call, " ")
+			live = live || strings.Contains(line, "audio_%03d.wav")
+			recorded = recorded || strings.Contains(line, "-segment_format wav")
+		}
+		if live != (mode != voiceKey) || recorded != (mode != voiceLive) {
+			t.Fatalf("%s: live capture %v, F9 recording %v", mode, live, recorded)
+		}
+		if (v.Keys() != nil) != (mode != voiceLive) {
+			t.Fatalf("%s: F9 is not listened for as it should be", mode)
+		}
+	}
+}
+
+// With hold_to_capture, F9 captures from its press until its release,
+// bounded by maxEchoCapture, and a tap captures nothing
+func TestHold(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	down := time.Now()
+	if from, ok := heldSpan(down, down.Add(4*time.Second)); !ok || !from.Equal(down) {
+		t.Fatalf("a 4s hold captures from %v (%v), want its press", from.Sub(down), ok)
+	}
+	if from, ok := heldSpan(down, down.Add(5*time.Minute)); !ok || from.Sub(down) != 5*time.Minute-maxEchoCapture {
+		t.Fatalf("a 5m hold captures from %v (%v), want the last %s", from.Sub(down), ok, maxEchoCapture)
+	}
+	if _, ok := heldSpan(down, down.Add(minHold/2)); ok {
+		t.Fatalf("a tap shorter than %s was captured", minHold)
+	}
+
+	answersPath := filepath.Join(dir, "answers.json")
+	if err := fakegame.WriteAnswers(answersPath, map[string]fakegame.Answer{}); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := audio.NewCommandListener(func() *exec.Cmd { return fakegame.TranscriberCommand(answersPath) }, audio.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Stop()
+
+	sources := []audio.Source{{Device: "game.monitor"}}
+	for _, hold := range []bool{false, true} {
+		v, err := newVoiceCapture(ctx, listener, sources, voiceKey, 5*time.Second, hold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases := v.Releases()
+		if hold {
+			v.press()
+			if v.heldSince.IsZero() {
+				v.close()
+				t.Fatalf("F9 pressed with hold did not start a span")
+			}
+			v.release()
+		}
+		held := v.heldSince
+		v.close()
+		if (releases != nil) != hold {
+			t.Fatalf("hold %v: F9 releases are listened for: %v", hold, releases != nil)
+		}
+		if !held.IsZero() {
+			t.Fatalf("F9 let go did not end the span")
+		}
+	}
+}
+
+// Voice context keeps the most recent speech within its window, entries and
+// token budget, and the prompt says how far back it goes
+func TestVoiceContext(t *testing.T) {
+	ctx := t.Context()
+	o := fakegame.NewOllama()
+	defer o.Close()
+	translator.OllamaHost = o.URL
+	tr, err := translator.NewOllamaTranslator(ctx, "fake", "English")
+	if err != nil {
+		t.Fatal(err)
+	}
+	window := voiceContextWindow
+	defer func() {
+		voiceContextWindow = window
+		translator.ConfigureContext(translator.DefaultContextSettings())
+	}()
+	voiceContextWindow = 30 * time.Second
+	translator.ConfigureContext(translator.ContextSettings{MaxEntries: 3, MaxTokens: 12})
+
+	vc := &voiceContext{}
+	start := time.Now()
+	for i, said := range []string{"first call", "push b now", "two on site", "one is low", "planting"} {
+		vc.add(said, start.Add(time.Duration(i)*time.Second))
+	}
+	// Speech older than the window is dropped
+	req := translator.Request{Text: "where is he", Kind: translator.KindVoice, Context: vc.add("where is he", start.Add(40*time.Second))}
+	if req.Context.ContextText != "" {
+		t.Fatalf("speech older than the window is context: %q", req.Context.ContextText)
+	}
+	// Lines over the entries or the token budget are dropped oldest first
+	for i, said := range []string{"rotate to a", "two on site", "one is low", "planting bomb at b site right now"} {
+		vc.add(said, start.Add(time.Duration(41+i)*time.Second))
+	}
+	req.Context = vc.add("where is he", start.Add(46*time.Second))
+	if _, err := tr.TranslateWithContext(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	prompts := o.Prompts()
+	prompt := prompts[len(prompts)-1]
+	if !strings.Contains(prompt, "(last 30 seconds)") {
+		t.Fatalf("the prompt does not give the window: %q", prompt)
+	}
+	if !strings.Contains(prompt, "one is low\nplanting bomb at b site right now\n") || strings.Contains(prompt, "two on site") {
+		t.Fatalf("the context is not the newest lines within the budget: %q", prompt)
+	}
+
+	// A newest line over the budget on its own keeps its last words
+	vc.add(strings.Repeat("very ", 20)+"long call", start.Add(50*time.Second))
+	req.Context = vc.add("where is he", start.Add(51*time.Second))
+	if _, err := tr.TranslateWithContext(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	prompts = o.Prompts()
+	if prompt = prompts[len(prompts)-1]; !strings.Contains(prompt, ":\nvery very very very very very very very very very long call\n\n") {
+		t.Fatalf("an overlong line is not cut to its end: %q", prompt)
+	}
+
+	// No window, no context
+	voiceContextWindow = 0
+	vc = &voiceContext{}
+	vc.add("push b", time.Now())
+	if c := vc.add("where is he", time.Now()); c.ContextText != "" {
+		t.Fatalf("-context-window 0 kept %q", c.ContextText)
+	}
+}
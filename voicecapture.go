@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/audio"
+	"github.com/micha/cs-ingame-translate/hotkey"
+	"github.com/micha/cs-ingame-translate/i18n"
+	"github.com/micha/cs-ingame-translate/tempdir"
+)
+
+// Voice modes of CS2 mode
+const (
+	voiceLive = "live" // every segment is transcribed as it is captured
+	voiceKey  = "key"  // only what captureKey captures, sparing the GPU
+	voiceBoth = "both"
+)
+
+// parseVoiceMode checks -voice-mode; empty is voiceLive
+func parseVoiceMode(mode string) (string, error) {
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case "":
+		return voiceLive, nil
+	case voiceLive, voiceKey, voiceBoth:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown voice mode %q (use %s, %s or %s)", mode, voiceLive, voiceKey, voiceBoth)
+}
+
+// voiceCapture records voice in CS2 mode as its mode says: live segments
+// for the listener, a recorder whose last window F9 hands to the listener,
+// or both
+type voiceCapture struct {
+	mode     string
+	listener *audio.Listener
+	sources  []audio.Source
+	window   time.Duration
+	hold     bool // F9 captures while held instead of the last window
+
+	dir       string           // recorder segments; "" without F9
+	rec       *echoRecorder    // nil while not recording for F9
+	keys      *hotkey.Listener // nil without F9
+	heldSince time.Time        // zero unless F9 is held with hold
+}
+
+// newVoiceCapture prepares capturing sources in mode; start begins it
+func newVoiceCapture(ctx context.Context, listener *audio.Listener, sources []audio.Source, mode string, window time.Duration, hold bool) (*voiceCapture, error) {
+	v := &voiceCapture{mode: mode, listener: listener, sources: sources, window: window, hold: hold}
+	if mode == voiceLive {
+		return v, nil
+	}
+	dir, err := tempdir.New("", "cs-voice-rec")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	v.dir = dir
+	v.keys = hotkey.NewListener(captureKey)
+	go func() {
+		if err := v.keys.Start(ctx); err != nil {
+			slog.Error("Voice capture hotkey failed", "err", err)
+		}
+	}()
+	return v, nil
+}
+
+// start begins capturing; with several sources F9 records the first
+func (v *voiceCapture) start(ctx context.Context) error {
+	if v.mode != voiceKey {
+		if err := v.listener.StartSources(ctx, v.sources); err != nil {
+			return err
+		}
+	}
+	if v.dir != "" {
+		rec, err := newEchoRecorder(ctx, v.dir, v.sources[0].Device)
+		if err != nil {
+			v.listener.StopCapture()
+			return err
+		}
+		v.rec = rec
+	}
+	return nil
+}
+
+// stop ends capturing until start is called again
+func (v *voiceCapture) stop() {
+	v.heldSince = time.Time{}
+	v.listener.StopCapture()
+	if v.rec != nil {
+		v.rec.stop()
+		v.rec = nil
+	}
+}
+
+// close stops capturing for good
+func (v *voiceCapture) close() {
+	if v == nil {
+		return
+	}
+	v.stop()
+	if v.dir != "" {
+		os.RemoveAll(v.dir)
+	}
+}
+
+// Keys delivers F9 presses; nil without voice or in live mode
+func (v *voiceCapture) Keys() <-chan struct{} {
+	if v == nil || v.keys == nil {
+		return nil
+	}
+	return v.keys.KeyPressed()
+}
+
+// Releases delivers F9 being let go; nil unless F9 captures while held
+func (v *voiceCapture) Releases() <-chan struct{} {
+	if v == nil || v.keys == nil || !v.hold {
+		return nil
+	}
+	return v.keys.KeyReleased()
+}
+
+// press handles F9: it captures the last window, or with hold starts the
+// span that release captures
+func (v *voiceCapture) press() {
+	if !v.hold {
+		v.capture()
+		return
+	}
+	v.heldSince = time.Now()
+	fmt.Println(i18n.T("\n[F9] Recording; let go of F9 to transcribe."))
+}
+
+// release captures what was said while F9 was held
+func (v *voiceCapture) release() {
+	down := v.heldSince
+	v.heldSince = time.Time{}
+	if down.IsZero() {
+		return
+	}
+	from, ok := heldSpan(down, time.Now())
+	if !ok {
+		fmt.Println(i18n.T("\n[F9] Hold F9 while speaking and let go when done."))
+		return
+	}
+	v.captureFrom(from, i18n.T("\n[F9] Capturing %.0fs while F9 was held...\n", time.Since(from).Seconds()))
+}
+
+// capture hands the last window of voice to the listener at once
+func (v *voiceCapture) capture() {
+	v.captureFrom(time.Now().Add(-v.window), i18n.T("\n[F9] Transcribing the last %s of voice...\n", v.window))
+}
+
+// captureFrom hands the voice since from to the listener, announced by msg
+func (v *voiceCapture) captureFrom(from time.Time, msg string) {
+	if v.rec == nil {
+		fmt.Println(i18n.T("\n[F9] Voice capture is not running."))
+		return
+	}
+	fmt.Print(msg)
+	if err := v.rec.capture(from, v.listener.OutputDir(), v.listener.SubmitFile); err != nil {
+		slog.Error("Capture failed", "err", err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/micha/cs-ingame-translate/config"
+	"github.com/micha/cs-ingame-translate/events"
+	"github.com/micha/cs-ingame-translate/server"
+)
+
+func modeName(isEchoMode bool) string {
+	if isEchoMode {
+		return "echo"
+	}
+	return "cs2"
+}
+
+// newWebServer sets up the embedded server with the status, control and
+// config API and the event stream
+func newWebServer(cfg config.Config, mode string) *server.Server {
+	srv := server.New(cfg.HTTPAddr)
+	started := time.Now()
+	if deck == nil {
+		deck = newStreamDeck(mode, cfg.Lang)
+	}
+	d := deck
+
+	srv.Handle("GET", "/api/status", "Current mode, model, target language, uptime, and GPU and model load", func(w http.ResponseWriter, r *http.Request) {
+		status := map[string]any{
+			"mode":           mode,
+			"model":          cfg.Model,
+			"lang":           d.currentState().Lang,
+			"voice":          cfg.Voice,
+			"paused":         d.currentState().Paused,
+			"uptime_seconds": int(time.Since(started).Seconds()),
+		}
+		if s, ok := sampleResources(r.Context()); ok {
+			status["resources"] = s
+		}
+		server.WriteJSON(w, http.StatusOK, status)
+	})
+	srv.Handle("GET", "/streamdeck", "WebSocket for Stream Deck plugins: toggle voice, switch language, trigger capture, last translation", d.handle)
+	for _, action := range []string{deckPause, deckResume} {
+		srv.Handle("POST", "/api/"+action, "Ask the mode loop to "+action+" capture and translation", func(w http.ResponseWriter, r *http.Request) {
+			if !d.request(deckAction{Action: action}) {
+				server.WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "busy, try again"})
+				return
+			}
+			server.WriteJSON(w, http.StatusAccepted, map[string]string{"requested": action})
+		})
+	}
+	srv.Handle("POST", "/api/lang", "Switch the target language, body {\"lang\": \"German\"}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Lang string `json:"lang"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Lang == "" {
+			server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "expected {\"lang\": \"...\"}"})
+			return
+		}
+		if !d.request(deckAction{Action: deckSetLang, Lang: body.Lang}) {
+			server.WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "busy, try again"})
+			return
+		}
+		server.WriteJSON(w, http.StatusAccepted, map[string]string{"requested": deckSetLang, "lang": body.Lang})
+	})
+	srv.Handle("GET", "/api/config", "Effective configuration (file merged with flags), secrets redacted", func(w http.ResponseWriter, r *http.Request) {
+		server.WriteJSON(w, http.StatusOK, config.Redacted(cfg))
+	})
+
+	stream := server.NewHub()
+	bus.Subscribe(func(e events.Event) { stream.Broadcast(events.Fields(e)) })
+	srv.Handle("GET", "/api/events", "WebSocket of every event as JSON: chat, transcripts, translations, errors, status and rounds", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := server.Upgrade(w, r)
+		if err != nil {
+			slog.Warn("Event stream", "err", err)
+			return
+		}
+		stream.Add(conn)
+		defer stream.Remove(conn)
+		// Nothing is read from clients; this notices them leaving
+		for {
+			if _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	return srv
+}